@@ -0,0 +1,256 @@
+package queue
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// GetWaitAffinity removes and returns the oldest element of the queue whose
+// key, as returned by keyOf, is key, so that the consumers holding state for
+// a key, such as a per-tenant cache, receive the elements of that key. Once
+// the head of the queue has waited for maxSkew it is returned instead,
+// whatever its key, so that preferring the matching elements never makes an
+// element wait longer than maxSkew. If no element matches and the head has
+// waited for less than maxSkew, it waits for a matching element to be
+// inserted or for the head to reach maxSkew.
+//
+// The waits are measured from the enqueue times recorded if the
+// WithEnqueueTimes option is provided, using the clock provided using
+// WithClock. The elements without an enqueue time, such as the elements of
+// a queue created without the option, are considered to have waited for
+// maxSkew, thus they are returned in FIFO order. The elements of the urgent
+// lane are returned first, whatever their key, and once the queue is closed
+// the head is returned unless an element matches.
+//
+// The consumers waiting for the same key are served in the order in which
+// they started waiting. If the queue is closed and empty, or if it was
+// created with the WithExclusiveConsumer option, it returns the zero value
+// of T.
+//
+// The matching elements are found by scanning the queue from its head, and
+// keyOf runs while holding the queue lock, thus it must be fast and must not
+// call the queue methods.
+func GetWaitAffinity[T, K comparable](
+	bq *Blocking[T],
+	key K,
+	keyOf func(elem T) K,
+	maxSkew time.Duration,
+) (v T) {
+	if bq.consumers.enter(queueConsumer) != nil {
+		return v
+	}
+
+	defer bq.consumers.leave(queueConsumer)
+
+	bq.lock.Lock()
+	defer bq.lock.Unlock()
+
+	if bq.affinity == nil {
+		bq.affinity = newAffinityWaiters(&bq.lock)
+
+		bq.lock.wake = bq.wakeAffinity
+	}
+
+	waiters := bq.affinity
+
+	id := waiters.enqueue(key, bq.clock.Now())
+	defer waiters.remove(key, id)
+
+	match := func(elem T) bool {
+		return keyOf(elem) == key
+	}
+
+	// the waiter is woken up once the head reaches maxSkew, rescheduled
+	// every time the head changes.
+	var (
+		due        time.Time
+		stopWakeup = func() {}
+	)
+
+	defer func() {
+		stopWakeup()
+	}()
+
+	for spins := 0; ; spins++ {
+		if bq.isEmpty() && bq.closedErr() != nil {
+			return v
+		}
+
+		if waiters.isFront(key, id) && !bq.isEmpty() && !bq.reservedForWaiters() {
+			elem, pos, headDue, found := bq.affinityCandidate(match, maxSkew)
+			if found {
+				bq.moveOut(elem, pos)
+
+				return elem
+			}
+
+			if !headDue.Equal(due) {
+				stopWakeup()
+
+				due = headDue
+				stopWakeup = bq.wakeAffinityAt(due)
+			}
+		}
+
+		bq.wait(waiters.cond, spins)
+	}
+}
+
+// affinityCandidate returns the element to be returned by GetWaitAffinity,
+// along with its position in FIFO order starting with the urgent lane: the
+// head if it has waited for maxSkew, the first element satisfying match
+// otherwise. It returns false if no element satisfies match while the head
+// has waited for less than maxSkew, along with the time at which the head
+// reaches maxSkew.
+// The queue must not be empty.
+func (bq *Blocking[T]) affinityCandidate(
+	match func(T) bool,
+	maxSkew time.Duration,
+) (elem T, pos int, due time.Time, _ bool) {
+	if bq.urgentTurn() {
+		return bq.urgent[0], 0, due, true
+	}
+
+	var enqueued time.Time
+
+	if bq.meta != nil {
+		enqueued = bq.meta[bq.elementsIndex].enqueued
+	}
+
+	due = enqueued.Add(maxSkew)
+
+	if enqueued.IsZero() || !bq.clock.Now().Before(due) {
+		return bq.elements[bq.elementsIndex], len(bq.urgent), due, true
+	}
+
+	for i, elem := range bq.elements[bq.elementsIndex:] {
+		if match(elem) {
+			return elem, len(bq.urgent) + i, due, true
+		}
+	}
+
+	if bq.closedErr() != nil {
+		return bq.elements[bq.elementsIndex], len(bq.urgent), due, true
+	}
+
+	return elem, 0, due, false
+}
+
+// wakeAffinityAt wakes up the consumers waiting in GetWaitAffinity at the
+// given time, on the queue clock, unless the returned function is called
+// first.
+func (bq *Blocking[T]) wakeAffinityAt(at time.Time) (stop func()) {
+	ctx, cancel := withClockTimeout(context.Background(), bq.clock, at.Sub(bq.clock.Now()))
+
+	stopWakeup := context.AfterFunc(ctx, func() {
+		bq.lock.Lock()
+		defer bq.lock.Unlock()
+
+		bq.affinity.wakeAll()
+	})
+
+	return func() {
+		stopWakeup()
+		cancel()
+	}
+}
+
+// wakeAffinity wakes up the consumers waiting in GetWaitAffinity if the
+// queue changed since they were last woken up. It is called before the
+// queue lock is released.
+func (bq *Blocking[T]) wakeAffinity() {
+	bq.affinity.wakeOnChange(affinityState{
+		version:    bq.version,
+		getWaiters: bq.getWaiters.len(),
+	})
+}
+
+// affinityWaiters holds the consumers waiting in GetWaitAffinity, queued by
+// their key. A nil affinityWaiters holds no consumers.
+type affinityWaiters struct {
+	cond *sync.Cond
+
+	// keys holds the consumers waiting for each key, count the consumers
+	// waiting for all of them.
+	keys  map[any]*waitQueue
+	count int
+
+	// woken is the state of the queue the consumers were last woken up for.
+	woken affinityState
+}
+
+// affinityState is the state of a queue the consumers waiting in
+// GetWaitAffinity depend on: the version of its elements and the number
+// of consumers the elements are reserved for.
+type affinityState struct {
+	version    uint64
+	getWaiters int
+}
+
+// newAffinityWaiters returns the waiters of a queue protected by lock.
+func newAffinityWaiters(lock sync.Locker) *affinityWaiters {
+	return &affinityWaiters{
+		cond: sync.NewCond(lock),
+		keys: make(map[any]*waitQueue),
+	}
+}
+
+// enqueue registers a new consumer waiting for key since now, and returns
+// its id.
+func (a *affinityWaiters) enqueue(key any, now time.Time) uint64 {
+	waiters, ok := a.keys[key]
+	if !ok {
+		waiters = &waitQueue{}
+
+		a.keys[key] = waiters
+	}
+
+	a.count++
+
+	return waiters.enqueue(now)
+}
+
+// remove unregisters the consumer waiting for key with the given id, and
+// wakes up the other consumers, so that the next consumer waiting for key
+// checks the queue.
+func (a *affinityWaiters) remove(key any, id uint64) {
+	waiters := a.keys[key]
+
+	waiters.remove(id)
+
+	if waiters.len() == 0 {
+		delete(a.keys, key)
+	}
+
+	a.count--
+
+	a.cond.Broadcast()
+}
+
+// isFront returns true if the consumer with the given id has been waiting
+// for key the longest.
+func (a *affinityWaiters) isFront(key any, id uint64) bool {
+	return a.keys[key].isFront(id)
+}
+
+// wakeOnChange wakes up the consumers if the state of the queue changed
+// since they were last woken up.
+func (a *affinityWaiters) wakeOnChange(state affinityState) {
+	if a.count == 0 || state == a.woken {
+		return
+	}
+
+	a.woken = state
+
+	a.cond.Broadcast()
+}
+
+// wakeAll wakes up the consumers.
+func (a *affinityWaiters) wakeAll() {
+	if a == nil {
+		return
+	}
+
+	a.cond.Broadcast()
+}
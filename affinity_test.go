@@ -0,0 +1,307 @@
+package queue_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/adrianbrad/queue"
+)
+
+func TestGetWaitAffinity(t *testing.T) {
+	t.Parallel()
+
+	tenantOf := func(j job) string { return j.tenant }
+
+	const maxSkew = time.Minute
+
+	t.Run("Routing", func(t *testing.T) {
+		t.Parallel()
+
+		clock := newFakeClock()
+
+		jobs := queue.NewBlocking(
+			[]job{{"acme", 1}, {"globex", 1}, {"acme", 2}, {"globex", 2}},
+			queue.WithClock(clock),
+			queue.WithEnqueueTimes(),
+		)
+
+		clock.Advance(maxSkew - time.Nanosecond)
+
+		if j := queue.GetWaitAffinity(jobs, "globex", tenantOf, maxSkew); j != (job{"globex", 1}) {
+			t.Fatalf("expected job to be {globex 1}, got %v", j)
+		}
+
+		if j := queue.GetWaitAffinity(jobs, "globex", tenantOf, maxSkew); j != (job{"globex", 2}) {
+			t.Fatalf("expected job to be {globex 2}, got %v", j)
+		}
+
+		if j := queue.GetWaitAffinity(jobs, "acme", tenantOf, maxSkew); j != (job{"acme", 1}) {
+			t.Fatalf("expected job to be {acme 1}, got %v", j)
+		}
+
+		if elems := jobs.ToSlice(); len(elems) != 1 || elems[0] != (job{"acme", 2}) {
+			t.Fatalf("expected elements to be [{acme 2}], got %v", elems)
+		}
+	})
+
+	t.Run("SkewBound", func(t *testing.T) {
+		t.Parallel()
+
+		clock := newFakeClock()
+
+		jobs := queue.NewBlocking(
+			[]job{{"acme", 1}, {"globex", 1}, {"globex", 2}},
+			queue.WithClock(clock),
+			queue.WithEnqueueTimes(),
+		)
+
+		clock.Advance(maxSkew - time.Nanosecond)
+
+		if j := queue.GetWaitAffinity(jobs, "globex", tenantOf, maxSkew); j != (job{"globex", 1}) {
+			t.Fatalf("expected job to be {globex 1}, got %v", j)
+		}
+
+		// the head has waited for maxSkew, it is returned whatever its key.
+		clock.Advance(time.Nanosecond)
+
+		if j := queue.GetWaitAffinity(jobs, "globex", tenantOf, maxSkew); j != (job{"acme", 1}) {
+			t.Fatalf("expected job to be {acme 1}, got %v", j)
+		}
+	})
+
+	t.Run("WaitsUntilSkewBound", func(t *testing.T) {
+		t.Parallel()
+
+		clock := newFakeClock()
+
+		jobs := queue.NewBlocking(
+			[]job{{"acme", 1}},
+			queue.WithClock(clock),
+			queue.WithEnqueueTimes(),
+		)
+
+		result := make(chan job)
+
+		go func() {
+			result <- queue.GetWaitAffinity(jobs, "globex", tenantOf, maxSkew)
+		}()
+
+		// the consumer waits for the head to reach maxSkew.
+		clock.WaitForTimers(t, 1)
+
+		clock.Advance(maxSkew - time.Nanosecond)
+
+		select {
+		case j := <-result:
+			t.Fatalf("expected the consumer to wait, got %v", j)
+		case <-time.After(10 * time.Millisecond):
+		}
+
+		clock.Advance(time.Nanosecond)
+
+		select {
+		case j := <-result:
+			if j != (job{"acme", 1}) {
+				t.Fatalf("expected job to be {acme 1}, got %v", j)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("expected the consumer to receive the head at the skew bound")
+		}
+	})
+
+	t.Run("WaitsForMatch", func(t *testing.T) {
+		t.Parallel()
+
+		clock := newFakeClock()
+
+		jobs := queue.NewBlocking(
+			[]job{{"acme", 1}},
+			queue.WithClock(clock),
+			queue.WithEnqueueTimes(),
+		)
+
+		result := make(chan job)
+
+		go func() {
+			result <- queue.GetWaitAffinity(jobs, "globex", tenantOf, maxSkew)
+		}()
+
+		clock.WaitForTimers(t, 1)
+
+		if err := jobs.Offer(job{"globex", 1}); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if j := <-result; j != (job{"globex", 1}) {
+			t.Fatalf("expected job to be {globex 1}, got %v", j)
+		}
+	})
+
+	t.Run("FIFOPerKey", func(t *testing.T) {
+		t.Parallel()
+
+		clock := newFakeClock()
+
+		jobs := queue.NewBlocking(
+			[]job{{"acme", 1}},
+			queue.WithClock(clock),
+			queue.WithEnqueueTimes(),
+		)
+
+		first, second := make(chan job), make(chan job)
+
+		go func() {
+			first <- queue.GetWaitAffinity(jobs, "globex", tenantOf, maxSkew)
+		}()
+
+		// the first consumer waits for the head to reach maxSkew.
+		clock.WaitForTimers(t, 1)
+
+		go func() {
+			second <- queue.GetWaitAffinity(jobs, "globex", tenantOf, maxSkew)
+		}()
+
+		time.Sleep(time.Millisecond)
+
+		if err := jobs.OfferAll(job{"globex", 1}, job{"globex", 2}); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if j := <-first; j != (job{"globex", 1}) {
+			t.Fatalf("expected the first consumer to receive {globex 1}, got %v", j)
+		}
+
+		if j := <-second; j != (job{"globex", 2}) {
+			t.Fatalf("expected the second consumer to receive {globex 2}, got %v", j)
+		}
+	})
+
+	t.Run("UrgentFirst", func(t *testing.T) {
+		t.Parallel()
+
+		jobs := queue.NewBlocking([]job{{"globex", 1}}, queue.WithEnqueueTimes())
+
+		if err := jobs.OfferUrgent(job{"acme", 1}); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if j := queue.GetWaitAffinity(jobs, "globex", tenantOf, maxSkew); j != (job{"acme", 1}) {
+			t.Fatalf("expected job to be {acme 1}, got %v", j)
+		}
+	})
+
+	t.Run("WithoutEnqueueTimes", func(t *testing.T) {
+		t.Parallel()
+
+		jobs := queue.NewBlocking([]job{{"acme", 1}, {"globex", 1}})
+
+		if j := queue.GetWaitAffinity(jobs, "globex", tenantOf, maxSkew); j != (job{"acme", 1}) {
+			t.Fatalf("expected job to be {acme 1}, got %v", j)
+		}
+	})
+
+	t.Run("Closed", func(t *testing.T) {
+		t.Parallel()
+
+		jobs := queue.NewBlocking([]job{{"acme", 1}}, queue.WithEnqueueTimes())
+
+		result := make(chan job)
+
+		go func() {
+			result <- queue.GetWaitAffinity(jobs, "globex", tenantOf, maxSkew)
+			result <- queue.GetWaitAffinity(jobs, "globex", tenantOf, maxSkew)
+		}()
+
+		time.Sleep(time.Millisecond)
+
+		jobs.Close()
+
+		// the closed queue is drained, whatever the keys.
+		if j := <-result; j != (job{"acme", 1}) {
+			t.Fatalf("expected job to be {acme 1}, got %v", j)
+		}
+
+		if j := <-result; j != (job{}) {
+			t.Fatalf("expected the zero job, got %v", j)
+		}
+	})
+
+	t.Run("Conservation", func(t *testing.T) {
+		t.Parallel()
+
+		jobs := queue.NewBlocking(
+			[]job{},
+			queue.WithEnqueueTimes(),
+			queue.WithConservationChecks(),
+		)
+
+		const (
+			producers       = 4
+			jobsPerProducer = 500
+		)
+
+		tenants := []string{"acme", "globex", "initech"}
+
+		received := make(chan job, producers*jobsPerProducer)
+
+		var consumers sync.WaitGroup
+
+		for i := 0; i < 2*len(tenants); i++ {
+			consumers.Add(1)
+
+			go func(tenant string) {
+				defer consumers.Done()
+
+				for {
+					j := queue.GetWaitAffinity(jobs, tenant, tenantOf, time.Millisecond)
+					if j == (job{}) {
+						return
+					}
+
+					received <- j
+				}
+			}(tenants[i%len(tenants)])
+		}
+
+		var producersGroup sync.WaitGroup
+
+		for p := 0; p < producers; p++ {
+			producersGroup.Add(1)
+
+			go func(p int) {
+				defer producersGroup.Done()
+
+				for i := 1; i <= jobsPerProducer; i++ {
+					jobs.OfferWait(job{tenants[i%len(tenants)], p*jobsPerProducer + i})
+				}
+			}(p)
+		}
+
+		producersGroup.Wait()
+
+		jobs.Close()
+
+		consumers.Wait()
+
+		close(received)
+
+		seen := make(map[int]bool)
+
+		for j := range received {
+			if seen[j.priority] {
+				t.Fatalf("expected job %v to be received once", j)
+			}
+
+			seen[j.priority] = true
+		}
+
+		if len(seen) != producers*jobsPerProducer {
+			t.Fatalf("expected %d jobs to be received, got %d", producers*jobsPerProducer, len(seen))
+		}
+
+		if err := jobs.LedgerCheck(); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,187 @@
+package queue_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/adrianbrad/queue"
+)
+
+func TestAt(t *testing.T) {
+	t.Parallel()
+
+	type atQueue interface {
+		queue.Queue[int]
+		At(i int) (int, error)
+	}
+
+	// the retrieval order of every queue is 1, 2, 3.
+	testCases := map[string]func() atQueue{
+		"Blocking": func() atQueue {
+			blockingQueue := queue.NewBlocking([]int{2, 3})
+
+			_ = blockingQueue.OfferUrgent(1)
+
+			return blockingQueue
+		},
+		"Circular": func() atQueue {
+			circularQueue := queue.NewCircular([]int{0, 1, 2}, 3)
+
+			// the ring wraps around.
+			_, _ = circularQueue.Get()
+			_ = circularQueue.Offer(3)
+
+			return circularQueue
+		},
+		"Linked": func() atQueue {
+			linkedQueue := queue.NewLinked([]int{0, 1, 2, 3})
+
+			_, _ = linkedQueue.Get()
+
+			return linkedQueue
+		},
+	}
+
+	for name, newQueue := range testCases {
+		newQueue := newQueue
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			t.Run("InRange", func(t *testing.T) {
+				t.Parallel()
+
+				q := newQueue()
+
+				for i, expected := range []int{1, 2, 3} {
+					elem, err := q.At(i)
+					if err != nil {
+						t.Fatalf("expected no error at %d, got %v", i, err)
+					}
+
+					if elem != expected {
+						t.Fatalf("expected elem at %d to be %d, got %d", i, expected, elem)
+					}
+				}
+
+				// the elements are not removed.
+				if size := q.Size(); size != 3 {
+					t.Fatalf("expected size to be 3, got %d", size)
+				}
+			})
+
+			t.Run("OutOfRange", func(t *testing.T) {
+				t.Parallel()
+
+				q := newQueue()
+
+				for _, i := range []int{-1, q.Size()} {
+					if _, err := q.At(i); !errors.Is(err, queue.ErrIndexOutOfRange) {
+						t.Fatalf("expected error at %d to be %v, got %v", i, queue.ErrIndexOutOfRange, err)
+					}
+				}
+			})
+		})
+	}
+
+	t.Run("CircularWrapped", func(t *testing.T) {
+		t.Parallel()
+
+		const capacity = 5
+
+		// newRing returns a ring whose head and tail have both wrapped
+		// around, after partial gets.
+		newRing := func() *queue.Circular[int] {
+			circularQueue := queue.NewCircular([]int{}, capacity)
+
+			for i := 0; i < 4; i++ {
+				_ = circularQueue.Offer(i)
+			}
+
+			for i := 0; i < 3; i++ {
+				_, _ = circularQueue.Get()
+			}
+
+			for i := 4; i < 8; i++ {
+				_ = circularQueue.Offer(i)
+			}
+
+			return circularQueue
+		}
+
+		circularQueue, clone := newRing(), newRing()
+
+		var drained []int
+
+		clone.Drain()(func(elem int) bool {
+			drained = append(drained, elem)
+
+			return true
+		})
+
+		if size := circularQueue.Size(); size != len(drained) {
+			t.Fatalf("expected size to be %d, got %d", len(drained), size)
+		}
+
+		for i, expected := range drained {
+			elem, err := circularQueue.At(i)
+			if err != nil {
+				t.Fatalf("expected no error at %d, got %v", i, err)
+			}
+
+			if elem != expected {
+				t.Fatalf("expected elem at %d to be %d, got %d", i, expected, elem)
+			}
+		}
+	})
+
+	t.Run("SearchCircular", func(t *testing.T) {
+		t.Parallel()
+
+		// timestamps holds the samples ordered by time, after the ring
+		// wrapped around.
+		timestamps := queue.NewCircular([]int{}, 4)
+
+		for _, ts := range []int{5, 10, 20, 30} {
+			_ = timestamps.Offer(ts)
+		}
+
+		_, _ = timestamps.Get()
+		_ = timestamps.Offer(40)
+
+		testCases := map[string]struct {
+			since    int
+			expected int
+		}{
+			"First":    {since: 10, expected: 0},
+			"Before":   {since: 1, expected: 0},
+			"Boundary": {since: 30, expected: 2},
+			"Between":  {since: 25, expected: 2},
+			"Last":     {since: 40, expected: 3},
+			"None":     {since: 41, expected: 4},
+		}
+
+		for name, tc := range testCases {
+			tc := tc
+
+			t.Run(name, func(t *testing.T) {
+				t.Parallel()
+
+				i := queue.SearchCircular(timestamps, func(ts int) bool { return ts >= tc.since })
+				if i != tc.expected {
+					t.Fatalf("expected index to be %d, got %d", tc.expected, i)
+				}
+			})
+		}
+
+		t.Run("Empty", func(t *testing.T) {
+			t.Parallel()
+
+			empty := queue.NewCircular([]int{}, 2)
+
+			if i := queue.SearchCircular(empty, func(int) bool { return true }); i != 0 {
+				t.Fatalf("expected index to be 0, got %d", i)
+			}
+		})
+	})
+}
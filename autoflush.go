@@ -0,0 +1,275 @@
+package queue
+
+import (
+	"sync"
+	"time"
+)
+
+// autoFlusher drains a queue once it reaches a size threshold, or once the
+// linger duration elapsed since an element was inserted into the empty
+// queue, and passes the drained elements to the flush function.
+//
+// The flush function is invoked sequentially, in the order in which the
+// batches were drained, on a goroutine started when there are batches to
+// flush or a linger timer is armed, which exits once it is idle.
+type autoFlusher[T any] struct {
+	threshold int
+	linger    time.Duration
+	flush     func([]T)
+	clock     Clock
+
+	// queueLock and clear give access to the queue when the linger
+	// timer fires. clear must be called while holding queueLock.
+	queueLock sync.Locker
+	clear     func() []T
+
+	// pending holds the batches drained but not flushed yet. drained and
+	// flushed count the batches drained and flushed so far.
+	pending [][]T
+	drained uint64
+	flushed uint64
+
+	// timer is the armed linger timer, valid while epoch equals timerEpoch.
+	// epoch is incremented by every drain and every arming of the timer.
+	timer      Timer
+	timerEpoch uint64
+	epoch      uint64
+
+	// wake interrupts the goroutine waiting for the linger timer.
+	wake chan struct{}
+
+	running bool
+	closed  bool
+
+	// synchronization
+	lock sync.Mutex
+	idle *sync.Cond
+}
+
+// newAutoFlusher returns the auto flusher configured by the options,
+// or nil if the WithAutoFlush option was not provided.
+func newAutoFlusher[T any](
+	opts options,
+	queueLock sync.Locker,
+	clear func() []T,
+) *autoFlusher[T] {
+	if opts.autoFlush == nil {
+		return nil
+	}
+
+	flush, ok := opts.autoFlush.(func([]T))
+	if !ok {
+		panic("auto flush func type does not match the queue element type")
+	}
+
+	clock := opts.clock
+	if clock == nil {
+		clock = systemClock{}
+	}
+
+	f := &autoFlusher[T]{
+		threshold: opts.autoFlushThreshold,
+		linger:    opts.flushLinger,
+		flush:     flush,
+		clock:     clock,
+		queueLock: queueLock,
+		clear:     clear,
+		wake:      make(chan struct{}, 1),
+	}
+
+	f.idle = sync.NewCond(&f.lock)
+
+	return f
+}
+
+// started is called once the queue is created with size initial elements,
+// which arm the linger timer as the first element inserted into the empty
+// queue does.
+func (f *autoFlusher[T]) started(size int) {
+	if size > 0 && f.linger > 0 {
+		f.arm()
+	}
+}
+
+// inserted is called, while holding the queue lock, after an element was
+// inserted into the queue, which now holds size elements.
+func (f *autoFlusher[T]) inserted(size int) {
+	if f.threshold > 0 && size >= f.threshold {
+		f.drain()
+
+		return
+	}
+
+	// the first element inserted into the empty queue arms the timer.
+	if size == 1 && f.linger > 0 {
+		f.arm()
+	}
+}
+
+// drain clears the queue and schedules the elements to be flushed.
+// It must be called while holding the queue lock.
+func (f *autoFlusher[T]) drain() {
+	batch := f.clear()
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.epoch++
+
+	if len(batch) == 0 {
+		return
+	}
+
+	f.pending = append(f.pending, batch)
+	f.drained++
+
+	f.start()
+}
+
+// arm arms the linger timer, replacing the previously armed one.
+func (f *autoFlusher[T]) arm() {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if f.closed {
+		return
+	}
+
+	if f.timer != nil {
+		f.timer.Stop()
+	}
+
+	f.epoch++
+
+	f.timer = f.clock.NewTimer(f.linger)
+	f.timerEpoch = f.epoch
+
+	f.start()
+}
+
+// close drains the queue and stops arming the linger timer.
+// It must be called while holding the queue lock.
+func (f *autoFlusher[T]) close() {
+	f.drain()
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.closed = true
+
+	if f.timer != nil {
+		f.timer.Stop()
+		f.timer = nil
+	}
+
+	f.notify()
+}
+
+// wait waits until all the drained batches are flushed.
+func (f *autoFlusher[T]) wait() {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	for f.running {
+		f.idle.Wait()
+	}
+}
+
+// waitDrained waits until the batches drained so far are flushed. Unlike
+// wait, it does not wait for the linger timer nor for the batches drained
+// afterwards.
+func (f *autoFlusher[T]) waitDrained() {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	for drained := f.drained; f.flushed < drained; {
+		f.idle.Wait()
+	}
+}
+
+// start starts the flushing goroutine if it is not running,
+// otherwise it wakes it up. It must be called while holding f.lock.
+func (f *autoFlusher[T]) start() {
+	if f.running {
+		f.notify()
+
+		return
+	}
+
+	f.running = true
+
+	go f.run()
+}
+
+// notify wakes up the flushing goroutine, if it is waiting for the timer.
+func (f *autoFlusher[T]) notify() {
+	select {
+	case f.wake <- struct{}{}:
+	default:
+	}
+}
+
+// run flushes the pending batches and waits for the linger timer,
+// until there is nothing left to do.
+func (f *autoFlusher[T]) run() {
+	for {
+		f.lock.Lock()
+
+		if len(f.pending) > 0 {
+			batch := f.pending[0]
+			f.pending[0] = nil
+			f.pending = f.pending[1:]
+
+			f.lock.Unlock()
+
+			f.flush(batch)
+
+			f.lock.Lock()
+			f.flushed++
+			f.idle.Broadcast()
+			f.lock.Unlock()
+
+			continue
+		}
+
+		timer, epoch := f.timer, f.timerEpoch
+
+		if timer == nil {
+			f.running = false
+			f.idle.Broadcast()
+
+			f.lock.Unlock()
+
+			return
+		}
+
+		f.lock.Unlock()
+
+		select {
+		case <-timer.C():
+			f.expire(timer, epoch)
+		case <-f.wake:
+		}
+	}
+}
+
+// expire drains the queue once the linger timer fires, unless the queue was
+// drained or the timer was re-armed in the meantime.
+func (f *autoFlusher[T]) expire(timer Timer, epoch uint64) {
+	f.queueLock.Lock()
+	defer f.queueLock.Unlock()
+
+	f.lock.Lock()
+
+	if f.timer == timer {
+		f.timer = nil
+	}
+
+	stale := f.epoch != epoch
+
+	f.lock.Unlock()
+
+	if !stale {
+		f.drain()
+	}
+}
@@ -0,0 +1,320 @@
+package queue_test
+
+import (
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/adrianbrad/queue"
+)
+
+// flushRecorder records the batches flushed by a queue.
+type flushRecorder struct {
+	flushed chan []int
+}
+
+func newFlushRecorder() *flushRecorder {
+	return &flushRecorder{flushed: make(chan []int, 1024)}
+}
+
+func (r *flushRecorder) flush(batch []int) {
+	r.flushed <- batch
+}
+
+func (r *flushRecorder) next(t *testing.T) []int {
+	t.Helper()
+
+	select {
+	case batch := <-r.flushed:
+		return batch
+	case <-time.After(time.Second):
+		t.Fatalf("expected a batch to be flushed")
+
+		return nil
+	}
+}
+
+func (r *flushRecorder) expectNone(t *testing.T) {
+	t.Helper()
+
+	select {
+	case batch := <-r.flushed:
+		t.Fatalf("expected no batch to be flushed, got %v", batch)
+	case <-time.After(10 * time.Millisecond):
+	}
+}
+
+func TestAutoFlush(t *testing.T) {
+	t.Parallel()
+
+	t.Run("ThresholdUnderConcurrentOffers", func(t *testing.T) {
+		t.Parallel()
+
+		const (
+			producers   = 8
+			perProducer = 250
+			threshold   = 10
+		)
+
+		testCases := map[string]func(r *flushRecorder) (offer func(int) error, closeQueue func()){
+			"Blocking": func(r *flushRecorder) (func(int) error, func()) {
+				blockingQueue := queue.NewBlocking([]int{}, queue.WithAutoFlush(threshold, r.flush))
+
+				return blockingQueue.Offer, blockingQueue.Close
+			},
+			"Linked": func(r *flushRecorder) (func(int) error, func()) {
+				linkedQueue := queue.NewLinked([]int{}, queue.WithAutoFlush(threshold, r.flush))
+
+				return linkedQueue.Offer, func() {}
+			},
+		}
+
+		for name, newQueue := range testCases {
+			newQueue := newQueue
+
+			t.Run(name, func(t *testing.T) {
+				t.Parallel()
+
+				recorder := newFlushRecorder()
+
+				offer, closeQueue := newQueue(recorder)
+
+				var wg sync.WaitGroup
+
+				wg.Add(producers)
+
+				for p := 0; p < producers; p++ {
+					go func(p int) {
+						defer wg.Done()
+
+						for i := 0; i < perProducer; i++ {
+							_ = offer(p*perProducer + i)
+						}
+					}(p)
+				}
+
+				wg.Wait()
+
+				closeQueue()
+
+				flushed := make([]int, 0, producers*perProducer)
+
+				for len(flushed) < producers*perProducer {
+					batch := recorder.next(t)
+
+					if len(batch) != threshold {
+						t.Fatalf("expected batch size to be %d, got %d", threshold, len(batch))
+					}
+
+					flushed = append(flushed, batch...)
+				}
+
+				sort.Ints(flushed)
+
+				for i, elem := range flushed {
+					if elem != i {
+						t.Fatalf("expected every element to be flushed exactly once, got %d at %d", elem, i)
+					}
+				}
+			})
+		}
+	})
+
+	t.Run("Linger", func(t *testing.T) {
+		t.Parallel()
+
+		clock := newFakeClock()
+		recorder := newFlushRecorder()
+
+		blockingQueue := queue.NewBlocking(
+			[]int{},
+			queue.WithAutoFlush(3, recorder.flush),
+			queue.WithFlushLinger(time.Minute),
+			queue.WithClock(clock),
+		)
+
+		_ = blockingQueue.Offer(1)
+		_ = blockingQueue.Offer(2)
+
+		clock.WaitForTimers(t, 1)
+
+		clock.Advance(30 * time.Second)
+
+		recorder.expectNone(t)
+
+		clock.Advance(30 * time.Second)
+
+		if batch := recorder.next(t); !reflect.DeepEqual([]int{1, 2}, batch) {
+			t.Fatalf("expected batch to be %v, got %v", []int{1, 2}, batch)
+		}
+
+		// the threshold is reached before the linger expires.
+		for i := 3; i <= 5; i++ {
+			_ = blockingQueue.Offer(i)
+		}
+
+		clock.WaitForTimers(t, 1)
+
+		if batch := recorder.next(t); !reflect.DeepEqual([]int{3, 4, 5}, batch) {
+			t.Fatalf("expected batch to be %v, got %v", []int{3, 4, 5}, batch)
+		}
+
+		clock.Advance(time.Minute)
+
+		recorder.expectNone(t)
+
+		_ = blockingQueue.Offer(6)
+
+		clock.WaitForTimers(t, 1)
+		clock.Advance(time.Minute)
+
+		if batch := recorder.next(t); !reflect.DeepEqual([]int{6}, batch) {
+			t.Fatalf("expected batch to be %v, got %v", []int{6}, batch)
+		}
+	})
+
+	t.Run("CloseFlushesRemaining", func(t *testing.T) {
+		t.Parallel()
+
+		recorder := newFlushRecorder()
+
+		blockingQueue := queue.NewBlocking(
+			[]int{},
+			queue.WithAutoFlush(10, recorder.flush),
+			queue.WithFlushLinger(time.Hour),
+		)
+
+		_ = blockingQueue.Offer(1)
+		_ = blockingQueue.Offer(2)
+
+		blockingQueue.Close()
+
+		// Close waits for the flush.
+		select {
+		case batch := <-recorder.flushed:
+			if !reflect.DeepEqual([]int{1, 2}, batch) {
+				t.Fatalf("expected batch to be %v, got %v", []int{1, 2}, batch)
+			}
+		default:
+			t.Fatalf("expected remaining elements to be flushed by Close")
+		}
+
+		if !blockingQueue.IsEmpty() {
+			t.Fatalf("expected queue to be empty")
+		}
+	})
+
+	t.Run("FlushFlushesRemaining", func(t *testing.T) {
+		t.Parallel()
+
+		recorder := newFlushRecorder()
+
+		linkedQueue := queue.NewLinked([]int{}, queue.WithAutoFlush(2, recorder.flush))
+
+		for i := 1; i <= 3; i++ {
+			_ = linkedQueue.Offer(i)
+		}
+
+		linkedQueue.Flush()
+
+		// Flush waits for the flush of every drained batch.
+		for _, expected := range [][]int{{1, 2}, {3}} {
+			select {
+			case batch := <-recorder.flushed:
+				if !reflect.DeepEqual(expected, batch) {
+					t.Fatalf("expected batch to be %v, got %v", expected, batch)
+				}
+			default:
+				t.Fatalf("expected remaining elements to be flushed by Flush")
+			}
+		}
+
+		if !linkedQueue.IsEmpty() {
+			t.Fatalf("expected queue to be empty")
+		}
+
+		// flushing the empty queue flushes no batch.
+		linkedQueue.Flush()
+
+		recorder.expectNone(t)
+	})
+
+	t.Run("LingerInitialElements", func(t *testing.T) {
+		t.Parallel()
+
+		for name, newQueue := range map[string]func(opts ...queue.Option) queue.Queue[int]{
+			"Blocking": func(opts ...queue.Option) queue.Queue[int] {
+				return queue.NewBlocking([]int{1, 2}, opts...)
+			},
+			"Linked": func(opts ...queue.Option) queue.Queue[int] {
+				return queue.NewLinked([]int{1, 2}, opts...)
+			},
+		} {
+			newQueue := newQueue
+
+			t.Run(name, func(t *testing.T) {
+				t.Parallel()
+
+				clock := newFakeClock()
+				recorder := newFlushRecorder()
+
+				_ = newQueue(
+					queue.WithAutoFlush(10, recorder.flush),
+					queue.WithFlushLinger(time.Minute),
+					queue.WithClock(clock),
+				)
+
+				// the initial elements arm the linger timer.
+				clock.WaitForTimers(t, 1)
+				clock.Advance(time.Minute)
+
+				if batch := recorder.next(t); !reflect.DeepEqual([]int{1, 2}, batch) {
+					t.Fatalf("expected batch to be %v, got %v", []int{1, 2}, batch)
+				}
+			})
+		}
+	})
+
+	t.Run("OfferAllCrossingThreshold", func(t *testing.T) {
+		t.Parallel()
+
+		recorder := newFlushRecorder()
+
+		blockingQueue := queue.NewBlocking(
+			[]int{},
+			queue.WithCapacity(3),
+			queue.WithAutoFlush(2, recorder.flush),
+		)
+
+		if err := blockingQueue.OfferAll(1, 2, 3); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if batch := recorder.next(t); !reflect.DeepEqual([]int{1, 2}, batch) {
+			t.Fatalf("expected batch to be %v, got %v", []int{1, 2}, batch)
+		}
+
+		// the elements inserted after the flush are counted.
+		if size := blockingQueue.Size(); size != 1 {
+			t.Fatalf("expected size to be 1, got %d", size)
+		}
+
+		if remaining := blockingQueue.Remaining(); remaining != 2 {
+			t.Fatalf("expected remaining to be 2, got %d", remaining)
+		}
+	})
+
+	t.Run("MismatchedType", func(t *testing.T) {
+		t.Parallel()
+
+		defer func() {
+			if r := recover(); r == nil {
+				t.Fatalf("expected panic")
+			}
+		}()
+
+		queue.NewLinked([]int{}, queue.WithAutoFlush(1, func([]string) {}))
+	})
+}
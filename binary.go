@@ -0,0 +1,77 @@
+package queue
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"fmt"
+)
+
+// errInvalidBinarySnapshot is returned when unmarshalling a queue from a
+// binary snapshot whose state is inconsistent.
+var errInvalidBinarySnapshot = errors.New("invalid binary snapshot")
+
+// binarySnapshot is the state of a queue encoded by the MarshalBinary
+// methods using encoding/gob.
+type binarySnapshot[T any] struct {
+	// Capacity is the capacity of the queue, or unboundedCapacity.
+	Capacity int
+
+	// Elems holds the elements of the queue: the elements of the normal lane
+	// of a Blocking queue, from head to tail, the elements of a Circular or
+	// Linked queue, from head to tail, and the heap of a Priority queue, in
+	// its layout.
+	Elems []T
+
+	// Urgent holds the urgent lane of a Blocking queue, and UrgentSize the
+	// length of the urgent lane heading the elements of a Linked queue.
+	Urgent     []T
+	UrgentSize int
+
+	// Head and Tail are the ring positions of the head and of the tail of a
+	// Circular queue.
+	Head int
+	Tail int
+
+	// Less is the comparator name of a Priority queue, as provided using
+	// WithLessName, and Seqs and NextSeq the insertion sequence numbers of
+	// its elements, if the WithStableOrder option is provided.
+	Less    string
+	Seqs    []uint64
+	NextSeq uint64
+}
+
+// encode returns the gob encoding of the snapshot.
+func (s *binarySnapshot[T]) encode() ([]byte, error) {
+	var buf bytes.Buffer
+
+	if err := gob.NewEncoder(&buf).Encode(s); err != nil {
+		return nil, fmt.Errorf("encode binary snapshot: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// decodeBinarySnapshot decodes the snapshot encoded in data.
+func decodeBinarySnapshot[T any](data []byte) (*binarySnapshot[T], error) {
+	var s binarySnapshot[T]
+
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&s); err != nil {
+		return nil, fmt.Errorf("decode binary snapshot: %w", err)
+	}
+
+	return &s, nil
+}
+
+// checkCapacity returns the ErrCapacityMismatch error if the snapshot was
+// recorded with another capacity than the one of the restored queue.
+func (s *binarySnapshot[T]) checkCapacity(capacity int) error {
+	if s.Capacity != capacity {
+		return fmt.Errorf(
+			"%w: recorded with %d, restored with %d",
+			ErrCapacityMismatch, s.Capacity, capacity,
+		)
+	}
+
+	return nil
+}
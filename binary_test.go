@@ -0,0 +1,251 @@
+package queue_test
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/gob"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/adrianbrad/queue"
+)
+
+// task is a small struct element, encoded using encoding/gob.
+type task struct {
+	ID       int
+	Priority int
+}
+
+func TestBinaryRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	lessTask := func(elem, otherElem task) bool { return elem.Priority < otherElem.Priority }
+
+	type binaryQueue interface {
+		queue.Queue[task]
+		encoding.BinaryMarshaler
+		encoding.BinaryUnmarshaler
+	}
+
+	// each queue is used, snapshotted, and restored into a fresh instance.
+	testCases := map[string]struct {
+		newQueue func() binaryQueue
+		use      func(t *testing.T, q binaryQueue)
+	}{
+		"Blocking": {
+			newQueue: func() binaryQueue {
+				return queue.NewBlocking([]task{}, queue.WithCapacity(8))
+			},
+			use: func(t *testing.T, q binaryQueue) {
+				t.Helper()
+
+				offerTasks(t, q, 1, 2, 3)
+				getTasks(t, q, 1)
+
+				if err := q.(*queue.Blocking[task]).OfferUrgent(task{ID: 4}); err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+			},
+		},
+		"Circular": {
+			newQueue: func() binaryQueue {
+				return queue.NewCircular([]task{}, 4)
+			},
+			use: func(t *testing.T, q binaryQueue) {
+				t.Helper()
+
+				// the ring wraps around, the head being past its middle.
+				offerTasks(t, q, 1, 2, 3, 4)
+				getTasks(t, q, 3)
+				offerTasks(t, q, 5, 6)
+			},
+		},
+		"CircularOverwritten": {
+			newQueue: func() binaryQueue {
+				return queue.NewCircular([]task{}, 3)
+			},
+			use: func(t *testing.T, q binaryQueue) {
+				t.Helper()
+
+				offerTasks(t, q, 1, 2, 3, 4, 5)
+			},
+		},
+		"Linked": {
+			newQueue: func() binaryQueue {
+				return queue.NewLinked([]task{})
+			},
+			use: func(t *testing.T, q binaryQueue) {
+				t.Helper()
+
+				offerTasks(t, q, 1, 2, 3)
+				getTasks(t, q, 1)
+
+				if err := q.(*queue.Linked[task]).OfferUrgent(task{ID: 4}); err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+			},
+		},
+		"PriorityStable": {
+			newQueue: func() binaryQueue {
+				return queue.NewPriority([]task{}, lessTask, queue.WithCapacity(8), queue.WithStableOrder())
+			},
+			use: func(t *testing.T, q binaryQueue) {
+				t.Helper()
+
+				for i := 1; i <= 6; i++ {
+					if err := q.Offer(task{ID: i, Priority: i % 2}); err != nil {
+						t.Fatalf("expected no error, got %v", err)
+					}
+				}
+
+				getTasks(t, q, 1)
+			},
+		},
+	}
+
+	for name, tc := range testCases {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			original := tc.newQueue()
+			tc.use(t, original)
+
+			data, err := original.MarshalBinary()
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			restored := tc.newQueue()
+
+			// the restored elements replace the current ones.
+			offerTasks(t, restored, 100)
+
+			if err := restored.UnmarshalBinary(data); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if restored.Size() != original.Size() {
+				t.Fatalf("expected size to be %d, got %d", original.Size(), restored.Size())
+			}
+
+			// the subsequent insertions land where they would have landed in
+			// the original queue.
+			offerTasks(t, original, 7, 8)
+			offerTasks(t, restored, 7, 8)
+
+			want := getTasks(t, original, original.Size())
+
+			if got := getTasks(t, restored, restored.Size()); !reflect.DeepEqual(want, got) {
+				t.Fatalf("expected elements to be %v, got %v", want, got)
+			}
+		})
+	}
+
+	t.Run("Empty", func(t *testing.T) {
+		t.Parallel()
+
+		data, err := queue.NewCircular([]int{}, 2).MarshalBinary()
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		circularQueue := queue.NewCircular([]int{1, 2}, 2)
+
+		if err := circularQueue.UnmarshalBinary(data); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if !circularQueue.IsEmpty() {
+			t.Fatalf("expected queue to be empty, got %v", circularQueue.ToSlice())
+		}
+	})
+
+	t.Run("CapacityMismatch", func(t *testing.T) {
+		t.Parallel()
+
+		data, err := queue.NewBlocking([]int{1}, queue.WithCapacity(2)).MarshalBinary()
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		blockingQueue := queue.NewBlocking([]int{3}, queue.WithCapacity(3))
+
+		if err := blockingQueue.UnmarshalBinary(data); !errors.Is(err, queue.ErrCapacityMismatch) {
+			t.Fatalf("expected error to be %v, got %v", queue.ErrCapacityMismatch, err)
+		}
+
+		if elems := blockingQueue.ToSlice(); !reflect.DeepEqual([]int{3}, elems) {
+			t.Fatalf("expected the queue to be unchanged, got %v", elems)
+		}
+
+		if err := queue.NewLinked([]int{}).UnmarshalBinary(data); !errors.Is(err, queue.ErrCapacityMismatch) {
+			t.Fatalf("expected error to be %v, got %v", queue.ErrCapacityMismatch, err)
+		}
+	})
+
+	t.Run("Corrupted", func(t *testing.T) {
+		t.Parallel()
+
+		if err := queue.NewLinked([]int{}).UnmarshalBinary([]byte("corrupted")); err == nil {
+			t.Fatal("expected an error")
+		}
+	})
+
+	t.Run("Gob", func(t *testing.T) {
+		t.Parallel()
+
+		type checkpoint struct {
+			Pending *queue.Linked[task]
+		}
+
+		var buf bytes.Buffer
+
+		pending := queue.NewLinked([]task{{ID: 1}, {ID: 2}})
+
+		if err := gob.NewEncoder(&buf).Encode(checkpoint{Pending: pending}); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		restored := checkpoint{Pending: queue.NewLinked([]task{})}
+
+		if err := gob.NewDecoder(&buf).Decode(&restored); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if elems := restored.Pending.ToSlice(); !reflect.DeepEqual(pending.ToSlice(), elems) {
+			t.Fatalf("expected elements to be %v, got %v", pending.ToSlice(), elems)
+		}
+	})
+}
+
+// offerTasks offers the tasks of the given IDs to q.
+func offerTasks(t *testing.T, q queue.Queue[task], ids ...int) {
+	t.Helper()
+
+	for _, id := range ids {
+		if err := q.Offer(task{ID: id}); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	}
+}
+
+// getTasks retrieves n tasks from q.
+func getTasks(t *testing.T, q queue.Queue[task], n int) []task {
+	t.Helper()
+
+	tasks := make([]task, 0, n)
+
+	for i := 0; i < n; i++ {
+		elem, err := q.Get()
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		tasks = append(tasks, elem)
+	}
+
+	return tasks
+}
@@ -1,7 +1,17 @@
 package queue
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+	"slices"
 	"sync"
+	"sync/atomic"
+	"time"
+	"unsafe"
 )
 
 var _ Queue[any] = (*Blocking[any])(nil)
@@ -15,17 +25,160 @@ var _ Queue[any] = (*Blocking[any])(nil)
 // It supports operations for retrieving and adding elements to a FIFO queue.
 // If there are no elements available the retrieve operations wait until
 // elements are added to the queue.
+//
+// A queue created using WithCapacity preallocates its storage, so that Offer,
+// OfferWait, Get and GetWait do not allocate once the queue has warmed up.
+// The storage grows once, up to twice the capacity, if the queue runs close
+// to full. The following features allocate and break this guarantee:
+//   - the urgent lane, used by OfferUrgent and by the elements re-offered to
+//     the head of the queue;
+//   - WithContentionProfiling, for the sampled lock acquisitions;
+//   - WithAutoFlush, WithSharedCapacity and WithConservationChecks;
+//   - the waits bounded by a context, such as Poll and ConsumeBatches.
 type Blocking[T comparable] struct {
 	// elements queue
 	elements      []T
 	elementsIndex int
 
-	initialLen int
+	// urgent holds the elements retrieved before the other elements.
+	// urgentMeta holds, alongside urgent, the metadata of the urgent
+	// elements, nil unless the WithConservationChecks option is provided.
+	urgent     []T
+	urgentMeta []elementMeta
+
+	// meta holds, alongside elements, the metadata of the elements inserted
+	// using OfferHandle or OfferCtx, or of all the elements if the
+	// WithConservationChecks or WithEnqueueTimes option is provided, the
+	// zero elementMeta for
+	// the other elements. It is nil until the metadata of an element is set.
+	// nextStamp is the last stamp issued by OfferHandle.
+	meta      []elementMeta
+	nextStamp uint64
+
+	// ledger accounts for the elements, if the WithConservationChecks
+	// option is provided.
+	ledger *ledger
+
+	// enqueueTimes records the time at which the elements are inserted, if
+	// the WithEnqueueTimes option is provided.
+	enqueueTimes bool
+
+	// affinity holds the consumers waiting in GetWaitAffinity, nil until
+	// one of them waits.
+	affinity *affinityWaiters
+
+	// tracker records the mutating operations, if the WithCallerTracking
+	// option is provided.
+	tracker *callerTracker[T]
+
+	// bloom filters the elements looked up by Contains, if the
+	// WithBloomFilter option is provided.
+	bloom *countingBloom[T]
+
+	// journal records the mutating operations, if the WithJournal option is
+	// provided.
+	journal *journal[T]
+
+	// overflow holds the elements offered while the queue is full, if the
+	// WithDiskOverflow option is provided.
+	overflow *diskOverflow[T]
+
+	// name is the name provided using WithName, carried by the errors.
+	name string
+
+	// laneRatio is the number of consecutive urgent elements retrieved while
+	// the other elements are waiting, after which one of them is retrieved,
+	// 0 if the urgent lane always comes first. urgentStreak counts them.
+	laneRatio    int
+	urgentStreak int
+
+	// maxSpins is the number of times the waiting methods yield the
+	// processor and re-check the queue before parking, 0 if they park
+	// right away.
+	maxSpins int
+
+	// propagator carries the values of the OfferCtx contexts to the
+	// GetWaitCtx contexts, if WithContextPropagation is provided.
+	propagator propagator
+
+	initialElements []T
+	resetCloner     func(T) T
+
+	// codec encodes and decodes the elements in the JSON methods.
+	codec jsonCodec[T]
+
+	// recycler releases the discarded elements, if WithRecycler is provided.
+	recycler recycler[T]
+
+	// occupancy counts the elements and enforces the capacity.
+	occupancy occupancy
+
+	clock Clock
+
+	// closeErr is the error returned by the queue once it is closed,
+	// nil while the queue is open.
+	closeErr error
+
+	// watch closes the queue once the context bound using WithContext is
+	// done, nil if the option is not provided.
+	watch *contextWatch[T]
+
+	// getWaiters holds the consumers waiting for an element, offerWaiters
+	// the producers waiting for capacity.
+	getWaiters   waitQueue
+	offerWaiters waitQueue
+
+	// waiterPriority reserves the available elements for the waiting
+	// consumers, in the order in which they started waiting.
+	waiterPriority bool
 
-	capacity *int
+	// strictResets counts the ResetStrict calls, allowing the waiting
+	// producers to detect a strict reset after waking up.
+	strictResets uint64
+
+	// flusher drains the queue, if the WithAutoFlush option is provided.
+	flusher *autoFlusher[T]
+
+	// drainRate rejects the elements which would wait too long, if the
+	// WithMaxProjectedWait option is provided.
+	drainRate *drainRate
+
+	// sentinel is the value reserved using WithSentinel, nil if none is.
+	// sentinelOffered is set once OfferSentinel is called, after which
+	// the queue accepts no more elements.
+	sentinel        *T
+	sentinelOffered bool
+
+	// validator checks the inserted elements, if the WithValidator option
+	// is provided.
+	validator validator[T]
+
+	// window rejects the elements admitted within the idempotency window, if
+	// the WithIdempotencyWindow option is provided.
+	window *idempotencyWindow[T]
+
+	// reads caches the results of the reads, if the WithCachedReads option
+	// is provided.
+	reads *readCache[T]
+
+	// snapshots publishes the size and the head of the queue, if the
+	// WithReadSnapshotting option is provided.
+	snapshots *readSnapshots[T]
+
+	// consumers grants the consumer leases, if the WithExclusiveConsumer
+	// option is provided.
+	consumers *consumerGate
+
+	// destroyed is set by Destroy, after which the queue holds no elements
+	// and closeErr is the ErrQueueDestroyed error.
+	destroyed bool
+
+	// version is incremented by every mutation of the elements,
+	// invalidating the cursors issued by InspectPage.
+	version uint64
 
 	// synchronization
-	lock         sync.RWMutex
+	lock         profiledRWMutex
 	notEmptyCond *sync.Cond
 	notFullCond  *sync.Cond
 }
@@ -37,27 +190,112 @@ func NewBlocking[T comparable](
 ) *Blocking[T] {
 	options := options{
 		capacity: nil,
+		ctx:      nil,
+		clock:    systemClock{},
 	}
 
 	for _, o := range opts {
 		o.apply(&options)
 	}
 
+	recycler := recyclerOf[T](options)
+
+	validator := validatorOf[T](options)
+
+	elems = validator.filter(elems, recycler)
+
+	if options.capacity != nil && len(elems) > *options.capacity {
+		recycler.discardAll(elems[*options.capacity:])
+
+		elems = elems[:*options.capacity]
+	}
+
+	resetCloner := resetClonerOf[T](options)
+
+	// the elements are copied into a backing array preallocated to the
+	// capacity, so that the insertions into a bounded queue do not allocate.
+	elements := make([]T, len(elems), max(len(elems), capacityOf(options.capacity)))
+
+	copy(elements, elems)
+
 	queue := &Blocking[T]{
-		elements:      elems,
-		elementsIndex: 0,
-		initialLen:    len(elems),
-		capacity:      options.capacity,
-		lock:          sync.RWMutex{},
+		elements:        elements,
+		elementsIndex:   0,
+		initialElements: initialElementsOf(options, elems, resetCloner),
+		resetCloner:     resetCloner,
+		codec:           jsonCodecOf[T](options),
+		recycler:        recycler,
+		occupancy:       newOccupancy(options.capacity),
+		clock:           options.clock,
+		waiterPriority:  options.waiterPriority,
+		laneRatio:       max(options.laneRatio, 0),
+		maxSpins:        max(options.maxSpins, 0),
+		propagator:      options.propagator,
+		enqueueTimes:    options.enqueueTimes,
+		sentinel:        sentinelOf[T](options),
+		validator:       validator,
+		window:          newIdempotencyWindow[T](options),
+		reads:           newReadCache[T](options),
+		snapshots:       newReadSnapshots[T](options),
+		tracker:         newCallerTracker[T](options),
+		bloom:           newCountingBloom[T](options),
+		journal:         newJournal[T](options),
+		overflow:        newDiskOverflow[T](options),
+		name:            options.name,
+		drainRate:       newDrainRate(options),
+		consumers:       newConsumerGate(options),
+		lock: profiledRWMutex{
+			profiler: newContentionProfiler(options.contentionSampleRate),
+		},
+	}
+
+	if options.capacityGroup != nil {
+		queue.occupancy.share(options.capacityGroup, queue.wakeProducers)
+	}
+
+	queue.occupancy.limit = newHardLimit(options)
+
+	if options.conservationChecks {
+		queue.ledger = newLedger()
+	}
+
+	queue.admitElements()
+
+	queue.occupancy.forceAdmit(len(elems), 0)
+
+	queue.bloom.add(elements...)
+
+	if checkInvariants {
+		queue.lock.verify = queue.verifyOccupancy
+	}
+
+	if queue.maxSpins > 0 {
+		queue.lock.unlocks = new(atomic.Uint64)
 	}
 
 	queue.notEmptyCond = sync.NewCond(&queue.lock)
 	queue.notFullCond = sync.NewCond(&queue.lock)
 
-	if queue.capacity != nil {
-		if len(queue.elements) > *queue.capacity {
-			queue.elements = queue.elements[:*queue.capacity]
-		}
+	queue.flusher = newAutoFlusher(options, &queue.lock, queue.clear)
+
+	if queue.flusher != nil {
+		queue.flusher.started(queue.size())
+	}
+
+	if queue.overflow != nil {
+		queue.lock.settle = queue.refill
+
+		queue.refill()
+	}
+
+	if queue.snapshots != nil {
+		queue.lock.publish = queue.publishSnapshot
+
+		queue.publishSnapshot()
+	}
+
+	if options.ctx != nil {
+		queue.bindContext(options.ctx)
 	}
 
 	return queue
@@ -67,231 +305,2984 @@ func NewBlocking[T comparable](
 
 // OfferWait inserts the element to the tail the queue.
 // It waits for necessary space to become available.
+// If the queue is closed, or if the element cannot be inserted for another
+// reason, the element is discarded, use OfferWaitErr in order to observe the
+// error.
 func (bq *Blocking[T]) OfferWait(elem T) {
 	bq.lock.Lock()
 	defer bq.lock.Unlock()
 
-	for bq.isFull() {
-		bq.notFullCond.Wait()
-	}
+	_ = bq.tracker.recordResult(elem, bq.offerWait(context.Background(), elem, nil))
+}
 
-	bq.elements = append(bq.elements, elem)
+// OfferWaitErr inserts the element to the tail the queue, as OfferWait does.
+// If the queue is closed the element is discarded and the ErrQueueClosed
+// error is returned. If the element is the sentinel provided using
+// WithSentinel the ErrReservedSentinel error is returned. If the queue is
+// reset using ResetStrict while waiting, the element is discarded and the
+// ErrResetWhileWaiting error is returned. If the element is rejected by the
+// validator provided using WithValidator, an InvalidElementError is returned
+// without waiting.
+func (bq *Blocking[T]) OfferWaitErr(elem T) error {
+	bq.lock.Lock()
+	defer bq.lock.Unlock()
 
-	bq.notEmptyCond.Signal()
+	return bq.named(bq.tracker.recordResult(elem, bq.offerWait(context.Background(), elem, nil)))
+}
+
+// OfferCtx inserts the element to the tail of the queue, waiting for the
+// necessary space to become available until ctx is done, in which case the
+// element is discarded and the ctx error is returned. Otherwise, it behaves
+// as OfferWaitErr.
+//
+// If the WithContextPropagation option is provided, the value extracted from
+// ctx is stored along with the element, to be injected into the context
+// returned by GetWaitCtx.
+func (bq *Blocking[T]) OfferCtx(ctx context.Context, elem T) error {
+	value := bq.propagator.extractFrom(ctx)
+
+	bq.lock.Lock()
+	defer bq.lock.Unlock()
+
+	return bq.named(bq.tracker.recordResult(elem, bq.offerWait(ctx, elem, value)))
 }
 
 // Offer inserts the element to the tail the queue.
 // If the queue is full it returns the ErrQueueIsFull error.
+// If the queue is closed it returns the ErrQueueClosed error.
 func (bq *Blocking[T]) Offer(elem T) error {
 	bq.lock.Lock()
 	defer bq.lock.Unlock()
 
-	if bq.isFull() {
-		return ErrQueueIsFull
-	}
+	return bq.named(bq.tracker.recordResult(elem, bq.offer(elem)))
+}
 
-	bq.elements = append(bq.elements, elem)
+// TryOffer attempts to insert the element to the tail of the queue without
+// waiting for the queue lock. If the lock is held by another goroutine it
+// returns false without attempting the insertion, otherwise it returns true
+// and the error Offer would have returned.
+// False negatives are expected under contention, TryOffer is meant for
+// callers that prefer skipping an operation over waiting for the lock.
+func (bq *Blocking[T]) TryOffer(elem T) (acquired bool, _ error) {
+	if !bq.lock.TryLock() {
+		return false, nil
+	}
 
-	bq.notEmptyCond.Signal()
+	defer bq.lock.Unlock()
 
-	return nil
+	return true, bq.named(bq.tracker.recordResult(elem, bq.offer(elem)))
 }
 
-// Reset sets the queue elements index to 0. The queue will be in its initial
-// state.
-func (bq *Blocking[T]) Reset() {
+// OfferAll inserts all the elements to the tail of the queue, in order,
+// or none of them. If the elements do not all fit it returns the
+// ErrQueueIsFull error without inserting any of them. If the queue is closed
+// it returns the ErrQueueClosed error, and if one of the elements is the
+// sentinel provided using WithSentinel the ErrReservedSentinel error.
+// The elements are all validated first, if the WithValidator option is
+// provided.
+func (bq *Blocking[T]) OfferAll(elems ...T) error {
 	bq.lock.Lock()
 	defer bq.lock.Unlock()
 
-	bq.elementsIndex = 0
+	if err := bq.validator.checkAll(elems); err != nil {
+		return bq.named(err)
+	}
 
-	bq.elements = bq.elements[:bq.initialLen]
+	if err := bq.window.checkAll(elems); err != nil {
+		return bq.named(err)
+	}
 
-	bq.notEmptyCond.Broadcast()
-}
+	for _, elem := range elems {
+		if err := bq.rejected(elem); err != nil {
+			return bq.named(err)
+		}
+	}
 
-// ===================================Removal==================================
+	if err := bq.drainRate.admit(bq.size()); err != nil {
+		return bq.named(err)
+	}
 
-// GetWait removes and returns the head of the elements queue.
-// If no element is available it waits until the queue
-// has an element available.
-//
-// It does not actually remove elements from the elements slice, but
-// it's incrementing the underlying index.
-func (bq *Blocking[T]) GetWait() (v T) {
-	bq.lock.Lock()
-	defer bq.lock.Unlock()
+	// the elements are counted as they are inserted, since an auto flush
+	// triggered by an insertion drains the elements inserted before it.
+	if err := bq.occupancy.reserve(len(elems)); err != nil {
+		return bq.named(err)
+	}
 
-	defer bq.notFullCond.Signal()
+	bq.window.rememberAll(elems)
+
+	for _, elem := range elems {
+		bq.occupancy.fillReserved(1)
 
-	idx := bq.getNextIndexOrWait()
+		bq.pushBack(elem)
 
-	elem := bq.elements[idx]
+		bq.inserted()
+	}
 
-	bq.elementsIndex++
+	bq.tracker.recordBulk()
 
-	return elem
+	return nil
 }
 
-// Get removes and returns the head of the elements queue.
-// If no element is available it returns an ErrNoElementsAvailable error.
-//
-// It does not actually remove elements from the elements slice, but
-// it's incrementing the underlying index.
-func (bq *Blocking[T]) Get() (v T, _ error) {
+// replaceAll replaces the elements of the queue with the given ones, using
+// the same semantics as OfferAll into the emptied queue. If the elements
+// cannot all be inserted the queue is left unchanged. The replaced elements
+// are released by the recycler.
+func (bq *Blocking[T]) replaceAll(elems ...T) error {
 	bq.lock.Lock()
 	defer bq.lock.Unlock()
 
-	return bq.get()
+	if err := bq.validator.checkAll(elems); err != nil {
+		return err
+	}
+
+	if err := bq.window.checkAll(elems); err != nil {
+		return err
+	}
+
+	for _, elem := range elems {
+		if err := bq.rejected(elem); err != nil {
+			return err
+		}
+	}
+
+	// the slots of the replaced elements are handed over to the elements,
+	// which are counted as they are inserted, as by OfferAll.
+	if err := bq.occupancy.replace(len(elems)); err != nil {
+		return err
+	}
+
+	bq.recycler.discardAll(bq.clear())
+
+	bq.window.rememberAll(elems)
+
+	for _, elem := range elems {
+		bq.occupancy.fillReserved(1)
+
+		bq.pushBack(elem)
+
+		bq.inserted()
+	}
+
+	bq.tracker.recordBulk()
+
+	return nil
 }
 
-// Clear removes and returns all elements from the queue.
-func (bq *Blocking[T]) Clear() []T {
+// OfferSome inserts as many of the elements as possible to the tail of the
+// queue, in order, and returns the number of inserted elements. It stops at
+// the first element which cannot be inserted, returning the error Offer would
+// have returned for it, such as the ErrQueueIsFull error.
+func (bq *Blocking[T]) OfferSome(elems ...T) (n int, _ error) {
 	bq.lock.Lock()
 	defer bq.lock.Unlock()
 
-	defer bq.notFullCond.Broadcast()
+	var err error
 
-	removed := bq.elements[bq.elementsIndex:]
+	for _, elem := range elems {
+		if err = bq.offer(elem); err != nil {
+			break
+		}
 
-	bq.elementsIndex += len(removed)
+		n++
+	}
 
-	return removed
-}
+	if n > 0 {
+		bq.tracker.recordBulk()
+	}
 
-// Iterator returns an iterator over the elements in this queue.
-// Iterator returns an iterator over the elements in the queue.
-// It removes the elements from the queue.
-func (bq *Blocking[T]) Iterator() <-chan T {
-	bq.lock.RLock()
-	defer bq.lock.RUnlock()
+	return n, bq.named(err)
+}
 
-	// use a buffered channel to avoid blocking the iterator.
-	iteratorCh := make(chan T, bq.size())
+// OfferAllWait inserts all the elements to the tail of the queue, in order,
+// waiting for the necessary space to become available whenever the queue is
+// full. The lock is released while waiting, so the elements of other
+// producers may be interleaved with them.
+// It returns the number of inserted elements, which is less than the number
+// of elements only if it stopped at an element which could not be inserted,
+// returning the error OfferWaitErr would have returned for it.
+func (bq *Blocking[T]) OfferAllWait(elems ...T) (n int, _ error) {
+	bq.lock.Lock()
+	defer bq.lock.Unlock()
 
-	// close the channel when the function returns.
-	defer close(iteratorCh)
+	var err error
 
-	// iterate over the elements and send them to the channel.
-	for {
-		elem, err := bq.get()
-		if err != nil {
+	for _, elem := range elems {
+		if err = bq.offerWait(context.Background(), elem, nil); err != nil {
 			break
 		}
 
-		iteratorCh <- elem
+		n++
 	}
 
-	return iteratorCh
-}
+	if n > 0 {
+		bq.tracker.recordBulk()
+	}
 
-// =================================Examination================================
+	return n, bq.named(err)
+}
 
-// Peek retrieves but does not return the head of the queue.
-// If no element is available it returns an ErrNoElementsAvailable error.
-func (bq *Blocking[T]) Peek() (v T, _ error) {
+// CanOffer returns true if n elements would currently fit into the queue,
+// false if the queue is closed or does not have enough remaining capacity.
+// The result is advisory, since the queue may change before the elements are
+// offered, use OfferAll in order to insert the elements all or nothing.
+func (bq *Blocking[T]) CanOffer(n int) bool {
 	bq.lock.RLock()
 	defer bq.lock.RUnlock()
 
-	if bq.isEmpty() {
-		return v, ErrNoElementsAvailable
+	if bq.closedErr() != nil || bq.sentinelOffered {
+		return false
 	}
 
-	elem := bq.elements[bq.elementsIndex]
-
-	return elem, nil
+	return bq.occupancy.fits(n)
 }
 
-// PeekWait retrieves but does not return the head of the queue.
-// If no element is available it waits until the queue
-// has an element available.
-func (bq *Blocking[T]) PeekWait() T {
+// OfferHandle inserts the element to the tail of the queue, using the same
+// semantics as Offer, and returns a handle identifying the inserted instance,
+// which can be cancelled even if the queue holds equal elements. Cancelling
+// an element takes linear time in the number of queued elements.
+func (bq *Blocking[T]) OfferHandle(elem T) (ElementHandle, error) {
 	bq.lock.Lock()
 	defer bq.lock.Unlock()
 
-	for bq.isEmpty() {
-		bq.notEmptyCond.Wait()
+	if err := bq.admitProjected(elem); err != nil {
+		return ElementHandle{}, bq.named(err)
 	}
 
-	elem := bq.elements[bq.elementsIndex]
+	bq.nextStamp++
 
-	// send the not empty signal again in case any remove method waits.
-	bq.notEmptyCond.Signal()
+	bq.pushBackMeta(elem, elementMeta{stamp: bq.nextStamp, seq: bq.ledger.admit()})
 
-	return elem
-}
+	bq.inserted()
 
-// Size returns the number of elements in the queue.
-func (bq *Blocking[T]) Size() int {
-	bq.lock.RLock()
-	defer bq.lock.RUnlock()
+	bq.tracker.record(elem)
 
-	return bq.size()
+	return ElementHandle{target: blockingStamp[T]{queue: bq, stamp: bq.nextStamp}}, nil
 }
 
-// Contains returns true if the queue contains the given element.
-func (bq *Blocking[T]) Contains(elem T) bool {
-	bq.lock.RLock()
-	defer bq.lock.RUnlock()
+// OfferUrgent inserts the element to the tail of the urgent lane of the
+// queue. The elements of the urgent lane are retrieved, in FIFO order, before
+// all the other elements, unless the WithLaneRatio option is provided.
+// Both lanes count towards the queue capacity.
+// If the queue is full it returns the ErrQueueIsFull error.
+// If the queue is closed it returns the ErrQueueClosed error.
+func (bq *Blocking[T]) OfferUrgent(elem T) error {
+	bq.lock.Lock()
+	defer bq.lock.Unlock()
 
-	for i := range bq.elements[bq.elementsIndex:] {
-		if bq.elements[i] == elem {
-			return true
-		}
+	if err := bq.admit(elem); err != nil {
+		return bq.named(err)
 	}
 
-	return false
-}
+	bq.urgent = append(bq.urgent, elem)
 
-// IsEmpty returns true if the queue is empty.
-func (bq *Blocking[T]) IsEmpty() bool {
-	bq.lock.RLock()
-	defer bq.lock.RUnlock()
+	if bq.ledger != nil {
+		bq.urgentMeta = append(bq.urgentMeta, elementMeta{seq: bq.ledger.admit()})
+	}
 
-	return bq.isEmpty()
+	bq.bloom.add(elem)
+
+	bq.journal.record(JournalOfferUrgent, elem, bq.stored())
+
+	bq.inserted()
+
+	bq.tracker.record(elem)
+
+	return nil
 }
 
-// ===================================Helpers==================================
+// OfferSentinel inserts n sentinels, provided using the WithSentinel option,
+// to the tail of the queue and closes it, so that each of n consumers
+// retrieves exactly one sentinel after the elements offered before.
+// It waits for the necessary space to become available for each sentinel.
+//
+// Once OfferSentinel is called the queue accepts no more elements: the
+// offers, including the producers waiting in OfferWaitErr, return the
+// ErrQueueClosed error. If the queue is closed before all the sentinels are
+// inserted, or if the sentinels were already offered, it returns the
+// ErrQueueClosed error.
+// It panics if the queue was created without the WithSentinel option.
+func (bq *Blocking[T]) OfferSentinel(n int) error {
+	bq.lock.Lock()
+	defer bq.lock.Unlock()
 
-// getNextIndexOrWait returns the next available index of the elements slice.
-func (bq *Blocking[T]) getNextIndexOrWait() int {
-	if !bq.isEmpty() {
-		return bq.elementsIndex
+	if bq.sentinel == nil {
+		panic("queue created without the WithSentinel option")
+	}
+
+	if err := bq.closedErr(); err != nil {
+		return bq.named(err)
+	}
+
+	if bq.sentinelOffered {
+		return bq.named(ErrQueueClosed)
+	}
+
+	bq.sentinelOffered = true
+
+	// the waiting producers give up their place to the sentinels.
+	bq.notFullCond.Broadcast()
+
+	for i := 0; i < n; i++ {
+		// the sentinel is admitted as soon as it fits, so that another
+		// queue of the CapacityGroup cannot take its slot in between.
+		for bq.closedErr() == nil && !bq.occupancy.admitFitting(1) {
+			bq.park(bq.notFullCond)
+		}
+
+		if err := bq.closedErr(); err != nil {
+			return bq.named(err)
+		}
+
+		bq.pushBack(*bq.sentinel)
+
+		bq.inserted()
 	}
 
-	bq.notEmptyCond.Wait()
+	bq.tracker.recordBulk()
+
+	bq.close(ErrQueueClosed)
 
-	return bq.getNextIndexOrWait()
+	return nil
 }
 
-// isEmpty returns true if the queue is empty.
-func (bq *Blocking[T]) isEmpty() bool {
-	return bq.elementsIndex >= len(bq.elements)
+// Reset sets the queue to its initial state, by replacing the current
+// elements with the elements provided at creation, or empties it if the
+// ResetEmpty behavior is provided using WithResetBehavior.
+// A closed queue remains closed.
+//
+// The producers waiting in OfferWait insert their elements after the initial
+// elements once there is enough space, use ResetStrict in order to reject them.
+func (bq *Blocking[T]) Reset() {
+	bq.lock.Lock()
+	defer bq.lock.Unlock()
+
+	bq.reset()
+
+	bq.tracker.recordBulk()
 }
 
-// isFull returns true if the queue is full.
-func (bq *Blocking[T]) isFull() bool {
-	if bq.capacity == nil {
-		return false
-	}
+// ResetStrict sets the queue to its initial state, like Reset, and makes the
+// producers waiting in OfferWait discard their elements, OfferWaitErr
+// returning the ErrResetWhileWaiting error, instead of inserting them, so
+// that the queue holds exactly the initial elements afterwards.
+// The consumers waiting in GetWait and PeekWait are served the initial
+// elements, as they are after Reset.
+func (bq *Blocking[T]) ResetStrict() {
+	bq.lock.Lock()
+	defer bq.lock.Unlock()
+
+	bq.strictResets++
 
-	return len(bq.elements)-bq.elementsIndex >= *bq.capacity
+	bq.reset()
+
+	bq.tracker.recordBulk()
+
+	bq.notFullCond.Broadcast()
 }
 
-func (bq *Blocking[T]) size() int {
-	return len(bq.elements) - bq.elementsIndex
+// Exchange atomically removes the head of the queue and inserts the element
+// to the tail of the queue, returning the removed head. Since one element
+// leaves as one enters, it succeeds even if the queue is full, and it does
+// not wake up the goroutines waiting for an element or for capacity.
+// If the WithDiskOverflow option is provided and the overflow holds
+// elements, the element is appended to the overflow, after them.
+//
+// If no element is available it inserts the element, as Offer does, and
+// returns the ErrNoElementsAvailable error, unless the insertion fails.
+// If the queue is closed it returns the ErrQueueClosed error, leaving the
+// queue unchanged.
+func (bq *Blocking[T]) Exchange(elem T) (v T, _ error) {
+	return bq.consumeExchange(queueConsumer, elem)
 }
 
-func (bq *Blocking[T]) get() (v T, _ error) {
-	defer bq.notFullCond.Signal()
+// Rotate atomically moves the head of the queue to its tail, so that the
+// elements can be visited in a round-robin fashion without removing them.
+// An element of the urgent lane is moved to the tail of the other elements.
+// If the WithDiskOverflow option is provided and the overflow holds
+// elements, the head is appended to the overflow, after them.
+// If no element is available it returns an ErrNoElementsAvailable error,
+// or the ErrQueueClosed error if the queue is closed.
+func (bq *Blocking[T]) Rotate() error {
+	bq.lock.Lock()
+	defer bq.lock.Unlock()
 
 	if bq.isEmpty() {
-		return v, ErrNoElementsAvailable
+		return bq.named(bq.emptyErr())
 	}
 
-	elem := bq.elements[bq.elementsIndex]
+	// the elements of the overflow are older, thus the head goes after them
+	// and the oldest one takes the slot of the head once the lock is
+	// released.
+	if bq.overflow.len() > 0 {
+		if err := bq.overflow.push(bq.headElem()); err != nil {
+			return bq.named(err)
+		}
+
+		elem := bq.removeHead()
+
+		bq.tracker.record(elem)
+
+		return nil
+	}
+
+	elem := bq.replaceHead()
+
+	bq.occupancy.takeOver()
+
+	bq.pushBack(elem)
+
+	bq.tracker.record(elem)
+
+	return nil
+}
+
+// ===================================Removal==================================
+
+// GetWait removes and returns the head of the elements queue.
+// If no element is available it waits until the queue
+// has an element available.
+// If the queue is closed and empty it returns the zero value of T,
+// use Get in order to observe the ErrQueueClosed error.
+// If the WithExclusiveConsumer option is provided, it returns the zero value
+// of T without waiting, use the GetWait method of the consumer lease.
+//
+// It does not actually remove elements from the elements slice, but
+// it's incrementing the underlying index.
+func (bq *Blocking[T]) GetWait() T {
+	return bq.consumeGetWait(queueConsumer)
+}
 
-	bq.elementsIndex++
+// GetWaitCtx removes and returns the head of the queue, waiting for an
+// element to become available until base is done, in which case it returns
+// the base error. If the queue is closed and empty it returns the
+// ErrQueueClosed error.
+//
+// It also returns the context of the element: base carrying the value stored
+// by OfferCtx if the WithContextPropagation option is provided, base itself
+// otherwise.
+func (bq *Blocking[T]) GetWaitCtx(base context.Context) (T, context.Context, error) {
+	return bq.consumeGetWaitCtx(base, queueConsumer)
+}
+
+// GetCtx removes and returns the head of the queue, waiting for an element
+// to become available until ctx is done, in which case it returns the ctx
+// error without removing any element. If the queue is closed and empty it
+// returns the ErrQueueClosed error. The consumers woken up by Reset are
+// served the initial elements, as they are by GetWait.
+// No goroutine is left behind once it returns, whether ctx is done or not.
+func (bq *Blocking[T]) GetCtx(ctx context.Context) (T, error) {
+	return bq.consumeGetCtx(ctx, queueConsumer)
+}
+
+// Get removes and returns the head of the elements queue.
+// If no element is available it returns an ErrNoElementsAvailable error,
+// or the ErrQueueClosed error if the queue is closed.
+// If the WithWaiterPriority option is provided, it also returns the
+// ErrNoElementsAvailable error while there are consumers waiting in GetWait.
+//
+// It does not actually remove elements from the elements slice, but
+// it's incrementing the underlying index.
+func (bq *Blocking[T]) Get() (T, error) {
+	return bq.consumeGet(queueConsumer)
+}
+
+// TryGet attempts to remove and return the head of the queue without
+// waiting for the queue lock. If the lock is held by another goroutine it
+// returns false without attempting the removal, otherwise it returns true
+// along with the element and the error Get would have returned.
+// False negatives are expected under contention.
+func (bq *Blocking[T]) TryGet() (v T, acquired bool, _ error) {
+	return bq.consumeTryGet(queueConsumer)
+}
+
+// Poll removes and returns the head of the queue, waiting for an element to
+// become available until ctx is done, in which case it returns the ctx error.
+// Unlike the other queues, it does not retry periodically but waits for an
+// element to be inserted, thus the interval is only validated: it returns the
+// ErrInvalidInterval error if the interval is not positive.
+// If the queue is closed and empty it returns the ErrQueueClosed error.
+func (bq *Blocking[T]) Poll(ctx context.Context, interval time.Duration) (v T, _ error) {
+	return bq.consumePoll(ctx, queueConsumer, interval)
+}
+
+// GetN removes and returns up to n elements from the head of the queue, in
+// the order in which Get would return them, acquiring the queue lock once.
+// It returns no elements if the queue is empty, or if the elements are
+// reserved for the consumers waiting in GetWait, as Get does.
+// Unless the WithLaneRatio option is provided, GetN(Size()) returns the
+// elements in the order in which Clear returns them.
+// It returns no elements if the WithExclusiveConsumer option is provided.
+func (bq *Blocking[T]) GetN(n int) []T {
+	return bq.consumeGetN(queueConsumer, n)
+}
+
+// DrainWait removes and returns up to n elements from the head of the queue,
+// in the order in which Get would return them. If no element is available it
+// waits until the queue has an element available, and then removes the
+// elements available at once, without waiting for more.
+// If the queue is closed and empty, if n is not positive, or if the
+// WithExclusiveConsumer option is provided, it returns no elements.
+func (bq *Blocking[T]) DrainWait(n int) []T {
+	return bq.consumeDrainWait(queueConsumer, n)
+}
+
+// Clear removes and returns all elements from the queue.
+// The elements of the urgent lane are returned first.
+// It returns no elements if the WithExclusiveConsumer option is provided.
+func (bq *Blocking[T]) Clear() []T {
+	return bq.consumeClear(queueConsumer)
+}
+
+// ClearUnsafe removes and returns all elements from the queue, as Clear
+// does, handing the backing array of the queue over to the caller instead of
+// copying the elements out of it. The caller owns the returned slice, which
+// the queue no longer references, and the queue starts over with a fresh
+// backing array, preallocated to its capacity, so that a flush cycle swaps
+// one array instead of copying every element. The elements of the urgent
+// lane cannot be prepended in place, thus a queue holding urgent elements is
+// cleared by copying, as Clear does.
+// It returns no elements if the WithExclusiveConsumer option is provided.
+func (bq *Blocking[T]) ClearUnsafe() []T {
+	return bq.consumeClearUnsafe(queueConsumer)
+}
+
+// ClearReport removes and returns all elements from the queue, as Clear does,
+// along with the number of producers waiting for capacity at the time of the
+// clear, which are woken up by it. Both are taken atomically, so the count
+// corresponds to the removed elements.
+// It returns no elements and no producers if the WithExclusiveConsumer option
+// is provided.
+func (bq *Blocking[T]) ClearReport() ClearResult[T] {
+	return bq.consumeClearReport(queueConsumer)
+}
+
+// ClearIf evaluates pred against the current state of the queue and, if it
+// returns true, removes and returns all elements from the queue along with
+// true. Otherwise, it returns nil and false, leaving the queue unchanged.
+// The check and the removal are atomic with respect to the other operations.
+// If the WithExclusiveConsumer option is provided, it returns nil and false
+// without calling pred.
+//
+// pred runs while holding the queue lock, thus it must be fast and must not
+// call the queue methods.
+func (bq *Blocking[T]) ClearIf(pred func(snapshot ClearSnapshot[T]) bool) ([]T, bool) {
+	return bq.consumeClearIf(queueConsumer, pred)
+}
+
+// ConsumeBatches repeatedly removes batches of elements from the queue and
+// passes them to fn, adapting the batch size to the traffic.
+//
+// It waits for at least one element to become available, after which it
+// retrieves up to maxBatch elements. The batch is passed to fn once it has
+// reached maxBatch elements, once it holds at least minBatch elements and no
+// other elements are immediately available, or once maxLinger elapsed since
+// its first element was retrieved. A minBatch lower than 1 is treated as 1 and
+// a maxBatch lower than minBatch is treated as minBatch.
+//
+// It returns nil once the queue is closed and drained, the context error if
+// ctx is done and the first error returned by fn. Elements retrieved for a
+// batch which was not yet passed to fn when ctx is done are re-offered to the
+// head of the queue. The elements of a batch rejected by fn are re-offered to
+// the head of the queue only if the WithRequeueOnError option is provided.
+// Re-offered elements are inserted even if the queue is full or closed.
+func (bq *Blocking[T]) ConsumeBatches(
+	ctx context.Context,
+	minBatch, maxBatch int,
+	maxLinger time.Duration,
+	fn func([]T) error,
+	opts ...BatchOption,
+) error {
+	return bq.consumeBatches(ctx, queueConsumer, minBatch, maxBatch, maxLinger, fn, opts...)
+}
+
+// Live returns an unbuffered channel yielding the elements of the queue as
+// they become available, removing them from the queue. An element is only
+// removed from the queue once the channel is ready to receive it, so that a
+// slow consumer does not drain the queue.
+//
+// The channel is closed once the queue is closed and drained or once ctx is
+// done, whichever happens first. An element retrieved from the queue but not
+// yet received when ctx is done is re-offered to the head of the queue.
+// If the WithExclusiveConsumer option is provided, the channel is closed
+// without yielding elements.
+func (bq *Blocking[T]) Live(ctx context.Context) <-chan T {
+	return bq.consumeLive(ctx, queueConsumer)
+}
+
+// IteratorsN removes all the elements from the queue at once and distributes
+// them, in FIFO order starting with the urgent lane, among k closed and
+// buffered channels, as specified by the partition mode, so that k workers can
+// consume disjoint shares of the queue without contending on it. Some channels
+// are empty if k is greater than the number of elements.
+// It returns the ErrInvalidPartitions error if k is lower than 1, leaving the
+// queue unchanged.
+func (bq *Blocking[T]) IteratorsN(k int, mode Partition) ([]<-chan T, error) {
+	return bq.consumeIteratorsN(queueConsumer, k, mode)
+}
+
+// All returns a sequence over the queue elements, in FIFO order starting with the urgent lane, without
+// removing them. It is an iter.Seq, thus it can be ranged over:
+//
+//	for elem := range q.All() {
+//		...
+//	}
+//
+// The elements are copied once the iteration starts, so the loop may modify
+// the queue, the changes not being visited.
+func (bq *Blocking[T]) All() func(yield func(T) bool) {
+	return snapshotSeq(bq.ToSlice)
+}
+
+// Drain returns a sequence removing the queue elements, in FIFO order starting with the urgent lane, as they
+// are iterated, until the queue is empty or the loop stops. It is an
+// iter.Seq, thus it can be ranged over:
+//
+//	for elem := range q.Drain() {
+//		...
+//	}
+//
+// Each element is removed using Get, so the queue lock is not held while the
+// loop body runs, and stopping the loop early leaves the other elements in
+// the queue. The sequence is empty if the WithExclusiveConsumer option is
+// provided, since Get fails.
+func (bq *Blocking[T]) Drain() func(yield func(T) bool) {
+	return bq.consumeDrain(queueConsumer)
+}
+
+// Iterator returns an iterator over the elements in the queue.
+// It removes the elements from the queue.
+// The iterator is empty if the WithExclusiveConsumer option is provided.
+func (bq *Blocking[T]) Iterator() <-chan T {
+	return bq.consumeIterator(queueConsumer)
+}
+
+// AcquireConsumer returns the lease through which the queue is consumed, if
+// the WithExclusiveConsumer option is provided, so that a single consumer
+// removes elements from the queue at a time. It returns the
+// ErrConsumerActive error while another lease is active, until it is
+// released or expires, and the ErrUnsupportedOperation error if the option is
+// not provided.
+func (bq *Blocking[T]) AcquireConsumer() (*ConsumerLease[T], error) {
+	if bq.consumers == nil {
+		return nil, bq.named(ErrUnsupportedOperation)
+	}
+
+	id, err := bq.consumers.acquire()
+	if err != nil {
+		return nil, bq.named(err)
+	}
+
+	return &ConsumerLease[T]{queue: bq, id: id}, nil
+}
+
+// =================================Examination================================
+
+// Peek retrieves but does not return the head of the queue.
+// If no element is available it returns an ErrNoElementsAvailable error,
+// or the ErrQueueClosed error if the queue is closed.
+func (bq *Blocking[T]) Peek() (v T, _ error) {
+	if bq.snapshots != nil {
+		h := bq.snapshots.load()
+
+		return h.head, bq.named(h.err)
+	}
+
+	if bq.reads != nil {
+		h := bq.readHead()
+
+		return h.elem, bq.named(h.err)
+	}
+
+	bq.lock.RLock()
+	defer bq.lock.RUnlock()
+
+	elem, err := bq.peek()
+
+	return elem, bq.named(err)
+}
+
+// TryPeek attempts to retrieve, without removing, the head of the queue
+// without waiting for the queue lock. If the lock is held by a writer it
+// returns false without attempting the retrieval, otherwise it returns true
+// along with the element and the error Peek would have returned.
+// False negatives are expected under contention.
+func (bq *Blocking[T]) TryPeek() (v T, acquired bool, _ error) {
+	if !bq.lock.TryRLock() {
+		return v, false, nil
+	}
+
+	defer bq.lock.RUnlock()
+
+	v, err := bq.peek()
+
+	return v, true, bq.named(err)
+}
+
+// PeekWait retrieves but does not return the head of the queue.
+// If no element is available it waits until the queue
+// has an element available.
+// If the queue is closed and empty it returns the zero value of T.
+func (bq *Blocking[T]) PeekWait() (v T) {
+	bq.lock.Lock()
+	defer bq.lock.Unlock()
+
+	if !bq.waitNotEmpty() {
+		return v
+	}
+
+	elem := bq.headElem()
+
+	// send the not empty signal again in case any remove method waits.
+	bq.signalNotEmpty()
+
+	return elem
+}
+
+// Size returns the number of elements in the queue.
+func (bq *Blocking[T]) Size() int {
+	if bq.snapshots != nil {
+		return bq.snapshots.load().size
+	}
+
+	if bq.reads != nil {
+		return bq.readHead().size
+	}
+
+	bq.lock.RLock()
+	defer bq.lock.RUnlock()
+
+	return bq.size()
+}
+
+// Contains returns true if the queue contains the given element.
+// If the WithBloomFilter option is provided, the queue is only scanned for
+// the elements which the filter does not rule out. If the
+// WithReadSnapshotting option is provided, the element is first looked up in
+// the last published head of the queue.
+func (bq *Blocking[T]) Contains(elem T) bool {
+	if bq.snapshots != nil {
+		if found, known := bq.snapshots.load().contains(elem); known {
+			return found
+		}
+	}
+
+	bq.lock.RLock()
+	defer bq.lock.RUnlock()
+
+	if !bq.bloom.mayContain(elem) {
+		return false
+	}
+
+	for i := range bq.urgent {
+		if bq.urgent[i] == elem {
+			return true
+		}
+	}
+
+	for i := bq.elementsIndex; i < len(bq.elements); i++ {
+		if bq.elements[i] == elem {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ContainsRecent returns true if the element is among the n elements nearest
+// to the tail of the queue, which are the most recently offered elements
+// unless the urgent lane is reached. It scans at most n elements.
+func (bq *Blocking[T]) ContainsRecent(n int, elem T) bool {
+	bq.lock.RLock()
+	defer bq.lock.RUnlock()
+
+	for i := len(bq.elements) - 1; i >= bq.elementsIndex && n > 0; i, n = i-1, n-1 {
+		if bq.elements[i] == elem {
+			return true
+		}
+	}
+
+	for i := len(bq.urgent) - 1; i >= 0 && n > 0; i, n = i-1, n-1 {
+		if bq.urgent[i] == elem {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsEmpty returns true if the queue is empty.
+func (bq *Blocking[T]) IsEmpty() bool {
+	if bq.snapshots != nil {
+		return bq.snapshots.load().size == 0
+	}
+
+	if bq.reads != nil {
+		return bq.readHead().size == 0
+	}
+
+	bq.lock.RLock()
+	defer bq.lock.RUnlock()
+
+	return bq.isEmpty()
+}
+
+// Capacity returns the fixed capacity of the queue, or -1 if the queue was
+// created without the WithCapacity option.
+func (bq *Blocking[T]) Capacity() int {
+	return bq.occupancy.capacity
+}
+
+// Remaining returns the number of elements the queue can hold in addition to
+// its current elements, or -1 if the queue is unbounded.
+func (bq *Blocking[T]) Remaining() int {
+	bq.lock.RLock()
+	defer bq.lock.RUnlock()
+
+	return bq.occupancy.remaining()
+}
+
+// ToSlice returns a copy of the queue elements in FIFO order, starting with
+// the urgent lane, without removing them.
+func (bq *Blocking[T]) ToSlice() []T {
+	if bq.reads != nil {
+		e := bq.readElems()
+
+		return e.copyN(len(e.elems))
+	}
+
+	bq.lock.RLock()
+	defer bq.lock.RUnlock()
+
+	return bq.snapshot()
+}
+
+// Each calls fn for the queue elements in FIFO order, starting with the
+// urgent lane, without removing or copying them, until fn returns false.
+// It holds the queue lock for reading, thus fn must not modify the queue.
+func (bq *Blocking[T]) Each(fn func(elem T) bool) {
+	bq.lock.RLock()
+	defer bq.lock.RUnlock()
+
+	for _, elem := range bq.urgent {
+		if !fn(elem) {
+			return
+		}
+	}
+
+	for _, elem := range bq.elements[bq.elementsIndex:] {
+		if !fn(elem) {
+			return
+		}
+	}
+}
+
+// PeekN returns a copy of at most n elements from the head of the queue,
+// in FIFO order starting with the urgent lane, without removing them.
+func (bq *Blocking[T]) PeekN(n int) []T {
+	if bq.reads != nil {
+		return bq.readElems().copyN(n)
+	}
+
+	bq.lock.RLock()
+	defer bq.lock.RUnlock()
+
+	n = min(max(n, 0), bq.size())
+
+	elems, _ := bq.copyRange(make([]T, 0, n), 0, n, nil)
+
+	return elems
+}
+
+// At returns, without removing it, the element at index i in the order in
+// which the elements are retrieved, starting with the urgent lane. It returns
+// the ErrIndexOutOfRange error if i is negative or not lower than the size
+// of the queue.
+func (bq *Blocking[T]) At(i int) (elem T, _ error) {
+	bq.lock.RLock()
+	defer bq.lock.RUnlock()
+
+	if i < 0 || i >= bq.size() {
+		return elem, bq.named(ErrIndexOutOfRange)
+	}
+
+	if i < len(bq.urgent) {
+		return bq.urgent[i], nil
+	}
+
+	return bq.elements[bq.elementsIndex+i-len(bq.urgent)], nil
+}
+
+// InspectPage returns a copy of at most limit elements, starting at the
+// cursor, along with the cursor of the next page, in FIFO order starting
+// with the urgent lane. The zero Cursor
+// starts from the head of the queue, the cursor returned along with the last
+// page is Done. The queue lock is held only while copying the page.
+//
+// If the queue was mutated since the cursor was issued it returns the
+// ErrCursorInvalidated error, the inspection has to be restarted.
+func (bq *Blocking[T]) InspectPage(cursor Cursor, limit int) ([]T, Cursor, error) {
+	bq.lock.RLock()
+	defer bq.lock.RUnlock()
+
+	elems, next, err := inspectPage(cursor, limit, bq.version, bq.size(), bq.copyRange)
+
+	return elems, next, bq.named(err)
+}
+
+// ContentionProfile returns, for every queue method sampled by the
+// WithContentionProfiling option, the time spent waiting to acquire the
+// queue lock. It returns nil if the option was not provided.
+func (bq *Blocking[T]) ContentionProfile() map[string]ContentionStats {
+	return bq.lock.contentionProfile()
+}
+
+// BlockedState returns a snapshot of the producers blocked waiting for
+// capacity and of the consumers blocked waiting for an element, along with
+// the size, the capacity and whether the queue is closed. The wait times are
+// measured using the Clock provided by WithClock.
+func (bq *Blocking[T]) BlockedState() BlockingState {
+	bq.lock.RLock()
+	defer bq.lock.RUnlock()
+
+	now := bq.clock.Now()
+
+	return BlockingState{
+		BlockedOffers: bq.offerWaiters.len(),
+		BlockedGets:   bq.getWaiters.len(),
+		LongestWait:   max(bq.offerWaiters.longestWait(now), bq.getWaiters.longestWait(now)),
+		Size:          bq.size(),
+		Capacity:      bq.occupancy.capacity,
+		Closed:        bq.closedErr() != nil,
+	}
+}
+
+// OverflowSize returns the number of elements held in the segment files of
+// the disk overflow, provided using WithDiskOverflow, which are not counted
+// by Size.
+func (bq *Blocking[T]) OverflowSize() int {
+	bq.lock.RLock()
+	defer bq.lock.RUnlock()
+
+	return bq.overflow.len()
+}
+
+// RecentOperations returns the last mutating operations of the queue, oldest
+// first, as recorded by the WithCallerTracking option. It returns nil if the
+// option was not provided.
+func (bq *Blocking[T]) RecentOperations() []OpRecord {
+	return bq.tracker.operations()
+}
+
+// LedgerCheck reports the elements lost or delivered twice by the queue, as
+// accounted for by the WithConservationChecks option: it returns an error
+// wrapping ErrElementsNotConserved for every element which left the queue
+// more than once, and for every admitted element which neither left the queue
+// nor is queued, or nil if every element is accounted for exactly once.
+// An element leaves the queue once it is retrieved, cleared, replaced by
+// Reset or cancelled using its ElementHandle. The elements moved by Rotate and
+// the elements re-offered to the head of the queue are admitted again.
+// It panics if the queue was created without the WithConservationChecks option.
+func (bq *Blocking[T]) LedgerCheck() error {
+	bq.lock.RLock()
+	defer bq.lock.RUnlock()
+
+	if bq.ledger == nil {
+		panic("queue created without the WithConservationChecks option")
+	}
+
+	queued := make([]uint64, 0, bq.size())
+
+	for _, meta := range bq.urgentMeta {
+		queued = append(queued, meta.seq)
+	}
+
+	for _, meta := range bq.meta[bq.elementsIndex:] {
+		queued = append(queued, meta.seq)
+	}
+
+	return bq.named(bq.ledger.check(queued))
+}
+
+// MemoryFootprint returns an estimate of the memory retained by the queue,
+// measuring every element using sizeOf, or its shallow size if sizeOf is nil.
+// The slots of the backing arrays left behind the head by the removals are
+// reported as wasted until the arrays are compacted by the insertions.
+func (bq *Blocking[T]) MemoryFootprint(sizeOf func(T) uintptr) MemoryReport {
+	bq.lock.RLock()
+	defer bq.lock.RUnlock()
+
+	f := newFootprint(sizeOf, unsafe.Sizeof(*bq))
+
+	f.elements(bq.urgent...)
+	f.elements(bq.elements[bq.elementsIndex:]...)
+	f.slots(cap(bq.urgent) + cap(bq.elements))
+
+	f.overhead(uintptr(cap(bq.urgentMeta)+cap(bq.meta)) * unsafe.Sizeof(elementMeta{}))
+	f.copies(bq.initialElements)
+
+	return f.done()
+}
+
+// SyncJournal waits until the journal records of the operations completed so
+// far are written, if the WithJournal option is provided.
+func (bq *Blocking[T]) SyncJournal() {
+	bq.journal.flush()
+}
+
+// Name returns the name provided using WithName, or an empty string if the
+// queue is unnamed.
+func (bq *Blocking[T]) Name() string {
+	return bq.name
+}
+
+// named wraps err in a QueueError if the queue is named.
+func (bq *Blocking[T]) named(err error) error {
+	return nameError(bq.name, "Blocking", err)
+}
+
+// =================================Termination================================
+
+// Close closes the queue and wakes up all the goroutines waiting on it.
+// Offer calls made after Close return the ErrQueueClosed error, while the
+// elements already in the queue can still be retrieved. Once the queue is
+// closed and empty, Get and Peek return the ErrQueueClosed error and the
+// waiting methods return immediately.
+// Closing an already closed queue has no effect.
+//
+// If the WithAutoFlush option is provided, Close drains the remaining
+// elements and waits until all the drained elements are flushed.
+// If the WithJournal option is provided, Close waits until the journal
+// records are written.
+func (bq *Blocking[T]) Close() {
+	bq.lock.Lock()
+	bq.close(ErrQueueClosed)
+	bq.lock.Unlock()
+
+	bq.tracker.recordBulk()
+
+	if bq.flusher != nil {
+		bq.flusher.wait()
+	}
+
+	bq.journal.flush()
+}
+
+// Destroy tears down the queue: it removes and returns all the elements,
+// like Clear, so that they can be salvaged, and wakes up all the goroutines
+// waiting on it with the ErrQueueDestroyed error.
+// Unlike Close, it does not allow draining the queue: every subsequent
+// insertion and retrieval returns the ErrQueueDestroyed error, the waiting
+// methods return immediately, Reset has no effect and the queue remains
+// empty. The elements re-offered to the head of the queue by ConsumeBatches
+// are discarded.
+// If the WithAutoFlush option is provided, the elements are returned instead
+// of being flushed, Destroy does not wait for the pending flushes.
+// If the WithJournal option is provided, Destroy waits until the journal
+// records are written.
+// Destroying an already destroyed queue returns nil.
+func (bq *Blocking[T]) Destroy() []T {
+	// the journal is flushed once the lock is released.
+	defer bq.journal.flush()
+
+	bq.lock.Lock()
+	defer bq.lock.Unlock()
+
+	if bq.destroyed {
+		return nil
+	}
+
+	elems := bq.clear()
+
+	bq.tracker.recordBulk()
+
+	bq.destroyed = true
+
+	bq.window.forget()
+
+	bq.close(ErrQueueDestroyed)
+
+	// a closed queue reports the ErrQueueDestroyed error as well.
+	bq.closeErr = ErrQueueDestroyed
+
+	bq.notEmptyCond.Broadcast()
+	bq.notFullCond.Broadcast()
+
+	return elems
+}
+
+// =================================Marshalling================================
+
+// MarshalJSON serializes the queue elements to a JSON array in FIFO order.
+func (bq *Blocking[T]) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+
+	if err := bq.MarshalJSONTo(&buf); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// MarshalJSONTo streams the queue elements to w as a JSON array in FIFO order.
+// The elements are copied while holding the queue lock and encoded one at a
+// time after releasing it, or before releasing it if the WithRecycler option
+// is provided. The output is identical to the MarshalJSON output.
+func (bq *Blocking[T]) MarshalJSONTo(w io.Writer) error {
+	bq.lock.RLock()
+	elems := bq.snapshot()
+
+	// the pooled elements are encoded before any of them can be released.
+	if bq.recycler.enabled() {
+		defer bq.lock.RUnlock()
+
+		return bq.named(encodeJSONArray(w, elems, bq.codec.marshal))
+	}
+
+	bq.lock.RUnlock()
+
+	return bq.named(encodeJSONArray(w, elems, bq.codec.marshal))
+}
+
+// UnmarshalJSONFrom reads a JSON array from r and replaces the elements of
+// the queue with its elements, inserted using the same semantics as OfferAll
+// into the emptied queue. If an element cannot be decoded, the error reports
+// its index, and if the elements cannot all be inserted, such as when they
+// exceed the capacity, none of them is and the queue is left unchanged. The
+// decoded elements are held until they are inserted, unless the
+// WithStreamingJSON option is provided.
+func (bq *Blocking[T]) UnmarshalJSONFrom(r io.Reader) error {
+	return bq.named(bq.codec.decodeInto(r, bq.recycler, bq.Offer, bq.replaceAll))
+}
+
+// UnmarshalJSON replaces the elements of the queue with the elements of the
+// JSON array data, as UnmarshalJSONFrom does.
+func (bq *Blocking[T]) UnmarshalJSON(data []byte) error {
+	return bq.UnmarshalJSONFrom(bytes.NewReader(data))
+}
+
+// MarshalBinary encodes the queue elements, along with the capacity of the
+// queue, using encoding/gob, so that they can be restored exactly using
+// UnmarshalBinary, including the urgent lane. The elements are copied while
+// holding the queue lock and encoded after releasing it, or before releasing
+// it if the WithRecycler option is provided.
+func (bq *Blocking[T]) MarshalBinary() ([]byte, error) {
+	bq.lock.RLock()
+
+	snapshot := &binarySnapshot[T]{
+		Capacity: bq.occupancy.capacity,
+		Elems:    slices.Clone(bq.elements[bq.elementsIndex:]),
+		Urgent:   slices.Clone(bq.urgent),
+	}
+
+	// the pooled elements are encoded before any of them can be released.
+	if bq.recycler.enabled() {
+		defer bq.lock.RUnlock()
+	} else {
+		bq.lock.RUnlock()
+	}
+
+	data, err := snapshot.encode()
+
+	return data, bq.named(err)
+}
+
+// UnmarshalBinary replaces the queue elements with the elements encoded by
+// MarshalBinary, which are restored as is, without being validated. The
+// replaced elements are released if the WithRecycler option is provided, and
+// the handles issued for them are invalidated, as they are by Reset.
+// A closed queue remains closed.
+//
+// It returns the ErrCapacityMismatch error, leaving the queue unchanged, if
+// the elements were encoded from a queue with another capacity.
+func (bq *Blocking[T]) UnmarshalBinary(data []byte) error {
+	snapshot, err := decodeBinarySnapshot[T](data)
+	if err != nil {
+		return bq.named(err)
+	}
+
+	bq.lock.Lock()
+	defer bq.lock.Unlock()
+
+	if bq.destroyed {
+		return bq.named(ErrQueueDestroyed)
+	}
+
+	if err := snapshot.checkCapacity(bq.occupancy.capacity); err != nil {
+		return bq.named(err)
+	}
+
+	bq.restore(snapshot.Urgent, snapshot.Elems)
+
+	bq.tracker.recordBulk()
+
+	bq.notFullCond.Broadcast()
+
+	return nil
+}
+
+// Dump writes a snapshot of the queue elements to w, in the format of
+// MarshalJSONTo, so that it can be restored using UnmarshalJSONFrom.
+func (bq *Blocking[T]) Dump(w io.Writer) error {
+	return bq.MarshalJSONTo(w)
+}
+
+// ===================================Helpers==================================
+
+// discard releases an element dropped after being retrieved from the queue.
+func (bq *Blocking[T]) discard(elem T) {
+	bq.recycler.discard(elem)
+}
+
+// bindContext closes the queue once ctx is done.
+func (bq *Blocking[T]) bindContext(ctx context.Context) {
+	if ctx.Err() != nil {
+		bq.closeErr = closedByContextErr(ctx)
+
+		bq.publishSnapshot()
+
+		return
+	}
+
+	// the queues flushing their elements in the background or serving
+	// lock-free reads are closed as soon as ctx is done, which references
+	// them until then.
+	bq.watch = watchContext(ctx, bq, bq.flusher != nil || bq.snapshots != nil)
+}
+
+// closedByContextErr returns the error reported by a queue closed due to
+// its bound context being done.
+func closedByContextErr(ctx context.Context) error {
+	return fmt.Errorf("%w: %w", ErrQueueClosed, context.Cause(ctx))
+}
+
+// closedErr returns the error returned by the queue once it is closed,
+// including once its bound context is done while it was not closed yet, or
+// nil while the queue is open.
+func (bq *Blocking[T]) closedErr() error {
+	if bq.closeErr != nil {
+		return bq.closeErr
+	}
+
+	return bq.watch.err()
+}
+
+// close marks the queue as closed with the given error and wakes up all
+// waiting goroutines.
+func (bq *Blocking[T]) close(err error) {
+	if bq.closeErr != nil {
+		return
+	}
+
+	// a queue whose context is done was closed by it, even if the watch did
+	// not close it yet.
+	if ctxErr := bq.watch.err(); ctxErr != nil {
+		err = ctxErr
+	}
+
+	bq.closeErr = err
+
+	bq.watch.unbind()
+
+	if bq.flusher != nil {
+		bq.flusher.close()
+	}
+
+	bq.notEmptyCond.Broadcast()
+	bq.notFullCond.Broadcast()
+
+	bq.affinity.wakeAll()
+}
+
+// waitNotEmpty waits until the queue has an element available.
+// It returns false if the queue is closed and empty.
+func (bq *Blocking[T]) waitNotEmpty() bool {
+	for spins := 0; bq.isEmpty(); {
+		if bq.closedErr() != nil {
+			return false
+		}
+
+		spins = bq.wait(bq.notEmptyCond, spins)
+	}
+
+	return true
+}
+
+// waitToGet waits until an element is available for the calling consumer
+// or ctx is done. It returns the context error if ctx is done and the close
+// error if the queue is closed and empty.
+//
+// A waiter leaving while elements remain, besides the one it is about to
+// remove, wakes up the next waiter, so that the wakeups consumed by the
+// waiters which did not remove the signalled element are not lost.
+func (bq *Blocking[T]) waitToGet(ctx context.Context) (err error) {
+	if !bq.isEmpty() && !bq.reservedForWaiters() {
+		return nil
+	}
+
+	if ctx.Done() != nil {
+		stop := context.AfterFunc(ctx, func() {
+			bq.lock.Lock()
+			defer bq.lock.Unlock()
+
+			bq.notEmptyCond.Broadcast()
+		})
+		defer stop()
+	}
+
+	id := bq.getWaiters.enqueue(bq.clock.Now())
+
+	defer func() {
+		bq.getWaiters.remove(id)
+
+		remaining := bq.size()
+		if err == nil {
+			remaining--
+		}
+
+		// let the next waiter in line check for the remaining elements.
+		if remaining > 0 {
+			bq.signalNotEmpty()
+		}
+	}()
+
+	for spins := 0; bq.isEmpty() || (bq.waiterPriority && !bq.getWaiters.isFront(id)); {
+		if err := bq.closedErr(); err != nil && bq.isEmpty() {
+			return err
+		}
+
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		spins = bq.wait(bq.notEmptyCond, spins)
+	}
+
+	return nil
+}
+
+// wait waits for cond to be signalled, unless the caller yielded the
+// processor fewer than maxSpins times, given by spins, so that the short
+// waits do not park the goroutine. In that case it releases the lock and
+// yields the processor until the queue is unlocked by another goroutine
+// which locked it for writing, or until maxSpins is reached, before locking
+// it again, so that the spinning goroutine only contends for the lock once
+// the queue may have changed. It returns spins incremented by the number of
+// times it yielded the processor.
+func (bq *Blocking[T]) wait(cond *sync.Cond, spins int) int {
+	if spins >= bq.maxSpins {
+		bq.park(cond)
+
+		return spins
+	}
+
+	// the unlock below is counted as well.
+	unlocks := bq.lock.unlocks.Load() + 1
+
+	bq.lock.Unlock()
+
+	for spins < bq.maxSpins {
+		runtime.Gosched()
+
+		spins++
+
+		if bq.lock.unlocks.Load() != unlocks {
+			break
+		}
+	}
+
+	bq.lock.Lock()
+
+	return spins
+}
+
+// park waits for cond to be signalled. The queue bound to a context which is
+// done is closed instead, if the watch did not close it since no goroutine
+// was waiting on it, so that the caller observes it is closed.
+func (bq *Blocking[T]) park(cond *sync.Cond) {
+	if bq.closeErr != nil || bq.watch == nil {
+		cond.Wait()
+
+		return
+	}
+
+	if !bq.watch.enter(bq) {
+		bq.close(ErrQueueClosed)
+
+		return
+	}
+
+	cond.Wait()
+
+	bq.watch.leave()
+}
+
+// consumeGet removes and returns the head of the queue, as Get does, on
+// behalf of the consumer with the given id.
+func (bq *Blocking[T]) consumeGet(id uint64) (v T, _ error) {
+	if err := bq.consumers.enter(id); err != nil {
+		return v, bq.named(err)
+	}
+
+	defer bq.consumers.leave(id)
+
+	bq.lock.Lock()
+	defer bq.lock.Unlock()
+
+	v, err := bq.getUnreserved()
+
+	return v, bq.named(bq.tracker.recordResult(v, err))
+}
+
+// consumeGetWait removes and returns the head of the queue, as GetWait does,
+// on behalf of the consumer with the given id.
+func (bq *Blocking[T]) consumeGetWait(id uint64) (v T) {
+	if bq.consumers.enter(id) != nil {
+		return v
+	}
+
+	defer bq.consumers.leave(id)
+
+	bq.lock.Lock()
+	defer bq.lock.Unlock()
+
+	defer bq.notFullCond.Signal()
+
+	if bq.waitToGet(context.Background()) != nil {
+		return v
+	}
+
+	v = bq.removeHead()
+
+	bq.tracker.record(v)
+
+	return v
+}
+
+// consumeGetCtx removes and returns the head of the queue, as GetCtx does,
+// on behalf of the consumer with the given id.
+func (bq *Blocking[T]) consumeGetCtx(ctx context.Context, id uint64) (v T, _ error) {
+	if err := bq.consumers.enter(id); err != nil {
+		return v, bq.named(err)
+	}
+
+	defer bq.consumers.leave(id)
+
+	elem, err := bq.waitGet(ctx)
+
+	return elem, bq.named(bq.tracker.recordResult(elem, err))
+}
+
+// consumePoll removes and returns the head of the queue, as Poll does, on
+// behalf of the consumer with the given id.
+func (bq *Blocking[T]) consumePoll(ctx context.Context, id uint64, interval time.Duration) (v T, _ error) {
+	if interval <= 0 {
+		return v, bq.named(ErrInvalidInterval)
+	}
+
+	v, err := bq.consumeWaitGet(ctx, id)
+
+	return v, bq.named(bq.tracker.recordResult(v, err))
+}
+
+// consumeGetN removes and returns up to n elements, as GetN does, on behalf
+// of the consumer with the given id.
+func (bq *Blocking[T]) consumeGetN(id uint64, n int) []T {
+	if bq.consumers.enter(id) != nil {
+		return []T{}
+	}
+
+	defer bq.consumers.leave(id)
+
+	bq.lock.Lock()
+	defer bq.lock.Unlock()
+
+	if bq.reservedForWaiters() {
+		return []T{}
+	}
+
+	return bq.getN(n)
+}
+
+// consumeClear removes and returns all elements, as Clear does, on behalf
+// of the consumer with the given id.
+func (bq *Blocking[T]) consumeClear(id uint64) []T {
+	if bq.consumers.enter(id) != nil {
+		return []T{}
+	}
+
+	defer bq.consumers.leave(id)
+
+	bq.lock.Lock()
+	defer bq.lock.Unlock()
+
+	bq.tracker.recordBulk()
+
+	return bq.clear()
+}
+
+// consumeIterator removes the elements into an iterator, as Iterator does,
+// on behalf of the consumer with the given id.
+func (bq *Blocking[T]) consumeIterator(id uint64) <-chan T {
+	if bq.consumers.enter(id) != nil {
+		return closedChan[T]()
+	}
+
+	defer bq.consumers.leave(id)
+
+	bq.lock.Lock()
+	defer bq.lock.Unlock()
+
+	bq.tracker.recordBulk()
+
+	// use a buffered channel to avoid blocking the iterator.
+	return bufferedChan(bq.size(), drainSeq(bq.get))
+}
+
+// consumeExchange removes the head of the queue and inserts the element, as
+// Exchange does, on behalf of the consumer with the given id.
+func (bq *Blocking[T]) consumeExchange(id uint64, elem T) (v T, _ error) {
+	if err := bq.consumers.enter(id); err != nil {
+		return v, bq.named(err)
+	}
+
+	defer bq.consumers.leave(id)
+
+	bq.lock.Lock()
+	defer bq.lock.Unlock()
+
+	if err := bq.rejected(elem); err != nil {
+		return v, bq.named(err)
+	}
+
+	if bq.isEmpty() || bq.reservedForWaiters() {
+		if err := bq.offer(elem); err != nil {
+			return v, bq.named(err)
+		}
+
+		bq.tracker.record(elem)
+
+		return v, bq.named(ErrNoElementsAvailable)
+	}
+
+	// the elements of the overflow are older, thus the element goes after
+	// them and the oldest one takes the slot of the head once the lock is
+	// released.
+	if bq.overflow.len() > 0 {
+		if _, err := bq.spill(elem); err != nil {
+			return v, bq.named(err)
+		}
+
+		v = bq.removeHead()
+
+		bq.tracker.record(elem)
+
+		return v, nil
+	}
+
+	// the element takes over the slot of the head, which is not released to
+	// the CapacityGroup of the queue meanwhile.
+	v = bq.replaceHead()
+
+	bq.occupancy.takeOver()
+
+	bq.window.remember(elem)
+
+	bq.pushBack(elem)
+
+	bq.tracker.record(elem)
+
+	return v, nil
+}
+
+// consumeGetWaitCtx removes and returns the head of the queue along with its
+// context, as GetWaitCtx does, on behalf of the consumer with the given id.
+func (bq *Blocking[T]) consumeGetWaitCtx(base context.Context, id uint64) (T, context.Context, error) {
+	if err := bq.consumers.enter(id); err != nil {
+		var zero T
+
+		return zero, base, bq.named(err)
+	}
+
+	defer bq.consumers.leave(id)
+
+	elem, value, err := bq.getWaitValue(base)
+	if err != nil {
+		return elem, base, bq.named(err)
+	}
+
+	bq.tracker.record(elem)
+
+	return elem, bq.propagator.injectInto(base, value), nil
+}
+
+// consumeTryGet attempts to remove and return the head of the queue, as
+// TryGet does, on behalf of the consumer with the given id.
+func (bq *Blocking[T]) consumeTryGet(id uint64) (v T, acquired bool, _ error) {
+	if err := bq.consumers.enter(id); err != nil {
+		return v, true, bq.named(err)
+	}
+
+	defer bq.consumers.leave(id)
+
+	if !bq.lock.TryLock() {
+		return v, false, nil
+	}
+
+	defer bq.lock.Unlock()
+
+	v, err := bq.getUnreserved()
+
+	return v, true, bq.named(bq.tracker.recordResult(v, err))
+}
+
+// consumeDrainWait removes and returns up to n elements, waiting for one, as
+// DrainWait does, on behalf of the consumer with the given id.
+func (bq *Blocking[T]) consumeDrainWait(id uint64, n int) []T {
+	if bq.consumers.enter(id) != nil {
+		return []T{}
+	}
+
+	defer bq.consumers.leave(id)
+
+	bq.lock.Lock()
+	defer bq.lock.Unlock()
+
+	if n <= 0 || bq.waitToGet(context.Background()) != nil {
+		return []T{}
+	}
+
+	return bq.getN(n)
+}
+
+// consumeClearUnsafe removes and returns all elements, as ClearUnsafe does,
+// on behalf of the consumer with the given id.
+func (bq *Blocking[T]) consumeClearUnsafe(id uint64) []T {
+	if bq.consumers.enter(id) != nil {
+		return []T{}
+	}
+
+	defer bq.consumers.leave(id)
+
+	bq.lock.Lock()
+	defer bq.lock.Unlock()
+
+	bq.tracker.recordBulk()
+
+	if len(bq.urgent) > 0 {
+		return bq.clear()
+	}
+
+	return bq.detach()
+}
+
+// consumeClearReport removes and returns all elements along with the number
+// of released producers, as ClearReport does, on behalf of the consumer with
+// the given id.
+func (bq *Blocking[T]) consumeClearReport(id uint64) ClearResult[T] {
+	if bq.consumers.enter(id) != nil {
+		return ClearResult[T]{Elements: []T{}}
+	}
+
+	defer bq.consumers.leave(id)
+
+	bq.lock.Lock()
+	defer bq.lock.Unlock()
+
+	bq.tracker.recordBulk()
+
+	return ClearResult[T]{
+		ReleasedProducers: bq.offerWaiters.len(),
+		Elements:          bq.clear(),
+	}
+}
+
+// consumeClearIf removes and returns all elements if pred returns true, as
+// ClearIf does, on behalf of the consumer with the given id.
+func (bq *Blocking[T]) consumeClearIf(id uint64, pred func(snapshot ClearSnapshot[T]) bool) ([]T, bool) {
+	if bq.consumers.enter(id) != nil {
+		return nil, false
+	}
+
+	defer bq.consumers.leave(id)
+
+	bq.lock.Lock()
+	defer bq.lock.Unlock()
+
+	snapshot := ClearSnapshot[T]{
+		Size:     bq.size(),
+		Capacity: bq.occupancy.capacity,
+	}
+
+	if !bq.isEmpty() {
+		snapshot.Head = bq.headElem()
+	}
+
+	if !pred(snapshot) {
+		return nil, false
+	}
+
+	bq.tracker.recordBulk()
+
+	return bq.clear(), true
+}
+
+// consumeLive returns a channel yielding the elements as they become
+// available, as Live does, on behalf of the consumer with the given id.
+func (bq *Blocking[T]) consumeLive(ctx context.Context, id uint64) <-chan T {
+	liveCh := make(chan T)
+
+	go func() {
+		defer close(liveCh)
+
+		for {
+			elem, err := bq.consumeWaitGet(ctx, id)
+			if err != nil {
+				return
+			}
+
+			bq.tracker.record(elem)
+
+			select {
+			case liveCh <- elem:
+			case <-ctx.Done():
+				bq.requeueFront(elem)
+
+				return
+			}
+		}
+	}()
+
+	return liveCh
+}
+
+// consumeIteratorsN distributes all the elements among k channels, as
+// IteratorsN does, on behalf of the consumer with the given id.
+func (bq *Blocking[T]) consumeIteratorsN(id uint64, k int, mode Partition) ([]<-chan T, error) {
+	if k < 1 {
+		return nil, bq.named(ErrInvalidPartitions)
+	}
+
+	if err := bq.consumers.enter(id); err != nil {
+		return nil, bq.named(err)
+	}
+
+	defer bq.consumers.leave(id)
+
+	bq.lock.Lock()
+	elems := bq.clear()
+	bq.lock.Unlock()
+
+	bq.tracker.recordBulk()
+
+	return partition(elems, k, mode), nil
+}
+
+// consumeDrain returns a sequence removing the elements as they are
+// iterated, as Drain does, on behalf of the consumer with the given id.
+func (bq *Blocking[T]) consumeDrain(id uint64) func(yield func(T) bool) {
+	return drainSeq(func() (T, error) {
+		return bq.consumeGet(id)
+	})
+}
+
+// consumeBatches passes batches of elements to fn, as ConsumeBatches does, on
+// behalf of the consumer with the given id.
+func (bq *Blocking[T]) consumeBatches(
+	ctx context.Context,
+	id uint64,
+	minBatch, maxBatch int,
+	maxLinger time.Duration,
+	fn func([]T) error,
+	opts ...BatchOption,
+) error {
+	options := batchOptions{
+		requeueOnError: false,
+	}
+
+	for _, o := range opts {
+		o.applyBatch(&options)
+	}
+
+	if minBatch < 1 {
+		minBatch = 1
+	}
+
+	if maxBatch < minBatch {
+		maxBatch = minBatch
+	}
+
+	if err := bq.consumers.enter(id); err != nil {
+		return bq.named(err)
+	}
+
+	defer bq.consumers.leave(id)
+
+	for {
+		batch, err := bq.gatherBatch(ctx, minBatch, maxBatch, maxLinger)
+		if errors.Is(err, ErrQueueClosed) && ctx.Err() == nil {
+			return nil
+		}
+
+		if err != nil {
+			return bq.named(err)
+		}
+
+		bq.tracker.recordBulk()
+
+		if err := fn(batch); err != nil {
+			if options.requeueOnError {
+				bq.requeueBatch(batch)
+			}
+
+			return bq.named(err)
+		}
+	}
+}
+
+// getCtx removes and returns the head of the queue, waiting for an element
+// to become available until ctx is done, on behalf of the package functions
+// such as ProcessEach. It returns the ErrConsumerRequired error if the
+// WithExclusiveConsumer option is provided.
+func (bq *Blocking[T]) getCtx(ctx context.Context) (v T, _ error) {
+	return bq.consumeWaitGet(ctx, queueConsumer)
+}
+
+// consumeWaitGet removes and returns the head of the queue, waiting for an
+// element to become available until ctx is done, on behalf of the consumer
+// with the given id.
+func (bq *Blocking[T]) consumeWaitGet(ctx context.Context, id uint64) (v T, _ error) {
+	if err := bq.consumers.enter(id); err != nil {
+		return v, err
+	}
+
+	defer bq.consumers.leave(id)
+
+	return bq.waitGet(ctx)
+}
+
+// waitGet removes and returns the head of the queue, waiting for an element
+// to become available until ctx is done.
+func (bq *Blocking[T]) waitGet(ctx context.Context) (v T, _ error) {
+	bq.lock.Lock()
+	defer bq.lock.Unlock()
+
+	if err := bq.waitToGet(ctx); err != nil {
+		return v, err
+	}
+
+	defer bq.notFullCond.Signal()
+
+	return bq.removeHead(), nil
+}
+
+// getWaitValue removes and returns the head of the queue along with its
+// propagated value, waiting for an element to become available until ctx
+// is done.
+func (bq *Blocking[T]) getWaitValue(ctx context.Context) (v T, _ any, _ error) {
+	bq.lock.Lock()
+	defer bq.lock.Unlock()
+
+	if err := bq.waitToGet(ctx); err != nil {
+		return v, nil, err
+	}
+
+	defer bq.notFullCond.Signal()
+
+	var value any
+
+	// the urgent elements carry no metadata.
+	if bq.meta != nil && !bq.urgentTurn() {
+		value = bq.meta[bq.elementsIndex].value
+	}
+
+	return bq.removeHead(), value, nil
+}
+
+// requeueFront inserts the element to the head of the queue, regardless of
+// the queue capacity and of the queue being closed.
+func (bq *Blocking[T]) requeueFront(elem T) {
+	bq.lock.Lock()
+	defer bq.lock.Unlock()
+
+	bq.offerFront([]T{elem})
+}
+
+// requeueBatch inserts the elements to the head of the queue, as
+// requeueFront does.
+func (bq *Blocking[T]) requeueBatch(elems []T) {
+	bq.lock.Lock()
+	defer bq.lock.Unlock()
+
+	bq.offerFront(elems)
+}
+
+// wakeProducers wakes up the producers waiting for capacity.
+func (bq *Blocking[T]) wakeProducers() {
+	bq.lock.Lock()
+	defer bq.lock.Unlock()
+
+	bq.notFullCond.Broadcast()
+}
+
+// reservedForWaiters returns true if the available elements are reserved
+// for the consumers waiting in GetWait.
+func (bq *Blocking[T]) reservedForWaiters() bool {
+	return bq.waiterPriority && bq.getWaiters.len() > 0
+}
+
+// signalNotEmpty wakes up the goroutines waiting for an element.
+// With waiter priority all of them are woken up, so that the longest
+// waiting consumer is able to retrieve the element.
+func (bq *Blocking[T]) signalNotEmpty() {
+	if bq.waiterPriority {
+		bq.notEmptyCond.Broadcast()
+
+		return
+	}
+
+	bq.notEmptyCond.Signal()
+}
+
+// getUnreserved removes and returns the head of the queue, unless the
+// elements are reserved for the waiting consumers.
+func (bq *Blocking[T]) getUnreserved() (v T, _ error) {
+	if !bq.isEmpty() && bq.reservedForWaiters() {
+		return v, ErrNoElementsAvailable
+	}
+
+	return bq.get()
+}
+
+// gatherBatch waits for at least one element and retrieves a batch of
+// elements as described by ConsumeBatches.
+func (bq *Blocking[T]) gatherBatch(
+	ctx context.Context,
+	minBatch, maxBatch int,
+	maxLinger time.Duration,
+) ([]T, error) {
+	bq.lock.Lock()
+	defer bq.lock.Unlock()
+
+	if err := bq.waitToGet(ctx); err != nil {
+		return nil, err
+	}
+
+	batch := bq.getUpTo(maxBatch, nil)
+
+	if len(batch) >= minBatch {
+		return batch, nil
+	}
+
+	lingerCtx, cancel := withClockTimeout(ctx, bq.clock, maxLinger)
+	defer cancel()
+
+	for len(batch) < minBatch {
+		if err := bq.waitToGet(lingerCtx); err != nil {
+			if ctx.Err() != nil {
+				bq.offerFront(batch)
+
+				return nil, ctx.Err()
+			}
+
+			// the linger duration elapsed or the queue was closed.
+			break
+		}
+
+		batch = bq.getUpTo(maxBatch-len(batch), batch)
+	}
+
+	return batch, nil
+}
+
+// getN removes and returns up to n elements from the head of the queue.
+func (bq *Blocking[T]) getN(n int) []T {
+	elems := bq.getUpTo(n, make([]T, 0, min(max(n, 0), bq.size())))
+
+	if len(elems) > 0 {
+		bq.tracker.recordBulk()
+	}
+
+	return elems
+}
+
+// getUpTo removes up to n elements from the head of the queue and appends
+// them to dst.
+func (bq *Blocking[T]) getUpTo(n int, dst []T) []T {
+	if n <= 0 || bq.isEmpty() {
+		return dst
+	}
+
+	for ; n > 0 && len(bq.urgent) > 0; n-- {
+		dst = append(dst, bq.removeHead())
+	}
+
+	removed := bq.elements[bq.elementsIndex:]
+
+	if len(removed) > n {
+		removed = removed[:n]
+	}
+
+	dst = append(dst, removed...)
+
+	if len(removed) > 0 {
+		bq.urgentStreak = 0
+	}
+
+	for i, elem := range removed {
+		bq.journal.record(JournalGet, elem, bq.stored()-i-1)
+	}
+
+	bq.dropFront(len(removed))
+
+	bq.occupancy.releaseAdmission(len(removed), 0)
+
+	bq.drainRate.removed(len(removed), bq.size())
+
+	bq.version++
+
+	bq.notFullCond.Broadcast()
+
+	return dst
+}
+
+// offerFront inserts the elements to the head of the queue, regardless of
+// the queue capacity, unless the queue is destroyed. The elements are inserted in front of the urgent lane.
+func (bq *Blocking[T]) offerFront(elems []T) {
+	if bq.destroyed {
+		bq.recycler.discardAll(elems)
+
+		return
+	}
+
+	if len(elems) == 0 {
+		return
+	}
+
+	urgent := make([]T, 0, len(elems)+len(bq.urgent))
+	urgent = append(urgent, elems...)
+	urgent = append(urgent, bq.urgent...)
+
+	bq.urgent = urgent
+
+	bq.bloom.add(elems...)
+
+	// the elements are journaled as if re-offered one at a time, last first.
+	for i := len(elems) - 1; i >= 0; i-- {
+		bq.journal.record(JournalRequeue, elems[i], bq.stored()-i)
+	}
+
+	// the re-offered elements are admitted again.
+	if bq.ledger != nil {
+		urgentMeta := make([]elementMeta, 0, len(elems)+len(bq.urgentMeta))
+
+		for range elems {
+			urgentMeta = append(urgentMeta, elementMeta{seq: bq.ledger.admit()})
+		}
+
+		bq.urgentMeta = append(urgentMeta, bq.urgentMeta...)
+	}
+
+	bq.occupancy.forceAdmit(len(elems), 0)
+
+	bq.version++
+
+	bq.notEmptyCond.Broadcast()
+}
+
+// reset replaces the current elements with the elements provided at creation.
+func (bq *Blocking[T]) reset() {
+	if bq.destroyed {
+		return
+	}
+
+	if bq.recycler.enabled() {
+		bq.recycler.discardAll(bq.urgent)
+		bq.recycler.discardAll(bq.elements[bq.elementsIndex:])
+	}
+
+	bq.dropUrgent()
+
+	// the backing array is reused, releasing the references it holds.
+	bq.dropFront(len(bq.elements) - bq.elementsIndex)
+
+	// the handles issued for the replaced elements are invalidated.
+	bq.meta = nil
+
+	bq.elements = slices.Grow(bq.elements[:0], len(bq.initialElements))[:len(bq.initialElements)]
+
+	copyElements(bq.elements, bq.initialElements, bq.resetCloner)
+
+	// the filter is rebuilt, releasing its saturated counters.
+	bq.bloom.reset()
+	bq.bloom.add(bq.elements...)
+
+	bq.window.forget()
+
+	bq.admitElements()
+
+	bq.occupancy.reset(len(bq.elements))
+
+	bq.journal.recordOp(JournalReset, bq.stored())
+
+	bq.version++
+
+	bq.notEmptyCond.Broadcast()
+}
+
+// restore replaces the current elements with the given lanes, as reset does
+// with the initial elements.
+func (bq *Blocking[T]) restore(urgent, elems []T) {
+	if bq.recycler.enabled() {
+		bq.recycler.discardAll(bq.urgent)
+		bq.recycler.discardAll(bq.elements[bq.elementsIndex:])
+	}
+
+	bq.dropUrgent()
+
+	bq.dropFront(len(bq.elements) - bq.elementsIndex)
+
+	bq.meta = nil
+
+	bq.elements = elems
+
+	bq.urgent = urgent
+
+	if bq.ledger != nil && len(urgent) > 0 {
+		bq.urgentMeta = make([]elementMeta, len(urgent))
+
+		for i := range bq.urgentMeta {
+			bq.urgentMeta[i].seq = bq.ledger.admit()
+		}
+	}
+
+	bq.bloom.reset()
+	bq.bloom.add(bq.urgent...)
+	bq.bloom.add(bq.elements...)
+
+	bq.admitElements()
+
+	bq.occupancy.reset(bq.stored())
+
+	// the restored elements are journaled as offered after a clear.
+	bq.journal.recordOp(JournalClear, 0)
+
+	for i, elem := range bq.urgent {
+		bq.journal.record(JournalOfferUrgent, elem, i+1)
+	}
+
+	for i, elem := range bq.elements {
+		bq.journal.record(JournalOffer, elem, len(bq.urgent)+i+1)
+	}
+
+	bq.version++
+
+	bq.notEmptyCond.Broadcast()
+}
+
+// clear removes and returns all elements from the queue, waking up the
+// producers waiting for capacity.
+func (bq *Blocking[T]) clear() []T {
+	defer bq.notFullCond.Broadcast()
+
+	bq.version++
+
+	// the removed elements are copied, as the backing array is reused.
+	removed := make([]T, 0, len(bq.urgent)+len(bq.elements)-bq.elementsIndex)
+	removed = append(removed, bq.urgent...)
+	removed = append(removed, bq.elements[bq.elementsIndex:]...)
+
+	bq.dropFront(len(bq.elements) - bq.elementsIndex)
+
+	bq.dropUrgent()
+
+	bq.bloom.reset()
+
+	bq.occupancy.reset(0)
+
+	bq.journal.recordOp(JournalClear, 0)
+
+	return removed
+}
+
+// detach removes and returns the elements of the queue, which must not hold
+// urgent elements, as clear does, replacing the backing array instead of
+// copying the elements out of it.
+func (bq *Blocking[T]) detach() []T {
+	defer bq.notFullCond.Broadcast()
+
+	bq.version++
+
+	removed := bq.elements[bq.elementsIndex:]
+
+	bq.bloom.remove(removed...)
+
+	size := max(bq.occupancy.capacity, 0)
+
+	if bq.meta != nil {
+		if bq.ledger != nil {
+			for _, meta := range bq.meta[bq.elementsIndex:] {
+				bq.ledger.exit(meta.seq)
+			}
+		}
+
+		bq.meta = make([]elementMeta, 0, size)
+	}
+
+	bq.elements = make([]T, 0, size)
+	bq.elementsIndex = 0
+
+	bq.occupancy.reset(0)
+
+	bq.journal.recordOp(JournalClear, 0)
+
+	return removed
+}
+
+// headElem returns the head of the queue, which must not be empty.
+func (bq *Blocking[T]) headElem() T {
+	if bq.urgentTurn() {
+		return bq.urgent[0]
+	}
+
+	return bq.elements[bq.elementsIndex]
+}
+
+// urgentTurn returns true if the head of the queue is the head of the urgent
+// lane, which is the case unless the urgent lane is empty or the lane ratio
+// provided using WithLaneRatio is reached.
+func (bq *Blocking[T]) urgentTurn() bool {
+	if len(bq.urgent) == 0 {
+		return false
+	}
+
+	return bq.laneRatio == 0 || bq.urgentStreak < bq.laneRatio || bq.elementsIndex >= len(bq.elements)
+}
+
+// removeHead removes and returns the head of the queue,
+// which must not be empty.
+func (bq *Blocking[T]) removeHead() T {
+	bq.occupancy.releaseAdmission(1, 0)
+
+	return bq.takeHead()
+}
+
+// replaceHead removes and returns the head of the queue, which must not be
+// empty, handing its slot over to the element the caller inserts next.
+func (bq *Blocking[T]) replaceHead() T {
+	bq.occupancy.handOver()
+
+	return bq.takeHead()
+}
+
+// takeHead removes and returns the head of the queue, which must not be
+// empty, once its slot is accounted for.
+func (bq *Blocking[T]) takeHead() T {
+	bq.version++
+
+	bq.drainRate.removed(1, bq.size())
+
+	if bq.urgentTurn() {
+		// only the urgent elements retrieved while the other elements are
+		// waiting count towards the lane ratio.
+		if bq.elementsIndex < len(bq.elements) {
+			bq.urgentStreak++
+		} else {
+			bq.urgentStreak = 0
+		}
+
+		elem := bq.urgent[0]
+
+		bq.bloom.remove(elem)
+
+		var zero T
+
+		// release the reference to the element.
+		bq.urgent[0] = zero
+
+		bq.urgent = bq.urgent[1:]
+
+		if len(bq.urgent) == 0 {
+			bq.urgent = nil
+		}
+
+		if bq.urgentMeta != nil {
+			bq.ledger.exit(bq.urgentMeta[0].seq)
+
+			bq.urgentMeta = bq.urgentMeta[1:]
+
+			if len(bq.urgentMeta) == 0 {
+				bq.urgentMeta = nil
+			}
+		}
+
+		bq.journal.record(JournalGet, elem, bq.stored())
+
+		return elem
+	}
+
+	bq.urgentStreak = 0
+
+	elem := bq.elements[bq.elementsIndex]
+
+	bq.dropFront(1)
+
+	bq.journal.record(JournalGet, elem, bq.stored())
+
+	return elem
+}
+
+// dropFront removes the n first elements of the elements slice, releasing
+// their references and accounting for their exit, and rewinds the slice to
+// the start of its backing array once it is empty.
+func (bq *Blocking[T]) dropFront(n int) {
+	bq.bloom.remove(bq.elements[bq.elementsIndex : bq.elementsIndex+n]...)
+
+	clear(bq.elements[bq.elementsIndex : bq.elementsIndex+n])
+
+	if bq.meta != nil {
+		if bq.ledger != nil {
+			for _, meta := range bq.meta[bq.elementsIndex : bq.elementsIndex+n] {
+				bq.ledger.exit(meta.seq)
+			}
+		}
+
+		clear(bq.meta[bq.elementsIndex : bq.elementsIndex+n])
+	}
+
+	bq.elementsIndex += n
+
+	if bq.elementsIndex == len(bq.elements) {
+		bq.elements = bq.elements[:0]
+		bq.elementsIndex = 0
+
+		if bq.meta != nil {
+			bq.meta = bq.meta[:0]
+		}
+	}
+}
+
+// pushBack appends the element to the elements slice, as pushBackMeta does,
+// admitting it into the ledger.
+func (bq *Blocking[T]) pushBack(elem T) {
+	bq.pushBackMeta(elem, elementMeta{seq: bq.ledger.admit()})
+}
+
+// pushBackMeta appends the element and its metadata to the elements slice,
+// recording its enqueue time if the WithEnqueueTimes option is provided.
+// If its backing array is full, the slots freed at its start are reused by
+// moving the elements to the start of the array, provided they take up at
+// most half of it, before growing the array, so that a bounded queue stops
+// allocating once its backing array holds twice its capacity.
+func (bq *Blocking[T]) pushBackMeta(elem T, meta elementMeta) {
+	if bq.enqueueTimes {
+		meta.enqueued = bq.clock.Now()
+	}
+
+	if len(bq.elements) == cap(bq.elements) && bq.elementsIndex >= len(bq.elements)-bq.elementsIndex {
+		n := copy(bq.elements, bq.elements[bq.elementsIndex:])
+
+		clear(bq.elements[n:])
+
+		bq.elements = bq.elements[:n]
+
+		if bq.meta != nil {
+			copy(bq.meta, bq.meta[bq.elementsIndex:])
+
+			clear(bq.meta[n:])
+
+			bq.meta = bq.meta[:n]
+		}
+
+		bq.elementsIndex = 0
+	}
+
+	bq.elements = append(bq.elements, elem)
+
+	bq.bloom.add(elem)
+
+	bq.journal.record(JournalOffer, elem, bq.stored())
+
+	if bq.meta == nil && meta.isZero() {
+		return
+	}
+
+	if bq.meta == nil {
+		bq.meta = make([]elementMeta, len(bq.elements)-1, cap(bq.elements))
+	}
+
+	bq.meta = append(bq.meta, meta)
+}
+
+// dropUrgent removes the elements of the urgent lane, accounting for their
+// exit, and restarts the count of consecutive urgent elements.
+func (bq *Blocking[T]) dropUrgent() {
+	for _, meta := range bq.urgentMeta {
+		bq.ledger.exit(meta.seq)
+	}
+
+	bq.bloom.remove(bq.urgent...)
+
+	bq.urgent = nil
+	bq.urgentMeta = nil
+	bq.urgentStreak = 0
+}
+
+// admitElements admits the elements into the ledger, if the
+// WithConservationChecks option is provided, and records their enqueue time,
+// if the WithEnqueueTimes option is provided. The metadata slice must be nil.
+func (bq *Blocking[T]) admitElements() {
+	if bq.ledger == nil && !bq.enqueueTimes {
+		return
+	}
+
+	bq.meta = make([]elementMeta, len(bq.elements), cap(bq.elements))
+
+	var now time.Time
+
+	if bq.enqueueTimes {
+		now = bq.clock.Now()
+	}
+
+	for i := bq.elementsIndex; i < len(bq.meta); i++ {
+		bq.meta[i].seq = bq.ledger.admit()
+		bq.meta[i].enqueued = now
+	}
+}
+
+// stampIndex returns the index in elements of the element with the given
+// stamp, or -1 if it is not queued.
+func (bq *Blocking[T]) stampIndex(stamp uint64) int {
+	if bq.meta == nil {
+		return -1
+	}
+
+	for i := bq.elementsIndex; i < len(bq.meta); i++ {
+		if bq.meta[i].stamp == stamp {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// cancelStamp removes the element with the given stamp, if it is queued.
+func (bq *Blocking[T]) cancelStamp(stamp uint64) bool {
+	bq.lock.Lock()
+	defer bq.lock.Unlock()
+
+	i := bq.stampIndex(stamp)
+	if i < 0 {
+		return false
+	}
+
+	bq.discard(bq.elements[i])
+
+	bq.tracker.record(bq.elements[i])
+
+	bq.bloom.remove(bq.elements[i])
+
+	bq.journal.recordRemove(bq.elements[i], len(bq.urgent)+i-bq.elementsIndex, bq.stored()-1)
+
+	bq.ledger.exit(bq.meta[i].seq)
+
+	last := len(bq.elements) - 1
+
+	copy(bq.elements[i:], bq.elements[i+1:])
+	copy(bq.meta[i:], bq.meta[i+1:])
+
+	var zero T
+
+	bq.elements[last] = zero
+	bq.meta[last] = elementMeta{}
+
+	bq.elements = bq.elements[:last]
+	bq.meta = bq.meta[:last]
+
+	// rewind the emptied elements.
+	bq.dropFront(0)
+
+	bq.occupancy.releaseAdmission(1, 0)
+
+	bq.version++
+
+	bq.notFullCond.Signal()
+
+	return true
+}
+
+// stampQueued returns true if the element with the given stamp is queued.
+func (bq *Blocking[T]) stampQueued(stamp uint64) bool {
+	bq.lock.RLock()
+	defer bq.lock.RUnlock()
+
+	return bq.stampIndex(stamp) >= 0
+}
+
+// moveLock returns the lock of the queue.
+func (bq *Blocking[T]) moveLock() *profiledRWMutex {
+	return &bq.lock
+}
+
+// moveCandidate returns the element to be moved by Move or MoveMatching,
+// along with its position in FIFO order starting with the urgent lane.
+func (bq *Blocking[T]) moveCandidate(pred func(T) bool) (elem T, pos int, _ error) {
+	if err := bq.consumers.enter(queueConsumer); err != nil {
+		return elem, 0, err
+	}
+
+	defer bq.consumers.leave(queueConsumer)
+
+	if bq.isEmpty() {
+		return elem, 0, bq.emptyErr()
+	}
+
+	if bq.reservedForWaiters() {
+		return elem, 0, ErrNoElementsAvailable
+	}
+
+	if pred == nil {
+		if bq.urgentTurn() {
+			return bq.urgent[0], 0, nil
+		}
+
+		return bq.elements[bq.elementsIndex], len(bq.urgent), nil
+	}
+
+	for i, elem := range bq.urgent {
+		if pred(elem) {
+			return elem, i, nil
+		}
+	}
+
+	for i, elem := range bq.elements[bq.elementsIndex:] {
+		if pred(elem) {
+			return elem, len(bq.urgent) + i, nil
+		}
+	}
+
+	return elem, 0, ErrNoElementsAvailable
+}
+
+// moveOut removes the element at the given position, in FIFO order starting
+// with the urgent lane. The elements before and after it keep their order.
+func (bq *Blocking[T]) moveOut(elem T, pos int) {
+	defer bq.notFullCond.Signal()
+
+	bq.tracker.record(elem)
+
+	headPos := len(bq.urgent)
+	if bq.urgentTurn() {
+		headPos = 0
+	}
+
+	if pos == headPos {
+		bq.removeHead()
+
+		return
+	}
+
+	bq.bloom.remove(elem)
+
+	if pos < len(bq.urgent) {
+		if bq.urgentMeta != nil {
+			bq.ledger.exit(bq.urgentMeta[pos].seq)
+
+			bq.urgentMeta = deleteIndex(bq.urgentMeta, pos)
+		}
+
+		bq.urgent = deleteIndex(bq.urgent, pos)
+
+		if len(bq.urgent) == 0 {
+			bq.urgent, bq.urgentMeta = nil, nil
+		}
+	} else {
+		i := bq.elementsIndex + pos - len(bq.urgent)
+
+		if bq.meta != nil {
+			bq.ledger.exit(bq.meta[i].seq)
+
+			bq.meta = deleteIndex(bq.meta, i)
+		}
+
+		bq.elements = deleteIndex(bq.elements, i)
+
+		// rewind the emptied elements.
+		bq.dropFront(0)
+	}
+
+	bq.journal.recordRemove(elem, pos, bq.stored())
+
+	bq.version++
+
+	bq.occupancy.releaseAdmission(1, 0)
+
+	bq.drainRate.removed(1, bq.size())
+}
+
+// moveAdmit admits the element moved into the queue, as Offer does.
+func (bq *Blocking[T]) moveAdmit(elem T) error {
+	return bq.admitProjected(elem)
+}
+
+// moveIn inserts the element moved into the queue to its tail.
+func (bq *Blocking[T]) moveIn(elem T) {
+	bq.tracker.record(elem)
+
+	bq.pushBack(elem)
+
+	bq.inserted()
+}
+
+// moveElems returns a copy of the elements, unless they are reserved for the
+// waiting consumers or the exclusive consumer mode is active.
+func (bq *Blocking[T]) moveElems() []T {
+	if err := bq.consumers.enter(queueConsumer); err != nil {
+		return nil
+	}
+
+	defer bq.consumers.leave(queueConsumer)
+
+	if bq.reservedForWaiters() {
+		return nil
+	}
+
+	return bq.snapshot()
+}
+
+// moveTail returns the position of the element at the tail of the queue,
+// provided it is elem.
+func (bq *Blocking[T]) moveTail(elem T) (pos int, ok bool) {
+	if bq.elementsIndex >= len(bq.elements) || bq.elements[len(bq.elements)-1] != elem {
+		return 0, false
+	}
+
+	return bq.stored() - 1, true
+}
+
+// replayRequeue inserts the element to the head of the queue, replaying a
+// JournalRequeue record.
+func (bq *Blocking[T]) replayRequeue(elem T) {
+	bq.lock.Lock()
+	defer bq.lock.Unlock()
+
+	bq.offerFront([]T{elem})
+}
+
+// emptyErr returns the error reported when there are no elements available.
+func (bq *Blocking[T]) emptyErr() error {
+	if err := bq.closedErr(); err != nil {
+		return err
+	}
+
+	return ErrNoElementsAvailable
+}
+
+// isEmpty returns true if the queue is empty.
+func (bq *Blocking[T]) isEmpty() bool {
+	return len(bq.urgent) == 0 && bq.elementsIndex >= len(bq.elements)
+}
+
+// publishSnapshot publishes the size and the head of the queue, if the
+// WithReadSnapshotting option is provided.
+func (bq *Blocking[T]) publishSnapshot() {
+	if bq.snapshots == nil {
+		return
+	}
+
+	head, err := bq.peek()
+
+	bq.snapshots.publish(bq.size(), head, err)
+}
+
+// exactSize returns the number of elements in the queue, bypassing the
+// cache of the WithCachedReads option.
+func (bq *Blocking[T]) exactSize() int {
+	bq.lock.RLock()
+	defer bq.lock.RUnlock()
+
+	return bq.size()
+}
+
+// readHead returns the size and the head of the queue cached by the
+// WithCachedReads option, refreshing them if they expired.
+func (bq *Blocking[T]) readHead() *cachedHead[T] {
+	if h := bq.reads.freshHead(); h != nil {
+		return h
+	}
+
+	bq.lock.RLock()
+	defer bq.lock.RUnlock()
+
+	elem, err := bq.peek()
+
+	return bq.reads.storeHead(bq.size(), elem, err)
+}
+
+// readElems returns the elements of the queue cached by the WithCachedReads
+// option, refreshing them if they expired.
+func (bq *Blocking[T]) readElems() *cachedElems[T] {
+	if e := bq.reads.freshElems(); e != nil {
+		return e
+	}
+
+	bq.lock.RLock()
+	defer bq.lock.RUnlock()
+
+	return bq.reads.storeElems(bq.snapshot())
+}
+
+// snapshot returns a copy of the queue elements in FIFO order,
+// starting with the urgent lane.
+func (bq *Blocking[T]) snapshot() []T {
+	elems := make([]T, 0, bq.size())
+
+	elems = append(elems, bq.urgent...)
+
+	return append(elems, bq.elements[bq.elementsIndex:]...)
+}
+
+// copyRange appends the elements in [from, to), relative to the head of the
+// queue, to dst.
+func (bq *Blocking[T]) copyRange(dst []T, from, to int, _ any) ([]T, any) {
+	if from < len(bq.urgent) {
+		dst = append(dst, bq.urgent[from:min(to, len(bq.urgent))]...)
+	}
+
+	from = max(from-len(bq.urgent), 0)
+	to -= len(bq.urgent)
+
+	if from < to {
+		dst = append(dst, bq.elements[bq.elementsIndex+from:bq.elementsIndex+to]...)
+	}
+
+	return dst, nil
+}
+
+func (bq *Blocking[T]) size() int {
+	return bq.occupancy.count
+}
+
+// stored returns the number of elements held by the queue, which the
+// occupancy counts once the current operation completes.
+func (bq *Blocking[T]) stored() int {
+	return len(bq.urgent) + len(bq.elements) - bq.elementsIndex
+}
+
+// verifyOccupancy checks that the occupancy counts the elements held by
+// the queue.
+func (bq *Blocking[T]) verifyOccupancy() {
+	bq.occupancy.verify(bq.stored())
+
+	if bq.meta != nil && len(bq.meta) != len(bq.elements) {
+		panic(fmt.Sprintf("queue holds the metadata of %d elements for %d elements", len(bq.meta), len(bq.elements)))
+	}
+
+	if bq.urgentMeta != nil && len(bq.urgentMeta) != len(bq.urgent) {
+		panic(fmt.Sprintf("queue holds the metadata of %d urgent elements for %d urgent elements", len(bq.urgentMeta), len(bq.urgent)))
+	}
+}
+
+// admit admits the element into the queue, which must be inserted right
+// after, or returns the error reported when it cannot be inserted.
+func (bq *Blocking[T]) admit(elem T) error {
+	if err := bq.rejected(elem); err != nil {
+		return err
+	}
+
+	if err := bq.occupancy.admit(1, 0); err != nil {
+		return err
+	}
+
+	bq.window.remember(elem)
+
+	return nil
+}
+
+// admitProjected admits the element, as admit does, unless it would wait
+// longer than the maximum wait provided using WithMaxProjectedWait.
+func (bq *Blocking[T]) admitProjected(elem T) error {
+	if err := bq.rejected(elem); err != nil {
+		return err
+	}
+
+	if err := bq.drainRate.admit(bq.size()); err != nil {
+		return err
+	}
+
+	if err := bq.occupancy.admit(1, 0); err != nil {
+		return err
+	}
+
+	bq.window.remember(elem)
+
+	return nil
+}
+
+// rejected returns the error preventing the element from being inserted,
+// regardless of the capacity, or nil if it may be inserted.
+func (bq *Blocking[T]) rejected(elem T) error {
+	if err := bq.validator.check(elem); err != nil {
+		return err
+	}
+
+	if err := bq.closedErr(); err != nil {
+		return err
+	}
+
+	switch {
+	case bq.sentinelOffered:
+		return ErrQueueClosed
+	case bq.sentinel != nil && elem == *bq.sentinel:
+		return ErrReservedSentinel
+	}
+
+	return bq.window.check(elem)
+}
+
+func (bq *Blocking[T]) offer(elem T) error {
+	if spilled, err := bq.spill(elem); spilled {
+		return err
+	}
+
+	if err := bq.admitProjected(elem); err != nil {
+		return err
+	}
+
+	bq.pushBack(elem)
+
+	bq.inserted()
+
+	return nil
+}
+
+// offerWait inserts the element to the tail of the queue, along with the
+// propagated value, waiting for the necessary space to become available
+// until ctx is done.
+func (bq *Blocking[T]) offerWait(ctx context.Context, elem T, value any) error {
+	if spilled, err := bq.spill(elem); spilled {
+		return err
+	}
+
+	if ctx.Done() != nil {
+		stop := context.AfterFunc(ctx, bq.wakeProducers)
+		defer stop()
+	}
+
+	strictResets := bq.strictResets
+
+	// the producer is registered as a waiter once it blocks.
+	var (
+		id      uint64
+		waiting bool
+	)
+
+	defer func() {
+		if waiting {
+			bq.offerWaiters.remove(id)
+		}
+	}()
+
+	// the slots of a CapacityGroup may be taken by another queue between
+	// the wake up and the admission, so the admission itself is retried.
+	for spins := 0; ; {
+		err := bq.admit(elem)
+		if err == nil {
+			break
+		}
+
+		if !errors.Is(err, ErrQueueIsFull) {
+			return err
+		}
+
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if !waiting {
+			id, waiting = bq.offerWaiters.enqueue(bq.clock.Now()), true
+		}
+
+		spins = bq.wait(bq.notFullCond, spins)
+
+		if bq.strictResets != strictResets {
+			return ErrResetWhileWaiting
+		}
+	}
+
+	bq.pushBackMeta(elem, elementMeta{value: value, seq: bq.ledger.admit()})
+
+	bq.inserted()
+
+	return nil
+}
+
+// spill appends the element to the disk overflow, if the WithDiskOverflow
+// option is provided and the queue is full or the overflow holds elements,
+// which are older. It returns false if the element is to be inserted into
+// the queue instead.
+func (bq *Blocking[T]) spill(elem T) (bool, error) {
+	if !bq.overflow.active() || (bq.overflow.len() == 0 && bq.occupancy.fits(1)) {
+		return false, nil
+	}
+
+	if err := bq.rejected(elem); err != nil {
+		return true, err
+	}
+
+	if err := bq.overflow.push(elem); err != nil {
+		return true, err
+	}
+
+	bq.window.remember(elem)
+
+	return true, nil
+}
+
+// refill moves the elements of the disk overflow into the queue, oldest
+// first, while the queue has room for them.
+func (bq *Blocking[T]) refill() {
+	for bq.overflow.len() > 0 && bq.occupancy.admitFitting(1) {
+		elem, ok := bq.overflow.pop()
+		if !ok {
+			bq.occupancy.releaseAdmission(1, 0)
+
+			return
+		}
+
+		bq.pushBack(elem)
+
+		bq.inserted()
+	}
+}
+
+// inserted notifies the consumers and the auto flusher that an element
+// was inserted.
+func (bq *Blocking[T]) inserted() {
+	bq.version++
+
+	bq.drainRate.observe(bq.size())
+
+	bq.signalNotEmpty()
+
+	if bq.flusher != nil {
+		bq.flusher.inserted(bq.size())
+	}
+}
+
+func (bq *Blocking[T]) peek() (v T, _ error) {
+	if bq.isEmpty() {
+		return v, bq.emptyErr()
+	}
+
+	return bq.headElem(), nil
+}
+
+func (bq *Blocking[T]) get() (v T, _ error) {
+	defer bq.notFullCond.Signal()
+
+	if bq.isEmpty() {
+		return v, bq.emptyErr()
+	}
 
-	return elem, nil
+	return bq.removeHead(), nil
 }
@@ -1,15 +1,23 @@
 package queue_test
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"reflect"
+	"runtime"
 	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/adrianbrad/queue"
+	"go.uber.org/goleak"
 )
 
 func TestBlocking(t *testing.T) {
@@ -293,6 +301,137 @@ func TestBlocking(t *testing.T) {
 				t.Fatalf("expected elem to be %d, got %d", 5, e)
 			}
 		})
+
+		t.Run("WakesAllWaiters", func(t *testing.T) {
+			t.Parallel()
+
+			const waiters = 500
+
+			blockingQueue := queue.NewBlocking([]int{1, 2, 3})
+
+			_ = blockingQueue.Clear()
+
+			var (
+				wg       sync.WaitGroup
+				received atomic.Int64
+			)
+
+			for i := 0; i < waiters; i++ {
+				wg.Add(1)
+
+				go func() {
+					defer wg.Done()
+
+					blockingQueue.GetWait()
+
+					received.Add(1)
+				}()
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			// the barging consumers and the peekers compete with the
+			// woken waiters, putting back the stolen elements.
+			for i := 0; i < 4; i++ {
+				go func() {
+					for ctx.Err() == nil {
+						if elem, err := blockingQueue.Get(); err == nil {
+							_ = blockingQueue.Offer(elem)
+						}
+
+						_, _ = blockingQueue.Peek()
+
+						runtime.Gosched()
+					}
+				}()
+			}
+
+			done := make(chan struct{})
+
+			go func() {
+				wg.Wait()
+				close(done)
+			}()
+
+			timeout := time.After(10 * time.Second)
+
+			for {
+				blockingQueue.Reset()
+
+				select {
+				case <-done:
+					return
+				case <-timeout:
+					t.Fatalf("expected all waiters to wake up, %d of %d did", received.Load(), waiters)
+				case <-time.After(time.Millisecond):
+				}
+			}
+		})
+
+		t.Run("WakesWaitersForRemainingElements", func(t *testing.T) {
+			t.Parallel()
+
+			const waiters = 500
+
+			blockingQueue := queue.NewBlocking([]int{})
+
+			var wg sync.WaitGroup
+
+			for i := 0; i < waiters; i++ {
+				wg.Add(1)
+
+				go func() {
+					defer wg.Done()
+
+					blockingQueue.GetWait()
+				}()
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+
+			var bargers sync.WaitGroup
+
+			for i := 0; i < 4; i++ {
+				bargers.Add(1)
+
+				go func() {
+					defer bargers.Done()
+
+					for ctx.Err() == nil {
+						if elem, err := blockingQueue.Get(); err == nil {
+							_ = blockingQueue.Offer(elem)
+						}
+
+						runtime.Gosched()
+					}
+				}()
+			}
+
+			// exactly one element per waiter is offered, no wakeup being
+			// sent once the last element is offered.
+			for i := 0; i < waiters; i += 5 {
+				_ = blockingQueue.OfferAll(i, i+1, i+2, i+3, i+4)
+			}
+
+			time.Sleep(10 * time.Millisecond)
+
+			cancel()
+			bargers.Wait()
+
+			done := make(chan struct{})
+
+			go func() {
+				wg.Wait()
+				close(done)
+			}()
+
+			select {
+			case <-done:
+			case <-time.After(10 * time.Second):
+				t.Fatalf("expected all waiters to wake up, %d elements left", blockingQueue.Size())
+			}
+		})
 	})
 
 	t.Run("OfferWait", func(t *testing.T) {
@@ -704,97 +843,3031 @@ func TestBlocking(t *testing.T) {
 			}
 		})
 	})
-}
 
-func testResetOnMultipleRoutinesFunc[T comparable](
-	ids []T,
-	totalRoutines int,
-) func(t *testing.T) {
-	// nolint: thelper // not a test helper
-	return func(t *testing.T) {
-		blockingQueue := queue.NewBlocking(ids)
+	t.Run("Close", func(t *testing.T) {
+		t.Parallel()
 
-		var wg sync.WaitGroup
+		t.Run("DrainAfterClose", func(t *testing.T) {
+			t.Parallel()
 
-		wg.Add(totalRoutines)
+			blockingQueue := queue.NewBlocking([]int{1, 2})
 
-		retrievedID := make(chan T, len(ids))
+			blockingQueue.Close()
 
-		// we start X number of goroutines where X is the total number
-		// of goroutines to be executed during this test.
-		for routineIdx := 0; routineIdx < totalRoutines; routineIdx++ {
-			go func(k int) {
+			if err := blockingQueue.Offer(3); !errors.Is(err, queue.ErrQueueClosed) {
+				t.Fatalf("expected error to be %v, got %v", queue.ErrQueueClosed, err)
+			}
+
+			if e := blockingQueue.GetWait(); e != 1 {
+				t.Fatalf("expected elem to be %d, got %d", 1, e)
+			}
+
+			if e, err := blockingQueue.Get(); err != nil || e != 2 {
+				t.Fatalf("expected elem to be %d and no error, got %d and %v", 2, e, err)
+			}
+
+			if _, err := blockingQueue.Get(); !errors.Is(err, queue.ErrQueueClosed) {
+				t.Fatalf("expected error to be %v, got %v", queue.ErrQueueClosed, err)
+			}
+
+			if _, err := blockingQueue.Peek(); !errors.Is(err, queue.ErrQueueClosed) {
+				t.Fatalf("expected error to be %v, got %v", queue.ErrQueueClosed, err)
+			}
+
+			if e := blockingQueue.GetWait(); e != 0 {
+				t.Fatalf("expected zero value, got %d", e)
+			}
+		})
+
+		t.Run("WakesWaiters", func(t *testing.T) {
+			t.Parallel()
+
+			blockingQueue := queue.NewBlocking([]int{1}, queue.WithCapacity(1))
+
+			var wg sync.WaitGroup
+
+			wg.Add(2)
+
+			go func() {
 				defer wg.Done()
 
-				t.Logf("start routine %d", k)
+				blockingQueue.OfferWait(2)
+			}()
 
-				var id T
+			time.Sleep(time.Millisecond)
 
-				defer func() {
-					t.Logf("done routine %d, id %v", k, id)
+			_ = blockingQueue.Clear()
+
+			emptyQueue := queue.NewBlocking([]int{})
+
+			go func() {
+				defer wg.Done()
+
+				_ = emptyQueue.GetWait()
+			}()
+
+			time.Sleep(time.Millisecond)
+
+			blockingQueue.Close()
+			emptyQueue.Close()
+
+			wg.Wait()
+		})
+
+		t.Run("Idempotent", func(t *testing.T) {
+			t.Parallel()
+
+			blockingQueue := queue.NewBlocking([]int{})
+
+			blockingQueue.Close()
+			blockingQueue.Close()
+
+			if err := blockingQueue.Offer(1); !errors.Is(err, queue.ErrQueueClosed) {
+				t.Fatalf("expected error to be %v, got %v", queue.ErrQueueClosed, err)
+			}
+
+			if err := blockingQueue.OfferWaitErr(1); !errors.Is(err, queue.ErrQueueClosed) {
+				t.Fatalf("expected error to be %v, got %v", queue.ErrQueueClosed, err)
+			}
+		})
+	})
+
+	t.Run("Destroy", func(t *testing.T) {
+		t.Parallel()
+
+		t.Run("WakesWaiters", func(t *testing.T) {
+			t.Parallel()
+
+			const waiters = 4
+
+			fullQueue := queue.NewBlocking([]int{1, 2}, queue.WithCapacity(2))
+			emptyQueue := queue.NewBlocking([]int{})
+
+			errs := make(chan error, 2*waiters)
+
+			for i := 0; i < waiters; i++ {
+				go func() {
+					errs <- fullQueue.OfferWaitErr(3)
 				}()
 
-				retrievedID <- blockingQueue.GetWait()
-			}(routineIdx)
-		}
+				go func() {
+					_, err := emptyQueue.Poll(context.Background(), time.Second)
 
-		routineCounter := 0
+					errs <- err
+				}()
+			}
 
-		for range retrievedID {
-			routineCounter++
+			time.Sleep(time.Millisecond)
 
-			t.Logf(
-				"routine counter: %d, refill: %t",
-				routineCounter,
-				routineCounter%len(ids) == 0,
-			)
+			if salvaged := fullQueue.Destroy(); !reflect.DeepEqual([]int{1, 2}, salvaged) {
+				t.Fatalf("expected salvaged elements to be %v, got %v", []int{1, 2}, salvaged)
+			}
 
-			if routineCounter == totalRoutines {
-				break
+			if salvaged := emptyQueue.Destroy(); len(salvaged) != 0 {
+				t.Fatalf("expected no salvaged elements, got %v", salvaged)
 			}
 
-			if routineCounter%len(ids) == 0 {
-				blockingQueue.Reset()
+			// every parked goroutine returns.
+			for i := 0; i < 2*waiters; i++ {
+				select {
+				case err := <-errs:
+					if !errors.Is(err, queue.ErrQueueDestroyed) {
+						t.Fatalf("expected error to be %v, got %v", queue.ErrQueueDestroyed, err)
+					}
+				case <-time.After(time.Second):
+					t.Fatalf("expected the waiting goroutines to return")
+				}
 			}
-		}
+		})
 
-		wg.Wait()
-	}
-}
+		t.Run("SubsequentCalls", func(t *testing.T) {
+			t.Parallel()
 
-func BenchmarkBlockingQueue(b *testing.B) {
-	b.Run("Peek", func(b *testing.B) {
-		blockingQueue := queue.NewBlocking([]int{1})
+			blockingQueue := queue.NewBlocking([]int{1, 2}, queue.WithCapacity(3))
 
-		b.ReportAllocs()
-		b.ResetTimer()
+			_ = blockingQueue.OfferUrgent(0)
 
-		for i := 0; i <= b.N; i++ {
-			_, _ = blockingQueue.Peek()
-		}
-	})
+			if salvaged := blockingQueue.Destroy(); !reflect.DeepEqual([]int{0, 1, 2}, salvaged) {
+				t.Fatalf("expected salvaged elements to be %v, got %v", []int{0, 1, 2}, salvaged)
+			}
 
-	b.Run("Get_Offer", func(b *testing.B) {
-		blockingQueue := queue.NewBlocking([]int{1})
+			blockingQueue.Reset()
 
-		b.ReportAllocs()
-		b.ResetTimer()
+			calls := map[string]func() error{
+				"Offer":       func() error { return blockingQueue.Offer(1) },
+				"OfferWait":   func() error { return blockingQueue.OfferWaitErr(1) },
+				"OfferUrgent": func() error { return blockingQueue.OfferUrgent(1) },
+				"OfferAll":    func() error { return blockingQueue.OfferAll(1, 2) },
+				"Get": func() error {
+					_, err := blockingQueue.Get()
+					return err
+				},
+				"Peek": func() error {
+					_, err := blockingQueue.Peek()
+					return err
+				},
+				"Rotate": blockingQueue.Rotate,
+				"Exchange": func() error {
+					_, err := blockingQueue.Exchange(1)
+					return err
+				},
+			}
 
-		for i := 0; i <= b.N; i++ {
-			_, _ = blockingQueue.Get()
+			for name, call := range calls {
+				if err := call(); !errors.Is(err, queue.ErrQueueDestroyed) {
+					t.Fatalf("expected %s error to be %v, got %v", name, queue.ErrQueueDestroyed, err)
+				}
+			}
 
-			_ = blockingQueue.Offer(1)
-		}
-	})
+			if e := blockingQueue.GetWait(); e != 0 {
+				t.Fatalf("expected zero value, got %d", e)
+			}
 
-	b.Run("Offer", func(b *testing.B) {
-		blockingQueue := queue.NewBlocking[int](nil)
+			if size := blockingQueue.Size(); size != 0 {
+				t.Fatalf("expected size to be 0, got %d", size)
+			}
 
-		b.ReportAllocs()
-		b.ResetTimer()
+			if blockingQueue.CanOffer(1) {
+				t.Fatalf("expected no element to fit into a destroyed queue")
+			}
 
-		for i := 0; i <= b.N; i++ {
-			_ = blockingQueue.Offer(i)
-		}
+			// closing or destroying again has no effect.
+			blockingQueue.Close()
+
+			if salvaged := blockingQueue.Destroy(); salvaged != nil {
+				t.Fatalf("expected no salvaged elements, got %v", salvaged)
+			}
+
+			if _, err := blockingQueue.Get(); !errors.Is(err, queue.ErrQueueDestroyed) {
+				t.Fatalf("expected error to be %v, got %v", queue.ErrQueueDestroyed, err)
+			}
+		})
+
+		t.Run("AfterClose", func(t *testing.T) {
+			t.Parallel()
+
+			blockingQueue := queue.NewBlocking([]int{1})
+
+			blockingQueue.Close()
+
+			if salvaged := blockingQueue.Destroy(); !reflect.DeepEqual([]int{1}, salvaged) {
+				t.Fatalf("expected salvaged elements to be %v, got %v", []int{1}, salvaged)
+			}
+
+			if err := blockingQueue.Offer(1); !errors.Is(err, queue.ErrQueueDestroyed) {
+				t.Fatalf("expected error to be %v, got %v", queue.ErrQueueDestroyed, err)
+			}
+		})
+
+		t.Run("ConsumeBatches", func(t *testing.T) {
+			t.Parallel()
+
+			blockingQueue := queue.NewBlocking([]int{})
+
+			done := make(chan error, 1)
+
+			go func() {
+				done <- blockingQueue.ConsumeBatches(context.Background(), 1, 10, time.Second, func([]int) error {
+					return nil
+				})
+			}()
+
+			time.Sleep(time.Millisecond)
+
+			blockingQueue.Destroy()
+
+			// unlike Close, Destroy is reported to the consumer.
+			if err := <-done; !errors.Is(err, queue.ErrQueueDestroyed) {
+				t.Fatalf("expected error to be %v, got %v", queue.ErrQueueDestroyed, err)
+			}
+		})
 	})
+
+	t.Run("WithContext", func(t *testing.T) {
+		t.Parallel()
+
+		t.Run("CancelReleasesWaiters", func(t *testing.T) {
+			t.Parallel()
+
+			ctx, cancel := context.WithCancel(context.Background())
+
+			consumerQueue := queue.NewBlocking([]int{}, queue.WithContext(ctx))
+			producerQueue := queue.NewBlocking(
+				[]int{1},
+				queue.WithCapacity(1),
+				queue.WithContext(ctx),
+			)
+
+			done := make(chan struct{}, 3)
+
+			go func() {
+				_ = consumerQueue.GetWait()
+				done <- struct{}{}
+			}()
+
+			go func() {
+				_ = consumerQueue.PeekWait()
+				done <- struct{}{}
+			}()
+
+			go func() {
+				producerQueue.OfferWait(2)
+				done <- struct{}{}
+			}()
+
+			select {
+			case <-done:
+				t.Fatalf("expected waiters to be parked")
+			case <-time.After(time.Millisecond):
+			}
+
+			cancel()
+
+			for i := 0; i < 3; i++ {
+				select {
+				case <-done:
+				case <-time.After(time.Second):
+					t.Fatalf("expected waiters to be released")
+				}
+			}
+
+			if producerQueue.Size() != 1 {
+				t.Fatalf("expected size to be %d, got %d", 1, producerQueue.Size())
+			}
+		})
+
+		t.Run("FailFastAfterCancel", func(t *testing.T) {
+			t.Parallel()
+
+			ctx, cancel := context.WithCancel(context.Background())
+
+			blockingQueue := queue.NewBlocking([]int{}, queue.WithContext(ctx))
+
+			cancel()
+
+			// the queue is closed asynchronously once the context is done.
+			var err error
+
+			for i := 0; i < 1000; i++ {
+				if err = blockingQueue.Offer(1); err != nil {
+					break
+				}
+
+				_, _ = blockingQueue.Get()
+
+				time.Sleep(time.Millisecond)
+			}
+
+			if !errors.Is(err, queue.ErrQueueClosed) {
+				t.Fatalf("expected error to be %v, got %v", queue.ErrQueueClosed, err)
+			}
+
+			if !errors.Is(err, context.Canceled) {
+				t.Fatalf("expected error to be %v, got %v", context.Canceled, err)
+			}
+
+			if _, err := blockingQueue.Get(); !errors.Is(err, context.Canceled) {
+				t.Fatalf("expected error to be %v, got %v", context.Canceled, err)
+			}
+		})
+
+		t.Run("WaitAfterCancel", func(t *testing.T) {
+			t.Parallel()
+
+			ctx, cancel := context.WithCancel(context.Background())
+
+			blockingQueue := queue.NewBlocking([]int{}, queue.WithContext(ctx))
+
+			// no goroutine waits on the queue when the context is done.
+			cancel()
+
+			done := make(chan error, 1)
+
+			go func() {
+				_, _, err := blockingQueue.GetWaitCtx(context.Background())
+				done <- err
+			}()
+
+			select {
+			case err := <-done:
+				if !errors.Is(err, context.Canceled) {
+					t.Fatalf("expected error to be %v, got %v", context.Canceled, err)
+				}
+			case <-time.After(time.Second):
+				t.Fatalf("expected the consumer to not wait")
+			}
+
+			blockingQueue.Close()
+
+			if err := blockingQueue.Offer(1); !errors.Is(err, context.Canceled) {
+				t.Fatalf("expected error to be %v, got %v", context.Canceled, err)
+			}
+		})
+
+		t.Run("AlreadyCancelled", func(t *testing.T) {
+			t.Parallel()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			blockingQueue := queue.NewBlocking([]int{1}, queue.WithContext(ctx))
+
+			if err := blockingQueue.Offer(2); !errors.Is(err, queue.ErrQueueClosed) {
+				t.Fatalf("expected error to be %v, got %v", queue.ErrQueueClosed, err)
+			}
+
+			if e := blockingQueue.GetWait(); e != 1 {
+				t.Fatalf("expected elem to be %d, got %d", 1, e)
+			}
+		})
+
+		t.Run("CloseBeforeCancel", func(t *testing.T) {
+			t.Parallel()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			blockingQueue := queue.NewBlocking([]int{}, queue.WithContext(ctx))
+
+			blockingQueue.Close()
+			cancel()
+
+			if err := blockingQueue.Offer(1); errors.Is(err, context.Canceled) {
+				t.Fatalf("expected error to not wrap %v, got %v", context.Canceled, err)
+			}
+		})
+	})
+
+	t.Run("ConsumeBatches", func(t *testing.T) {
+		t.Parallel()
+
+		t.Run("LightTraffic", func(t *testing.T) {
+			t.Parallel()
+
+			ctx, cancel := context.WithCancel(context.Background())
+
+			blockingQueue := queue.NewBlocking([]int{1})
+
+			batches := make(chan []int)
+
+			errCh := make(chan error, 1)
+
+			go func() {
+				errCh <- blockingQueue.ConsumeBatches(ctx, 1, 10, time.Second, func(batch []int) error {
+					batches <- batch
+					return nil
+				})
+			}()
+
+			if batch := <-batches; !reflect.DeepEqual([]int{1}, batch) {
+				t.Fatalf("expected batch to be %v, got %v", []int{1}, batch)
+			}
+
+			blockingQueue.OfferWait(2)
+
+			if batch := <-batches; !reflect.DeepEqual([]int{2}, batch) {
+				t.Fatalf("expected batch to be %v, got %v", []int{2}, batch)
+			}
+
+			cancel()
+
+			if err := <-errCh; !errors.Is(err, context.Canceled) {
+				t.Fatalf("expected error to be %v, got %v", context.Canceled, err)
+			}
+		})
+
+		t.Run("Backlog", func(t *testing.T) {
+			t.Parallel()
+
+			elems := make([]int, 25)
+
+			for i := range elems {
+				elems[i] = i
+			}
+
+			blockingQueue := queue.NewBlocking(elems)
+
+			blockingQueue.Close()
+
+			var sizes []int
+
+			err := blockingQueue.ConsumeBatches(context.Background(), 1, 10, time.Second, func(batch []int) error {
+				sizes = append(sizes, len(batch))
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if !reflect.DeepEqual([]int{10, 10, 5}, sizes) {
+				t.Fatalf("expected batch sizes to be %v, got %v", []int{10, 10, 5}, sizes)
+			}
+		})
+
+		t.Run("MinBatchReached", func(t *testing.T) {
+			t.Parallel()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			clock := newFakeClock()
+
+			blockingQueue := queue.NewBlocking([]int{1, 2}, queue.WithClock(clock))
+
+			batches := make(chan []int, 1)
+
+			go func() {
+				_ = blockingQueue.ConsumeBatches(ctx, 3, 10, time.Second, func(batch []int) error {
+					batches <- batch
+					return nil
+				})
+			}()
+
+			clock.WaitForTimers(t, 1)
+
+			blockingQueue.OfferWait(3)
+
+			if batch := <-batches; !reflect.DeepEqual([]int{1, 2, 3}, batch) {
+				t.Fatalf("expected batch to be %v, got %v", []int{1, 2, 3}, batch)
+			}
+		})
+
+		t.Run("LingerExpiry", func(t *testing.T) {
+			t.Parallel()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			clock := newFakeClock()
+
+			blockingQueue := queue.NewBlocking([]int{1, 2}, queue.WithClock(clock))
+
+			batches := make(chan []int, 1)
+
+			go func() {
+				_ = blockingQueue.ConsumeBatches(ctx, 3, 10, time.Second, func(batch []int) error {
+					batches <- batch
+					return nil
+				})
+			}()
+
+			clock.WaitForTimers(t, 1)
+
+			select {
+			case batch := <-batches:
+				t.Fatalf("received unexpected batch: %v", batch)
+			case <-time.After(time.Millisecond):
+			}
+
+			clock.Advance(time.Second)
+
+			if batch := <-batches; !reflect.DeepEqual([]int{1, 2}, batch) {
+				t.Fatalf("expected batch to be %v, got %v", []int{1, 2}, batch)
+			}
+		})
+
+		t.Run("FnError", func(t *testing.T) {
+			t.Parallel()
+
+			errConsume := errors.New("consume error")
+
+			t.Run("WithoutRequeue", func(t *testing.T) {
+				t.Parallel()
+
+				blockingQueue := queue.NewBlocking([]int{1, 2, 3})
+
+				err := blockingQueue.ConsumeBatches(context.Background(), 1, 2, time.Second, func([]int) error {
+					return errConsume
+				})
+				if !errors.Is(err, errConsume) {
+					t.Fatalf("expected error to be %v, got %v", errConsume, err)
+				}
+
+				if elems := blockingQueue.Clear(); !reflect.DeepEqual([]int{3}, elems) {
+					t.Fatalf("expected elements to be %v, got %v", []int{3}, elems)
+				}
+			})
+
+			t.Run("WithRequeue", func(t *testing.T) {
+				t.Parallel()
+
+				blockingQueue := queue.NewBlocking([]int{1, 2, 3})
+
+				err := blockingQueue.ConsumeBatches(
+					context.Background(),
+					1,
+					2,
+					time.Second,
+					func([]int) error {
+						return errConsume
+					},
+					queue.WithRequeueOnError(),
+				)
+				if !errors.Is(err, errConsume) {
+					t.Fatalf("expected error to be %v, got %v", errConsume, err)
+				}
+
+				if elems := blockingQueue.Clear(); !reflect.DeepEqual([]int{1, 2, 3}, elems) {
+					t.Fatalf("expected elements to be %v, got %v", []int{1, 2, 3}, elems)
+				}
+			})
+		})
+
+		t.Run("CancelMidGather", func(t *testing.T) {
+			t.Parallel()
+
+			ctx, cancel := context.WithCancel(context.Background())
+
+			clock := newFakeClock()
+
+			blockingQueue := queue.NewBlocking([]int{1, 2}, queue.WithClock(clock))
+
+			errCh := make(chan error, 1)
+
+			go func() {
+				errCh <- blockingQueue.ConsumeBatches(ctx, 3, 10, time.Second, func(batch []int) error {
+					t.Errorf("received unexpected batch: %v", batch)
+					return nil
+				})
+			}()
+
+			clock.WaitForTimers(t, 1)
+
+			cancel()
+
+			if err := <-errCh; !errors.Is(err, context.Canceled) {
+				t.Fatalf("expected error to be %v, got %v", context.Canceled, err)
+			}
+
+			if elems := blockingQueue.Clear(); !reflect.DeepEqual([]int{1, 2}, elems) {
+				t.Fatalf("expected elements to be %v, got %v", []int{1, 2}, elems)
+			}
+		})
+	})
+
+	t.Run("Live", func(t *testing.T) {
+		t.Parallel()
+
+		t.Run("Streaming", func(t *testing.T) {
+			t.Parallel()
+
+			ctx, cancel := context.WithCancel(context.Background())
+
+			blockingQueue := queue.NewBlocking([]int{})
+
+			liveCh := blockingQueue.Live(ctx)
+
+			go func() {
+				for i := 1; i <= 3; i++ {
+					blockingQueue.OfferWait(i)
+				}
+			}()
+
+			for i := 1; i <= 3; i++ {
+				if elem := <-liveCh; elem != i {
+					t.Fatalf("expected element to be %d, got %d", i, elem)
+				}
+			}
+
+			cancel()
+
+			// the channel is closed once the feeding goroutine returns.
+			if elem, ok := <-liveCh; ok {
+				t.Fatalf("expected channel to be closed, got %d", elem)
+			}
+
+			if size := blockingQueue.Size(); size != 0 {
+				t.Fatalf("expected size to be 0, got %d", size)
+			}
+		})
+
+		t.Run("CloseAfterDraining", func(t *testing.T) {
+			t.Parallel()
+
+			blockingQueue := queue.NewBlocking([]int{1, 2, 3})
+
+			liveCh := blockingQueue.Live(context.Background())
+
+			blockingQueue.Close()
+
+			var elems []int
+
+			for elem := range liveCh {
+				elems = append(elems, elem)
+			}
+
+			if !reflect.DeepEqual([]int{1, 2, 3}, elems) {
+				t.Fatalf("expected elements to be %v, got %v", []int{1, 2, 3}, elems)
+			}
+		})
+
+		t.Run("CancelRestoresInFlight", func(t *testing.T) {
+			t.Parallel()
+
+			ctx, cancel := context.WithCancel(context.Background())
+
+			blockingQueue := queue.NewBlocking([]int{1, 2})
+
+			liveCh := blockingQueue.Live(ctx)
+
+			// wait for the head to be retrieved without being received.
+			for deadline := time.Now().Add(time.Second); blockingQueue.Size() != 1; {
+				if time.Now().After(deadline) {
+					t.Fatalf("expected the head to be retrieved")
+				}
+
+				time.Sleep(time.Millisecond)
+			}
+
+			cancel()
+
+			if elem, ok := <-liveCh; ok {
+				t.Fatalf("expected channel to be closed, got %d", elem)
+			}
+
+			if elems := blockingQueue.Clear(); !reflect.DeepEqual([]int{1, 2}, elems) {
+				t.Fatalf("expected elements to be %v, got %v", []int{1, 2}, elems)
+			}
+		})
+
+		t.Run("CancelWhileWaiting", func(t *testing.T) {
+			t.Parallel()
+
+			ctx, cancel := context.WithCancel(context.Background())
+
+			blockingQueue := queue.NewBlocking([]int{})
+
+			liveCh := blockingQueue.Live(ctx)
+
+			cancel()
+
+			select {
+			case elem, ok := <-liveCh:
+				if ok {
+					t.Fatalf("expected channel to be closed, got %d", elem)
+				}
+			case <-time.After(time.Second):
+				t.Fatalf("expected channel to be closed")
+			}
+
+			if err := blockingQueue.Offer(1); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+		})
+	})
+
+	t.Run("WithResetCloner", func(t *testing.T) {
+		t.Parallel()
+
+		type elem struct {
+			value int
+		}
+
+		clone := func(e *elem) *elem {
+			c := *e
+
+			return &c
+		}
+
+		t.Run("Cloned", func(t *testing.T) {
+			t.Parallel()
+
+			elems := []*elem{{value: 1}, {value: 2}}
+
+			blockingQueue := queue.NewBlocking(elems, queue.WithResetCloner(clone))
+
+			elems[0].value = 10
+
+			for i := 0; i < 2; i++ {
+				blockingQueue.Reset()
+
+				head, err := blockingQueue.Get()
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+
+				if head.value != 1 {
+					t.Fatalf("expected head value to be %d, got %d", 1, head.value)
+				}
+
+				head.value = 100
+			}
+		})
+
+		t.Run("Shallow", func(t *testing.T) {
+			t.Parallel()
+
+			elems := []*elem{{value: 1}, {value: 2}}
+
+			blockingQueue := queue.NewBlocking(elems)
+
+			elems[0].value = 10
+
+			blockingQueue.Reset()
+
+			head, err := blockingQueue.Get()
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if head.value != 10 {
+				t.Fatalf("expected head value to be %d, got %d", 10, head.value)
+			}
+		})
+
+		t.Run("MismatchedType", func(t *testing.T) {
+			t.Parallel()
+
+			defer func() {
+				if p := recover(); p == nil {
+					t.Fatalf("expected panic")
+				}
+			}()
+
+			_ = queue.NewBlocking([]int{1}, queue.WithResetCloner(clone))
+		})
+	})
+
+	t.Run("Try", func(t *testing.T) {
+		t.Parallel()
+
+		t.Run("Acquired", func(t *testing.T) {
+			t.Parallel()
+
+			blockingQueue := queue.NewBlocking([]int{1})
+
+			if acquired, err := blockingQueue.TryOffer(2); !acquired || err != nil {
+				t.Fatalf("expected lock to be acquired and no error, got %t and %v", acquired, err)
+			}
+
+			elem, acquired, err := blockingQueue.TryPeek()
+			if !acquired || err != nil || elem != 1 {
+				t.Fatalf("expected elem %d, lock acquired and no error, got %d, %t and %v", 1, elem, acquired, err)
+			}
+
+			elem, acquired, err = blockingQueue.TryGet()
+			if !acquired || err != nil || elem != 1 {
+				t.Fatalf("expected elem %d, lock acquired and no error, got %d, %t and %v", 1, elem, acquired, err)
+			}
+		})
+
+		t.Run("Contended", func(t *testing.T) {
+			t.Parallel()
+
+			holder := newLockHolder()
+
+			blockingQueue := queue.NewBlocking([]int{1}, queue.WithResetCloner(holder.Clone))
+
+			holder.Lock(blockingQueue.Reset)
+			defer holder.Unlock()
+
+			if acquired, err := blockingQueue.TryOffer(2); acquired || err != nil {
+				t.Fatalf("expected lock to not be acquired and no error, got %t and %v", acquired, err)
+			}
+
+			if _, acquired, err := blockingQueue.TryPeek(); acquired || err != nil {
+				t.Fatalf("expected lock to not be acquired and no error, got %t and %v", acquired, err)
+			}
+
+			if _, acquired, err := blockingQueue.TryGet(); acquired || err != nil {
+				t.Fatalf("expected lock to not be acquired and no error, got %t and %v", acquired, err)
+			}
+		})
+	})
+
+	t.Run("MarshalJSON", func(t *testing.T) {
+		t.Parallel()
+
+		t.Run("Streamed", func(t *testing.T) {
+			t.Parallel()
+
+			blockingQueue := queue.NewBlocking([]int{1, 2, 3})
+
+			_, _ = blockingQueue.Get()
+
+			marshaled, err := blockingQueue.MarshalJSON()
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			expected, err := json.Marshal([]int{2, 3})
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if !bytes.Equal(expected, marshaled) {
+				t.Fatalf("expected json to be %s, got %s", expected, marshaled)
+			}
+
+			var buf bytes.Buffer
+
+			if err := blockingQueue.MarshalJSONTo(&buf); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if !bytes.Equal(marshaled, buf.Bytes()) {
+				t.Fatalf("expected streamed json to be %s, got %s", marshaled, buf.Bytes())
+			}
+		})
+
+		t.Run("Empty", func(t *testing.T) {
+			t.Parallel()
+
+			blockingQueue := queue.NewBlocking([]int{})
+
+			marshaled, err := json.Marshal(blockingQueue)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if string(marshaled) != "[]" {
+				t.Fatalf("expected json to be [], got %s", marshaled)
+			}
+		})
+	})
+
+	t.Run("UnmarshalJSONFrom", func(t *testing.T) {
+		t.Parallel()
+
+		t.Run("Success", func(t *testing.T) {
+			t.Parallel()
+
+			blockingQueue := queue.NewBlocking([]int{1})
+
+			if err := blockingQueue.UnmarshalJSONFrom(strings.NewReader("[2, 3]")); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			// the elements of the queue are replaced.
+			if elems := blockingQueue.Clear(); !reflect.DeepEqual([]int{2, 3}, elems) {
+				t.Fatalf("expected elements to be %v, got %v", []int{2, 3}, elems)
+			}
+		})
+
+		t.Run("InvalidJSON", func(t *testing.T) {
+			t.Parallel()
+
+			blockingQueue := queue.NewBlocking([]int{})
+
+			if err := blockingQueue.UnmarshalJSONFrom(strings.NewReader(`{"a": 1}`)); err == nil {
+				t.Fatalf("expected error")
+			}
+
+			if err := blockingQueue.UnmarshalJSONFrom(strings.NewReader(`[1, "a"]`)); err == nil {
+				t.Fatalf("expected error")
+			}
+		})
+
+		t.Run("ErrQueueIsFull", func(t *testing.T) {
+			t.Parallel()
+
+			blockingQueue := queue.NewBlocking([]int{1}, queue.WithCapacity(2))
+
+			err := blockingQueue.UnmarshalJSONFrom(strings.NewReader("[2, 3, 4]"))
+			if !errors.Is(err, queue.ErrQueueIsFull) {
+				t.Fatalf("expected error to be %v, got %v", queue.ErrQueueIsFull, err)
+			}
+
+			// none of the elements is inserted.
+			if elems := blockingQueue.Clear(); !reflect.DeepEqual([]int{1}, elems) {
+				t.Fatalf("expected elements to be %v, got %v", []int{1}, elems)
+			}
+		})
+
+		t.Run("ErrQueueIsFullStreaming", func(t *testing.T) {
+			t.Parallel()
+
+			blockingQueue := queue.NewBlocking([]int{1}, queue.WithCapacity(2), queue.WithStreamingJSON())
+
+			err := blockingQueue.UnmarshalJSONFrom(strings.NewReader("[2, 3, 4]"))
+			if !errors.Is(err, queue.ErrQueueIsFull) {
+				t.Fatalf("expected error to be %v, got %v", queue.ErrQueueIsFull, err)
+			}
+
+			// the elements of the queue are removed once the array is opened.
+			if elems := blockingQueue.Clear(); !reflect.DeepEqual([]int{2, 3}, elems) {
+				t.Fatalf("expected elements to be %v, got %v", []int{2, 3}, elems)
+			}
+		})
+	})
+
+	t.Run("WithWaiterPriority", func(t *testing.T) {
+		t.Parallel()
+
+		t.Run("GetYieldsToWaiter", func(t *testing.T) {
+			t.Parallel()
+
+			blockingQueue := queue.NewBlocking([]int{}, queue.WithWaiterPriority())
+
+			elem := make(chan int)
+
+			go func() {
+				elem <- blockingQueue.GetWait()
+			}()
+
+			time.Sleep(time.Millisecond)
+
+			if err := blockingQueue.Offer(1); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if _, err := blockingQueue.Get(); !errors.Is(err, queue.ErrNoElementsAvailable) {
+				t.Fatalf("expected error to be %v, got %v", queue.ErrNoElementsAvailable, err)
+			}
+
+			if _, _, err := blockingQueue.TryGet(); !errors.Is(err, queue.ErrNoElementsAvailable) {
+				t.Fatalf("expected error to be %v, got %v", queue.ErrNoElementsAvailable, err)
+			}
+
+			if e := <-elem; e != 1 {
+				t.Fatalf("expected elem to be %d, got %d", 1, e)
+			}
+
+			if err := blockingQueue.Offer(2); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if e, err := blockingQueue.Get(); err != nil || e != 2 {
+				t.Fatalf("expected elem to be %d and no error, got %d and %v", 2, e, err)
+			}
+		})
+
+		t.Run("FIFOWaiters", func(t *testing.T) {
+			t.Parallel()
+
+			const waiters = 5
+
+			blockingQueue := queue.NewBlocking([]int{}, queue.WithWaiterPriority())
+
+			results := make([]chan int, waiters)
+
+			for i := range results {
+				results[i] = make(chan int, 1)
+
+				go func(i int) {
+					results[i] <- blockingQueue.GetWait()
+				}(i)
+
+				// give the goroutine time to start waiting.
+				time.Sleep(time.Millisecond)
+			}
+
+			for i := 0; i < waiters; i++ {
+				blockingQueue.OfferWait(i)
+			}
+
+			for i := range results {
+				if e := <-results[i]; e != i {
+					t.Fatalf("expected waiter %d to receive %d, got %d", i, i, e)
+				}
+			}
+		})
+	})
+
+	t.Run("OfferUrgent", func(t *testing.T) {
+		t.Parallel()
+
+		t.Run("UrgentFirst", func(t *testing.T) {
+			t.Parallel()
+
+			blockingQueue := queue.NewBlocking([]int{1, 2})
+
+			for _, offer := range []func() error{
+				func() error { return blockingQueue.OfferUrgent(10) },
+				func() error { return blockingQueue.Offer(3) },
+				func() error { return blockingQueue.OfferUrgent(11) },
+			} {
+				if err := offer(); err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+			}
+
+			if !blockingQueue.Contains(11) || !blockingQueue.Contains(3) {
+				t.Fatalf("expected queue to contain both lanes")
+			}
+
+			if e, err := blockingQueue.Peek(); err != nil || e != 10 {
+				t.Fatalf("expected peeked elem to be %d and no error, got %d and %v", 10, e, err)
+			}
+
+			if e := blockingQueue.GetWait(); e != 10 {
+				t.Fatalf("expected elem to be %d, got %d", 10, e)
+			}
+
+			if e, err := blockingQueue.Get(); err != nil || e != 11 {
+				t.Fatalf("expected elem to be %d and no error, got %d and %v", 11, e, err)
+			}
+
+			if err := blockingQueue.OfferUrgent(12); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			expected := []int{12, 1, 2, 3}
+
+			if elems := blockingQueue.Clear(); !reflect.DeepEqual(expected, elems) {
+				t.Fatalf("expected elements to be %v, got %v", expected, elems)
+			}
+		})
+
+		t.Run("Capacity", func(t *testing.T) {
+			t.Parallel()
+
+			blockingQueue := queue.NewBlocking([]int{1, 2}, queue.WithCapacity(3))
+
+			if err := blockingQueue.OfferUrgent(10); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if err := blockingQueue.OfferUrgent(11); !errors.Is(err, queue.ErrQueueIsFull) {
+				t.Fatalf("expected error to be %v, got %v", queue.ErrQueueIsFull, err)
+			}
+
+			if err := blockingQueue.Offer(3); !errors.Is(err, queue.ErrQueueIsFull) {
+				t.Fatalf("expected error to be %v, got %v", queue.ErrQueueIsFull, err)
+			}
+
+			if size := blockingQueue.Size(); size != 3 {
+				t.Fatalf("expected size to be %d, got %d", 3, size)
+			}
+
+			if _, err := blockingQueue.Get(); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if err := blockingQueue.Offer(3); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			blockingQueue.Reset()
+
+			if elems := blockingQueue.Clear(); !reflect.DeepEqual([]int{1, 2}, elems) {
+				t.Fatalf("expected elements to be %v, got %v", []int{1, 2}, elems)
+			}
+		})
+
+		t.Run("Closed", func(t *testing.T) {
+			t.Parallel()
+
+			blockingQueue := queue.NewBlocking([]int{})
+
+			blockingQueue.Close()
+
+			if err := blockingQueue.OfferUrgent(1); !errors.Is(err, queue.ErrQueueClosed) {
+				t.Fatalf("expected error to be %v, got %v", queue.ErrQueueClosed, err)
+			}
+		})
+
+		t.Run("ConcurrentConsumers", func(t *testing.T) {
+			t.Parallel()
+
+			const (
+				consumers = 4
+				perLane   = 500
+				urgent    = 100_000
+			)
+
+			blockingQueue := queue.NewBlocking([]int{})
+
+			// interleave the lanes, urgent elements are offset by urgent.
+			for i := 0; i < perLane; i++ {
+				if err := blockingQueue.Offer(i); err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+
+				if err := blockingQueue.OfferUrgent(urgent + i); err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+			}
+
+			received := make([][]int, consumers)
+
+			var wg sync.WaitGroup
+
+			wg.Add(consumers)
+
+			for c := 0; c < consumers; c++ {
+				go func(c int) {
+					defer wg.Done()
+
+					for {
+						e, err := blockingQueue.Get()
+						if err != nil {
+							return
+						}
+
+						received[c] = append(received[c], e)
+					}
+				}(c)
+			}
+
+			wg.Wait()
+
+			total := 0
+
+			for c, elems := range received {
+				total += len(elems)
+
+				// every consumer observes a subsequence of the removal
+				// order: urgent elements in FIFO order, then normal ones.
+				seenNormal := false
+
+				for i, e := range elems {
+					if e < urgent {
+						seenNormal = true
+					} else if seenNormal {
+						t.Fatalf("consumer %d got urgent elem %d after a normal elem", c, e)
+					}
+
+					if i > 0 && e < elems[i-1] && (e >= urgent) == (elems[i-1] >= urgent) {
+						t.Fatalf("consumer %d got elem %d after %d", c, e, elems[i-1])
+					}
+				}
+			}
+
+			if total != 2*perLane {
+				t.Fatalf("expected %d elements to be received, got %d", 2*perLane, total)
+			}
+		})
+	})
+
+	t.Run("WithLaneRatio", func(t *testing.T) {
+		t.Parallel()
+
+		t.Run("SaturatedUrgentLane", func(t *testing.T) {
+			t.Parallel()
+
+			const (
+				ratio = 4
+				steps = 500
+			)
+
+			// the urgent elements are positive, the other elements negative.
+			blockingQueue := queue.NewBlocking([]int{}, queue.WithLaneRatio(ratio))
+
+			var (
+				// offered holds the step at which each normal element was
+				// offered, eligible the step at which the previous normal
+				// element was retrieved.
+				offered   = map[int]int{}
+				eligible  = 0
+				normal    = 0
+				retrieved = 0
+			)
+
+			for step := 0; step < steps; step++ {
+				// the urgent lane never empties.
+				_ = blockingQueue.OfferUrgent(step + 1)
+				_ = blockingQueue.OfferUrgent(step + 1)
+
+				if step%7 == 0 {
+					normal--
+
+					offered[normal] = step
+
+					_ = blockingQueue.Offer(normal)
+				}
+
+				peeked, err := blockingQueue.Peek()
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+
+				elem := blockingQueue.GetWait()
+
+				if elem != peeked {
+					t.Fatalf("step %d: expected Get to return the peeked %d, got %d", step, peeked, elem)
+				}
+
+				if elem > 0 {
+					continue
+				}
+
+				// the element is eligible once it is offered and the previous
+				// normal element is retrieved.
+				from := max(offered[elem], eligible)
+
+				if waited := step - from; waited > ratio {
+					t.Fatalf("expected element %d to be retrieved within %d retrievals, took %d", elem, ratio+1, waited+1)
+				}
+
+				eligible = step + 1
+
+				retrieved++
+			}
+
+			if retrieved < steps/8 {
+				t.Fatalf("expected at least %d normal elements to be retrieved, got %d", steps/8, retrieved)
+			}
+		})
+
+		t.Run("Disabled", func(t *testing.T) {
+			t.Parallel()
+
+			for _, ratio := range []int{0, -1} {
+				blockingQueue := queue.NewBlocking([]int{-1, -2}, queue.WithLaneRatio(ratio))
+
+				for i := 1; i <= 10; i++ {
+					_ = blockingQueue.OfferUrgent(i)
+				}
+
+				var elems []int
+
+				for !blockingQueue.IsEmpty() {
+					elems = append(elems, blockingQueue.GetWait())
+				}
+
+				expected := []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, -1, -2}
+
+				if !reflect.DeepEqual(expected, elems) {
+					t.Fatalf("ratio %d: expected elements to be %v, got %v", ratio, expected, elems)
+				}
+			}
+		})
+
+		t.Run("Orderings", func(t *testing.T) {
+			t.Parallel()
+
+			blockingQueue := queue.NewBlocking([]int{-1, -2, -3}, queue.WithLaneRatio(2))
+
+			for i := 1; i <= 5; i++ {
+				_ = blockingQueue.OfferUrgent(i)
+			}
+
+			// the snapshots and Clear return the urgent lane first.
+			if elems := blockingQueue.ToSlice(); !reflect.DeepEqual([]int{1, 2, 3, 4, 5, -1, -2, -3}, elems) {
+				t.Fatalf("expected elements to be %v, got %v", []int{1, 2, 3, 4, 5, -1, -2, -3}, elems)
+			}
+
+			errStop := errors.New("stop")
+
+			var batch []int
+
+			err := blockingQueue.ConsumeBatches(context.Background(), 6, 6, time.Second, func(b []int) error {
+				batch = b
+
+				return errStop
+			})
+			if !errors.Is(err, errStop) {
+				t.Fatalf("expected error to be %v, got %v", errStop, err)
+			}
+
+			// the batches follow the ratio.
+			if expected := []int{1, 2, -1, 3, 4, -2}; !reflect.DeepEqual(expected, batch) {
+				t.Fatalf("expected batch to be %v, got %v", expected, batch)
+			}
+
+			if elems := blockingQueue.Clear(); !reflect.DeepEqual([]int{5, -3}, elems) {
+				t.Fatalf("expected elements to be %v, got %v", []int{5, -3}, elems)
+			}
+		})
+
+		t.Run("Reset", func(t *testing.T) {
+			t.Parallel()
+
+			blockingQueue := queue.NewBlocking([]int{-1, -2}, queue.WithLaneRatio(1))
+
+			_ = blockingQueue.OfferUrgent(1)
+
+			if elem := blockingQueue.GetWait(); elem != 1 {
+				t.Fatalf("expected element to be 1, got %d", elem)
+			}
+
+			blockingQueue.Reset()
+
+			_ = blockingQueue.OfferUrgent(2)
+
+			// the streak of urgent elements restarts after the reset.
+			if elem := blockingQueue.GetWait(); elem != 2 {
+				t.Fatalf("expected element to be 2, got %d", elem)
+			}
+
+			if elem := blockingQueue.GetWait(); elem != -1 {
+				t.Fatalf("expected element to be -1, got %d", elem)
+			}
+		})
+	})
+
+	t.Run("WithContextPropagation", func(t *testing.T) {
+		t.Parallel()
+
+		type traceKey struct{}
+
+		propagation := queue.WithContextPropagation(
+			func(ctx context.Context) any {
+				return ctx.Value(traceKey{})
+			},
+			func(ctx context.Context, value any) context.Context {
+				return context.WithValue(ctx, traceKey{}, value)
+			},
+		)
+
+		traced := func(traceID string) context.Context {
+			return context.WithValue(context.Background(), traceKey{}, traceID)
+		}
+
+		t.Run("TraceID", func(t *testing.T) {
+			t.Parallel()
+
+			blockingQueue := queue.NewBlocking([]int{}, propagation)
+
+			go func() {
+				_ = blockingQueue.OfferCtx(traced("trace-1"), 1)
+			}()
+
+			elem, ctx, err := blockingQueue.GetWaitCtx(context.Background())
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if elem != 1 {
+				t.Fatalf("expected element to be 1, got %d", elem)
+			}
+
+			if traceID := ctx.Value(traceKey{}); traceID != "trace-1" {
+				t.Fatalf("expected trace id to be trace-1, got %v", traceID)
+			}
+		})
+
+		t.Run("WithoutContext", func(t *testing.T) {
+			t.Parallel()
+
+			// the urgent elements and the elements offered without a
+			// context carry no value.
+			blockingQueue := queue.NewBlocking([]int{0}, propagation)
+
+			_ = blockingQueue.OfferCtx(traced("trace-1"), 1)
+			_ = blockingQueue.Offer(2)
+			_ = blockingQueue.OfferUrgent(3)
+			_ = blockingQueue.OfferCtx(context.Background(), 4)
+
+			base := context.Background()
+
+			for _, expected := range []struct {
+				elem    int
+				traceID any
+			}{{3, nil}, {0, nil}, {1, "trace-1"}, {2, nil}, {4, nil}} {
+				elem, ctx, err := blockingQueue.GetWaitCtx(base)
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+
+				if elem != expected.elem {
+					t.Fatalf("expected element to be %d, got %d", expected.elem, elem)
+				}
+
+				if expected.traceID == nil && ctx != base {
+					t.Fatalf("expected the base context for element %d", elem)
+				}
+
+				if traceID := ctx.Value(traceKey{}); traceID != expected.traceID {
+					t.Fatalf("expected trace id of element %d to be %v, got %v", elem, expected.traceID, traceID)
+				}
+			}
+		})
+
+		t.Run("WithoutOption", func(t *testing.T) {
+			t.Parallel()
+
+			blockingQueue := queue.NewBlocking([]int{})
+
+			_ = blockingQueue.OfferCtx(traced("trace-1"), 1)
+
+			base := context.Background()
+
+			if _, ctx, _ := blockingQueue.GetWaitCtx(base); ctx != base {
+				t.Fatal("expected the base context")
+			}
+		})
+
+		t.Run("ReusedStorage", func(t *testing.T) {
+			t.Parallel()
+
+			blockingQueue := queue.NewBlocking([]int{}, queue.WithCapacity(3), propagation)
+
+			// the values follow their elements while the storage is compacted.
+			for i := 0; i < 100; i++ {
+				if err := blockingQueue.OfferCtx(traced(fmt.Sprint(i)), i); err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+
+				if i%3 != 2 {
+					continue
+				}
+
+				for j := i - 2; j <= i; j++ {
+					elem, ctx, _ := blockingQueue.GetWaitCtx(context.Background())
+
+					if elem != j || ctx.Value(traceKey{}) != fmt.Sprint(j) {
+						t.Fatalf("expected element %d traced as %d, got %d traced as %v", j, j, elem, ctx.Value(traceKey{}))
+					}
+				}
+			}
+		})
+
+		t.Run("DroppedOnClear", func(t *testing.T) {
+			t.Parallel()
+
+			blockingQueue := queue.NewBlocking([]int{}, propagation)
+
+			collected := make(chan struct{})
+
+			func() {
+				traceID := new([64]byte)
+
+				runtime.SetFinalizer(traceID, func(*[64]byte) {
+					close(collected)
+				})
+
+				ctx := context.WithValue(context.Background(), traceKey{}, traceID)
+
+				_ = blockingQueue.OfferCtx(ctx, 1)
+			}()
+
+			_ = blockingQueue.Clear()
+
+			// the storage of the queue is kept alive.
+			defer runtime.KeepAlive(blockingQueue)
+
+			for i := 0; i < 10; i++ {
+				runtime.GC()
+
+				select {
+				case <-collected:
+					return
+				case <-time.After(10 * time.Millisecond):
+				}
+			}
+
+			t.Fatal("expected the value of the cleared element to be released")
+		})
+
+		t.Run("OfferCtxCancelled", func(t *testing.T) {
+			t.Parallel()
+
+			blockingQueue := queue.NewBlocking([]int{1}, queue.WithCapacity(1))
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			defer cancel()
+
+			if err := blockingQueue.OfferCtx(ctx, 2); !errors.Is(err, context.DeadlineExceeded) {
+				t.Fatalf("expected error to be %v, got %v", context.DeadlineExceeded, err)
+			}
+
+			if elems := blockingQueue.ToSlice(); !reflect.DeepEqual([]int{1}, elems) {
+				t.Fatalf("expected elements to be %v, got %v", []int{1}, elems)
+			}
+		})
+
+		t.Run("GetWaitCtxCancelled", func(t *testing.T) {
+			t.Parallel()
+
+			blockingQueue := queue.NewBlocking([]int{})
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			defer cancel()
+
+			_, elemCtx, err := blockingQueue.GetWaitCtx(ctx)
+			if !errors.Is(err, context.DeadlineExceeded) {
+				t.Fatalf("expected error to be %v, got %v", context.DeadlineExceeded, err)
+			}
+
+			if elemCtx != ctx {
+				t.Fatal("expected the base context")
+			}
+		})
+	})
+
+	t.Run("Ctx", func(t *testing.T) {
+		t.Parallel()
+
+		t.Run("GetCtxCancelled", func(t *testing.T) {
+			t.Parallel()
+
+			blockingQueue := queue.NewBlocking([]int{})
+
+			ctx, cancel := context.WithCancel(context.Background())
+
+			time.AfterFunc(10*time.Millisecond, cancel)
+
+			if _, err := blockingQueue.GetCtx(ctx); !errors.Is(err, context.Canceled) {
+				t.Fatalf("expected error to be %v, got %v", context.Canceled, err)
+			}
+
+			// the cancelled consumer does not take the next element.
+			_ = blockingQueue.Offer(1)
+
+			if elem, err := blockingQueue.Get(); err != nil || elem != 1 {
+				t.Fatalf("expected to get 1, got %d, %v", elem, err)
+			}
+		})
+
+		t.Run("GetCtxElementOffered", func(t *testing.T) {
+			t.Parallel()
+
+			blockingQueue := queue.NewBlocking([]int{})
+
+			time.AfterFunc(10*time.Millisecond, func() { _ = blockingQueue.Offer(1) })
+
+			elem, err := blockingQueue.GetCtx(context.Background())
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if elem != 1 {
+				t.Fatalf("expected elem to be 1, got %d", elem)
+			}
+		})
+
+		t.Run("GetCtxWokenByReset", func(t *testing.T) {
+			t.Parallel()
+
+			blockingQueue := queue.NewBlocking([]int{1})
+
+			_ = blockingQueue.Clear()
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+
+			time.AfterFunc(10*time.Millisecond, blockingQueue.Reset)
+
+			elem, err := blockingQueue.GetCtx(ctx)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if elem != 1 {
+				t.Fatalf("expected elem to be 1, got %d", elem)
+			}
+		})
+
+		t.Run("GetCtxClosed", func(t *testing.T) {
+			t.Parallel()
+
+			blockingQueue := queue.NewBlocking([]int{})
+
+			time.AfterFunc(10*time.Millisecond, blockingQueue.Close)
+
+			if _, err := blockingQueue.GetCtx(context.Background()); !errors.Is(err, queue.ErrQueueClosed) {
+				t.Fatalf("expected error to be %v, got %v", queue.ErrQueueClosed, err)
+			}
+		})
+
+		t.Run("OfferCtxWokenByClear", func(t *testing.T) {
+			t.Parallel()
+
+			blockingQueue := queue.NewBlocking([]int{1}, queue.WithCapacity(1))
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+
+			time.AfterFunc(10*time.Millisecond, func() { _ = blockingQueue.Clear() })
+
+			if err := blockingQueue.OfferCtx(ctx, 2); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if elems := blockingQueue.ToSlice(); !reflect.DeepEqual([]int{2}, elems) {
+				t.Fatalf("expected elements to be %v, got %v", []int{2}, elems)
+			}
+		})
+
+		t.Run("OfferCtxWokenByReset", func(t *testing.T) {
+			t.Parallel()
+
+			blockingQueue := queue.NewBlocking([]int{}, queue.WithCapacity(1))
+
+			_ = blockingQueue.Offer(1)
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+
+			time.AfterFunc(10*time.Millisecond, blockingQueue.Reset)
+
+			if err := blockingQueue.OfferCtx(ctx, 2); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if elems := blockingQueue.ToSlice(); !reflect.DeepEqual([]int{2}, elems) {
+				t.Fatalf("expected elements to be %v, got %v", []int{2}, elems)
+			}
+		})
+
+		t.Run("CancelledWaitersReturn", func(t *testing.T) {
+			t.Parallel()
+
+			const waiters = 100
+
+			blockingQueue := queue.NewBlocking([]int{0}, queue.WithCapacity(1))
+
+			ctx, cancel := context.WithCancel(context.Background())
+
+			var wg sync.WaitGroup
+
+			wg.Add(2 * waiters)
+
+			for i := 0; i < waiters; i++ {
+				go func() {
+					defer wg.Done()
+
+					_ = blockingQueue.OfferCtx(ctx, 1)
+				}()
+
+				go func() {
+					defer wg.Done()
+
+					emptyQueue := queue.NewBlocking([]int{})
+
+					_, _ = emptyQueue.GetCtx(ctx)
+				}()
+			}
+
+			cancel()
+
+			done := make(chan struct{})
+
+			go func() {
+				wg.Wait()
+				close(done)
+			}()
+
+			select {
+			case <-done:
+			case <-time.After(time.Second):
+				t.Fatal("expected the cancelled waiters to return")
+			}
+
+			if size := blockingQueue.Size(); size != 1 {
+				t.Fatalf("expected size to be 1, got %d", size)
+			}
+		})
+	})
+
+	t.Run("WithSpinWait", func(t *testing.T) {
+		t.Parallel()
+
+		t.Run("PingPong", func(t *testing.T) {
+			t.Parallel()
+
+			const elems = 1000
+
+			requests := queue.NewBlocking([]int{}, queue.WithCapacity(1), queue.WithSpinWait(100))
+			responses := queue.NewBlocking([]int{}, queue.WithCapacity(1), queue.WithSpinWait(100))
+
+			go func() {
+				for i := 0; i < elems; i++ {
+					responses.OfferWait(requests.GetWait() + 1)
+				}
+			}()
+
+			for i := 0; i < elems; i++ {
+				if err := requests.OfferWaitErr(i); err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+
+				if elem := responses.PeekWait(); elem != i+1 {
+					t.Fatalf("expected peeked element to be %d, got %d", i+1, elem)
+				}
+
+				if elem := responses.GetWait(); elem != i+1 {
+					t.Fatalf("expected element to be %d, got %d", i+1, elem)
+				}
+			}
+		})
+
+		t.Run("OfferWaitFull", func(t *testing.T) {
+			t.Parallel()
+
+			blockingQueue := queue.NewBlocking([]int{1}, queue.WithCapacity(1), queue.WithSpinWait(10))
+
+			done := make(chan error)
+
+			go func() {
+				done <- blockingQueue.OfferWaitErr(2)
+			}()
+
+			// the producer parks once it is done spinning.
+			time.Sleep(10 * time.Millisecond)
+
+			if elem := blockingQueue.GetWait(); elem != 1 {
+				t.Fatalf("expected element to be 1, got %d", elem)
+			}
+
+			if err := <-done; err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if elem := blockingQueue.GetWait(); elem != 2 {
+				t.Fatalf("expected element to be 2, got %d", elem)
+			}
+		})
+
+		t.Run("LocksOnChange", func(t *testing.T) {
+			t.Parallel()
+
+			blockingQueue := queue.NewBlocking(
+				[]int{},
+				queue.WithSpinWait(1000),
+				queue.WithContentionProfiling(1),
+			)
+
+			done := make(chan int)
+
+			go func() {
+				done <- blockingQueue.GetWait()
+			}()
+
+			// the consumer spins without locking the unchanged queue, then
+			// parks.
+			time.Sleep(10 * time.Millisecond)
+
+			if err := blockingQueue.Offer(1); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if elem := <-done; elem != 1 {
+				t.Fatalf("expected element to be 1, got %d", elem)
+			}
+
+			// the queue is locked again once done spinning, rather than
+			// after every yield.
+			if count := blockingQueue.ContentionProfile()["wait"].Count; count > 2 {
+				t.Fatalf("expected the spinning consumer to lock the queue at most twice, got %d", count)
+			}
+		})
+
+		t.Run("Close", func(t *testing.T) {
+			t.Parallel()
+
+			blockingQueue := queue.NewBlocking([]int{}, queue.WithSpinWait(1<<20))
+
+			done := make(chan int)
+
+			go func() {
+				done <- blockingQueue.GetWait()
+			}()
+
+			// the consumer is woken up while spinning or parked.
+			blockingQueue.Close()
+
+			if elem := <-done; elem != 0 {
+				t.Fatalf("expected the zero value, got %d", elem)
+			}
+		})
+
+		t.Run("ContextDone", func(t *testing.T) {
+			t.Parallel()
+
+			blockingQueue := queue.NewBlocking([]int{}, queue.WithSpinWait(10))
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			defer cancel()
+
+			if _, err := blockingQueue.Poll(ctx, time.Millisecond); !errors.Is(err, context.DeadlineExceeded) {
+				t.Fatalf("expected error to be %v, got %v", context.DeadlineExceeded, err)
+			}
+		})
+	})
+
+	t.Run("ContainsRecent", func(t *testing.T) {
+		t.Parallel()
+
+		t.Run("Window", func(t *testing.T) {
+			t.Parallel()
+
+			blockingQueue := queue.NewBlocking([]int{1, 2, 3, 4, 5})
+
+			testCases := []struct {
+				n        int
+				elem     int
+				expected bool
+			}{
+				{n: 2, elem: 4, expected: true},
+				{n: 2, elem: 3, expected: false},
+				{n: 0, elem: 5, expected: false},
+				{n: 10, elem: 1, expected: true},
+			}
+
+			for _, tc := range testCases {
+				if got := blockingQueue.ContainsRecent(tc.n, tc.elem); got != tc.expected {
+					t.Fatalf("expected ContainsRecent(%d, %d) to be %t", tc.n, tc.elem, tc.expected)
+				}
+			}
+		})
+
+		t.Run("LeftWindow", func(t *testing.T) {
+			t.Parallel()
+
+			blockingQueue := queue.NewBlocking([]int{1, 2, 3})
+
+			if _, err := blockingQueue.Get(); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if blockingQueue.ContainsRecent(3, 1) {
+				t.Fatalf("expected removed element not to be found")
+			}
+
+			if err := blockingQueue.OfferUrgent(9); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if blockingQueue.ContainsRecent(2, 9) {
+				t.Fatalf("expected urgent element to be outside the window")
+			}
+
+			if !blockingQueue.ContainsRecent(3, 9) {
+				t.Fatalf("expected urgent element to be inside the window")
+			}
+		})
+	})
+
+	t.Run("ClearIf", func(t *testing.T) {
+		t.Parallel()
+
+		t.Run("True", func(t *testing.T) {
+			t.Parallel()
+
+			blockingQueue := queue.NewBlocking([]int{1, 2, 3})
+
+			var snapshot queue.ClearSnapshot[int]
+
+			elems, cleared := blockingQueue.ClearIf(func(s queue.ClearSnapshot[int]) bool {
+				snapshot = s
+
+				return s.Size >= 3
+			})
+			if !cleared {
+				t.Fatalf("expected queue to be cleared")
+			}
+
+			expectedSnapshot := queue.ClearSnapshot[int]{Size: 3, Capacity: -1, Head: 1}
+
+			if snapshot != expectedSnapshot {
+				t.Fatalf("expected snapshot to be %+v, got %+v", expectedSnapshot, snapshot)
+			}
+
+			if !reflect.DeepEqual([]int{1, 2, 3}, elems) {
+				t.Fatalf("expected elements to be %v, got %v", []int{1, 2, 3}, elems)
+			}
+
+			if !blockingQueue.IsEmpty() {
+				t.Fatalf("expected queue to be empty")
+			}
+		})
+
+		t.Run("False", func(t *testing.T) {
+			t.Parallel()
+
+			blockingQueue := queue.NewBlocking([]int{1, 2, 3})
+
+			elems, cleared := blockingQueue.ClearIf(func(s queue.ClearSnapshot[int]) bool {
+				return s.Size > 3
+			})
+			if cleared || elems != nil {
+				t.Fatalf("expected queue not to be cleared, got %v and %t", elems, cleared)
+			}
+
+			if size := blockingQueue.Size(); size != 3 {
+				t.Fatalf("expected size to be %d, got %d", 3, size)
+			}
+		})
+
+		t.Run("WakesProducer", func(t *testing.T) {
+			t.Parallel()
+
+			blockingQueue := queue.NewBlocking([]int{1}, queue.WithCapacity(1))
+
+			done := make(chan struct{})
+
+			go func() {
+				defer close(done)
+
+				blockingQueue.OfferWait(2)
+			}()
+
+			// give the producer time to start waiting.
+			time.Sleep(time.Millisecond)
+
+			if _, cleared := blockingQueue.ClearIf(func(s queue.ClearSnapshot[int]) bool {
+				return s.Size == s.Capacity
+			}); !cleared {
+				t.Fatalf("expected queue to be cleared")
+			}
+
+			select {
+			case <-done:
+			case <-time.After(time.Second):
+				t.Fatalf("expected producer to be woken up")
+			}
+
+			if e, err := blockingQueue.Get(); err != nil || e != 2 {
+				t.Fatalf("expected elem to be %d and no error, got %d and %v", 2, e, err)
+			}
+		})
+
+		t.Run("ConcurrentOffers", func(t *testing.T) {
+			t.Parallel()
+
+			const (
+				producers = 4
+				perProd   = 1000
+			)
+
+			blockingQueue := queue.NewBlocking([]int{})
+
+			var wg sync.WaitGroup
+
+			wg.Add(producers)
+
+			for p := 0; p < producers; p++ {
+				go func() {
+					defer wg.Done()
+
+					for i := 0; i < perProd; i++ {
+						_ = blockingQueue.Offer(i)
+					}
+				}()
+			}
+
+			stop := make(chan struct{})
+			total := make(chan int)
+
+			go func() {
+				cleared := 0
+
+				for {
+					select {
+					case <-stop:
+						total <- cleared
+
+						return
+					default:
+					}
+
+					var size int
+
+					elems, ok := blockingQueue.ClearIf(func(s queue.ClearSnapshot[int]) bool {
+						size = s.Size
+
+						return s.Size >= 10
+					})
+					if !ok {
+						continue
+					}
+
+					// the drained elements match the evaluated state.
+					if len(elems) != size {
+						t.Errorf("expected %d elements to be cleared, got %d", size, len(elems))
+					}
+
+					cleared += len(elems)
+				}
+			}()
+
+			wg.Wait()
+			close(stop)
+
+			if got := <-total + blockingQueue.Size(); got != producers*perProd {
+				t.Fatalf("expected %d elements, got %d", producers*perProd, got)
+			}
+		})
+	})
+
+	t.Run("ClearReport", func(t *testing.T) {
+		t.Parallel()
+
+		t.Run("ReleasedProducers", func(t *testing.T) {
+			t.Parallel()
+
+			const producers = 3
+
+			blockingQueue := queue.NewBlocking([]int{1, 2, 3}, queue.WithCapacity(3))
+
+			var wg sync.WaitGroup
+
+			for i := 0; i < producers; i++ {
+				wg.Add(1)
+
+				go func(elem int) {
+					defer wg.Done()
+
+					blockingQueue.OfferWait(elem)
+				}(10 + i)
+			}
+
+			for blockingQueue.BlockedState().BlockedOffers < producers {
+				time.Sleep(time.Millisecond)
+			}
+
+			result := blockingQueue.ClearReport()
+
+			if !reflect.DeepEqual([]int{1, 2, 3}, result.Elements) {
+				t.Fatalf("expected elements to be %v, got %v", []int{1, 2, 3}, result.Elements)
+			}
+
+			if result.ReleasedProducers != producers {
+				t.Fatalf("expected %d released producers, got %d", producers, result.ReleasedProducers)
+			}
+
+			wg.Wait()
+
+			elems := blockingQueue.ToSlice()
+
+			sort.Ints(elems)
+
+			if !reflect.DeepEqual([]int{10, 11, 12}, elems) {
+				t.Fatalf("expected elements to be %v, got %v", []int{10, 11, 12}, elems)
+			}
+		})
+
+		t.Run("NoProducers", func(t *testing.T) {
+			t.Parallel()
+
+			blockingQueue := queue.NewBlocking([]int{1, 2})
+
+			result := blockingQueue.ClearReport()
+
+			if !reflect.DeepEqual([]int{1, 2}, result.Elements) || result.ReleasedProducers != 0 {
+				t.Fatalf("expected elements [1 2] and no released producers, got %+v", result)
+			}
+
+			if !blockingQueue.IsEmpty() {
+				t.Fatalf("expected queue to be empty")
+			}
+		})
+	})
+
+	t.Run("ClearUnsafe", func(t *testing.T) {
+		t.Parallel()
+
+		t.Run("NoAliasing", func(t *testing.T) {
+			t.Parallel()
+
+			blockingQueue := queue.NewBlocking([]int{1, 2, 3}, queue.WithCapacity(4))
+
+			_, _ = blockingQueue.Get()
+			_ = blockingQueue.Offer(4)
+
+			elems := blockingQueue.ClearUnsafe()
+
+			if !reflect.DeepEqual([]int{2, 3, 4}, elems) {
+				t.Fatalf("expected elements to be %v, got %v", []int{2, 3, 4}, elems)
+			}
+
+			// the queue does not write to the returned slice.
+			for i := 10; i < 14; i++ {
+				if err := blockingQueue.Offer(i); err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+			}
+
+			if !reflect.DeepEqual([]int{2, 3, 4}, elems) {
+				t.Fatalf("expected elements to be unchanged, got %v", elems)
+			}
+
+			// the queue does not read from the returned slice.
+			for i := range elems {
+				elems[i] = -1
+			}
+
+			elems = append(elems, -1)
+
+			if queued := blockingQueue.ToSlice(); !reflect.DeepEqual([]int{10, 11, 12, 13}, queued) {
+				t.Fatalf("expected elements to be %v, got %v", []int{10, 11, 12, 13}, queued)
+			}
+
+			if err := blockingQueue.Offer(14); !errors.Is(err, queue.ErrQueueIsFull) {
+				t.Fatalf("expected error to be %v, got %v", queue.ErrQueueIsFull, err)
+			}
+		})
+
+		t.Run("UrgentLane", func(t *testing.T) {
+			t.Parallel()
+
+			blockingQueue := queue.NewBlocking([]int{1, 2})
+
+			_ = blockingQueue.OfferUrgent(0)
+
+			if elems := blockingQueue.ClearUnsafe(); !reflect.DeepEqual([]int{0, 1, 2}, elems) {
+				t.Fatalf("expected elements to be %v, got %v", []int{0, 1, 2}, elems)
+			}
+
+			if !blockingQueue.IsEmpty() {
+				t.Fatalf("expected queue to be empty")
+			}
+		})
+
+		t.Run("Conservation", func(t *testing.T) {
+			t.Parallel()
+
+			blockingQueue := queue.NewBlocking(
+				[]int{1, 2},
+				queue.WithConservationChecks(),
+				queue.WithEnqueueTimes(),
+			)
+
+			_ = blockingQueue.ClearUnsafe()
+
+			_ = blockingQueue.Offer(3)
+
+			if elem, err := blockingQueue.Get(); err != nil || elem != 3 {
+				t.Fatalf("expected elem to be 3, got %d, %v", elem, err)
+			}
+
+			if err := blockingQueue.LedgerCheck(); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+		})
+	})
+
+	t.Run("ResetStrict", func(t *testing.T) {
+		t.Parallel()
+
+		t.Run("RejectsWaitingProducers", func(t *testing.T) {
+			t.Parallel()
+
+			blockingQueue := queue.NewBlocking([]int{1}, queue.WithCapacity(1))
+
+			errs := make(chan error, 2)
+
+			for i := 0; i < 2; i++ {
+				go func() {
+					errs <- blockingQueue.OfferWaitErr(2)
+				}()
+			}
+
+			time.Sleep(time.Millisecond)
+
+			blockingQueue.ResetStrict()
+
+			for i := 0; i < 2; i++ {
+				if err := <-errs; !errors.Is(err, queue.ErrResetWhileWaiting) {
+					t.Fatalf("expected error to be %v, got %v", queue.ErrResetWhileWaiting, err)
+				}
+			}
+
+			if elems := blockingQueue.Clear(); !reflect.DeepEqual([]int{1}, elems) {
+				t.Fatalf("expected elements to be %v, got %v", []int{1}, elems)
+			}
+
+			// producers waiting after the reset are not affected.
+			if err := blockingQueue.OfferWaitErr(3); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+		})
+
+		t.Run("ConsumersServed", func(t *testing.T) {
+			t.Parallel()
+
+			blockingQueue := queue.NewBlocking([]int{1})
+
+			if _, err := blockingQueue.Get(); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			elem := make(chan int, 1)
+
+			go func() {
+				elem <- blockingQueue.GetWait()
+			}()
+
+			time.Sleep(time.Millisecond)
+
+			blockingQueue.ResetStrict()
+
+			select {
+			case e := <-elem:
+				if e != 1 {
+					t.Fatalf("expected elem to be %d, got %d", 1, e)
+				}
+			case <-time.After(time.Second):
+				t.Fatalf("expected consumer to be served")
+			}
+		})
+
+		t.Run("ResetKeepsWaitingProducers", func(t *testing.T) {
+			t.Parallel()
+
+			blockingQueue := queue.NewBlocking([]int{1, 2}, queue.WithCapacity(2))
+
+			errs := make(chan error, 1)
+
+			go func() {
+				errs <- blockingQueue.OfferWaitErr(3)
+			}()
+
+			time.Sleep(time.Millisecond)
+
+			blockingQueue.Reset()
+
+			if _, err := blockingQueue.Get(); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if err := <-errs; err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if elems := blockingQueue.Clear(); !reflect.DeepEqual([]int{2, 3}, elems) {
+				t.Fatalf("expected elements to be %v, got %v", []int{2, 3}, elems)
+			}
+		})
+	})
+
+	t.Run("WithSentinel", func(t *testing.T) {
+		t.Parallel()
+
+		t.Run("MultiConsumerShutdown", func(t *testing.T) {
+			t.Parallel()
+
+			const consumers = 4
+
+			var (
+				sentinel      *int
+				elems         = []*int{new(int), new(int), new(int)}
+				consumed      atomic.Int32
+				sentinelsSeen atomic.Int32
+			)
+
+			blockingQueue := queue.NewBlocking(elems, queue.WithSentinel(sentinel))
+
+			var wg sync.WaitGroup
+
+			wg.Add(consumers)
+
+			for i := 0; i < consumers; i++ {
+				go func() {
+					defer wg.Done()
+
+					for {
+						if elem := blockingQueue.GetWait(); elem == sentinel {
+							sentinelsSeen.Add(1)
+
+							return
+						}
+
+						consumed.Add(1)
+					}
+				}()
+			}
+
+			if err := blockingQueue.OfferSentinel(consumers); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			done := make(chan struct{})
+
+			go func() {
+				wg.Wait()
+				close(done)
+			}()
+
+			select {
+			case <-done:
+			case <-time.After(time.Second):
+				t.Fatalf("expected every consumer to stop")
+			}
+
+			if c := consumed.Load(); c != int32(len(elems)) {
+				t.Fatalf("expected %d elements to be consumed, got %d", len(elems), c)
+			}
+
+			if s := sentinelsSeen.Load(); s != consumers {
+				t.Fatalf("expected %d sentinels to be received, got %d", consumers, s)
+			}
+
+			if err := blockingQueue.Offer(new(int)); !errors.Is(err, queue.ErrQueueClosed) {
+				t.Fatalf("expected error to be %v, got %v", queue.ErrQueueClosed, err)
+			}
+
+			if err := blockingQueue.OfferSentinel(1); !errors.Is(err, queue.ErrQueueClosed) {
+				t.Fatalf("expected error to be %v, got %v", queue.ErrQueueClosed, err)
+			}
+		})
+
+		t.Run("AccidentalOfferRejected", func(t *testing.T) {
+			t.Parallel()
+
+			blockingQueue := queue.NewBlocking([]int{}, queue.WithSentinel(-1))
+
+			offers := map[string]func(int) error{
+				"Offer":       blockingQueue.Offer,
+				"OfferWait":   blockingQueue.OfferWaitErr,
+				"OfferUrgent": blockingQueue.OfferUrgent,
+				"TryOffer": func(elem int) error {
+					_, err := blockingQueue.TryOffer(elem)
+
+					return err
+				},
+			}
+
+			for name, offer := range offers {
+				if err := offer(-1); !errors.Is(err, queue.ErrReservedSentinel) {
+					t.Fatalf("expected %s error to be %v, got %v", name, queue.ErrReservedSentinel, err)
+				}
+			}
+
+			if err := blockingQueue.Offer(1); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if size := blockingQueue.Size(); size != 1 {
+				t.Fatalf("expected size to be 1, got %d", size)
+			}
+		})
+
+		t.Run("WaitsForCapacity", func(t *testing.T) {
+			t.Parallel()
+
+			blockingQueue := queue.NewBlocking(
+				[]int{1, 2},
+				queue.WithCapacity(2),
+				queue.WithSentinel(0),
+			)
+
+			producerErr := make(chan error, 1)
+
+			go func() {
+				producerErr <- blockingQueue.OfferWaitErr(3)
+			}()
+
+			time.Sleep(time.Millisecond)
+
+			sentinelErr := make(chan error, 1)
+
+			go func() {
+				sentinelErr <- blockingQueue.OfferSentinel(2)
+			}()
+
+			// the waiting producer gives up its place to the sentinels.
+			if err := <-producerErr; !errors.Is(err, queue.ErrQueueClosed) {
+				t.Fatalf("expected error to be %v, got %v", queue.ErrQueueClosed, err)
+			}
+
+			select {
+			case err := <-sentinelErr:
+				t.Fatalf("expected OfferSentinel to wait for capacity, got %v", err)
+			case <-time.After(10 * time.Millisecond):
+			}
+
+			received := make([]int, 0, 4)
+
+			for i := 0; i < 4; i++ {
+				received = append(received, blockingQueue.GetWait())
+			}
+
+			if err := <-sentinelErr; err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if expected := []int{1, 2, 0, 0}; !reflect.DeepEqual(expected, received) {
+				t.Fatalf("expected elements to be %v, got %v", expected, received)
+			}
+
+			if _, err := blockingQueue.Get(); !errors.Is(err, queue.ErrQueueClosed) {
+				t.Fatalf("expected error to be %v, got %v", queue.ErrQueueClosed, err)
+			}
+		})
+
+		t.Run("WithoutOption", func(t *testing.T) {
+			t.Parallel()
+
+			defer func() {
+				if r := recover(); r == nil {
+					t.Fatalf("expected panic")
+				}
+			}()
+
+			_ = queue.NewBlocking([]int{}).OfferSentinel(1)
+		})
+
+		t.Run("MismatchedType", func(t *testing.T) {
+			t.Parallel()
+
+			defer func() {
+				if r := recover(); r == nil {
+					t.Fatalf("expected panic")
+				}
+			}()
+
+			queue.NewBlocking([]int{}, queue.WithSentinel("end"))
+		})
+	})
+}
+
+func testResetOnMultipleRoutinesFunc[T comparable](
+	ids []T,
+	totalRoutines int,
+) func(t *testing.T) {
+	// nolint: thelper // not a test helper
+	return func(t *testing.T) {
+		blockingQueue := queue.NewBlocking(ids)
+
+		var wg sync.WaitGroup
+
+		wg.Add(totalRoutines)
+
+		retrievedID := make(chan T, len(ids))
+
+		// we start X number of goroutines where X is the total number
+		// of goroutines to be executed during this test.
+		for routineIdx := 0; routineIdx < totalRoutines; routineIdx++ {
+			go func(k int) {
+				defer wg.Done()
+
+				t.Logf("start routine %d", k)
+
+				var id T
+
+				defer func() {
+					t.Logf("done routine %d, id %v", k, id)
+				}()
+
+				retrievedID <- blockingQueue.GetWait()
+			}(routineIdx)
+		}
+
+		routineCounter := 0
+
+		for range retrievedID {
+			routineCounter++
+
+			t.Logf(
+				"routine counter: %d, refill: %t",
+				routineCounter,
+				routineCounter%len(ids) == 0,
+			)
+
+			if routineCounter == totalRoutines {
+				break
+			}
+
+			if routineCounter%len(ids) == 0 {
+				blockingQueue.Reset()
+			}
+		}
+
+		wg.Wait()
+	}
+}
+
+// TestBlockingContextLeaks is not parallel, since goleak inspects the
+// goroutines of the whole process.
+func TestBlockingContextLeaks(t *testing.T) {
+	t.Run("CancelThenDrop", func(t *testing.T) {
+		defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+		ctx, cancel := context.WithCancel(context.Background())
+
+		blockingQueue := queue.NewBlocking([]int{}, queue.WithCapacity(1), queue.WithContext(ctx))
+
+		var wg sync.WaitGroup
+
+		wg.Add(2)
+
+		go func() {
+			defer wg.Done()
+
+			_ = blockingQueue.GetWait()
+		}()
+
+		if err := blockingQueue.Offer(1); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		go func() {
+			defer wg.Done()
+
+			blockingQueue.OfferWait(2)
+		}()
+
+		cancel()
+
+		// the parked consumer and producer are released.
+		wg.Wait()
+
+		blockingQueue = nil
+
+		runtime.GC()
+	})
+
+	t.Run("DropThenCancel", func(t *testing.T) {
+		defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		// the queue references itself, thus it cannot be finalized, its
+		// element is finalized once the queue is collected.
+		finalized := make(chan struct{})
+
+		elem := new(int)
+
+		runtime.SetFinalizer(elem, func(*int) { close(finalized) })
+
+		blockingQueue := queue.NewBlocking([]*int{}, queue.WithContext(ctx))
+
+		got := make(chan *int)
+
+		// the context references the queue while the consumer waits.
+		go func() {
+			got <- blockingQueue.GetWait()
+		}()
+
+		blockingQueue.OfferWait(elem)
+
+		if retrieved := <-got; retrieved != elem {
+			t.Fatalf("expected elem to be %p, got %p", elem, retrieved)
+		}
+
+		blockingQueue.OfferWait(elem)
+
+		elem, blockingQueue = nil, nil
+
+		deadline := time.After(time.Second)
+
+		for collected := false; !collected; {
+			runtime.GC()
+
+			select {
+			case <-finalized:
+				collected = true
+			case <-deadline:
+				t.Fatal("expected the queue to be collected before its context is done")
+			case <-time.After(time.Millisecond):
+			}
+		}
+
+		// cancelling the context starts a goroutine which returns once done.
+		cancel()
+	})
+
+	t.Run("CloseThenCancel", func(t *testing.T) {
+		defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		blockingQueue := queue.NewBlocking([]int{}, queue.WithContext(ctx))
+
+		// Close unregisters the context, cancelling it starts no goroutine.
+		blockingQueue.Close()
+
+		cancel()
+	})
+}
+
+// TestBlockingAllocs is not parallel, since testing.AllocsPerRun counts the
+// allocations of all the goroutines.
+func TestBlockingAllocs(t *testing.T) {
+	const (
+		capacity = 64
+		ops      = 10 * capacity
+	)
+
+	// newBounded returns a bounded queue holding n elements.
+	newBounded := func(n int) *queue.Blocking[int] {
+		blockingQueue := queue.NewBlocking([]int{}, queue.WithCapacity(capacity))
+
+		for i := 0; i < n; i++ {
+			_ = blockingQueue.Offer(i)
+		}
+
+		return blockingQueue
+	}
+
+	// allocsOf returns the total allocations of ops calls to fn, rather than
+	// their rounded down average, after a warm up call.
+	allocsOf := func(fn func()) float64 {
+		return testing.AllocsPerRun(1, func() {
+			for i := 0; i < ops; i++ {
+				fn()
+			}
+		})
+	}
+
+	t.Run("Offer_Get", func(t *testing.T) {
+		blockingQueue := newBounded(capacity / 2)
+
+		allocs := allocsOf(func() {
+			_ = blockingQueue.Offer(1)
+			_, _ = blockingQueue.Get()
+		})
+
+		if allocs != 0 {
+			t.Fatalf("expected no allocations, got %v", allocs)
+		}
+
+		if size := blockingQueue.Size(); size != capacity/2 {
+			t.Fatalf("expected size to be %d, got %d", capacity/2, size)
+		}
+	})
+
+	t.Run("OfferWait_GetWait", func(t *testing.T) {
+		blockingQueue := newBounded(capacity / 2)
+
+		allocs := allocsOf(func() {
+			blockingQueue.OfferWait(1)
+			_ = blockingQueue.GetWait()
+		})
+
+		if allocs != 0 {
+			t.Fatalf("expected no allocations, got %v", allocs)
+		}
+	})
+
+	t.Run("UnboundedMillion", func(t *testing.T) {
+		const (
+			elements = 1_000_000
+			size     = 10
+		)
+
+		blockingQueue := queue.NewBlocking([]int{})
+
+		// the slots freed by Get are reused, the backing array stops
+		// growing during the warm up.
+		allocs := testing.AllocsPerRun(1, func() {
+			for i := 0; i < elements; i++ {
+				_ = blockingQueue.Offer(i)
+
+				if blockingQueue.Size() == size {
+					_, _ = blockingQueue.Get()
+				}
+			}
+		})
+
+		if allocs != 0 {
+			t.Fatalf("expected no allocations, got %v", allocs)
+		}
+	})
+
+	t.Run("Full", func(t *testing.T) {
+		blockingQueue := newBounded(capacity)
+
+		// the backing array grows once during the warm up, up to twice
+		// the capacity.
+		allocs := allocsOf(func() {
+			_, _ = blockingQueue.Get()
+			_ = blockingQueue.Offer(1)
+		})
+
+		if allocs != 0 {
+			t.Fatalf("expected no allocations, got %v", allocs)
+		}
+	})
+}
+
+func BenchmarkBlockingClear(b *testing.B) {
+	for _, size := range []int{1 << 10, 1 << 14, 1 << 18} {
+		size := size
+
+		for _, unsafe := range []bool{false, true} {
+			unsafe := unsafe
+
+			name := fmt.Sprintf("Clear/%d", size)
+			if unsafe {
+				name = fmt.Sprintf("ClearUnsafe/%d", size)
+			}
+
+			b.Run(name, func(b *testing.B) {
+				blockingQueue := queue.NewBlocking([]int{}, queue.WithCapacity(size))
+
+				b.ReportAllocs()
+				b.ResetTimer()
+
+				for i := 0; i < b.N; i++ {
+					for j := 0; j < size; j++ {
+						_ = blockingQueue.Offer(j)
+					}
+
+					var elems []int
+
+					if unsafe {
+						elems = blockingQueue.ClearUnsafe()
+					} else {
+						elems = blockingQueue.Clear()
+					}
+
+					if len(elems) != size {
+						b.Fatalf("expected %d elements, got %d", size, len(elems))
+					}
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkBlockingQueue(b *testing.B) {
+	b.Run("Offer_Get_HalfFull", func(b *testing.B) {
+		const capacity = 64
+
+		blockingQueue := queue.NewBlocking([]int{}, queue.WithCapacity(capacity))
+
+		for i := 0; i < capacity/2; i++ {
+			_ = blockingQueue.Offer(i)
+		}
+
+		b.ReportAllocs()
+		b.ResetTimer()
+
+		for i := 0; i <= b.N; i++ {
+			_ = blockingQueue.Offer(i)
+
+			_, _ = blockingQueue.Get()
+		}
+	})
+
+	b.Run("Offer_Get_Unbounded", func(b *testing.B) {
+		blockingQueue := queue.NewBlocking([]int{})
+
+		for i := 0; i < 10; i++ {
+			_ = blockingQueue.Offer(i)
+		}
+
+		b.ReportAllocs()
+		b.ResetTimer()
+
+		for i := 0; i <= b.N; i++ {
+			_ = blockingQueue.Offer(i)
+
+			_, _ = blockingQueue.Get()
+		}
+	})
+
+	b.Run("OfferUrgent_Get", func(b *testing.B) {
+		blockingQueue := queue.NewBlocking([]int{})
+
+		for i := 0; i < 10; i++ {
+			_ = blockingQueue.OfferUrgent(i)
+		}
+
+		b.ReportAllocs()
+		b.ResetTimer()
+
+		for i := 0; i <= b.N; i++ {
+			_ = blockingQueue.OfferUrgent(i)
+
+			_, _ = blockingQueue.Get()
+		}
+	})
+
+	b.Run("Peek", func(b *testing.B) {
+		blockingQueue := queue.NewBlocking([]int{1})
+
+		b.ReportAllocs()
+		b.ResetTimer()
+
+		for i := 0; i <= b.N; i++ {
+			_, _ = blockingQueue.Peek()
+		}
+	})
+
+	b.Run("Get_Offer", func(b *testing.B) {
+		blockingQueue := queue.NewBlocking([]int{1})
+
+		b.ReportAllocs()
+		b.ResetTimer()
+
+		for i := 0; i <= b.N; i++ {
+			_, _ = blockingQueue.Get()
+
+			_ = blockingQueue.Offer(1)
+		}
+	})
+
+	b.Run("Offer", func(b *testing.B) {
+		blockingQueue := queue.NewBlocking[int](nil)
+
+		b.ReportAllocs()
+		b.ResetTimer()
+
+		for i := 0; i <= b.N; i++ {
+			_ = blockingQueue.Offer(i)
+		}
+	})
+
+	pingPong := func(b *testing.B, opts ...queue.Option) {
+		b.Helper()
+
+		blockingQueue := queue.NewBlocking([]int{}, opts...)
+
+		done := make(chan struct{})
+
+		go func() {
+			defer close(done)
+
+			for i := 0; i <= b.N; i++ {
+				_ = blockingQueue.GetWait()
+			}
+		}()
+
+		b.ReportAllocs()
+		b.ResetTimer()
+
+		for i := 0; i <= b.N; i++ {
+			blockingQueue.OfferWait(i)
+		}
+
+		<-done
+	}
+
+	b.Run("OfferWait_GetWait", func(b *testing.B) {
+		pingPong(b)
+	})
+
+	b.Run("OfferWait_GetWait_WaiterPriority", func(b *testing.B) {
+		pingPong(b, queue.WithWaiterPriority())
+	})
+
+	// roundTrip measures the latency of an element sent to another goroutine
+	// and sent back, each side waiting for the other.
+	roundTrip := func(b *testing.B, opts ...queue.Option) {
+		b.Helper()
+
+		opts = append(opts, queue.WithCapacity(1))
+
+		requests := queue.NewBlocking([]int{}, opts...)
+		responses := queue.NewBlocking([]int{}, opts...)
+
+		go func() {
+			for i := 0; i <= b.N; i++ {
+				responses.OfferWait(requests.GetWait())
+			}
+		}()
+
+		b.ReportAllocs()
+		b.ResetTimer()
+
+		for i := 0; i <= b.N; i++ {
+			requests.OfferWait(i)
+			_ = responses.GetWait()
+		}
+	}
+
+	b.Run("RoundTrip", func(b *testing.B) {
+		roundTrip(b)
+	})
+
+	b.Run("RoundTrip_SpinWait", func(b *testing.B) {
+		roundTrip(b, queue.WithSpinWait(100))
+	})
+
+	b.Run("OfferWait_GetWait_SpinWait", func(b *testing.B) {
+		pingPong(b, queue.WithSpinWait(100))
+	})
+
+	b.Run("MarshalJSON", func(b *testing.B) {
+		blockingQueue := queue.NewBlocking(make([]int, 1<<16))
+
+		b.ReportAllocs()
+		b.ResetTimer()
+
+		for i := 0; i <= b.N; i++ {
+			_, _ = blockingQueue.MarshalJSON()
+		}
+	})
+
+	b.Run("MarshalJSONTo", func(b *testing.B) {
+		blockingQueue := queue.NewBlocking(make([]int, 1<<16))
+
+		b.ReportAllocs()
+		b.ResetTimer()
+
+		for i := 0; i <= b.N; i++ {
+			_ = blockingQueue.MarshalJSONTo(io.Discard)
+		}
+	})
+
+	// the following benchmarks measure the worst case latency of a peek
+	// while another goroutine repeatedly clears a large queue.
+	concurrentClear := func(b *testing.B, peek func(*queue.Blocking[int])) {
+		b.Helper()
+
+		elems := make([]int, 1<<20)
+
+		blockingQueue := queue.NewBlocking(elems)
+
+		done := make(chan struct{})
+		defer close(done)
+
+		go func() {
+			for {
+				select {
+				case <-done:
+					return
+				default:
+					blockingQueue.Reset()
+					_ = blockingQueue.Clear()
+				}
+			}
+		}()
+
+		var worst time.Duration
+
+		b.ResetTimer()
+
+		for i := 0; i <= b.N; i++ {
+			start := time.Now()
+
+			peek(blockingQueue)
+
+			if elapsed := time.Since(start); elapsed > worst {
+				worst = elapsed
+			}
+		}
+
+		b.ReportMetric(float64(worst.Nanoseconds()), "worst-ns")
+	}
+
+	b.Run("Peek_ConcurrentClear", func(b *testing.B) {
+		concurrentClear(b, func(blockingQueue *queue.Blocking[int]) {
+			_, _ = blockingQueue.Peek()
+		})
+	})
+
+	b.Run("TryPeek_ConcurrentClear", func(b *testing.B) {
+		concurrentClear(b, func(blockingQueue *queue.Blocking[int]) {
+			_, _, _ = blockingQueue.TryPeek()
+		})
+	})
+}
+
+// lockHolder keeps a queue locked by blocking inside the reset cloner,
+// which is called by Reset while holding the queue write lock.
+type lockHolder struct {
+	hold    atomic.Bool
+	entered chan struct{}
+	release chan struct{}
+}
+
+func newLockHolder() *lockHolder {
+	return &lockHolder{
+		entered: make(chan struct{}),
+		release: make(chan struct{}),
+	}
+}
+
+// Clone is the reset cloner to be provided to the queue.
+func (h *lockHolder) Clone(elem int) int {
+	if h.hold.CompareAndSwap(true, false) {
+		close(h.entered)
+		<-h.release
+	}
+
+	return elem
+}
+
+// Lock calls reset in a separate goroutine and returns once the queue lock
+// is held. The queue must have been created with at least one element.
+func (h *lockHolder) Lock(reset func()) {
+	h.hold.Store(true)
+
+	go reset()
+
+	<-h.entered
+}
+
+// Unlock releases the queue lock.
+func (h *lockHolder) Unlock() {
+	close(h.release)
 }
@@ -0,0 +1,228 @@
+package queue
+
+import (
+	"encoding/binary"
+	"hash/maphash"
+	"math"
+	"reflect"
+)
+
+// countingBloom is a counting bloom filter over the elements of a queue,
+// created using the WithBloomFilter option. Each element increments the
+// counters of its cells when inserted and decrements them when removed, so
+// that a cell holding a zero counter proves that no queued element maps to
+// it. A counter reaching its maximum value is never decremented again, which
+// may only cause false positives. A nil filter considers every element
+// possibly queued. The filter is guarded by the queue lock.
+type countingBloom[T comparable] struct {
+	seed     maphash.Seed
+	counters []uint8
+
+	// hashes is the number of cells each element maps to.
+	hashes int
+}
+
+// newCountingBloom returns a filter sized for the expected number of
+// elements and false positive rate provided using WithBloomFilter, or nil if
+// the option is not provided or its arguments are invalid.
+func newCountingBloom[T comparable](opts options) *countingBloom[T] {
+	n, p := float64(opts.bloomExpected), opts.bloomFalsePositiveRate
+
+	if !(n > 0) || !(p > 0 && p < 1) {
+		return nil
+	}
+
+	cells := math.Ceil(-n * math.Log(p) / (math.Ln2 * math.Ln2))
+
+	return &countingBloom[T]{
+		seed:     maphash.MakeSeed(),
+		counters: make([]uint8, int(cells)),
+		hashes:   max(1, int(math.Round(cells/n*math.Ln2))),
+	}
+}
+
+// add accounts for the insertion of the elements.
+func (b *countingBloom[T]) add(elems ...T) {
+	if b == nil {
+		return
+	}
+
+	for _, elem := range elems {
+		h1, h2 := b.hash(elem)
+
+		for i := 0; i < b.hashes; i++ {
+			c := &b.counters[b.cell(h1, h2, i)]
+
+			if *c < math.MaxUint8 {
+				*c++
+			}
+		}
+	}
+}
+
+// remove accounts for the removal of the elements, which must have been
+// added.
+func (b *countingBloom[T]) remove(elems ...T) {
+	if b == nil {
+		return
+	}
+
+	for _, elem := range elems {
+		h1, h2 := b.hash(elem)
+
+		for i := 0; i < b.hashes; i++ {
+			c := &b.counters[b.cell(h1, h2, i)]
+
+			// the saturated counters may undercount the elements.
+			if *c > 0 && *c < math.MaxUint8 {
+				*c--
+			}
+		}
+	}
+}
+
+// mayContain returns false if the element is certainly not queued.
+func (b *countingBloom[T]) mayContain(elem T) bool {
+	if b == nil {
+		return true
+	}
+
+	h1, h2 := b.hash(elem)
+
+	for i := 0; i < b.hashes; i++ {
+		if b.counters[b.cell(h1, h2, i)] == 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// reset zeroes the counters, once the queue holds no elements.
+func (b *countingBloom[T]) reset() {
+	if b == nil {
+		return
+	}
+
+	clear(b.counters)
+}
+
+// cell returns the index of the i-th cell of an element, derived from the
+// two halves of its hash.
+func (b *countingBloom[T]) cell(h1, h2 uint64, i int) int {
+	return int((h1 + uint64(i)*h2) % uint64(len(b.counters)))
+}
+
+// hash returns the two halves of the hash of the element, the second one
+// being odd. Equal elements have equal hashes.
+func (b *countingBloom[T]) hash(elem T) (h1, h2 uint64) {
+	var sum uint64
+
+	switch v := any(elem).(type) {
+	case string:
+		sum = maphash.String(b.seed, v)
+	case int:
+		sum = b.hashUint(uint64(v))
+	case int64:
+		sum = b.hashUint(uint64(v))
+	case int32:
+		sum = b.hashUint(uint64(v))
+	case uint:
+		sum = b.hashUint(uint64(v))
+	case uint64:
+		sum = b.hashUint(v)
+	case uint32:
+		sum = b.hashUint(uint64(v))
+	default:
+		var h maphash.Hash
+
+		h.SetSeed(b.seed)
+
+		writeComparable(&h, reflect.ValueOf(any(elem)))
+
+		sum = h.Sum64()
+	}
+
+	return sum & math.MaxUint32, sum>>32 | 1
+}
+
+func (b *countingBloom[T]) hashUint(v uint64) uint64 {
+	var buf [8]byte
+
+	binary.LittleEndian.PutUint64(buf[:], v)
+
+	return maphash.Bytes(b.seed, buf[:])
+}
+
+// writeComparable writes the value of a comparable type to h, so that equal
+// values, as reported by ==, write the same bytes.
+func writeComparable(h *maphash.Hash, v reflect.Value) {
+	var buf [8]byte
+
+	writeUint := func(u uint64) {
+		binary.LittleEndian.PutUint64(buf[:], u)
+
+		_, _ = h.Write(buf[:])
+	}
+
+	writeFloat := func(f float64) {
+		// -0 equals 0.
+		if f == 0 {
+			f = 0
+		}
+
+		writeUint(math.Float64bits(f))
+	}
+
+	switch v.Kind() {
+	case reflect.Invalid:
+		// a nil interface.
+		_ = h.WriteByte(0)
+	case reflect.Bool:
+		if v.Bool() {
+			_ = h.WriteByte(1)
+		} else {
+			_ = h.WriteByte(0)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		writeUint(uint64(v.Int()))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		writeUint(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		writeFloat(v.Float())
+	case reflect.Complex64, reflect.Complex128:
+		writeFloat(real(v.Complex()))
+		writeFloat(imag(v.Complex()))
+	case reflect.String:
+		_, _ = h.WriteString(v.String())
+	case reflect.Pointer, reflect.Chan, reflect.UnsafePointer:
+		writeUint(uint64(v.Pointer()))
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			writeComparable(h, v.Index(i))
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			// the blank fields are ignored by ==.
+			if v.Type().Field(i).Name == "_" {
+				continue
+			}
+
+			writeComparable(h, v.Field(i))
+		}
+	case reflect.Interface:
+		if v.IsNil() {
+			_ = h.WriteByte(0)
+
+			return
+		}
+
+		elem := v.Elem()
+
+		_, _ = h.WriteString(elem.Type().String())
+
+		writeComparable(h, elem)
+	default:
+		// the other kinds are not comparable, they are never part of T.
+	}
+}
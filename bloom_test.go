@@ -0,0 +1,415 @@
+package queue
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestCountingBloom(t *testing.T) {
+	t.Parallel()
+
+	t.Run("FalsePositiveRate", func(t *testing.T) {
+		t.Parallel()
+
+		const (
+			expected = 10_000
+			target   = 0.01
+			probes   = 100_000
+		)
+
+		b := newCountingBloom[int](options{bloomExpected: expected, bloomFalsePositiveRate: target})
+
+		for i := 0; i < expected; i++ {
+			b.add(i)
+		}
+
+		falsePositives := 0
+
+		for i := expected; i < expected+probes; i++ {
+			if b.mayContain(i) {
+				falsePositives++
+			}
+		}
+
+		if rate := float64(falsePositives) / probes; rate > 2*target {
+			t.Fatalf("expected a false positive rate of at most %v, got %v", 2*target, rate)
+		}
+	})
+
+	t.Run("RemoveAll", func(t *testing.T) {
+		t.Parallel()
+
+		b := newCountingBloom[string](options{bloomExpected: 100, bloomFalsePositiveRate: 0.01})
+
+		for i := 0; i < 100; i++ {
+			b.add(fmt.Sprint(i), fmt.Sprint(i))
+		}
+
+		for i := 0; i < 100; i++ {
+			b.remove(fmt.Sprint(i), fmt.Sprint(i))
+		}
+
+		for i, c := range b.counters {
+			if c != 0 {
+				t.Fatalf("expected counter %d to be 0, got %d", i, c)
+			}
+		}
+	})
+
+	t.Run("Saturated", func(t *testing.T) {
+		t.Parallel()
+
+		b := newCountingBloom[int](options{bloomExpected: 10, bloomFalsePositiveRate: 0.01})
+
+		for i := 0; i < 300; i++ {
+			b.add(1)
+		}
+
+		// the saturated counters are not decremented, so removing the
+		// element more times than the counters count keeps it.
+		for i := 0; i < 299; i++ {
+			b.remove(1)
+		}
+
+		if !b.mayContain(1) {
+			t.Fatal("expected the element to be possibly contained")
+		}
+	})
+
+	t.Run("InvalidOptions", func(t *testing.T) {
+		t.Parallel()
+
+		testCases := map[string]options{
+			"NoOption":               {},
+			"NegativeExpected":       {bloomExpected: -1, bloomFalsePositiveRate: 0.01},
+			"ZeroRate":               {bloomExpected: 10},
+			"RateOfOne":              {bloomExpected: 10, bloomFalsePositiveRate: 1},
+			"NaNRate":                {bloomExpected: 10, bloomFalsePositiveRate: math.NaN()},
+			"RateGreaterThanOne":     {bloomExpected: 10, bloomFalsePositiveRate: 2},
+			"NegativeRate":           {bloomExpected: 10, bloomFalsePositiveRate: -0.5},
+			"NegativeExpectedNoRate": {bloomExpected: -10},
+		}
+
+		for name, opts := range testCases {
+			opts := opts
+
+			t.Run(name, func(t *testing.T) {
+				t.Parallel()
+
+				b := newCountingBloom[int](opts)
+				if b != nil {
+					t.Fatalf("expected no filter, got %+v", b)
+				}
+
+				// a nil filter rules nothing out.
+				b.add(1)
+				b.remove(1)
+				b.reset()
+
+				if !b.mayContain(1) {
+					t.Fatal("expected a nil filter to possibly contain every element")
+				}
+			})
+		}
+	})
+
+	t.Run("EqualElementsHashEqually", func(t *testing.T) {
+		t.Parallel()
+
+		type pair struct {
+			F float64
+			V any
+		}
+
+		ptr := new(int)
+		negZero := math.Copysign(0, -1)
+
+		testCases := map[string][2]any{
+			"NegativeZero":     {negZero, 0.0},
+			"Complex":          {complex(negZero, 1), complex(0, 1)},
+			"Struct":           {pair{F: negZero, V: "a"}, pair{F: 0, V: "a"}},
+			"NestedInterface":  {pair{V: pair{F: negZero}}, pair{V: pair{F: 0}}},
+			"NilInterface":     {pair{}, pair{}},
+			"Array":            {[2]any{1, "a"}, [2]any{1, "a"}},
+			"Pointer":          {ptr, ptr},
+			"Nil":              {nil, nil},
+			"String":           {"a", strings.Repeat("a", 1)},
+			"Int8":             {int8(-1), int8(-1)},
+			"Bool":             {true, true},
+			"InterfaceInArray": {[1]any{negZero}, [1]any{0.0}},
+		}
+
+		b := newCountingBloom[any](options{bloomExpected: 10, bloomFalsePositiveRate: 0.01})
+
+		for name, tc := range testCases {
+			tc := tc
+
+			t.Run(name, func(t *testing.T) {
+				t.Parallel()
+
+				if tc[0] != tc[1] {
+					t.Fatalf("expected %v to equal %v", tc[0], tc[1])
+				}
+
+				h1, h2 := b.hash(tc[0])
+				otherH1, otherH2 := b.hash(tc[1])
+
+				if h1 != otherH1 || h2 != otherH2 {
+					t.Fatalf("expected equal hashes, got %x %x and %x %x", h1, h2, otherH1, otherH2)
+				}
+			})
+		}
+	})
+}
+
+// bloomQueue is implemented by the queues supporting WithBloomFilter.
+type bloomQueue interface {
+	Queue[int]
+	OfferUrgent(elem int) error
+	OfferHandle(elem int) (ElementHandle, error)
+	Exchange(elem int) (int, error)
+	GetN(n int) []int
+	ToSlice() []int
+}
+
+// bloomOf returns the filter of the queue.
+func bloomOf(q bloomQueue) *countingBloom[int] {
+	switch q := q.(type) {
+	case *Blocking[int]:
+		return q.bloom
+	case *Linked[int]:
+		return q.bloom
+	default:
+		panic(fmt.Sprintf("unexpected queue %T", q))
+	}
+}
+
+func TestBloomFilterQueues(t *testing.T) {
+	t.Parallel()
+
+	// a small filter, so that the false positives go through the scan.
+	bloom := WithBloomFilter(16, 0.05)
+
+	initial := []int{1, 2, 3, 2}
+
+	testCases := map[string]func() (bloomQueue, bloomQueue){
+		"Blocking": func() (bloomQueue, bloomQueue) {
+			return NewBlocking(initial, bloom), NewBlocking([]int{}, bloom)
+		},
+		"BlockingBounded": func() (bloomQueue, bloomQueue) {
+			return NewBlocking(initial, bloom, WithCapacity(24)), NewBlocking([]int{}, bloom, WithCapacity(8))
+		},
+		"Linked": func() (bloomQueue, bloomQueue) {
+			return NewLinked(initial, bloom), NewLinked([]int{}, bloom)
+		},
+	}
+
+	for name, newQueues := range testCases {
+		newQueues := newQueues
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			for seed := int64(0); seed < 20; seed++ {
+				q, other := newQueues()
+
+				if bloomOf(q) == nil || bloomOf(other) == nil {
+					t.Fatal("expected the queues to have a bloom filter")
+				}
+
+				assertBloomOracle(t, rand.New(rand.NewSource(seed)), q, other, initial)
+			}
+		})
+	}
+}
+
+// assertBloomOracle runs random operations on q and other, checking after
+// each of them that the filters hold every queued element and that Contains
+// agrees with the counts of the elements kept by the test.
+func assertBloomOracle(t *testing.T, rng *rand.Rand, q, other bloomQueue, initial []int) {
+	t.Helper()
+
+	const (
+		ops    = 2000
+		values = 64
+	)
+
+	counts := map[bloomQueue]map[int]int{
+		q:     make(map[int]int),
+		other: make(map[int]int),
+	}
+
+	for _, elem := range initial {
+		counts[q][elem]++
+	}
+
+	type handle struct {
+		h     ElementHandle
+		queue bloomQueue
+		elem  int
+	}
+
+	var handles []handle
+
+	for op := 0; op < ops; op++ {
+		target, peer := q, other
+		if rng.Intn(3) == 0 {
+			target, peer = other, q
+		}
+
+		c := counts[target]
+		elem := rng.Intn(values)
+
+		switch rng.Intn(13) {
+		case 0, 1:
+			if target.Offer(elem) == nil {
+				c[elem]++
+			}
+		case 2:
+			if target.OfferUrgent(elem) == nil {
+				c[elem]++
+			}
+		case 3, 4:
+			if got, err := target.Get(); err == nil {
+				c[got]--
+			}
+		case 5:
+			for _, got := range target.GetN(rng.Intn(5)) {
+				c[got]--
+			}
+		case 6:
+			got, err := target.Exchange(elem)
+
+			switch {
+			case err == nil:
+				c[got]--
+				c[elem]++
+			case errors.Is(err, ErrNoElementsAvailable):
+				c[elem]++
+			}
+		case 7:
+			if h, err := target.OfferHandle(elem); err == nil {
+				c[elem]++
+
+				handles = append(handles, handle{h: h, queue: target, elem: elem})
+			}
+		case 8:
+			if len(handles) == 0 {
+				continue
+			}
+
+			i := rng.Intn(len(handles))
+
+			if handles[i].h.Cancel() {
+				counts[handles[i].queue][handles[i].elem]--
+			}
+
+			handles = append(handles[:i], handles[i+1:]...)
+		case 9:
+			if got, err := Move[int](target, peer); err == nil {
+				c[got]--
+				counts[peer][got]++
+			}
+		case 10:
+			if got, err := MoveMatching[int](target, peer, func(e int) bool { return e%3 == 0 }); err == nil {
+				c[got]--
+				counts[peer][got]++
+			}
+		case 11:
+			if rng.Intn(4) != 0 {
+				continue
+			}
+
+			reset := rng.Intn(2) == 0
+
+			if reset {
+				target.Reset()
+			} else {
+				target.Clear()
+			}
+
+			clear(c)
+
+			if reset && target == q {
+				for _, e := range initial {
+					c[e]++
+				}
+			}
+		case 12:
+			// saturate the counters of the element.
+			if rng.Intn(20) != 0 {
+				continue
+			}
+
+			for i := 0; i < 300; i++ {
+				if target.Offer(elem) == nil {
+					c[elem]++
+				}
+			}
+		}
+
+		for _, queue := range []bloomQueue{q, other} {
+			filter := bloomOf(queue)
+
+			for _, queued := range queue.ToSlice() {
+				if !filter.mayContain(queued) {
+					t.Fatalf("op %d: false negative for %d", op, queued)
+				}
+			}
+
+			for v := 0; v < values; v++ {
+				if got, want := queue.Contains(v), counts[queue][v] > 0; got != want {
+					t.Fatalf("op %d: expected Contains(%d) to return %v, got %v", op, v, want, got)
+				}
+			}
+		}
+	}
+}
+
+func BenchmarkContainsMiss(b *testing.B) {
+	const size = 300_000
+
+	elems := make([]string, size)
+
+	for i := range elems {
+		elems[i] = fmt.Sprintf("%064d", i)
+	}
+
+	misses := make([]string, 1024)
+
+	for i := range misses {
+		misses[i] = fmt.Sprintf("%064d", size+i)
+	}
+
+	testCases := map[string][]Option{
+		"Scan":        nil,
+		"BloomFilter": {WithBloomFilter(size, 0.01)},
+	}
+
+	for name, opts := range testCases {
+		for _, q := range []struct {
+			name  string
+			queue Queue[string]
+		}{
+			{name: "Blocking", queue: NewBlocking(elems, opts...)},
+			{name: "Linked", queue: NewLinked(elems, opts...)},
+		} {
+			q := q
+
+			b.Run(q.name+"/"+name, func(b *testing.B) {
+				b.ReportAllocs()
+				b.ResetTimer()
+
+				for i := 0; i < b.N; i++ {
+					if q.queue.Contains(misses[i%len(misses)]) {
+						b.Fatal("expected a miss")
+					}
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package queue
+
+// Ensure the queues implement the BulkOfferer interface.
+var (
+	_ BulkOfferer[any] = (*Blocking[any])(nil)
+	_ BulkOfferer[any] = (*Circular[any])(nil)
+	_ BulkOfferer[any] = (*Linked[any])(nil)
+	_ BulkOfferer[any] = (*PriorityAny[any])(nil)
+)
+
+// Ensure the queues implement the BulkGetter interface.
+var (
+	_ BulkGetter[any] = (*Blocking[any])(nil)
+	_ BulkGetter[any] = (*Circular[any])(nil)
+	_ BulkGetter[any] = (*Linked[any])(nil)
+	_ BulkGetter[any] = (*PriorityAny[any])(nil)
+)
+
+// A BulkOfferer is a queue which inserts multiple elements at once.
+type BulkOfferer[T any] interface {
+	// CanOffer returns true if n elements would currently fit into the queue.
+	CanOffer(n int) bool
+
+	// OfferAll inserts all the elements into the queue, or none of them.
+	OfferAll(elems ...T) error
+}
+
+// A BulkGetter is a queue which removes multiple elements at once.
+type BulkGetter[T any] interface {
+	// GetN removes and returns up to n elements from the head of the queue,
+	// in the order in which Get would return them.
+	GetN(n int) []T
+}
+
+// OfferAllOrNothing inserts all the elements into the queue, or none of them.
+// It checks whether the elements fit using CanOffer, returning the
+// ErrQueueIsFull error without locking the queue for writing if they do not,
+// and inserts them using OfferAll, which rejects them all if the queue changed
+// in the meantime.
+func OfferAllOrNothing[T any](q BulkOfferer[T], elems []T) error {
+	if !q.CanOffer(len(elems)) {
+		return ErrQueueIsFull
+	}
+
+	return q.OfferAll(elems...)
+}
+
+// DrainQueue removes and returns up to n elements from the head of the queue,
+// in the order in which Get would return them. It uses GetN if the queue is a
+// BulkGetter, as the queues of this package are, and calls Get until the queue
+// returns an error otherwise.
+func DrainQueue[T comparable](q Queue[T], n int) []T {
+	if b, ok := q.(BulkGetter[T]); ok {
+		return b.GetN(n)
+	}
+
+	elems := make([]T, 0, min(max(n, 0), q.Size()))
+
+	for len(elems) < n {
+		elem, err := q.Get()
+		if err != nil {
+			break
+		}
+
+		elems = append(elems, elem)
+	}
+
+	return elems
+}
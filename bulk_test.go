@@ -0,0 +1,528 @@
+package queue_test
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/adrianbrad/queue"
+)
+
+// bulkQueue is implemented by all the queues.
+type bulkQueue interface {
+	queue.BulkOfferer[int]
+	Offer(int) error
+	Clear() []int
+}
+
+func TestCanOffer(t *testing.T) {
+	t.Parallel()
+
+	lessInt := func(elem, otherElem int) bool { return elem < otherElem }
+
+	boundedCases := map[string]func(capacity int) bulkQueue{
+		"Blocking": func(capacity int) bulkQueue {
+			return queue.NewBlocking([]int{1, 2}, queue.WithCapacity(capacity))
+		},
+		"Priority": func(capacity int) bulkQueue {
+			return queue.NewPriority([]int{1, 2}, lessInt, queue.WithCapacity(capacity))
+		},
+	}
+
+	for name, newQueue := range boundedCases {
+		newQueue := newQueue
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			t.Run("Boundaries", func(t *testing.T) {
+				t.Parallel()
+
+				// 3 remaining slots.
+				q := newQueue(5)
+
+				for n, expected := range map[int]bool{0: true, 1: true, 3: true, 4: false} {
+					if canOffer := q.CanOffer(n); canOffer != expected {
+						t.Fatalf("expected CanOffer(%d) to be %t, got %t", n, expected, canOffer)
+					}
+				}
+			})
+
+			t.Run("OfferAllOrNothing", func(t *testing.T) {
+				t.Parallel()
+
+				q := newQueue(5)
+
+				if err := queue.OfferAllOrNothing[int](q, []int{3, 4, 5, 6}); !errors.Is(err, queue.ErrQueueIsFull) {
+					t.Fatalf("expected error to be %v, got %v", queue.ErrQueueIsFull, err)
+				}
+
+				if err := queue.OfferAllOrNothing[int](q, []int{3, 4, 5}); err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+
+				if elems := q.Clear(); !reflect.DeepEqual([]int{1, 2, 3, 4, 5}, elems) {
+					t.Fatalf("expected elements to be %v, got %v", []int{1, 2, 3, 4, 5}, elems)
+				}
+			})
+
+			t.Run("Advisory", func(t *testing.T) {
+				t.Parallel()
+
+				q := newQueue(4)
+
+				if !q.CanOffer(2) {
+					t.Fatalf("expected 2 elements to fit")
+				}
+
+				// another producer takes the remaining capacity in the meantime.
+				var wg sync.WaitGroup
+
+				wg.Add(1)
+
+				go func() {
+					defer wg.Done()
+
+					_ = q.Offer(3)
+				}()
+
+				wg.Wait()
+
+				if err := q.OfferAll(4, 5); !errors.Is(err, queue.ErrQueueIsFull) {
+					t.Fatalf("expected error to be %v, got %v", queue.ErrQueueIsFull, err)
+				}
+
+				// none of the elements was inserted.
+				if elems := q.Clear(); !reflect.DeepEqual([]int{1, 2, 3}, elems) {
+					t.Fatalf("expected elements to be %v, got %v", []int{1, 2, 3}, elems)
+				}
+			})
+		})
+	}
+
+	unboundedCases := map[string]func() bulkQueue{
+		"BlockingUnbounded": func() bulkQueue {
+			return queue.NewBlocking([]int{1, 2})
+		},
+		"Circular": func() bulkQueue {
+			return queue.NewCircular([]int{1, 2}, 2)
+		},
+		"Linked": func() bulkQueue {
+			return queue.NewLinked([]int{1, 2})
+		},
+		"PriorityUnbounded": func() bulkQueue {
+			return queue.NewPriority([]int{1, 2}, lessInt)
+		},
+	}
+
+	for name, newQueue := range unboundedCases {
+		newQueue := newQueue
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			q := newQueue()
+
+			if !q.CanOffer(1 << 20) {
+				t.Fatalf("expected any number of elements to fit")
+			}
+
+			if err := queue.OfferAllOrNothing[int](q, []int{3, 4}); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+		})
+	}
+
+	t.Run("BlockingClosed", func(t *testing.T) {
+		t.Parallel()
+
+		blockingQueue := queue.NewBlocking([]int{})
+
+		blockingQueue.Close()
+
+		if blockingQueue.CanOffer(1) {
+			t.Fatalf("expected no element to fit into a closed queue")
+		}
+
+		if err := blockingQueue.OfferAll(1); !errors.Is(err, queue.ErrQueueClosed) {
+			t.Fatalf("expected error to be %v, got %v", queue.ErrQueueClosed, err)
+		}
+	})
+
+	t.Run("CircularOverwrites", func(t *testing.T) {
+		t.Parallel()
+
+		circularQueue := queue.NewCircular([]int{}, 2)
+
+		if err := circularQueue.OfferAll(1, 2, 3); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if size := circularQueue.Size(); size != 2 {
+			t.Fatalf("expected size to be 2, got %d", size)
+		}
+	})
+}
+
+func TestGetN(t *testing.T) {
+	t.Parallel()
+
+	lessInt := func(elem, otherElem int) bool { return elem < otherElem }
+
+	// getNQueue is implemented by all the queues removing multiple elements.
+	type getNQueue interface {
+		queue.Queue[int]
+		queue.BulkGetter[int]
+	}
+
+	testCases := map[string]struct {
+		newQueue func() getNQueue
+		expected []int
+	}{
+		"Blocking": {
+			newQueue: func() getNQueue { return queue.NewBlocking([]int{3, 1, 4, 2}) },
+			expected: []int{3, 1, 4, 2},
+		},
+		"Circular": {
+			newQueue: func() getNQueue { return queue.NewCircular([]int{3, 1, 4, 2}, 6) },
+			expected: []int{3, 1, 4, 2},
+		},
+		"Linked": {
+			newQueue: func() getNQueue { return queue.NewLinked([]int{3, 1, 4, 2}) },
+			expected: []int{3, 1, 4, 2},
+		},
+		"Priority": {
+			newQueue: func() getNQueue { return queue.NewPriority([]int{3, 1, 4, 2}, lessInt) },
+			expected: []int{1, 2, 3, 4},
+		},
+	}
+
+	for name, tc := range testCases {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			t.Run("QueueOrder", func(t *testing.T) {
+				t.Parallel()
+
+				q := tc.newQueue()
+
+				if elems := q.GetN(3); !reflect.DeepEqual(tc.expected[:3], elems) {
+					t.Fatalf("expected elements to be %v, got %v", tc.expected[:3], elems)
+				}
+
+				if elems := q.GetN(3); !reflect.DeepEqual(tc.expected[3:], elems) {
+					t.Fatalf("expected elements to be %v, got %v", tc.expected[3:], elems)
+				}
+
+				if elems := q.GetN(3); elems == nil || len(elems) != 0 {
+					t.Fatalf("expected no elements, got %#v", elems)
+				}
+			})
+
+			t.Run("NotPositive", func(t *testing.T) {
+				t.Parallel()
+
+				q := tc.newQueue()
+
+				for _, n := range []int{0, -1} {
+					if elems := q.GetN(n); len(elems) != 0 {
+						t.Fatalf("expected no elements for %d, got %v", n, elems)
+					}
+				}
+
+				if size := q.Size(); size != len(tc.expected) {
+					t.Fatalf("expected size to be %d, got %d", len(tc.expected), size)
+				}
+			})
+
+			t.Run("EquivalentToClear", func(t *testing.T) {
+				t.Parallel()
+
+				q, other := tc.newQueue(), tc.newQueue()
+
+				_, _ = q.Get()
+				_, _ = other.Get()
+
+				_ = q.Offer(5)
+				_ = other.Offer(5)
+
+				if elems, cleared := q.GetN(q.Size()), other.Clear(); !reflect.DeepEqual(cleared, elems) {
+					t.Fatalf("expected elements to be %v, got %v", cleared, elems)
+				}
+			})
+
+			t.Run("DrainQueue", func(t *testing.T) {
+				t.Parallel()
+
+				if elems := queue.DrainQueue[int](tc.newQueue(), 2); !reflect.DeepEqual(tc.expected[:2], elems) {
+					t.Fatalf("expected elements to be %v, got %v", tc.expected[:2], elems)
+				}
+			})
+		})
+	}
+
+	t.Run("BlockingUrgentLane", func(t *testing.T) {
+		t.Parallel()
+
+		blockingQueue := queue.NewBlocking([]int{1, 2})
+
+		_ = blockingQueue.OfferUrgent(3)
+
+		if elems := blockingQueue.GetN(2); !reflect.DeepEqual([]int{3, 1}, elems) {
+			t.Fatalf("expected elements to be %v, got %v", []int{3, 1}, elems)
+		}
+	})
+
+	t.Run("BlockingReservedForWaiters", func(t *testing.T) {
+		t.Parallel()
+
+		blockingQueue := queue.NewBlocking([]int{}, queue.WithWaiterPriority())
+
+		received := make(chan int)
+
+		go func() {
+			received <- blockingQueue.GetWait()
+		}()
+
+		time.Sleep(time.Millisecond)
+
+		// the element is handed to the waiting consumer.
+		_ = blockingQueue.Offer(1)
+
+		if elems := blockingQueue.GetN(1); len(elems) != 0 {
+			t.Fatalf("expected no elements, got %v", elems)
+		}
+
+		if elem := <-received; elem != 1 {
+			t.Fatalf("expected elem to be 1, got %d", elem)
+		}
+	})
+
+	t.Run("DrainWait", func(t *testing.T) {
+		t.Parallel()
+
+		blockingQueue := queue.NewBlocking([]int{})
+
+		drained := make(chan []int)
+
+		go func() {
+			drained <- blockingQueue.DrainWait(5)
+		}()
+
+		time.Sleep(10 * time.Millisecond)
+
+		_ = blockingQueue.OfferAll(1, 2, 3)
+
+		// the waiting consumer may be woken up before all the elements are
+		// inserted, it takes the available ones.
+		elems := <-drained
+
+		if len(elems) == 0 || !reflect.DeepEqual([]int{1, 2, 3}[:len(elems)], elems) {
+			t.Fatalf("expected a prefix of %v, got %v", []int{1, 2, 3}, elems)
+		}
+
+		if size := blockingQueue.Size(); size != 3-len(elems) {
+			t.Fatalf("expected size to be %d, got %d", 3-len(elems), size)
+		}
+	})
+
+	t.Run("DrainWaitAvailable", func(t *testing.T) {
+		t.Parallel()
+
+		blockingQueue := queue.NewBlocking([]int{1, 2, 3})
+
+		if elems := blockingQueue.DrainWait(2); !reflect.DeepEqual([]int{1, 2}, elems) {
+			t.Fatalf("expected elements to be %v, got %v", []int{1, 2}, elems)
+		}
+	})
+
+	t.Run("DrainWaitClosed", func(t *testing.T) {
+		t.Parallel()
+
+		blockingQueue := queue.NewBlocking([]int{})
+
+		time.AfterFunc(10*time.Millisecond, blockingQueue.Close)
+
+		if elems := blockingQueue.DrainWait(2); len(elems) != 0 {
+			t.Fatalf("expected no elements, got %v", elems)
+		}
+	})
+
+	t.Run("DrainQueueFallback", func(t *testing.T) {
+		t.Parallel()
+
+		ch := make(chan int, 3)
+
+		ch <- 1
+		ch <- 2
+		ch <- 3
+
+		if elems := queue.DrainQueue[int](queue.NewFromChannel(ch), 2); !reflect.DeepEqual([]int{1, 2}, elems) {
+			t.Fatalf("expected elements to be %v, got %v", []int{1, 2}, elems)
+		}
+
+		if elems := queue.DrainQueue[int](queue.NewHandle[int](queue.NewFromChannel(ch)), 5); !reflect.DeepEqual([]int{3}, elems) {
+			t.Fatalf("expected elements to be %v, got %v", []int{3}, elems)
+		}
+	})
+}
+
+func TestOfferSome(t *testing.T) {
+	t.Parallel()
+
+	lessInt := func(elem, otherElem int) bool { return elem < otherElem }
+
+	// offerSomeQueue is implemented by all the queues inserting as many
+	// elements as possible.
+	type offerSomeQueue interface {
+		OfferSome(elems ...int) (int, error)
+		Clear() []int
+	}
+
+	boundedCases := map[string]func(capacity int) offerSomeQueue{
+		"Blocking": func(capacity int) offerSomeQueue {
+			return queue.NewBlocking([]int{1, 2}, queue.WithCapacity(capacity))
+		},
+		"Priority": func(capacity int) offerSomeQueue {
+			return queue.NewPriority([]int{1, 2}, lessInt, queue.WithCapacity(capacity))
+		},
+		"BlockingSharedCapacity": func(capacity int) offerSomeQueue {
+			return queue.NewBlocking([]int{1, 2}, queue.WithSharedCapacity(queue.NewCapacityGroup(capacity)))
+		},
+	}
+
+	for name, newQueue := range boundedCases {
+		newQueue := newQueue
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			t.Run("Partial", func(t *testing.T) {
+				t.Parallel()
+
+				// 3 remaining slots.
+				q := newQueue(5)
+
+				n, err := q.OfferSome(3, 4, 5, 6, 7)
+				if !errors.Is(err, queue.ErrQueueIsFull) {
+					t.Fatalf("expected error to be %v, got %v", queue.ErrQueueIsFull, err)
+				}
+
+				if n != 3 {
+					t.Fatalf("expected 3 elements to be inserted, got %d", n)
+				}
+
+				if elems := q.Clear(); !reflect.DeepEqual([]int{1, 2, 3, 4, 5}, elems) {
+					t.Fatalf("expected elements to be %v, got %v", []int{1, 2, 3, 4, 5}, elems)
+				}
+			})
+
+			t.Run("AllFit", func(t *testing.T) {
+				t.Parallel()
+
+				q := newQueue(5)
+
+				if n, err := q.OfferSome(3, 4, 5); err != nil || n != 3 {
+					t.Fatalf("expected 3 elements to be inserted without error, got %d, %v", n, err)
+				}
+
+				if n, err := q.OfferSome(); err != nil || n != 0 {
+					t.Fatalf("expected no element to be inserted without error, got %d, %v", n, err)
+				}
+			})
+		})
+	}
+
+	t.Run("CircularOverwrites", func(t *testing.T) {
+		t.Parallel()
+
+		circularQueue := queue.NewCircular([]int{1}, 3)
+
+		if n, err := circularQueue.OfferSome(2, 3, 4, 5); err != nil || n != 4 {
+			t.Fatalf("expected 4 elements to be inserted without error, got %d, %v", n, err)
+		}
+
+		if size := circularQueue.Size(); size != 3 {
+			t.Fatalf("expected size to be 3, got %d", size)
+		}
+	})
+
+	t.Run("LinkedUnbounded", func(t *testing.T) {
+		t.Parallel()
+
+		linkedQueue := queue.NewLinked([]int{})
+
+		if n, err := linkedQueue.OfferSome(1, 2, 3); err != nil || n != 3 {
+			t.Fatalf("expected 3 elements to be inserted without error, got %d, %v", n, err)
+		}
+	})
+
+	t.Run("BlockingSentinel", func(t *testing.T) {
+		t.Parallel()
+
+		blockingQueue := queue.NewBlocking([]int{}, queue.WithSentinel(-1))
+
+		n, err := blockingQueue.OfferSome(1, -1, 2)
+		if !errors.Is(err, queue.ErrReservedSentinel) {
+			t.Fatalf("expected error to be %v, got %v", queue.ErrReservedSentinel, err)
+		}
+
+		if n != 1 {
+			t.Fatalf("expected 1 element to be inserted, got %d", n)
+		}
+	})
+
+	t.Run("OfferAllWait", func(t *testing.T) {
+		t.Parallel()
+
+		blockingQueue := queue.NewBlocking([]int{}, queue.WithCapacity(2))
+
+		elems := []int{1, 2, 3, 4, 5, 6, 7}
+
+		received := make(chan []int)
+
+		go func() {
+			var got []int
+
+			for len(got) < len(elems) {
+				got = append(got, blockingQueue.GetWait())
+			}
+
+			received <- got
+		}()
+
+		n, err := blockingQueue.OfferAllWait(elems...)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if n != len(elems) {
+			t.Fatalf("expected %d elements to be inserted, got %d", len(elems), n)
+		}
+
+		if got := <-received; !reflect.DeepEqual(elems, got) {
+			t.Fatalf("expected elements to be %v, got %v", elems, got)
+		}
+	})
+
+	t.Run("OfferAllWaitClosed", func(t *testing.T) {
+		t.Parallel()
+
+		blockingQueue := queue.NewBlocking([]int{}, queue.WithCapacity(2))
+
+		time.AfterFunc(10*time.Millisecond, blockingQueue.Close)
+
+		n, err := blockingQueue.OfferAllWait(1, 2, 3)
+		if !errors.Is(err, queue.ErrQueueClosed) {
+			t.Fatalf("expected error to be %v, got %v", queue.ErrQueueClosed, err)
+		}
+
+		if n != 2 {
+			t.Fatalf("expected 2 elements to be inserted, got %d", n)
+		}
+	})
+}
@@ -0,0 +1,122 @@
+package queue
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// exactSizer is implemented by the queues whose Size may be served from the
+// cache of the WithCachedReads option.
+type exactSizer interface {
+	exactSize() int
+}
+
+// exactSize returns the number of elements in the queue, bypassing the
+// cache of the WithCachedReads option.
+func exactSize(q interface{ Size() int }) int {
+	if s, ok := q.(exactSizer); ok {
+		return s.exactSize()
+	}
+
+	return q.Size()
+}
+
+// readCache serves the Size, IsEmpty, Peek, ToSlice and PeekN reads of a
+// queue created with the WithCachedReads option from immutable snapshots of
+// the queue. A snapshot is taken by the first read finding none, and dropped
+// once maxStaleness elapses on the clock, so that the reads in between only
+// load it. The mutations do not drop the snapshots, they age out.
+//
+// The size and the head of the queue are cached apart from its elements, so
+// that the queues read for their size only do not copy their elements.
+type readCache[T any] struct {
+	clock        Clock
+	maxStaleness time.Duration
+
+	head  atomic.Pointer[cachedHead[T]]
+	elems atomic.Pointer[cachedElems[T]]
+}
+
+// cachedHead is the size and the head of a queue, along with the error Peek
+// returned.
+type cachedHead[T any] struct {
+	size int
+	elem T
+	err  error
+}
+
+// cachedElems is a copy of the elements of a queue, in the order in which
+// ToSlice returns them.
+type cachedElems[T any] struct {
+	elems []T
+}
+
+// newReadCache returns the read cache of a queue, or nil if the
+// WithCachedReads option was not provided.
+func newReadCache[T any](opts options) *readCache[T] {
+	if opts.maxStaleness <= 0 {
+		return nil
+	}
+
+	clock := opts.clock
+	if clock == nil {
+		clock = systemClock{}
+	}
+
+	return &readCache[T]{clock: clock, maxStaleness: opts.maxStaleness}
+}
+
+// freshHead returns the cached size and head, or nil if they expired.
+func (c *readCache[T]) freshHead() *cachedHead[T] {
+	return c.head.Load()
+}
+
+// storeHead caches the size and the head of the queue for maxStaleness.
+func (c *readCache[T]) storeHead(size int, elem T, err error) *cachedHead[T] {
+	h := &cachedHead[T]{size: size, elem: elem, err: err}
+
+	c.head.Store(h)
+
+	c.expire(func() { c.head.CompareAndSwap(h, nil) })
+
+	return h
+}
+
+// freshElems returns the cached elements, or nil if they expired.
+func (c *readCache[T]) freshElems() *cachedElems[T] {
+	return c.elems.Load()
+}
+
+// storeElems caches the elements of the queue for maxStaleness. The
+// elements must not be modified afterwards.
+func (c *readCache[T]) storeElems(elems []T) *cachedElems[T] {
+	e := &cachedElems[T]{elems: elems}
+
+	c.elems.Store(e)
+
+	c.expire(func() { c.elems.CompareAndSwap(e, nil) })
+
+	return e
+}
+
+// expire calls drop once maxStaleness elapses on the clock.
+func (c *readCache[T]) expire(drop func()) {
+	timer := c.clock.NewTimer(c.maxStaleness)
+
+	go func() {
+		<-timer.C()
+
+		drop()
+	}()
+}
+
+// copyN returns a copy of at most n of the cached elements, from the head.
+func (e *cachedElems[T]) copyN(n int) []T {
+	n = min(max(n, 0), len(e.elems))
+
+	elems := make([]T, n)
+
+	copy(elems, e.elems)
+
+	return elems
+}
@@ -0,0 +1,225 @@
+package queue_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/adrianbrad/queue"
+)
+
+func TestWithCachedReads(t *testing.T) {
+	t.Parallel()
+
+	lessInt := func(elem, otherElem int) bool { return elem < otherElem }
+
+	type cachedQueue interface {
+		queue.Queue[int]
+		OfferAll(elems ...int) error
+		ToSlice() []int
+		PeekN(n int) []int
+	}
+
+	testCases := map[string]func(elems []int, opts ...queue.Option) cachedQueue{
+		"Blocking": func(elems []int, opts ...queue.Option) cachedQueue {
+			return queue.NewBlocking(elems, opts...)
+		},
+		"Circular": func(elems []int, opts ...queue.Option) cachedQueue {
+			return queue.NewCircular(elems, 8, opts...)
+		},
+		"Linked": func(elems []int, opts ...queue.Option) cachedQueue {
+			return queue.NewLinked(elems, opts...)
+		},
+		"Priority": func(elems []int, opts ...queue.Option) cachedQueue {
+			return queue.NewPriority(elems, lessInt, opts...)
+		},
+		"PriorityAny": func(elems []int, opts ...queue.Option) cachedQueue {
+			return queue.NewPriorityAny(elems, lessInt, opts...)
+		},
+	}
+
+	for impl, newQueue := range testCases {
+		impl, newQueue := impl, newQueue
+
+		t.Run(impl, func(t *testing.T) {
+			t.Parallel()
+
+			t.Run("StaleWithinWindow", func(t *testing.T) {
+				t.Parallel()
+
+				clock := newFakeClock()
+
+				q := newQueue(
+					[]int{1, 2},
+					queue.WithClock(clock),
+					queue.WithCachedReads(100*time.Millisecond),
+				)
+
+				assertReads(t, q, []int{1, 2})
+
+				if _, err := q.Get(); err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+
+				if err := q.OfferAll(3, 4); err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+
+				clock.Advance(100*time.Millisecond - time.Nanosecond)
+
+				// the snapshots taken before the mutations are still served.
+				assertReads(t, q, []int{1, 2})
+
+				// PriorityAny has no equality function to look elements up.
+				if impl != "PriorityAny" && !q.Contains(3) {
+					t.Fatal("expected Contains to read the current elements")
+				}
+
+				clock.Advance(time.Nanosecond)
+
+				waitForReads(t, q, []int{2, 3, 4})
+			})
+
+			t.Run("Empty", func(t *testing.T) {
+				t.Parallel()
+
+				clock := newFakeClock()
+
+				q := newQueue(
+					[]int{},
+					queue.WithClock(clock),
+					queue.WithCachedReads(time.Second),
+				)
+
+				if _, err := q.Peek(); !errors.Is(err, queue.ErrNoElementsAvailable) {
+					t.Fatalf("expected error to be %v, got %v", queue.ErrNoElementsAvailable, err)
+				}
+
+				if err := q.Offer(1); err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+
+				if !q.IsEmpty() {
+					t.Fatal("expected the cached empty state to be served")
+				}
+
+				if _, err := q.Peek(); !errors.Is(err, queue.ErrNoElementsAvailable) {
+					t.Fatalf("expected error to be %v, got %v", queue.ErrNoElementsAvailable, err)
+				}
+
+				clock.Advance(time.Second)
+
+				waitForReads(t, q, []int{1})
+			})
+
+			t.Run("CopiesOwnedByCaller", func(t *testing.T) {
+				t.Parallel()
+
+				q := newQueue([]int{1, 2}, queue.WithCachedReads(time.Hour))
+
+				elems := q.ToSlice()
+				elems[0] = 10
+
+				head := q.PeekN(1)
+				head[0] = 20
+
+				assertReads(t, q, []int{1, 2})
+			})
+		})
+	}
+
+	t.Run("Disabled", func(t *testing.T) {
+		t.Parallel()
+
+		blockingQueue := queue.NewBlocking([]int{1}, queue.WithCachedReads(0))
+
+		_ = blockingQueue.Offer(2)
+
+		if size := blockingQueue.Size(); size != 2 {
+			t.Fatalf("expected size to be 2, got %d", size)
+		}
+	})
+}
+
+// readsQueue is a queue whose reads may be cached.
+type readsQueue interface {
+	queue.Queue[int]
+	ToSlice() []int
+	PeekN(n int) []int
+}
+
+// waitForReads waits for the expired snapshots of the queue to be dropped,
+// which happens asynchronously once the clock is advanced, and checks the
+// results of the reads against the expected elements.
+func waitForReads(t *testing.T, q readsQueue, expected []int) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+
+	for time.Now().Before(deadline) {
+		if q.Size() == len(expected) && reflect.DeepEqual(expected, q.ToSlice()) {
+			break
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+
+	assertReads(t, q, expected)
+}
+
+// assertReads checks the results of the cached reads of the queue against
+// the expected elements.
+func assertReads(t *testing.T, q readsQueue, expected []int) {
+	t.Helper()
+
+	if size := q.Size(); size != len(expected) {
+		t.Fatalf("expected size to be %d, got %d", len(expected), size)
+	}
+
+	if empty := q.IsEmpty(); empty != (len(expected) == 0) {
+		t.Fatalf("expected IsEmpty to be %t, got %t", len(expected) == 0, empty)
+	}
+
+	if head, err := q.Peek(); err != nil || head != expected[0] {
+		t.Fatalf("expected head to be %d, got %d (error %v)", expected[0], head, err)
+	}
+
+	if elems := q.ToSlice(); !reflect.DeepEqual(expected, elems) {
+		t.Fatalf("expected elements to be %v, got %v", expected, elems)
+	}
+
+	if elems := q.PeekN(1); !reflect.DeepEqual(expected[:1], elems) {
+		t.Fatalf("expected the first element to be %v, got %v", expected[:1], elems)
+	}
+}
+
+func BenchmarkCachedReads(b *testing.B) {
+	elems := make([]int, 1024)
+
+	b.Run("Size", func(b *testing.B) {
+		blockingQueue := queue.NewBlocking(elems)
+
+		b.ReportAllocs()
+		b.ResetTimer()
+
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				_ = blockingQueue.Size()
+			}
+		})
+	})
+
+	b.Run("CachedSize", func(b *testing.B) {
+		blockingQueue := queue.NewBlocking(elems, queue.WithCachedReads(100*time.Millisecond))
+
+		b.ReportAllocs()
+		b.ResetTimer()
+
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				_ = blockingQueue.Size()
+			}
+		})
+	})
+}
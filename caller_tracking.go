@@ -0,0 +1,230 @@
+package queue
+
+import (
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// trackedOperations is the number of operations recorded by a queue created
+// using the WithCallerTracking option, the older ones being overwritten.
+const trackedOperations = 64
+
+// trackedPackageFrames is the number of frames of the queue package
+// captured, in addition to the configured depth, before the caller frames.
+const trackedPackageFrames = 16
+
+// trackedPackage prefixes the names of the functions of the queue package.
+var trackedPackage = reflect.TypeOf(OpRecord{}).PkgPath() + "."
+
+// OpRecord describes a mutating operation of a queue, as recorded by the
+// WithCallerTracking option.
+type OpRecord struct {
+	// Op is the name of the queue method called from outside the queue
+	// package, such as "Offer" or "Clear".
+	Op string
+
+	// Time is the time at which the operation was performed, on the clock of
+	// the queue.
+	Time time.Time
+
+	// Element is the summary of the element inserted by the operation, or
+	// removed by the operations which do not insert any, as returned by the
+	// function provided using WithOperationSummary. It is empty if no such
+	// function is provided and for the operations affecting all the elements.
+	Element string
+
+	// Callers holds the frames of the goroutine which performed the
+	// operation, starting with the caller of the queue method, up to the
+	// depth provided using WithCallerTracking.
+	Callers []runtime.Frame
+}
+
+// callerTracker records the last mutating operations of a queue, along with
+// the stacks of their callers, in a fixed ring. A nil callerTracker records
+// nothing.
+type callerTracker[T any] struct {
+	lock sync.Mutex
+
+	depth   int
+	clock   Clock
+	summary func(T) string
+
+	// records is the ring of operations, next is the index of the slot
+	// written by the next operation and count the number of operations
+	// recorded, up to the length of the ring.
+	records []trackedOperation
+	next    int
+	count   int
+}
+
+// trackedOperation is an operation recorded in the ring, its caller stack
+// being resolved once the operations are retrieved.
+type trackedOperation struct {
+	time    time.Time
+	element string
+
+	// pcs holds the program counters of the stack, starting with the queue
+	// method, its backing array being reused by the following operations.
+	pcs []uintptr
+}
+
+// newCallerTracker returns the tracker configured by the WithCallerTracking
+// option, or nil if the option was not provided. It panics if the summary
+// function provided using WithOperationSummary does not match the queue
+// element type.
+func newCallerTracker[T any](opts options) *callerTracker[T] {
+	if opts.callerDepth <= 0 {
+		return nil
+	}
+
+	var summary func(T) string
+
+	if opts.operationSummary != nil {
+		s, ok := opts.operationSummary.(func(T) string)
+		if !ok {
+			panic("operation summary func type does not match the queue element type")
+		}
+
+		summary = s
+	}
+
+	clock := opts.clock
+	if clock == nil {
+		clock = systemClock{}
+	}
+
+	records := make([]trackedOperation, trackedOperations)
+
+	for i := range records {
+		records[i].pcs = make([]uintptr, opts.callerDepth+trackedPackageFrames)
+	}
+
+	return &callerTracker[T]{
+		depth:   opts.callerDepth,
+		clock:   clock,
+		summary: summary,
+		records: records,
+	}
+}
+
+// record records an operation inserting or removing the element.
+// It must be called by the queue method performing the operation.
+func (t *callerTracker[T]) record(elem T) {
+	if t == nil {
+		return
+	}
+
+	var element string
+
+	if t.summary != nil {
+		element = t.summary(elem)
+	}
+
+	t.capture(element)
+}
+
+// recordResult records an operation inserting or removing the element,
+// unless err is not nil, and returns err.
+// It must be called by the queue method performing the operation.
+func (t *callerTracker[T]) recordResult(elem T, err error) error {
+	if t == nil || err != nil {
+		return err
+	}
+
+	var element string
+
+	if t.summary != nil {
+		element = t.summary(elem)
+	}
+
+	t.capture(element)
+
+	return nil
+}
+
+// recordBulk records an operation affecting all the elements of the queue.
+// It must be called by the queue method performing the operation.
+func (t *callerTracker[T]) recordBulk() {
+	if t == nil {
+		return
+	}
+
+	t.capture("")
+}
+
+// capture writes the operation to the next slot of the ring.
+func (t *callerTracker[T]) capture(element string) {
+	// capture, record or recordResult or recordBulk.
+	const skip = 3
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	r := &t.records[t.next]
+
+	r.time = t.clock.Now()
+	r.element = element
+	r.pcs = r.pcs[:runtime.Callers(skip, r.pcs[:cap(r.pcs)])]
+
+	t.next = (t.next + 1) % len(t.records)
+	t.count = min(t.count+1, len(t.records))
+}
+
+// operations returns the recorded operations, oldest first, or nil if the
+// tracker is nil.
+func (t *callerTracker[T]) operations() []OpRecord {
+	if t == nil {
+		return nil
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	ops := make([]OpRecord, 0, t.count)
+
+	for i := 0; i < t.count; i++ {
+		r := t.records[(t.next-t.count+i+len(t.records))%len(t.records)]
+
+		op, callers := t.resolve(r.pcs)
+
+		ops = append(ops, OpRecord{
+			Op:      op,
+			Time:    r.time,
+			Element: r.element,
+			Callers: callers,
+		})
+	}
+
+	return ops
+}
+
+// resolve returns the name of the outermost queue method of the stack,
+// which is the method called from outside the queue package, and the frames
+// of its callers, trimmed to the tracker depth.
+func (t *callerTracker[T]) resolve(pcs []uintptr) (string, []runtime.Frame) {
+	var (
+		op      string
+		callers []runtime.Frame
+	)
+
+	frames := runtime.CallersFrames(pcs)
+
+	for more := len(pcs) > 0; more && len(callers) < t.depth; {
+		var frame runtime.Frame
+
+		frame, more = frames.Next()
+
+		if len(callers) == 0 && strings.HasPrefix(frame.Function, trackedPackage) {
+			op = methodName(frame.Function)
+
+			continue
+		}
+
+		callers = append(callers, frame)
+	}
+
+	return op, callers
+}
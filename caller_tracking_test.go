@@ -0,0 +1,220 @@
+package queue_test
+
+import (
+	"context"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/adrianbrad/queue"
+)
+
+// trackedQueue is implemented by the queues recording their operations.
+type trackedQueue interface {
+	queue.Queue[int]
+	OfferAll(elems ...int) error
+	Poll(ctx context.Context, interval time.Duration) (int, error)
+	RecentOperations() []queue.OpRecord
+}
+
+func newTrackedQueues() map[string]func(elems []int, opts ...queue.Option) trackedQueue {
+	return map[string]func(elems []int, opts ...queue.Option) trackedQueue{
+		"Blocking": func(elems []int, opts ...queue.Option) trackedQueue {
+			return queue.NewBlocking(elems, opts...)
+		},
+		"Circular": func(elems []int, opts ...queue.Option) trackedQueue {
+			return queue.NewCircular(elems, 100, opts...)
+		},
+		"Linked": func(elems []int, opts ...queue.Option) trackedQueue {
+			return queue.NewLinked(elems, opts...)
+		},
+		"Priority": func(elems []int, opts ...queue.Option) trackedQueue {
+			return queue.NewPriority(elems, func(elem, otherElem int) bool { return elem < otherElem }, opts...)
+		},
+	}
+}
+
+func TestCallerTracking(t *testing.T) {
+	t.Parallel()
+
+	summary := queue.WithOperationSummary(strconv.Itoa)
+
+	for name, newQueue := range newTrackedQueues() {
+		newQueue := newQueue
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			t.Run("ScriptedSequence", func(t *testing.T) {
+				t.Parallel()
+
+				clock := newFakeClock()
+
+				q := newQueue([]int{1}, queue.WithCallerTracking(4), queue.WithClock(clock), summary)
+
+				_ = q.Offer(2)
+
+				clock.Advance(time.Second)
+
+				_ = q.OfferAll(3, 4)
+				_, _ = q.Get()
+				_, _ = q.Poll(context.Background(), time.Millisecond)
+				_ = q.Clear()
+				q.Reset()
+
+				// the failed operations are not recorded.
+				_, _ = q.Get()
+				_, _ = q.Get()
+
+				type op struct {
+					name, element string
+				}
+
+				expected := []op{
+					{"Offer", "2"}, {"OfferAll", ""}, {"Get", "1"}, {"Poll", "2"},
+					{"Clear", ""}, {"Reset", ""}, {"Get", "1"},
+				}
+
+				records := q.RecentOperations()
+
+				actual := make([]op, 0, len(records))
+
+				for _, record := range records {
+					actual = append(actual, op{record.Op, record.Element})
+				}
+
+				if !reflect.DeepEqual(expected, actual) {
+					t.Fatalf("expected operations to be %v, got %v", expected, actual)
+				}
+
+				if !records[0].Time.Equal(clock.Now().Add(-time.Second)) || !records[1].Time.Equal(clock.Now()) {
+					t.Fatalf("expected the times to be read from the queue clock, got %v and %v", records[0].Time, records[1].Time)
+				}
+
+				for _, record := range records {
+					if len(record.Callers) == 0 || !strings.Contains(record.Callers[0].Function, "TestCallerTracking") {
+						t.Fatalf("expected the caller of %s to be the test function, got %v", record.Op, record.Callers)
+					}
+
+					if len(record.Callers) > 4 {
+						t.Fatalf("expected at most 4 callers, got %d", len(record.Callers))
+					}
+				}
+			})
+
+			t.Run("RingOverwritesOldest", func(t *testing.T) {
+				t.Parallel()
+
+				q := newQueue(nil, queue.WithCallerTracking(1), summary)
+
+				for i := 0; i < 70; i++ {
+					_ = q.Offer(i)
+				}
+
+				records := q.RecentOperations()
+
+				if len(records) != 64 {
+					t.Fatalf("expected 64 records, got %d", len(records))
+				}
+
+				if first, last := records[0].Element, records[63].Element; first != "6" || last != "69" {
+					t.Fatalf("expected the records to range from 6 to 69, got %s to %s", first, last)
+				}
+			})
+
+			t.Run("Disabled", func(t *testing.T) {
+				t.Parallel()
+
+				q := newQueue([]int{1})
+
+				_ = q.Offer(2)
+
+				if records := q.RecentOperations(); records != nil {
+					t.Fatalf("expected no records, got %v", records)
+				}
+			})
+
+			t.Run("MismatchedSummary", func(t *testing.T) {
+				t.Parallel()
+
+				defer func() {
+					if r := recover(); r == nil {
+						t.Fatalf("expected panic")
+					}
+				}()
+
+				newQueue(nil, queue.WithCallerTracking(1), queue.WithOperationSummary(func(string) string { return "" }))
+			})
+		})
+	}
+
+	t.Run("Cancel", func(t *testing.T) {
+		t.Parallel()
+
+		blockingQueue := queue.NewBlocking([]int{}, queue.WithCallerTracking(1))
+
+		handle, _ := blockingQueue.OfferHandle(1)
+
+		handle.Cancel()
+
+		records := blockingQueue.RecentOperations()
+
+		if len(records) != 2 || records[0].Op != "OfferHandle" || records[1].Op != "Cancel" {
+			t.Fatalf("expected OfferHandle and Cancel to be recorded, got %v", records)
+		}
+	})
+
+	t.Run("ThroughHandle", func(t *testing.T) {
+		t.Parallel()
+
+		blockingQueue := queue.NewBlocking([]int{1}, queue.WithCallerTracking(1))
+
+		handle := queue.NewHandle[int](blockingQueue)
+
+		_, _ = handle.Get()
+
+		// the frames of the queue package are trimmed.
+		records := blockingQueue.RecentOperations()
+
+		if len(records) != 1 || !strings.Contains(records[0].Callers[0].Function, "TestCallerTracking") {
+			t.Fatalf("expected the caller of Handle.Get to be recorded, got %v", records)
+		}
+	})
+}
+
+func BenchmarkCallerTrackingDisabled(b *testing.B) {
+	blockingQueue := queue.NewBlocking([]int{}, queue.WithCapacity(1))
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		_ = blockingQueue.Offer(i)
+		_, _ = blockingQueue.Get()
+	}
+
+	b.StopTimer()
+
+	allocs := testing.AllocsPerRun(100, func() {
+		_ = blockingQueue.Offer(1)
+		_, _ = blockingQueue.Get()
+	})
+
+	if allocs != 0 {
+		b.Fatalf("expected no allocations per op, got %.0f", allocs)
+	}
+}
+
+func BenchmarkCallerTrackingEnabled(b *testing.B) {
+	blockingQueue := queue.NewBlocking([]int{}, queue.WithCapacity(1), queue.WithCallerTracking(8))
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		_ = blockingQueue.Offer(i)
+		_, _ = blockingQueue.Get()
+	}
+}
@@ -0,0 +1,193 @@
+package queue
+
+import (
+	"strings"
+)
+
+// Ensure the queue implementations satisfy the capability interfaces.
+var (
+	_ Waiter[any] = (*Blocking[any])(nil)
+	_ Waiter[any] = (*Priority[any])(nil)
+	_ Waiter[any] = (*PriorityAny[any])(nil)
+	_ Closer      = (*Blocking[any])(nil)
+
+	_ Bounded = (*Blocking[any])(nil)
+	_ Bounded = (*Circular[any])(nil)
+	_ Bounded = (*Priority[any])(nil)
+	_ Bounded = (*PriorityAny[any])(nil)
+	_ Bounded = (*ChanQueue[any])(nil)
+
+	_ Drainer[any] = (*Blocking[any])(nil)
+	_ Drainer[any] = (*Circular[any])(nil)
+	_ Drainer[any] = (*Linked[any])(nil)
+	_ Drainer[any] = (*Priority[any])(nil)
+	_ Drainer[any] = (*PriorityAny[any])(nil)
+	_ Drainer[any] = (*Handle[any])(nil)
+	_ Drainer[any] = (*ChanQueue[any])(nil)
+
+	_ Snapshotter[any] = (*Blocking[any])(nil)
+	_ Snapshotter[any] = (*Circular[any])(nil)
+	_ Snapshotter[any] = (*Linked[any])(nil)
+	_ Snapshotter[any] = (*Priority[any])(nil)
+	_ Snapshotter[any] = (*PriorityAny[any])(nil)
+
+	_ Visitor[any] = (*Blocking[any])(nil)
+	_ Visitor[any] = (*Circular[any])(nil)
+	_ Visitor[any] = (*Linked[any])(nil)
+	_ Visitor[any] = (*Priority[any])(nil)
+	_ Visitor[any] = (*PriorityAny[any])(nil)
+)
+
+// Waiter is implemented by the queues whose operations can wait for an
+// element or for capacity to become available.
+type Waiter[T any] interface {
+	// GetWait removes and returns the head of the queue, waiting for an
+	// element to become available.
+	GetWait() T
+
+	// OfferWait inserts the element to the tail of the queue, waiting for
+	// capacity to become available.
+	OfferWait(elem T)
+
+	// OfferWaitErr inserts the element as OfferWait does, and returns the
+	// error if the element cannot be inserted.
+	OfferWaitErr(elem T) error
+
+	// PeekWait returns the head of the queue without removing it, waiting
+	// for an element to become available.
+	PeekWait() T
+}
+
+// Closer is implemented by the queues which can be closed, after which they
+// reject the insertions.
+type Closer interface {
+	// Close closes the queue and wakes up the goroutines waiting on it.
+	Close()
+}
+
+// Bounded is implemented by the queues which may have a fixed capacity.
+type Bounded interface {
+	// Capacity returns the fixed capacity of the queue, or -1 if the queue
+	// is unbounded.
+	Capacity() int
+
+	// Remaining returns the number of elements the queue can hold in
+	// addition to its current elements, or -1 if the queue is unbounded.
+	Remaining() int
+}
+
+// Drainer is implemented by the queues whose elements can be removed all
+// at once.
+type Drainer[T any] interface {
+	// Clear removes and returns all elements from the queue.
+	Clear() []T
+
+	// Iterator removes all elements from the queue and returns a channel
+	// holding them.
+	Iterator() <-chan T
+}
+
+// Snapshotter is implemented by the queues whose elements can be copied
+// without removing them.
+type Snapshotter[T any] interface {
+	// ToSlice returns a copy of the queue elements, in the order in which
+	// they would be retrieved.
+	ToSlice() []T
+
+	// PeekN returns a copy of at most n elements from the head of the queue,
+	// in the order in which they would be retrieved.
+	PeekN(n int) []T
+}
+
+// Visitor is implemented by the queues whose elements can be visited in
+// place, without removing them, unlike the draining Iterator.
+type Visitor[T any] interface {
+	// Each calls fn for the queue elements, in the order in which they
+	// would be retrieved, until fn returns false.
+	Each(fn func(elem T) bool)
+}
+
+// CapabilitySet is a set of the capabilities supported by a queue,
+// as reported by Capabilities.
+type CapabilitySet uint8
+
+// The capabilities reported by Capabilities.
+const (
+	// CapWaiter is set for the queues implementing Waiter.
+	CapWaiter CapabilitySet = 1 << iota
+
+	// CapCloser is set for the queues implementing Closer.
+	CapCloser
+
+	// CapBounded is set for the queues implementing Bounded which have
+	// a fixed capacity.
+	CapBounded
+
+	// CapDrainer is set for the queues implementing Drainer.
+	CapDrainer
+
+	// CapSnapshotter is set for the queues implementing Snapshotter.
+	CapSnapshotter
+
+	// CapVisitor is set for the queues implementing Visitor.
+	CapVisitor
+)
+
+// capabilityNames holds the names of the capabilities, in bit order.
+var capabilityNames = []string{"Waiter", "Closer", "Bounded", "Drainer", "Snapshotter", "Visitor"}
+
+// Has returns true if the set holds all the given capabilities.
+func (s CapabilitySet) Has(capabilities CapabilitySet) bool {
+	return s&capabilities == capabilities
+}
+
+// String returns the names of the capabilities in the set, separated by |.
+func (s CapabilitySet) String() string {
+	names := make([]string, 0, len(capabilityNames))
+
+	for i, name := range capabilityNames {
+		if s&(1<<i) != 0 {
+			names = append(names, name)
+		}
+	}
+
+	if len(names) == 0 {
+		return "None"
+	}
+
+	return strings.Join(names, "|")
+}
+
+// Capabilities reports which capability interfaces q implements for the
+// element type T, allowing the code operating on any queue, including the
+// implementations outside this package, to adapt its behavior.
+// A Bounded queue without a fixed capacity is not reported as CapBounded.
+func Capabilities[T any](q any) CapabilitySet {
+	var s CapabilitySet
+
+	if _, ok := q.(Waiter[T]); ok {
+		s |= CapWaiter
+	}
+
+	if _, ok := q.(Closer); ok {
+		s |= CapCloser
+	}
+
+	if b, ok := q.(Bounded); ok && b.Capacity() != unboundedCapacity {
+		s |= CapBounded
+	}
+
+	if _, ok := q.(Drainer[T]); ok {
+		s |= CapDrainer
+	}
+
+	if _, ok := q.(Snapshotter[T]); ok {
+		s |= CapSnapshotter
+	}
+
+	if _, ok := q.(Visitor[T]); ok {
+		s |= CapVisitor
+	}
+
+	return s
+}
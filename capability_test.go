@@ -0,0 +1,296 @@
+package queue_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/adrianbrad/queue"
+)
+
+// waitingQueue is a queue implemented outside the package, which supports
+// the blocking waits and closing. It is unbounded and drains strings, thus it
+// is neither reported as bounded nor as a drainer of ints.
+type waitingQueue struct{}
+
+func (waitingQueue) GetWait() int            { return 0 }
+func (waitingQueue) OfferWait(int)           {}
+func (waitingQueue) OfferWaitErr(int) error  { return nil }
+func (waitingQueue) PeekWait() int           { return 0 }
+func (waitingQueue) Close()                  {}
+func (waitingQueue) Capacity() int           { return -1 }
+func (waitingQueue) Remaining() int          { return -1 }
+func (waitingQueue) Iterator() <-chan string { return nil }
+
+func TestCapabilities(t *testing.T) {
+	t.Parallel()
+
+	lessInt := func(elem, otherElem int) bool { return elem < otherElem }
+
+	testCases := map[string]struct {
+		queue    any
+		expected queue.CapabilitySet
+	}{
+		"Blocking": {
+			queue:    queue.NewBlocking([]int{}, queue.WithCapacity(1)),
+			expected: queue.CapWaiter | queue.CapCloser | queue.CapBounded | queue.CapDrainer | queue.CapSnapshotter | queue.CapVisitor,
+		},
+		"BlockingUnbounded": {
+			queue:    queue.NewBlocking([]int{}),
+			expected: queue.CapWaiter | queue.CapCloser | queue.CapDrainer | queue.CapSnapshotter | queue.CapVisitor,
+		},
+		"Circular": {
+			queue:    queue.NewCircular([]int{}, 1),
+			expected: queue.CapBounded | queue.CapDrainer | queue.CapSnapshotter | queue.CapVisitor,
+		},
+		"Linked": {
+			queue:    queue.NewLinked([]int{}),
+			expected: queue.CapDrainer | queue.CapSnapshotter | queue.CapVisitor,
+		},
+		"Priority": {
+			queue:    queue.NewPriority([]int{}, lessInt, queue.WithCapacity(1)),
+			expected: queue.CapWaiter | queue.CapBounded | queue.CapDrainer | queue.CapSnapshotter | queue.CapVisitor,
+		},
+		"PriorityAny": {
+			queue:    queue.NewPriorityAny([]int{}, lessInt),
+			expected: queue.CapWaiter | queue.CapDrainer | queue.CapSnapshotter | queue.CapVisitor,
+		},
+		"Handle": {
+			queue:    queue.NewHandle[int](queue.NewBlocking([]int{})),
+			expected: queue.CapDrainer,
+		},
+		"ChanQueue": {
+			queue:    queue.NewFromChannel(make(chan int, 1)),
+			expected: queue.CapBounded | queue.CapDrainer,
+		},
+		"Concurrent": {
+			queue:    queue.NewConcurrent([]int{}, queue.WithCapacity(1)),
+			expected: queue.CapBounded | queue.CapDrainer,
+		},
+		"ThirdParty": {
+			queue:    waitingQueue{},
+			expected: queue.CapWaiter | queue.CapCloser,
+		},
+		"None": {
+			queue:    struct{}{},
+			expected: 0,
+		},
+	}
+
+	for name, tc := range testCases {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if capabilities := queue.Capabilities[int](tc.queue); capabilities != tc.expected {
+				t.Fatalf("expected capabilities to be %s, got %s", tc.expected, capabilities)
+			}
+		})
+	}
+
+	t.Run("String", func(t *testing.T) {
+		t.Parallel()
+
+		capabilities := queue.CapWaiter | queue.CapDrainer
+
+		if s := capabilities.String(); s != "Waiter|Drainer" {
+			t.Fatalf("expected Waiter|Drainer, got %s", s)
+		}
+
+		if !capabilities.Has(queue.CapWaiter) || capabilities.Has(queue.CapWaiter|queue.CapCloser) {
+			t.Fatalf("expected the set to have only its capabilities")
+		}
+
+		if s := queue.CapabilitySet(0).String(); s != "None" {
+			t.Fatalf("expected None, got %s", s)
+		}
+	})
+}
+
+// snapshotQueue is implemented by all the queues.
+type snapshotQueue interface {
+	queue.Snapshotter[int]
+	Size() int
+}
+
+func TestSnapshotter(t *testing.T) {
+	t.Parallel()
+
+	lessInt := func(elem, otherElem int) bool { return elem < otherElem }
+
+	// the retrieval order of every queue is 1, 2, 3.
+	testCases := map[string]func() snapshotQueue{
+		"Blocking": func() snapshotQueue {
+			blockingQueue := queue.NewBlocking([]int{2, 3})
+
+			_ = blockingQueue.OfferUrgent(1)
+
+			return blockingQueue
+		},
+		"Circular": func() snapshotQueue {
+			return queue.NewCircular([]int{1, 2, 3}, 4)
+		},
+		"Linked": func() snapshotQueue {
+			return queue.NewLinked([]int{1, 2, 3})
+		},
+		"Priority": func() snapshotQueue {
+			return queue.NewPriority([]int{3, 1, 2}, lessInt)
+		},
+	}
+
+	for name, newQueue := range testCases {
+		newQueue := newQueue
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			q := newQueue()
+
+			if elems := q.ToSlice(); !reflect.DeepEqual([]int{1, 2, 3}, elems) {
+				t.Fatalf("expected elements to be %v, got %v", []int{1, 2, 3}, elems)
+			}
+
+			for n, expected := range map[int][]int{-1: {}, 0: {}, 2: {1, 2}, 5: {1, 2, 3}} {
+				if elems := q.PeekN(n); !reflect.DeepEqual(expected, elems) {
+					t.Fatalf("expected PeekN(%d) to be %v, got %v", n, expected, elems)
+				}
+			}
+
+			if size := q.Size(); size != 3 {
+				t.Fatalf("expected the elements not to be removed, got size %d", size)
+			}
+		})
+	}
+}
+
+func TestVisitor(t *testing.T) {
+	t.Parallel()
+
+	lessInt := func(elem, otherElem int) bool { return elem < otherElem }
+
+	type visitorQueue interface {
+		queue.Visitor[int]
+		Get() (int, error)
+		Size() int
+	}
+
+	// the retrieval order of every queue is 1, 2, 3.
+	testCases := map[string]func() visitorQueue{
+		"Blocking": func() visitorQueue {
+			blockingQueue := queue.NewBlocking([]int{2, 3})
+
+			_ = blockingQueue.OfferUrgent(1)
+
+			return blockingQueue
+		},
+		"Circular": func() visitorQueue {
+			circularQueue := queue.NewCircular([]int{0, 1, 2}, 3)
+
+			// the head of the queue is not at the start of its slots.
+			_, _ = circularQueue.Get()
+			_ = circularQueue.Offer(3)
+
+			return circularQueue
+		},
+		"Linked": func() visitorQueue {
+			return queue.NewLinked([]int{1, 2, 3})
+		},
+		"Priority": func() visitorQueue {
+			return queue.NewPriority([]int{3, 1, 2}, lessInt)
+		},
+	}
+
+	for name, newQueue := range testCases {
+		newQueue := newQueue
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			t.Run("All", func(t *testing.T) {
+				t.Parallel()
+
+				q := newQueue()
+
+				var visited []int
+
+				q.Each(func(elem int) bool {
+					visited = append(visited, elem)
+
+					return true
+				})
+
+				if !reflect.DeepEqual([]int{1, 2, 3}, visited) {
+					t.Fatalf("expected the visited elements to be %v, got %v", []int{1, 2, 3}, visited)
+				}
+
+				// the elements are neither removed nor reordered.
+				for _, expected := range []int{1, 2, 3} {
+					if elem, err := q.Get(); err != nil || elem != expected {
+						t.Fatalf("expected to get %d, got %d, %v", expected, elem, err)
+					}
+				}
+			})
+
+			t.Run("Stop", func(t *testing.T) {
+				t.Parallel()
+
+				q := newQueue()
+
+				var visited []int
+
+				q.Each(func(elem int) bool {
+					visited = append(visited, elem)
+
+					return elem < 2
+				})
+
+				if !reflect.DeepEqual([]int{1, 2}, visited) {
+					t.Fatalf("expected the visited elements to be %v, got %v", []int{1, 2}, visited)
+				}
+
+				if size := q.Size(); size != 3 {
+					t.Fatalf("expected the elements not to be removed, got size %d", size)
+				}
+			})
+		})
+	}
+}
+
+func TestBounded(t *testing.T) {
+	t.Parallel()
+
+	lessInt := func(elem, otherElem int) bool { return elem < otherElem }
+
+	testCases := map[string]struct {
+		queue     queue.Bounded
+		capacity  int
+		remaining int
+	}{
+		"Blocking":          {queue: queue.NewBlocking([]int{1}, queue.WithCapacity(3)), capacity: 3, remaining: 2},
+		"BlockingUnbounded": {queue: queue.NewBlocking([]int{1}), capacity: -1, remaining: -1},
+		"Circular":          {queue: queue.NewCircular([]int{1, 2}, 3), capacity: 3, remaining: 1},
+		"Priority":          {queue: queue.NewPriority([]int{1}, lessInt, queue.WithCapacity(1)), capacity: 1, remaining: 0},
+		"PriorityUnbounded": {queue: queue.NewPriority([]int{1}, lessInt), capacity: -1, remaining: -1},
+		"Linked":            {queue: queue.NewLinked([]int{1}), capacity: -1, remaining: -1},
+		"LinkedBounded":     {queue: queue.NewLinked([]int{1}, queue.WithCapacity(3)), capacity: 3, remaining: 2},
+		"Deque":             {queue: queue.NewDeque([]int{1}, queue.WithCapacity(3)), capacity: 3, remaining: 2},
+		"DequeUnbounded":    {queue: queue.NewDeque([]int{1}), capacity: -1, remaining: -1},
+		"Concurrent":        {queue: queue.NewConcurrent([]int{1}, queue.WithCapacity(3)), capacity: 3, remaining: 2},
+	}
+
+	for name, tc := range testCases {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if capacity := tc.queue.Capacity(); capacity != tc.capacity {
+				t.Fatalf("expected capacity to be %d, got %d", tc.capacity, capacity)
+			}
+
+			if remaining := tc.queue.Remaining(); remaining != tc.remaining {
+				t.Fatalf("expected remaining to be %d, got %d", tc.remaining, remaining)
+			}
+		})
+	}
+}
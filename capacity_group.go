@@ -0,0 +1,152 @@
+package queue
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// CapacityGroup is a budget of slots shared by several Blocking queues,
+// such as the stages of a pipeline, so that together they never hold more
+// elements than the group capacity.
+//
+// The slots are taken on a first come, first served basis: a single queue
+// may use the whole budget, blocking the insertions into the other queues of
+// the group until it releases some of its slots. Providing the queues with
+// a capacity of their own, lower than the group capacity, bounds the share of
+// the budget each of them can take.
+type CapacityGroup struct {
+	capacity int64
+	used     atomic.Int64
+
+	lock    sync.Mutex
+	members []*capacityMember
+}
+
+// NewCapacityGroup returns a group sharing the given number of slots.
+// A negative capacity is treated as 0.
+func NewCapacityGroup(capacity int) *CapacityGroup {
+	return &CapacityGroup{
+		capacity: int64(max(capacity, 0)),
+	}
+}
+
+// Capacity returns the number of slots shared by the group.
+func (g *CapacityGroup) Capacity() int {
+	return int(g.capacity)
+}
+
+// Used returns the number of slots taken by the queues of the group.
+func (g *CapacityGroup) Used() int {
+	return int(g.used.Load())
+}
+
+// Remaining returns the number of slots available to the queues of
+// the group.
+func (g *CapacityGroup) Remaining() int {
+	return int(max(g.capacity-g.used.Load(), 0))
+}
+
+// Usage returns the number of slots taken by each queue of the group, in the
+// order in which the queues joined it. The usages are not read atomically
+// with each other.
+func (g *CapacityGroup) Usage() []int {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	usage := make([]int, len(g.members))
+
+	for i, member := range g.members {
+		usage[i] = int(member.used.Load())
+	}
+
+	return usage
+}
+
+// join registers a queue into the group. The wake function is called
+// whenever another queue of the group releases some slots.
+func (g *CapacityGroup) join(wake func()) *capacityMember {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	member := &capacityMember{
+		group: g,
+		wake:  wake,
+	}
+
+	g.members = append(g.members, member)
+
+	return member
+}
+
+// capacityMember accounts for the slots taken by a queue of a CapacityGroup.
+type capacityMember struct {
+	group *CapacityGroup
+	used  atomic.Int64
+
+	// wake wakes up the producers of the queue waiting for capacity and
+	// waking coalesces the concurrent wake ups.
+	wake   func()
+	waking atomic.Bool
+}
+
+// fits returns true if n more elements fit into the group.
+func (m *capacityMember) fits(n int) bool {
+	return m.group.used.Load()+int64(n) <= m.group.capacity
+}
+
+// tryAcquire takes n slots from the group, if they are all available.
+func (m *capacityMember) tryAcquire(n int) bool {
+	for {
+		used := m.group.used.Load()
+
+		if used+int64(n) > m.group.capacity {
+			return false
+		}
+
+		if m.group.used.CompareAndSwap(used, used+int64(n)) {
+			m.used.Add(int64(n))
+
+			return true
+		}
+	}
+}
+
+// acquire takes n slots from the group, regardless of its capacity.
+func (m *capacityMember) acquire(n int) {
+	m.group.used.Add(int64(n))
+	m.used.Add(int64(n))
+}
+
+// release returns n slots to the group and wakes up the other queues of
+// the group.
+func (m *capacityMember) release(n int) {
+	if n <= 0 {
+		return
+	}
+
+	m.used.Add(-int64(n))
+	m.group.used.Add(-int64(n))
+
+	m.group.lock.Lock()
+	members := m.group.members
+	m.group.lock.Unlock()
+
+	for _, member := range members {
+		if member != m {
+			member.notify()
+		}
+	}
+}
+
+// notify wakes up the producers of the queue from a separate goroutine, as
+// the releasing queue holds its own lock and the queue locks are unordered.
+func (m *capacityMember) notify() {
+	if !m.waking.CompareAndSwap(false, true) {
+		return
+	}
+
+	go func() {
+		m.waking.Store(false)
+		m.wake()
+	}()
+}
@@ -0,0 +1,308 @@
+package queue_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/adrianbrad/queue"
+)
+
+func TestCapacityGroup(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Pipeline", func(t *testing.T) {
+		t.Parallel()
+
+		const (
+			totalSlots = 5
+			elems      = 200
+		)
+
+		group := queue.NewCapacityGroup(totalSlots)
+
+		// the stages feeding other stages have a capacity of their own so
+		// that the final stage is never starved of budget.
+		ingress := queue.NewBlocking([]int{}, queue.WithSharedCapacity(group), queue.WithCapacity(2))
+		middle := queue.NewBlocking([]int{}, queue.WithSharedCapacity(group), queue.WithCapacity(2))
+		final := queue.NewBlocking([]int{}, queue.WithSharedCapacity(group))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		var wg sync.WaitGroup
+
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				default:
+				}
+
+				if used := group.Used(); used > totalSlots {
+					t.Errorf("expected at most %d used slots, got %d", totalSlots, used)
+
+					return
+				}
+
+				time.Sleep(50 * time.Microsecond)
+			}
+		}()
+
+		go func() {
+			for i := 0; i < elems; i++ {
+				ingress.OfferWait(i)
+			}
+
+			ingress.Close()
+		}()
+
+		go func() {
+			for elem := range ingress.Live(ctx) {
+				middle.OfferWait(elem)
+			}
+
+			middle.Close()
+		}()
+
+		go func() {
+			for elem := range middle.Live(ctx) {
+				final.OfferWait(elem)
+			}
+
+			final.Close()
+		}()
+
+		received := make([]int, 0, elems)
+
+		// the final stage is the slowest one.
+		for elem := range final.Live(ctx) {
+			received = append(received, elem)
+
+			time.Sleep(100 * time.Microsecond)
+		}
+
+		cancel()
+		wg.Wait()
+
+		if len(received) != elems {
+			t.Fatalf("expected %d elements, got %d", elems, len(received))
+		}
+
+		for i, elem := range received {
+			if elem != i {
+				t.Fatalf("expected element %d to be %d, got %d", i, i, elem)
+			}
+		}
+
+		if used := group.Used(); used != 0 {
+			t.Fatalf("expected no used slots, got %d", used)
+		}
+	})
+
+	t.Run("FullGroup", func(t *testing.T) {
+		t.Parallel()
+
+		group := queue.NewCapacityGroup(3)
+
+		first := queue.NewBlocking([]int{1, 2}, queue.WithSharedCapacity(group))
+		second := queue.NewBlocking([]int{}, queue.WithSharedCapacity(group), queue.WithCapacity(5))
+
+		if err := second.Offer(3); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if err := second.Offer(4); !errors.Is(err, queue.ErrQueueIsFull) {
+			t.Fatalf("expected error to be %v, got %v", queue.ErrQueueIsFull, err)
+		}
+
+		if err := second.OfferAll(4, 5); !errors.Is(err, queue.ErrQueueIsFull) {
+			t.Fatalf("expected error to be %v, got %v", queue.ErrQueueIsFull, err)
+		}
+
+		if second.CanOffer(1) {
+			t.Fatalf("expected CanOffer to be false")
+		}
+
+		if remaining := second.Remaining(); remaining != 0 {
+			t.Fatalf("expected remaining to be 0, got %d", remaining)
+		}
+
+		if capacity := second.Capacity(); capacity != 5 {
+			t.Fatalf("expected capacity to be 5, got %d", capacity)
+		}
+
+		if usage := group.Usage(); !reflect.DeepEqual([]int{2, 1}, usage) {
+			t.Fatalf("expected usage to be %v, got %v", []int{2, 1}, usage)
+		}
+
+		if _, err := first.Get(); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if remaining := second.Remaining(); remaining != 1 {
+			t.Fatalf("expected remaining to be 1, got %d", remaining)
+		}
+	})
+
+	t.Run("WakesOtherQueue", func(t *testing.T) {
+		t.Parallel()
+
+		group := queue.NewCapacityGroup(1)
+
+		first := queue.NewBlocking([]int{1}, queue.WithSharedCapacity(group))
+		second := queue.NewBlocking([]int{}, queue.WithSharedCapacity(group))
+
+		errCh := make(chan error, 1)
+
+		go func() {
+			errCh <- second.OfferWaitErr(2)
+		}()
+
+		select {
+		case err := <-errCh:
+			t.Fatalf("expected OfferWait to wait, got %v", err)
+		case <-time.After(10 * time.Millisecond):
+		}
+
+		if _, err := first.Get(); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		select {
+		case err := <-errCh:
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("expected OfferWait to return")
+		}
+
+		if usage := group.Usage(); !reflect.DeepEqual([]int{0, 1}, usage) {
+			t.Fatalf("expected usage to be %v, got %v", []int{0, 1}, usage)
+		}
+	})
+
+	t.Run("ExchangeKeepsSlot", func(t *testing.T) {
+		t.Parallel()
+
+		group := queue.NewCapacityGroup(2)
+
+		exchanged := queue.NewBlocking([]int{1, 2}, queue.WithSharedCapacity(group))
+		other := queue.NewBlocking([]int{}, queue.WithSharedCapacity(group))
+
+		ctx, cancel := context.WithCancel(context.Background())
+
+		var wg sync.WaitGroup
+
+		wg.Add(1)
+
+		// the group stays full, the exchanged elements taking over the
+		// slots of the removed ones.
+		go func() {
+			defer wg.Done()
+
+			for ctx.Err() == nil {
+				if err := other.Offer(3); !errors.Is(err, queue.ErrQueueIsFull) {
+					t.Errorf("expected error to be %v, got %v", queue.ErrQueueIsFull, err)
+
+					return
+				}
+			}
+		}()
+
+		for i := 0; i < 10000; i++ {
+			if _, err := exchanged.Exchange(i); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if err := exchanged.Rotate(); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+		}
+
+		cancel()
+		wg.Wait()
+
+		if usage := group.Usage(); !reflect.DeepEqual([]int{2, 0}, usage) {
+			t.Fatalf("expected usage to be %v, got %v", []int{2, 0}, usage)
+		}
+	})
+
+	t.Run("UnmarshalJSONKeepsSlots", func(t *testing.T) {
+		t.Parallel()
+
+		group := queue.NewCapacityGroup(3)
+
+		replaced := queue.NewBlocking([]int{1, 2}, queue.WithSharedCapacity(group))
+		other := queue.NewBlocking([]int{}, queue.WithSharedCapacity(group))
+
+		// the elements of the queue do not fit along with the new ones,
+		// which take over their slots.
+		if err := replaced.UnmarshalJSON([]byte("[3, 4, 5]")); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if err := other.Offer(6); !errors.Is(err, queue.ErrQueueIsFull) {
+			t.Fatalf("expected error to be %v, got %v", queue.ErrQueueIsFull, err)
+		}
+
+		if err := replaced.UnmarshalJSON([]byte("[7]")); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if usage := group.Usage(); !reflect.DeepEqual([]int{1, 0}, usage) {
+			t.Fatalf("expected usage to be %v, got %v", []int{1, 0}, usage)
+		}
+	})
+
+	t.Run("Releases", func(t *testing.T) {
+		t.Parallel()
+
+		group := queue.NewCapacityGroup(10)
+
+		cleared := queue.NewBlocking([]int{1, 2, 3}, queue.WithSharedCapacity(group))
+		closed := queue.NewBlocking([]int{4, 5}, queue.WithSharedCapacity(group))
+		destroyed := queue.NewBlocking([]int{6}, queue.WithSharedCapacity(group))
+
+		if used := group.Used(); used != 6 {
+			t.Fatalf("expected 6 used slots, got %d", used)
+		}
+
+		cleared.Clear()
+
+		closed.Close()
+
+		// the elements of a closed queue hold their slots until drained.
+		if used := group.Used(); used != 3 {
+			t.Fatalf("expected 3 used slots, got %d", used)
+		}
+
+		for range closed.Iterator() {
+		}
+
+		destroyed.Destroy()
+
+		if usage := group.Usage(); !reflect.DeepEqual([]int{0, 0, 0}, usage) {
+			t.Fatalf("expected usage to be %v, got %v", []int{0, 0, 0}, usage)
+		}
+
+		cleared.Reset()
+
+		if used := group.Used(); used != 3 {
+			t.Fatalf("expected 3 used slots, got %d", used)
+		}
+
+		if remaining := group.Remaining(); remaining != 7 {
+			t.Fatalf("expected 7 remaining slots, got %d", remaining)
+		}
+	})
+}
@@ -0,0 +1,199 @@
+package queue
+
+import (
+	"sync"
+	"unsafe"
+)
+
+var _ Queue[any] = (*ChanQueue[any])(nil)
+
+// ChanQueue is a Queue implementation backed by a channel, allowing the code
+// written against the Queue interface to use a plain channel where one is
+// sufficient, as described by the Blocking documentation.
+//
+// The channel buffer is the queue capacity. Offer and Get never wait, while
+// OfferWait and GetWait are blocking sends and receives. Peek is not supported
+// by a channel and returns the ErrUnsupportedOperation error. Contains drains
+// and refills the channel, so the elements sent directly to the channel while
+// the queue is in use are not supported.
+type ChanQueue[T comparable] struct {
+	ch chan T
+
+	// lock is held for reading by the sends and for writing by Contains,
+	// so that no element is sent while the channel is being refilled.
+	lock sync.RWMutex
+}
+
+// NewFromChannel adapts a channel to the Queue interface, it returns a
+// ChanQueue backed by the given channel.
+// The elements already in the channel are the elements of the queue.
+func NewFromChannel[T comparable](ch chan T) *ChanQueue[T] {
+	return &ChanQueue[T]{
+		ch: ch,
+	}
+}
+
+// ==================================Insertion=================================
+
+// Offer inserts the element to the tail of the queue without waiting.
+// If the channel buffer is full it returns the ErrQueueIsFull error.
+func (cq *ChanQueue[T]) Offer(elem T) error {
+	cq.lock.RLock()
+	defer cq.lock.RUnlock()
+
+	select {
+	case cq.ch <- elem:
+		return nil
+	default:
+		return ErrQueueIsFull
+	}
+}
+
+// OfferWait inserts the element to the tail of the queue, waiting for the
+// channel buffer to have room for it.
+func (cq *ChanQueue[T]) OfferWait(elem T) {
+	cq.lock.RLock()
+	defer cq.lock.RUnlock()
+
+	cq.ch <- elem
+}
+
+// OfferWaitErr inserts the element to the tail of the queue, as OfferWait
+// does. It always returns nil, the error being returned for consistency with
+// the Blocking queue.
+func (cq *ChanQueue[T]) OfferWaitErr(elem T) error {
+	cq.OfferWait(elem)
+
+	return nil
+}
+
+// Reset removes all the elements from the queue, since a channel has no
+// initial elements to be restored.
+func (cq *ChanQueue[T]) Reset() {
+	cq.drain()
+}
+
+// ===================================Removal==================================
+
+// Get removes and returns the head of the queue without waiting.
+// If no element is available it returns an ErrNoElementsAvailable error.
+func (cq *ChanQueue[T]) Get() (v T, _ error) {
+	select {
+	case v = <-cq.ch:
+		return v, nil
+	default:
+		return v, ErrNoElementsAvailable
+	}
+}
+
+// GetWait removes and returns the head of the queue, waiting for an element
+// to become available.
+func (cq *ChanQueue[T]) GetWait() T {
+	return <-cq.ch
+}
+
+// Clear removes and returns all the elements available in the queue.
+func (cq *ChanQueue[T]) Clear() []T {
+	return cq.drain()
+}
+
+// Iterator removes all the elements available in the queue and returns
+// a closed channel holding them.
+func (cq *ChanQueue[T]) Iterator() <-chan T {
+	elems := cq.drain()
+
+	iteratorCh := make(chan T, len(elems))
+
+	for _, elem := range elems {
+		iteratorCh <- elem
+	}
+
+	close(iteratorCh)
+
+	return iteratorCh
+}
+
+// =================================Examination================================
+
+// Peek is not supported by a channel, it returns the
+// ErrUnsupportedOperation error.
+func (cq *ChanQueue[T]) Peek() (v T, _ error) {
+	return v, ErrUnsupportedOperation
+}
+
+// Contains returns true if the queue contains the element.
+// It drains the channel and refills it with the same elements, in the same
+// order, blocking the sends to the queue meanwhile. The elements received by
+// other goroutines while the channel is drained are not refilled.
+func (cq *ChanQueue[T]) Contains(elem T) bool {
+	cq.lock.Lock()
+	defer cq.lock.Unlock()
+
+	elems := cq.drain()
+
+	// the channel has room for the drained elements, as no element is sent
+	// while the lock is held.
+	for _, e := range elems {
+		cq.ch <- e
+	}
+
+	for _, e := range elems {
+		if e == elem {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Size returns the number of elements in the queue.
+func (cq *ChanQueue[T]) Size() int {
+	return len(cq.ch)
+}
+
+// IsEmpty returns true if the queue is empty.
+func (cq *ChanQueue[T]) IsEmpty() bool {
+	return len(cq.ch) == 0
+}
+
+// Capacity returns the size of the channel buffer.
+func (cq *ChanQueue[T]) Capacity() int {
+	return cap(cq.ch)
+}
+
+// Remaining returns the number of elements the channel buffer can hold in
+// addition to its current elements.
+func (cq *ChanQueue[T]) Remaining() int {
+	return cap(cq.ch) - len(cq.ch)
+}
+
+// MemoryFootprint returns an estimate of the memory retained by the queue.
+// The elements cannot be examined without being received from the channel,
+// thus their shallow size is reported and sizeOf is ignored.
+func (cq *ChanQueue[T]) MemoryFootprint(_ func(T) uintptr) MemoryReport {
+	f := newFootprint[T](nil, unsafe.Sizeof(*cq))
+
+	n := len(cq.ch)
+
+	f.report.Elements = n
+	f.report.PayloadBytes = uintptr(n) * f.slot
+	f.slots(cap(cq.ch))
+
+	return f.done()
+}
+
+// ===================================Helpers==================================
+
+// drain receives the elements available in the channel without waiting.
+func (cq *ChanQueue[T]) drain() []T {
+	elems := make([]T, 0, len(cq.ch))
+
+	for {
+		select {
+		case elem := <-cq.ch:
+			elems = append(elems, elem)
+		default:
+			return elems
+		}
+	}
+}
@@ -0,0 +1,324 @@
+package queue_test
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/adrianbrad/queue"
+)
+
+// chanQueue is the subset of the queue methods implemented by both
+// the Blocking queue and the ChanQueue.
+type chanQueue interface {
+	queue.Queue[int]
+	OfferWait(elem int)
+	GetWait() int
+}
+
+// newChanQueues returns the constructors of the queues which are expected to
+// behave the same, given a capacity and no initial elements.
+func newChanQueues() map[string]func(capacity int) chanQueue {
+	return map[string]func(capacity int) chanQueue{
+		"Blocking": func(capacity int) chanQueue {
+			return queue.NewBlocking([]int{}, queue.WithCapacity(capacity))
+		},
+		"ChanQueue": func(capacity int) chanQueue {
+			return queue.NewFromChannel(make(chan int, capacity))
+		},
+	}
+}
+
+func TestChanQueue(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Conformance", func(t *testing.T) {
+		t.Parallel()
+
+		for name, newQueue := range newChanQueues() {
+			newQueue := newQueue
+
+			t.Run(name, func(t *testing.T) {
+				t.Parallel()
+
+				t.Run("OfferGet", func(t *testing.T) {
+					t.Parallel()
+
+					q := newQueue(2)
+
+					if err := q.Offer(1); err != nil {
+						t.Fatalf("expected no error, got %v", err)
+					}
+
+					if err := q.Offer(2); err != nil {
+						t.Fatalf("expected no error, got %v", err)
+					}
+
+					if err := q.Offer(3); !errors.Is(err, queue.ErrQueueIsFull) {
+						t.Fatalf("expected error to be %v, got %v", queue.ErrQueueIsFull, err)
+					}
+
+					if size := q.Size(); size != 2 {
+						t.Fatalf("expected size to be 2, got %d", size)
+					}
+
+					for _, expected := range []int{1, 2} {
+						elem, err := q.Get()
+						if err != nil {
+							t.Fatalf("expected no error, got %v", err)
+						}
+
+						if elem != expected {
+							t.Fatalf("expected element to be %d, got %d", expected, elem)
+						}
+					}
+
+					if _, err := q.Get(); !errors.Is(err, queue.ErrNoElementsAvailable) {
+						t.Fatalf("expected error to be %v, got %v", queue.ErrNoElementsAvailable, err)
+					}
+
+					if !q.IsEmpty() {
+						t.Fatalf("expected queue to be empty")
+					}
+				})
+
+				t.Run("OfferWaitGetWait", func(t *testing.T) {
+					t.Parallel()
+
+					const elems = 100
+
+					q := newQueue(1)
+
+					go func() {
+						for i := 0; i < elems; i++ {
+							q.OfferWait(i)
+						}
+					}()
+
+					for i := 0; i < elems; i++ {
+						if elem := q.GetWait(); elem != i {
+							t.Fatalf("expected element to be %d, got %d", i, elem)
+						}
+					}
+				})
+
+				t.Run("Contains", func(t *testing.T) {
+					t.Parallel()
+
+					q := newQueue(3)
+
+					_ = q.Offer(1)
+					_ = q.Offer(2)
+
+					if !q.Contains(2) {
+						t.Fatalf("expected queue to contain 2")
+					}
+
+					if q.Contains(3) {
+						t.Fatalf("expected queue not to contain 3")
+					}
+
+					if elems := q.Clear(); !reflect.DeepEqual([]int{1, 2}, elems) {
+						t.Fatalf("expected elements to be %v, got %v", []int{1, 2}, elems)
+					}
+				})
+
+				t.Run("Iterator", func(t *testing.T) {
+					t.Parallel()
+
+					q := newQueue(3)
+
+					_ = q.Offer(1)
+					_ = q.Offer(2)
+
+					var elems []int
+
+					for elem := range q.Iterator() {
+						elems = append(elems, elem)
+					}
+
+					if !reflect.DeepEqual([]int{1, 2}, elems) {
+						t.Fatalf("expected elements to be %v, got %v", []int{1, 2}, elems)
+					}
+
+					if !q.IsEmpty() {
+						t.Fatalf("expected queue to be empty")
+					}
+				})
+
+				t.Run("Reset", func(t *testing.T) {
+					t.Parallel()
+
+					q := newQueue(3)
+
+					_ = q.Offer(1)
+
+					q.Reset()
+
+					if !q.IsEmpty() {
+						t.Fatalf("expected queue to be empty")
+					}
+				})
+			})
+		}
+	})
+
+	t.Run("ExistingElements", func(t *testing.T) {
+		t.Parallel()
+
+		ch := make(chan int, 3)
+		ch <- 1
+		ch <- 2
+
+		chanQueue := queue.NewFromChannel(ch)
+
+		if size := chanQueue.Size(); size != 2 {
+			t.Fatalf("expected size to be 2, got %d", size)
+		}
+
+		if remaining := chanQueue.Remaining(); remaining != 1 {
+			t.Fatalf("expected remaining to be 1, got %d", remaining)
+		}
+
+		if capacity := chanQueue.Capacity(); capacity != 3 {
+			t.Fatalf("expected capacity to be 3, got %d", capacity)
+		}
+
+		// the queue elements can be received directly from the channel.
+		if elem := <-ch; elem != 1 {
+			t.Fatalf("expected element to be 1, got %d", elem)
+		}
+	})
+
+	t.Run("PeekUnsupported", func(t *testing.T) {
+		t.Parallel()
+
+		chanQueue := queue.NewFromChannel(make(chan int, 1))
+
+		_ = chanQueue.Offer(1)
+
+		if _, err := chanQueue.Peek(); !errors.Is(err, queue.ErrUnsupportedOperation) {
+			t.Fatalf("expected error to be %v, got %v", queue.ErrUnsupportedOperation, err)
+		}
+
+		if size := chanQueue.Size(); size != 1 {
+			t.Fatalf("expected size to be 1, got %d", size)
+		}
+	})
+
+	t.Run("Unbuffered", func(t *testing.T) {
+		t.Parallel()
+
+		chanQueue := queue.NewFromChannel(make(chan int))
+
+		if err := chanQueue.Offer(1); !errors.Is(err, queue.ErrQueueIsFull) {
+			t.Fatalf("expected error to be %v, got %v", queue.ErrQueueIsFull, err)
+		}
+
+		go func() {
+			chanQueue.OfferWait(1)
+		}()
+
+		if elem := chanQueue.GetWait(); elem != 1 {
+			t.Fatalf("expected element to be 1, got %d", elem)
+		}
+	})
+
+	t.Run("ContainsConcurrentOffers", func(t *testing.T) {
+		t.Parallel()
+
+		const (
+			producers = 4
+			elems     = 50
+		)
+
+		chanQueue := queue.NewFromChannel(make(chan int, producers*elems))
+
+		var wg sync.WaitGroup
+
+		wg.Add(producers + 1)
+
+		for p := 0; p < producers; p++ {
+			go func() {
+				defer wg.Done()
+
+				for i := 0; i < elems; i++ {
+					_ = chanQueue.Offer(i)
+				}
+			}()
+		}
+
+		go func() {
+			defer wg.Done()
+
+			for i := 0; i < elems; i++ {
+				_ = chanQueue.Contains(i)
+			}
+		}()
+
+		wg.Wait()
+
+		// no element is lost while refilling the channel.
+		if size := chanQueue.Size(); size != producers*elems {
+			t.Fatalf("expected size to be %d, got %d", producers*elems, size)
+		}
+	})
+}
+
+func BenchmarkChanQueue(b *testing.B) {
+	for name, newQueue := range newChanQueues() {
+		newQueue := newQueue
+
+		b.Run(name, func(b *testing.B) {
+			b.Run("Offer_Get", func(b *testing.B) {
+				q := newQueue(1)
+
+				b.ReportAllocs()
+				b.ResetTimer()
+
+				for i := 0; i <= b.N; i++ {
+					_ = q.Offer(i)
+
+					_, _ = q.Get()
+				}
+			})
+
+			b.Run("Size", func(b *testing.B) {
+				q := newQueue(1)
+
+				_ = q.Offer(1)
+
+				b.ReportAllocs()
+				b.ResetTimer()
+
+				for i := 0; i <= b.N; i++ {
+					_ = q.Size()
+				}
+			})
+
+			b.Run("PingPong", func(b *testing.B) {
+				q := newQueue(16)
+
+				done := make(chan struct{})
+
+				go func() {
+					defer close(done)
+
+					for i := 0; i <= b.N; i++ {
+						_ = q.GetWait()
+					}
+				}()
+
+				b.ReportAllocs()
+				b.ResetTimer()
+
+				for i := 0; i <= b.N; i++ {
+					q.OfferWait(i)
+				}
+
+				<-done
+			})
+		})
+	}
+}
@@ -1,7 +1,13 @@
 package queue
 
 import (
-	"sync"
+	"bytes"
+	"context"
+	"io"
+	"sort"
+	"sync/atomic"
+	"time"
+	"unsafe"
 )
 
 // Ensure Priority implements the Queue interface.
@@ -18,18 +24,81 @@ var _ Queue[any] = (*Circular[any])(nil)
 // So, if we add the element `4`, the queue will look like this: [4, 2, 3].
 // If the head of the queue is set to 0, as if we never removed an element yet,
 // then the next element to be removed from the queue will be the element at index 0, which is `4`.
+//
+// A queue created with the WithoutOverwrite option rejects the insertions
+// once it is full instead.
 type Circular[T comparable] struct {
 	initialElements []T
+	resetCloner     func(T) T
 	elems           []T
 	head            int
 	tail            int
-	size            int
+
+	// occupancy counts the elements, its capacity is the length of elems.
+	occupancy occupancy
+
+	// codec encodes and decodes the elements in the JSON methods.
+	codec jsonCodec[T]
+
+	// recycler releases the discarded elements, if WithRecycler is provided.
+	recycler recycler[T]
+
+	// validator checks the inserted elements, if WithValidator is provided.
+	validator validator[T]
+
+	// window rejects the elements admitted within the idempotency window, if
+	// WithIdempotencyWindow is provided.
+	window *idempotencyWindow[T]
+
+	// rejectFull makes the insertions into a full queue return the
+	// ErrQueueIsFull error instead of overwriting the oldest element, if
+	// WithoutOverwrite is provided.
+	rejectFull bool
+
+	// reads caches the results of the reads, if WithCachedReads is provided.
+	reads *readCache[T]
+
+	// snapshots publishes the size and the head of the queue, if
+	// WithReadSnapshotting is provided.
+	snapshots *readSnapshots[T]
+
+	// poller retries Get in Poll.
+	poller poller[T]
+
+	// tracker records the mutating operations, if the WithCallerTracking
+	// option is provided.
+	tracker *callerTracker[T]
+
+	// journal records the mutating operations, if the WithJournal option is
+	// provided.
+	journal *journal[T]
+
+	// name is the name provided using WithName, carried by the errors.
+	name string
 
 	// synchronization
-	lock sync.RWMutex
+	lock profiledRWMutex
+
+	// lock-free reads, see Size, IsEmpty and Peek.
+	//
+	// atomicSize mirrors size, seq is incremented by every mutation and
+	// peekHead caches the head observed by a locked Peek, valid as long as
+	// its seq matches the current one.
+	atomicSize atomic.Int64
+	seq        atomic.Uint64
+	peekHead   atomic.Pointer[circularHead[T]]
+}
+
+// circularHead is the head of a Circular queue, as observed at seq.
+type circularHead[T any] struct {
+	seq   uint64
+	elem  T
+	empty bool
 }
 
 // NewCircular creates a new Circular Queue containing the given elements.
+// It panics if the capacity, given directly or by WithCapacity, is not
+// positive.
 func NewCircular[T comparable](
 	givenElems []T,
 	capacity int,
@@ -43,65 +112,322 @@ func NewCircular[T comparable](
 		o.apply(&options)
 	}
 
+	if *options.capacity <= 0 {
+		panic("queue: circular capacity must be positive")
+	}
+
 	elems := make([]T, *options.capacity)
 
+	recycler := recyclerOf[T](options)
+
+	validator := validatorOf[T](options)
+
+	givenElems = validator.filter(givenElems, recycler)
+
+	// the elements exceeding the capacity are dropped, they are not
+	// restored by Reset either.
+	recycler.discardAll(givenElems[min(len(givenElems), len(elems)):])
+
+	givenElems = givenElems[:min(len(givenElems), len(elems))]
+
 	copy(elems, givenElems)
 
-	initialElems := make([]T, len(givenElems))
+	resetCloner := resetClonerOf[T](options)
 
-	copy(initialElems, givenElems)
+	initialElems := initialElementsOf(options, givenElems, resetCloner)
 
 	tail := 0
 
 	size := len(elems)
 
-	if len(initialElems) < len(elems) {
-		tail = len(initialElems)
-		size = len(initialElems)
+	if len(givenElems) < len(elems) {
+		tail = len(givenElems)
+		size = len(givenElems)
 	}
 
-	return &Circular[T]{
+	queue := &Circular[T]{
 		initialElements: initialElems,
+		resetCloner:     resetCloner,
+		codec:           jsonCodecOf[T](options),
+		recycler:        recycler,
+		validator:       validator,
+		window:          newIdempotencyWindow[T](options),
+		rejectFull:      options.withoutOverwrite,
+		reads:           newReadCache[T](options),
+		snapshots:       newReadSnapshots[T](options),
+		poller:          newPoller[T](options),
+		tracker:         newCallerTracker[T](options),
+		journal:         newJournal[T](options),
+		name:            options.name,
 		elems:           elems,
 		head:            0,
 		tail:            tail,
-		size:            size,
-		lock:            sync.RWMutex{},
+		occupancy:       newOccupancy(options.capacity),
+		lock: profiledRWMutex{
+			profiler: newContentionProfiler(options.contentionSampleRate),
+		},
+	}
+
+	queue.occupancy.forceAdmit(size, 0)
+
+	if checkInvariants {
+		queue.lock.verify = queue.verifyOccupancy
+	}
+
+	if queue.snapshots != nil {
+		queue.lock.publish = queue.publishSnapshot
+
+		queue.publishSnapshot()
 	}
+
+	queue.atomicSize.Store(int64(size))
+
+	return queue
 }
 
 // ==================================Insertion=================================
 
 // Offer adds an element into the queue.
-// If the queue is full then the oldest item is overwritten.
+// If the queue is full then the oldest item is overwritten, unless the
+// WithoutOverwrite option is provided, in which case the ErrQueueIsFull error
+// is returned.
+// If the element is rejected by the validator provided using WithValidator
+// it returns an InvalidElementError, overwriting no element.
 func (q *Circular[T]) Offer(item T) error {
 	q.lock.Lock()
 	defer q.lock.Unlock()
 
-	if q.size < len(q.elems) {
-		q.size++
+	return q.named(q.tracker.recordResult(item, q.offerValid(item)))
+}
+
+// OfferWithEvicted adds an element into the queue as Offer does, and returns
+// the element it overwrote at the tail position if the queue was full.
+// wasEvicted is false if the queue had room for the element, or if the
+// element was not inserted, in which case the error Offer would have returned
+// is returned.
+// The evicted element is handed to the caller, it is not released if the
+// WithRecycler option is provided.
+func (q *Circular[T]) OfferWithEvicted(item T) (evicted T, wasEvicted bool, _ error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if err := q.validator.check(item); err != nil {
+		return evicted, false, q.named(q.tracker.recordResult(item, err))
 	}
 
-	q.elems[q.tail] = item
-	q.tail = (q.tail + 1) % len(q.elems)
+	if err := q.window.check(item); err != nil {
+		return evicted, false, q.named(q.tracker.recordResult(item, err))
+	}
+
+	evicted, wasEvicted, err := q.offerEvicting(item)
+	if err == nil {
+		q.window.remember(item)
+	}
+
+	return evicted, wasEvicted, q.named(q.tracker.recordResult(item, err))
+}
+
+// TryOffer attempts to insert the element to the tail of the queue without
+// waiting for the queue lock. If the lock is held by another goroutine it
+// returns false without attempting the insertion, otherwise it returns true
+// and the error Offer would have returned.
+// False negatives are expected under contention, TryOffer is meant for
+// callers that prefer skipping an operation over waiting for the lock.
+func (q *Circular[T]) TryOffer(item T) (acquired bool, _ error) {
+	if !q.lock.TryLock() {
+		return false, nil
+	}
+
+	defer q.lock.Unlock()
+
+	return true, q.named(q.tracker.recordResult(item, q.offerValid(item)))
+}
+
+// OfferAll inserts all the elements to the tail of the queue, in order.
+// Like Offer, it overwrites the oldest elements once the queue is full. If
+// the WithoutOverwrite option is provided and the elements do not all fit, it
+// returns the ErrQueueIsFull error without inserting any of them.
+// If one of the elements is rejected by the validator provided using
+// WithValidator it returns an InvalidElementError without inserting any of
+// them.
+func (q *Circular[T]) OfferAll(items ...T) error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if err := q.validator.checkAll(items); err != nil {
+		return q.named(err)
+	}
+
+	if err := q.window.checkAll(items); err != nil {
+		return q.named(err)
+	}
+
+	if q.rejectFull && !q.occupancy.fits(len(items)) {
+		return q.named(ErrQueueIsFull)
+	}
+
+	q.window.rememberAll(items)
+
+	for _, item := range items {
+		_ = q.offer(item)
+	}
+
+	q.tracker.recordBulk()
+
+	return nil
+}
+
+// replaceAll replaces the elements of the queue with the given ones, using
+// the same semantics as OfferAll into the emptied queue. If the elements
+// cannot all be inserted the queue is left unchanged. The replaced elements
+// are released by the recycler.
+func (q *Circular[T]) replaceAll(items ...T) error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if err := q.validator.checkAll(items); err != nil {
+		return err
+	}
+
+	if err := q.window.checkAll(items); err != nil {
+		return err
+	}
+
+	if q.rejectFull && !q.occupancy.fits(len(items)-q.occupancy.count) {
+		return ErrQueueIsFull
+	}
+
+	q.recycler.discardAll(q.clear())
+
+	q.window.rememberAll(items)
+
+	for _, item := range items {
+		_ = q.offer(item)
+	}
+
+	q.tracker.recordBulk()
 
 	return nil
 }
 
+// OfferSome inserts the elements to the tail of the queue, in order, and
+// returns the number of inserted elements. Like Offer, it overwrites the
+// oldest elements once the queue is full, thus it inserts all the elements
+// and returns a nil error, unless it stops at an element rejected by the
+// validator provided using WithValidator, returning an InvalidElementError.
+// If the WithoutOverwrite option is provided it also stops at the first
+// element which does not fit, returning the ErrQueueIsFull error.
+func (q *Circular[T]) OfferSome(items ...T) (n int, _ error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	var err error
+
+	for _, item := range items {
+		if err = q.offerValid(item); err != nil {
+			break
+		}
+
+		n++
+	}
+
+	if n > 0 {
+		q.tracker.recordBulk()
+	}
+
+	return n, q.named(err)
+}
+
+// CanOffer returns true, since the queue overwrites its oldest elements once
+// it is full. If the WithoutOverwrite option is provided it returns true only
+// if n elements would currently fit into the queue.
+func (q *Circular[T]) CanOffer(n int) bool {
+	if !q.rejectFull {
+		return true
+	}
+
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+
+	return q.occupancy.fits(n)
+}
+
 // Reset resets the queue to its initial state.
 func (q *Circular[T]) Reset() {
 	q.lock.Lock()
 	defer q.lock.Unlock()
 
-	copy(q.elems, q.initialElements)
+	if q.recycler.enabled() {
+		for i := 0; i < q.occupancy.count; i++ {
+			q.recycler.discard(q.elems[(q.head+i)%len(q.elems)])
+		}
+	}
+
+	copyElements(q.elems, q.initialElements, q.resetCloner)
+
+	// zero the slots left over from before the reset.
+	clear(q.elems[len(q.initialElements):])
 
 	q.head = 0
 	q.tail = 0
-	q.size = len(q.initialElements)
+	q.occupancy.reset(len(q.initialElements))
+
+	q.window.forget()
 
 	if len(q.initialElements) < len(q.elems) {
 		q.tail = len(q.initialElements)
 	}
+
+	q.journal.recordOp(JournalReset, q.occupancy.count)
+
+	q.mutated()
+
+	q.tracker.recordBulk()
+}
+
+// Exchange atomically removes the head of the queue and inserts the element
+// to the tail of the queue, returning the removed head. Since one element
+// leaves as one enters, no element is overwritten.
+// If no element is available it inserts the element and returns the
+// ErrNoElementsAvailable error. If the element is rejected by the validator
+// provided using WithValidator it returns an InvalidElementError, leaving the
+// queue unchanged.
+func (q *Circular[T]) Exchange(item T) (v T, _ error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if err := q.validator.check(item); err != nil {
+		return v, q.named(err)
+	}
+
+	if err := q.window.check(item); err != nil {
+		return v, q.named(err)
+	}
+
+	v, err := q.get()
+
+	_ = q.offer(item)
+
+	q.window.remember(item)
+
+	q.tracker.record(item)
+
+	return v, q.named(err)
+}
+
+// Rotate atomically moves the head of the queue to its tail, so that the
+// elements can be visited in a round-robin fashion without removing them.
+// If no element is available it returns an ErrNoElementsAvailable error.
+func (q *Circular[T]) Rotate() error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	item, err := q.get()
+	if err != nil {
+		return q.named(err)
+	}
+
+	return q.named(q.tracker.recordResult(item, q.offer(item)))
 }
 
 // ===================================Removal==================================
@@ -111,17 +437,50 @@ func (q *Circular[T]) Get() (v T, _ error) {
 	q.lock.Lock()
 	defer q.lock.Unlock()
 
-	return q.get()
+	v, err := q.get()
+
+	return v, q.named(q.tracker.recordResult(v, err))
 }
 
-// Clear removes all elements from the queue.
-func (q *Circular[T]) Clear() []T {
+// TryGet attempts to remove and return the head of the queue without
+// waiting for the queue lock. If the lock is held by another goroutine it
+// returns false without attempting the removal, otherwise it returns true
+// along with the element and the error Get would have returned.
+// False negatives are expected under contention.
+func (q *Circular[T]) TryGet() (v T, acquired bool, _ error) {
+	if !q.lock.TryLock() {
+		return v, false, nil
+	}
+
+	defer q.lock.Unlock()
+
+	v, err := q.get()
+
+	return v, true, q.named(q.tracker.recordResult(v, err))
+}
+
+// Poll removes and returns the head of the queue. If no element is available
+// it retries after the polling interval elapses on the queue clock, until an
+// element is available or ctx is done, in which case it returns the ctx error.
+// The queue lock is not held between the attempts, which are always at least
+// an interval apart, thus Poll never busy-spins.
+// It returns the ErrInvalidInterval error if the interval is not positive.
+func (q *Circular[T]) Poll(ctx context.Context, interval time.Duration) (T, error) {
+	elem, err := q.poller.poll(ctx, interval, q.Get)
+
+	return elem, q.named(err)
+}
+
+// GetN removes and returns up to n elements from the head of the queue, in
+// FIFO order, acquiring the queue lock once. GetN(Size()) is equivalent to
+// Clear.
+func (q *Circular[T]) GetN(n int) []T {
 	q.lock.Lock()
 	defer q.lock.Unlock()
 
-	elems := make([]T, 0, q.size)
+	elems := make([]T, 0, min(max(n, 0), q.occupancy.count))
 
-	for {
+	for len(elems) < cap(elems) {
 		elem, err := q.get()
 		if err != nil {
 			break
@@ -130,50 +489,139 @@ func (q *Circular[T]) Clear() []T {
 		elems = append(elems, elem)
 	}
 
-	// clear the queue
-	q.head = 0
-	q.tail = 0
+	if len(elems) > 0 {
+		q.tracker.recordBulk()
+	}
 
 	return elems
 }
 
-// Iterator returns an iterator over the elements in the queue.
-// It removes the elements from the queue.
-func (q *Circular[T]) Iterator() <-chan T {
-	q.lock.RLock()
-	defer q.lock.RUnlock()
+// Clear removes all elements from the queue.
+func (q *Circular[T]) Clear() []T {
+	q.lock.Lock()
+	defer q.lock.Unlock()
 
-	// use a buffered channel to avoid blocking the iterator.
-	iteratorCh := make(chan T, q.size)
+	q.tracker.recordBulk()
 
-	// close the channel when the function returns.
-	defer close(iteratorCh)
+	return q.clear()
+}
 
-	// iterate over the elements and send them to the channel.
-	for {
-		elem, err := q.get()
-		if err != nil {
-			break
-		}
+// ClearIf evaluates pred against the current state of the queue and, if it
+// returns true, removes and returns all elements from the queue along with
+// true. Otherwise, it returns nil and false, leaving the queue unchanged.
+// The check and the removal are atomic with respect to the other operations.
+//
+// pred runs while holding the queue lock, thus it must be fast and must not
+// call the queue methods.
+func (q *Circular[T]) ClearIf(pred func(snapshot ClearSnapshot[T]) bool) ([]T, bool) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	snapshot := ClearSnapshot[T]{
+		Size:     q.occupancy.count,
+		Capacity: len(q.elems),
+	}
 
-		iteratorCh <- elem
+	if !q.isEmpty() {
+		snapshot.Head = q.elems[q.head]
 	}
 
-	return iteratorCh
+	if !pred(snapshot) {
+		return nil, false
+	}
+
+	q.tracker.recordBulk()
+
+	return q.clear(), true
+}
+
+// IteratorsN removes all the elements from the queue at once and distributes
+// them, in FIFO order, among k closed and buffered channels, as specified by
+// the partition mode, so that k workers can consume disjoint shares of the
+// queue without contending on it. Some channels are empty if k is greater than
+// the number of elements.
+// It returns the ErrInvalidPartitions error if k is lower than 1, leaving the
+// queue unchanged.
+func (q *Circular[T]) IteratorsN(k int, mode Partition) ([]<-chan T, error) {
+	if k < 1 {
+		return nil, q.named(ErrInvalidPartitions)
+	}
+
+	q.lock.Lock()
+	elems := q.clear()
+	q.lock.Unlock()
+
+	q.tracker.recordBulk()
+
+	return partition(elems, k, mode), nil
+}
+
+// All returns a sequence over the queue elements, from head to tail, without
+// removing them. It is an iter.Seq, thus it can be ranged over:
+//
+//	for elem := range q.All() {
+//		...
+//	}
+//
+// The elements are copied once the iteration starts, so the loop may modify
+// the queue, the changes not being visited.
+func (q *Circular[T]) All() func(yield func(T) bool) {
+	return snapshotSeq(q.ToSlice)
+}
+
+// Drain returns a sequence removing the queue elements, from head to tail, as they
+// are iterated, until the queue is empty or the loop stops. It is an
+// iter.Seq, thus it can be ranged over:
+//
+//	for elem := range q.Drain() {
+//		...
+//	}
+//
+// Each element is removed using Get, so the queue lock is not held while the
+// loop body runs, and stopping the loop early leaves the other elements in
+// the queue.
+func (q *Circular[T]) Drain() func(yield func(T) bool) {
+	return drainSeq(q.Get)
+}
+
+// Iterator returns an iterator over the elements in the queue.
+// It removes the elements from the queue.
+func (q *Circular[T]) Iterator() <-chan T {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	q.tracker.recordBulk()
+
+	// use a buffered channel to avoid blocking the iterator.
+	return bufferedChan(q.occupancy.count, drainSeq(q.get))
 }
 
 // =================================Examination================================
 
 // IsEmpty returns true if the queue is empty.
+// It does not acquire the queue lock.
 func (q *Circular[T]) IsEmpty() bool {
-	q.lock.RLock()
-	defer q.lock.RUnlock()
+	if q.snapshots != nil {
+		return q.snapshots.load().size == 0
+	}
 
-	return q.isEmpty()
+	if q.reads != nil {
+		return q.readHead().size == 0
+	}
+
+	return q.atomicSize.Load() == 0
 }
 
 // Contains returns true if the queue contains the given element.
+// If the WithReadSnapshotting option is provided, the element is first looked
+// up in the last published head of the queue.
 func (q *Circular[T]) Contains(elem T) bool {
+	if q.snapshots != nil {
+		if found, known := q.snapshots.load().contains(elem); known {
+			return found
+		}
+	}
+
 	q.lock.RLock()
 	defer q.lock.RUnlock()
 
@@ -181,10 +629,8 @@ func (q *Circular[T]) Contains(elem T) bool {
 		return false // queue is empty, item not found
 	}
 
-	for i := q.head; i < q.size; i++ {
-		idx := (q.head + i) % len(q.elems)
-
-		if q.elems[idx] == elem {
+	for i := 0; i < q.occupancy.count; i++ {
+		if q.at(i) == elem {
 			return true // item found
 		}
 	}
@@ -192,42 +638,707 @@ func (q *Circular[T]) Contains(elem T) bool {
 	return false // item not found
 }
 
+// ContainsRecent returns true if the element is among the n most recently
+// offered elements still in the queue. It scans at most n elements.
+func (q *Circular[T]) ContainsRecent(n int, elem T) bool {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+
+	n = min(n, q.occupancy.count)
+
+	for i := 1; i <= n; i++ {
+		idx := (q.tail - i + len(q.elems)) % len(q.elems)
+
+		if q.elems[idx] == elem {
+			return true
+		}
+	}
+
+	return false
+}
+
 // Peek returns the element at the head of the queue.
+//
+// The head observed by a Peek is cached until the next mutation of the
+// queue, so the subsequent Peeks return it without acquiring the queue lock.
+// The cache is validated using a sequence number incremented by every
+// mutation, Peek falls back to acquiring the lock when the queue was
+// mutated since the head was cached.
 func (q *Circular[T]) Peek() (v T, _ error) {
+	if q.snapshots != nil {
+		h := q.snapshots.load()
+
+		return h.head, q.named(h.err)
+	}
+
+	if q.reads != nil {
+		h := q.readHead()
+
+		return h.elem, q.named(h.err)
+	}
+
+	if h := q.peekHead.Load(); h != nil && h.seq == q.seq.Load() {
+		if h.empty {
+			return v, q.named(ErrNoElementsAvailable)
+		}
+
+		return h.elem, nil
+	}
+
 	q.lock.RLock()
 	defer q.lock.RUnlock()
 
-	if q.isEmpty() {
-		return v, ErrNoElementsAvailable
+	v, err := q.peek()
+
+	// the sequence number cannot change while holding the lock.
+	q.peekHead.Store(&circularHead[T]{
+		seq:   q.seq.Load(),
+		elem:  v,
+		empty: err != nil,
+	})
+
+	return v, q.named(err)
+}
+
+// TryPeek attempts to retrieve, without removing, the head of the queue
+// without waiting for the queue lock. If the lock is held by a writer it
+// returns false without attempting the retrieval, otherwise it returns true
+// along with the element and the error Peek would have returned.
+// False negatives are expected under contention.
+func (q *Circular[T]) TryPeek() (v T, acquired bool, _ error) {
+	if !q.lock.TryRLock() {
+		return v, false, nil
 	}
 
-	return q.elems[q.head], nil
+	defer q.lock.RUnlock()
+
+	v, err := q.peek()
+
+	return v, true, q.named(err)
 }
 
 // Size returns the number of elements in the queue.
+// It does not acquire the queue lock.
 func (q *Circular[T]) Size() int {
+	if q.snapshots != nil {
+		return q.snapshots.load().size
+	}
+
+	if q.reads != nil {
+		return q.readHead().size
+	}
+
+	return int(q.atomicSize.Load())
+}
+
+// Capacity returns the fixed capacity of the queue.
+func (q *Circular[T]) Capacity() int {
+	return len(q.elems)
+}
+
+// Remaining returns the number of elements the queue can hold before it
+// starts overwriting its oldest elements, or rejecting the insertions if the
+// WithoutOverwrite option is provided.
+func (q *Circular[T]) Remaining() int {
+	return len(q.elems) - int(q.atomicSize.Load())
+}
+
+// ToSlice returns a copy of the queue elements, from head to tail, without
+// removing them.
+func (q *Circular[T]) ToSlice() []T {
+	if q.reads != nil {
+		e := q.readElems()
+
+		return e.copyN(len(e.elems))
+	}
+
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+
+	return q.snapshot()
+}
+
+// Each calls fn for the queue elements, from head to tail, without removing
+// or copying them, until fn returns false.
+// It holds the queue lock for reading, thus fn must not modify the queue.
+func (q *Circular[T]) Each(fn func(elem T) bool) {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+
+	for i := 0; i < q.occupancy.count; i++ {
+		if !fn(q.elems[(q.head+i)%len(q.elems)]) {
+			return
+		}
+	}
+}
+
+// PeekN returns a copy of at most n elements from the head of the queue,
+// from head to tail, without removing them.
+func (q *Circular[T]) PeekN(n int) []T {
+	if q.reads != nil {
+		return q.readElems().copyN(n)
+	}
+
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+
+	n = min(max(n, 0), q.occupancy.count)
+
+	elems, _ := q.copyRange(make([]T, 0, n), 0, n, nil)
+
+	return elems
+}
+
+// At returns, without removing it, the element at index i from the head of
+// the queue. It returns the ErrIndexOutOfRange error if i is negative or not
+// lower than the size of the queue.
+func (q *Circular[T]) At(i int) (elem T, _ error) {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+
+	if i < 0 || i >= q.occupancy.count {
+		return elem, q.named(ErrIndexOutOfRange)
+	}
+
+	return q.at(i), nil
+}
+
+// SearchCircular returns the smallest index i, from the head of the queue,
+// at which pred returns true for the element, using a binary search. As
+// for sort.Search, pred must return false for the elements of a prefix of
+// the queue and true for the others, such as the elements not older than a
+// given time in a queue of samples ordered by time. It returns the size of
+// the queue if pred returns false for all of them.
+// It holds the queue lock for reading, thus pred must not modify the queue.
+func SearchCircular[T comparable](q *Circular[T], pred func(elem T) bool) int {
 	q.lock.RLock()
 	defer q.lock.RUnlock()
 
-	return q.size
+	return sort.Search(q.occupancy.count, func(i int) bool {
+		return pred(q.at(i))
+	})
+}
+
+// InspectPage returns a copy of at most limit elements, starting at the
+// cursor, along with the cursor of the next page, in FIFO order. The zero
+// Cursor starts from the head of the queue, the cursor returned along with the
+// last page is Done. The queue lock is held only while copying the page.
+//
+// If the queue was mutated since the cursor was issued it returns the
+// ErrCursorInvalidated error, the inspection has to be restarted.
+func (q *Circular[T]) InspectPage(cursor Cursor, limit int) ([]T, Cursor, error) {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+
+	elems, next, err := inspectPage(cursor, limit, q.seq.Load(), q.occupancy.count, q.copyRange)
+
+	return elems, next, q.named(err)
+}
+
+// ContentionProfile returns, for every queue method sampled by the
+// WithContentionProfiling option, the time spent waiting to acquire the
+// queue lock. It returns nil if the option was not provided.
+func (q *Circular[T]) ContentionProfile() map[string]ContentionStats {
+	return q.lock.contentionProfile()
+}
+
+// RecentOperations returns the last mutating operations of the queue, oldest
+// first, as recorded by the WithCallerTracking option. It returns nil if the
+// option was not provided.
+func (q *Circular[T]) RecentOperations() []OpRecord {
+	return q.tracker.operations()
+}
+
+// MemoryFootprint returns an estimate of the memory retained by the queue,
+// measuring every element using sizeOf, or its shallow size if sizeOf is nil.
+// The capacity of the queue is allocated upfront, thus the free slots are
+// reported as wasted.
+func (q *Circular[T]) MemoryFootprint(sizeOf func(T) uintptr) MemoryReport {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+
+	f := newFootprint(sizeOf, unsafe.Sizeof(*q))
+
+	for i := 0; i < q.occupancy.count; i++ {
+		f.elements(q.elems[(q.head+i)%len(q.elems)])
+	}
+
+	f.slots(len(q.elems))
+	f.copies(q.initialElements)
+
+	return f.done()
+}
+
+// SyncJournal waits until the journal records of the operations completed so
+// far are written, if the WithJournal option is provided.
+func (q *Circular[T]) SyncJournal() {
+	q.journal.flush()
+}
+
+// Name returns the name provided using WithName, or an empty string if the
+// queue is unnamed.
+func (q *Circular[T]) Name() string {
+	return q.name
+}
+
+// named wraps err in a QueueError if the queue is named.
+func (q *Circular[T]) named(err error) error {
+	return nameError(q.name, "Circular", err)
+}
+
+// =================================Marshalling================================
+
+// MarshalJSON serializes the queue elements to a JSON array, from head to tail.
+func (q *Circular[T]) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+
+	if err := q.MarshalJSONTo(&buf); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// MarshalJSONTo streams the queue elements to w as a JSON array, from head to tail.
+// The elements are copied while holding the queue lock and encoded one at a
+// time after releasing it, or before releasing it if the WithRecycler option
+// is provided. The output is identical to the MarshalJSON output.
+func (q *Circular[T]) MarshalJSONTo(w io.Writer) error {
+	q.lock.RLock()
+	elems := q.snapshot()
+
+	// the pooled elements are encoded before any of them can be released.
+	if q.recycler.enabled() {
+		defer q.lock.RUnlock()
+
+		return q.named(encodeJSONArray(w, elems, q.codec.marshal))
+	}
+
+	q.lock.RUnlock()
+
+	return q.named(encodeJSONArray(w, elems, q.codec.marshal))
+}
+
+// UnmarshalJSONFrom reads a JSON array from r and replaces the elements of
+// the queue with its elements, inserted using the same semantics as OfferAll
+// into the emptied queue. If an element cannot be decoded, the error reports
+// its index, and if the elements cannot all be inserted, none of them is and
+// the queue is left unchanged. The decoded elements are held until they are
+// inserted, unless the WithStreamingJSON option is provided.
+func (q *Circular[T]) UnmarshalJSONFrom(r io.Reader) error {
+	return q.named(q.codec.decodeInto(r, q.recycler, q.Offer, q.replaceAll))
+}
+
+// UnmarshalJSON replaces the elements of the queue with the elements of the
+// JSON array data, as UnmarshalJSONFrom does.
+func (q *Circular[T]) UnmarshalJSON(data []byte) error {
+	return q.UnmarshalJSONFrom(bytes.NewReader(data))
+}
+
+// MarshalBinary encodes the queue elements, along with the capacity of the
+// queue and the ring positions of its head and tail, using encoding/gob, so
+// that they can be restored exactly using UnmarshalBinary: once restored, the
+// insertions overwrite the same positions they would have overwritten in the
+// encoded queue. The elements are copied while holding the queue lock and
+// encoded after releasing it, or before releasing it if the WithRecycler
+// option is provided.
+func (q *Circular[T]) MarshalBinary() ([]byte, error) {
+	q.lock.RLock()
+
+	snapshot := &binarySnapshot[T]{
+		Capacity: len(q.elems),
+		Elems:    q.snapshot(),
+		Head:     q.head,
+		Tail:     q.tail,
+	}
+
+	// the pooled elements are encoded before any of them can be released.
+	if q.recycler.enabled() {
+		defer q.lock.RUnlock()
+	} else {
+		q.lock.RUnlock()
+	}
+
+	data, err := snapshot.encode()
+
+	return data, q.named(err)
+}
+
+// UnmarshalBinary replaces the queue elements with the elements encoded by
+// MarshalBinary, at the same ring positions, without validating them. The
+// replaced elements are released if the WithRecycler option is provided.
+//
+// It returns the ErrCapacityMismatch error, leaving the queue unchanged, if
+// the elements were encoded from a queue with another capacity.
+func (q *Circular[T]) UnmarshalBinary(data []byte) error {
+	snapshot, err := decodeBinarySnapshot[T](data)
+	if err != nil {
+		return q.named(err)
+	}
+
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if err := snapshot.checkCapacity(len(q.elems)); err != nil {
+		return q.named(err)
+	}
+
+	if len(snapshot.Elems) > len(q.elems) ||
+		snapshot.Head < 0 || snapshot.Head >= len(q.elems) ||
+		snapshot.Tail < 0 || snapshot.Tail >= len(q.elems) {
+		return q.named(errInvalidBinarySnapshot)
+	}
+
+	if q.recycler.enabled() {
+		for i := 0; i < q.occupancy.count; i++ {
+			q.recycler.discard(q.elems[(q.head+i)%len(q.elems)])
+		}
+	}
+
+	clear(q.elems)
+
+	for i, item := range snapshot.Elems {
+		q.elems[(snapshot.Head+i)%len(q.elems)] = item
+	}
+
+	q.head = snapshot.Head
+	q.tail = snapshot.Tail
+	q.occupancy.reset(len(snapshot.Elems))
+
+	// the restored elements are journaled as offered after a clear.
+	q.journal.recordOp(JournalClear, 0)
+
+	for i, item := range snapshot.Elems {
+		q.journal.record(JournalOffer, item, i+1)
+	}
+
+	q.mutated()
+
+	q.tracker.recordBulk()
+
+	return nil
+}
+
+// Dump writes a snapshot of the queue elements to w, in the format of
+// MarshalJSONTo, so that it can be restored using UnmarshalJSONFrom.
+func (q *Circular[T]) Dump(w io.Writer) error {
+	return q.MarshalJSONTo(w)
 }
 
 // ===================================Helpers==================================
 
+// discard releases an element dropped after being retrieved from the queue.
+func (q *Circular[T]) discard(item T) {
+	q.recycler.discard(item)
+}
+
+// offerValid adds an element into the queue, as offer does, if it is
+// admitted by the validator and the idempotency window.
+func (q *Circular[T]) offerValid(item T) error {
+	if err := q.validator.check(item); err != nil {
+		return err
+	}
+
+	if err := q.window.check(item); err != nil {
+		return err
+	}
+
+	if err := q.offer(item); err != nil {
+		return err
+	}
+
+	q.window.remember(item)
+
+	return nil
+}
+
+// offer adds an element into the queue, overwriting the oldest element
+// if the queue is full, or returning the ErrQueueIsFull error if rejectFull
+// is set.
+func (q *Circular[T]) offer(item T) error {
+	evicted, wasEvicted, err := q.offerEvicting(item)
+	if wasEvicted {
+		q.recycler.discard(evicted)
+	}
+
+	return err
+}
+
+// offerEvicting adds an element into the queue as offer does, returning the
+// overwritten element instead of releasing it.
+func (q *Circular[T]) offerEvicting(item T) (evicted T, wasEvicted bool, _ error) {
+	// a full queue overwrites its oldest element.
+	if err := q.occupancy.admit(1, 0); err != nil {
+		if q.rejectFull {
+			return evicted, false, err
+		}
+
+		evicted, wasEvicted = q.elems[q.tail], true
+	}
+
+	q.elems[q.tail] = item
+	q.tail = (q.tail + 1) % len(q.elems)
+
+	q.journal.record(JournalOffer, item, q.occupancy.count)
+
+	q.mutated()
+
+	return evicted, wasEvicted, nil
+}
+
+// peek returns the element at the head of the queue without removing it.
+func (q *Circular[T]) peek() (v T, _ error) {
+	if q.isEmpty() {
+		return v, ErrNoElementsAvailable
+	}
+
+	return q.elems[q.head], nil
+}
+
 // get returns the element at the head of the queue.
 func (q *Circular[T]) get() (v T, _ error) {
+	v, err := q.removeHead()
+	if err == nil {
+		q.journal.record(JournalGet, v, q.occupancy.count)
+	}
+
+	return v, err
+}
+
+// removeHead removes and returns the element at the head of the queue,
+// without journaling its removal.
+func (q *Circular[T]) removeHead() (v T, _ error) {
 	if q.isEmpty() {
 		return v, ErrNoElementsAvailable
 	}
 
 	item := q.elems[q.head]
+
+	// zero the vacated slot, so it does not retain the removed element.
+	var zero T
+	q.elems[q.head] = zero
+
 	q.head = (q.head + 1) % len(q.elems)
-	q.size--
+	q.occupancy.releaseAdmission(1, 0)
+
+	// once the queue was full, the tail may have moved past the head while
+	// overwriting elements, the next element is inserted after the last one.
+	q.tail = (q.head + q.occupancy.count) % len(q.elems)
+
+	q.mutated()
 
 	return item, nil
 }
 
+// moveLock returns the lock of the queue.
+func (q *Circular[T]) moveLock() *profiledRWMutex {
+	return &q.lock
+}
+
+// moveCandidate returns the element to be moved by Move or MoveMatching,
+// along with its offset from the head of the queue.
+func (q *Circular[T]) moveCandidate(pred func(T) bool) (item T, pos int, _ error) {
+	if q.isEmpty() {
+		return item, 0, ErrNoElementsAvailable
+	}
+
+	if pred == nil {
+		return q.elems[q.head], 0, nil
+	}
+
+	for i := 0; i < q.occupancy.count; i++ {
+		if item := q.elems[(q.head+i)%len(q.elems)]; pred(item) {
+			return item, i, nil
+		}
+	}
+
+	return item, 0, ErrNoElementsAvailable
+}
+
+// moveOut removes the element at the given offset from the head of the
+// queue, shifting the following elements towards the head.
+func (q *Circular[T]) moveOut(item T, pos int) {
+	q.tracker.record(item)
+
+	if pos == 0 {
+		_, _ = q.get()
+
+		return
+	}
+
+	for i := pos; i < q.occupancy.count-1; i++ {
+		q.elems[(q.head+i)%len(q.elems)] = q.elems[(q.head+i+1)%len(q.elems)]
+	}
+
+	// zero the vacated slot, so it does not retain the last element.
+	var zero T
+	q.elems[(q.head+q.occupancy.count-1)%len(q.elems)] = zero
+
+	q.occupancy.releaseAdmission(1, 0)
+
+	q.tail = (q.head + q.occupancy.count) % len(q.elems)
+
+	q.journal.recordRemove(item, pos, q.occupancy.count)
+
+	q.mutated()
+}
+
+// moveAdmit admits every valid element, since the queue overwrites its
+// oldest elements once it is full, unless the WithoutOverwrite option is
+// provided.
+func (q *Circular[T]) moveAdmit(item T) error {
+	if err := q.validator.check(item); err != nil {
+		return err
+	}
+
+	if err := q.window.check(item); err != nil {
+		return err
+	}
+
+	if q.rejectFull && q.occupancy.full() {
+		return ErrQueueIsFull
+	}
+
+	return nil
+}
+
+// moveIn inserts the element moved into the queue to its tail.
+func (q *Circular[T]) moveIn(item T) {
+	q.tracker.record(item)
+
+	_ = q.offer(item)
+
+	q.window.remember(item)
+}
+
+// moveElems returns a copy of the elements.
+func (q *Circular[T]) moveElems() []T {
+	return q.snapshot()
+}
+
+// moveTail returns the offset from the head of the element at the tail of
+// the queue, provided it is item.
+func (q *Circular[T]) moveTail(item T) (pos int, ok bool) {
+	if q.isEmpty() || q.elems[(q.head+q.occupancy.count-1)%len(q.elems)] != item {
+		return 0, false
+	}
+
+	return q.occupancy.count - 1, true
+}
+
+// verifyOccupancy checks that the occupancy counts the elements held by the
+// queue. The elements of a full queue cannot be told apart from the empty
+// slots, while the elements of a queue which is not full end at the tail.
+func (q *Circular[T]) verifyOccupancy() {
+	structural := (q.tail - q.head + len(q.elems)) % len(q.elems)
+
+	if q.occupancy.count == len(q.elems) {
+		structural = len(q.elems)
+	}
+
+	q.occupancy.verify(structural)
+}
+
+// publishSnapshot publishes the size and the head of the queue, if
+// WithReadSnapshotting is provided.
+func (q *Circular[T]) publishSnapshot() {
+	v, err := q.peek()
+
+	q.snapshots.publish(q.occupancy.count, v, err)
+}
+
+// mutated publishes a mutation of the queue to the lock-free readers.
+func (q *Circular[T]) mutated() {
+	q.atomicSize.Store(int64(q.occupancy.count))
+	q.seq.Add(1)
+}
+
+// clear removes and returns all elements from the queue.
+func (q *Circular[T]) clear() []T {
+	elems := make([]T, 0, q.occupancy.count)
+
+	for {
+		elem, err := q.removeHead()
+		if err != nil {
+			break
+		}
+
+		elems = append(elems, elem)
+	}
+
+	// clear the queue
+	q.head = 0
+	q.tail = 0
+
+	q.journal.recordOp(JournalClear, 0)
+
+	return elems
+}
+
+// copyRange appends the elements in [from, to), relative to the head of the
+// queue, to dst.
+func (q *Circular[T]) copyRange(dst []T, from, to int, _ any) ([]T, any) {
+	for i := from; i < to; i++ {
+		dst = append(dst, q.at(i))
+	}
+
+	return dst, nil
+}
+
+// at returns the element at index i from the head of the queue.
+func (q *Circular[T]) at(i int) T {
+	return q.elems[(q.head+i)%len(q.elems)]
+}
+
+// exactSize returns the number of elements in the queue, bypassing the
+// cache of the WithCachedReads option.
+func (q *Circular[T]) exactSize() int {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+
+	return q.occupancy.count
+}
+
+// readHead returns the size and the head of the queue cached by the
+// WithCachedReads option, refreshing them if they expired.
+func (q *Circular[T]) readHead() *cachedHead[T] {
+	if h := q.reads.freshHead(); h != nil {
+		return h
+	}
+
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+
+	elem, err := q.peek()
+
+	return q.reads.storeHead(q.occupancy.count, elem, err)
+}
+
+// readElems returns the elements of the queue cached by the WithCachedReads
+// option, refreshing them if they expired.
+func (q *Circular[T]) readElems() *cachedElems[T] {
+	if e := q.reads.freshElems(); e != nil {
+		return e
+	}
+
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+
+	return q.reads.storeElems(q.snapshot())
+}
+
+// snapshot returns a copy of the queue elements, from head to tail.
+func (q *Circular[T]) snapshot() []T {
+	elems := make([]T, q.occupancy.count)
+
+	for i := range elems {
+		elems[i] = q.elems[(q.head+i)%len(q.elems)]
+	}
+
+	return elems
+}
+
 // isEmpty returns true if the queue is empty.
 func (q *Circular[T]) isEmpty() bool {
-	return q.size == 0
+	return q.occupancy.count == 0
 }
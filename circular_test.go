@@ -1,8 +1,12 @@
 package queue_test
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
 	"reflect"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/adrianbrad/queue"
@@ -34,6 +38,53 @@ func TestCircular(t *testing.T) {
 		}
 	})
 
+	t.Run("NilElems", func(t *testing.T) {
+		t.Parallel()
+
+		circularQueue := queue.NewCircular[int](nil, 2)
+
+		if !circularQueue.IsEmpty() {
+			t.Fatalf("expected queue to be empty, got size %d", circularQueue.Size())
+		}
+
+		if err := circularQueue.Offer(1); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if elem, err := circularQueue.Get(); err != nil || elem != 1 {
+			t.Fatalf("expected elem to be 1, got %d, err %v", elem, err)
+		}
+	})
+
+	t.Run("InvalidCapacity", func(t *testing.T) {
+		t.Parallel()
+
+		testCases := map[string]func(){
+			"Zero": func() {
+				queue.NewCircular([]int{1}, 0)
+			},
+			"NegativeOption": func() {
+				queue.NewCircular([]int{}, 1, queue.WithCapacity(-1))
+			},
+		}
+
+		for name, newQueue := range testCases {
+			newQueue := newQueue
+
+			t.Run(name, func(t *testing.T) {
+				t.Parallel()
+
+				defer func() {
+					if recover() == nil {
+						t.Fatal("expected a non positive capacity to panic")
+					}
+				}()
+
+				newQueue()
+			})
+		}
+	})
+
 	t.Run("Get", func(t *testing.T) {
 		t.Parallel()
 
@@ -214,6 +265,79 @@ func TestCircular(t *testing.T) {
 				t.Fatalf("expected elem to not be found")
 			}
 		})
+
+		t.Run("States", func(t *testing.T) {
+			t.Parallel()
+
+			testCases := map[string]struct {
+				newQueue func() *queue.Circular[int]
+				found    []int
+				notFound []int
+			}{
+				"NotWrapped": {
+					newQueue: func() *queue.Circular[int] {
+						circularQueue := queue.NewCircular([]int{1, 2, 3}, 4)
+
+						_, _ = circularQueue.Get()
+
+						return circularQueue
+					},
+					found:    []int{2, 3},
+					notFound: []int{1, 4},
+				},
+				"Wrapped": {
+					newQueue: func() *queue.Circular[int] {
+						circularQueue := queue.NewCircular([]int{1, 2, 3}, 3)
+
+						_, _ = circularQueue.Get()
+						_, _ = circularQueue.Get()
+
+						// the tail wraps around, behind the head.
+						_ = circularQueue.Offer(4)
+						_ = circularQueue.Offer(5)
+
+						return circularQueue
+					},
+					found:    []int{3, 4, 5},
+					notFound: []int{1, 2},
+				},
+				"FullyOverwritten": {
+					newQueue: func() *queue.Circular[int] {
+						circularQueue := queue.NewCircular([]int{1, 2, 3}, 3)
+
+						for elem := 4; elem <= 6; elem++ {
+							_ = circularQueue.Offer(elem)
+						}
+
+						return circularQueue
+					},
+					found:    []int{4, 5, 6},
+					notFound: []int{1, 2, 3},
+				},
+			}
+
+			for name, tc := range testCases {
+				tc := tc
+
+				t.Run(name, func(t *testing.T) {
+					t.Parallel()
+
+					circularQueue := tc.newQueue()
+
+					for _, elem := range tc.found {
+						if !circularQueue.Contains(elem) {
+							t.Fatalf("expected elem %d to be found", elem)
+						}
+					}
+
+					for _, elem := range tc.notFound {
+						if circularQueue.Contains(elem) {
+							t.Fatalf("expected elem %d to not be found", elem)
+						}
+					}
+				})
+			}
+		})
 	})
 
 	t.Run("Clear", func(t *testing.T) {
@@ -269,6 +393,119 @@ func TestCircular(t *testing.T) {
 		}
 	})
 
+	t.Run("ResetWrapped", func(t *testing.T) {
+		t.Parallel()
+
+		circularQueue := queue.NewCircular([]int{1, 2}, 3)
+
+		// wrap the ring around.
+		for i := 3; i <= 7; i++ {
+			_ = circularQueue.Offer(i)
+			_, _ = circularQueue.Get()
+		}
+
+		circularQueue.Reset()
+
+		assertCircularState(t, circularQueue, []int{1, 2})
+
+		_ = circularQueue.Offer(3)
+
+		assertCircularState(t, circularQueue, []int{1, 2, 3})
+	})
+
+	t.Run("OfferAfterOverwriteAndGet", func(t *testing.T) {
+		t.Parallel()
+
+		circularQueue := queue.NewCircular([]int{1, 2, 3}, 3)
+
+		// overwrites the head.
+		_ = circularQueue.Offer(4)
+
+		if elem, _ := circularQueue.Get(); elem != 4 {
+			t.Fatalf("expected element to be 4, got %d", elem)
+		}
+
+		_ = circularQueue.Offer(5)
+
+		assertCircularState(t, circularQueue, []int{2, 3, 5})
+	})
+
+	t.Run("ResetInitialExceedingCapacity", func(t *testing.T) {
+		t.Parallel()
+
+		circularQueue := queue.NewCircular([]int{1, 2, 3, 4}, 2)
+
+		assertCircularState(t, circularQueue, []int{1, 2})
+
+		_, _ = circularQueue.Get()
+
+		circularQueue.Reset()
+
+		assertCircularState(t, circularQueue, []int{1, 2})
+
+		// the ring arithmetic holds after the reset: the offer overwrites
+		// the oldest element and the drain returns the remaining ones.
+		_ = circularQueue.Offer(5)
+
+		if size := circularQueue.Size(); size > circularQueue.Capacity() {
+			t.Fatalf("expected size to be at most %d, got %d", circularQueue.Capacity(), size)
+		}
+
+		var drained []int
+
+		for !circularQueue.IsEmpty() {
+			elem, err := circularQueue.Get()
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			drained = append(drained, elem)
+		}
+
+		if expected := []int{5, 2}; !reflect.DeepEqual(expected, drained) {
+			t.Fatalf("expected drained elements to be %v, got %v", expected, drained)
+		}
+
+		circularQueue.Reset()
+
+		if !circularQueue.Contains(2) || circularQueue.Contains(3) {
+			t.Fatalf("expected queue to contain only the admitted initial elements")
+		}
+	})
+
+	t.Run("ResetClonerInitialExceedingCapacity", func(t *testing.T) {
+		t.Parallel()
+
+		one, two, three := 1, 2, 3
+
+		var clones int
+
+		circularQueue := queue.NewCircular(
+			[]*int{&one, &two, &three},
+			2,
+			queue.WithResetCloner(func(elem *int) *int {
+				clones++
+
+				clone := *elem
+
+				return &clone
+			}),
+		)
+
+		circularQueue.Reset()
+
+		// the dropped initial element is neither cloned nor restored.
+		if clones != 4 {
+			t.Fatalf("expected 4 clones, got %d", clones)
+		}
+
+		elems := circularQueue.Clear()
+
+		if len(elems) != 2 || *elems[0] != 1 || *elems[1] != 2 {
+			t.Fatalf("expected elements to be [1 2], got %d elements", len(elems))
+		}
+	})
+
 	t.Run("Iterator", func(t *testing.T) {
 		elems := []int{1, 2, 3, 4}
 
@@ -290,9 +527,816 @@ func TestCircular(t *testing.T) {
 			t.Fatalf("expected elements to be %v, got %v", elems, iterElems)
 		}
 	})
+
+	t.Run("IteratorConcurrentWithOfferAndGet", func(t *testing.T) {
+		t.Parallel()
+
+		// the capacity holds all the elements, so that none is overwritten.
+		assertIteratorConcurrentConservation(t, queue.NewCircular([]int{}, 1000))
+	})
+
+	t.Run("WithResetCloner", func(t *testing.T) {
+		t.Parallel()
+
+		type elem struct {
+			value int
+		}
+
+		clone := func(e *elem) *elem {
+			c := *e
+
+			return &c
+		}
+
+		t.Run("Cloned", func(t *testing.T) {
+			t.Parallel()
+
+			elems := []*elem{{value: 1}, {value: 2}}
+
+			circularQueue := queue.NewCircular(elems, 2, queue.WithResetCloner(clone))
+
+			elems[0].value = 10
+
+			for i := 0; i < 2; i++ {
+				circularQueue.Reset()
+
+				head, err := circularQueue.Get()
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+
+				if head.value != 1 {
+					t.Fatalf("expected head value to be %d, got %d", 1, head.value)
+				}
+
+				head.value = 100
+			}
+		})
+
+		t.Run("Shallow", func(t *testing.T) {
+			t.Parallel()
+
+			elems := []*elem{{value: 1}, {value: 2}}
+
+			circularQueue := queue.NewCircular(elems, 2)
+
+			elems[0].value = 10
+
+			circularQueue.Reset()
+
+			head, err := circularQueue.Get()
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if head.value != 10 {
+				t.Fatalf("expected head value to be %d, got %d", 10, head.value)
+			}
+		})
+
+		t.Run("MismatchedType", func(t *testing.T) {
+			t.Parallel()
+
+			defer func() {
+				if p := recover(); p == nil {
+					t.Fatalf("expected panic")
+				}
+			}()
+
+			_ = queue.NewCircular([]int{1}, 1, queue.WithResetCloner(clone))
+		})
+	})
+
+	t.Run("Try", func(t *testing.T) {
+		t.Parallel()
+
+		t.Run("Acquired", func(t *testing.T) {
+			t.Parallel()
+
+			circularQueue := queue.NewCircular([]int{1}, 2)
+
+			if acquired, err := circularQueue.TryOffer(2); !acquired || err != nil {
+				t.Fatalf("expected lock to be acquired and no error, got %t and %v", acquired, err)
+			}
+
+			elem, acquired, err := circularQueue.TryPeek()
+			if !acquired || err != nil || elem != 1 {
+				t.Fatalf("expected elem %d, lock acquired and no error, got %d, %t and %v", 1, elem, acquired, err)
+			}
+
+			elem, acquired, err = circularQueue.TryGet()
+			if !acquired || err != nil || elem != 1 {
+				t.Fatalf("expected elem %d, lock acquired and no error, got %d, %t and %v", 1, elem, acquired, err)
+			}
+		})
+
+		t.Run("Contended", func(t *testing.T) {
+			t.Parallel()
+
+			holder := newLockHolder()
+
+			circularQueue := queue.NewCircular([]int{1}, 2, queue.WithResetCloner(holder.Clone))
+
+			holder.Lock(circularQueue.Reset)
+			defer holder.Unlock()
+
+			if acquired, err := circularQueue.TryOffer(2); acquired || err != nil {
+				t.Fatalf("expected lock to not be acquired and no error, got %t and %v", acquired, err)
+			}
+
+			if _, acquired, err := circularQueue.TryPeek(); acquired || err != nil {
+				t.Fatalf("expected lock to not be acquired and no error, got %t and %v", acquired, err)
+			}
+
+			if _, acquired, err := circularQueue.TryGet(); acquired || err != nil {
+				t.Fatalf("expected lock to not be acquired and no error, got %t and %v", acquired, err)
+			}
+		})
+	})
+
+	t.Run("MarshalJSON", func(t *testing.T) {
+		t.Parallel()
+
+		t.Run("Streamed", func(t *testing.T) {
+			t.Parallel()
+
+			circularQueue := queue.NewCircular([]int{1, 2, 3}, 4)
+
+			_, _ = circularQueue.Get()
+
+			_ = circularQueue.Offer(4)
+
+			marshaled, err := circularQueue.MarshalJSON()
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			expected, err := json.Marshal([]int{2, 3, 4})
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if !bytes.Equal(expected, marshaled) {
+				t.Fatalf("expected json to be %s, got %s", expected, marshaled)
+			}
+
+			var buf bytes.Buffer
+
+			if err := circularQueue.MarshalJSONTo(&buf); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if !bytes.Equal(marshaled, buf.Bytes()) {
+				t.Fatalf("expected streamed json to be %s, got %s", marshaled, buf.Bytes())
+			}
+		})
+
+		t.Run("Empty", func(t *testing.T) {
+			t.Parallel()
+
+			circularQueue := queue.NewCircular([]int{}, 3)
+
+			marshaled, err := json.Marshal(circularQueue)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if string(marshaled) != "[]" {
+				t.Fatalf("expected json to be [], got %s", marshaled)
+			}
+		})
+	})
+
+	t.Run("UnmarshalJSONFrom", func(t *testing.T) {
+		t.Parallel()
+
+		t.Run("Success", func(t *testing.T) {
+			t.Parallel()
+
+			circularQueue := queue.NewCircular([]int{1}, 3)
+
+			if err := circularQueue.UnmarshalJSONFrom(strings.NewReader("[2, 3]")); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			// the elements of the queue are replaced.
+			if elems := circularQueue.Clear(); !reflect.DeepEqual([]int{2, 3}, elems) {
+				t.Fatalf("expected elements to be %v, got %v", []int{2, 3}, elems)
+			}
+		})
+
+		t.Run("InvalidJSON", func(t *testing.T) {
+			t.Parallel()
+
+			circularQueue := queue.NewCircular([]int{}, 3)
+
+			if err := circularQueue.UnmarshalJSONFrom(strings.NewReader(`{"a": 1}`)); err == nil {
+				t.Fatalf("expected error")
+			}
+
+			if err := circularQueue.UnmarshalJSONFrom(strings.NewReader(`[1, "a"]`)); err == nil {
+				t.Fatalf("expected error")
+			}
+		})
+	})
+
+	t.Run("ContainsRecent", func(t *testing.T) {
+		t.Parallel()
+
+		circularQueue := queue.NewCircular([]int{1, 2, 3}, 3)
+
+		if _, err := circularQueue.Get(); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		// the tail wraps around to the first slot.
+		if err := circularQueue.Offer(4); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		testCases := []struct {
+			n        int
+			elem     int
+			expected bool
+		}{
+			{n: 1, elem: 4, expected: true},
+			{n: 1, elem: 3, expected: false},
+			{n: 2, elem: 3, expected: true},
+			{n: 3, elem: 2, expected: true},
+			{n: 3, elem: 1, expected: false},
+		}
+
+		for _, tc := range testCases {
+			if got := circularQueue.ContainsRecent(tc.n, tc.elem); got != tc.expected {
+				t.Fatalf("expected ContainsRecent(%d, %d) to be %t", tc.n, tc.elem, tc.expected)
+			}
+		}
+
+		for i := 0; i < 2; i++ {
+			if _, err := circularQueue.Get(); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+		}
+
+		if circularQueue.ContainsRecent(3, 2) {
+			t.Fatalf("expected removed element not to be found")
+		}
+	})
+
+	t.Run("ClearIf", func(t *testing.T) {
+		t.Parallel()
+
+		t.Run("True", func(t *testing.T) {
+			t.Parallel()
+
+			circularQueue := queue.NewCircular([]int{1, 2, 3}, 4)
+
+			var snapshot queue.ClearSnapshot[int]
+
+			elems, cleared := circularQueue.ClearIf(func(s queue.ClearSnapshot[int]) bool {
+				snapshot = s
+
+				return s.Size >= 3
+			})
+			if !cleared {
+				t.Fatalf("expected queue to be cleared")
+			}
+
+			expectedSnapshot := queue.ClearSnapshot[int]{Size: 3, Capacity: 4, Head: 1}
+
+			if snapshot != expectedSnapshot {
+				t.Fatalf("expected snapshot to be %+v, got %+v", expectedSnapshot, snapshot)
+			}
+
+			if !reflect.DeepEqual([]int{1, 2, 3}, elems) {
+				t.Fatalf("expected elements to be %v, got %v", []int{1, 2, 3}, elems)
+			}
+
+			if !circularQueue.IsEmpty() {
+				t.Fatalf("expected queue to be empty")
+			}
+		})
+
+		t.Run("False", func(t *testing.T) {
+			t.Parallel()
+
+			circularQueue := queue.NewCircular([]int{1, 2, 3}, 4)
+
+			elems, cleared := circularQueue.ClearIf(func(s queue.ClearSnapshot[int]) bool {
+				return s.Size > 3
+			})
+			if cleared || elems != nil {
+				t.Fatalf("expected queue not to be cleared, got %v and %t", elems, cleared)
+			}
+
+			if size := circularQueue.Size(); size != 3 {
+				t.Fatalf("expected size to be %d, got %d", 3, size)
+			}
+		})
+	})
+
+	t.Run("LockFreeReads", func(t *testing.T) {
+		t.Parallel()
+
+		t.Run("PeekAfterMutations", func(t *testing.T) {
+			t.Parallel()
+
+			circularQueue := queue.NewCircular([]int{1, 2}, 2)
+
+			if e, err := circularQueue.Peek(); err != nil || e != 1 {
+				t.Fatalf("expected elem to be %d and no error, got %d and %v", 1, e, err)
+			}
+
+			// overwrites the head.
+			if err := circularQueue.Offer(3); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if e, err := circularQueue.Peek(); err != nil || e != 3 {
+				t.Fatalf("expected elem to be %d and no error, got %d and %v", 3, e, err)
+			}
+
+			_ = circularQueue.Clear()
+
+			if _, err := circularQueue.Peek(); !errors.Is(err, queue.ErrNoElementsAvailable) {
+				t.Fatalf("expected error to be %v, got %v", queue.ErrNoElementsAvailable, err)
+			}
+
+			if !circularQueue.IsEmpty() || circularQueue.Size() != 0 {
+				t.Fatalf("expected queue to be empty")
+			}
+
+			circularQueue.Reset()
+
+			if e, err := circularQueue.Peek(); err != nil || e != 1 {
+				t.Fatalf("expected elem to be %d and no error, got %d and %v", 1, e, err)
+			}
+
+			if size := circularQueue.Size(); size != 2 {
+				t.Fatalf("expected size to be %d, got %d", 2, size)
+			}
+		})
+
+		t.Run("ConcurrentWriter", func(t *testing.T) {
+			t.Parallel()
+
+			type pair struct{ a, b int }
+
+			const (
+				readers = 8
+				writes  = 5000
+			)
+
+			circularQueue := queue.NewCircular([]pair{}, 4)
+
+			var wg sync.WaitGroup
+
+			wg.Add(readers)
+
+			done := make(chan struct{})
+
+			for r := 0; r < readers; r++ {
+				go func() {
+					defer wg.Done()
+
+					last := -1
+
+					for {
+						select {
+						case <-done:
+							return
+						default:
+						}
+
+						if size := circularQueue.Size(); size < 0 || size > 4 {
+							t.Errorf("expected size to be within capacity, got %d", size)
+						}
+
+						e, err := circularQueue.Peek()
+						if err != nil {
+							continue
+						}
+
+						if e.a != e.b {
+							t.Errorf("expected untorn element, got %+v", e)
+						}
+
+						// the writer removes every element after offering it,
+						// thus the observed heads never decrease.
+						if e.a < last {
+							t.Errorf("expected head %d to follow %d", e.a, last)
+						}
+
+						last = e.a
+					}
+				}()
+			}
+
+			for i := 0; i < writes; i++ {
+				_ = circularQueue.Offer(pair{a: i, b: i})
+				_, _ = circularQueue.Get()
+			}
+
+			close(done)
+
+			wg.Wait()
+		})
+	})
+
+	t.Run("WithoutOverwrite", func(t *testing.T) {
+		t.Parallel()
+
+		t.Run("Full", func(t *testing.T) {
+			t.Parallel()
+
+			circularQueue := queue.NewCircular([]int{1, 2}, 3, queue.WithoutOverwrite())
+
+			if err := circularQueue.Offer(3); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if err := circularQueue.Offer(4); !errors.Is(err, queue.ErrQueueIsFull) {
+				t.Fatalf("expected error to be %v, got %v", queue.ErrQueueIsFull, err)
+			}
+
+			if circularQueue.CanOffer(1) {
+				t.Fatal("expected a full queue not to accept an element")
+			}
+
+			assertCircularState(t, circularQueue, []int{1, 2, 3})
+		})
+
+		t.Run("Wrapped", func(t *testing.T) {
+			t.Parallel()
+
+			circularQueue := queue.NewCircular([]int{1, 2, 3}, 3, queue.WithoutOverwrite())
+
+			for i := 0; i < 2; i++ {
+				if _, err := circularQueue.Get(); err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+			}
+
+			// the tail wraps around to the start of the ring.
+			if n, err := circularQueue.OfferSome(4, 5, 6); n != 2 || !errors.Is(err, queue.ErrQueueIsFull) {
+				t.Fatalf("expected 2 elements inserted and error %v, got %d and %v", queue.ErrQueueIsFull, n, err)
+			}
+
+			assertCircularState(t, circularQueue, []int{3, 4, 5})
+
+			if err := circularQueue.Rotate(); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if _, err := circularQueue.Exchange(6); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			assertCircularState(t, circularQueue, []int{5, 3, 6})
+		})
+
+		t.Run("OfferAll", func(t *testing.T) {
+			t.Parallel()
+
+			circularQueue := queue.NewCircular([]int{1}, 3, queue.WithoutOverwrite())
+
+			if err := circularQueue.OfferAll(2, 3, 4); !errors.Is(err, queue.ErrQueueIsFull) {
+				t.Fatalf("expected error to be %v, got %v", queue.ErrQueueIsFull, err)
+			}
+
+			assertCircularState(t, circularQueue, []int{1})
+
+			if err := circularQueue.OfferAll(2, 3); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			assertCircularState(t, circularQueue, []int{1, 2, 3})
+		})
+
+		t.Run("Reset", func(t *testing.T) {
+			t.Parallel()
+
+			circularQueue := queue.NewCircular([]int{1, 2}, 2, queue.WithoutOverwrite())
+
+			if _, err := circularQueue.Get(); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if err := circularQueue.Offer(3); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			circularQueue.Reset()
+
+			if err := circularQueue.Offer(4); !errors.Is(err, queue.ErrQueueIsFull) {
+				t.Fatalf("expected error to be %v, got %v", queue.ErrQueueIsFull, err)
+			}
+
+			assertCircularState(t, circularQueue, []int{1, 2})
+
+			circularQueue.Clear()
+
+			if !circularQueue.CanOffer(2) {
+				t.Fatal("expected an empty queue to accept 2 elements")
+			}
+		})
+
+		t.Run("Move", func(t *testing.T) {
+			t.Parallel()
+
+			src := queue.NewLinked([]int{1})
+			dst := queue.NewCircular([]int{2}, 1, queue.WithoutOverwrite())
+
+			if _, err := queue.Move[int](src, dst); !errors.Is(err, queue.ErrQueueIsFull) {
+				t.Fatalf("expected error to be %v, got %v", queue.ErrQueueIsFull, err)
+			}
+
+			if src.Size() != 1 {
+				t.Fatalf("expected the element to stay in the source queue, got %v", src.ToSlice())
+			}
+
+			assertCircularState(t, dst, []int{2})
+		})
+	})
+
+	t.Run("OfferWithEvicted", func(t *testing.T) {
+		t.Parallel()
+
+		t.Run("Wraparound", func(t *testing.T) {
+			t.Parallel()
+
+			var released []int
+
+			circularQueue := queue.NewCircular(
+				[]int{1, 2, 3},
+				3,
+				queue.WithRecycler(func() int { return 0 }, func(elem int) { released = append(released, elem) }),
+			)
+
+			if _, err := circularQueue.Get(); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if evicted, wasEvicted, err := circularQueue.OfferWithEvicted(4); err != nil || wasEvicted {
+				t.Fatalf("expected no eviction while there is room, got %d, %t, %v", evicted, wasEvicted, err)
+			}
+
+			// the tail wrapped around to index 0, the next insertions
+			// overwrite 2 and 3, then 4 at the start of the ring.
+			for _, expected := range []int{2, 3, 4} {
+				evicted, wasEvicted, err := circularQueue.OfferWithEvicted(expected + 3)
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+
+				if !wasEvicted || evicted != expected {
+					t.Fatalf("expected %d to be evicted, got %d, %t", expected, evicted, wasEvicted)
+				}
+			}
+
+			if len(released) != 0 {
+				t.Fatalf("expected the evicted elements not to be released, got %v", released)
+			}
+
+			if err := circularQueue.Offer(8); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if !reflect.DeepEqual([]int{5}, released) {
+				t.Fatalf("expected the element overwritten by Offer to be released, got %v", released)
+			}
+		})
+
+		t.Run("WithoutOverwrite", func(t *testing.T) {
+			t.Parallel()
+
+			circularQueue := queue.NewCircular([]int{1}, 1, queue.WithoutOverwrite())
+
+			evicted, wasEvicted, err := circularQueue.OfferWithEvicted(2)
+			if !errors.Is(err, queue.ErrQueueIsFull) {
+				t.Fatalf("expected error to be %v, got %v", queue.ErrQueueIsFull, err)
+			}
+
+			if wasEvicted || evicted != 0 {
+				t.Fatalf("expected no eviction, got %d, %t", evicted, wasEvicted)
+			}
+
+			assertCircularState(t, circularQueue, []int{1})
+		})
+	})
+}
+
+// circularModel is the reference implementation the Circular queue is
+// checked against: once full, an offered element overwrites the slot
+// following the previously overwritten one, starting from the head, and
+// once an element is removed the elements are offered after the last one.
+type circularModel struct {
+	initial  []int
+	capacity int
+	elems    []int
+	// overwrite is the position of the next element overwritten by Offer,
+	// relative to the head.
+	overwrite int
+}
+
+func newCircularModel(initial []int, capacity int) *circularModel {
+	m := &circularModel{
+		initial:  initial[:min(len(initial), capacity)],
+		capacity: capacity,
+	}
+
+	m.reset()
+
+	return m
+}
+
+func (m *circularModel) reset() {
+	m.elems = append([]int(nil), m.initial...)
+	m.overwrite = len(m.elems) % m.capacity
+}
+
+func (m *circularModel) offer(elem int) {
+	if len(m.elems) < m.capacity {
+		m.elems = append(m.elems, elem)
+		m.overwrite = len(m.elems) % m.capacity
+
+		return
+	}
+
+	m.elems[m.overwrite] = elem
+	m.overwrite = (m.overwrite + 1) % m.capacity
+}
+
+func (m *circularModel) get() (int, bool) {
+	if len(m.elems) == 0 {
+		return 0, false
+	}
+
+	elem := m.elems[0]
+	m.elems = m.elems[1:]
+	m.overwrite = len(m.elems) % m.capacity
+
+	return elem, true
+}
+
+func (m *circularModel) clear() []int {
+	elems := m.elems
+	m.elems = nil
+	m.overwrite = 0
+
+	return elems
+}
+
+// assertCircularState checks the observable state of the queue against
+// the expected elements, from head to tail, without altering it.
+func assertCircularState(t *testing.T, circularQueue *queue.Circular[int], expected []int) {
+	t.Helper()
+
+	if size := circularQueue.Size(); size != len(expected) {
+		t.Fatalf("expected size to be %d, got %d", len(expected), size)
+	}
+
+	if empty := circularQueue.IsEmpty(); empty != (len(expected) == 0) {
+		t.Fatalf("expected IsEmpty to be %t, got %t", len(expected) == 0, empty)
+	}
+
+	head, err := circularQueue.Peek()
+
+	switch {
+	case len(expected) == 0 && !errors.Is(err, queue.ErrNoElementsAvailable):
+		t.Fatalf("expected error to be %v, got %v", queue.ErrNoElementsAvailable, err)
+	case len(expected) > 0 && (err != nil || head != expected[0]):
+		t.Fatalf("expected head to be %d, got %d (error %v)", expected[0], head, err)
+	}
+
+	marshaled, err := circularQueue.MarshalJSON()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	var elems []int
+
+	if err := json.Unmarshal(marshaled, &elems); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(elems) != len(expected) || (len(elems) > 0 && !reflect.DeepEqual(expected, elems)) {
+		t.Fatalf("expected elements to be %v, got %v", expected, elems)
+	}
+}
+
+func FuzzCircular(f *testing.F) {
+	testcases := []struct {
+		capacity byte
+		initial  []byte
+		ops      []byte
+	}{
+		{capacity: 3, initial: []byte{1, 2}, ops: []byte{1, 0, 5, 1, 0, 6, 0, 7, 2, 1, 0, 8, 0, 9, 3}},
+		{capacity: 3, initial: []byte{1, 2}, ops: []byte{0, 5, 0, 6, 1, 0, 7, 0, 8, 2, 0, 9, 3}},
+		{capacity: 2, initial: []byte{1, 2, 3, 4}, ops: []byte{1, 2, 0, 5, 0, 6, 0, 7, 2, 1}},
+		{capacity: 4, initial: nil, ops: []byte{0, 1, 0, 2, 0, 3, 1, 1, 0, 4, 0, 5, 0, 6, 0, 7, 2, 3, 2}},
+		{capacity: 1, initial: []byte{1, 2, 3}, ops: []byte{2, 0, 4, 2, 1, 2, 3, 2}},
+		{capacity: 3, initial: []byte{1, 2, 3, 4, 5, 6, 7}, ops: []byte{1, 1, 2, 0, 8, 0, 9, 2, 1, 0, 10}},
+	}
+
+	for _, tc := range testcases {
+		f.Add(tc.capacity, tc.initial, tc.ops)
+	}
+
+	f.Fuzz(func(t *testing.T, capacity byte, initial, ops []byte) {
+		capacity = max(capacity%9, 1)
+
+		initialElems := make([]int, len(initial))
+
+		for i, v := range initial {
+			initialElems[i] = int(v)
+		}
+
+		circularQueue := queue.NewCircular(initialElems, int(capacity))
+		model := newCircularModel(initialElems, int(capacity))
+
+		assertCircularState(t, circularQueue, model.elems)
+
+		for i := 0; i < len(ops); i++ {
+			switch ops[i] % 4 {
+			case 0: // offer the next byte.
+				elem := 0
+
+				if i+1 < len(ops) {
+					i++
+					elem = int(ops[i])
+				}
+
+				if err := circularQueue.Offer(elem); err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+
+				model.offer(elem)
+
+			case 1:
+				elem, err := circularQueue.Get()
+
+				expected, ok := model.get()
+
+				if ok != (err == nil) || elem != expected {
+					t.Fatalf("expected Get to return %d (%t), got %d (error %v)", expected, ok, elem, err)
+				}
+
+			case 2:
+				circularQueue.Reset()
+
+				model.reset()
+
+			case 3:
+				elems := circularQueue.Clear()
+
+				expected := model.clear()
+
+				if len(elems) != len(expected) || (len(elems) > 0 && !reflect.DeepEqual(expected, elems)) {
+					t.Fatalf("expected cleared elements to be %v, got %v", expected, elems)
+				}
+			}
+
+			assertCircularState(t, circularQueue, model.elems)
+		}
+	})
 }
 
 func BenchmarkCircularQueue(b *testing.B) {
+	b.Run("IsEmpty_ConcurrentWriter", func(b *testing.B) {
+		circularQueue := queue.NewCircular([]int{1}, 2)
+
+		stop := runCircularWriter(circularQueue)
+		defer stop()
+
+		b.ReportAllocs()
+		b.SetParallelism(8)
+		b.ResetTimer()
+
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				_ = circularQueue.IsEmpty()
+			}
+		})
+	})
+
+	b.Run("Peek_ConcurrentWriter", func(b *testing.B) {
+		circularQueue := queue.NewCircular([]int{1}, 2)
+
+		stop := runCircularWriter(circularQueue)
+		defer stop()
+
+		b.ReportAllocs()
+		b.SetParallelism(8)
+		b.ResetTimer()
+
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				_, _ = circularQueue.Peek()
+			}
+		})
+	})
+
 	b.Run("Peek", func(b *testing.B) {
 		circularQueue := queue.NewCircular([]int{1}, 1)
 
@@ -328,3 +1372,30 @@ func BenchmarkCircularQueue(b *testing.B) {
 		}
 	})
 }
+
+// runCircularWriter offers and removes elements from the queue until the
+// returned function is called.
+func runCircularWriter(circularQueue *queue.Circular[int]) (stop func()) {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+
+		for i := 0; ; i++ {
+			select {
+			case <-done:
+				return
+			default:
+			}
+
+			_ = circularQueue.Offer(i)
+			_, _ = circularQueue.Get()
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
@@ -0,0 +1,41 @@
+package queue
+
+// unboundedCapacity is the ClearSnapshot capacity of the queues without a
+// fixed capacity.
+const unboundedCapacity = -1
+
+// ClearSnapshot describes the state of a queue at the time a ClearIf
+// predicate is evaluated.
+type ClearSnapshot[T any] struct {
+	// Size is the number of elements in the queue.
+	Size int
+
+	// Capacity is the fixed capacity of the queue, or -1 if the queue
+	// is unbounded.
+	Capacity int
+
+	// Head is the element at the head of the queue, or the zero value
+	// if the queue is empty.
+	Head T
+}
+
+// ClearResult describes the effect of a Blocking ClearReport call.
+type ClearResult[T any] struct {
+	// Elements are the removed elements, the elements of the urgent lane
+	// first.
+	Elements []T
+
+	// ReleasedProducers is the number of producers waiting for capacity in
+	// OfferWait or OfferCtx when the queue was cleared, which the clear
+	// wakes up.
+	ReleasedProducers int
+}
+
+// capacityOf returns the ClearSnapshot capacity for the given capacity option.
+func capacityOf(capacity *int) int {
+	if capacity == nil {
+		return unboundedCapacity
+	}
+
+	return *capacity
+}
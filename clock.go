@@ -0,0 +1,75 @@
+package queue
+
+import (
+	"context"
+	"time"
+)
+
+// A Clock provides the time related functionality used by the queues.
+// It allows time dependent behavior to be driven deterministically,
+// by replacing the system clock using the WithClock option.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+
+	// NewTimer creates a new Timer that sends the current time on its
+	// channel after at least duration d.
+	NewTimer(d time.Duration) Timer
+}
+
+// A Timer represents a single event, it mirrors time.Timer.
+type Timer interface {
+	// C returns the channel on which the time is delivered.
+	C() <-chan time.Time
+
+	// Stop prevents the Timer from firing.
+	// It returns false if the timer has already expired or been stopped.
+	Stop() bool
+}
+
+// systemClock is a Clock backed by the time package.
+type systemClock struct{}
+
+// Now returns the current local time.
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// NewTimer creates a new time.Timer.
+func (systemClock) NewTimer(d time.Duration) Timer {
+	return systemTimer{Timer: time.NewTimer(d)}
+}
+
+// systemTimer adapts time.Timer to the Timer interface.
+type systemTimer struct {
+	*time.Timer
+}
+
+// C returns the channel on which the time is delivered.
+func (t systemTimer) C() <-chan time.Time {
+	return t.Timer.C
+}
+
+// withClockTimeout returns a copy of ctx that is cancelled once the given
+// duration elapses on the clock.
+func withClockTimeout(
+	ctx context.Context,
+	clock Clock,
+	d time.Duration,
+) (context.Context, context.CancelFunc) {
+	timeoutCtx, cancel := context.WithCancel(ctx)
+
+	timer := clock.NewTimer(d)
+
+	go func() {
+		defer timer.Stop()
+
+		select {
+		case <-timer.C():
+			cancel()
+		case <-timeoutCtx.Done():
+		}
+	}()
+
+	return timeoutCtx, cancel
+}
@@ -0,0 +1,143 @@
+package queue_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/adrianbrad/queue"
+)
+
+var _ queue.Clock = (*fakeClock)(nil)
+
+// fakeClock is a queue.Clock whose time only advances when Advance is called.
+type fakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []*fakeTimer
+
+	// timerCreated receives a value every time a timer is created.
+	timerCreated chan struct{}
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{
+		now:          time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		timerCreated: make(chan struct{}, 1024),
+	}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+func (c *fakeClock) NewTimer(d time.Duration) queue.Timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	timer := &fakeTimer{
+		clock:    c,
+		deadline: c.now.Add(d),
+		ch:       make(chan time.Time, 1),
+	}
+
+	if d <= 0 {
+		timer.ch <- c.now
+	} else {
+		c.timers = append(c.timers, timer)
+	}
+
+	c.timerCreated <- struct{}{}
+
+	return timer
+}
+
+// Advance moves the clock forward, firing the timers that expire.
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+
+	active := c.timers[:0]
+
+	for _, timer := range c.timers {
+		if timer.deadline.After(c.now) {
+			active = append(active, timer)
+			continue
+		}
+
+		timer.ch <- c.now
+	}
+
+	c.timers = active
+}
+
+// WaitForTimers blocks until n timers have been created since the last call.
+func (c *fakeClock) WaitForTimers(t *testing.T, n int) {
+	t.Helper()
+
+	for i := 0; i < n; i++ {
+		select {
+		case <-c.timerCreated:
+		case <-time.After(time.Second):
+			t.Fatalf("expected %d timers to be created, got %d", n, i)
+		}
+	}
+}
+
+type fakeTimer struct {
+	clock    *fakeClock
+	deadline time.Time
+	ch       chan time.Time
+}
+
+func (t *fakeTimer) C() <-chan time.Time {
+	return t.ch
+}
+
+func (t *fakeTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+
+	for i, timer := range t.clock.timers {
+		if timer == t {
+			t.clock.timers = append(t.clock.timers[:i], t.clock.timers[i+1:]...)
+
+			return true
+		}
+	}
+
+	return false
+}
+
+func TestFakeClock(t *testing.T) {
+	t.Parallel()
+
+	clock := newFakeClock()
+
+	timer := clock.NewTimer(time.Second)
+
+	clock.Advance(time.Second - time.Nanosecond)
+
+	select {
+	case <-timer.C():
+		t.Fatalf("expected timer to not fire")
+	default:
+	}
+
+	clock.Advance(time.Nanosecond)
+
+	select {
+	case <-timer.C():
+	default:
+		t.Fatalf("expected timer to fire")
+	}
+
+	if timer.Stop() {
+		t.Fatalf("expected expired timer to not be stopped")
+	}
+}
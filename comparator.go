@@ -0,0 +1,145 @@
+package queue
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+)
+
+// comparators holds the comparators registered using RegisterLess, each of
+// them being a func(T, T) bool for the type it was registered for.
+var comparators = struct {
+	sync.RWMutex
+	byName map[string]any
+}{
+	byName: make(map[string]any),
+}
+
+// RegisterLess registers the less function of the Priority queues of
+// elements of type T under name, for the lifetime of the process.
+//
+// The queues created with the WithLessName option use the registered
+// comparator and record its name in their JSON checkpoints, in which case
+// MarshalJSON writes an object holding the name and the elements, such as
+// {"less":"byDeadline","elements":[...]}, instead of an array.
+// UnmarshalJSONFrom then returns the ErrComparatorMismatch error when
+// restoring a checkpoint recorded under another name, or recorded by a queue
+// created without the option, unless the WithComparatorOverride option is
+// provided. The checkpoints holding an array are restored regardless of
+// the comparator.
+// UnmarshalBinary checks the name recorded by MarshalBinary the same way.
+//
+// It returns the ErrComparatorRegistered error if a comparator is already
+// registered under name. It panics if less is nil.
+func RegisterLess[T any](name string, less func(elem, otherElem T) bool) error {
+	if less == nil {
+		panic("nil less func")
+	}
+
+	comparators.Lock()
+	defer comparators.Unlock()
+
+	if _, ok := comparators.byName[name]; ok {
+		return fmt.Errorf("%w: %q", ErrComparatorRegistered, name)
+	}
+
+	comparators.byName[name] = less
+
+	return nil
+}
+
+// registeredLess returns the comparator registered under name.
+// It panics if no comparator of elements of type T is registered under name.
+func registeredLess[T any](name string) func(elem, otherElem T) bool {
+	comparators.RLock()
+	defer comparators.RUnlock()
+
+	registered, ok := comparators.byName[name]
+	if !ok {
+		panic(fmt.Sprintf("no comparator registered under %q", name))
+	}
+
+	less, ok := registered.(func(elem, otherElem T) bool)
+	if !ok {
+		panic(fmt.Sprintf("comparator registered under %q does not match the queue element type", name))
+	}
+
+	return less
+}
+
+// checkpointEnvelope is the JSON checkpoint of a Priority queue created
+// with the WithLessName option.
+type checkpointEnvelope struct {
+	Less     string          `json:"less"`
+	Elements json.RawMessage `json:"elements"`
+}
+
+// writeCheckpoint writes the elements to w, using the writeElements function,
+// wrapped in a checkpoint envelope recording lessName, if any.
+func writeCheckpoint(w io.Writer, lessName string, writeElements func(io.Writer) error) error {
+	if lessName == "" {
+		return writeElements(w)
+	}
+
+	if _, err := io.WriteString(w, `{"less":`+strconv.Quote(lessName)+`,"elements":`); err != nil {
+		return err
+	}
+
+	if err := writeElements(w); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, "}")
+
+	return err
+}
+
+// readCheckpoint returns a reader of the JSON array of elements held by the
+// checkpoint read from r. If the checkpoint is an envelope, it returns the
+// ErrComparatorMismatch error if it was recorded with another comparator
+// than lessName, unless override is true.
+func readCheckpoint(r io.Reader, lessName string, override bool) (io.Reader, error) {
+	br := bufio.NewReader(r)
+
+	// the read errors are reported while decoding the array.
+	if first, err := peekNonSpace(br); err != nil || first != '{' {
+		return br, nil
+	}
+
+	var envelope checkpointEnvelope
+
+	if err := json.NewDecoder(br).Decode(&envelope); err != nil {
+		return nil, err
+	}
+
+	if envelope.Less != lessName && !override {
+		return nil, fmt.Errorf(
+			"%w: recorded with %q, restored with %q",
+			ErrComparatorMismatch, envelope.Less, lessName,
+		)
+	}
+
+	return bytes.NewReader(envelope.Elements), nil
+}
+
+// peekNonSpace skips the JSON white space read from br and returns the next
+// byte, without consuming it.
+func peekNonSpace(br *bufio.Reader) (byte, error) {
+	for {
+		b, err := br.Peek(1)
+		if err != nil {
+			return 0, err
+		}
+
+		switch b[0] {
+		case ' ', '\t', '\n', '\r':
+			_, _ = br.ReadByte()
+		default:
+			return b[0], nil
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package queue
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// comparatorCheckPeers is the number of resident elements each sampled
+// insertion is compared against.
+const comparatorCheckPeers = 3
+
+// comparatorChecker verifies, on a sampled fraction of the insertions and
+// removals of a Priority queue, that its less function is consistent.
+// A nil comparatorChecker verifies nothing.
+type comparatorChecker[T any] struct {
+	sampleRate float64
+	rng        *rand.Rand
+	less       func(elem, otherElem T) bool
+	handler    func(err error)
+}
+
+// newComparatorChecker returns the checker configured by the
+// WithComparatorChecks option, or nil if the sample rate is not positive.
+func newComparatorChecker[T any](
+	opts options,
+	less func(elem, otherElem T) bool,
+) *comparatorChecker[T] {
+	if !(opts.comparatorSampleRate > 0) {
+		return nil
+	}
+
+	handler := opts.comparatorViolationHandler
+	if handler == nil {
+		handler = func(err error) { panic(err) }
+	}
+
+	// nolint: gosec // the sampling does not need a secure source.
+	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+
+	return &comparatorChecker[T]{
+		sampleRate: opts.comparatorSampleRate,
+		rng:        rng,
+		less:       less,
+		handler:    handler,
+	}
+}
+
+// sample reports whether the current operation is verified.
+func (c *comparatorChecker[T]) sample() bool {
+	return c.sampleRate >= 1 || c.rng.Float64() < c.sampleRate
+}
+
+// offered verifies, if the operation is sampled, that the element about to
+// be inserted is not less than itself and that it is not both less and
+// greater than a few of the resident elements.
+func (c *comparatorChecker[T]) offered(elem T, resident []T) {
+	if c == nil || !c.sample() {
+		return
+	}
+
+	if c.less(elem, elem) {
+		c.handler(fmt.Errorf("%w: less(%v, %v) is true", ErrComparatorInconsistent, elem, elem))
+
+		return
+	}
+
+	for i := 0; i < comparatorCheckPeers && len(resident) > 0; i++ {
+		peer := resident[c.rng.Intn(len(resident))]
+
+		if c.less(elem, peer) && c.less(peer, elem) {
+			c.handler(fmt.Errorf(
+				"%w: less(%v, %v) and less(%v, %v) are both true",
+				ErrComparatorInconsistent, elem, peer, peer, elem,
+			))
+
+			return
+		}
+	}
+}
+
+// removed verifies, if the operation is sampled, that the new head of the
+// heap is not less than the element just removed from it.
+func (c *comparatorChecker[T]) removed(elem T, heapElems []T) {
+	if c == nil || len(heapElems) == 0 || !c.sample() {
+		return
+	}
+
+	if head := heapElems[0]; c.less(head, elem) {
+		c.handler(fmt.Errorf(
+			"%w: removed %v while the new head %v is less",
+			ErrComparatorInconsistent, elem, head,
+		))
+	}
+}
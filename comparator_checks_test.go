@@ -0,0 +1,219 @@
+package queue_test
+
+import (
+	"errors"
+	"math/rand"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/adrianbrad/queue"
+)
+
+func TestWithComparatorChecks(t *testing.T) {
+	t.Parallel()
+
+	lessInt := func(elem, otherElem int) bool { return elem < otherElem }
+
+	// newChecked returns a queue collecting the inconsistencies found.
+	newChecked := func(
+		elems []int,
+		less func(elem, otherElem int) bool,
+		sampleRate float64,
+	) (*queue.Priority[int], *[]error) {
+		var violations []error
+
+		priorityQueue := queue.NewPriority(
+			elems,
+			less,
+			queue.WithComparatorChecks(sampleRate),
+			queue.WithComparatorViolationHandler(func(err error) {
+				violations = append(violations, err)
+			}),
+		)
+
+		return priorityQueue, &violations
+	}
+
+	t.Run("RandomComparatorDetected", func(t *testing.T) {
+		t.Parallel()
+
+		rng := rand.New(rand.NewSource(1))
+
+		priorityQueue, violations := newChecked(nil, func(int, int) bool {
+			return rng.Intn(2) == 0
+		}, 1)
+
+		for i := 0; i < 100 && len(*violations) == 0; i++ {
+			_ = priorityQueue.Offer(i)
+
+			if i%3 == 0 {
+				_, _ = priorityQueue.Get()
+			}
+		}
+
+		if len(*violations) == 0 {
+			t.Fatal("expected the random comparator to be detected")
+		}
+
+		if err := (*violations)[0]; !errors.Is(err, queue.ErrComparatorInconsistent) {
+			t.Fatalf("expected error to be %v, got %v", queue.ErrComparatorInconsistent, err)
+		}
+	})
+
+	t.Run("ConsistentComparator", func(t *testing.T) {
+		t.Parallel()
+
+		rng := rand.New(rand.NewSource(1))
+
+		priorityQueue, violations := newChecked([]int{5, 3, 8}, lessInt, 1)
+
+		for i := 0; i < 10_000; i++ {
+			switch rng.Intn(4) {
+			case 0:
+				_, _ = priorityQueue.Get()
+			case 1:
+				_, _ = priorityQueue.Exchange(rng.Intn(100))
+			case 2:
+				_ = priorityQueue.OfferAll(rng.Intn(100), rng.Intn(100))
+			default:
+				_ = priorityQueue.Offer(rng.Intn(100))
+			}
+		}
+
+		if len(*violations) != 0 {
+			t.Fatalf("expected no violation, got %v", *violations)
+		}
+	})
+
+	t.Run("Irreflexivity", func(t *testing.T) {
+		t.Parallel()
+
+		priorityQueue, violations := newChecked(nil, func(elem, otherElem int) bool {
+			return elem <= otherElem
+		}, 1)
+
+		_ = priorityQueue.Offer(7)
+
+		if len(*violations) != 1 || !strings.Contains((*violations)[0].Error(), "less(7, 7)") {
+			t.Fatalf("expected the reflexive comparison of 7 to be reported, got %v", *violations)
+		}
+	})
+
+	t.Run("Antisymmetry", func(t *testing.T) {
+		t.Parallel()
+
+		priorityQueue, violations := newChecked([]int{1}, func(elem, otherElem int) bool {
+			return elem != otherElem
+		}, 1)
+
+		_ = priorityQueue.Offer(2)
+
+		if len(*violations) != 1 || !strings.Contains((*violations)[0].Error(), "less(2, 1) and less(1, 2)") {
+			t.Fatalf("expected the asymmetric comparison of 1 and 2 to be reported, got %v", *violations)
+		}
+	})
+
+	t.Run("GetOrder", func(t *testing.T) {
+		t.Parallel()
+
+		var reversed atomic.Bool
+
+		// the ordering is reversed once the elements are in the heap.
+		priorityQueue, violations := newChecked([]int{1, 2, 3, 4}, func(elem, otherElem int) bool {
+			if reversed.Load() {
+				return elem > otherElem
+			}
+
+			return elem < otherElem
+		}, 1)
+
+		reversed.Store(true)
+
+		if _, err := priorityQueue.Get(); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if len(*violations) != 1 || !strings.Contains((*violations)[0].Error(), "removed 1") {
+			t.Fatalf("expected the removal of 1 to be reported, got %v", *violations)
+		}
+	})
+
+	t.Run("DefaultPanics", func(t *testing.T) {
+		t.Parallel()
+
+		priorityQueue := queue.NewPriority([]int{1}, func(elem, otherElem int) bool {
+			return elem <= otherElem
+		}, queue.WithComparatorChecks(1))
+
+		func() {
+			defer func() {
+				err, ok := recover().(error)
+				if !ok || !errors.Is(err, queue.ErrComparatorInconsistent) {
+					t.Fatalf("expected panic with %v, got %v", queue.ErrComparatorInconsistent, err)
+				}
+			}()
+
+			_ = priorityQueue.Offer(2)
+		}()
+
+		// the rejected element was not inserted and the queue is unlocked.
+		if size := priorityQueue.Size(); size != 1 {
+			t.Fatalf("expected size to be 1, got %d", size)
+		}
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		t.Parallel()
+
+		priorityQueue, violations := newChecked(nil, func(elem, otherElem int) bool {
+			return elem <= otherElem
+		}, 0)
+
+		_ = priorityQueue.Offer(1)
+		_, _ = priorityQueue.Get()
+
+		if len(*violations) != 0 {
+			t.Fatalf("expected no violation, got %v", *violations)
+		}
+	})
+}
+
+func BenchmarkComparatorChecks(b *testing.B) {
+	lessInt := func(elem, otherElem int) bool { return elem < otherElem }
+
+	offerGet := func(priorityQueue *queue.Priority[int]) func() {
+		return func() {
+			_ = priorityQueue.Offer(1)
+			_, _ = priorityQueue.Get()
+		}
+	}
+
+	unchecked := offerGet(queue.NewPriority([]int{2, 3}, lessInt))
+
+	for name, sampleRate := range map[string]float64{"Disabled": 0, "Sampled": 0.01, "Every": 1} {
+		sampleRate := sampleRate
+
+		b.Run(name, func(b *testing.B) {
+			op := offerGet(queue.NewPriority([]int{2, 3}, lessInt, queue.WithComparatorChecks(sampleRate)))
+
+			b.ReportAllocs()
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				op()
+			}
+
+			b.StopTimer()
+
+			if sampleRate > 0 {
+				return
+			}
+
+			// the disabled checks do not allocate.
+			if allocs, baseline := testing.AllocsPerRun(100, op), testing.AllocsPerRun(100, unchecked); allocs != baseline {
+				b.Fatalf("expected %.0f allocations per op, got %.0f", baseline, allocs)
+			}
+		})
+	}
+}
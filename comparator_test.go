@@ -0,0 +1,155 @@
+package queue_test
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/adrianbrad/queue"
+)
+
+func TestRegisterLess(t *testing.T) {
+	t.Parallel()
+
+	// the registry is shared by the process, each test registers its own names.
+	ascending := func(elem, otherElem int) bool { return elem < otherElem }
+	descending := func(elem, otherElem int) bool { return elem > otherElem }
+
+	if err := queue.RegisterLess("comparator_test.ascending", ascending); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if err := queue.RegisterLess("comparator_test.descending", descending); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	newNamed := func(name string, elems []int, opts ...queue.Option) *queue.Priority[int] {
+		return queue.NewPriority(elems, nil, append(opts, queue.WithLessName(name))...)
+	}
+
+	t.Run("RoundTrip", func(t *testing.T) {
+		t.Parallel()
+
+		priorityQueue := newNamed("comparator_test.ascending", []int{3, 1, 2})
+
+		data, err := priorityQueue.MarshalJSON()
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		expected := `{"less":"comparator_test.ascending","elements":[1,2,3]}`
+
+		if string(data) != expected {
+			t.Fatalf("expected checkpoint to be %s, got %s", expected, data)
+		}
+
+		restored := newNamed("comparator_test.ascending", nil)
+
+		if err := restored.UnmarshalJSON(data); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if elems := restored.Clear(); !reflect.DeepEqual([]int{1, 2, 3}, elems) {
+			t.Fatalf("expected elements to be %v, got %v", []int{1, 2, 3}, elems)
+		}
+	})
+
+	t.Run("Mismatch", func(t *testing.T) {
+		t.Parallel()
+
+		data, _ := newNamed("comparator_test.ascending", []int{1, 2}).MarshalJSON()
+
+		restoringQueues := map[string]*queue.Priority[int]{
+			"OtherName": newNamed("comparator_test.descending", []int{5}),
+			"Unnamed":   queue.NewPriority([]int{5}, ascending),
+		}
+
+		for name, restoring := range restoringQueues {
+			if err := restoring.UnmarshalJSON(data); !errors.Is(err, queue.ErrComparatorMismatch) {
+				t.Fatalf("%s: expected error to be %v, got %v", name, queue.ErrComparatorMismatch, err)
+			}
+
+			if elems := restoring.ToSlice(); !reflect.DeepEqual([]int{5}, elems) {
+				t.Fatalf("%s: expected elements to be %v, got %v", name, []int{5}, elems)
+			}
+		}
+	})
+
+	t.Run("Override", func(t *testing.T) {
+		t.Parallel()
+
+		data, _ := newNamed("comparator_test.ascending", []int{1, 3, 2}).MarshalJSON()
+
+		restored := newNamed("comparator_test.descending", []int{}, queue.WithComparatorOverride())
+
+		if err := restored.UnmarshalJSON(data); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		// the restored elements are ordered by the new comparator.
+		if elems := restored.Clear(); !reflect.DeepEqual([]int{3, 2, 1}, elems) {
+			t.Fatalf("expected elements to be %v, got %v", []int{3, 2, 1}, elems)
+		}
+	})
+
+	t.Run("UnnamedCheckpoint", func(t *testing.T) {
+		t.Parallel()
+
+		// the checkpoints of the unnamed queues hold an array, restored
+		// regardless of the comparator.
+		data, _ := queue.NewPriority([]int{2, 1}, ascending).MarshalJSON()
+
+		if !strings.HasPrefix(string(data), "[") {
+			t.Fatalf("expected an array, got %s", data)
+		}
+
+		restored := newNamed("comparator_test.descending", nil)
+
+		if err := restored.UnmarshalJSON(data); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if elems := restored.Clear(); !reflect.DeepEqual([]int{2, 1}, elems) {
+			t.Fatalf("expected elements to be %v, got %v", []int{2, 1}, elems)
+		}
+	})
+
+	t.Run("DuplicateName", func(t *testing.T) {
+		t.Parallel()
+
+		err := queue.RegisterLess("comparator_test.ascending", descending)
+		if !errors.Is(err, queue.ErrComparatorRegistered) {
+			t.Fatalf("expected error to be %v, got %v", queue.ErrComparatorRegistered, err)
+		}
+
+		// the first registration is kept.
+		if elems := newNamed("comparator_test.ascending", []int{2, 1}).Clear(); !reflect.DeepEqual([]int{1, 2}, elems) {
+			t.Fatalf("expected elements to be %v, got %v", []int{1, 2}, elems)
+		}
+	})
+
+	t.Run("Unregistered", func(t *testing.T) {
+		t.Parallel()
+
+		defer func() {
+			if recover() == nil {
+				t.Fatal("expected an unregistered name to panic")
+			}
+		}()
+
+		newNamed("comparator_test.unregistered", nil)
+	})
+
+	t.Run("TypeMismatch", func(t *testing.T) {
+		t.Parallel()
+
+		defer func() {
+			if recover() == nil {
+				t.Fatal("expected a comparator of another type to panic")
+			}
+		}()
+
+		queue.NewPriority([]string{}, nil, queue.WithLessName("comparator_test.ascending"))
+	})
+}
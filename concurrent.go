@@ -0,0 +1,366 @@
+package queue
+
+import (
+	"math/bits"
+	"math/rand"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+)
+
+// Ensure Concurrent implements the Queue interface.
+var _ Queue[any] = (*Concurrent[any])(nil)
+
+// cacheLineSize is the size of the padding keeping the fields written by
+// different goroutines on different cache lines.
+const cacheLineSize = 64
+
+// Concurrent is a bounded Queue implementation for many producers and
+// consumers, such as when the lock of a Blocking queue becomes the
+// bottleneck of the insertions and removals.
+//
+// The elements are held in a ring buffer whose slots carry a sequence number,
+// so that Offer and Get claim a position using a compare and swap and publish
+// the element through its slot, without acquiring a lock shared by all the
+// goroutines. They never wait, returning the ErrQueueIsFull and
+// ErrNoElementsAvailable errors instead. A producer descheduled between
+// claiming a position and publishing its element delays the removal of the
+// elements after it, which are reported as not available meanwhile.
+//
+// The operations examining or replacing all the elements, such as Contains,
+// Peek, Clear and Reset, stop the insertions and removals while they run,
+// thus they are much more expensive than with the other queues. Size is an
+// estimate while elements are being inserted or removed.
+//
+// The capacity must be provided using WithCapacity, the other options have
+// no effect.
+type Concurrent[T comparable] struct {
+	initialElements []T
+
+	// slots is the ring buffer, the element at position pos being held by
+	// slots[pos%capacity].
+	slots    []concurrentSlot[T]
+	capacity uint64
+
+	// tail is the position of the next insertion, head the position of the
+	// next removal, they only grow between the resets.
+	_    [cacheLineSize]byte
+	tail atomic.Uint64
+	_    [cacheLineSize - unsafe.Sizeof(atomic.Uint64{})]byte
+	head atomic.Uint64
+	_    [cacheLineSize - unsafe.Sizeof(atomic.Uint64{})]byte
+
+	// synchronization
+	gate concurrentGate
+}
+
+// concurrentSlot holds the element at a position of a Concurrent queue.
+// Its seq is pos while the slot is free for the insertion at pos, and pos+1
+// once the element inserted at pos can be removed.
+type concurrentSlot[T any] struct {
+	seq  atomic.Uint64
+	elem T
+}
+
+// NewConcurrent creates a new Concurrent queue containing the given elements,
+// whose capacity is provided using WithCapacity. The elements exceeding the
+// capacity are dropped, they are not restored by Reset either.
+// It panics if no positive capacity is provided.
+func NewConcurrent[T comparable](elems []T, opts ...Option) *Concurrent[T] {
+	options := options{}
+
+	for _, o := range opts {
+		o.apply(&options)
+	}
+
+	if options.capacity == nil || *options.capacity <= 0 {
+		panic("queue: concurrent capacity must be provided using WithCapacity and be positive")
+	}
+
+	elems = elems[:min(len(elems), *options.capacity)]
+
+	cq := &Concurrent[T]{
+		initialElements: initialElementsOf(options, elems, nil),
+		slots:           make([]concurrentSlot[T], *options.capacity),
+		capacity:        uint64(*options.capacity),
+		gate:            newConcurrentGate(),
+	}
+
+	cq.fill(elems)
+
+	return cq
+}
+
+// ==================================Insertion=================================
+
+// Offer inserts the element to the tail of the queue.
+// If the queue is full it returns the ErrQueueIsFull error.
+func (cq *Concurrent[T]) Offer(elem T) error {
+	shard := cq.gate.rlock()
+	defer shard.RUnlock()
+
+	pos := cq.tail.Load()
+
+	for {
+		slot := &cq.slots[pos%cq.capacity]
+
+		switch seq := slot.seq.Load(); {
+		case seq == pos:
+			if !cq.tail.CompareAndSwap(pos, pos+1) {
+				pos = cq.tail.Load()
+
+				continue
+			}
+
+			slot.elem = elem
+			slot.seq.Store(pos + 1)
+
+			return nil
+
+		case seq < pos:
+			// the slot still holds the element inserted one lap earlier.
+			return ErrQueueIsFull
+
+		default:
+			// another producer claimed the position.
+			pos = cq.tail.Load()
+		}
+	}
+}
+
+// Reset sets the queue to its initial state.
+func (cq *Concurrent[T]) Reset() {
+	cq.gate.lock()
+	defer cq.gate.unlock()
+
+	cq.fill(cq.initialElements)
+}
+
+// ===================================Removal==================================
+
+// Get removes and returns the head of the queue.
+// If no element is available it returns an ErrNoElementsAvailable error.
+func (cq *Concurrent[T]) Get() (v T, _ error) {
+	shard := cq.gate.rlock()
+	defer shard.RUnlock()
+
+	pos := cq.head.Load()
+
+	for {
+		slot := &cq.slots[pos%cq.capacity]
+
+		switch seq := slot.seq.Load(); {
+		case seq == pos+1:
+			if !cq.head.CompareAndSwap(pos, pos+1) {
+				pos = cq.head.Load()
+
+				continue
+			}
+
+			var zero T
+
+			v, slot.elem = slot.elem, zero
+
+			// the slot is free for the insertion one lap later.
+			slot.seq.Store(pos + cq.capacity)
+
+			return v, nil
+
+		case seq < pos+1:
+			// the element at the position is not inserted yet.
+			return v, ErrNoElementsAvailable
+
+		default:
+			// another consumer claimed the position.
+			pos = cq.head.Load()
+		}
+	}
+}
+
+// Clear removes and returns all the elements from the queue.
+func (cq *Concurrent[T]) Clear() []T {
+	cq.gate.lock()
+	defer cq.gate.unlock()
+
+	elems := cq.elems()
+
+	cq.fill(nil)
+
+	return elems
+}
+
+// Iterator returns an iterator over the elements in the queue.
+// It removes the elements from the queue.
+func (cq *Concurrent[T]) Iterator() <-chan T {
+	elems := cq.Clear()
+
+	iteratorCh := make(chan T, len(elems))
+
+	for _, elem := range elems {
+		iteratorCh <- elem
+	}
+
+	close(iteratorCh)
+
+	return iteratorCh
+}
+
+// =================================Examination================================
+
+// Contains returns true if the queue contains the element.
+func (cq *Concurrent[T]) Contains(elem T) bool {
+	cq.gate.lock()
+	defer cq.gate.unlock()
+
+	for pos := cq.head.Load(); pos != cq.tail.Load(); pos++ {
+		if cq.slots[pos%cq.capacity].elem == elem {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Peek retrieves but does not remove the head of the queue.
+// If the queue is empty it returns an ErrNoElementsAvailable error.
+func (cq *Concurrent[T]) Peek() (v T, _ error) {
+	cq.gate.lock()
+	defer cq.gate.unlock()
+
+	head := cq.head.Load()
+
+	if head == cq.tail.Load() {
+		return v, ErrNoElementsAvailable
+	}
+
+	return cq.slots[head%cq.capacity].elem, nil
+}
+
+// ToSlice returns a copy of the queue elements, from head to tail, without
+// removing them.
+func (cq *Concurrent[T]) ToSlice() []T {
+	cq.gate.lock()
+	defer cq.gate.unlock()
+
+	return cq.elems()
+}
+
+// Size returns the number of elements in the queue.
+// It counts the positions claimed by the insertions and not by the removals,
+// thus it is an estimate while elements are being inserted or removed.
+func (cq *Concurrent[T]) Size() int {
+	head := cq.head.Load()
+	tail := cq.tail.Load()
+
+	if tail <= head {
+		return 0
+	}
+
+	return int(min(tail-head, cq.capacity))
+}
+
+// IsEmpty returns true if the queue is empty, false otherwise.
+func (cq *Concurrent[T]) IsEmpty() bool {
+	return cq.Size() == 0
+}
+
+// Capacity returns the capacity of the queue.
+func (cq *Concurrent[T]) Capacity() int {
+	return int(cq.capacity)
+}
+
+// Remaining returns the number of elements the queue can hold in addition to
+// its current elements, an estimate as the one of Size.
+func (cq *Concurrent[T]) Remaining() int {
+	return int(cq.capacity) - cq.Size()
+}
+
+// ===================================Helpers==================================
+
+// elems returns the elements of the queue, from head to tail. It must be
+// called while holding the gate for writing.
+func (cq *Concurrent[T]) elems() []T {
+	head, tail := cq.head.Load(), cq.tail.Load()
+
+	elems := make([]T, 0, tail-head)
+
+	for pos := head; pos != tail; pos++ {
+		elems = append(elems, cq.slots[pos%cq.capacity].elem)
+	}
+
+	return elems
+}
+
+// fill replaces the elements of the queue with the given ones, which do not
+// exceed the capacity. It must be called while holding the gate for writing,
+// or before the queue is shared.
+func (cq *Concurrent[T]) fill(elems []T) {
+	var zero T
+
+	for i := range cq.slots {
+		slot := &cq.slots[i]
+
+		if i < len(elems) {
+			slot.elem = elems[i]
+			slot.seq.Store(uint64(i) + 1)
+
+			continue
+		}
+
+		slot.elem = zero
+		slot.seq.Store(uint64(i))
+	}
+
+	cq.head.Store(0)
+	cq.tail.Store(uint64(len(elems)))
+}
+
+// concurrentGate is a lock split into shards, held for reading on a single
+// shard by the insertions and removals, so that they do not contend on the
+// same cache line, and for writing on every shard by the operations which
+// examine or replace all the elements.
+type concurrentGate struct {
+	shards []gateShard
+	mask   uint32
+}
+
+// gateShard is a shard of a concurrentGate, padded to its own cache line.
+type gateShard struct {
+	sync.RWMutex
+	_ [cacheLineSize - unsafe.Sizeof(sync.RWMutex{})%cacheLineSize]byte
+}
+
+// newConcurrentGate returns a gate with a shard per processor, rounded up to
+// a power of two.
+func newConcurrentGate() concurrentGate {
+	n := 1 << bits.Len(uint(runtime.GOMAXPROCS(0)-1))
+
+	return concurrentGate{
+		shards: make([]gateShard, n),
+		mask:   uint32(n - 1),
+	}
+}
+
+// rlock locks a random shard for reading and returns it.
+func (g *concurrentGate) rlock() *gateShard {
+	shard := &g.shards[rand.Uint32()&g.mask]
+
+	shard.RLock()
+
+	return shard
+}
+
+// lock locks every shard for writing.
+func (g *concurrentGate) lock() {
+	for i := range g.shards {
+		g.shards[i].Lock()
+	}
+}
+
+// unlock unlocks every shard.
+func (g *concurrentGate) unlock() {
+	for i := range g.shards {
+		g.shards[i].Unlock()
+	}
+}
@@ -0,0 +1,308 @@
+package queue_test
+
+import (
+	"errors"
+	"reflect"
+	"runtime"
+	"sync"
+	"testing"
+
+	"github.com/adrianbrad/queue"
+)
+
+func TestConcurrent(t *testing.T) {
+	t.Parallel()
+
+	t.Run("OfferGet", func(t *testing.T) {
+		t.Parallel()
+
+		concurrentQueue := queue.NewConcurrent([]int{1, 2}, queue.WithCapacity(3))
+
+		if err := concurrentQueue.Offer(3); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if err := concurrentQueue.Offer(4); !errors.Is(err, queue.ErrQueueIsFull) {
+			t.Fatalf("expected error to be %v, got %v", queue.ErrQueueIsFull, err)
+		}
+
+		// the ring wraps around once the head is removed.
+		for lap := 0; lap < 3; lap++ {
+			elem, err := concurrentQueue.Get()
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if err := concurrentQueue.Offer(elem + 3); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+		}
+
+		for i := 4; i <= 6; i++ {
+			if elem, err := concurrentQueue.Get(); err != nil || elem != i {
+				t.Fatalf("expected elem to be %d, got %d, %v", i, elem, err)
+			}
+		}
+
+		if _, err := concurrentQueue.Get(); !errors.Is(err, queue.ErrNoElementsAvailable) {
+			t.Fatalf("expected error to be %v, got %v", queue.ErrNoElementsAvailable, err)
+		}
+	})
+
+	t.Run("Examination", func(t *testing.T) {
+		t.Parallel()
+
+		concurrentQueue := queue.NewConcurrent([]int{1, 2, 3}, queue.WithCapacity(3))
+
+		_, _ = concurrentQueue.Get()
+		_ = concurrentQueue.Offer(4)
+
+		if elem, err := concurrentQueue.Peek(); err != nil || elem != 2 {
+			t.Fatalf("expected head to be 2, got %d, %v", elem, err)
+		}
+
+		if !concurrentQueue.Contains(4) || concurrentQueue.Contains(1) {
+			t.Fatal("expected the queue to contain 4 and not 1")
+		}
+
+		if size, remaining := concurrentQueue.Size(), concurrentQueue.Remaining(); size != 3 || remaining != 0 {
+			t.Fatalf("expected size 3 and remaining 0, got %d and %d", size, remaining)
+		}
+
+		// the elements wrap around the end of the ring.
+		if elems := concurrentQueue.ToSlice(); !reflect.DeepEqual([]int{2, 3, 4}, elems) {
+			t.Fatalf("expected elements to be %v, got %v", []int{2, 3, 4}, elems)
+		}
+
+		if elems := concurrentQueue.Clear(); !reflect.DeepEqual([]int{2, 3, 4}, elems) {
+			t.Fatalf("expected elements to be %v, got %v", []int{2, 3, 4}, elems)
+		}
+
+		if !concurrentQueue.IsEmpty() {
+			t.Fatal("expected queue to be empty")
+		}
+
+		if _, err := concurrentQueue.Peek(); !errors.Is(err, queue.ErrNoElementsAvailable) {
+			t.Fatalf("expected error to be %v, got %v", queue.ErrNoElementsAvailable, err)
+		}
+	})
+
+	t.Run("Reset", func(t *testing.T) {
+		t.Parallel()
+
+		concurrentQueue := queue.NewConcurrent([]int{1, 2, 3, 4}, queue.WithCapacity(3))
+
+		_, _ = concurrentQueue.Get()
+		_ = concurrentQueue.Offer(5)
+
+		concurrentQueue.Reset()
+
+		// the element exceeding the capacity is not restored.
+		var elems []int
+
+		for elem := range concurrentQueue.Iterator() {
+			elems = append(elems, elem)
+		}
+
+		if !reflect.DeepEqual([]int{1, 2, 3}, elems) {
+			t.Fatalf("expected elements to be %v, got %v", []int{1, 2, 3}, elems)
+		}
+
+		if !concurrentQueue.IsEmpty() {
+			t.Fatal("expected the iterator to empty the queue")
+		}
+	})
+
+	t.Run("CapacityRequired", func(t *testing.T) {
+		t.Parallel()
+
+		for name, opts := range map[string][]queue.Option{
+			"Missing": nil,
+			"Zero":    {queue.WithCapacity(0)},
+		} {
+			opts := opts
+
+			t.Run(name, func(t *testing.T) {
+				t.Parallel()
+
+				defer func() {
+					if recover() == nil {
+						t.Fatal("expected NewConcurrent to panic")
+					}
+				}()
+
+				_ = queue.NewConcurrent([]int{}, opts...)
+			})
+		}
+	})
+
+	t.Run("Conservation", func(t *testing.T) {
+		t.Parallel()
+
+		const (
+			producers        = 8
+			consumers        = 8
+			elemsPerProducer = 2000
+		)
+
+		concurrentQueue := queue.NewConcurrent([]int{}, queue.WithCapacity(16))
+
+		var (
+			producersGroup sync.WaitGroup
+			consumersGroup sync.WaitGroup
+
+			lock     sync.Mutex
+			received = make(map[int]int)
+		)
+
+		for p := 0; p < producers; p++ {
+			producersGroup.Add(1)
+
+			go func(p int) {
+				defer producersGroup.Done()
+
+				for i := 0; i < elemsPerProducer; {
+					if concurrentQueue.Offer(p*elemsPerProducer+i) != nil {
+						runtime.Gosched()
+
+						continue
+					}
+
+					i++
+				}
+			}(p)
+		}
+
+		done := make(chan struct{})
+
+		for c := 0; c < consumers; c++ {
+			consumersGroup.Add(1)
+
+			go func() {
+				defer consumersGroup.Done()
+
+				for {
+					elem, err := concurrentQueue.Get()
+					if err == nil {
+						lock.Lock()
+						received[elem]++
+						lock.Unlock()
+
+						continue
+					}
+
+					select {
+					case <-done:
+						return
+					default:
+						runtime.Gosched()
+					}
+				}
+			}()
+		}
+
+		// the examinations stop the insertions and removals meanwhile.
+		for i := 0; i < 100; i++ {
+			_ = concurrentQueue.Contains(i)
+			_, _ = concurrentQueue.Peek()
+		}
+
+		producersGroup.Wait()
+
+		for !concurrentQueue.IsEmpty() {
+			runtime.Gosched()
+		}
+
+		close(done)
+		consumersGroup.Wait()
+
+		if len(received) != producers*elemsPerProducer {
+			t.Fatalf("expected %d elements, got %d", producers*elemsPerProducer, len(received))
+		}
+
+		for elem, count := range received {
+			if count != 1 {
+				t.Fatalf("expected elem %d to be received once, got %d", elem, count)
+			}
+		}
+	})
+
+	t.Run("ProducerOrder", func(t *testing.T) {
+		t.Parallel()
+
+		const (
+			producers        = 4
+			elemsPerProducer = 2000
+		)
+
+		concurrentQueue := queue.NewConcurrent([]int{}, queue.WithCapacity(8))
+
+		for p := 0; p < producers; p++ {
+			go func(p int) {
+				for i := 0; i < elemsPerProducer; {
+					if concurrentQueue.Offer(p*elemsPerProducer+i) != nil {
+						runtime.Gosched()
+
+						continue
+					}
+
+					i++
+				}
+			}(p)
+		}
+
+		// the elements of every producer are received in order by a single
+		// consumer.
+		last := make(map[int]int)
+
+		for n := 0; n < producers*elemsPerProducer; {
+			elem, err := concurrentQueue.Get()
+			if err != nil {
+				runtime.Gosched()
+
+				continue
+			}
+
+			n++
+
+			p := elem / elemsPerProducer
+
+			if prev, ok := last[p]; ok && prev >= elem {
+				t.Fatalf("expected the elements of producer %d in order, got %d after %d", p, elem, prev)
+			}
+
+			last[p] = elem
+		}
+	})
+}
+
+func BenchmarkConcurrentQueue(b *testing.B) {
+	benchmarks := map[string]func() queue.Queue[int]{
+		"Blocking": func() queue.Queue[int] {
+			return queue.NewBlocking([]int{}, queue.WithCapacity(1024))
+		},
+		"Concurrent": func() queue.Queue[int] {
+			return queue.NewConcurrent([]int{}, queue.WithCapacity(1024))
+		},
+	}
+
+	for name, newQueue := range benchmarks {
+		newQueue := newQueue
+
+		b.Run("Offer_Get_Parallel/"+name, func(b *testing.B) {
+			q := newQueue()
+
+			b.ReportAllocs()
+			b.SetParallelism(4)
+			b.ResetTimer()
+
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					_ = q.Offer(1)
+
+					_, _ = q.Get()
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package queue
+
+import (
+	"errors"
+	"fmt"
+)
+
+// maxViolations is the number of conservation violations a ledger retains,
+// the following ones being only counted.
+const maxViolations = 16
+
+// ledger accounts for the elements of a Blocking or Priority queue created
+// using the WithConservationChecks option. Every admitted element is assigned a
+// sequence ID, held until the element leaves the queue, whether it is
+// retrieved, cleared, discarded or cancelled. A nil ledger accounts for
+// nothing. The ledger is guarded by the queue lock.
+type ledger struct {
+	// lastID is the last sequence ID assigned, the IDs start at 1.
+	lastID uint64
+
+	// held holds the IDs of the admitted elements which did not leave the
+	// queue yet.
+	held map[uint64]struct{}
+
+	// violations holds the first violations observed as the elements left
+	// the queue, dropped counts the other ones.
+	violations []error
+	dropped    int
+}
+
+func newLedger() *ledger {
+	return &ledger{
+		held: make(map[uint64]struct{}),
+	}
+}
+
+// admit returns the sequence ID of a newly admitted element, or 0 if the
+// ledger is nil.
+func (l *ledger) admit() uint64 {
+	if l == nil {
+		return 0
+	}
+
+	l.lastID++
+
+	l.held[l.lastID] = struct{}{}
+
+	return l.lastID
+}
+
+// exit accounts for the element with the given sequence ID leaving the queue.
+func (l *ledger) exit(id uint64) {
+	if l == nil {
+		return
+	}
+
+	if _, ok := l.held[id]; ok {
+		delete(l.held, id)
+
+		return
+	}
+
+	switch {
+	case id == 0:
+		l.violate(fmt.Errorf("%w: an element without sequence ID left the queue", ErrElementsNotConserved))
+	case id > l.lastID:
+		l.violate(fmt.Errorf("%w: element %d left the queue without being admitted", ErrElementsNotConserved, id))
+	default:
+		l.violate(fmt.Errorf("%w: element %d left the queue twice", ErrElementsNotConserved, id))
+	}
+}
+
+func (l *ledger) violate(err error) {
+	if len(l.violations) == maxViolations {
+		l.dropped++
+
+		return
+	}
+
+	l.violations = append(l.violations, err)
+}
+
+// check returns the violations observed so far, along with the differences
+// between the held IDs and the IDs of the queued elements.
+func (l *ledger) check(queued []uint64) error {
+	errs := append([]error(nil), l.violations...)
+
+	if l.dropped > 0 {
+		errs = append(errs, fmt.Errorf("%w: %d more violations", ErrElementsNotConserved, l.dropped))
+	}
+
+	seen := make(map[uint64]struct{}, len(queued))
+
+	for _, id := range queued {
+		if _, ok := seen[id]; ok {
+			errs = append(errs, fmt.Errorf("%w: element %d is queued twice", ErrElementsNotConserved, id))
+
+			continue
+		}
+
+		seen[id] = struct{}{}
+
+		if _, ok := l.held[id]; !ok {
+			errs = append(errs, fmt.Errorf("%w: element %d is queued without being held", ErrElementsNotConserved, id))
+		}
+	}
+
+	for id := range l.held {
+		if _, ok := seen[id]; !ok {
+			errs = append(errs, fmt.Errorf("%w: element %d is unaccounted for", ErrElementsNotConserved, id))
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,512 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLedger(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Conserved", func(t *testing.T) {
+		t.Parallel()
+
+		l := newLedger()
+
+		first, second := l.admit(), l.admit()
+
+		l.exit(first)
+
+		if err := l.check([]uint64{second}); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	})
+
+	t.Run("Violations", func(t *testing.T) {
+		t.Parallel()
+
+		testCases := map[string]func(l *ledger) []uint64{
+			"ExitTwice": func(l *ledger) []uint64 {
+				id := l.admit()
+
+				l.exit(id)
+				l.exit(id)
+
+				return nil
+			},
+			"ExitWithoutID": func(l *ledger) []uint64 {
+				l.exit(0)
+
+				return nil
+			},
+			"ExitNotAdmitted": func(l *ledger) []uint64 {
+				l.exit(1)
+
+				return nil
+			},
+			"Lost": func(l *ledger) []uint64 {
+				l.admit()
+
+				return nil
+			},
+			"QueuedTwice": func(l *ledger) []uint64 {
+				id := l.admit()
+
+				return []uint64{id, id}
+			},
+			"QueuedAfterExit": func(l *ledger) []uint64 {
+				id := l.admit()
+
+				l.exit(id)
+
+				return []uint64{id}
+			},
+		}
+
+		for name, violate := range testCases {
+			violate := violate
+
+			t.Run(name, func(t *testing.T) {
+				t.Parallel()
+
+				l := newLedger()
+
+				queued := violate(l)
+
+				if err := l.check(queued); !errors.Is(err, ErrElementsNotConserved) {
+					t.Fatalf("expected error to be %v, got %v", ErrElementsNotConserved, err)
+				}
+			})
+		}
+	})
+
+	t.Run("MaxViolations", func(t *testing.T) {
+		t.Parallel()
+
+		l := newLedger()
+
+		for i := 0; i < 2*maxViolations; i++ {
+			l.exit(0)
+		}
+
+		if len(l.violations) != maxViolations || l.dropped != maxViolations {
+			t.Fatalf("expected %d violations and %d dropped, got %d and %d", maxViolations, maxViolations, len(l.violations), l.dropped)
+		}
+	})
+}
+
+func TestBlockingLedgerCheck(t *testing.T) {
+	t.Parallel()
+
+	newConserved := func(elems []int, opts ...Option) *Blocking[int] {
+		return NewBlocking(elems, append(opts, WithConservationChecks())...)
+	}
+
+	t.Run("ExitPaths", func(t *testing.T) {
+		t.Parallel()
+
+		testCases := map[string]func(bq *Blocking[int]){
+			"Get": func(bq *Blocking[int]) {
+				_, _ = bq.Get()
+			},
+			"Clear": func(bq *Blocking[int]) {
+				_ = bq.Clear()
+			},
+			"Iterator": func(bq *Blocking[int]) {
+				for range bq.Iterator() {
+				}
+			},
+			"IteratorsN": func(bq *Blocking[int]) {
+				_, _ = bq.IteratorsN(2, RoundRobin)
+			},
+			"Reset": func(bq *Blocking[int]) {
+				bq.Reset()
+			},
+			"Destroy": func(bq *Blocking[int]) {
+				_ = bq.Destroy()
+			},
+			"Exchange": func(bq *Blocking[int]) {
+				_, _ = bq.Exchange(4)
+			},
+			"Rotate": func(bq *Blocking[int]) {
+				_ = bq.Rotate()
+			},
+			"Cancel": func(bq *Blocking[int]) {
+				handle, _ := bq.OfferHandle(4)
+
+				handle.Cancel()
+			},
+			"Urgent": func(bq *Blocking[int]) {
+				_ = bq.OfferUrgent(4)
+				_, _ = bq.Get()
+			},
+			"ConsumeBatchesRequeue": func(bq *Blocking[int]) {
+				_ = bq.ConsumeBatches(context.Background(), 1, 2, time.Second, func([]int) error {
+					return errors.New("rejected")
+				}, WithRequeueOnError())
+			},
+			"OfferCtx": func(bq *Blocking[int]) {
+				_ = bq.OfferCtx(context.Background(), 4)
+				_, _, _ = bq.GetWaitCtx(context.Background())
+			},
+		}
+
+		for name, exit := range testCases {
+			exit := exit
+
+			t.Run(name, func(t *testing.T) {
+				t.Parallel()
+
+				bq := newConserved([]int{1, 2}, WithCapacity(4))
+
+				_ = bq.Offer(3)
+
+				exit(bq)
+
+				if err := bq.LedgerCheck(); err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+
+				// the remaining elements are accounted for once salvaged.
+				_ = bq.Destroy()
+
+				if err := bq.LedgerCheck(); err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+
+				if held := len(bq.ledger.held); held != 0 {
+					t.Fatalf("expected no element to be held, got %d", held)
+				}
+			})
+		}
+	})
+
+	t.Run("AutoFlush", func(t *testing.T) {
+		t.Parallel()
+
+		bq := newConserved(nil, WithAutoFlush(2, func([]int) {}))
+
+		for i := 0; i < 5; i++ {
+			_ = bq.Offer(i)
+		}
+
+		bq.Close()
+
+		if err := bq.LedgerCheck(); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	})
+
+	t.Run("DetectsDuplicate", func(t *testing.T) {
+		t.Parallel()
+
+		bq := newConserved([]int{1, 2})
+
+		// simulate a storage bug duplicating the head of the queue.
+		bq.lock.Lock()
+		bq.meta[1] = bq.meta[0]
+		bq.lock.Unlock()
+
+		if err := bq.LedgerCheck(); !errors.Is(err, ErrElementsNotConserved) {
+			t.Fatalf("expected error to be %v, got %v", ErrElementsNotConserved, err)
+		}
+
+		_ = bq.Clear()
+
+		if err := bq.LedgerCheck(); !errors.Is(err, ErrElementsNotConserved) {
+			t.Fatalf("expected error to be %v, got %v", ErrElementsNotConserved, err)
+		}
+	})
+
+	t.Run("WithoutOption", func(t *testing.T) {
+		t.Parallel()
+
+		defer func() {
+			if recover() == nil {
+				t.Fatal("expected LedgerCheck to panic")
+			}
+		}()
+
+		_ = NewBlocking([]int{1}).LedgerCheck()
+	})
+}
+
+func TestPriorityLedgerCheck(t *testing.T) {
+	t.Parallel()
+
+	t.Run("ExitPaths", func(t *testing.T) {
+		t.Parallel()
+
+		testCases := map[string]func(pq *Priority[int]){
+			"Get": func(pq *Priority[int]) {
+				_, _ = pq.Get()
+			},
+			"Clear": func(pq *Priority[int]) {
+				_ = pq.Clear()
+			},
+			"ClearUnsafe": func(pq *Priority[int]) {
+				_ = pq.ClearUnsafe()
+			},
+			"Iterator": func(pq *Priority[int]) {
+				for range pq.Iterator() {
+				}
+			},
+			"Reset": func(pq *Priority[int]) {
+				pq.Reset()
+			},
+			"Exchange": func(pq *Priority[int]) {
+				_, _ = pq.Exchange(4)
+			},
+			"Update": func(pq *Priority[int]) {
+				_ = pq.Update(2, 5)
+			},
+			"Remove": func(pq *Priority[int]) {
+				_ = pq.Remove(2)
+			},
+			"Rebuild": func(pq *Priority[int]) {
+				pq.Rebuild()
+			},
+			"OfferBoundedEviction": func(pq *Priority[int]) {
+				_, _ = pq.OfferBounded(0)
+			},
+			"OfferBoundedRejected": func(pq *Priority[int]) {
+				_, _ = pq.OfferBounded(4)
+			},
+			"UnmarshalBinary": func(pq *Priority[int]) {
+				data, _ := NewPriority([]int{7, 8}, pq.core.elements.lessFunc, WithCapacity(3)).MarshalBinary()
+
+				_ = pq.UnmarshalBinary(data)
+			},
+		}
+
+		for name, exit := range testCases {
+			exit := exit
+
+			t.Run(name, func(t *testing.T) {
+				t.Parallel()
+
+				pq := NewPriority(
+					[]int{1, 2},
+					func(elem, otherElem int) bool { return elem < otherElem },
+					WithCapacity(3),
+					WithConservationChecks(),
+				)
+
+				if _, err := pq.OfferBounded(3); err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+
+				exit(pq)
+
+				if err := pq.LedgerCheck(); err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+
+				_ = pq.Clear()
+
+				if err := pq.LedgerCheck(); err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+
+				if held := len(pq.core.elements.ledger.held); held != 0 {
+					t.Fatalf("expected no element to be held, got %d", held)
+				}
+			})
+		}
+	})
+
+	t.Run("DetectsDuplicate", func(t *testing.T) {
+		t.Parallel()
+
+		pq := NewPriority([]int{1, 2}, func(elem, otherElem int) bool { return elem < otherElem }, WithConservationChecks())
+
+		// simulate a storage bug duplicating the head of the queue.
+		pq.core.lock.Lock()
+		pq.core.elements.ids[1] = pq.core.elements.ids[0]
+		pq.core.lock.Unlock()
+
+		if err := pq.LedgerCheck(); !errors.Is(err, ErrElementsNotConserved) {
+			t.Fatalf("expected error to be %v, got %v", ErrElementsNotConserved, err)
+		}
+	})
+
+	t.Run("WithoutOption", func(t *testing.T) {
+		t.Parallel()
+
+		defer func() {
+			if recover() == nil {
+				t.Fatal("expected LedgerCheck to panic")
+			}
+		}()
+
+		_ = NewPriority([]int{1}, func(elem, otherElem int) bool { return elem < otherElem }).LedgerCheck()
+	})
+}
+
+// TestConservationSoak hammers queues enabling random combinations of
+// features with concurrent producers and consumers, checking that every
+// element is accounted for exactly once.
+func TestConservationSoak(t *testing.T) {
+	if testing.Short() {
+		t.Skip("soak test skipped in short mode")
+	}
+
+	t.Parallel()
+
+	seed := time.Now().UnixNano()
+
+	t.Logf("seed: %d", seed)
+
+	rng := rand.New(rand.NewSource(seed))
+
+	for deadline := time.Now().Add(3 * time.Second); time.Now().Before(deadline); {
+		soakRound(t, rng.Int63(), 200*time.Millisecond)
+	}
+}
+
+// soakRound runs the producers and consumers of a single queue for the given
+// duration, and checks its ledger once the queue is destroyed.
+func soakRound(t *testing.T, seed int64, duration time.Duration) {
+	t.Helper()
+
+	rng := rand.New(rand.NewSource(seed))
+
+	opts := []Option{WithConservationChecks()}
+	features := []string{}
+
+	enable := func(feature string, opt Option) {
+		if rng.Intn(2) == 0 {
+			opts = append(opts, opt)
+			features = append(features, feature)
+		}
+	}
+
+	enable("Capacity", WithCapacity(1+rng.Intn(64)))
+	enable("WaiterPriority", WithWaiterPriority())
+	enable("LaneRatio", WithLaneRatio(1+rng.Intn(3)))
+	enable("SpinWait", WithSpinWait(1+rng.Intn(10)))
+	enable("AutoFlush", WithAutoFlush(1+rng.Intn(32), func([]int) {}))
+	enable("ContextPropagation", WithContextPropagation(
+		func(context.Context) any { return 1 },
+		func(ctx context.Context, _ any) context.Context { return ctx },
+	))
+
+	initial := make([]int, rng.Intn(8))
+
+	bq := NewBlocking(initial, opts...)
+
+	ctx, cancel := context.WithTimeout(context.Background(), duration)
+	defer cancel()
+
+	var (
+		handlesMu sync.Mutex
+		handles   []ElementHandle
+	)
+
+	ops := []func(rng *rand.Rand){
+		func(*rand.Rand) { _ = bq.Offer(1) },
+		func(*rand.Rand) { _, _ = bq.TryOffer(1) },
+		func(*rand.Rand) { _ = bq.OfferAll(1, 2, 3) },
+		func(*rand.Rand) { _ = bq.OfferUrgent(1) },
+		func(*rand.Rand) { _ = bq.OfferCtx(ctx, 1) },
+		func(*rand.Rand) { _, _ = bq.Exchange(1) },
+		func(*rand.Rand) {
+			handle, err := bq.OfferHandle(1)
+			if err != nil {
+				return
+			}
+
+			handlesMu.Lock()
+			handles = append(handles, handle)
+			handlesMu.Unlock()
+		},
+		func(rng *rand.Rand) {
+			handlesMu.Lock()
+			defer handlesMu.Unlock()
+
+			if len(handles) == 0 {
+				return
+			}
+
+			i := rng.Intn(len(handles))
+
+			handles[i].Cancel()
+
+			handles[i] = handles[len(handles)-1]
+			handles = handles[:len(handles)-1]
+		},
+		func(*rand.Rand) { _, _ = bq.Get() },
+		func(*rand.Rand) { _, _, _ = bq.TryGet() },
+		func(*rand.Rand) { _, _, _ = bq.GetWaitCtx(ctx) },
+		func(*rand.Rand) { _, _ = bq.Poll(ctx, time.Millisecond) },
+		func(*rand.Rand) { _ = bq.Rotate() },
+		func(*rand.Rand) { _, _ = bq.Peek() },
+		func(*rand.Rand) {
+			batchCtx, cancel := context.WithTimeout(ctx, time.Millisecond)
+			defer cancel()
+
+			_ = bq.ConsumeBatches(batchCtx, 2, 8, time.Millisecond, func([]int) error {
+				return errors.New("rejected")
+			}, WithRequeueOnError())
+		},
+		func(*rand.Rand) {
+			liveCtx, cancel := context.WithTimeout(ctx, time.Millisecond)
+			defer cancel()
+
+			for range bq.Live(liveCtx) {
+			}
+		},
+		func(rng *rand.Rand) {
+			// the rare operations, removing many elements at once.
+			switch rng.Intn(20) {
+			case 0:
+				_ = bq.Clear()
+			case 1:
+				for range bq.Iterator() {
+				}
+			case 2:
+				_, _ = bq.IteratorsN(3, Contiguous)
+			case 3:
+				bq.Reset()
+			case 4:
+				_, _ = bq.ClearIf(func(ClearSnapshot[int]) bool { return true })
+			}
+		},
+	}
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+
+		workerRng := rand.New(rand.NewSource(rng.Int63()))
+
+		go func() {
+			defer wg.Done()
+
+			for ctx.Err() == nil {
+				ops[workerRng.Intn(len(ops))](workerRng)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	// the remaining elements are salvaged.
+	_ = bq.Destroy()
+
+	if err := bq.LedgerCheck(); err != nil {
+		t.Fatalf("features %v, seed %d: expected no error, got %v", features, seed, err)
+	}
+
+	bq.lock.RLock()
+	defer bq.lock.RUnlock()
+
+	if held := len(bq.ledger.held); held != 0 {
+		t.Fatalf("features %v, seed %d: expected no element to be held, got %d", features, seed, held)
+	}
+}
@@ -0,0 +1,286 @@
+package queue
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// ConsumerLease is the exclusive right to consume a Blocking queue created
+// with the WithExclusiveConsumer option, returned by AcquireConsumer. Its
+// removal methods behave as the ones of the queue, as long as the lease is
+// active.
+//
+// The lease is active until Release is called, or until it expires if the
+// WithConsumerLeaseTimeout option is provided. The removals through an
+// inactive lease return the ErrConsumerRequired error, or no element for the
+// methods returning no error, as the removals made through the queue itself
+// always do.
+type ConsumerLease[T comparable] struct {
+	queue *Blocking[T]
+	id    uint64
+}
+
+// Get removes and returns the head of the queue, as Blocking.Get does.
+func (l *ConsumerLease[T]) Get() (T, error) {
+	return l.queue.consumeGet(l.id)
+}
+
+// GetWait removes and returns the head of the queue, waiting for an element
+// to become available, as Blocking.GetWait does. It returns the zero value
+// of T if the lease is not active.
+func (l *ConsumerLease[T]) GetWait() T {
+	return l.queue.consumeGetWait(l.id)
+}
+
+// GetCtx removes and returns the head of the queue, waiting for an element
+// to become available until ctx is done, as Blocking.GetCtx does.
+func (l *ConsumerLease[T]) GetCtx(ctx context.Context) (T, error) {
+	return l.queue.consumeGetCtx(ctx, l.id)
+}
+
+// GetN removes and returns up to n elements from the head of the queue, as
+// Blocking.GetN does. It returns no elements if the lease is not active.
+func (l *ConsumerLease[T]) GetN(n int) []T {
+	return l.queue.consumeGetN(l.id, n)
+}
+
+// Clear removes and returns all elements from the queue, as Blocking.Clear
+// does. It returns no elements if the lease is not active.
+func (l *ConsumerLease[T]) Clear() []T {
+	return l.queue.consumeClear(l.id)
+}
+
+// Iterator returns an iterator over the elements in the queue, removing them
+// from the queue, as Blocking.Iterator does. The iterator is empty if the
+// lease is not active.
+func (l *ConsumerLease[T]) Iterator() <-chan T {
+	return l.queue.consumeIterator(l.id)
+}
+
+// GetWaitCtx removes and returns the head of the queue along with its
+// context, waiting for an element to become available until base is done, as
+// Blocking.GetWaitCtx does.
+func (l *ConsumerLease[T]) GetWaitCtx(base context.Context) (T, context.Context, error) {
+	return l.queue.consumeGetWaitCtx(base, l.id)
+}
+
+// TryGet attempts to remove and return the head of the queue without waiting
+// for the queue lock, as Blocking.TryGet does.
+func (l *ConsumerLease[T]) TryGet() (elem T, acquired bool, _ error) {
+	return l.queue.consumeTryGet(l.id)
+}
+
+// Poll removes and returns the head of the queue, waiting for an element to
+// become available until ctx is done, as Blocking.Poll does.
+func (l *ConsumerLease[T]) Poll(ctx context.Context, interval time.Duration) (T, error) {
+	return l.queue.consumePoll(ctx, l.id, interval)
+}
+
+// Exchange atomically removes the head of the queue and inserts the element
+// to its tail, as Blocking.Exchange does.
+func (l *ConsumerLease[T]) Exchange(elem T) (T, error) {
+	return l.queue.consumeExchange(l.id, elem)
+}
+
+// DrainWait removes and returns up to n elements from the head of the queue,
+// waiting for an element to become available, as Blocking.DrainWait does. It
+// returns no elements if the lease is not active.
+func (l *ConsumerLease[T]) DrainWait(n int) []T {
+	return l.queue.consumeDrainWait(l.id, n)
+}
+
+// ClearUnsafe removes and returns all elements from the queue, handing its
+// backing array over to the caller, as Blocking.ClearUnsafe does. It returns
+// no elements if the lease is not active.
+func (l *ConsumerLease[T]) ClearUnsafe() []T {
+	return l.queue.consumeClearUnsafe(l.id)
+}
+
+// ClearReport removes and returns all elements from the queue along with the
+// number of producers it releases, as Blocking.ClearReport does. It returns
+// no elements and no producers if the lease is not active.
+func (l *ConsumerLease[T]) ClearReport() ClearResult[T] {
+	return l.queue.consumeClearReport(l.id)
+}
+
+// ClearIf removes and returns all elements from the queue if pred returns
+// true, as Blocking.ClearIf does. It returns nil and false, without calling
+// pred, if the lease is not active.
+func (l *ConsumerLease[T]) ClearIf(pred func(snapshot ClearSnapshot[T]) bool) ([]T, bool) {
+	return l.queue.consumeClearIf(l.id, pred)
+}
+
+// IteratorsN removes all the elements from the queue and distributes them
+// among k channels, as Blocking.IteratorsN does.
+func (l *ConsumerLease[T]) IteratorsN(k int, mode Partition) ([]<-chan T, error) {
+	return l.queue.consumeIteratorsN(l.id, k, mode)
+}
+
+// ConsumeBatches repeatedly removes batches of elements from the queue and
+// passes them to fn, as Blocking.ConsumeBatches does.
+func (l *ConsumerLease[T]) ConsumeBatches(
+	ctx context.Context,
+	minBatch, maxBatch int,
+	maxLinger time.Duration,
+	fn func([]T) error,
+	opts ...BatchOption,
+) error {
+	return l.queue.consumeBatches(ctx, l.id, minBatch, maxBatch, maxLinger, fn, opts...)
+}
+
+// Live returns a channel yielding the elements of the queue as they become
+// available, removing them from the queue, as Blocking.Live does. The channel
+// is closed once the lease is no longer active as well.
+func (l *ConsumerLease[T]) Live(ctx context.Context) <-chan T {
+	return l.queue.consumeLive(ctx, l.id)
+}
+
+// Drain returns a sequence removing the queue elements as they are iterated,
+// as Blocking.Drain does. The sequence ends once the lease is no longer
+// active as well.
+func (l *ConsumerLease[T]) Drain() func(yield func(T) bool) {
+	return l.queue.consumeDrain(l.id)
+}
+
+// Active returns true if the lease is active.
+func (l *ConsumerLease[T]) Active() bool {
+	return l.queue.consumers.active(l.id)
+}
+
+// Release ends the lease, so that another consumer can acquire the queue.
+// It has no effect if the lease is no longer active.
+func (l *ConsumerLease[T]) Release() {
+	l.queue.consumers.release(l.id)
+}
+
+// queueConsumer is the id on behalf of which the removals made through the
+// queue methods enter the consumer gate. It is never granted, thus the gate
+// rejects them.
+const queueConsumer uint64 = 0
+
+// consumerGate grants the consumer leases of a queue created with the
+// WithExclusiveConsumer option, one at a time. A nil consumerGate admits
+// every removal.
+type consumerGate struct {
+	clock   Clock
+	timeout time.Duration
+
+	// lease is the id of the active lease, 0 if there is none, nextID the
+	// id of the last lease granted. inUse counts the removals in progress
+	// through the active lease and lastUse is the time at which it was
+	// granted or at which its last removal returned.
+	lease   uint64
+	nextID  uint64
+	inUse   int
+	lastUse time.Time
+
+	lock sync.Mutex
+}
+
+// newConsumerGate returns the gate of a queue, nil unless the
+// WithExclusiveConsumer option is provided.
+func newConsumerGate(opts options) *consumerGate {
+	if !opts.exclusiveConsumer {
+		return nil
+	}
+
+	clock := opts.clock
+	if clock == nil {
+		clock = systemClock{}
+	}
+
+	return &consumerGate{
+		clock:   clock,
+		timeout: opts.consumerLeaseTimeout,
+	}
+}
+
+// acquire grants a new lease and returns its id, unless another lease is
+// active.
+func (g *consumerGate) acquire() (uint64, error) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	if g.lease != 0 && !g.expired() {
+		return 0, ErrConsumerActive
+	}
+
+	g.nextID++
+
+	g.lease = g.nextID
+	g.inUse = 0
+	g.lastUse = g.clock.Now()
+
+	return g.lease, nil
+}
+
+// enter starts a removal on behalf of the lease with the given id. It
+// returns the ErrConsumerRequired error if the lease is not active.
+func (g *consumerGate) enter(id uint64) error {
+	if g == nil {
+		return nil
+	}
+
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	if id == queueConsumer || g.lease != id || g.expired() {
+		return ErrConsumerRequired
+	}
+
+	g.inUse++
+
+	return nil
+}
+
+// leave ends a removal started by enter.
+func (g *consumerGate) leave(id uint64) {
+	if g == nil {
+		return
+	}
+
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	// the lease may have been released meanwhile.
+	if id == queueConsumer || g.lease != id {
+		return
+	}
+
+	g.inUse--
+	g.lastUse = g.clock.Now()
+}
+
+// active returns true if the lease is active.
+func (g *consumerGate) active(id uint64) bool {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	return g.lease == id && !g.expired()
+}
+
+// release ends the lease, if it is active.
+func (g *consumerGate) release(id uint64) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	if g.lease == id {
+		g.lease = 0
+	}
+}
+
+// expired returns true if the active lease has not been used for the
+// timeout. It must be called while holding the gate lock.
+func (g *consumerGate) expired() bool {
+	return g.timeout > 0 && g.inUse == 0 && g.clock.Now().Sub(g.lastUse) >= g.timeout
+}
+
+// closedChan returns a closed channel.
+func closedChan[T any]() <-chan T {
+	ch := make(chan T)
+
+	close(ch)
+
+	return ch
+}
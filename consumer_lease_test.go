@@ -0,0 +1,401 @@
+package queue_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/adrianbrad/queue"
+)
+
+func TestWithExclusiveConsumer(t *testing.T) {
+	t.Parallel()
+
+	acquire := func(t *testing.T, blockingQueue *queue.Blocking[int]) *queue.ConsumerLease[int] {
+		t.Helper()
+
+		lease, err := blockingQueue.AcquireConsumer()
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		return lease
+	}
+
+	t.Run("SecondAcquirerRejected", func(t *testing.T) {
+		t.Parallel()
+
+		blockingQueue := queue.NewBlocking([]int{1}, queue.WithExclusiveConsumer())
+
+		_ = acquire(t, blockingQueue)
+
+		if _, err := blockingQueue.AcquireConsumer(); !errors.Is(err, queue.ErrConsumerActive) {
+			t.Fatalf("expected error to be %v, got %v", queue.ErrConsumerActive, err)
+		}
+	})
+
+	t.Run("QueueRemovalsRejected", func(t *testing.T) {
+		t.Parallel()
+
+		blockingQueue := queue.NewBlocking([]int{1, 2}, queue.WithExclusiveConsumer())
+
+		lease := acquire(t, blockingQueue)
+
+		if _, err := blockingQueue.Get(); !errors.Is(err, queue.ErrConsumerRequired) {
+			t.Fatalf("expected error to be %v, got %v", queue.ErrConsumerRequired, err)
+		}
+
+		if _, err := blockingQueue.GetCtx(context.Background()); !errors.Is(err, queue.ErrConsumerRequired) {
+			t.Fatalf("expected error to be %v, got %v", queue.ErrConsumerRequired, err)
+		}
+
+		if _, err := queue.Move[int](blockingQueue, queue.NewLinked([]int{})); !errors.Is(err, queue.ErrConsumerRequired) {
+			t.Fatalf("expected error to be %v, got %v", queue.ErrConsumerRequired, err)
+		}
+
+		if _, _, err := blockingQueue.TryGet(); !errors.Is(err, queue.ErrConsumerRequired) {
+			t.Fatalf("expected error to be %v, got %v", queue.ErrConsumerRequired, err)
+		}
+
+		if _, err := blockingQueue.Exchange(3); !errors.Is(err, queue.ErrConsumerRequired) {
+			t.Fatalf("expected error to be %v, got %v", queue.ErrConsumerRequired, err)
+		}
+
+		// the removals which cannot return an error remove no element.
+		if elem := blockingQueue.GetWait(); elem != 0 {
+			t.Fatalf("expected elem to be 0, got %d", elem)
+		}
+
+		for name, remove := range map[string]func() []int{
+			"GetN":        func() []int { return blockingQueue.GetN(2) },
+			"DrainWait":   func() []int { return blockingQueue.DrainWait(2) },
+			"Clear":       blockingQueue.Clear,
+			"ClearUnsafe": blockingQueue.ClearUnsafe,
+			"ClearReport": func() []int { return blockingQueue.ClearReport().Elements },
+			"ClearIf": func() []int {
+				elems, _ := blockingQueue.ClearIf(func(queue.ClearSnapshot[int]) bool { return true })
+
+				return elems
+			},
+			"Iterator": func() []int { return receiveAll(blockingQueue.Iterator()) },
+			"Live":     func() []int { return receiveAll(blockingQueue.Live(context.Background())) },
+			"Drain": func() []int {
+				var elems []int
+
+				blockingQueue.Drain()(func(elem int) bool {
+					elems = append(elems, elem)
+
+					return true
+				})
+
+				return elems
+			},
+		} {
+			if elems := remove(); len(elems) != 0 {
+				t.Fatalf("expected %s to remove no element, got %v", name, elems)
+			}
+		}
+
+		if elem := queue.GetWaitAffinity(blockingQueue, 1, func(elem int) int { return elem }, 0); elem != 0 {
+			t.Fatalf("expected elem to be 0, got %d", elem)
+		}
+
+		if size := blockingQueue.Size(); size != 2 {
+			t.Fatalf("expected size to be %d, got %d", 2, size)
+		}
+
+		// the producers and the examinations are not restricted.
+		if err := blockingQueue.Offer(3); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if elem, err := blockingQueue.Peek(); err != nil || elem != 1 {
+			t.Fatalf("expected head to be 1, got %d, %v", elem, err)
+		}
+
+		if elem, err := lease.Get(); err != nil || elem != 1 {
+			t.Fatalf("expected elem to be 1, got %d, %v", elem, err)
+		}
+
+		if elem := lease.GetWait(); elem != 2 {
+			t.Fatalf("expected elem to be 2, got %d", elem)
+		}
+
+		if elems := lease.Clear(); !reflect.DeepEqual([]int{3}, elems) {
+			t.Fatalf("expected elements to be %v, got %v", []int{3}, elems)
+		}
+	})
+
+	t.Run("LeaseRemovals", func(t *testing.T) {
+		t.Parallel()
+
+		blockingQueue := queue.NewBlocking([]int{1, 2, 3, 4, 5, 6, 7, 8}, queue.WithExclusiveConsumer())
+
+		lease := acquire(t, blockingQueue)
+
+		if elem, acquired, err := lease.TryGet(); err != nil || !acquired || elem != 1 {
+			t.Fatalf("expected elem to be 1, got %d, %t, %v", elem, acquired, err)
+		}
+
+		if elem, _, err := lease.GetWaitCtx(context.Background()); err != nil || elem != 2 {
+			t.Fatalf("expected elem to be 2, got %d, %v", elem, err)
+		}
+
+		if elem, err := lease.Poll(context.Background(), time.Millisecond); err != nil || elem != 3 {
+			t.Fatalf("expected elem to be 3, got %d, %v", elem, err)
+		}
+
+		if elem, err := lease.Exchange(9); err != nil || elem != 4 {
+			t.Fatalf("expected elem to be 4, got %d, %v", elem, err)
+		}
+
+		if elems := lease.DrainWait(1); !reflect.DeepEqual([]int{5}, elems) {
+			t.Fatalf("expected elements to be %v, got %v", []int{5}, elems)
+		}
+
+		var drained []int
+
+		lease.Drain()(func(elem int) bool {
+			drained = append(drained, elem)
+
+			return len(drained) < 2
+		})
+
+		if !reflect.DeepEqual([]int{6, 7}, drained) {
+			t.Fatalf("expected elements to be %v, got %v", []int{6, 7}, drained)
+		}
+
+		if elems, cleared := lease.ClearIf(func(snapshot queue.ClearSnapshot[int]) bool {
+			return snapshot.Size > 2
+		}); cleared || elems != nil {
+			t.Fatalf("expected no elements to be cleared, got %v", elems)
+		}
+
+		if result := lease.ClearReport(); !reflect.DeepEqual([]int{8, 9}, result.Elements) {
+			t.Fatalf("expected elements to be %v, got %v", []int{8, 9}, result.Elements)
+		}
+
+		if err := blockingQueue.Offer(1); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+
+		liveCh := lease.Live(ctx)
+
+		if elem := <-liveCh; elem != 1 {
+			t.Fatalf("expected elem to be 1, got %d", elem)
+		}
+
+		cancel()
+
+		if elems := receiveAll(liveCh); len(elems) != 0 {
+			t.Fatalf("expected no elements, got %v", elems)
+		}
+
+		if err := blockingQueue.OfferAll(2, 3); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		channels, err := lease.IteratorsN(1, queue.RoundRobin)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if elems := receiveAll(channels[0]); !reflect.DeepEqual([]int{2, 3}, elems) {
+			t.Fatalf("expected elements to be %v, got %v", []int{2, 3}, elems)
+		}
+
+		if err := blockingQueue.OfferAll(4, 5); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if elems := lease.ClearUnsafe(); !reflect.DeepEqual([]int{4, 5}, elems) {
+			t.Fatalf("expected elements to be %v, got %v", []int{4, 5}, elems)
+		}
+
+		lease.Release()
+
+		// the removals through the released lease remove no element.
+		if err := blockingQueue.Offer(6); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if elems, cleared := lease.ClearIf(func(queue.ClearSnapshot[int]) bool { return true }); cleared || elems != nil {
+			t.Fatalf("expected no elements to be cleared, got %v", elems)
+		}
+
+		if elems := receiveAll(lease.Live(context.Background())); len(elems) != 0 {
+			t.Fatalf("expected no elements, got %v", elems)
+		}
+
+		err = lease.ConsumeBatches(context.Background(), 1, 1, time.Millisecond, func([]int) error {
+			return nil
+		})
+		if !errors.Is(err, queue.ErrConsumerRequired) {
+			t.Fatalf("expected error to be %v, got %v", queue.ErrConsumerRequired, err)
+		}
+	})
+
+	t.Run("ProcessEachRejected", func(t *testing.T) {
+		t.Parallel()
+
+		blockingQueue := queue.NewBlocking([]int{1, 2, 3}, queue.WithExclusiveConsumer())
+
+		lease := acquire(t, blockingQueue)
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		report := queue.ProcessEach(ctx, queue.Queue[int](blockingQueue), func(context.Context, int) error {
+			return nil
+		})
+
+		if report.Succeeded != 0 {
+			t.Fatalf("expected no element to be processed, got %d", report.Succeeded)
+		}
+
+		if elems := lease.Clear(); !reflect.DeepEqual([]int{1, 2, 3}, elems) {
+			t.Fatalf("expected elements to be %v, got %v", []int{1, 2, 3}, elems)
+		}
+	})
+
+	t.Run("ReleaseReacquire", func(t *testing.T) {
+		t.Parallel()
+
+		blockingQueue := queue.NewBlocking([]int{1, 2, 3}, queue.WithExclusiveConsumer())
+
+		for i := 1; i <= 3; i++ {
+			lease := acquire(t, blockingQueue)
+
+			if elem, err := lease.Get(); err != nil || elem != i {
+				t.Fatalf("expected elem to be %d, got %d, %v", i, elem, err)
+			}
+
+			lease.Release()
+
+			if lease.Active() {
+				t.Fatal("expected the released lease not to be active")
+			}
+
+			// the released lease no longer consumes the queue.
+			if _, err := lease.Get(); !errors.Is(err, queue.ErrConsumerRequired) {
+				t.Fatalf("expected error to be %v, got %v", queue.ErrConsumerRequired, err)
+			}
+		}
+	})
+
+	t.Run("TimeoutReclamation", func(t *testing.T) {
+		t.Parallel()
+
+		clock := newFakeClock()
+
+		blockingQueue := queue.NewBlocking(
+			[]int{1, 2},
+			queue.WithClock(clock),
+			queue.WithExclusiveConsumer(),
+			queue.WithConsumerLeaseTimeout(time.Minute),
+		)
+
+		abandoned := acquire(t, blockingQueue)
+
+		clock.Advance(30 * time.Second)
+
+		// a removal renews the lease.
+		if _, err := abandoned.Get(); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		clock.Advance(59 * time.Second)
+
+		if _, err := blockingQueue.AcquireConsumer(); !errors.Is(err, queue.ErrConsumerActive) {
+			t.Fatalf("expected error to be %v, got %v", queue.ErrConsumerActive, err)
+		}
+
+		clock.Advance(time.Second)
+
+		lease := acquire(t, blockingQueue)
+
+		if _, err := abandoned.Get(); !errors.Is(err, queue.ErrConsumerRequired) {
+			t.Fatalf("expected error to be %v, got %v", queue.ErrConsumerRequired, err)
+		}
+
+		// releasing the reclaimed lease does not release the new one.
+		abandoned.Release()
+
+		if elem, err := lease.Get(); err != nil || elem != 2 {
+			t.Fatalf("expected elem to be 2, got %d, %v", elem, err)
+		}
+	})
+
+	t.Run("WaitingLeaseNotReclaimed", func(t *testing.T) {
+		t.Parallel()
+
+		clock := newFakeClock()
+
+		blockingQueue := queue.NewBlocking(
+			[]int{},
+			queue.WithClock(clock),
+			queue.WithExclusiveConsumer(),
+			queue.WithConsumerLeaseTimeout(time.Minute),
+		)
+
+		lease := acquire(t, blockingQueue)
+
+		result := make(chan int)
+
+		go func() {
+			result <- lease.GetWait()
+		}()
+
+		// the lease is in use once the consumer waits.
+		for blockingQueue.BlockedState().BlockedGets == 0 {
+			time.Sleep(time.Millisecond)
+		}
+
+		clock.Advance(time.Hour)
+
+		if _, err := blockingQueue.AcquireConsumer(); !errors.Is(err, queue.ErrConsumerActive) {
+			t.Fatalf("expected error to be %v, got %v", queue.ErrConsumerActive, err)
+		}
+
+		if err := blockingQueue.Offer(1); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if elem := <-result; elem != 1 {
+			t.Fatalf("expected elem to be 1, got %d", elem)
+		}
+
+		if !lease.Active() {
+			t.Fatal("expected the lease to be active")
+		}
+	})
+
+	t.Run("ModeOff", func(t *testing.T) {
+		t.Parallel()
+
+		blockingQueue := queue.NewBlocking([]int{1})
+
+		if _, err := blockingQueue.AcquireConsumer(); !errors.Is(err, queue.ErrUnsupportedOperation) {
+			t.Fatalf("expected error to be %v, got %v", queue.ErrUnsupportedOperation, err)
+		}
+
+		if elem, err := blockingQueue.Get(); err != nil || elem != 1 {
+			t.Fatalf("expected elem to be 1, got %d, %v", elem, err)
+		}
+	})
+}
+
+// receiveAll receives the elements from ch until it is closed.
+func receiveAll(ch <-chan int) []int {
+	var elems []int
+
+	for elem := range ch {
+		elems = append(elems, elem)
+	}
+
+	return elems
+}
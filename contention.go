@@ -0,0 +1,269 @@
+package queue
+
+import (
+	"math"
+	"math/bits"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ContentionStats describes the time the sampled calls of a queue method
+// spent waiting to acquire the queue lock.
+type ContentionStats struct {
+	// Count is the number of sampled calls.
+	Count int
+
+	// P50 and P95 are upper bounds of the median and of the 95th
+	// percentile of the wait times, with a power of two precision.
+	P50 time.Duration
+	P95 time.Duration
+
+	// Max is the longest wait time.
+	Max time.Duration
+}
+
+// profiledRWMutex is a sync.RWMutex which, if a profiler is set, samples the
+// time spent waiting to acquire it. Without a profiler it only adds a nil
+// check to the locking methods.
+type profiledRWMutex struct {
+	sync.RWMutex
+
+	profiler *contentionProfiler
+
+	// settle completes the pending work of the queue before the mutex is
+	// unlocked for writing, such as refilling it from its disk overflow.
+	settle func()
+
+	// verify checks the queue invariants before the mutex is unlocked for
+	// writing, it is only set while testing.
+	verify func()
+
+	// publish publishes the read snapshot of the queue before the mutex is
+	// unlocked for writing, if the WithReadSnapshotting option is provided.
+	publish func()
+
+	// wake wakes the goroutines waiting on the queue conditions before the
+	// mutex is unlocked for writing, if the queue does not signal them
+	// itself.
+	wake func()
+
+	// unlocks counts the unlocks for writing, if not nil, so that the
+	// goroutines spinning without holding the mutex can tell whether the
+	// state it guards may have changed.
+	unlocks *atomic.Uint64
+}
+
+// Lock locks the mutex for writing.
+func (m *profiledRWMutex) Lock() {
+	if m.profiler == nil || !m.profiler.sample() {
+		m.RWMutex.Lock()
+
+		return
+	}
+
+	start := time.Now()
+
+	m.RWMutex.Lock()
+
+	m.profiler.record(time.Since(start))
+}
+
+// Unlock unlocks the mutex for writing.
+func (m *profiledRWMutex) Unlock() {
+	if m.settle != nil {
+		m.settle()
+	}
+
+	if m.verify != nil {
+		m.verify()
+	}
+
+	if m.publish != nil {
+		m.publish()
+	}
+
+	if m.wake != nil {
+		m.wake()
+	}
+
+	if m.unlocks != nil {
+		m.unlocks.Add(1)
+	}
+
+	m.RWMutex.Unlock()
+}
+
+// RLock locks the mutex for reading.
+func (m *profiledRWMutex) RLock() {
+	if m.profiler == nil || !m.profiler.sample() {
+		m.RWMutex.RLock()
+
+		return
+	}
+
+	start := time.Now()
+
+	m.RWMutex.RLock()
+
+	m.profiler.record(time.Since(start))
+}
+
+// contentionProfile returns the profile of the sampled lock acquisitions,
+// or nil if profiling is disabled.
+func (m *profiledRWMutex) contentionProfile() map[string]ContentionStats {
+	if m.profiler == nil {
+		return nil
+	}
+
+	return m.profiler.profile()
+}
+
+// contentionProfiler accumulates the wait times of the sampled lock
+// acquisitions, per queue method.
+type contentionProfiler struct {
+	// every is the sampling interval, one in every acquisitions is sampled.
+	every   uint64
+	counter atomic.Uint64
+
+	lock       sync.Mutex
+	histograms map[string]*waitHistogram
+}
+
+// newContentionProfiler returns a profiler sampling the given fraction of
+// the lock acquisitions, or nil if the sample rate is not positive.
+func newContentionProfiler(sampleRate float64) *contentionProfiler {
+	if !(sampleRate > 0) {
+		return nil
+	}
+
+	every := uint64(1)
+
+	if sampleRate < 1 {
+		every = uint64(math.Round(1 / sampleRate))
+	}
+
+	return &contentionProfiler{
+		every:      every,
+		histograms: make(map[string]*waitHistogram),
+	}
+}
+
+// sample reports whether the current acquisition is sampled.
+func (p *contentionProfiler) sample() bool {
+	return p.counter.Add(1)%p.every == 0
+}
+
+// record adds the wait time of a sampled acquisition to the histogram
+// of the queue method which acquired the lock.
+func (p *contentionProfiler) record(wait time.Duration) {
+	op := callerMethod()
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	h, ok := p.histograms[op]
+	if !ok {
+		h = &waitHistogram{}
+		p.histograms[op] = h
+	}
+
+	h.add(wait)
+}
+
+// profile returns the stats of every profiled queue method.
+func (p *contentionProfiler) profile() map[string]ContentionStats {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	profile := make(map[string]ContentionStats, len(p.histograms))
+
+	for op, h := range p.histograms {
+		profile[op] = h.stats()
+	}
+
+	return profile
+}
+
+// callerMethod returns the name of the queue method acquiring the lock,
+// skipping the frames of the lock and of the profiler.
+func callerMethod() string {
+	// callerMethod, record, Lock or RLock.
+	const skip = 4
+
+	var pcs [1]uintptr
+
+	if runtime.Callers(skip, pcs[:]) == 0 {
+		return "unknown"
+	}
+
+	frame, _ := runtime.CallersFrames(pcs[:]).Next()
+
+	return methodName(frame.Function)
+}
+
+// methodName returns the method name from a fully qualified function name,
+// such as "github.com/adrianbrad/queue.(*Blocking[...]).Offer.func1".
+func methodName(function string) string {
+	// drop the package path, which may contain dots.
+	if i := strings.LastIndex(function, "/"); i >= 0 {
+		function = function[i+1:]
+	}
+
+	// drop the type parameters of the generic functions, such as Move[...].
+	function = strings.ReplaceAll(function, "[...]", "")
+
+	parts := strings.Split(function, ".")
+
+	// drop the closures.
+	for len(parts) > 1 && strings.HasPrefix(parts[len(parts)-1], "func") {
+		parts = parts[:len(parts)-1]
+	}
+
+	// the removals shared by the queue and its consumer lease, such as
+	// consumeGet, are reported as the method they implement.
+	return strings.TrimPrefix(parts[len(parts)-1], "consume")
+}
+
+// waitHistogram counts the wait times in buckets of powers of two
+// nanoseconds.
+type waitHistogram struct {
+	buckets [64]int
+	count   int
+	max     time.Duration
+}
+
+func (h *waitHistogram) add(wait time.Duration) {
+	h.buckets[bits.Len64(uint64(max(wait, 0)))]++
+	h.count++
+	h.max = max(h.max, wait)
+}
+
+func (h *waitHistogram) stats() ContentionStats {
+	return ContentionStats{
+		Count: h.count,
+		P50:   h.percentile(0.5),
+		P95:   h.percentile(0.95),
+		Max:   h.max,
+	}
+}
+
+// percentile returns the upper bound of the bucket holding the
+// given percentile, capped to the max wait time.
+func (h *waitHistogram) percentile(q float64) time.Duration {
+	rank := int(math.Ceil(q * float64(h.count)))
+
+	seen := 0
+
+	for i, n := range h.buckets {
+		seen += n
+
+		if seen >= rank && n > 0 {
+			return min(time.Duration(1)<<i-1, h.max)
+		}
+	}
+
+	return h.max
+}
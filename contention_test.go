@@ -0,0 +1,145 @@
+package queue_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/adrianbrad/queue"
+)
+
+// contentionProfiledQueue is implemented by all the queues.
+type contentionProfiledQueue interface {
+	Offer(int) error
+	ClearIf(pred func(queue.ClearSnapshot[int]) bool) ([]int, bool)
+	ContentionProfile() map[string]queue.ContentionStats
+}
+
+func TestContentionProfile(t *testing.T) {
+	t.Parallel()
+
+	lessInt := func(elem, otherElem int) bool { return elem < otherElem }
+
+	testCases := map[string]func(opts ...queue.Option) contentionProfiledQueue{
+		"Blocking": func(opts ...queue.Option) contentionProfiledQueue {
+			return queue.NewBlocking([]int{}, opts...)
+		},
+		"Circular": func(opts ...queue.Option) contentionProfiledQueue {
+			return queue.NewCircular([]int{}, 10, opts...)
+		},
+		"Linked": func(opts ...queue.Option) contentionProfiledQueue {
+			return queue.NewLinked([]int{}, opts...)
+		},
+		"Priority": func(opts ...queue.Option) contentionProfiledQueue {
+			return queue.NewPriority([]int{}, lessInt, opts...)
+		},
+		"PriorityAny": func(opts ...queue.Option) contentionProfiledQueue {
+			return queue.NewPriorityAny([]int{}, lessInt, opts...)
+		},
+	}
+
+	for name, newQueue := range testCases {
+		newQueue := newQueue
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			t.Run("InducedWait", func(t *testing.T) {
+				t.Parallel()
+
+				const hold = 50 * time.Millisecond
+
+				q := newQueue(queue.WithContentionProfiling(1))
+
+				held := make(chan struct{})
+				released := make(chan struct{})
+
+				// the predicate runs while holding the queue lock.
+				go func() {
+					defer close(released)
+
+					q.ClearIf(func(queue.ClearSnapshot[int]) bool {
+						close(held)
+
+						time.Sleep(hold)
+
+						return false
+					})
+				}()
+
+				<-held
+
+				if err := q.Offer(1); err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+
+				<-released
+
+				profile := q.ContentionProfile()
+
+				offer, ok := profile["Offer"]
+				if !ok {
+					t.Fatalf("expected Offer to be profiled, got %v", profile)
+				}
+
+				if offer.Count != 1 {
+					t.Fatalf("expected 1 sampled Offer, got %d", offer.Count)
+				}
+
+				if offer.Max < hold/5 {
+					t.Fatalf("expected Offer to wait for the held lock, got %s", offer.Max)
+				}
+
+				if offer.P50 > offer.Max || offer.P95 > offer.Max {
+					t.Fatalf("expected percentiles not to exceed the max, got %+v", offer)
+				}
+
+				if clearIf := profile["ClearIf"]; clearIf.Count != 1 {
+					t.Fatalf("expected 1 sampled ClearIf, got %d", clearIf.Count)
+				}
+			})
+
+			t.Run("SampleRate", func(t *testing.T) {
+				t.Parallel()
+
+				q := newQueue(queue.WithContentionProfiling(0.25))
+
+				for i := 0; i < 8; i++ {
+					_ = q.Offer(i)
+				}
+
+				if count := q.ContentionProfile()["Offer"].Count; count != 2 {
+					t.Fatalf("expected 2 sampled Offer calls, got %d", count)
+				}
+			})
+
+			t.Run("Disabled", func(t *testing.T) {
+				t.Parallel()
+
+				q := newQueue()
+
+				_ = q.Offer(1)
+
+				if profile := q.ContentionProfile(); profile != nil {
+					t.Fatalf("expected nil profile, got %v", profile)
+				}
+			})
+		})
+	}
+}
+
+func BenchmarkContentionProfilingDisabled(b *testing.B) {
+	linkedQueue := queue.NewLinked([]int{0})
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		_, _ = linkedQueue.Peek()
+	}
+
+	b.StopTimer()
+
+	if allocs := testing.AllocsPerRun(100, func() { _, _ = linkedQueue.Peek() }); allocs != 0 {
+		b.Fatalf("expected no allocations per op, got %.0f", allocs)
+	}
+}
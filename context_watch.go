@@ -0,0 +1,124 @@
+package queue
+
+import (
+	"context"
+	"sync"
+)
+
+// contextWatch closes a Blocking queue once the context bound using
+// WithContext is done.
+//
+// The context references the watch, which references the queue only while
+// goroutines wait on it, unless the queue is pinned, so that a queue which is
+// no longer used can be garbage collected before its context is done. Until
+// a goroutine waits on it, the queue observes the done context on its own,
+// as if it was closed.
+type contextWatch[T comparable] struct {
+	// nolint: containedctx // the watch outlives the constructor call.
+	ctx context.Context
+
+	// stop unregisters the watch from the context.
+	stop func() bool
+
+	lock sync.Mutex
+
+	// queue is the watched queue while it is referenced, nil otherwise.
+	queue *Blocking[T]
+
+	// waiters counts the goroutines waiting on the queue.
+	waiters int
+
+	// pinned keeps the queue referenced until the context is done.
+	pinned bool
+}
+
+// watchContext returns a watch closing the queue once ctx is done, which
+// references the queue until then if pinned is true.
+func watchContext[T comparable](ctx context.Context, queue *Blocking[T], pinned bool) *contextWatch[T] {
+	w := &contextWatch[T]{
+		ctx:    ctx,
+		pinned: pinned,
+	}
+
+	if pinned {
+		w.queue = queue
+	}
+
+	w.stop = context.AfterFunc(ctx, w.closeQueue)
+
+	return w
+}
+
+// closeQueue closes the queue, if it is referenced, once the context is
+// done.
+func (w *contextWatch[T]) closeQueue() {
+	w.lock.Lock()
+	queue := w.queue
+	w.lock.Unlock()
+
+	// the queue was not waited on, it observes the done context on its own.
+	if queue == nil {
+		return
+	}
+
+	queue.lock.Lock()
+	defer queue.lock.Unlock()
+
+	queue.close(closedByContextErr(w.ctx))
+}
+
+// err returns the error reported by the queue once the context is done, or
+// nil while it is not done.
+func (w *contextWatch[T]) err() error {
+	if w == nil || w.ctx.Err() == nil {
+		return nil
+	}
+
+	return closedByContextErr(w.ctx)
+}
+
+// enter references the queue while the calling goroutine, holding the queue
+// lock, waits on it. It returns false if the context is done, in which case
+// the watch might have skipped closing the queue, thus the goroutine must not
+// wait.
+func (w *contextWatch[T]) enter(queue *Blocking[T]) bool {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.ctx.Err() != nil {
+		return false
+	}
+
+	w.queue = queue
+	w.waiters++
+
+	return true
+}
+
+// leave releases the reference taken by enter once the goroutine stops
+// waiting.
+func (w *contextWatch[T]) leave() {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	w.waiters--
+
+	if w.waiters == 0 && !w.pinned {
+		w.queue = nil
+	}
+}
+
+// unbind unregisters the watch from the context once the queue is closed.
+func (w *contextWatch[T]) unbind() {
+	if w == nil {
+		return
+	}
+
+	w.stop()
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	w.queue = nil
+	w.pinned = false
+}
@@ -0,0 +1,336 @@
+package queue
+
+import (
+	"sync"
+)
+
+// Ensure Deque implements the Queue interface.
+var _ Queue[any] = (*Deque[any])(nil)
+
+// Deque is a double-ended Queue implementation, whose elements can be
+// inserted and removed at both its head and its tail, such as the elements
+// pushed back to the head of the queue to be retried first.
+//
+// The elements are held in a ring buffer, so that the insertions and removals
+// at both ends take constant time. The ring buffer of an unbounded deque grows
+// as needed, while the one of a deque created with the WithCapacity option is
+// allocated upfront, the insertions into a full deque returning the
+// ErrQueueIsFull error.
+//
+// Offer is OfferBack, Get is GetFront and Peek is PeekFront, so that a Deque
+// used as a Queue is first in first out.
+type Deque[T comparable] struct {
+	initialElements []T
+
+	// elems is the ring buffer holding the elements, head is the index of the
+	// head of the deque.
+	elems []T
+	head  int
+
+	// occupancy counts the elements and enforces the capacity.
+	occupancy occupancy
+
+	// synchronization
+	lock sync.RWMutex
+}
+
+// NewDeque creates a new Deque containing the given elements, from head to
+// tail. The elements exceeding the capacity provided using WithCapacity are
+// dropped, they are not restored by Reset either.
+func NewDeque[T comparable](
+	elems []T,
+	opts ...Option,
+) *Deque[T] {
+	options := options{}
+
+	for _, o := range opts {
+		o.apply(&options)
+	}
+
+	if options.capacity != nil && len(elems) > *options.capacity {
+		elems = elems[:max(*options.capacity, 0)]
+	}
+
+	d := &Deque[T]{
+		initialElements: initialElementsOf(options, elems, nil),
+		occupancy:       newOccupancy(options.capacity),
+	}
+
+	// the ring buffer of a bounded deque is allocated upfront, so that the
+	// insertions do not allocate.
+	d.elems = make([]T, max(len(elems), capacityOf(options.capacity)))
+
+	copy(d.elems, elems)
+
+	d.occupancy.reset(len(elems))
+
+	d.occupancy.limit = newHardLimit(options)
+
+	return d
+}
+
+// ==================================Insertion=================================
+
+// Offer inserts the element to the tail of the deque, as OfferBack does.
+func (d *Deque[T]) Offer(elem T) error {
+	return d.OfferBack(elem)
+}
+
+// OfferBack inserts the element to the tail of the deque.
+// If the deque is full it returns the ErrQueueIsFull error.
+func (d *Deque[T]) OfferBack(elem T) error {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	if err := d.admit(); err != nil {
+		return err
+	}
+
+	d.elems[d.index(d.occupancy.count-1)] = elem
+
+	return nil
+}
+
+// OfferFront inserts the element to the head of the deque, so that it is the
+// next element retrieved by Get.
+// If the deque is full it returns the ErrQueueIsFull error.
+func (d *Deque[T]) OfferFront(elem T) error {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	if err := d.admit(); err != nil {
+		return err
+	}
+
+	d.head = d.index(len(d.elems) - 1)
+	d.elems[d.head] = elem
+
+	return nil
+}
+
+// Reset sets the deque to its initial state, by replacing the current
+// elements with the elements provided at creation.
+func (d *Deque[T]) Reset() {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	if len(d.elems) < len(d.initialElements) {
+		d.elems = make([]T, len(d.initialElements))
+	}
+
+	copy(d.elems, d.initialElements)
+
+	// zero the slots left over from before the reset.
+	clear(d.elems[len(d.initialElements):])
+
+	d.head = 0
+	d.occupancy.reset(len(d.initialElements))
+}
+
+// ===================================Removal==================================
+
+// Get removes and returns the head of the deque, as GetFront does.
+func (d *Deque[T]) Get() (T, error) {
+	return d.GetFront()
+}
+
+// GetFront removes and returns the head of the deque.
+// If no element is available it returns an ErrNoElementsAvailable error.
+func (d *Deque[T]) GetFront() (elem T, _ error) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	if d.isEmpty() {
+		return elem, ErrNoElementsAvailable
+	}
+
+	elem = d.remove(d.head)
+
+	d.head = d.index(1)
+
+	return elem, nil
+}
+
+// GetBack removes and returns the tail of the deque.
+// If no element is available it returns an ErrNoElementsAvailable error.
+func (d *Deque[T]) GetBack() (elem T, _ error) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	if d.isEmpty() {
+		return elem, ErrNoElementsAvailable
+	}
+
+	return d.remove(d.index(d.occupancy.count - 1)), nil
+}
+
+// Clear removes and returns all the elements from the deque, from head to
+// tail.
+func (d *Deque[T]) Clear() []T {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	elems := d.snapshot()
+
+	clear(d.elems)
+
+	d.head = 0
+	d.occupancy.reset(0)
+
+	return elems
+}
+
+// Iterator returns an iterator over the elements in the deque, from head to
+// tail. It removes the elements from the deque.
+func (d *Deque[T]) Iterator() <-chan T {
+	elems := d.Clear()
+
+	return bufferedChan(len(elems), sliceSeq(elems))
+}
+
+// =================================Examination================================
+
+// Peek returns the head of the deque without removing it, as PeekFront does.
+func (d *Deque[T]) Peek() (T, error) {
+	return d.PeekFront()
+}
+
+// PeekFront returns the head of the deque without removing it.
+// If no element is available it returns an ErrNoElementsAvailable error.
+func (d *Deque[T]) PeekFront() (elem T, _ error) {
+	d.lock.RLock()
+	defer d.lock.RUnlock()
+
+	if d.isEmpty() {
+		return elem, ErrNoElementsAvailable
+	}
+
+	return d.elems[d.head], nil
+}
+
+// PeekBack returns the tail of the deque without removing it.
+// If no element is available it returns an ErrNoElementsAvailable error.
+func (d *Deque[T]) PeekBack() (elem T, _ error) {
+	d.lock.RLock()
+	defer d.lock.RUnlock()
+
+	if d.isEmpty() {
+		return elem, ErrNoElementsAvailable
+	}
+
+	return d.elems[d.index(d.occupancy.count-1)], nil
+}
+
+// Contains returns true if the deque contains the element.
+func (d *Deque[T]) Contains(elem T) bool {
+	d.lock.RLock()
+	defer d.lock.RUnlock()
+
+	for i := 0; i < d.occupancy.count; i++ {
+		if d.elems[d.index(i)] == elem {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Size returns the number of elements in the deque.
+func (d *Deque[T]) Size() int {
+	d.lock.RLock()
+	defer d.lock.RUnlock()
+
+	return d.occupancy.count
+}
+
+// IsEmpty returns true if the deque is empty.
+func (d *Deque[T]) IsEmpty() bool {
+	d.lock.RLock()
+	defer d.lock.RUnlock()
+
+	return d.isEmpty()
+}
+
+// Capacity returns the capacity of the deque, or -1 if it is unbounded.
+func (d *Deque[T]) Capacity() int {
+	return d.occupancy.capacity
+}
+
+// Remaining returns the number of elements the deque can hold in addition
+// to its current elements, or -1 if it is unbounded.
+func (d *Deque[T]) Remaining() int {
+	d.lock.RLock()
+	defer d.lock.RUnlock()
+
+	return d.occupancy.remaining()
+}
+
+// ToSlice returns a slice of the deque elements, from head to tail.
+func (d *Deque[T]) ToSlice() []T {
+	d.lock.RLock()
+	defer d.lock.RUnlock()
+
+	return d.snapshot()
+}
+
+// ===================================Helpers==================================
+
+// admit accounts for an element inserted into the deque, growing the ring
+// buffer of an unbounded deque if it is full.
+func (d *Deque[T]) admit() error {
+	if err := d.occupancy.admit(1, 0); err != nil {
+		return err
+	}
+
+	if d.occupancy.count > len(d.elems) {
+		d.grow()
+	}
+
+	return nil
+}
+
+// grow doubles the length of the ring buffer, moving the elements to its
+// start.
+func (d *Deque[T]) grow() {
+	elems := make([]T, max(2*len(d.elems), 1))
+
+	n := copy(elems, d.elems[d.head:])
+	copy(elems[n:], d.elems[:d.head])
+
+	d.elems = elems
+	d.head = 0
+}
+
+// remove zeroes the slot at index i, so it does not retain the removed
+// element, and returns the element.
+func (d *Deque[T]) remove(i int) T {
+	elem := d.elems[i]
+
+	var zero T
+	d.elems[i] = zero
+
+	d.occupancy.releaseAdmission(1, 0)
+
+	return elem
+}
+
+// index returns the ring buffer index of the i-th element from the head.
+func (d *Deque[T]) index(i int) int {
+	return (d.head + i) % len(d.elems)
+}
+
+// isEmpty returns true if the deque is empty.
+func (d *Deque[T]) isEmpty() bool {
+	return d.occupancy.count == 0
+}
+
+// snapshot returns a copy of the elements, from head to tail.
+func (d *Deque[T]) snapshot() []T {
+	elems := make([]T, d.occupancy.count)
+
+	for i := range elems {
+		elems[i] = d.elems[d.index(i)]
+	}
+
+	return elems
+}
@@ -0,0 +1,300 @@
+package queue_test
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/adrianbrad/queue"
+)
+
+func TestDeque(t *testing.T) {
+	t.Parallel()
+
+	t.Run("BothEnds", func(t *testing.T) {
+		t.Parallel()
+
+		deque := queue.NewDeque([]int{2, 3})
+
+		if err := deque.OfferFront(1); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if err := deque.OfferBack(4); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if elems := deque.ToSlice(); !reflect.DeepEqual([]int{1, 2, 3, 4}, elems) {
+			t.Fatalf("expected elements to be %v, got %v", []int{1, 2, 3, 4}, elems)
+		}
+
+		if elem, err := deque.PeekFront(); err != nil || elem != 1 {
+			t.Fatalf("expected head to be 1, got %d, %v", elem, err)
+		}
+
+		if elem, err := deque.PeekBack(); err != nil || elem != 4 {
+			t.Fatalf("expected tail to be 4, got %d, %v", elem, err)
+		}
+
+		if elem, err := deque.GetBack(); err != nil || elem != 4 {
+			t.Fatalf("expected tail to be 4, got %d, %v", elem, err)
+		}
+
+		if elem, err := deque.GetFront(); err != nil || elem != 1 {
+			t.Fatalf("expected head to be 1, got %d, %v", elem, err)
+		}
+
+		if elems := deque.ToSlice(); !reflect.DeepEqual([]int{2, 3}, elems) {
+			t.Fatalf("expected elements to be %v, got %v", []int{2, 3}, elems)
+		}
+	})
+
+	t.Run("Empty", func(t *testing.T) {
+		t.Parallel()
+
+		deque := queue.NewDeque[int](nil)
+
+		if _, err := deque.GetFront(); !errors.Is(err, queue.ErrNoElementsAvailable) {
+			t.Fatalf("expected error to be %v, got %v", queue.ErrNoElementsAvailable, err)
+		}
+
+		if _, err := deque.GetBack(); !errors.Is(err, queue.ErrNoElementsAvailable) {
+			t.Fatalf("expected error to be %v, got %v", queue.ErrNoElementsAvailable, err)
+		}
+
+		if _, err := deque.PeekFront(); !errors.Is(err, queue.ErrNoElementsAvailable) {
+			t.Fatalf("expected error to be %v, got %v", queue.ErrNoElementsAvailable, err)
+		}
+
+		if _, err := deque.PeekBack(); !errors.Is(err, queue.ErrNoElementsAvailable) {
+			t.Fatalf("expected error to be %v, got %v", queue.ErrNoElementsAvailable, err)
+		}
+
+		if !deque.IsEmpty() || deque.Size() != 0 {
+			t.Fatalf("expected deque to be empty, got %v", deque.ToSlice())
+		}
+	})
+
+	t.Run("Grow", func(t *testing.T) {
+		t.Parallel()
+
+		deque := queue.NewDeque[int](nil)
+
+		// the elements wrap around the ring buffer as it grows.
+		want := make([]int, 0, 64)
+
+		for i := 0; i < 32; i++ {
+			_ = deque.OfferBack(i)
+			_ = deque.OfferFront(-i - 1)
+
+			want = append([]int{-i - 1}, want...)
+			want = append(want, i)
+		}
+
+		if elems := deque.ToSlice(); !reflect.DeepEqual(want, elems) {
+			t.Fatalf("expected elements to be %v, got %v", want, elems)
+		}
+
+		if deque.Capacity() != -1 {
+			t.Fatalf("expected capacity to be -1, got %d", deque.Capacity())
+		}
+	})
+
+	t.Run("WithCapacity", func(t *testing.T) {
+		t.Parallel()
+
+		deque := queue.NewDeque([]int{1, 2, 3}, queue.WithCapacity(2))
+
+		if elems := deque.ToSlice(); !reflect.DeepEqual([]int{1, 2}, elems) {
+			t.Fatalf("expected elements to be %v, got %v", []int{1, 2}, elems)
+		}
+
+		if err := deque.OfferFront(0); !errors.Is(err, queue.ErrQueueIsFull) {
+			t.Fatalf("expected error to be %v, got %v", queue.ErrQueueIsFull, err)
+		}
+
+		if err := deque.OfferBack(3); !errors.Is(err, queue.ErrQueueIsFull) {
+			t.Fatalf("expected error to be %v, got %v", queue.ErrQueueIsFull, err)
+		}
+
+		_, _ = deque.GetBack()
+
+		if err := deque.OfferFront(0); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if elems := deque.ToSlice(); !reflect.DeepEqual([]int{0, 1}, elems) {
+			t.Fatalf("expected elements to be %v, got %v", []int{0, 1}, elems)
+		}
+	})
+
+	t.Run("Reset", func(t *testing.T) {
+		t.Parallel()
+
+		deque := queue.NewDeque([]int{1, 2})
+
+		_, _ = deque.Get()
+
+		for i := 0; i < 8; i++ {
+			_ = deque.OfferFront(i)
+		}
+
+		deque.Reset()
+
+		if elems := deque.ToSlice(); !reflect.DeepEqual([]int{1, 2}, elems) {
+			t.Fatalf("expected elements to be %v, got %v", []int{1, 2}, elems)
+		}
+
+		_ = deque.OfferFront(0)
+
+		if elems := deque.ToSlice(); !reflect.DeepEqual([]int{0, 1, 2}, elems) {
+			t.Fatalf("expected elements to be %v, got %v", []int{0, 1, 2}, elems)
+		}
+	})
+
+	t.Run("ClearAndIterator", func(t *testing.T) {
+		t.Parallel()
+
+		deque := queue.NewDeque([]int{2, 3})
+
+		_ = deque.OfferFront(1)
+
+		if elems := deque.Clear(); !reflect.DeepEqual([]int{1, 2, 3}, elems) {
+			t.Fatalf("expected elements to be %v, got %v", []int{1, 2, 3}, elems)
+		}
+
+		_ = deque.OfferFront(5)
+		_ = deque.OfferFront(4)
+
+		var elems []int
+
+		for elem := range deque.Iterator() {
+			elems = append(elems, elem)
+		}
+
+		if !reflect.DeepEqual([]int{4, 5}, elems) {
+			t.Fatalf("expected elements to be %v, got %v", []int{4, 5}, elems)
+		}
+
+		if !deque.IsEmpty() {
+			t.Fatalf("expected deque to be empty, got %v", deque.ToSlice())
+		}
+	})
+
+	t.Run("Contains", func(t *testing.T) {
+		t.Parallel()
+
+		deque := queue.NewDeque([]int{1, 2}, queue.WithCapacity(3))
+
+		_, _ = deque.GetFront()
+		_ = deque.OfferBack(3)
+		_ = deque.OfferBack(4)
+
+		// the tail wrapped around the ring buffer.
+		if !deque.Contains(4) || deque.Contains(1) {
+			t.Fatalf("expected the deque to contain only %v, got %v", []int{2, 3, 4}, deque.ToSlice())
+		}
+	})
+
+	t.Run("RetryAtHead", func(t *testing.T) {
+		t.Parallel()
+
+		const workers, jobs = 4, 1000
+
+		deque := queue.NewDeque[int](nil)
+
+		for i := 0; i < jobs; i++ {
+			_ = deque.Offer(i)
+		}
+
+		var (
+			mu        sync.Mutex
+			processed = make(map[int]bool, jobs)
+			wg        sync.WaitGroup
+		)
+
+		for w := 0; w < workers; w++ {
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+
+				for {
+					elem, err := deque.Get()
+					if err != nil {
+						return
+					}
+
+					mu.Lock()
+					retry := !processed[elem] && elem%3 == 0
+					processed[elem] = !retry
+					mu.Unlock()
+
+					// the failed elements are pushed back to be retried first.
+					if retry {
+						_ = deque.OfferFront(elem + jobs)
+					}
+				}
+			}()
+		}
+
+		wg.Wait()
+
+		for i := 0; i < jobs; i++ {
+			if i%3 == 0 && !processed[i+jobs] || i%3 != 0 && !processed[i] {
+				t.Fatalf("expected element %d to be processed", i)
+			}
+		}
+	})
+}
+
+func BenchmarkDeque(b *testing.B) {
+	b.Run("OfferBack_GetFront", func(b *testing.B) {
+		deque := queue.NewDeque([]int{1})
+
+		b.ReportAllocs()
+		b.ResetTimer()
+
+		for i := 0; i <= b.N; i++ {
+			_ = deque.OfferBack(i)
+
+			_, _ = deque.GetFront()
+		}
+	})
+
+	b.Run("OfferFront_GetBack", func(b *testing.B) {
+		deque := queue.NewDeque([]int{1})
+
+		b.ReportAllocs()
+		b.ResetTimer()
+
+		for i := 0; i <= b.N; i++ {
+			_ = deque.OfferFront(i)
+
+			_, _ = deque.GetBack()
+		}
+	})
+
+	b.Run("Offer", func(b *testing.B) {
+		deque := queue.NewDeque[int](nil)
+
+		b.ReportAllocs()
+		b.ResetTimer()
+
+		for i := 0; i <= b.N; i++ {
+			_ = deque.Offer(i)
+		}
+	})
+
+	b.Run("Peek", func(b *testing.B) {
+		deque := queue.NewDeque([]int{1})
+
+		b.ReportAllocs()
+		b.ResetTimer()
+
+		for i := 0; i <= b.N; i++ {
+			_, _ = deque.Peek()
+		}
+	})
+}
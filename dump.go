@@ -0,0 +1,164 @@
+package queue
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Ensure the queue implementations satisfy the Dumper interface.
+var (
+	_ Dumper = (*Blocking[any])(nil)
+	_ Dumper = (*Circular[any])(nil)
+	_ Dumper = (*Linked[any])(nil)
+	_ Dumper = (*Priority[any])(nil)
+	_ Dumper = (*PriorityAny[any])(nil)
+)
+
+// dumpFileExt is the extension of the files written by WriteDumps.
+const dumpFileExt = ".json"
+
+// Dumper is implemented by the queues that can write a snapshot of their
+// elements. The snapshot is a JSON array, which can be read back into a
+// queue using UnmarshalJSONFrom.
+type Dumper interface {
+	Dump(w io.Writer) error
+}
+
+// NamedDumper pairs a Dumper with the name used for its dump file. If Name
+// is empty, the name of a queue created with the WithName option is used.
+type NamedDumper struct {
+	Name   string
+	Dumper Dumper
+}
+
+// DumpOnPanic returns a function which, when deferred, recovers a panic,
+// writes the dumps of the given queues to dir using WriteDumps and then
+// panics again with the recovered value. It does nothing if there is no
+// panic. It is meant to be deferred directly:
+//
+//	defer queue.DumpOnPanic(dir, os.Stderr, queue.NamedDumper{Name: "jobs", Dumper: jobs})()
+//
+// The errors encountered while writing the dumps are reported to errW, as
+// the panic is propagated regardless. They are dropped if errW is nil.
+func DumpOnPanic(dir string, errW io.Writer, queues ...NamedDumper) func() {
+	return func() {
+		r := recover()
+		if r == nil {
+			return
+		}
+
+		if err := WriteDumps(dir, queues...); err != nil && errW != nil {
+			_, _ = fmt.Fprintf(errW, "queue: write dumps: %v\n", err)
+		}
+
+		panic(r)
+	}
+}
+
+// WriteDumps writes the dump of each queue to its own file in dir, naming it
+// after the queue. Characters which are not safe in file names are replaced
+// and a numeric suffix is added to the names colliding with other queues or
+// with existing files, thus no file is ever overwritten.
+//
+// A failure to dump a queue does not prevent the others from being dumped,
+// all the failures are joined in the returned error.
+func WriteDumps(dir string, queues ...NamedDumper) error {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return fmt.Errorf("create dump directory: %w", err)
+	}
+
+	errs := make([]error, 0, len(queues))
+
+	for _, q := range queues {
+		q.Name = dumperName(q)
+
+		if err := writeDump(dir, q); err != nil {
+			errs = append(errs, fmt.Errorf("dump %q: %w", q.Name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// dumperName returns the name of the queue, falling back to the name it was
+// created with when none is provided.
+func dumperName(q NamedDumper) string {
+	if q.Name != "" {
+		return q.Name
+	}
+
+	if named, ok := q.Dumper.(interface{ Name() string }); ok {
+		return named.Name()
+	}
+
+	return ""
+}
+
+// writeDump writes the dump of the queue to a new file in dir.
+func writeDump(dir string, q NamedDumper) (err error) {
+	if q.Dumper == nil {
+		return errNilDumper
+	}
+
+	f, err := createDumpFile(dir, dumpFileName(q.Name))
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+
+	return q.Dumper.Dump(f)
+}
+
+// createDumpFile creates a new file in dir named after base, adding a
+// numeric suffix if a file with the same name already exists.
+func createDumpFile(dir, base string) (*os.File, error) {
+	for i := 1; ; i++ {
+		name := base
+		if i > 1 {
+			name += "-" + strconv.Itoa(i)
+		}
+
+		f, err := os.OpenFile(
+			filepath.Join(dir, name+dumpFileExt),
+			os.O_WRONLY|os.O_CREATE|os.O_EXCL,
+			0o600,
+		)
+		if errors.Is(err, os.ErrExist) {
+			continue
+		}
+
+		return f, err
+	}
+}
+
+// dumpFileName returns the name, without extension, of the dump file of the
+// queue with the given name.
+func dumpFileName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z',
+			r >= 'A' && r <= 'Z',
+			r >= '0' && r <= '9',
+			r == '-', r == '_':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+
+	if name == "" {
+		return "queue"
+	}
+
+	return name
+}
@@ -0,0 +1,216 @@
+package queue_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/adrianbrad/queue"
+)
+
+func TestWriteDumps(t *testing.T) {
+	t.Parallel()
+
+	t.Run("RoundTrip", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+
+		// a file named after the first queue already exists.
+		if err := os.WriteFile(filepath.Join(dir, "jobs.json"), []byte("keep"), 0o600); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		err := queue.WriteDumps(
+			dir,
+			queue.NamedDumper{Name: "jobs", Dumper: queue.NewBlocking([]int{1, 2})},
+			queue.NamedDumper{Name: "jobs", Dumper: queue.NewLinked([]int{3})},
+			queue.NamedDumper{Name: "a/b", Dumper: queue.NewCircular([]int{4, 5}, 3)},
+			queue.NamedDumper{Name: "", Dumper: queue.NewPriority([]int{7, 6}, func(elem, otherElem int) bool { return elem < otherElem })},
+		)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if data, _ := os.ReadFile(filepath.Join(dir, "jobs.json")); string(data) != "keep" {
+			t.Fatalf("expected existing file to be kept, got %s", data)
+		}
+
+		expected := map[string][]int{
+			"jobs-2.json": {1, 2},
+			"jobs-3.json": {3},
+			"a_b.json":    {4, 5},
+			"queue.json":  {6, 7},
+		}
+
+		for name, elems := range expected {
+			restored := queue.NewBlocking([]int{})
+
+			f, err := os.Open(filepath.Join(dir, name))
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			err = restored.UnmarshalJSONFrom(f)
+
+			_ = f.Close()
+
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if got := restored.Clear(); !reflect.DeepEqual(elems, got) {
+				t.Fatalf("expected %s elements to be %v, got %v", name, elems, got)
+			}
+		}
+	})
+
+	t.Run("QueueName", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+
+		err := queue.WriteDumps(
+			dir,
+			queue.NamedDumper{Dumper: queue.NewLinked([]int{1}, queue.WithName("jobs"))},
+			queue.NamedDumper{Name: "other", Dumper: queue.NewLinked([]int{2}, queue.WithName("jobs"))},
+		)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		for name, expected := range map[string]string{"jobs.json": "[1]", "other.json": "[2]"} {
+			data, err := os.ReadFile(filepath.Join(dir, name))
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if string(data) != expected {
+				t.Fatalf("expected %s to hold %s, got %s", name, expected, data)
+			}
+		}
+	})
+
+	t.Run("PartialFailure", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+
+		err := queue.WriteDumps(
+			dir,
+			queue.NamedDumper{Name: "failing", Dumper: failingDumper{}},
+			queue.NamedDumper{Name: "nil", Dumper: nil},
+			queue.NamedDumper{Name: "ok", Dumper: queue.NewLinked([]int{1})},
+		)
+		if !errors.Is(err, errDump) {
+			t.Fatalf("expected error to be %v, got %v", errDump, err)
+		}
+
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		names := make([]string, 0, len(entries))
+
+		for _, e := range entries {
+			names = append(names, e.Name())
+		}
+
+		sort.Strings(names)
+
+		// the file of the failing dumper is still created.
+		if expected := []string{"failing.json", "ok.json"}; !reflect.DeepEqual(expected, names) {
+			t.Fatalf("expected files to be %v, got %v", expected, names)
+		}
+	})
+}
+
+func TestDumpOnPanic(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Panic", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+
+		blockingQueue := queue.NewBlocking([]int{1, 2})
+
+		recovered := func() (r any) {
+			defer func() { r = recover() }()
+
+			defer queue.DumpOnPanic(dir, io.Discard, queue.NamedDumper{Name: "blocking", Dumper: blockingQueue})()
+
+			panic("boom")
+		}()
+
+		if recovered != "boom" {
+			t.Fatalf("expected panic value to be %v, got %v", "boom", recovered)
+		}
+
+		f, err := os.Open(filepath.Join(dir, "blocking.json"))
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		defer f.Close()
+
+		restored := queue.NewBlocking([]int{})
+
+		if err := restored.UnmarshalJSONFrom(f); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if elems := restored.Clear(); !reflect.DeepEqual([]int{1, 2}, elems) {
+			t.Fatalf("expected elements to be %v, got %v", []int{1, 2}, elems)
+		}
+	})
+
+	t.Run("ReportsErrors", func(t *testing.T) {
+		t.Parallel()
+
+		var errW bytes.Buffer
+
+		recovered := func() (r any) {
+			defer func() { r = recover() }()
+
+			defer queue.DumpOnPanic(t.TempDir(), &errW, queue.NamedDumper{Name: "q", Dumper: failingDumper{}})()
+
+			panic("boom")
+		}()
+
+		if recovered != "boom" {
+			t.Fatalf("expected panic value to be %v, got %v", "boom", recovered)
+		}
+
+		if !strings.Contains(errW.String(), errDump.Error()) {
+			t.Fatalf("expected the dump error to be reported, got %q", errW.String())
+		}
+	})
+
+	t.Run("NoPanic", func(t *testing.T) {
+		t.Parallel()
+
+		dir := filepath.Join(t.TempDir(), "dumps")
+
+		func() {
+			defer queue.DumpOnPanic(dir, io.Discard, queue.NamedDumper{Name: "q", Dumper: queue.NewLinked([]int{1})})()
+		}()
+
+		if _, err := os.Stat(dir); !errors.Is(err, os.ErrNotExist) {
+			t.Fatalf("expected no dumps to be written, got %v", err)
+		}
+	})
+}
+
+var errDump = errors.New("dump failed")
+
+type failingDumper struct{}
+
+func (failingDumper) Dump(io.Writer) error { return errDump }
@@ -0,0 +1,60 @@
+package queue
+
+// ElementHandle identifies an element instance inserted using OfferHandle,
+// allowing it to be cancelled even if the queue holds equal elements.
+//
+// The handle is invalidated once the element leaves the queue, including
+// through Clear, Reset and Rotate, which re-inserts the element as a new
+// instance. The zero ElementHandle identifies no element.
+type ElementHandle struct {
+	target handleTarget
+}
+
+// handleTarget is implemented by the queues issuing handles, for each
+// element instance.
+type handleTarget interface {
+	cancel() bool
+	queued() bool
+}
+
+// Cancel removes the element instance from the queue and returns true if it
+// is still queued, or returns false otherwise. The elements before and after
+// it keep their order.
+func (h ElementHandle) Cancel() bool {
+	return h.target != nil && h.target.cancel()
+}
+
+// Queued returns true if the element instance is still queued.
+func (h ElementHandle) Queued() bool {
+	return h.target != nil && h.target.queued()
+}
+
+// blockingStamp is the handle target of an element of a Blocking queue,
+// identified by its stamp.
+type blockingStamp[T comparable] struct {
+	queue *Blocking[T]
+	stamp uint64
+}
+
+func (s blockingStamp[T]) cancel() bool {
+	return s.queue.cancelStamp(s.stamp)
+}
+
+func (s blockingStamp[T]) queued() bool {
+	return s.queue.stampQueued(s.stamp)
+}
+
+// linkedNode is the handle target of an element of a Linked queue,
+// identified by its node.
+type linkedNode[T comparable] struct {
+	queue *Linked[T]
+	node  *node[T]
+}
+
+func (n linkedNode[T]) cancel() bool {
+	return n.queue.cancelNode(n.node)
+}
+
+func (n linkedNode[T]) queued() bool {
+	return n.queue.nodeQueued(n.node)
+}
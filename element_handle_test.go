@@ -0,0 +1,270 @@
+package queue_test
+
+import (
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/adrianbrad/queue"
+)
+
+// handleQueue is implemented by the queues issuing element handles.
+type handleQueue interface {
+	queue.Queue[int]
+	OfferHandle(elem int) (queue.ElementHandle, error)
+	ToSlice() []int
+}
+
+func newHandleQueues() map[string]func(elems []int) handleQueue {
+	return map[string]func(elems []int) handleQueue{
+		"Blocking": func(elems []int) handleQueue {
+			return queue.NewBlocking(elems, queue.WithCapacity(1000))
+		},
+		"Linked": func(elems []int) handleQueue {
+			return queue.NewLinked(elems)
+		},
+	}
+}
+
+func TestElementHandle(t *testing.T) {
+	t.Parallel()
+
+	t.Run("ZeroHandle", func(t *testing.T) {
+		t.Parallel()
+
+		var handle queue.ElementHandle
+
+		if handle.Cancel() {
+			t.Fatal("expected the zero handle not to cancel any element")
+		}
+
+		if handle.Queued() {
+			t.Fatal("expected the zero handle not to be queued")
+		}
+	})
+
+	for name, newQueue := range newHandleQueues() {
+		newQueue := newQueue
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			t.Run("CancelInstance", func(t *testing.T) {
+				t.Parallel()
+
+				// the handles identify instances among equal elements.
+				testCases := map[string]struct {
+					cancel   int
+					expected []int
+				}{
+					"Head":   {cancel: 0, expected: []int{0, 7, 1, 7}},
+					"Middle": {cancel: 2, expected: []int{7, 0, 1, 7}},
+					"Tail":   {cancel: 4, expected: []int{7, 0, 7, 1}},
+				}
+
+				for caseName, tc := range testCases {
+					tc := tc
+
+					t.Run(caseName, func(t *testing.T) {
+						t.Parallel()
+
+						q := newQueue(nil)
+
+						var handles []queue.ElementHandle
+
+						for _, elem := range []int{7, 0, 7, 1, 7} {
+							handle, err := q.OfferHandle(elem)
+							if err != nil {
+								t.Fatalf("expected no error, got %v", err)
+							}
+
+							handles = append(handles, handle)
+						}
+
+						if !handles[tc.cancel].Cancel() {
+							t.Fatal("expected the element to be cancelled")
+						}
+
+						if elems := q.ToSlice(); !reflect.DeepEqual(tc.expected, elems) {
+							t.Fatalf("expected elements to be %v, got %v", tc.expected, elems)
+						}
+
+						if size := q.Size(); size != 4 {
+							t.Fatalf("expected size to be 4, got %d", size)
+						}
+
+						for i, handle := range handles {
+							if queued := handle.Queued(); queued != (i != tc.cancel) {
+								t.Fatalf("expected handle %d queued to be %t, got %t", i, i != tc.cancel, queued)
+							}
+						}
+
+						// the queue keeps working after the cancellation.
+						if err := q.Offer(2); err != nil {
+							t.Fatalf("expected no error, got %v", err)
+						}
+
+						expected := append(tc.expected, 2)
+
+						if elems := q.Clear(); !reflect.DeepEqual(expected, elems) {
+							t.Fatalf("expected elements to be %v, got %v", expected, elems)
+						}
+					})
+				}
+			})
+
+			t.Run("DoubleCancel", func(t *testing.T) {
+				t.Parallel()
+
+				q := newQueue(nil)
+
+				handle, _ := q.OfferHandle(1)
+				_ = q.Offer(1)
+
+				if !handle.Cancel() {
+					t.Fatal("expected the first cancel to remove the element")
+				}
+
+				if handle.Cancel() {
+					t.Fatal("expected the second cancel not to remove any element")
+				}
+
+				if elems := q.ToSlice(); !reflect.DeepEqual([]int{1}, elems) {
+					t.Fatalf("expected elements to be %v, got %v", []int{1}, elems)
+				}
+			})
+
+			t.Run("Retrieved", func(t *testing.T) {
+				t.Parallel()
+
+				q := newQueue(nil)
+
+				handle, _ := q.OfferHandle(1)
+
+				if _, err := q.Get(); err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+
+				_ = q.Offer(1)
+
+				if handle.Queued() || handle.Cancel() {
+					t.Fatal("expected the retrieved element not to be cancelled")
+				}
+
+				if size := q.Size(); size != 1 {
+					t.Fatalf("expected size to be 1, got %d", size)
+				}
+			})
+
+			t.Run("InvalidatedByClear", func(t *testing.T) {
+				t.Parallel()
+
+				q := newQueue(nil)
+
+				handle, _ := q.OfferHandle(1)
+
+				_ = q.Clear()
+
+				_ = q.Offer(1)
+
+				if handle.Cancel() {
+					t.Fatal("expected the handle to be invalidated by Clear")
+				}
+
+				if size := q.Size(); size != 1 {
+					t.Fatalf("expected size to be 1, got %d", size)
+				}
+			})
+
+			t.Run("InvalidatedByReset", func(t *testing.T) {
+				t.Parallel()
+
+				q := newQueue([]int{1})
+
+				handle, _ := q.OfferHandle(1)
+
+				q.Reset()
+
+				if handle.Cancel() {
+					t.Fatal("expected the handle to be invalidated by Reset")
+				}
+
+				if elems := q.ToSlice(); !reflect.DeepEqual([]int{1}, elems) {
+					t.Fatalf("expected elements to be %v, got %v", []int{1}, elems)
+				}
+			})
+
+			t.Run("ConcurrentConsumers", func(t *testing.T) {
+				t.Parallel()
+
+				const elems = 1000
+
+				q := newQueue(nil)
+
+				handles := make([]queue.ElementHandle, 0, elems)
+
+				var (
+					wg        sync.WaitGroup
+					cancelled atomic.Int64
+					retrieved atomic.Int64
+					delivered = make([]atomic.Int32, elems)
+				)
+
+				for i := 0; i < elems; i++ {
+					handle, err := q.OfferHandle(i)
+					if err != nil {
+						t.Fatalf("expected no error, got %v", err)
+					}
+
+					handles = append(handles, handle)
+				}
+
+				wg.Add(2)
+
+				// the consumer retrieves the elements from the head while
+				// they are cancelled from the tail.
+				go func() {
+					defer wg.Done()
+
+					for {
+						elem, err := q.Get()
+						if err != nil {
+							if int(cancelled.Load()+retrieved.Load()) == elems {
+								return
+							}
+
+							continue
+						}
+
+						delivered[elem].Add(1)
+						retrieved.Add(1)
+					}
+				}()
+
+				go func() {
+					defer wg.Done()
+
+					for i := elems - 1; i >= 0; i-- {
+						if handles[i].Cancel() {
+							delivered[i].Add(1)
+							cancelled.Add(1)
+						}
+					}
+				}()
+
+				wg.Wait()
+
+				for i := range delivered {
+					if n := delivered[i].Load(); n != 1 {
+						t.Fatalf("expected element %d to be retrieved or cancelled once, got %d", i, n)
+					}
+				}
+
+				if !q.IsEmpty() {
+					t.Fatal("expected queue to be empty")
+				}
+			})
+		})
+	}
+}
@@ -12,4 +12,113 @@ var (
 	// ErrQueueIsFull is an error returned whenever the queue is full and there
 	// is an attempt to add an element to it.
 	ErrQueueIsFull = errors.New("queue is full")
+
+	// ErrQueueClosed is an error returned whenever there is an attempt to
+	// add an element to a closed queue, or to extract an element from a
+	// closed and empty queue.
+	ErrQueueClosed = errors.New("queue is closed")
+
+	// ErrQueueDestroyed is an error returned by all the operations of a
+	// queue torn down using Destroy.
+	ErrQueueDestroyed = errors.New("queue is destroyed")
+
+	// ErrResetWhileWaiting is an error returned by OfferWaitErr whenever the
+	// queue is reset using ResetStrict while waiting for capacity.
+	ErrResetWhileWaiting = errors.New("queue was reset while waiting to offer")
+
+	// ErrReservedSentinel is an error returned whenever there is an attempt
+	// to offer the sentinel value of a queue, which can only be inserted
+	// using OfferSentinel.
+	ErrReservedSentinel = errors.New("sentinel value is reserved")
+
+	// ErrCursorInvalidated is an error returned by InspectPage whenever the
+	// queue was mutated since the cursor was issued, the inspection has to
+	// be restarted from the zero Cursor.
+	ErrCursorInvalidated = errors.New("queue was mutated since the cursor was issued")
+
+	// ErrInvalidPartitions is an error returned by IteratorsN whenever the
+	// number of iterators is lower than 1.
+	ErrInvalidPartitions = errors.New("number of partitions must be positive")
+
+	// ErrConsumerRequired is an error returned by the removals from a
+	// Blocking queue created with the WithExclusiveConsumer option, unless
+	// they go through its active consumer lease.
+	ErrConsumerRequired = errors.New("removal requires the active consumer lease")
+
+	// ErrConsumerActive is an error returned by AcquireConsumer whenever
+	// another consumer lease of the queue is active.
+	ErrConsumerActive = errors.New("consumer lease already active")
+
+	// ErrUnsupportedOperation is an error returned by the queue operations
+	// which cannot be implemented by the underlying storage, such as Peek
+	// for a ChanQueue.
+	ErrUnsupportedOperation = errors.New("operation not supported by the queue")
+
+	// ErrInvalidInterval is an error returned by Poll whenever the polling
+	// interval is not positive.
+	ErrInvalidInterval = errors.New("polling interval must be positive")
+
+	// ErrSemaphoreWeight is an error returned by Semaphore.AcquireN whenever
+	// more tokens are requested than the size of the semaphore.
+	ErrSemaphoreWeight = errors.New("weight exceeds the semaphore size")
+
+	// ErrComparatorMismatch is an error returned by the UnmarshalJSONFrom
+	// method of a Priority queue whenever the JSON checkpoint was recorded
+	// with another comparator than the one of the queue.
+	ErrComparatorMismatch = errors.New("checkpoint comparator does not match the queue comparator")
+
+	// ErrComparatorRegistered is an error returned by RegisterLess whenever
+	// a comparator is already registered under the given name.
+	ErrComparatorRegistered = errors.New("comparator already registered")
+
+	// ErrElementsNotConserved is an error returned by LedgerCheck whenever
+	// an element left the queue more than once, or was lost.
+	ErrElementsNotConserved = errors.New("queue elements not conserved")
+
+	// ErrComparatorInconsistent is the error passed to the violation handler
+	// of the Priority queues created with the WithComparatorChecks option
+	// whenever their less function is found to be inconsistent.
+	ErrComparatorInconsistent = errors.New("inconsistent less func")
+
+	// ErrWouldExceedWait is an error wrapped by the ProjectedWaitError
+	// returned by the insertions of a Blocking queue created with the
+	// WithMaxProjectedWait option whenever the element would wait too long.
+	ErrWouldExceedWait = errors.New("element would exceed the maximum wait")
+
+	// ErrIndexOutOfRange is an error returned by At whenever the index is
+	// negative or not lower than the size of the queue.
+	ErrIndexOutOfRange = errors.New("index out of range")
+
+	// ErrInvalidElement is an error wrapped by the InvalidElementError
+	// returned by the insertions of a queue created with the WithValidator
+	// option whenever the element is rejected by the validator.
+	ErrInvalidElement = errors.New("invalid element")
+
+	// ErrDuplicateWithinWindow is an error returned by the insertions of a
+	// queue created with the WithIdempotencyWindow option whenever the key of
+	// the element was admitted within the window.
+	ErrDuplicateWithinWindow = errors.New("duplicate element within the idempotency window")
+
+	// ErrCapacityMismatch is an error returned by the UnmarshalBinary methods
+	// whenever the binary snapshot was recorded with another capacity than
+	// the one of the queue.
+	ErrCapacityMismatch = errors.New("snapshot capacity does not match the queue capacity")
+
+	// ErrInvalidField is an error returned by NewPriorityByField whenever
+	// the field path cannot be resolved on the element type or the field
+	// type is not ordered.
+	ErrInvalidField = errors.New("invalid priority field")
+
+	// ErrLossyJSON is an error returned by the JSON marshalling methods of
+	// the queues created with the WithStrictJSON option, whenever an element
+	// would be encoded as the zero value of its type without being zero,
+	// such as a struct holding only unexported fields.
+	ErrLossyJSON = errors.New("element JSON encoding loses data")
 )
+
+// errInvalidJSONArray is returned when unmarshalling a queue from a JSON
+// value which is not an array.
+var errInvalidJSONArray = errors.New("invalid JSON array")
+
+// errNilDumper is returned when dumping a queue registered without a Dumper.
+var errNilDumper = errors.New("nil dumper")
@@ -0,0 +1,27 @@
+package queue_test
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/adrianbrad/queue"
+)
+
+func ExampleGetWaitAffinity() {
+	jobs := queue.NewBlocking(
+		[]job{{"acme", 1}, {"globex", 1}, {"acme", 2}},
+		queue.WithEnqueueTimes(),
+	)
+
+	tenantOf := func(j job) string { return j.tenant }
+
+	// the consumer warm for globex receives its job ahead of the older ones.
+	fmt.Println("globex:", queue.GetWaitAffinity(jobs, "globex", tenantOf, time.Hour))
+	fmt.Println("acme:", queue.GetWaitAffinity(jobs, "acme", tenantOf, time.Hour))
+	fmt.Println("Elements:", jobs.ToSlice())
+
+	// Output:
+	// globex: {globex 1}
+	// acme: {acme 1}
+	// Elements: [{acme 2}]
+}
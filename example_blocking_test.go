@@ -1,7 +1,13 @@
 package queue_test
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/adrianbrad/queue"
@@ -77,3 +83,1106 @@ func ExampleBlocking() {
 	// Peeked elem: 4
 	// Elem 4 received after 1ms
 }
+
+func ExampleBlocking_AcquireConsumer() {
+	blockingQueue := queue.NewBlocking([]int{1, 2}, queue.WithExclusiveConsumer())
+
+	lease, err := blockingQueue.AcquireConsumer()
+	if err != nil {
+		fmt.Println("AcquireConsumer err:", err)
+		return
+	}
+
+	_, err = blockingQueue.AcquireConsumer()
+	fmt.Println("Second AcquireConsumer:", err)
+
+	_, err = blockingQueue.Get()
+	fmt.Println("Queue Get:", err)
+
+	elem, err := lease.Get()
+	fmt.Println("Lease Get:", elem, err)
+
+	lease.Release()
+
+	lease, _ = blockingQueue.AcquireConsumer()
+	fmt.Println("Clear:", lease.Clear())
+
+	// Output:
+	// Second AcquireConsumer: consumer lease already active
+	// Queue Get: removal requires the active consumer lease
+	// Lease Get: 1 <nil>
+	// Clear: [2]
+}
+
+func ExampleBlocking_All() {
+	blockingQueue := queue.NewBlocking([]int{1, 2, 3})
+
+	blockingQueue.All()(func(elem int) bool {
+		fmt.Println("All:", elem)
+
+		return true
+	})
+
+	fmt.Println("Size:", blockingQueue.Size())
+
+	// Output:
+	// All: 1
+	// All: 2
+	// All: 3
+	// Size: 3
+}
+
+func ExampleBlocking_At() {
+	blockingQueue := queue.NewBlocking([]int{2, 3})
+
+	// the urgent lane is retrieved first.
+	_ = blockingQueue.OfferUrgent(1)
+
+	elem, err := blockingQueue.At(1)
+	fmt.Println("At:", elem, err)
+
+	_, err = blockingQueue.At(3)
+	fmt.Println("Err:", err)
+
+	// Output:
+	// At: 2 <nil>
+	// Err: index out of range
+}
+
+func ExampleBlocking_CanOffer() {
+	blockingQueue := queue.NewBlocking([]int{1, 2}, queue.WithCapacity(3))
+
+	fmt.Println("Can offer 1:", blockingQueue.CanOffer(1))
+	fmt.Println("Can offer 2:", blockingQueue.CanOffer(2))
+
+	// Output:
+	// Can offer 1: true
+	// Can offer 2: false
+}
+
+func ExampleBlocking_Capacity() {
+	blockingQueue := queue.NewBlocking([]int{1}, queue.WithCapacity(3))
+
+	fmt.Println("Capacity:", blockingQueue.Capacity())
+
+	// Output:
+	// Capacity: 3
+}
+
+func ExampleBlocking_Clear() {
+	blockingQueue := queue.NewBlocking([]int{1, 2, 3})
+
+	fmt.Println("Clear:", blockingQueue.Clear())
+	fmt.Println("Size:", blockingQueue.Size())
+
+	// Output:
+	// Clear: [1 2 3]
+	// Size: 0
+}
+
+func ExampleBlocking_ClearIf() {
+	blockingQueue := queue.NewBlocking([]int{1, 2, 3})
+
+	full := func(snapshot queue.ClearSnapshot[int]) bool {
+		return snapshot.Size >= 3
+	}
+
+	elems, cleared := blockingQueue.ClearIf(full)
+	fmt.Println("Cleared:", cleared, elems)
+
+	elems, cleared = blockingQueue.ClearIf(full)
+	fmt.Println("Cleared:", cleared, elems)
+
+	// Output:
+	// Cleared: true [1 2 3]
+	// Cleared: false []
+}
+
+func ExampleBlocking_OverflowSize() {
+	dir, err := os.MkdirTemp("", "overflow")
+	if err != nil {
+		fmt.Println(err)
+
+		return
+	}
+
+	defer os.RemoveAll(dir)
+
+	blockingQueue := queue.NewBlocking(
+		[]int{1},
+		queue.WithCapacity(2),
+		queue.WithDiskOverflow(dir, 1<<20, queue.JournalCodec[int]{}),
+	)
+
+	for _, elem := range []int{2, 3, 4} {
+		_ = blockingQueue.Offer(elem)
+	}
+
+	fmt.Println("Size:", blockingQueue.Size())
+	fmt.Println("OverflowSize:", blockingQueue.OverflowSize())
+
+	_, _ = blockingQueue.Get()
+
+	fmt.Println("Elements:", blockingQueue.ToSlice())
+	fmt.Println("OverflowSize:", blockingQueue.OverflowSize())
+
+	// Output:
+	// Size: 2
+	// OverflowSize: 2
+	// Elements: [2 3]
+	// OverflowSize: 1
+}
+
+func ExampleBlocking_ClearReport() {
+	blockingQueue := queue.NewBlocking([]int{1, 2}, queue.WithCapacity(2))
+
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		blockingQueue.OfferWait(3)
+	}()
+
+	for blockingQueue.BlockedState().BlockedOffers == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	result := blockingQueue.ClearReport()
+	fmt.Println("Elements:", result.Elements)
+	fmt.Println("ReleasedProducers:", result.ReleasedProducers)
+
+	<-done
+
+	fmt.Println("Elements:", blockingQueue.ToSlice())
+
+	// Output:
+	// Elements: [1 2]
+	// ReleasedProducers: 1
+	// Elements: [3]
+}
+
+func ExampleBlocking_ClearUnsafe() {
+	blockingQueue := queue.NewBlocking([]int{1, 2, 3}, queue.WithCapacity(3))
+
+	// the returned slice is owned by the caller.
+	elems := blockingQueue.ClearUnsafe()
+	elems[0] = 10
+
+	_ = blockingQueue.Offer(4)
+
+	fmt.Println("Elements:", elems)
+	fmt.Println("Queue:", blockingQueue.ToSlice())
+
+	// Output:
+	// Elements: [10 2 3]
+	// Queue: [4]
+}
+
+func ExampleBlocking_Close() {
+	blockingQueue := queue.NewBlocking([]int{1})
+
+	blockingQueue.Close()
+
+	fmt.Println("Offer err:", blockingQueue.Offer(2))
+
+	// the elements offered before closing can still be retrieved.
+	fmt.Println("GetWait:", blockingQueue.GetWait())
+
+	_, err := blockingQueue.Get()
+	fmt.Println("Get err:", err)
+
+	// Output:
+	// Offer err: queue is closed
+	// GetWait: 1
+	// Get err: queue is closed
+}
+
+func ExampleBlocking_ConsumeBatches() {
+	blockingQueue := queue.NewBlocking([]int{1, 2, 3, 4, 5})
+
+	blockingQueue.Close()
+
+	err := blockingQueue.ConsumeBatches(
+		context.Background(),
+		1, 2,
+		time.Second,
+		func(batch []int) error {
+			fmt.Println("Batch:", batch)
+			return nil
+		},
+	)
+	fmt.Println("ConsumeBatches err:", err)
+
+	// Output:
+	// Batch: [1 2]
+	// Batch: [3 4]
+	// Batch: [5]
+	// ConsumeBatches err: <nil>
+}
+
+func ExampleBlocking_Contains() {
+	blockingQueue := queue.NewBlocking([]int{1, 2})
+
+	fmt.Println("Contains 2:", blockingQueue.Contains(2))
+	fmt.Println("Contains 3:", blockingQueue.Contains(3))
+
+	// Output:
+	// Contains 2: true
+	// Contains 3: false
+}
+
+func ExampleBlocking_ContainsRecent() {
+	blockingQueue := queue.NewBlocking([]int{1, 2, 3})
+
+	fmt.Println("Recent 3:", blockingQueue.ContainsRecent(1, 3))
+	fmt.Println("Recent 1:", blockingQueue.ContainsRecent(2, 1))
+
+	// Output:
+	// Recent 3: true
+	// Recent 1: false
+}
+
+func ExampleBlocking_ContentionProfile() {
+	blockingQueue := queue.NewBlocking(
+		[]int{},
+		queue.WithContentionProfiling(1),
+	)
+
+	for i := 0; i < 3; i++ {
+		_ = blockingQueue.Offer(i)
+	}
+
+	fmt.Println("Offer samples:", blockingQueue.ContentionProfile()["Offer"].Count)
+
+	// Output:
+	// Offer samples: 3
+}
+
+func ExampleBlocking_Destroy() {
+	blockingQueue := queue.NewBlocking([]int{1, 2})
+
+	fmt.Println("Destroy:", blockingQueue.Destroy())
+	fmt.Println("Offer err:", blockingQueue.Offer(3))
+
+	// Output:
+	// Destroy: [1 2]
+	// Offer err: queue is destroyed
+}
+
+func ExampleBlocking_Drain() {
+	blockingQueue := queue.NewBlocking([]int{1, 2, 3})
+
+	blockingQueue.Drain()(func(elem int) bool {
+		fmt.Println("Drain:", elem)
+
+		return elem < 2
+	})
+
+	fmt.Println("Size:", blockingQueue.Size())
+
+	// Output:
+	// Drain: 1
+	// Drain: 2
+	// Size: 1
+}
+
+func ExampleBlocking_DrainWait() {
+	blockingQueue := queue.NewBlocking([]int{})
+
+	go func() {
+		_ = blockingQueue.OfferAll(1, 2, 3)
+	}()
+
+	// DrainWait waits for the first element and takes the available ones.
+	elems := blockingQueue.DrainWait(2)
+	fmt.Println("DrainWait:", len(elems) > 0, elems[0])
+
+	// Output:
+	// DrainWait: true 1
+}
+
+func ExampleBlocking_Dump() {
+	blockingQueue := queue.NewBlocking([]int{1, 2})
+
+	if err := blockingQueue.Dump(os.Stdout); err != nil {
+		fmt.Println("Dump err:", err)
+	}
+
+	// Output:
+	// [1,2]
+}
+
+func ExampleBlocking_Each() {
+	blockingQueue := queue.NewBlocking([]int{1, 2, 3})
+
+	blockingQueue.Each(func(elem int) bool {
+		fmt.Println("Each:", elem)
+
+		return elem < 2
+	})
+
+	fmt.Println("Size:", blockingQueue.Size())
+
+	// Output:
+	// Each: 1
+	// Each: 2
+	// Size: 3
+}
+
+func ExampleBlocking_Exchange() {
+	blockingQueue := queue.NewBlocking([]int{1, 2})
+
+	elem, err := blockingQueue.Exchange(3)
+	fmt.Println("Exchange:", elem, err)
+	fmt.Println("Elements:", blockingQueue.ToSlice())
+
+	// Output:
+	// Exchange: 1 <nil>
+	// Elements: [2 3]
+}
+
+func ExampleBlocking_Get() {
+	blockingQueue := queue.NewBlocking([]int{1})
+
+	elem, err := blockingQueue.Get()
+	fmt.Println("Get:", elem, err)
+
+	_, err = blockingQueue.Get()
+	fmt.Println("Get err:", err)
+
+	// Output:
+	// Get: 1 <nil>
+	// Get err: no elements available in the queue
+}
+
+func ExampleBlocking_GetCtx() {
+	blockingQueue := queue.NewBlocking([]int{1})
+
+	elem, err := blockingQueue.GetCtx(context.Background())
+	fmt.Println("GetCtx:", elem, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+
+	// GetCtx stops waiting once ctx is done.
+	_, err = blockingQueue.GetCtx(ctx)
+	fmt.Println("GetCtx err:", err)
+
+	// Output:
+	// GetCtx: 1 <nil>
+	// GetCtx err: context deadline exceeded
+}
+
+func ExampleBlocking_GetN() {
+	blockingQueue := queue.NewBlocking([]int{1, 2, 3})
+
+	fmt.Println("GetN:", blockingQueue.GetN(2))
+	fmt.Println("Size:", blockingQueue.Size())
+
+	// Output:
+	// GetN: [1 2]
+	// Size: 1
+}
+
+func ExampleBlocking_GetWait() {
+	blockingQueue := queue.NewBlocking([]int{})
+
+	go func() {
+		_ = blockingQueue.Offer(1)
+	}()
+
+	// GetWait waits for the element to be offered.
+	fmt.Println("GetWait:", blockingQueue.GetWait())
+
+	// Output:
+	// GetWait: 1
+}
+
+func ExampleBlocking_InspectPage() {
+	blockingQueue := queue.NewBlocking([]int{1, 2, 3, 4, 5})
+
+	var cursor queue.Cursor
+
+	for !cursor.Done() {
+		var (
+			page []int
+			err  error
+		)
+
+		page, cursor, err = blockingQueue.InspectPage(cursor, 2)
+		if err != nil {
+			fmt.Println("InspectPage err:", err)
+			return
+		}
+
+		fmt.Println("Page:", page)
+	}
+
+	// Output:
+	// Page: [1 2]
+	// Page: [3 4]
+	// Page: [5]
+}
+
+func ExampleBlocking_IsEmpty() {
+	blockingQueue := queue.NewBlocking([]int{1})
+
+	fmt.Println("Empty:", blockingQueue.IsEmpty())
+
+	_, _ = blockingQueue.Get()
+
+	fmt.Println("Empty:", blockingQueue.IsEmpty())
+
+	// Output:
+	// Empty: false
+	// Empty: true
+}
+
+func ExampleBlocking_Iterator() {
+	blockingQueue := queue.NewBlocking([]int{1, 2, 3})
+
+	for elem := range blockingQueue.Iterator() {
+		fmt.Println("Elem:", elem)
+	}
+
+	// Output:
+	// Elem: 1
+	// Elem: 2
+	// Elem: 3
+}
+
+func ExampleBlocking_IteratorsN() {
+	blockingQueue := queue.NewBlocking([]int{1, 2, 3, 4, 5})
+
+	iterators, err := blockingQueue.IteratorsN(2, queue.RoundRobin)
+	if err != nil {
+		fmt.Println("IteratorsN err:", err)
+		return
+	}
+
+	for i, iterator := range iterators {
+		for elem := range iterator {
+			fmt.Println("Iterator", i, "elem:", elem)
+		}
+	}
+
+	// Output:
+	// Iterator 0 elem: 1
+	// Iterator 0 elem: 3
+	// Iterator 0 elem: 5
+	// Iterator 1 elem: 2
+	// Iterator 1 elem: 4
+}
+
+func ExampleBlocking_LedgerCheck() {
+	blockingQueue := queue.NewBlocking(
+		[]int{1, 2},
+		queue.WithConservationChecks(),
+	)
+
+	_ = blockingQueue.Offer(3)
+	_, _ = blockingQueue.Get()
+	blockingQueue.Reset()
+	_ = blockingQueue.Clear()
+
+	fmt.Println("LedgerCheck:", blockingQueue.LedgerCheck())
+
+	// Output:
+	// LedgerCheck: <nil>
+}
+
+func ExampleBlocking_Live() {
+	blockingQueue := queue.NewBlocking([]int{1, 2})
+
+	blockingQueue.Close()
+
+	// the channel is closed once the closed queue is drained.
+	for elem := range blockingQueue.Live(context.Background()) {
+		fmt.Println("Elem:", elem)
+	}
+
+	// Output:
+	// Elem: 1
+	// Elem: 2
+}
+
+func ExampleBlocking_MarshalJSON() {
+	blockingQueue := queue.NewBlocking([]int{1, 2})
+
+	data, err := blockingQueue.MarshalJSON()
+	fmt.Println("MarshalJSON:", string(data), err)
+
+	// Output:
+	// MarshalJSON: [1,2] <nil>
+}
+
+func ExampleBlocking_MarshalJSONTo() {
+	blockingQueue := queue.NewBlocking([]int{1, 2})
+
+	var buf bytes.Buffer
+
+	err := blockingQueue.MarshalJSONTo(&buf)
+	fmt.Println("MarshalJSONTo:", buf.String(), err)
+
+	// Output:
+	// MarshalJSONTo: [1,2] <nil>
+}
+
+func ExampleBlocking_MemoryFootprint() {
+	blockingQueue := queue.NewBlocking([]int{}, queue.WithCapacity(8))
+
+	_ = blockingQueue.OfferAll(1, 2, 3, 4)
+
+	_, _ = blockingQueue.Get()
+
+	report := blockingQueue.MemoryFootprint(nil)
+
+	fmt.Println("Elements:", report.Elements)
+	fmt.Println("Slots:", report.Slots)
+	fmt.Println("WastedSlots:", report.WastedSlots)
+
+	// Output:
+	// Elements: 3
+	// Slots: 8
+	// WastedSlots: 5
+}
+
+func ExampleBlocking_Name() {
+	blockingQueue := queue.NewBlocking([]int{}, queue.WithName("jobs"))
+
+	fmt.Println("Name:", blockingQueue.Name())
+
+	_, err := blockingQueue.Get()
+
+	var queueErr *queue.QueueError
+	if errors.As(err, &queueErr) {
+		fmt.Println("QueueError:", queueErr.Name, queueErr.Queue)
+	}
+
+	fmt.Println("Err:", err)
+
+	// Output:
+	// Name: jobs
+	// QueueError: jobs Blocking
+	// Err: Blocking queue "jobs": no elements available in the queue
+}
+
+func ExampleBlocking_Offer() {
+	blockingQueue := queue.NewBlocking([]int{1}, queue.WithCapacity(2))
+
+	fmt.Println("Offer:", blockingQueue.Offer(2))
+	fmt.Println("Offer err:", blockingQueue.Offer(3))
+
+	// Output:
+	// Offer: <nil>
+	// Offer err: queue is full
+}
+
+func ExampleBlocking_OfferAll() {
+	blockingQueue := queue.NewBlocking([]int{}, queue.WithCapacity(3))
+
+	fmt.Println("OfferAll:", blockingQueue.OfferAll(1, 2))
+
+	// the elements are inserted all or none.
+	fmt.Println("OfferAll err:", blockingQueue.OfferAll(3, 4))
+	fmt.Println("Elements:", blockingQueue.ToSlice())
+
+	// Output:
+	// OfferAll: <nil>
+	// OfferAll err: queue is full
+	// Elements: [1 2]
+}
+
+func ExampleBlocking_OfferAllWait() {
+	blockingQueue := queue.NewBlocking([]int{}, queue.WithCapacity(2))
+
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		for i := 0; i < 4; i++ {
+			fmt.Println("GetWait:", blockingQueue.GetWait())
+		}
+	}()
+
+	// waits for the consumer to make room for the last 2 elements.
+	n, err := blockingQueue.OfferAllWait(1, 2, 3, 4)
+
+	<-done
+
+	fmt.Println("OfferAllWait:", n, err)
+
+	// Output:
+	// GetWait: 1
+	// GetWait: 2
+	// GetWait: 3
+	// GetWait: 4
+	// OfferAllWait: 4 <nil>
+}
+
+func ExampleBlocking_OfferHandle() {
+	blockingQueue := queue.NewBlocking([]int{})
+
+	_ = blockingQueue.Offer(1)
+
+	handle, err := blockingQueue.OfferHandle(1)
+	if err != nil {
+		fmt.Println("OfferHandle err:", err)
+		return
+	}
+
+	_ = blockingQueue.Offer(1)
+
+	fmt.Println("Cancel:", handle.Cancel())
+	fmt.Println("Queued:", handle.Queued())
+	fmt.Println("Size:", blockingQueue.Size())
+
+	// Output:
+	// Cancel: true
+	// Queued: false
+	// Size: 2
+}
+
+func ExampleBlocking_OfferSentinel() {
+	blockingQueue := queue.NewBlocking([]int{1}, queue.WithSentinel(-1))
+
+	if err := blockingQueue.OfferSentinel(2); err != nil {
+		fmt.Println("OfferSentinel err:", err)
+		return
+	}
+
+	for i := 0; i < 3; i++ {
+		fmt.Println("GetWait:", blockingQueue.GetWait())
+	}
+
+	// Output:
+	// GetWait: 1
+	// GetWait: -1
+	// GetWait: -1
+}
+
+func ExampleBlocking_OfferSome() {
+	blockingQueue := queue.NewBlocking([]int{1}, queue.WithCapacity(3))
+
+	n, err := blockingQueue.OfferSome(2, 3, 4)
+
+	fmt.Println("OfferSome:", n, err)
+	fmt.Println("Elements:", blockingQueue.Clear())
+
+	// Output:
+	// OfferSome: 2 queue is full
+	// Elements: [1 2 3]
+}
+
+func ExampleBlocking_OfferUrgent() {
+	blockingQueue := queue.NewBlocking([]int{1, 2})
+
+	if err := blockingQueue.OfferUrgent(3); err != nil {
+		fmt.Println("OfferUrgent err:", err)
+		return
+	}
+
+	fmt.Println("Elements:", blockingQueue.ToSlice())
+
+	// Output:
+	// Elements: [3 1 2]
+}
+
+func ExampleBlocking_OfferWait() {
+	blockingQueue := queue.NewBlocking([]int{1}, queue.WithCapacity(1))
+
+	done := make(chan struct{})
+
+	// OfferWait waits for the queue to have room for the element.
+	go func() {
+		defer close(done)
+
+		blockingQueue.OfferWait(2)
+	}()
+
+	fmt.Println("GetWait:", blockingQueue.GetWait())
+
+	<-done
+
+	fmt.Println("Elements:", blockingQueue.ToSlice())
+
+	// Output:
+	// GetWait: 1
+	// Elements: [2]
+}
+
+func ExampleBlocking_OfferWaitErr() {
+	blockingQueue := queue.NewBlocking([]int{1}, queue.WithCapacity(1))
+
+	done := make(chan struct{})
+
+	// OfferWaitErr returns the error once the queue is closed.
+	go func() {
+		defer close(done)
+
+		fmt.Println("OfferWaitErr err:", blockingQueue.OfferWaitErr(2))
+	}()
+
+	// wait for the producer to wait for room in the queue.
+	time.Sleep(10 * time.Millisecond)
+
+	blockingQueue.Close()
+
+	<-done
+
+	// Output:
+	// OfferWaitErr err: queue is closed
+}
+
+func ExampleBlocking_Peek() {
+	blockingQueue := queue.NewBlocking([]int{1, 2})
+
+	elem, err := blockingQueue.Peek()
+	fmt.Println("Peek:", elem, err)
+	fmt.Println("Size:", blockingQueue.Size())
+
+	// Output:
+	// Peek: 1 <nil>
+	// Size: 2
+}
+
+func ExampleBlocking_PeekN() {
+	blockingQueue := queue.NewBlocking([]int{1, 2, 3})
+
+	fmt.Println("PeekN:", blockingQueue.PeekN(2))
+
+	// Output:
+	// PeekN: [1 2]
+}
+
+func ExampleBlocking_PeekWait() {
+	blockingQueue := queue.NewBlocking([]int{})
+
+	go func() {
+		_ = blockingQueue.Offer(1)
+	}()
+
+	// PeekWait waits for the element without removing it.
+	fmt.Println("PeekWait:", blockingQueue.PeekWait())
+	fmt.Println("Size:", blockingQueue.Size())
+
+	// Output:
+	// PeekWait: 1
+	// Size: 1
+}
+
+func ExampleBlocking_Poll() {
+	blockingQueue := queue.NewBlocking([]int{1})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+
+	elem, err := blockingQueue.Poll(ctx, time.Millisecond)
+	fmt.Println("Poll:", elem, err)
+
+	_, err = blockingQueue.Poll(ctx, time.Millisecond)
+	fmt.Println("Poll err:", err)
+
+	// Output:
+	// Poll: 1 <nil>
+	// Poll err: context deadline exceeded
+}
+
+func ExampleBlocking_RecentOperations() {
+	blockingQueue := queue.NewBlocking(
+		[]int{1},
+		queue.WithCallerTracking(1),
+		queue.WithOperationSummary(strconv.Itoa),
+	)
+
+	_ = blockingQueue.Offer(2)
+	_, _ = blockingQueue.Get()
+	_ = blockingQueue.Clear()
+
+	for _, op := range blockingQueue.RecentOperations() {
+		fmt.Printf("%s(%s)\n", op.Op, op.Element)
+	}
+
+	// Output:
+	// Offer(2)
+	// Get(1)
+	// Clear()
+}
+
+func ExampleBlocking_Remaining() {
+	blockingQueue := queue.NewBlocking([]int{1}, queue.WithCapacity(3))
+
+	fmt.Println("Remaining:", blockingQueue.Remaining())
+
+	// Output:
+	// Remaining: 2
+}
+
+func ExampleBlocking_Reset() {
+	blockingQueue := queue.NewBlocking([]int{1, 2})
+
+	_, _ = blockingQueue.Get()
+	_ = blockingQueue.Offer(3)
+
+	blockingQueue.Reset()
+
+	fmt.Println("Elements:", blockingQueue.ToSlice())
+
+	// Output:
+	// Elements: [1 2]
+}
+
+func ExampleBlocking_ResetStrict() {
+	blockingQueue := queue.NewBlocking([]int{1}, queue.WithCapacity(1))
+
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		fmt.Println("OfferWaitErr err:", blockingQueue.OfferWaitErr(2))
+	}()
+
+	// wait for the producer to wait for room in the queue.
+	time.Sleep(10 * time.Millisecond)
+
+	blockingQueue.ResetStrict()
+
+	<-done
+
+	fmt.Println("Elements:", blockingQueue.ToSlice())
+
+	// Output:
+	// OfferWaitErr err: queue was reset while waiting to offer
+	// Elements: [1]
+}
+
+func ExampleBlocking_Rotate() {
+	blockingQueue := queue.NewBlocking([]int{1, 2, 3})
+
+	if err := blockingQueue.Rotate(); err != nil {
+		fmt.Println("Rotate err:", err)
+		return
+	}
+
+	fmt.Println("Elements:", blockingQueue.ToSlice())
+
+	// Output:
+	// Elements: [2 3 1]
+}
+
+func ExampleBlocking_Size() {
+	blockingQueue := queue.NewBlocking([]int{1, 2})
+
+	fmt.Println("Size:", blockingQueue.Size())
+
+	// Output:
+	// Size: 2
+}
+
+func ExampleBlocking_SyncJournal() {
+	var journal bytes.Buffer
+
+	blockingQueue := queue.NewBlocking([]int{1}, queue.WithJournal(&journal, queue.JournalCodec[int]{}))
+
+	_ = blockingQueue.Offer(2)
+	_, _ = blockingQueue.Get()
+
+	// the records are written by a separate goroutine.
+	blockingQueue.SyncJournal()
+
+	replayed, err := queue.Replay(&journal, queue.JournalCodec[int]{}, func() queue.Queue[int] {
+		return queue.NewBlocking([]int{1})
+	})
+
+	fmt.Println("Replay:", err)
+	fmt.Println("Elements:", replayed.(*queue.Blocking[int]).ToSlice())
+
+	// Output:
+	// Replay: <nil>
+	// Elements: [2]
+}
+
+func ExampleBlocking_ToSlice() {
+	blockingQueue := queue.NewBlocking([]int{1, 2})
+
+	fmt.Println("ToSlice:", blockingQueue.ToSlice())
+	fmt.Println("Size:", blockingQueue.Size())
+
+	// Output:
+	// ToSlice: [1 2]
+	// Size: 2
+}
+
+func ExampleBlocking_TryGet() {
+	blockingQueue := queue.NewBlocking([]int{1})
+
+	elem, acquired, err := blockingQueue.TryGet()
+	fmt.Println("TryGet:", elem, acquired, err)
+
+	// Output:
+	// TryGet: 1 true <nil>
+}
+
+func ExampleBlocking_TryOffer() {
+	blockingQueue := queue.NewBlocking([]int{})
+
+	acquired, err := blockingQueue.TryOffer(1)
+	fmt.Println("TryOffer:", acquired, err)
+
+	// Output:
+	// TryOffer: true <nil>
+}
+
+func ExampleBlocking_TryPeek() {
+	blockingQueue := queue.NewBlocking([]int{1})
+
+	elem, acquired, err := blockingQueue.TryPeek()
+	fmt.Println("TryPeek:", elem, acquired, err)
+
+	// Output:
+	// TryPeek: 1 true <nil>
+}
+
+func ExampleBlocking_UnmarshalJSON() {
+	blockingQueue := queue.NewBlocking([]int{}, queue.WithCapacity(4))
+
+	if err := blockingQueue.UnmarshalJSON([]byte("[1,2]")); err != nil {
+		fmt.Println("UnmarshalJSON err:", err)
+		return
+	}
+
+	fmt.Println("Elements:", blockingQueue.ToSlice())
+
+	// Output:
+	// Elements: [1 2]
+}
+
+func ExampleBlocking_UnmarshalJSONFrom() {
+	blockingQueue := queue.NewBlocking([]int{}, queue.WithCapacity(4))
+
+	if err := blockingQueue.UnmarshalJSONFrom(strings.NewReader("[1,2]")); err != nil {
+		fmt.Println("UnmarshalJSONFrom err:", err)
+		return
+	}
+
+	fmt.Println("Elements:", blockingQueue.ToSlice())
+
+	// Output:
+	// Elements: [1 2]
+}
+
+func ExampleBlocking_MarshalBinary() {
+	blockingQueue := queue.NewBlocking([]int{1, 2}, queue.WithCapacity(4))
+
+	if err := blockingQueue.OfferUrgent(3); err != nil {
+		fmt.Println("OfferUrgent err:", err)
+		return
+	}
+
+	data, err := blockingQueue.MarshalBinary()
+	if err != nil {
+		fmt.Println("MarshalBinary err:", err)
+		return
+	}
+
+	restored := queue.NewBlocking([]int{}, queue.WithCapacity(4))
+
+	fmt.Println("UnmarshalBinary:", restored.UnmarshalBinary(data))
+	fmt.Println("Elements:", restored.ToSlice())
+
+	// Output:
+	// UnmarshalBinary: <nil>
+	// Elements: [3 1 2]
+}
+
+func ExampleBlocking_UnmarshalBinary() {
+	data, err := queue.NewBlocking([]int{1, 2}, queue.WithCapacity(4)).MarshalBinary()
+	if err != nil {
+		fmt.Println("MarshalBinary err:", err)
+		return
+	}
+
+	blockingQueue := queue.NewBlocking([]int{5}, queue.WithCapacity(4))
+
+	fmt.Println("UnmarshalBinary:", blockingQueue.UnmarshalBinary(data))
+	fmt.Println("Elements:", blockingQueue.ToSlice())
+
+	err = queue.NewBlocking([]int{}, queue.WithCapacity(2)).UnmarshalBinary(data)
+	fmt.Println("Capacity mismatch:", errors.Is(err, queue.ErrCapacityMismatch))
+
+	// Output:
+	// UnmarshalBinary: <nil>
+	// Elements: [1 2]
+	// Capacity mismatch: true
+}
+
+func ExampleBlocking_GetWaitCtx() {
+	type traceKey struct{}
+
+	blockingQueue := queue.NewBlocking(
+		[]int{},
+		queue.WithContextPropagation(
+			func(ctx context.Context) any {
+				return ctx.Value(traceKey{})
+			},
+			func(ctx context.Context, value any) context.Context {
+				return context.WithValue(ctx, traceKey{}, value)
+			},
+		),
+	)
+
+	producerCtx := context.WithValue(context.Background(), traceKey{}, "trace-1")
+
+	go func() {
+		_ = blockingQueue.OfferCtx(producerCtx, 1)
+	}()
+
+	elem, ctx, err := blockingQueue.GetWaitCtx(context.Background())
+	fmt.Println("GetWaitCtx:", elem, ctx.Value(traceKey{}), err)
+
+	// Output:
+	// GetWaitCtx: 1 trace-1 <nil>
+}
+
+func ExampleBlocking_OfferCtx() {
+	blockingQueue := queue.NewBlocking([]int{1}, queue.WithCapacity(1))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+
+	// OfferCtx waits for room in the queue until ctx is done.
+	fmt.Println("OfferCtx err:", blockingQueue.OfferCtx(ctx, 2))
+	fmt.Println("Elements:", blockingQueue.ToSlice())
+
+	// Output:
+	// OfferCtx err: context deadline exceeded
+	// Elements: [1]
+}
+
+func ExampleBlocking_BlockedState() {
+	blockingQueue := queue.NewBlocking([]int{1}, queue.WithCapacity(1))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() {
+		_ = blockingQueue.OfferCtx(ctx, 2)
+	}()
+
+	for blockingQueue.BlockedState().BlockedOffers == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	state := blockingQueue.BlockedState()
+	fmt.Println("BlockedOffers:", state.BlockedOffers)
+	fmt.Println("BlockedGets:", state.BlockedGets)
+	fmt.Println("Size:", state.Size, "Capacity:", state.Capacity)
+
+	// Output:
+	// BlockedOffers: 1
+	// BlockedGets: 0
+	// Size: 1 Capacity: 1
+}
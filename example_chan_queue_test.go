@@ -0,0 +1,240 @@
+package queue_test
+
+import (
+	"fmt"
+
+	"github.com/adrianbrad/queue"
+)
+
+func ExampleChanQueue() {
+	ch := make(chan int, 2)
+	ch <- 1
+
+	chanQueue := queue.NewFromChannel(ch)
+
+	if err := chanQueue.Offer(2); err != nil {
+		fmt.Println("Offer err:", err)
+		return
+	}
+
+	fmt.Println("Size:", chanQueue.Size())
+
+	// the elements can be received directly from the channel.
+	fmt.Println("Received:", <-ch)
+
+	elem, err := chanQueue.Get()
+	fmt.Println("Get:", elem, err)
+
+	// Output:
+	// Size: 2
+	// Received: 1
+	// Get: 2 <nil>
+}
+
+func ExampleChanQueue_Capacity() {
+	chanQueue := queue.NewFromChannel(make(chan int, 3))
+
+	fmt.Println("Capacity:", chanQueue.Capacity())
+
+	// Output:
+	// Capacity: 3
+}
+
+func ExampleChanQueue_Clear() {
+	chanQueue := queue.NewFromChannel(make(chan int, 3))
+
+	_ = chanQueue.Offer(1)
+	_ = chanQueue.Offer(2)
+
+	fmt.Println("Clear:", chanQueue.Clear())
+	fmt.Println("Size:", chanQueue.Size())
+
+	// Output:
+	// Clear: [1 2]
+	// Size: 0
+}
+
+func ExampleChanQueue_Contains() {
+	chanQueue := queue.NewFromChannel(make(chan int, 3))
+
+	_ = chanQueue.Offer(1)
+	_ = chanQueue.Offer(2)
+
+	fmt.Println("Contains 2:", chanQueue.Contains(2))
+	fmt.Println("Contains 3:", chanQueue.Contains(3))
+	fmt.Println("Size:", chanQueue.Size())
+
+	// Output:
+	// Contains 2: true
+	// Contains 3: false
+	// Size: 2
+}
+
+func ExampleChanQueue_Get() {
+	chanQueue := queue.NewFromChannel(make(chan int, 1))
+
+	_ = chanQueue.Offer(1)
+
+	elem, err := chanQueue.Get()
+	fmt.Println("Get:", elem, err)
+
+	_, err = chanQueue.Get()
+	fmt.Println("Get err:", err)
+
+	// Output:
+	// Get: 1 <nil>
+	// Get err: no elements available in the queue
+}
+
+func ExampleChanQueue_GetWait() {
+	chanQueue := queue.NewFromChannel(make(chan int))
+
+	go func() {
+		chanQueue.OfferWait(1)
+	}()
+
+	fmt.Println("GetWait:", chanQueue.GetWait())
+
+	// Output:
+	// GetWait: 1
+}
+
+func ExampleChanQueue_IsEmpty() {
+	chanQueue := queue.NewFromChannel(make(chan int, 1))
+
+	fmt.Println("Empty:", chanQueue.IsEmpty())
+
+	_ = chanQueue.Offer(1)
+
+	fmt.Println("Empty:", chanQueue.IsEmpty())
+
+	// Output:
+	// Empty: true
+	// Empty: false
+}
+
+func ExampleChanQueue_Iterator() {
+	chanQueue := queue.NewFromChannel(make(chan int, 2))
+
+	_ = chanQueue.Offer(1)
+	_ = chanQueue.Offer(2)
+
+	for elem := range chanQueue.Iterator() {
+		fmt.Println("Elem:", elem)
+	}
+
+	// Output:
+	// Elem: 1
+	// Elem: 2
+}
+
+func ExampleChanQueue_MemoryFootprint() {
+	chanQueue := queue.NewFromChannel(make(chan int, 4))
+
+	_ = chanQueue.Offer(1)
+
+	report := chanQueue.MemoryFootprint(nil)
+
+	fmt.Println("Elements:", report.Elements)
+	fmt.Println("Slots:", report.Slots)
+	fmt.Println("WastedSlots:", report.WastedSlots)
+
+	// Output:
+	// Elements: 1
+	// Slots: 4
+	// WastedSlots: 3
+}
+
+func ExampleChanQueue_Offer() {
+	chanQueue := queue.NewFromChannel(make(chan int, 1))
+
+	fmt.Println("Offer:", chanQueue.Offer(1))
+	fmt.Println("Offer err:", chanQueue.Offer(2))
+
+	// Output:
+	// Offer: <nil>
+	// Offer err: queue is full
+}
+
+func ExampleChanQueue_OfferWait() {
+	chanQueue := queue.NewFromChannel(make(chan int, 1))
+
+	_ = chanQueue.Offer(1)
+
+	done := make(chan struct{})
+
+	// OfferWait waits for the channel buffer to have room for the element.
+	go func() {
+		defer close(done)
+
+		chanQueue.OfferWait(2)
+	}()
+
+	fmt.Println("GetWait:", chanQueue.GetWait())
+
+	<-done
+
+	fmt.Println("GetWait:", chanQueue.GetWait())
+
+	// Output:
+	// GetWait: 1
+	// GetWait: 2
+}
+
+func ExampleChanQueue_OfferWaitErr() {
+	chanQueue := queue.NewFromChannel(make(chan int, 1))
+
+	fmt.Println("OfferWaitErr:", chanQueue.OfferWaitErr(1))
+	fmt.Println("GetWait:", chanQueue.GetWait())
+
+	// Output:
+	// OfferWaitErr: <nil>
+	// GetWait: 1
+}
+
+func ExampleChanQueue_Peek() {
+	chanQueue := queue.NewFromChannel(make(chan int, 1))
+
+	_ = chanQueue.Offer(1)
+
+	_, err := chanQueue.Peek()
+	fmt.Println("Peek err:", err)
+
+	// Output:
+	// Peek err: operation not supported by the queue
+}
+
+func ExampleChanQueue_Remaining() {
+	chanQueue := queue.NewFromChannel(make(chan int, 3))
+
+	_ = chanQueue.Offer(1)
+
+	fmt.Println("Remaining:", chanQueue.Remaining())
+
+	// Output:
+	// Remaining: 2
+}
+
+func ExampleChanQueue_Reset() {
+	chanQueue := queue.NewFromChannel(make(chan int, 2))
+
+	_ = chanQueue.Offer(1)
+
+	chanQueue.Reset()
+
+	fmt.Println("Size:", chanQueue.Size())
+
+	// Output:
+	// Size: 0
+}
+
+func ExampleChanQueue_Size() {
+	chanQueue := queue.NewFromChannel(make(chan int, 2))
+
+	_ = chanQueue.Offer(1)
+
+	fmt.Println("Size:", chanQueue.Size())
+
+	// Output:
+	// Size: 1
+}
@@ -1,7 +1,15 @@
 package queue_test
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"expvar"
 	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/adrianbrad/queue"
 )
@@ -77,3 +85,677 @@ func ExampleCircular() {
 	// Offered 7
 	// Get: 7
 }
+
+func ExampleCircular_All() {
+	circularQueue := queue.NewCircular([]int{1, 2, 3}, 4)
+
+	circularQueue.All()(func(elem int) bool {
+		fmt.Println("All:", elem)
+
+		return true
+	})
+
+	fmt.Println("Size:", circularQueue.Size())
+
+	// Output:
+	// All: 1
+	// All: 2
+	// All: 3
+	// Size: 3
+}
+
+func ExampleCircular_At() {
+	circularQueue := queue.NewCircular([]int{1, 2, 3}, 3)
+
+	// the ring wraps around: 4 is stored at the start of the slice.
+	_, _ = circularQueue.Get()
+	_ = circularQueue.Offer(4)
+
+	elem, err := circularQueue.At(2)
+	fmt.Println("At:", elem, err)
+
+	_, err = circularQueue.At(3)
+	fmt.Println("Err:", err)
+
+	// Output:
+	// At: 4 <nil>
+	// Err: index out of range
+}
+
+func ExampleCircular_CanOffer() {
+	circularQueue := queue.NewCircular([]int{1, 2}, 2)
+
+	// the oldest elements are overwritten once the queue is full.
+	fmt.Println("Can offer:", circularQueue.CanOffer(1))
+
+	// Output:
+	// Can offer: true
+}
+
+func ExampleCircular_Capacity() {
+	circularQueue := queue.NewCircular([]int{1}, 3)
+
+	fmt.Println("Capacity:", circularQueue.Capacity())
+
+	// Output:
+	// Capacity: 3
+}
+
+func ExampleCircular_Clear() {
+	circularQueue := queue.NewCircular([]int{1, 2, 3}, 4)
+
+	fmt.Println("Clear:", circularQueue.Clear())
+	fmt.Println("Size:", circularQueue.Size())
+
+	// Output:
+	// Clear: [1 2 3]
+	// Size: 0
+}
+
+func ExampleCircular_ClearIf() {
+	circularQueue := queue.NewCircular([]int{1, 2}, 4)
+
+	full := func(snapshot queue.ClearSnapshot[int]) bool {
+		return snapshot.Size == snapshot.Capacity
+	}
+
+	elems, cleared := circularQueue.ClearIf(full)
+	fmt.Println("Cleared:", cleared, elems)
+
+	_ = circularQueue.OfferAll(3, 4)
+
+	elems, cleared = circularQueue.ClearIf(full)
+	fmt.Println("Cleared:", cleared, elems)
+
+	// Output:
+	// Cleared: false []
+	// Cleared: true [1 2 3 4]
+}
+
+func ExampleCircular_Contains() {
+	circularQueue := queue.NewCircular([]int{1, 2}, 4)
+
+	fmt.Println("Contains 2:", circularQueue.Contains(2))
+	fmt.Println("Contains 3:", circularQueue.Contains(3))
+
+	// Output:
+	// Contains 2: true
+	// Contains 3: false
+}
+
+func ExampleCircular_ContainsRecent() {
+	circularQueue := queue.NewCircular([]int{1, 2, 3}, 4)
+
+	fmt.Println("Recent 3:", circularQueue.ContainsRecent(1, 3))
+	fmt.Println("Recent 1:", circularQueue.ContainsRecent(2, 1))
+
+	// Output:
+	// Recent 3: true
+	// Recent 1: false
+}
+
+func ExampleCircular_ContentionProfile() {
+	circularQueue := queue.NewCircular(
+		[]int{},
+		4,
+		queue.WithContentionProfiling(1),
+	)
+
+	for i := 0; i < 3; i++ {
+		_ = circularQueue.Offer(i)
+	}
+
+	fmt.Println("Offer samples:", circularQueue.ContentionProfile()["Offer"].Count)
+
+	// Output:
+	// Offer samples: 3
+}
+
+func ExampleCircular_Drain() {
+	circularQueue := queue.NewCircular([]int{1, 2, 3}, 4)
+
+	circularQueue.Drain()(func(elem int) bool {
+		fmt.Println("Drain:", elem)
+
+		return elem < 2
+	})
+
+	fmt.Println("Size:", circularQueue.Size())
+
+	// Output:
+	// Drain: 1
+	// Drain: 2
+	// Size: 1
+}
+
+func ExampleCircular_Dump() {
+	circularQueue := queue.NewCircular([]int{1, 2}, 4)
+
+	if err := circularQueue.Dump(os.Stdout); err != nil {
+		fmt.Println("Dump err:", err)
+	}
+
+	// Output:
+	// [1,2]
+}
+
+func ExampleCircular_Each() {
+	circularQueue := queue.NewCircular([]int{1, 2, 3}, 4)
+
+	circularQueue.Each(func(elem int) bool {
+		fmt.Println("Each:", elem)
+
+		return elem < 2
+	})
+
+	fmt.Println("Size:", circularQueue.Size())
+
+	// Output:
+	// Each: 1
+	// Each: 2
+	// Size: 3
+}
+
+func ExampleCircular_Exchange() {
+	circularQueue := queue.NewCircular([]int{1, 2}, 4)
+
+	elem, err := circularQueue.Exchange(3)
+	fmt.Println("Exchange:", elem, err)
+	fmt.Println("Elements:", circularQueue.ToSlice())
+
+	// Output:
+	// Exchange: 1 <nil>
+	// Elements: [2 3]
+}
+
+func ExampleCircular_Get() {
+	circularQueue := queue.NewCircular([]int{1}, 4)
+
+	elem, err := circularQueue.Get()
+	fmt.Println("Get:", elem, err)
+
+	_, err = circularQueue.Get()
+	fmt.Println("Get err:", err)
+
+	// Output:
+	// Get: 1 <nil>
+	// Get err: no elements available in the queue
+}
+
+func ExampleCircular_GetN() {
+	circularQueue := queue.NewCircular([]int{1, 2, 3}, 4)
+
+	fmt.Println("GetN:", circularQueue.GetN(2))
+	fmt.Println("Size:", circularQueue.Size())
+
+	// Output:
+	// GetN: [1 2]
+	// Size: 1
+}
+
+func ExampleCircular_InspectPage() {
+	circularQueue := queue.NewCircular([]int{1, 2, 3, 4, 5}, 5)
+
+	var cursor queue.Cursor
+
+	for !cursor.Done() {
+		var (
+			page []int
+			err  error
+		)
+
+		page, cursor, err = circularQueue.InspectPage(cursor, 2)
+		if err != nil {
+			fmt.Println("InspectPage err:", err)
+			return
+		}
+
+		fmt.Println("Page:", page)
+	}
+
+	// Output:
+	// Page: [1 2]
+	// Page: [3 4]
+	// Page: [5]
+}
+
+func ExampleCircular_IsEmpty() {
+	circularQueue := queue.NewCircular([]int{1}, 4)
+
+	fmt.Println("Empty:", circularQueue.IsEmpty())
+
+	_, _ = circularQueue.Get()
+
+	fmt.Println("Empty:", circularQueue.IsEmpty())
+
+	// Output:
+	// Empty: false
+	// Empty: true
+}
+
+func ExampleCircular_Iterator() {
+	circularQueue := queue.NewCircular([]int{1, 2, 3}, 4)
+
+	for elem := range circularQueue.Iterator() {
+		fmt.Println("Elem:", elem)
+	}
+
+	// Output:
+	// Elem: 1
+	// Elem: 2
+	// Elem: 3
+}
+
+func ExampleCircular_IteratorsN() {
+	circularQueue := queue.NewCircular([]int{1, 2, 3, 4, 5}, 5)
+
+	iterators, err := circularQueue.IteratorsN(2, queue.Contiguous)
+	if err != nil {
+		fmt.Println("IteratorsN err:", err)
+		return
+	}
+
+	for i, iterator := range iterators {
+		for elem := range iterator {
+			fmt.Println("Iterator", i, "elem:", elem)
+		}
+	}
+
+	// Output:
+	// Iterator 0 elem: 1
+	// Iterator 0 elem: 2
+	// Iterator 0 elem: 3
+	// Iterator 1 elem: 4
+	// Iterator 1 elem: 5
+}
+
+func ExampleCircular_MarshalJSON() {
+	circularQueue := queue.NewCircular([]int{1, 2}, 4)
+
+	data, err := circularQueue.MarshalJSON()
+	fmt.Println("MarshalJSON:", string(data), err)
+
+	// Output:
+	// MarshalJSON: [1,2] <nil>
+}
+
+func ExampleCircular_MarshalJSONTo() {
+	circularQueue := queue.NewCircular([]int{1, 2}, 4)
+
+	var buf bytes.Buffer
+
+	err := circularQueue.MarshalJSONTo(&buf)
+	fmt.Println("MarshalJSONTo:", buf.String(), err)
+
+	// Output:
+	// MarshalJSONTo: [1,2] <nil>
+}
+
+func ExampleCircular_MemoryFootprint() {
+	circularQueue := queue.NewCircular([]int{}, 1000)
+
+	for i := 0; i < 10; i++ {
+		_ = circularQueue.Offer(i)
+	}
+
+	// publish the report as expvar gauges, served on /debug/vars.
+	gauges, ok := expvar.Get("circular_queue_memory").(*expvar.Map)
+	if !ok {
+		gauges = expvar.NewMap("circular_queue_memory")
+	}
+
+	report := circularQueue.MemoryFootprint(nil)
+
+	gauges.Set("total_bytes", expvarInt(int64(report.TotalBytes())))
+	gauges.Set("wasted_slots", expvarInt(int64(report.WastedSlots)))
+
+	fmt.Println("wasted_slots:", gauges.Get("wasted_slots"))
+
+	// Output:
+	// wasted_slots: 990
+}
+
+// expvarInt returns an expvar.Int holding the given value.
+func expvarInt(value int64) *expvar.Int {
+	v := new(expvar.Int)
+	v.Set(value)
+
+	return v
+}
+
+func ExampleCircular_Name() {
+	circularQueue := queue.NewCircular([]int{}, 2, queue.WithName("jobs"))
+
+	fmt.Println("Name:", circularQueue.Name())
+
+	_, err := circularQueue.Get()
+
+	var queueErr *queue.QueueError
+	if errors.As(err, &queueErr) {
+		fmt.Println("QueueError:", queueErr.Name, queueErr.Queue)
+	}
+
+	fmt.Println("Err:", err)
+
+	// Output:
+	// Name: jobs
+	// QueueError: jobs Circular
+	// Err: Circular queue "jobs": no elements available in the queue
+}
+
+func ExampleCircular_Offer() {
+	circularQueue := queue.NewCircular([]int{1, 2}, 2)
+
+	// the oldest element, at index 0, is overwritten.
+	fmt.Println("Offer:", circularQueue.Offer(3))
+	fmt.Println("Elements:", circularQueue.ToSlice())
+
+	// Output:
+	// Offer: <nil>
+	// Elements: [3 2]
+}
+
+func ExampleCircular_OfferAll() {
+	circularQueue := queue.NewCircular([]int{1}, 4)
+
+	fmt.Println("OfferAll:", circularQueue.OfferAll(2, 3))
+	fmt.Println("Elements:", circularQueue.ToSlice())
+
+	// Output:
+	// OfferAll: <nil>
+	// Elements: [1 2 3]
+}
+
+func ExampleCircular_OfferSome() {
+	circularQueue := queue.NewCircular([]int{}, 2)
+
+	n, err := circularQueue.OfferSome(1, 2, 3)
+
+	fmt.Println("OfferSome:", n, err)
+	fmt.Println("Size:", circularQueue.Size())
+
+	// Output:
+	// OfferSome: 3 <nil>
+	// Size: 2
+}
+
+func ExampleCircular_OfferWithEvicted() {
+	circularQueue := queue.NewCircular([]int{1}, 2)
+
+	evicted, wasEvicted, err := circularQueue.OfferWithEvicted(2)
+
+	fmt.Println("OfferWithEvicted:", evicted, wasEvicted, err)
+
+	// the oldest element, at index 0, is overwritten.
+	evicted, wasEvicted, err = circularQueue.OfferWithEvicted(3)
+
+	fmt.Println("OfferWithEvicted:", evicted, wasEvicted, err)
+	fmt.Println("Elements:", circularQueue.ToSlice())
+
+	// Output:
+	// OfferWithEvicted: 0 false <nil>
+	// OfferWithEvicted: 1 true <nil>
+	// Elements: [3 2]
+}
+
+func ExampleCircular_Peek() {
+	circularQueue := queue.NewCircular([]int{1, 2}, 4)
+
+	elem, err := circularQueue.Peek()
+	fmt.Println("Peek:", elem, err)
+	fmt.Println("Size:", circularQueue.Size())
+
+	// Output:
+	// Peek: 1 <nil>
+	// Size: 2
+}
+
+func ExampleCircular_PeekN() {
+	circularQueue := queue.NewCircular([]int{1, 2, 3}, 4)
+
+	fmt.Println("PeekN:", circularQueue.PeekN(2))
+
+	// Output:
+	// PeekN: [1 2]
+}
+
+func ExampleCircular_Poll() {
+	circularQueue := queue.NewCircular([]int{}, 4)
+
+	go func() {
+		_ = circularQueue.Offer(1)
+	}()
+
+	// Poll retries until the element is offered.
+	elem, err := circularQueue.Poll(context.Background(), time.Millisecond)
+	fmt.Println("Poll:", elem, err)
+
+	// Output:
+	// Poll: 1 <nil>
+}
+
+func ExampleCircular_RecentOperations() {
+	circularQueue := queue.NewCircular(
+		[]int{1},
+		2,
+		queue.WithCallerTracking(1),
+		queue.WithOperationSummary(strconv.Itoa),
+	)
+
+	_ = circularQueue.Offer(2)
+	_, _ = circularQueue.Get()
+	_ = circularQueue.Clear()
+
+	for _, op := range circularQueue.RecentOperations() {
+		fmt.Printf("%s(%s)\n", op.Op, op.Element)
+	}
+
+	// Output:
+	// Offer(2)
+	// Get(1)
+	// Clear()
+}
+
+func ExampleCircular_Remaining() {
+	circularQueue := queue.NewCircular([]int{1}, 3)
+
+	fmt.Println("Remaining:", circularQueue.Remaining())
+
+	// Output:
+	// Remaining: 2
+}
+
+func ExampleCircular_Reset() {
+	circularQueue := queue.NewCircular([]int{1, 2}, 4)
+
+	_, _ = circularQueue.Get()
+	_ = circularQueue.Offer(3)
+
+	circularQueue.Reset()
+
+	fmt.Println("Elements:", circularQueue.ToSlice())
+
+	// Output:
+	// Elements: [1 2]
+}
+
+func ExampleCircular_Rotate() {
+	circularQueue := queue.NewCircular([]int{1, 2, 3}, 3)
+
+	if err := circularQueue.Rotate(); err != nil {
+		fmt.Println("Rotate err:", err)
+		return
+	}
+
+	fmt.Println("Elements:", circularQueue.ToSlice())
+
+	// Output:
+	// Elements: [2 3 1]
+}
+
+func ExampleCircular_Size() {
+	circularQueue := queue.NewCircular([]int{1, 2}, 4)
+
+	fmt.Println("Size:", circularQueue.Size())
+
+	// Output:
+	// Size: 2
+}
+
+func ExampleCircular_SyncJournal() {
+	var journal bytes.Buffer
+
+	circularQueue := queue.NewCircular([]int{1}, 2, queue.WithJournal(&journal, queue.JournalCodec[int]{}))
+
+	// the third element overwrites the oldest one.
+	_ = circularQueue.Offer(2)
+	_ = circularQueue.Offer(3)
+
+	// the records are written by a separate goroutine.
+	circularQueue.SyncJournal()
+
+	replayed, err := queue.Replay(&journal, queue.JournalCodec[int]{}, func() queue.Queue[int] {
+		return queue.NewCircular([]int{1}, 2)
+	})
+
+	fmt.Println("Replay:", err)
+	fmt.Println("Size:", replayed.Size())
+
+	// Output:
+	// Replay: <nil>
+	// Size: 2
+}
+
+func ExampleCircular_ToSlice() {
+	circularQueue := queue.NewCircular([]int{1, 2}, 4)
+
+	fmt.Println("ToSlice:", circularQueue.ToSlice())
+	fmt.Println("Size:", circularQueue.Size())
+
+	// Output:
+	// ToSlice: [1 2]
+	// Size: 2
+}
+
+func ExampleCircular_TryGet() {
+	circularQueue := queue.NewCircular([]int{1}, 4)
+
+	elem, acquired, err := circularQueue.TryGet()
+	fmt.Println("TryGet:", elem, acquired, err)
+
+	// Output:
+	// TryGet: 1 true <nil>
+}
+
+func ExampleCircular_TryOffer() {
+	circularQueue := queue.NewCircular([]int{}, 4)
+
+	acquired, err := circularQueue.TryOffer(1)
+	fmt.Println("TryOffer:", acquired, err)
+
+	// Output:
+	// TryOffer: true <nil>
+}
+
+func ExampleCircular_TryPeek() {
+	circularQueue := queue.NewCircular([]int{1}, 4)
+
+	elem, acquired, err := circularQueue.TryPeek()
+	fmt.Println("TryPeek:", elem, acquired, err)
+
+	// Output:
+	// TryPeek: 1 true <nil>
+}
+
+func ExampleCircular_UnmarshalJSON() {
+	circularQueue := queue.NewCircular([]int{}, 4)
+
+	if err := circularQueue.UnmarshalJSON([]byte("[1,2]")); err != nil {
+		fmt.Println("UnmarshalJSON err:", err)
+		return
+	}
+
+	fmt.Println("Elements:", circularQueue.ToSlice())
+
+	// Output:
+	// Elements: [1 2]
+}
+
+func ExampleCircular_UnmarshalJSONFrom() {
+	circularQueue := queue.NewCircular([]int{}, 4)
+
+	if err := circularQueue.UnmarshalJSONFrom(strings.NewReader("[1,2]")); err != nil {
+		fmt.Println("UnmarshalJSONFrom err:", err)
+		return
+	}
+
+	fmt.Println("Elements:", circularQueue.ToSlice())
+
+	// Output:
+	// Elements: [1 2]
+}
+
+func ExampleCircular_MarshalBinary() {
+	circularQueue := queue.NewCircular([]int{1, 2, 3}, 3)
+
+	if err := circularQueue.Offer(4); err != nil {
+		fmt.Println("Offer err:", err)
+		return
+	}
+
+	data, err := circularQueue.MarshalBinary()
+	if err != nil {
+		fmt.Println("MarshalBinary err:", err)
+		return
+	}
+
+	restored := queue.NewCircular([]int{}, 3)
+
+	fmt.Println("UnmarshalBinary:", restored.UnmarshalBinary(data))
+	fmt.Println("Elements:", restored.ToSlice())
+
+	// Output:
+	// UnmarshalBinary: <nil>
+	// Elements: [4 2 3]
+}
+
+func ExampleCircular_UnmarshalBinary() {
+	data, err := queue.NewCircular([]int{1, 2, 3}, 3).MarshalBinary()
+	if err != nil {
+		fmt.Println("MarshalBinary err:", err)
+		return
+	}
+
+	circularQueue := queue.NewCircular([]int{}, 3)
+
+	fmt.Println("UnmarshalBinary:", circularQueue.UnmarshalBinary(data))
+
+	// the restored queue is full, so the next insertion overwrites its head.
+	if err := circularQueue.Offer(4); err != nil {
+		fmt.Println("Offer err:", err)
+		return
+	}
+
+	fmt.Println("Elements:", circularQueue.ToSlice())
+
+	// Output:
+	// UnmarshalBinary: <nil>
+	// Elements: [4 2 3]
+}
+
+func ExampleSearchCircular() {
+	// the timestamps of the samples, ordered by time.
+	samples := queue.NewCircular([]int{10, 20, 30}, 3)
+
+	_, _ = samples.Get()
+	_ = samples.Offer(40)
+
+	// the index of the first sample not older than 25.
+	i := queue.SearchCircular(samples, func(ts int) bool { return ts >= 25 })
+
+	elem, _ := samples.At(i)
+	fmt.Println("SearchCircular:", i, elem)
+
+	// Output:
+	// SearchCircular: 1 30
+}
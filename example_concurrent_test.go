@@ -0,0 +1,188 @@
+package queue_test
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/adrianbrad/queue"
+)
+
+func ExampleConcurrent() {
+	concurrentQueue := queue.NewConcurrent([]int{}, queue.WithCapacity(64))
+
+	var producers sync.WaitGroup
+
+	for p := 0; p < 4; p++ {
+		producers.Add(1)
+
+		go func(p int) {
+			defer producers.Done()
+
+			for i := 0; i < 10; i++ {
+				_ = concurrentQueue.Offer(p*10 + i)
+			}
+		}(p)
+	}
+
+	producers.Wait()
+
+	sum := 0
+
+	for {
+		elem, err := concurrentQueue.Get()
+		if err != nil {
+			break
+		}
+
+		sum += elem
+	}
+
+	fmt.Println("Sum:", sum)
+
+	// Output:
+	// Sum: 780
+}
+
+func ExampleConcurrent_Capacity() {
+	concurrentQueue := queue.NewConcurrent([]int{1}, queue.WithCapacity(3))
+
+	fmt.Println("Capacity:", concurrentQueue.Capacity())
+
+	// Output:
+	// Capacity: 3
+}
+
+func ExampleConcurrent_Clear() {
+	concurrentQueue := queue.NewConcurrent([]int{1, 2}, queue.WithCapacity(3))
+
+	fmt.Println("Clear:", concurrentQueue.Clear())
+	fmt.Println("Size:", concurrentQueue.Size())
+
+	// Output:
+	// Clear: [1 2]
+	// Size: 0
+}
+
+func ExampleConcurrent_Contains() {
+	concurrentQueue := queue.NewConcurrent([]int{1, 2}, queue.WithCapacity(3))
+
+	fmt.Println("Contains 2:", concurrentQueue.Contains(2))
+	fmt.Println("Contains 3:", concurrentQueue.Contains(3))
+
+	// Output:
+	// Contains 2: true
+	// Contains 3: false
+}
+
+func ExampleConcurrent_Get() {
+	concurrentQueue := queue.NewConcurrent([]int{1}, queue.WithCapacity(3))
+
+	elem, err := concurrentQueue.Get()
+	fmt.Println("Get:", elem, err)
+
+	_, err = concurrentQueue.Get()
+	fmt.Println("Get:", err)
+
+	// Output:
+	// Get: 1 <nil>
+	// Get: no elements available in the queue
+}
+
+func ExampleConcurrent_IsEmpty() {
+	concurrentQueue := queue.NewConcurrent([]int{}, queue.WithCapacity(3))
+
+	fmt.Println("IsEmpty:", concurrentQueue.IsEmpty())
+
+	_ = concurrentQueue.Offer(1)
+
+	fmt.Println("IsEmpty:", concurrentQueue.IsEmpty())
+
+	// Output:
+	// IsEmpty: true
+	// IsEmpty: false
+}
+
+func ExampleConcurrent_Iterator() {
+	concurrentQueue := queue.NewConcurrent([]int{1, 2, 3}, queue.WithCapacity(3))
+
+	for elem := range concurrentQueue.Iterator() {
+		fmt.Println("Elem:", elem)
+	}
+
+	fmt.Println("Size:", concurrentQueue.Size())
+
+	// Output:
+	// Elem: 1
+	// Elem: 2
+	// Elem: 3
+	// Size: 0
+}
+
+func ExampleConcurrent_Offer() {
+	concurrentQueue := queue.NewConcurrent([]int{1}, queue.WithCapacity(2))
+
+	fmt.Println("Offer:", concurrentQueue.Offer(2))
+	fmt.Println("Offer:", concurrentQueue.Offer(3))
+
+	// Output:
+	// Offer: <nil>
+	// Offer: queue is full
+}
+
+func ExampleConcurrent_Peek() {
+	concurrentQueue := queue.NewConcurrent([]int{1, 2}, queue.WithCapacity(3))
+
+	elem, err := concurrentQueue.Peek()
+	fmt.Println("Peek:", elem, err)
+	fmt.Println("Size:", concurrentQueue.Size())
+
+	// Output:
+	// Peek: 1 <nil>
+	// Size: 2
+}
+
+func ExampleConcurrent_Remaining() {
+	concurrentQueue := queue.NewConcurrent([]int{1}, queue.WithCapacity(3))
+
+	fmt.Println("Remaining:", concurrentQueue.Remaining())
+
+	// Output:
+	// Remaining: 2
+}
+
+func ExampleConcurrent_Reset() {
+	concurrentQueue := queue.NewConcurrent([]int{1, 2}, queue.WithCapacity(3))
+
+	_, _ = concurrentQueue.Get()
+	_ = concurrentQueue.Offer(3)
+
+	concurrentQueue.Reset()
+
+	fmt.Println("Clear:", concurrentQueue.Clear())
+
+	// Output:
+	// Clear: [1 2]
+}
+
+func ExampleConcurrent_Size() {
+	concurrentQueue := queue.NewConcurrent([]int{1, 2}, queue.WithCapacity(3))
+
+	fmt.Println("Size:", concurrentQueue.Size())
+
+	// Output:
+	// Size: 2
+}
+
+func ExampleConcurrent_ToSlice() {
+	concurrentQueue := queue.NewConcurrent([]int{1, 2, 3}, queue.WithCapacity(3))
+
+	_, _ = concurrentQueue.Get()
+	_ = concurrentQueue.Offer(4)
+
+	fmt.Println("ToSlice:", concurrentQueue.ToSlice())
+	fmt.Println("Size:", concurrentQueue.Size())
+
+	// Output:
+	// ToSlice: [2 3 4]
+	// Size: 3
+}
@@ -0,0 +1,238 @@
+package queue_test
+
+import (
+	"fmt"
+
+	"github.com/adrianbrad/queue"
+)
+
+func ExampleDeque() {
+	deque := queue.NewDeque([]int{1, 2, 3})
+
+	elem, err := deque.Get()
+	fmt.Println("Get:", elem, err)
+
+	// the element failed to be processed, it is retried first.
+	if err := deque.OfferFront(elem); err != nil {
+		fmt.Println("OfferFront err:", err)
+		return
+	}
+
+	fmt.Println("Elements:", deque.ToSlice())
+
+	// Output:
+	// Get: 1 <nil>
+	// Elements: [1 2 3]
+}
+
+func ExampleDeque_Capacity() {
+	deque := queue.NewDeque([]int{}, queue.WithCapacity(3))
+
+	fmt.Println("Capacity:", deque.Capacity())
+
+	// Output:
+	// Capacity: 3
+}
+
+func ExampleDeque_Clear() {
+	deque := queue.NewDeque([]int{1, 2})
+
+	fmt.Println("Clear:", deque.Clear())
+	fmt.Println("Size:", deque.Size())
+
+	// Output:
+	// Clear: [1 2]
+	// Size: 0
+}
+
+func ExampleDeque_Contains() {
+	deque := queue.NewDeque([]int{1, 2})
+
+	fmt.Println("Contains 2:", deque.Contains(2))
+	fmt.Println("Contains 3:", deque.Contains(3))
+
+	// Output:
+	// Contains 2: true
+	// Contains 3: false
+}
+
+func ExampleDeque_Get() {
+	deque := queue.NewDeque([]int{1, 2})
+
+	elem, err := deque.Get()
+	fmt.Println("Get:", elem, err)
+
+	// Output:
+	// Get: 1 <nil>
+}
+
+func ExampleDeque_GetBack() {
+	deque := queue.NewDeque([]int{1, 2})
+
+	elem, err := deque.GetBack()
+	fmt.Println("GetBack:", elem, err)
+	fmt.Println("Elements:", deque.ToSlice())
+
+	// Output:
+	// GetBack: 2 <nil>
+	// Elements: [1]
+}
+
+func ExampleDeque_GetFront() {
+	deque := queue.NewDeque([]int{1, 2})
+
+	elem, err := deque.GetFront()
+	fmt.Println("GetFront:", elem, err)
+	fmt.Println("Elements:", deque.ToSlice())
+
+	// Output:
+	// GetFront: 1 <nil>
+	// Elements: [2]
+}
+
+func ExampleDeque_IsEmpty() {
+	deque := queue.NewDeque([]int{})
+
+	fmt.Println("IsEmpty:", deque.IsEmpty())
+
+	_ = deque.Offer(1)
+
+	fmt.Println("IsEmpty:", deque.IsEmpty())
+
+	// Output:
+	// IsEmpty: true
+	// IsEmpty: false
+}
+
+func ExampleDeque_Iterator() {
+	deque := queue.NewDeque([]int{2, 3})
+
+	_ = deque.OfferFront(1)
+
+	for elem := range deque.Iterator() {
+		fmt.Println("Iterating:", elem)
+	}
+
+	fmt.Println("Size:", deque.Size())
+
+	// Output:
+	// Iterating: 1
+	// Iterating: 2
+	// Iterating: 3
+	// Size: 0
+}
+
+func ExampleDeque_Offer() {
+	deque := queue.NewDeque([]int{1}, queue.WithCapacity(2))
+
+	fmt.Println("Offer:", deque.Offer(2))
+	fmt.Println("Offer:", deque.Offer(3))
+	fmt.Println("Elements:", deque.ToSlice())
+
+	// Output:
+	// Offer: <nil>
+	// Offer: queue is full
+	// Elements: [1 2]
+}
+
+func ExampleDeque_OfferBack() {
+	deque := queue.NewDeque([]int{1})
+
+	fmt.Println("OfferBack:", deque.OfferBack(2))
+	fmt.Println("Elements:", deque.ToSlice())
+
+	// Output:
+	// OfferBack: <nil>
+	// Elements: [1 2]
+}
+
+func ExampleDeque_OfferFront() {
+	deque := queue.NewDeque([]int{1})
+
+	fmt.Println("OfferFront:", deque.OfferFront(0))
+	fmt.Println("Elements:", deque.ToSlice())
+
+	// Output:
+	// OfferFront: <nil>
+	// Elements: [0 1]
+}
+
+func ExampleDeque_Peek() {
+	deque := queue.NewDeque([]int{1, 2})
+
+	elem, err := deque.Peek()
+	fmt.Println("Peek:", elem, err)
+
+	// Output:
+	// Peek: 1 <nil>
+}
+
+func ExampleDeque_PeekBack() {
+	deque := queue.NewDeque([]int{1, 2})
+
+	elem, err := deque.PeekBack()
+	fmt.Println("PeekBack:", elem, err)
+	fmt.Println("Size:", deque.Size())
+
+	// Output:
+	// PeekBack: 2 <nil>
+	// Size: 2
+}
+
+func ExampleDeque_PeekFront() {
+	deque := queue.NewDeque([]int{1, 2})
+
+	elem, err := deque.PeekFront()
+	fmt.Println("PeekFront:", elem, err)
+	fmt.Println("Size:", deque.Size())
+
+	// Output:
+	// PeekFront: 1 <nil>
+	// Size: 2
+}
+
+func ExampleDeque_Remaining() {
+	deque := queue.NewDeque([]int{1}, queue.WithCapacity(3))
+
+	fmt.Println("Remaining:", deque.Remaining())
+
+	// Output:
+	// Remaining: 2
+}
+
+func ExampleDeque_Reset() {
+	deque := queue.NewDeque([]int{1, 2})
+
+	_ = deque.OfferFront(0)
+	_, _ = deque.GetBack()
+
+	deque.Reset()
+
+	fmt.Println("Elements:", deque.ToSlice())
+
+	// Output:
+	// Elements: [1 2]
+}
+
+func ExampleDeque_Size() {
+	deque := queue.NewDeque([]int{1, 2})
+
+	_ = deque.OfferFront(0)
+
+	fmt.Println("Size:", deque.Size())
+
+	// Output:
+	// Size: 3
+}
+
+func ExampleDeque_ToSlice() {
+	deque := queue.NewDeque([]int{1, 2})
+
+	_ = deque.OfferFront(0)
+	_ = deque.OfferBack(3)
+
+	fmt.Println("Elements:", deque.ToSlice())
+
+	// Output:
+	// Elements: [0 1 2 3]
+}
@@ -0,0 +1,48 @@
+package queue_test
+
+import (
+	"fmt"
+
+	"github.com/adrianbrad/queue"
+)
+
+type job struct {
+	tenant   string
+	priority int
+}
+
+func ExampleGroupBy() {
+	jobs := queue.NewPriority(
+		[]job{{"acme", 3}, {"globex", 1}, {"acme", 1}, {"globex", 2}},
+		func(elem, otherElem job) bool { return elem.priority < otherElem.priority },
+	)
+
+	groups := queue.GroupBy[job](jobs, func(j job) string { return j.tenant })
+
+	fmt.Println("acme:", groups["acme"])
+	fmt.Println("globex:", groups["globex"])
+	fmt.Println("Size:", jobs.Size())
+
+	// Output:
+	// acme: [{acme 1} {acme 3}]
+	// globex: [{globex 1} {globex 2}]
+	// Size: 4
+}
+
+func ExampleGroupByStream() {
+	jobs := queue.NewLinked([]job{{"acme", 3}, {"globex", 1}, {"acme", 1}})
+
+	err := queue.GroupByStream[job](jobs, func(j job) string { return j.tenant }, func(tenant string, j job) error {
+		fmt.Println(tenant, j.priority)
+
+		return nil
+	})
+
+	fmt.Println("GroupByStream:", err)
+
+	// Output:
+	// acme 3
+	// acme 1
+	// globex 1
+	// GroupByStream: <nil>
+}
@@ -0,0 +1,150 @@
+package queue_test
+
+import (
+	"fmt"
+
+	"github.com/adrianbrad/queue"
+)
+
+func ExampleHandle() {
+	handle := queue.NewHandle[int](queue.NewLinked([]int{1, 2}))
+
+	// the elements are migrated to the bounded queue.
+	if _, err := handle.Swap(queue.NewBlocking([]int{}, queue.WithCapacity(3)), true); err != nil {
+		fmt.Println("Swap err:", err)
+		return
+	}
+
+	_ = handle.Offer(3)
+
+	fmt.Println("Offer err:", handle.Offer(4))
+	fmt.Println("Clear:", handle.Clear())
+
+	// Output:
+	// Offer err: queue is full
+	// Clear: [1 2 3]
+}
+
+func ExampleHandle_Clear() {
+	handle := queue.NewHandle[int](queue.NewLinked([]int{1, 2}))
+
+	fmt.Println("Clear:", handle.Clear())
+	fmt.Println("Size:", handle.Size())
+
+	// Output:
+	// Clear: [1 2]
+	// Size: 0
+}
+
+func ExampleHandle_Contains() {
+	handle := queue.NewHandle[int](queue.NewLinked([]int{1, 2}))
+
+	fmt.Println("Contains 2:", handle.Contains(2))
+	fmt.Println("Contains 3:", handle.Contains(3))
+
+	// Output:
+	// Contains 2: true
+	// Contains 3: false
+}
+
+func ExampleHandle_Get() {
+	handle := queue.NewHandle[int](queue.NewLinked([]int{1}))
+
+	elem, err := handle.Get()
+	fmt.Println("Get:", elem, err)
+
+	// Output:
+	// Get: 1 <nil>
+}
+
+func ExampleHandle_Inner() {
+	linkedQueue := queue.NewLinked([]int{1})
+
+	handle := queue.NewHandle[int](linkedQueue)
+
+	fmt.Println("Inner is the linked queue:", handle.Inner() == queue.Queue[int](linkedQueue))
+
+	// Output:
+	// Inner is the linked queue: true
+}
+
+func ExampleHandle_IsEmpty() {
+	handle := queue.NewHandle[int](queue.NewLinked([]int{}))
+
+	fmt.Println("Empty:", handle.IsEmpty())
+
+	// Output:
+	// Empty: true
+}
+
+func ExampleHandle_Iterator() {
+	handle := queue.NewHandle[int](queue.NewLinked([]int{1, 2}))
+
+	for elem := range handle.Iterator() {
+		fmt.Println("Elem:", elem)
+	}
+
+	// Output:
+	// Elem: 1
+	// Elem: 2
+}
+
+func ExampleHandle_Offer() {
+	handle := queue.NewHandle[int](queue.NewLinked([]int{1}))
+
+	fmt.Println("Offer:", handle.Offer(2))
+	fmt.Println("Size:", handle.Size())
+
+	// Output:
+	// Offer: <nil>
+	// Size: 2
+}
+
+func ExampleHandle_Peek() {
+	handle := queue.NewHandle[int](queue.NewLinked([]int{1, 2}))
+
+	elem, err := handle.Peek()
+	fmt.Println("Peek:", elem, err)
+
+	// Output:
+	// Peek: 1 <nil>
+}
+
+func ExampleHandle_Reset() {
+	handle := queue.NewHandle[int](queue.NewLinked([]int{1}))
+
+	_ = handle.Offer(2)
+
+	handle.Reset()
+
+	fmt.Println("Size:", handle.Size())
+
+	// Output:
+	// Size: 1
+}
+
+func ExampleHandle_Size() {
+	handle := queue.NewHandle[int](queue.NewLinked([]int{1, 2}))
+
+	fmt.Println("Size:", handle.Size())
+
+	// Output:
+	// Size: 2
+}
+
+func ExampleHandle_Swap() {
+	handle := queue.NewHandle[int](queue.NewLinked([]int{1, 2}))
+
+	previous, err := handle.Swap(queue.NewLinked([]int{3}), false)
+	if err != nil {
+		fmt.Println("Swap err:", err)
+		return
+	}
+
+	fmt.Println("Previous size:", previous.Size())
+	fmt.Println("Clear:", handle.Clear())
+
+	// Output:
+	// Previous size: 2
+	// Clear: [3]
+}
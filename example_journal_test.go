@@ -0,0 +1,42 @@
+package queue_test
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+
+	"github.com/adrianbrad/queue"
+)
+
+func ExampleReplay() {
+	codec := queue.JournalCodec[int]{
+		Encode: func(elem int) ([]byte, error) {
+			return strconv.AppendInt(nil, int64(elem), 10), nil
+		},
+		Decode: func(data []byte) (int, error) {
+			return strconv.Atoi(string(data))
+		},
+	}
+
+	var journal bytes.Buffer
+
+	recorded := queue.NewBlocking([]int{}, queue.WithJournal(&journal, codec))
+
+	_ = recorded.Offer(1)
+	_ = recorded.Offer(2)
+	_, _ = recorded.Get()
+
+	// Close waits until the journal is written.
+	recorded.Close()
+
+	replayed, err := queue.Replay(&journal, codec, func() queue.Queue[int] {
+		return queue.NewBlocking([]int{})
+	})
+
+	fmt.Println("Replay:", err)
+	fmt.Println("Elements:", replayed.(*queue.Blocking[int]).ToSlice())
+
+	// Output:
+	// Replay: <nil>
+	// Elements: [2]
+}
@@ -1,7 +1,14 @@
 package queue_test
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/adrianbrad/queue"
 )
@@ -54,3 +61,665 @@ func ExampleLinked() {
 	// Empty after clear: true
 	// Get: 5
 }
+
+func ExampleLinked_All() {
+	linkedQueue := queue.NewLinked([]int{1, 2, 3})
+
+	linkedQueue.All()(func(elem int) bool {
+		fmt.Println("All:", elem)
+
+		return true
+	})
+
+	fmt.Println("Size:", linkedQueue.Size())
+
+	// Output:
+	// All: 1
+	// All: 2
+	// All: 3
+	// Size: 3
+}
+
+func ExampleLinked_At() {
+	linkedQueue := queue.NewLinked([]int{1, 2, 3})
+
+	elem, err := linkedQueue.At(1)
+	fmt.Println("At:", elem, err)
+
+	_, err = linkedQueue.At(-1)
+	fmt.Println("Err:", err)
+
+	// Output:
+	// At: 2 <nil>
+	// Err: index out of range
+}
+
+func ExampleLinked_CanOffer() {
+	linkedQueue := queue.NewLinked([]int{1, 2})
+
+	// the queue is unbounded.
+	fmt.Println("Can offer:", linkedQueue.CanOffer(100))
+
+	// Output:
+	// Can offer: true
+}
+
+func ExampleLinked_Capacity() {
+	linkedQueue := queue.NewLinked([]int{1, 2})
+
+	// the queue is unbounded.
+	fmt.Println("Capacity:", linkedQueue.Capacity())
+
+	// Output:
+	// Capacity: -1
+}
+
+func ExampleLinked_Clear() {
+	linkedQueue := queue.NewLinked([]int{1, 2, 3})
+
+	fmt.Println("Clear:", linkedQueue.Clear())
+	fmt.Println("Size:", linkedQueue.Size())
+
+	// Output:
+	// Clear: [1 2 3]
+	// Size: 0
+}
+
+func ExampleLinked_ClearIf() {
+	linkedQueue := queue.NewLinked([]int{1, 2})
+
+	headIsOne := func(snapshot queue.ClearSnapshot[int]) bool {
+		return snapshot.Head == 1
+	}
+
+	elems, cleared := linkedQueue.ClearIf(headIsOne)
+	fmt.Println("Cleared:", cleared, elems)
+
+	_ = linkedQueue.OfferAll(2, 1)
+
+	elems, cleared = linkedQueue.ClearIf(headIsOne)
+	fmt.Println("Cleared:", cleared, elems)
+
+	// Output:
+	// Cleared: true [1 2]
+	// Cleared: false []
+}
+
+func ExampleLinked_Contains() {
+	linkedQueue := queue.NewLinked([]int{1, 2})
+
+	fmt.Println("Contains 2:", linkedQueue.Contains(2))
+	fmt.Println("Contains 3:", linkedQueue.Contains(3))
+
+	// Output:
+	// Contains 2: true
+	// Contains 3: false
+}
+
+func ExampleLinked_ContainsRecent() {
+	linkedQueue := queue.NewLinked([]int{1, 2, 3})
+
+	fmt.Println("Recent 3:", linkedQueue.ContainsRecent(1, 3))
+	fmt.Println("Recent 1:", linkedQueue.ContainsRecent(2, 1))
+
+	// Output:
+	// Recent 3: true
+	// Recent 1: false
+}
+
+func ExampleLinked_ContentionProfile() {
+	linkedQueue := queue.NewLinked(
+		[]int{},
+		queue.WithContentionProfiling(1),
+	)
+
+	for i := 0; i < 3; i++ {
+		_ = linkedQueue.Offer(i)
+	}
+
+	fmt.Println("Offer samples:", linkedQueue.ContentionProfile()["Offer"].Count)
+
+	// Output:
+	// Offer samples: 3
+}
+
+func ExampleLinked_Drain() {
+	linkedQueue := queue.NewLinked([]int{1, 2, 3})
+
+	linkedQueue.Drain()(func(elem int) bool {
+		fmt.Println("Drain:", elem)
+
+		return elem < 2
+	})
+
+	fmt.Println("Size:", linkedQueue.Size())
+
+	// Output:
+	// Drain: 1
+	// Drain: 2
+	// Size: 1
+}
+
+func ExampleLinked_Dump() {
+	linkedQueue := queue.NewLinked([]int{1, 2})
+
+	if err := linkedQueue.Dump(os.Stdout); err != nil {
+		fmt.Println("Dump err:", err)
+	}
+
+	// Output:
+	// [1,2]
+}
+
+func ExampleLinked_Each() {
+	linkedQueue := queue.NewLinked([]int{1, 2, 3})
+
+	linkedQueue.Each(func(elem int) bool {
+		fmt.Println("Each:", elem)
+
+		return elem < 2
+	})
+
+	fmt.Println("Size:", linkedQueue.Size())
+
+	// Output:
+	// Each: 1
+	// Each: 2
+	// Size: 3
+}
+
+func ExampleLinked_Exchange() {
+	linkedQueue := queue.NewLinked([]int{1, 2})
+
+	elem, err := linkedQueue.Exchange(3)
+	fmt.Println("Exchange:", elem, err)
+	fmt.Println("Elements:", linkedQueue.ToSlice())
+
+	// Output:
+	// Exchange: 1 <nil>
+	// Elements: [2 3]
+}
+
+func ExampleLinked_Flush() {
+	linkedQueue := queue.NewLinked(
+		[]int{},
+		queue.WithAutoFlush(3, func(batch []int) {
+			fmt.Println("Flushed:", batch)
+		}),
+	)
+
+	for i := 1; i <= 4; i++ {
+		_ = linkedQueue.Offer(i)
+	}
+
+	// the element left below the threshold is flushed.
+	linkedQueue.Flush()
+
+	fmt.Println("Size:", linkedQueue.Size())
+
+	// Output:
+	// Flushed: [1 2 3]
+	// Flushed: [4]
+	// Size: 0
+}
+
+func ExampleLinked_Get() {
+	linkedQueue := queue.NewLinked([]int{1})
+
+	elem, err := linkedQueue.Get()
+	fmt.Println("Get:", elem, err)
+
+	_, err = linkedQueue.Get()
+	fmt.Println("Get err:", err)
+
+	// Output:
+	// Get: 1 <nil>
+	// Get err: no elements available in the queue
+}
+
+func ExampleLinked_GetN() {
+	linkedQueue := queue.NewLinked([]int{1, 2, 3})
+
+	fmt.Println("GetN:", linkedQueue.GetN(2))
+	fmt.Println("Size:", linkedQueue.Size())
+
+	// Output:
+	// GetN: [1 2]
+	// Size: 1
+}
+
+func ExampleLinked_InspectPage() {
+	linkedQueue := queue.NewLinked([]int{1, 2, 3, 4, 5})
+
+	var cursor queue.Cursor
+
+	for !cursor.Done() {
+		var (
+			page []int
+			err  error
+		)
+
+		page, cursor, err = linkedQueue.InspectPage(cursor, 2)
+		if err != nil {
+			fmt.Println("InspectPage err:", err)
+			return
+		}
+
+		fmt.Println("Page:", page)
+	}
+
+	// Output:
+	// Page: [1 2]
+	// Page: [3 4]
+	// Page: [5]
+}
+
+func ExampleLinked_IsEmpty() {
+	linkedQueue := queue.NewLinked([]int{1})
+
+	fmt.Println("Empty:", linkedQueue.IsEmpty())
+
+	_, _ = linkedQueue.Get()
+
+	fmt.Println("Empty:", linkedQueue.IsEmpty())
+
+	// Output:
+	// Empty: false
+	// Empty: true
+}
+
+func ExampleLinked_Iterator() {
+	linkedQueue := queue.NewLinked([]int{1, 2, 3})
+
+	for elem := range linkedQueue.Iterator() {
+		fmt.Println("Elem:", elem)
+	}
+
+	// Output:
+	// Elem: 1
+	// Elem: 2
+	// Elem: 3
+}
+
+func ExampleLinked_IteratorsN() {
+	linkedQueue := queue.NewLinked([]int{1, 2, 3, 4, 5})
+
+	iterators, err := linkedQueue.IteratorsN(3, queue.RoundRobin)
+	if err != nil {
+		fmt.Println("IteratorsN err:", err)
+		return
+	}
+
+	for i, iterator := range iterators {
+		for elem := range iterator {
+			fmt.Println("Iterator", i, "elem:", elem)
+		}
+	}
+
+	// Output:
+	// Iterator 0 elem: 1
+	// Iterator 0 elem: 4
+	// Iterator 1 elem: 2
+	// Iterator 1 elem: 5
+	// Iterator 2 elem: 3
+}
+
+func ExampleLinked_MarshalJSON() {
+	linkedQueue := queue.NewLinked([]int{1, 2})
+
+	data, err := linkedQueue.MarshalJSON()
+	fmt.Println("MarshalJSON:", string(data), err)
+
+	// Output:
+	// MarshalJSON: [1,2] <nil>
+}
+
+func ExampleLinked_MarshalJSONTo() {
+	linkedQueue := queue.NewLinked([]int{1, 2})
+
+	var buf bytes.Buffer
+
+	err := linkedQueue.MarshalJSONTo(&buf)
+	fmt.Println("MarshalJSONTo:", buf.String(), err)
+
+	// Output:
+	// MarshalJSONTo: [1,2] <nil>
+}
+
+func ExampleLinked_MemoryFootprint() {
+	linkedQueue := queue.NewLinked([]string{"alpha", "beta"})
+
+	// measure the bytes of the strings, excluding their headers.
+	report := linkedQueue.MemoryFootprint(func(elem string) uintptr {
+		return uintptr(len(elem))
+	})
+
+	fmt.Println("Elements:", report.Elements)
+	fmt.Println("PayloadBytes:", report.PayloadBytes)
+	fmt.Println("WastedSlots:", report.WastedSlots)
+
+	// Output:
+	// Elements: 2
+	// PayloadBytes: 9
+	// WastedSlots: 0
+}
+
+func ExampleLinked_Name() {
+	linkedQueue := queue.NewLinked([]int{}, queue.WithName("jobs"))
+
+	fmt.Println("Name:", linkedQueue.Name())
+
+	_, err := linkedQueue.Get()
+
+	var queueErr *queue.QueueError
+	if errors.As(err, &queueErr) {
+		fmt.Println("QueueError:", queueErr.Name, queueErr.Queue)
+	}
+
+	fmt.Println("Err:", err)
+
+	// Output:
+	// Name: jobs
+	// QueueError: jobs Linked
+	// Err: Linked queue "jobs": no elements available in the queue
+}
+
+func ExampleLinked_Offer() {
+	linkedQueue := queue.NewLinked([]int{1})
+
+	fmt.Println("Offer:", linkedQueue.Offer(2))
+	fmt.Println("Elements:", linkedQueue.ToSlice())
+
+	// Output:
+	// Offer: <nil>
+	// Elements: [1 2]
+}
+
+func ExampleLinked_OfferAll() {
+	linkedQueue := queue.NewLinked([]int{1})
+
+	fmt.Println("OfferAll:", linkedQueue.OfferAll(2, 3))
+	fmt.Println("Elements:", linkedQueue.ToSlice())
+
+	// Output:
+	// OfferAll: <nil>
+	// Elements: [1 2 3]
+}
+
+func ExampleLinked_OfferHandle() {
+	linkedQueue := queue.NewLinked([]int{1})
+
+	handle, err := linkedQueue.OfferHandle(1)
+	if err != nil {
+		fmt.Println("OfferHandle err:", err)
+		return
+	}
+
+	_ = linkedQueue.Offer(2)
+
+	fmt.Println("Cancel:", handle.Cancel())
+	fmt.Println("Elements:", linkedQueue.ToSlice())
+
+	// Output:
+	// Cancel: true
+	// Elements: [1 2]
+}
+
+func ExampleLinked_OfferSome() {
+	linkedQueue := queue.NewLinked([]int{1})
+
+	n, err := linkedQueue.OfferSome(2, 3)
+
+	fmt.Println("OfferSome:", n, err)
+	fmt.Println("Elements:", linkedQueue.Clear())
+
+	// Output:
+	// OfferSome: 2 <nil>
+	// Elements: [1 2 3]
+}
+
+func ExampleLinked_OfferUrgent() {
+	linkedQueue := queue.NewLinked([]int{1, 2})
+
+	if err := linkedQueue.OfferUrgent(3); err != nil {
+		fmt.Println("OfferUrgent err:", err)
+		return
+	}
+
+	fmt.Println("Elements:", linkedQueue.ToSlice())
+
+	// Output:
+	// Elements: [3 1 2]
+}
+
+func ExampleLinked_Peek() {
+	linkedQueue := queue.NewLinked([]int{1, 2})
+
+	elem, err := linkedQueue.Peek()
+	fmt.Println("Peek:", elem, err)
+	fmt.Println("Size:", linkedQueue.Size())
+
+	// Output:
+	// Peek: 1 <nil>
+	// Size: 2
+}
+
+func ExampleLinked_PeekN() {
+	linkedQueue := queue.NewLinked([]int{1, 2, 3})
+
+	fmt.Println("PeekN:", linkedQueue.PeekN(2))
+
+	// Output:
+	// PeekN: [1 2]
+}
+
+func ExampleLinked_Poll() {
+	linkedQueue := queue.NewLinked([]int{})
+
+	go func() {
+		_ = linkedQueue.Offer(1)
+	}()
+
+	// Poll retries until the element is offered.
+	elem, err := linkedQueue.Poll(context.Background(), time.Millisecond)
+	fmt.Println("Poll:", elem, err)
+
+	// Output:
+	// Poll: 1 <nil>
+}
+
+func ExampleLinked_RecentOperations() {
+	linkedQueue := queue.NewLinked(
+		[]int{1},
+		queue.WithCallerTracking(1),
+		queue.WithOperationSummary(strconv.Itoa),
+	)
+
+	_ = linkedQueue.Offer(2)
+	_, _ = linkedQueue.Get()
+	_ = linkedQueue.Clear()
+
+	for _, op := range linkedQueue.RecentOperations() {
+		fmt.Printf("%s(%s)\n", op.Op, op.Element)
+	}
+
+	// Output:
+	// Offer(2)
+	// Get(1)
+	// Clear()
+}
+
+func ExampleLinked_Remaining() {
+	linkedQueue := queue.NewLinked([]int{1, 2})
+
+	// the queue is unbounded.
+	fmt.Println("Remaining:", linkedQueue.Remaining())
+
+	// Output:
+	// Remaining: -1
+}
+
+func ExampleLinked_Reset() {
+	linkedQueue := queue.NewLinked([]int{1, 2})
+
+	_, _ = linkedQueue.Get()
+	_ = linkedQueue.Offer(3)
+
+	linkedQueue.Reset()
+
+	fmt.Println("Elements:", linkedQueue.ToSlice())
+
+	// Output:
+	// Elements: [1 2]
+}
+
+func ExampleLinked_Rotate() {
+	linkedQueue := queue.NewLinked([]int{1, 2, 3})
+
+	if err := linkedQueue.Rotate(); err != nil {
+		fmt.Println("Rotate err:", err)
+		return
+	}
+
+	fmt.Println("Elements:", linkedQueue.ToSlice())
+
+	// Output:
+	// Elements: [2 3 1]
+}
+
+func ExampleLinked_Size() {
+	linkedQueue := queue.NewLinked([]int{1, 2})
+
+	fmt.Println("Size:", linkedQueue.Size())
+
+	// Output:
+	// Size: 2
+}
+
+func ExampleLinked_SyncJournal() {
+	var journal bytes.Buffer
+
+	linkedQueue := queue.NewLinked([]string{"a"}, queue.WithJournal(&journal, queue.JournalCodec[string]{}))
+
+	_ = linkedQueue.OfferUrgent("b")
+	_ = linkedQueue.Offer("c")
+
+	// the records are written by a separate goroutine.
+	linkedQueue.SyncJournal()
+
+	replayed, err := queue.Replay(&journal, queue.JournalCodec[string]{}, func() queue.Queue[string] {
+		return queue.NewLinked([]string{"a"})
+	})
+
+	fmt.Println("Replay:", err)
+	fmt.Println("Elements:", replayed.(*queue.Linked[string]).ToSlice())
+
+	// Output:
+	// Replay: <nil>
+	// Elements: [b a c]
+}
+
+func ExampleLinked_ToSlice() {
+	linkedQueue := queue.NewLinked([]int{1, 2})
+
+	fmt.Println("ToSlice:", linkedQueue.ToSlice())
+	fmt.Println("Size:", linkedQueue.Size())
+
+	// Output:
+	// ToSlice: [1 2]
+	// Size: 2
+}
+
+func ExampleLinked_TryGet() {
+	linkedQueue := queue.NewLinked([]int{1})
+
+	elem, acquired, err := linkedQueue.TryGet()
+	fmt.Println("TryGet:", elem, acquired, err)
+
+	// Output:
+	// TryGet: 1 true <nil>
+}
+
+func ExampleLinked_TryOffer() {
+	linkedQueue := queue.NewLinked([]int{})
+
+	acquired, err := linkedQueue.TryOffer(1)
+	fmt.Println("TryOffer:", acquired, err)
+
+	// Output:
+	// TryOffer: true <nil>
+}
+
+func ExampleLinked_TryPeek() {
+	linkedQueue := queue.NewLinked([]int{1})
+
+	elem, acquired, err := linkedQueue.TryPeek()
+	fmt.Println("TryPeek:", elem, acquired, err)
+
+	// Output:
+	// TryPeek: 1 true <nil>
+}
+
+func ExampleLinked_UnmarshalJSON() {
+	linkedQueue := queue.NewLinked([]int{})
+
+	if err := linkedQueue.UnmarshalJSON([]byte("[1,2]")); err != nil {
+		fmt.Println("UnmarshalJSON err:", err)
+		return
+	}
+
+	fmt.Println("Elements:", linkedQueue.ToSlice())
+
+	// Output:
+	// Elements: [1 2]
+}
+
+func ExampleLinked_UnmarshalJSONFrom() {
+	linkedQueue := queue.NewLinked([]int{})
+
+	if err := linkedQueue.UnmarshalJSONFrom(strings.NewReader("[1,2]")); err != nil {
+		fmt.Println("UnmarshalJSONFrom err:", err)
+		return
+	}
+
+	fmt.Println("Elements:", linkedQueue.ToSlice())
+
+	// Output:
+	// Elements: [1 2]
+}
+
+func ExampleLinked_MarshalBinary() {
+	linkedQueue := queue.NewLinked([]int{1, 2})
+
+	data, err := linkedQueue.MarshalBinary()
+	if err != nil {
+		fmt.Println("MarshalBinary err:", err)
+		return
+	}
+
+	restored := queue.NewLinked([]int{})
+
+	fmt.Println("UnmarshalBinary:", restored.UnmarshalBinary(data))
+	fmt.Println("Elements:", restored.ToSlice())
+
+	// Output:
+	// UnmarshalBinary: <nil>
+	// Elements: [1 2]
+}
+
+func ExampleLinked_UnmarshalBinary() {
+	data, err := queue.NewLinked([]int{1, 2}).MarshalBinary()
+	if err != nil {
+		fmt.Println("MarshalBinary err:", err)
+		return
+	}
+
+	linkedQueue := queue.NewLinked([]int{5})
+
+	fmt.Println("UnmarshalBinary:", linkedQueue.UnmarshalBinary(data))
+	fmt.Println("Elements:", linkedQueue.ToSlice())
+
+	// Output:
+	// UnmarshalBinary: <nil>
+	// Elements: [1 2]
+}
@@ -0,0 +1,74 @@
+package queue_test
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/adrianbrad/queue"
+)
+
+// rawEvent is an event as received from the wire.
+type rawEvent struct {
+	kind    string
+	payload string
+}
+
+// order is the domain object built from a raw event.
+type order struct {
+	ID     string
+	Status string
+}
+
+func toOrder(event rawEvent) order {
+	id, status, _ := strings.Cut(event.payload, ":")
+
+	return order{ID: id, Status: event.kind + "/" + status}
+}
+
+func ExampleMapView() {
+	events := queue.NewLinked([]rawEvent{
+		{kind: "order", payload: "1:created"},
+		{kind: "order", payload: "2:paid"},
+	})
+
+	orders := queue.MapView[rawEvent](events, toOrder)
+
+	fmt.Println("Size:", orders.Size())
+
+	head, _ := orders.Peek()
+
+	fmt.Printf("Peek: %+v\n", head)
+
+	for orders.Size() > 0 {
+		o, _ := orders.Get()
+
+		fmt.Printf("Get: %+v\n", o)
+	}
+
+	if _, err := orders.Get(); err != nil {
+		fmt.Println("Get err:", err)
+	}
+
+	// Output:
+	// Size: 2
+	// Peek: {ID:1 Status:order/created}
+	// Get: {ID:1 Status:order/created}
+	// Get: {ID:2 Status:order/paid}
+	// Get err: no elements available in the queue
+}
+
+func ExampleClearMap() {
+	events := queue.NewBlocking([]rawEvent{
+		{kind: "order", payload: "1:created"},
+		{kind: "refund", payload: "1:issued"},
+	})
+
+	orders := queue.ClearMap[rawEvent](events, toOrder)
+
+	fmt.Printf("%+v\n", orders)
+	fmt.Println("Size:", events.Size())
+
+	// Output:
+	// [{ID:1 Status:order/created} {ID:1 Status:refund/issued}]
+	// Size: 0
+}
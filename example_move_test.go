@@ -0,0 +1,47 @@
+package queue_test
+
+import (
+	"fmt"
+
+	"github.com/adrianbrad/queue"
+)
+
+func ExampleMove() {
+	pending := queue.NewLinked([]string{"job-1", "job-2"})
+	inProgress := queue.NewBlocking([]string{}, queue.WithCapacity(1))
+
+	job, err := queue.Move[string](pending, inProgress)
+
+	fmt.Println("Move:", job, err)
+
+	// the destination is full, the job stays pending.
+	job, err = queue.Move[string](pending, inProgress)
+
+	fmt.Println("Move:", job, err)
+	fmt.Println("Pending:", pending.ToSlice())
+	fmt.Println("In progress:", inProgress.ToSlice())
+
+	// Output:
+	// Move: job-1 <nil>
+	// Move: job-2 queue is full
+	// Pending: [job-2]
+	// In progress: [job-1]
+}
+
+func ExampleMoveMatching() {
+	pending := queue.NewLinked([]string{"job-1", "urgent-job-2", "job-3"})
+	inProgress := queue.NewLinked([]string{})
+
+	job, err := queue.MoveMatching[string](pending, inProgress, func(job string) bool {
+		return job == "urgent-job-2"
+	})
+
+	fmt.Println("MoveMatching:", job, err)
+	fmt.Println("Pending:", pending.ToSlice())
+	fmt.Println("In progress:", inProgress.ToSlice())
+
+	// Output:
+	// MoveMatching: urgent-job-2 <nil>
+	// Pending: [job-1 job-3]
+	// In progress: [urgent-job-2]
+}
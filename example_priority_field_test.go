@@ -0,0 +1,45 @@
+package queue_test
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/adrianbrad/queue"
+)
+
+func ExampleNewPriorityByField() {
+	type schedule struct {
+		Deadline time.Time
+	}
+
+	type order struct {
+		ID       string
+		Schedule schedule
+	}
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	orders, err := queue.NewPriorityByField(
+		[]order{
+			{ID: "late", Schedule: schedule{Deadline: now.Add(time.Hour)}},
+			{ID: "urgent", Schedule: schedule{Deadline: now}},
+		},
+		"Schedule.Deadline",
+		true,
+	)
+	if err != nil {
+		fmt.Println(err)
+
+		return
+	}
+
+	head, _ := orders.Get()
+	fmt.Println("Head:", head.ID)
+
+	_, err = queue.NewPriorityByField([]order{}, "Schedule.Priority", true)
+	fmt.Println("Error:", err)
+
+	// Output:
+	// Head: urgent
+	// Error: invalid priority field "Schedule.Priority" of queue_test.order: queue_test.schedule has no field "Priority"
+}
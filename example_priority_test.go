@@ -1,7 +1,14 @@
 package queue_test
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/adrianbrad/queue"
 )
@@ -58,3 +65,1039 @@ func ExamplePriority() {
 	// Empty after clear: true
 	// Get: 5
 }
+
+func ExamplePriority_All() {
+	priorityQueue := queue.NewPriority(
+		[]int{3, 1, 2},
+		func(elem, otherElem int) bool {
+			return elem < otherElem
+		},
+	)
+
+	priorityQueue.All()(func(elem int) bool {
+		fmt.Println("All:", elem)
+
+		return true
+	})
+
+	fmt.Println("Size:", priorityQueue.Size())
+
+	// Output:
+	// All: 1
+	// All: 2
+	// All: 3
+	// Size: 3
+}
+
+func ExamplePriority_CanOffer() {
+	priorityQueue := queue.NewPriority(
+		[]int{2, 1},
+		func(elem, otherElem int) bool {
+			return elem < otherElem
+		},
+		queue.WithCapacity(3),
+	)
+
+	fmt.Println("Can offer 1:", priorityQueue.CanOffer(1))
+	fmt.Println("Can offer 2:", priorityQueue.CanOffer(2))
+
+	// Output:
+	// Can offer 1: true
+	// Can offer 2: false
+}
+
+func ExamplePriority_Capacity() {
+	priorityQueue := queue.NewPriority(
+		[]int{1},
+		func(elem, otherElem int) bool {
+			return elem < otherElem
+		},
+		queue.WithCapacity(3),
+	)
+
+	fmt.Println("Capacity:", priorityQueue.Capacity())
+
+	// Output:
+	// Capacity: 3
+}
+
+func ExamplePriority_Clear() {
+	priorityQueue := queue.NewPriority(
+		[]int{3, 1, 2},
+		func(elem, otherElem int) bool {
+			return elem < otherElem
+		},
+		queue.WithCapacity(4),
+	)
+
+	fmt.Println("Clear:", priorityQueue.Clear())
+	fmt.Println("Size:", priorityQueue.Size())
+
+	// Output:
+	// Clear: [1 2 3]
+	// Size: 0
+}
+
+func ExamplePriority_ClearIf() {
+	priorityQueue := queue.NewPriority(
+		[]int{3, 1, 2},
+		func(elem, otherElem int) bool {
+			return elem < otherElem
+		},
+		queue.WithCapacity(4),
+	)
+
+	headIsOne := func(snapshot queue.ClearSnapshot[int]) bool {
+		return snapshot.Head == 1
+	}
+
+	elems, cleared := priorityQueue.ClearIf(headIsOne)
+	fmt.Println("Cleared:", cleared, elems)
+
+	// Output:
+	// Cleared: true [1 2 3]
+}
+
+func ExamplePriority_ClearUnsafe() {
+	priorityQueue := queue.NewPriority(
+		[]int{3, 1, 2},
+		func(elem, otherElem int) bool {
+			return elem < otherElem
+		},
+		queue.WithCapacity(4),
+	)
+
+	// the returned slice is owned by the caller.
+	elems := priorityQueue.ClearUnsafe()
+
+	fmt.Println("ClearUnsafe:", elems)
+	fmt.Println("Size:", priorityQueue.Size())
+
+	// Output:
+	// ClearUnsafe: [1 2 3]
+	// Size: 0
+}
+
+func ExamplePriority_Contains() {
+	priorityQueue := queue.NewPriority(
+		[]int{1, 2},
+		func(elem, otherElem int) bool {
+			return elem < otherElem
+		},
+		queue.WithCapacity(4),
+	)
+
+	fmt.Println("Contains 2:", priorityQueue.Contains(2))
+	fmt.Println("Contains 3:", priorityQueue.Contains(3))
+
+	// Output:
+	// Contains 2: true
+	// Contains 3: false
+}
+
+func ExamplePriority_ContentionProfile() {
+	priorityQueue := queue.NewPriority(
+		[]int{},
+		func(elem, otherElem int) bool {
+			return elem < otherElem
+		},
+		queue.WithCapacity(4),
+		queue.WithContentionProfiling(1),
+	)
+
+	for i := 0; i < 3; i++ {
+		_ = priorityQueue.Offer(i)
+	}
+
+	fmt.Println("Offer samples:", priorityQueue.ContentionProfile()["Offer"].Count)
+
+	// Output:
+	// Offer samples: 3
+}
+
+func ExamplePriority_Drain() {
+	priorityQueue := queue.NewPriority(
+		[]int{3, 1, 2},
+		func(elem, otherElem int) bool {
+			return elem < otherElem
+		},
+	)
+
+	priorityQueue.Drain()(func(elem int) bool {
+		fmt.Println("Drain:", elem)
+
+		return elem < 2
+	})
+
+	fmt.Println("Size:", priorityQueue.Size())
+
+	// Output:
+	// Drain: 1
+	// Drain: 2
+	// Size: 1
+}
+
+func ExamplePriority_Dump() {
+	priorityQueue := queue.NewPriority(
+		[]int{2, 1},
+		func(elem, otherElem int) bool {
+			return elem < otherElem
+		},
+		queue.WithCapacity(4),
+	)
+
+	if err := priorityQueue.Dump(os.Stdout); err != nil {
+		fmt.Println("Dump err:", err)
+	}
+
+	// Output:
+	// [1,2]
+}
+
+func ExamplePriority_Each() {
+	priorityQueue := queue.NewPriority(
+		[]int{3, 1, 2},
+		func(elem, otherElem int) bool {
+			return elem < otherElem
+		},
+	)
+
+	priorityQueue.Each(func(elem int) bool {
+		fmt.Println("Each:", elem)
+
+		return elem < 2
+	})
+
+	fmt.Println("Size:", priorityQueue.Size())
+
+	// Output:
+	// Each: 1
+	// Each: 2
+	// Size: 3
+}
+
+func ExamplePriority_Exchange() {
+	priorityQueue := queue.NewPriority(
+		[]int{2, 1},
+		func(elem, otherElem int) bool {
+			return elem < otherElem
+		},
+		queue.WithCapacity(2),
+	)
+
+	elem, err := priorityQueue.Exchange(3)
+	fmt.Println("Exchange:", elem, err)
+	fmt.Println("Elements:", priorityQueue.ToSlice())
+
+	// Output:
+	// Exchange: 1 <nil>
+	// Elements: [2 3]
+}
+
+func ExamplePriority_Get() {
+	priorityQueue := queue.NewPriority(
+		[]int{1},
+		func(elem, otherElem int) bool {
+			return elem < otherElem
+		},
+		queue.WithCapacity(4),
+	)
+
+	elem, err := priorityQueue.Get()
+	fmt.Println("Get:", elem, err)
+
+	_, err = priorityQueue.Get()
+	fmt.Println("Get err:", err)
+
+	// Output:
+	// Get: 1 <nil>
+	// Get err: no elements available in the queue
+}
+
+func ExamplePriority_GetWait() {
+	priorityQueue := queue.NewPriority(
+		[]int{},
+		func(elem, otherElem int) bool {
+			return elem < otherElem
+		},
+	)
+
+	go func() {
+		_ = priorityQueue.OfferAll(2, 1)
+	}()
+
+	fmt.Println("GetWait:", priorityQueue.GetWait())
+	fmt.Println("GetWait:", priorityQueue.GetWait())
+
+	// Output:
+	// GetWait: 1
+	// GetWait: 2
+}
+
+func ExamplePriority_GetN() {
+	priorityQueue := queue.NewPriority(
+		[]int{3, 1, 2},
+		func(elem, otherElem int) bool {
+			return elem < otherElem
+		},
+	)
+
+	fmt.Println("GetN:", priorityQueue.GetN(2))
+	fmt.Println("Size:", priorityQueue.Size())
+
+	// Output:
+	// GetN: [1 2]
+	// Size: 1
+}
+
+func ExamplePriority_HeapStats() {
+	priorityQueue := queue.NewPriority(
+		[]int{},
+		func(elem, otherElem int) bool {
+			return elem < otherElem
+		},
+	)
+
+	for i := 7; i > 0; i-- {
+		_ = priorityQueue.Offer(i)
+	}
+
+	depth, _, size := priorityQueue.HeapStats()
+
+	fmt.Println("Depth:", depth)
+	fmt.Println("Size:", size)
+
+	// Output:
+	// Depth: 3
+	// Size: 7
+}
+
+func ExamplePriority_InspectPage() {
+	priorityQueue := queue.NewPriority(
+		[]int{1, 2, 3, 4, 5},
+		func(elem, otherElem int) bool {
+			return elem < otherElem
+		},
+		queue.WithCapacity(5),
+	)
+
+	var cursor queue.Cursor
+
+	for !cursor.Done() {
+		var (
+			page []int
+			err  error
+		)
+
+		page, cursor, err = priorityQueue.InspectPage(cursor, 2)
+		if err != nil {
+			fmt.Println("InspectPage err:", err)
+			return
+		}
+
+		fmt.Println("Page:", page)
+	}
+
+	// Output:
+	// Page: [1 2]
+	// Page: [3 4]
+	// Page: [5]
+}
+
+func ExamplePriority_IsEmpty() {
+	priorityQueue := queue.NewPriority(
+		[]int{1},
+		func(elem, otherElem int) bool {
+			return elem < otherElem
+		},
+		queue.WithCapacity(4),
+	)
+
+	fmt.Println("Empty:", priorityQueue.IsEmpty())
+
+	_, _ = priorityQueue.Get()
+
+	fmt.Println("Empty:", priorityQueue.IsEmpty())
+
+	// Output:
+	// Empty: false
+	// Empty: true
+}
+
+func ExamplePriority_Iterator() {
+	priorityQueue := queue.NewPriority(
+		[]int{3, 1, 2},
+		func(elem, otherElem int) bool {
+			return elem < otherElem
+		},
+		queue.WithCapacity(4),
+	)
+
+	for elem := range priorityQueue.Iterator() {
+		fmt.Println("Elem:", elem)
+	}
+
+	// Output:
+	// Elem: 1
+	// Elem: 2
+	// Elem: 3
+}
+
+func ExamplePriority_IteratorsN() {
+	priorityQueue := queue.NewPriority(
+		[]int{4, 2, 3, 1},
+		func(elem, otherElem int) bool {
+			return elem < otherElem
+		},
+		queue.WithCapacity(4),
+	)
+
+	iterators, err := priorityQueue.IteratorsN(2, queue.RoundRobin)
+	if err != nil {
+		fmt.Println("IteratorsN err:", err)
+		return
+	}
+
+	for i, iterator := range iterators {
+		for elem := range iterator {
+			fmt.Println("Iterator", i, "elem:", elem)
+		}
+	}
+
+	// Output:
+	// Iterator 0 elem: 1
+	// Iterator 0 elem: 3
+	// Iterator 1 elem: 2
+	// Iterator 1 elem: 4
+}
+
+func ExamplePriority_LedgerCheck() {
+	priorityQueue := queue.NewPriority(
+		[]int{1, 2},
+		func(elem, otherElem int) bool {
+			return elem < otherElem
+		},
+		queue.WithCapacity(2),
+		queue.WithConservationChecks(),
+	)
+
+	_, _ = priorityQueue.OfferBounded(0)
+	_, _ = priorityQueue.Get()
+	priorityQueue.Reset()
+	_ = priorityQueue.Clear()
+
+	fmt.Println("LedgerCheck:", priorityQueue.LedgerCheck())
+
+	// Output:
+	// LedgerCheck: <nil>
+}
+
+func ExamplePriority_MarshalJSON() {
+	priorityQueue := queue.NewPriority(
+		[]int{2, 1},
+		func(elem, otherElem int) bool {
+			return elem < otherElem
+		},
+		queue.WithCapacity(4),
+	)
+
+	data, err := priorityQueue.MarshalJSON()
+	fmt.Println("MarshalJSON:", string(data), err)
+
+	// Output:
+	// MarshalJSON: [1,2] <nil>
+}
+
+func ExamplePriority_MarshalJSONTo() {
+	priorityQueue := queue.NewPriority(
+		[]int{1, 2},
+		func(elem, otherElem int) bool {
+			return elem < otherElem
+		},
+		queue.WithCapacity(4),
+	)
+
+	var buf bytes.Buffer
+
+	err := priorityQueue.MarshalJSONTo(&buf)
+	fmt.Println("MarshalJSONTo:", buf.String(), err)
+
+	// Output:
+	// MarshalJSONTo: [1,2] <nil>
+}
+
+func ExamplePriority_MemoryFootprint() {
+	priorityQueue := queue.NewPriority(
+		[]int{3, 1, 2},
+		func(elem, otherElem int) bool { return elem < otherElem },
+	)
+
+	_, _ = priorityQueue.Get()
+
+	report := priorityQueue.MemoryFootprint(nil)
+
+	fmt.Println("Elements:", report.Elements)
+	fmt.Println("Slots:", report.Slots)
+	fmt.Println("WastedSlots:", report.WastedSlots)
+
+	// Output:
+	// Elements: 2
+	// Slots: 3
+	// WastedSlots: 1
+}
+
+func ExamplePriority_Name() {
+	priorityQueue := queue.NewPriority(
+		[]int{},
+		func(elem, otherElem int) bool {
+			return elem < otherElem
+		},
+		queue.WithName("jobs"),
+	)
+
+	fmt.Println("Name:", priorityQueue.Name())
+
+	_, err := priorityQueue.Get()
+
+	var queueErr *queue.QueueError
+	if errors.As(err, &queueErr) {
+		fmt.Println("QueueError:", queueErr.Name, queueErr.Queue)
+	}
+
+	fmt.Println("Err:", err)
+
+	// Output:
+	// Name: jobs
+	// QueueError: jobs Priority
+	// Err: Priority queue "jobs": no elements available in the queue
+}
+
+func ExamplePriority_Offer() {
+	priorityQueue := queue.NewPriority(
+		[]int{2},
+		func(elem, otherElem int) bool {
+			return elem < otherElem
+		},
+		queue.WithCapacity(2),
+	)
+
+	fmt.Println("Offer:", priorityQueue.Offer(1))
+	fmt.Println("Offer err:", priorityQueue.Offer(3))
+	fmt.Println("Elements:", priorityQueue.ToSlice())
+
+	// Output:
+	// Offer: <nil>
+	// Offer err: queue is full
+	// Elements: [1 2]
+}
+
+func ExamplePriority_OfferWait() {
+	priorityQueue := queue.NewPriority(
+		[]int{2},
+		func(elem, otherElem int) bool {
+			return elem < otherElem
+		},
+		queue.WithCapacity(1),
+	)
+
+	done := make(chan struct{})
+
+	// waits for the space freed by GetWait.
+	go func() {
+		defer close(done)
+
+		priorityQueue.OfferWait(3)
+	}()
+
+	elem := priorityQueue.GetWait()
+
+	<-done
+
+	fmt.Println("GetWait:", elem)
+	fmt.Println("Elements:", priorityQueue.ToSlice())
+
+	// Output:
+	// GetWait: 2
+	// Elements: [3]
+}
+
+func ExamplePriority_OfferWaitErr() {
+	priorityQueue := queue.NewPriority(
+		[]int{2},
+		func(elem, otherElem int) bool {
+			return elem < otherElem
+		},
+		queue.WithCapacity(1),
+	)
+
+	offered := make(chan error)
+
+	// waits for the space freed by GetWait.
+	go func() {
+		offered <- priorityQueue.OfferWaitErr(3)
+	}()
+
+	elem := priorityQueue.GetWait()
+
+	fmt.Println("OfferWaitErr:", <-offered)
+	fmt.Println("GetWait:", elem)
+	fmt.Println("Elements:", priorityQueue.ToSlice())
+
+	// Output:
+	// OfferWaitErr: <nil>
+	// GetWait: 2
+	// Elements: [3]
+}
+
+func ExamplePriority_OfferAll() {
+	priorityQueue := queue.NewPriority(
+		[]int{2},
+		func(elem, otherElem int) bool {
+			return elem < otherElem
+		},
+		queue.WithCapacity(4),
+	)
+
+	fmt.Println("OfferAll:", priorityQueue.OfferAll(3, 1))
+	fmt.Println("Elements:", priorityQueue.ToSlice())
+
+	// Output:
+	// OfferAll: <nil>
+	// Elements: [1 2 3]
+}
+
+func ExamplePriority_OfferBounded() {
+	// the queue keeps the 2 highest elements offered to it.
+	priorityQueue := queue.NewPriority(
+		[]int{},
+		func(elem, otherElem int) bool {
+			return elem > otherElem
+		},
+		queue.WithCapacity(2),
+	)
+
+	for _, elem := range []int{3, 1, 3, 5, 2} {
+		outcome, err := priorityQueue.OfferBounded(elem)
+		if err != nil {
+			fmt.Println("OfferBounded err:", err)
+			return
+		}
+
+		fmt.Println("OfferBounded", elem, outcome.Status, outcome.Evicted)
+	}
+
+	fmt.Println("Elements:", priorityQueue.ToSlice())
+
+	// Output:
+	// OfferBounded 3 Accepted 0
+	// OfferBounded 1 Accepted 0
+	// OfferBounded 3 RejectedDuplicate 0
+	// OfferBounded 5 AcceptedWithEviction 1
+	// OfferBounded 2 RejectedWorse 0
+	// Elements: [5 3]
+}
+
+func ExamplePriority_OfferSome() {
+	priorityQueue := queue.NewPriority(
+		[]int{3},
+		func(elem, otherElem int) bool { return elem < otherElem },
+		queue.WithCapacity(3),
+	)
+
+	n, err := priorityQueue.OfferSome(2, 1, 4)
+
+	fmt.Println("OfferSome:", n, err)
+	fmt.Println("Elements:", priorityQueue.Clear())
+
+	// Output:
+	// OfferSome: 2 queue is full
+	// Elements: [1 2 3]
+}
+
+func ExamplePriority_Peek() {
+	priorityQueue := queue.NewPriority(
+		[]int{2, 1},
+		func(elem, otherElem int) bool {
+			return elem < otherElem
+		},
+		queue.WithCapacity(4),
+	)
+
+	elem, err := priorityQueue.Peek()
+	fmt.Println("Peek:", elem, err)
+	fmt.Println("Size:", priorityQueue.Size())
+
+	// Output:
+	// Peek: 1 <nil>
+	// Size: 2
+}
+
+func ExamplePriority_PeekWait() {
+	priorityQueue := queue.NewPriority(
+		[]int{},
+		func(elem, otherElem int) bool {
+			return elem < otherElem
+		},
+	)
+
+	go func() {
+		_ = priorityQueue.Offer(1)
+	}()
+
+	fmt.Println("PeekWait:", priorityQueue.PeekWait())
+	fmt.Println("Size:", priorityQueue.Size())
+
+	// Output:
+	// PeekWait: 1
+	// Size: 1
+}
+
+func ExamplePriority_PeekN() {
+	priorityQueue := queue.NewPriority(
+		[]int{3, 1, 2},
+		func(elem, otherElem int) bool {
+			return elem < otherElem
+		},
+		queue.WithCapacity(4),
+	)
+
+	fmt.Println("PeekN:", priorityQueue.PeekN(2))
+
+	// Output:
+	// PeekN: [1 2]
+}
+
+func ExamplePriority_Poll() {
+	priorityQueue := queue.NewPriority(
+		[]int{},
+		func(elem, otherElem int) bool {
+			return elem < otherElem
+		},
+		queue.WithCapacity(4),
+	)
+
+	go func() {
+		_ = priorityQueue.Offer(1)
+	}()
+
+	// Poll retries until the element is offered.
+	elem, err := priorityQueue.Poll(context.Background(), time.Millisecond)
+	fmt.Println("Poll:", elem, err)
+
+	// Output:
+	// Poll: 1 <nil>
+}
+
+func ExamplePriority_Rebuild() {
+	priorityQueue := queue.NewPriority(
+		[]int{},
+		func(elem, otherElem int) bool {
+			return elem < otherElem
+		},
+	)
+
+	for i := 7; i > 0; i-- {
+		_ = priorityQueue.Offer(i)
+	}
+
+	priorityQueue.Rebuild()
+
+	fmt.Println("Elems:", priorityQueue.Clear())
+
+	// Output:
+	// Elems: [1 2 3 4 5 6 7]
+}
+
+func ExamplePriority_RecentOperations() {
+	priorityQueue := queue.NewPriority(
+		[]int{1},
+		func(elem, otherElem int) bool { return elem < otherElem },
+		queue.WithCallerTracking(1),
+		queue.WithOperationSummary(strconv.Itoa),
+	)
+
+	_ = priorityQueue.Offer(2)
+	_, _ = priorityQueue.Get()
+	_ = priorityQueue.Clear()
+
+	for _, op := range priorityQueue.RecentOperations() {
+		fmt.Printf("%s(%s)\n", op.Op, op.Element)
+	}
+
+	// Output:
+	// Offer(2)
+	// Get(1)
+	// Clear()
+}
+
+func ExamplePriority_Remaining() {
+	priorityQueue := queue.NewPriority(
+		[]int{1},
+		func(elem, otherElem int) bool {
+			return elem < otherElem
+		},
+		queue.WithCapacity(3),
+	)
+
+	fmt.Println("Remaining:", priorityQueue.Remaining())
+
+	// Output:
+	// Remaining: 2
+}
+
+func ExamplePriority_Remove() {
+	priorityQueue := queue.NewPriority(
+		[]int{2, 4, 6},
+		func(elem, otherElem int) bool {
+			return elem < otherElem
+		},
+	)
+
+	fmt.Println("Remove 4:", priorityQueue.Remove(4))
+	fmt.Println("Remove 5:", priorityQueue.Remove(5))
+	fmt.Println("Elems:", priorityQueue.Clear())
+
+	// Output:
+	// Remove 4: true
+	// Remove 5: false
+	// Elems: [2 6]
+}
+
+func ExamplePriority_Reset() {
+	priorityQueue := queue.NewPriority(
+		[]int{1, 2},
+		func(elem, otherElem int) bool {
+			return elem < otherElem
+		},
+		queue.WithCapacity(4),
+	)
+
+	_, _ = priorityQueue.Get()
+	_ = priorityQueue.Offer(3)
+
+	priorityQueue.Reset()
+
+	fmt.Println("Elements:", priorityQueue.ToSlice())
+
+	// Output:
+	// Elements: [1 2]
+}
+
+func ExamplePriority_Size() {
+	priorityQueue := queue.NewPriority(
+		[]int{1, 2},
+		func(elem, otherElem int) bool {
+			return elem < otherElem
+		},
+		queue.WithCapacity(4),
+	)
+
+	fmt.Println("Size:", priorityQueue.Size())
+
+	// Output:
+	// Size: 2
+}
+
+func ExamplePriority_SyncJournal() {
+	var journal bytes.Buffer
+
+	less := func(elem, otherElem int) bool {
+		return elem < otherElem
+	}
+
+	priorityQueue := queue.NewPriority([]int{3}, less, queue.WithJournal(&journal, queue.JournalCodec[int]{}))
+
+	_ = priorityQueue.Offer(1)
+	_, _ = priorityQueue.Exchange(2)
+
+	// the records are written by a separate goroutine.
+	priorityQueue.SyncJournal()
+
+	replayed, err := queue.Replay(&journal, queue.JournalCodec[int]{}, func() queue.Queue[int] {
+		return queue.NewPriority([]int{3}, less)
+	})
+
+	fmt.Println("Replay:", err)
+	fmt.Println("Elements:", replayed.(*queue.Priority[int]).ToSlice())
+
+	// Output:
+	// Replay: <nil>
+	// Elements: [2 3]
+}
+
+func ExamplePriority_ToSlice() {
+	priorityQueue := queue.NewPriority(
+		[]int{2, 1},
+		func(elem, otherElem int) bool {
+			return elem < otherElem
+		},
+		queue.WithCapacity(4),
+	)
+
+	fmt.Println("ToSlice:", priorityQueue.ToSlice())
+	fmt.Println("Size:", priorityQueue.Size())
+
+	// Output:
+	// ToSlice: [1 2]
+	// Size: 2
+}
+
+func ExamplePriority_TryGet() {
+	priorityQueue := queue.NewPriority(
+		[]int{1},
+		func(elem, otherElem int) bool {
+			return elem < otherElem
+		},
+		queue.WithCapacity(4),
+	)
+
+	elem, acquired, err := priorityQueue.TryGet()
+	fmt.Println("TryGet:", elem, acquired, err)
+
+	// Output:
+	// TryGet: 1 true <nil>
+}
+
+func ExamplePriority_TryOffer() {
+	priorityQueue := queue.NewPriority(
+		[]int{},
+		func(elem, otherElem int) bool {
+			return elem < otherElem
+		},
+		queue.WithCapacity(4),
+	)
+
+	acquired, err := priorityQueue.TryOffer(1)
+	fmt.Println("TryOffer:", acquired, err)
+
+	// Output:
+	// TryOffer: true <nil>
+}
+
+func ExamplePriority_TryPeek() {
+	priorityQueue := queue.NewPriority(
+		[]int{1},
+		func(elem, otherElem int) bool {
+			return elem < otherElem
+		},
+		queue.WithCapacity(4),
+	)
+
+	elem, acquired, err := priorityQueue.TryPeek()
+	fmt.Println("TryPeek:", elem, acquired, err)
+
+	// Output:
+	// TryPeek: 1 true <nil>
+}
+
+func ExamplePriority_UnmarshalJSON() {
+	priorityQueue := queue.NewPriority(
+		[]int{},
+		func(elem, otherElem int) bool {
+			return elem < otherElem
+		},
+		queue.WithCapacity(4),
+	)
+
+	if err := priorityQueue.UnmarshalJSON([]byte("[1,2]")); err != nil {
+		fmt.Println("UnmarshalJSON err:", err)
+		return
+	}
+
+	fmt.Println("Elements:", priorityQueue.ToSlice())
+
+	// Output:
+	// Elements: [1 2]
+}
+
+func ExamplePriority_UnmarshalJSONFrom() {
+	priorityQueue := queue.NewPriority(
+		[]int{},
+		func(elem, otherElem int) bool {
+			return elem < otherElem
+		},
+		queue.WithCapacity(4),
+	)
+
+	if err := priorityQueue.UnmarshalJSONFrom(strings.NewReader("[1,2]")); err != nil {
+		fmt.Println("UnmarshalJSONFrom err:", err)
+		return
+	}
+
+	fmt.Println("Elements:", priorityQueue.ToSlice())
+
+	// Output:
+	// Elements: [1 2]
+}
+
+func ExamplePriority_MarshalBinary() {
+	lessFunc := func(elem, otherElem int) bool {
+		return elem < otherElem
+	}
+
+	priorityQueue := queue.NewPriority([]int{3, 1, 2}, lessFunc, queue.WithCapacity(4))
+
+	data, err := priorityQueue.MarshalBinary()
+	if err != nil {
+		fmt.Println("MarshalBinary err:", err)
+		return
+	}
+
+	restored := queue.NewPriority([]int{}, lessFunc, queue.WithCapacity(4))
+
+	fmt.Println("UnmarshalBinary:", restored.UnmarshalBinary(data))
+	fmt.Println("Elements:", restored.ToSlice())
+
+	// Output:
+	// UnmarshalBinary: <nil>
+	// Elements: [1 2 3]
+}
+
+func ExamplePriority_UnmarshalBinary() {
+	lessFunc := func(elem, otherElem int) bool {
+		return elem < otherElem
+	}
+
+	if err := queue.RegisterLess("binaryAscending", lessFunc); err != nil &&
+		!errors.Is(err, queue.ErrComparatorRegistered) {
+		fmt.Println("RegisterLess err:", err)
+		return
+	}
+
+	data, err := queue.NewPriority([]int{2, 1}, lessFunc, queue.WithLessName("binaryAscending")).MarshalBinary()
+	if err != nil {
+		fmt.Println("MarshalBinary err:", err)
+		return
+	}
+
+	priorityQueue := queue.NewPriority([]int{5}, lessFunc, queue.WithLessName("binaryAscending"))
+
+	fmt.Println("UnmarshalBinary:", priorityQueue.UnmarshalBinary(data))
+	fmt.Println("Elements:", priorityQueue.ToSlice())
+
+	err = queue.NewPriority([]int{}, lessFunc).UnmarshalBinary(data)
+	fmt.Println("Comparator mismatch:", errors.Is(err, queue.ErrComparatorMismatch))
+
+	// Output:
+	// UnmarshalBinary: <nil>
+	// Elements: [1 2]
+	// Comparator mismatch: true
+}
+
+func ExamplePriority_Update() {
+	priorityQueue := queue.NewPriority(
+		[]int{2, 4, 6},
+		func(elem, otherElem int) bool {
+			return elem < otherElem
+		},
+	)
+
+	fmt.Println("Update 6 to 1:", priorityQueue.Update(6, 1))
+	fmt.Println("Update 5 to 3:", priorityQueue.Update(5, 3))
+	fmt.Println("Elems:", priorityQueue.Clear())
+
+	// Output:
+	// Update 6 to 1: true
+	// Update 5 to 3: false
+	// Elems: [1 2 4]
+}
@@ -0,0 +1,51 @@
+package queue_test
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/adrianbrad/queue"
+)
+
+func ExampleSemaphore() {
+	semaphore := queue.NewSemaphore(2)
+
+	if err := semaphore.AcquireN(context.Background(), 2); err != nil {
+		fmt.Println("AcquireN err:", err)
+		return
+	}
+
+	fmt.Println("TryAcquire:", semaphore.TryAcquire())
+
+	semaphore.Release()
+
+	fmt.Println("TryAcquire:", semaphore.TryAcquire())
+	fmt.Println("Available:", semaphore.Available())
+
+	// Output:
+	// TryAcquire: false
+	// TryAcquire: true
+	// Available: 0
+}
+
+func ExampleTokenBucket() {
+	// a burst of 2 tokens, refilled with a token every millisecond.
+	bucket := queue.NewTokenBucket(2, time.Millisecond)
+
+	fmt.Println("TryTake:", bucket.TryTake())
+	fmt.Println("TryTake:", bucket.TryTake())
+
+	// Take waits for the next refill.
+	if err := bucket.Take(context.Background()); err != nil {
+		fmt.Println("Take err:", err)
+		return
+	}
+
+	fmt.Println("Taken after the refill")
+
+	// Output:
+	// TryTake: true
+	// TryTake: true
+	// Taken after the refill
+}
@@ -0,0 +1,61 @@
+package queue_test
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/adrianbrad/queue"
+)
+
+// TestExamples checks that every exported method of the queue
+// implementations has a runnable example, named Example<Type>_<Method>.
+func TestExamples(t *testing.T) {
+	t.Parallel()
+
+	fset := token.NewFileSet()
+
+	pkgs, err := parser.ParseDir(fset, ".", func(info fs.FileInfo) bool {
+		return strings.HasSuffix(info.Name(), "_test.go")
+	}, 0)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	examples := make(map[string]bool)
+
+	for _, file := range pkgs["queue_test"].Files {
+		for _, decl := range file.Decls {
+			if fn, ok := decl.(*ast.FuncDecl); ok && strings.HasPrefix(fn.Name.Name, "Example") {
+				examples[fn.Name.Name] = true
+			}
+		}
+	}
+
+	implementations := map[string]any{
+		"Blocking":   (*queue.Blocking[int])(nil),
+		"Circular":   (*queue.Circular[int])(nil),
+		"Linked":     (*queue.Linked[int])(nil),
+		"Priority":   (*queue.Priority[int])(nil),
+		"ChanQueue":  (*queue.ChanQueue[int])(nil),
+		"Deque":      (*queue.Deque[int])(nil),
+		"Concurrent": (*queue.Concurrent[int])(nil),
+		"Handle":     (*queue.Handle[int])(nil),
+	}
+
+	for name, impl := range implementations {
+		typ := reflect.TypeOf(impl)
+
+		for i := 0; i < typ.NumMethod(); i++ {
+			example := "Example" + name + "_" + typ.Method(i).Name
+
+			if !examples[example] {
+				t.Errorf("expected %s to exist", example)
+			}
+		}
+	}
+}
@@ -0,0 +1,298 @@
+package queue_test
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/adrianbrad/queue"
+)
+
+// exchangeQueue is implemented by all the queues.
+type exchangeQueue interface {
+	Exchange(int) (int, error)
+	Size() int
+	Clear() []int
+}
+
+// rotateQueue is implemented by all the queues but Priority.
+type rotateQueue interface {
+	Rotate() error
+	Peek() (int, error)
+	Clear() []int
+}
+
+func TestExchange(t *testing.T) {
+	t.Parallel()
+
+	lessInt := func(elem, otherElem int) bool { return elem < otherElem }
+
+	testCases := map[string]func(elems []int, capacity int) exchangeQueue{
+		"Blocking": func(elems []int, capacity int) exchangeQueue {
+			return queue.NewBlocking(elems, queue.WithCapacity(capacity))
+		},
+		"Circular": func(elems []int, capacity int) exchangeQueue {
+			return queue.NewCircular(elems, capacity)
+		},
+		"Linked": func(elems []int, _ int) exchangeQueue {
+			return queue.NewLinked(elems)
+		},
+		"Priority": func(elems []int, capacity int) exchangeQueue {
+			return queue.NewPriority(elems, lessInt, queue.WithCapacity(capacity))
+		},
+	}
+
+	for name, newQueue := range testCases {
+		newQueue := newQueue
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			t.Run("Full", func(t *testing.T) {
+				t.Parallel()
+
+				q := newQueue([]int{1, 2, 3}, 3)
+
+				elem, err := q.Exchange(4)
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+
+				if elem != 1 {
+					t.Fatalf("expected exchanged element to be 1, got %d", elem)
+				}
+
+				if elems := q.Clear(); !reflect.DeepEqual([]int{2, 3, 4}, elems) {
+					t.Fatalf("expected elements to be %v, got %v", []int{2, 3, 4}, elems)
+				}
+			})
+
+			t.Run("Empty", func(t *testing.T) {
+				t.Parallel()
+
+				q := newQueue([]int{}, 3)
+
+				if _, err := q.Exchange(1); !errors.Is(err, queue.ErrNoElementsAvailable) {
+					t.Fatalf("expected error to be %v, got %v", queue.ErrNoElementsAvailable, err)
+				}
+
+				if elems := q.Clear(); !reflect.DeepEqual([]int{1}, elems) {
+					t.Fatalf("expected the element to be offered, got %v", elems)
+				}
+			})
+
+			t.Run("PoolOccupancy", func(t *testing.T) {
+				t.Parallel()
+
+				const (
+					poolSize   = 8
+					goroutines = 16
+					exchanges  = 500
+				)
+
+				pool := make([]int, poolSize)
+
+				for i := range pool {
+					pool[i] = i
+				}
+
+				q := newQueue(pool, poolSize)
+
+				var wg sync.WaitGroup
+
+				wg.Add(goroutines)
+
+				for g := 0; g < goroutines; g++ {
+					go func() {
+						defer wg.Done()
+
+						for i := 0; i < exchanges; i++ {
+							// put back the element taken out.
+							elem, err := q.Exchange(-1)
+							if err != nil {
+								t.Errorf("expected no error, got %v", err)
+
+								return
+							}
+
+							if _, err := q.Exchange(elem); err != nil {
+								t.Errorf("expected no error, got %v", err)
+
+								return
+							}
+
+							if size := q.Size(); size != poolSize {
+								t.Errorf("expected size to be %d, got %d", poolSize, size)
+
+								return
+							}
+						}
+					}()
+				}
+
+				wg.Wait()
+
+				elems := q.Clear()
+
+				if len(elems) != poolSize {
+					t.Fatalf("expected %d elements, got %d", poolSize, len(elems))
+				}
+
+				// the -1 placeholders replaced some elements, each element
+				// is still present at most once.
+				sort.Ints(elems)
+
+				for i := 1; i < len(elems); i++ {
+					if elems[i] != -1 && elems[i] == elems[i-1] {
+						t.Fatalf("expected no duplicated element, got %v", elems)
+					}
+				}
+			})
+		})
+	}
+
+	t.Run("BlockingClosed", func(t *testing.T) {
+		t.Parallel()
+
+		blockingQueue := queue.NewBlocking([]int{1})
+
+		blockingQueue.Close()
+
+		if _, err := blockingQueue.Exchange(2); !errors.Is(err, queue.ErrQueueClosed) {
+			t.Fatalf("expected error to be %v, got %v", queue.ErrQueueClosed, err)
+		}
+
+		if elems := blockingQueue.Clear(); !reflect.DeepEqual([]int{1}, elems) {
+			t.Fatalf("expected queue to be unchanged, got %v", elems)
+		}
+	})
+
+	t.Run("PriorityOrder", func(t *testing.T) {
+		t.Parallel()
+
+		priorityQueue := queue.NewPriority([]int{1, 3, 5}, lessInt)
+
+		if elem, _ := priorityQueue.Exchange(4); elem != 1 {
+			t.Fatalf("expected exchanged element to be 1, got %d", elem)
+		}
+
+		if elems := priorityQueue.Clear(); !reflect.DeepEqual([]int{3, 4, 5}, elems) {
+			t.Fatalf("expected elements to be %v, got %v", []int{3, 4, 5}, elems)
+		}
+	})
+}
+
+func TestRotate(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]func(elems []int) rotateQueue{
+		"Blocking": func(elems []int) rotateQueue {
+			return queue.NewBlocking(elems, queue.WithCapacity(len(elems)))
+		},
+		"Circular": func(elems []int) rotateQueue {
+			return queue.NewCircular(elems, max(len(elems), 1))
+		},
+		"Linked": func(elems []int) rotateQueue {
+			return queue.NewLinked(elems)
+		},
+	}
+
+	for name, newQueue := range testCases {
+		newQueue := newQueue
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			t.Run("Order", func(t *testing.T) {
+				t.Parallel()
+
+				q := newQueue([]int{1, 2, 3})
+
+				if err := q.Rotate(); err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+
+				if elems := q.Clear(); !reflect.DeepEqual([]int{2, 3, 1}, elems) {
+					t.Fatalf("expected elements to be %v, got %v", []int{2, 3, 1}, elems)
+				}
+			})
+
+			t.Run("Empty", func(t *testing.T) {
+				t.Parallel()
+
+				if err := newQueue(nil).Rotate(); !errors.Is(err, queue.ErrNoElementsAvailable) {
+					t.Fatalf("expected error to be %v, got %v", queue.ErrNoElementsAvailable, err)
+				}
+			})
+
+			t.Run("Fairness", func(t *testing.T) {
+				t.Parallel()
+
+				const (
+					elems     = 5
+					consumers = 4
+					rounds    = 250
+				)
+
+				initial := make([]int, elems)
+
+				for i := range initial {
+					initial[i] = i
+				}
+
+				q := newQueue(initial)
+
+				var (
+					wg     sync.WaitGroup
+					lock   sync.Mutex
+					visits = make(map[int]int)
+				)
+
+				wg.Add(consumers)
+
+				for c := 0; c < consumers; c++ {
+					go func() {
+						defer wg.Done()
+
+						for i := 0; i < rounds; i++ {
+							if err := q.Rotate(); err != nil {
+								t.Errorf("expected no error, got %v", err)
+
+								return
+							}
+
+							elem, err := q.Peek()
+							if err != nil {
+								t.Errorf("expected no error, got %v", err)
+
+								return
+							}
+
+							lock.Lock()
+							visits[elem]++
+							lock.Unlock()
+						}
+					}()
+				}
+
+				wg.Wait()
+
+				// every rotation moves the head by one position, the heads
+				// observed by the consumers are spread over all the elements.
+				if len(visits) != elems {
+					t.Fatalf("expected every element to be visited, got %v", visits)
+				}
+
+				remaining := q.Clear()
+
+				sort.Ints(remaining)
+
+				if !reflect.DeepEqual(initial, remaining) {
+					t.Fatalf("expected elements to be %v, got %v", initial, remaining)
+				}
+			})
+		})
+	}
+}
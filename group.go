@@ -0,0 +1,76 @@
+package queue
+
+import (
+	"slices"
+)
+
+// GroupBy returns the elements of q bucketed by key, without removing them.
+// The elements are copied once, using ToSlice, and each bucket holds its
+// elements in the order in which they would be retrieved: priority order for
+// the Priority queues, FIFO order for the others.
+// key is called once for each element, while no queue lock is held.
+func GroupBy[T any, K comparable](q Snapshotter[T], key func(elem T) K) map[K][]T {
+	return group(q.ToSlice(), key)
+}
+
+// GroupByDrain removes all the elements of q, using Clear, and returns them
+// bucketed by key as GroupBy does.
+func GroupByDrain[T any, K comparable](q Drainer[T], key func(elem T) K) map[K][]T {
+	return group(q.Clear(), key)
+}
+
+// GroupByStream passes the elements of q to sink bucket by bucket, without
+// removing them and without building the buckets: the buckets are passed in
+// the order of their first element, and the elements of each bucket in the
+// order in which GroupBy would bucket them.
+// key is called once for each element, before sink is first called.
+//
+// If sink returns an error, GroupByStream returns it without passing the
+// following elements, the queue being left unchanged.
+func GroupByStream[T any, K comparable](q Snapshotter[T], key func(elem T) K, sink func(key K, elem T) error) error {
+	elems := q.ToSlice()
+
+	keys, ranks, order := make([]K, len(elems)), make([]int, len(elems)), make([]int, len(elems))
+
+	// buckets holds the rank of each key, in the order of the first element
+	// of its bucket.
+	buckets := make(map[K]int)
+
+	for i, elem := range elems {
+		k := key(elem)
+
+		rank, ok := buckets[k]
+		if !ok {
+			rank = len(buckets)
+			buckets[k] = rank
+		}
+
+		keys[i], ranks[i], order[i] = k, rank, i
+	}
+
+	// the stable sort keeps the order of the elements within each bucket.
+	slices.SortStableFunc(order, func(i, j int) int {
+		return ranks[i] - ranks[j]
+	})
+
+	for _, i := range order {
+		if err := sink(keys[i], elems[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// group buckets the elements by key, keeping their order within each bucket.
+func group[T any, K comparable](elems []T, key func(elem T) K) map[K][]T {
+	groups := make(map[K][]T)
+
+	for _, elem := range elems {
+		k := key(elem)
+
+		groups[k] = append(groups[k], elem)
+	}
+
+	return groups
+}
@@ -0,0 +1,194 @@
+package queue_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/adrianbrad/queue"
+)
+
+func TestGroupBy(t *testing.T) {
+	t.Parallel()
+
+	lessInt := func(elem, otherElem int) bool { return elem < otherElem }
+
+	type groupQueue interface {
+		queue.Snapshotter[int]
+		queue.Drainer[int]
+	}
+
+	testCases := map[string]func(elems []int) groupQueue{
+		"Blocking": func(elems []int) groupQueue { return queue.NewBlocking(elems) },
+		"Circular": func(elems []int) groupQueue { return queue.NewCircular(elems, 16) },
+		"Linked":   func(elems []int) groupQueue { return queue.NewLinked(elems) },
+		"Priority": func(elems []int) groupQueue { return queue.NewPriority(elems, lessInt) },
+		"PriorityAny": func(elems []int) groupQueue {
+			return queue.NewPriorityAny(elems, lessInt)
+		},
+	}
+
+	elems := []int{7, 2, 9, 4, 1, 6, 3, 8, 5}
+	parity := func(elem int) int { return elem % 2 }
+
+	// filteredDrain returns the elements of the bucket, in the order in which
+	// a twin queue drains them.
+	filteredDrain := func(twin groupQueue, k int) []int {
+		var bucket []int
+
+		for _, elem := range twin.Clear() {
+			if parity(elem) == k {
+				bucket = append(bucket, elem)
+			}
+		}
+
+		return bucket
+	}
+
+	for name, newQueue := range testCases {
+		newQueue := newQueue
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			t.Run("GroupBy", func(t *testing.T) {
+				t.Parallel()
+
+				q := newQueue(elems)
+
+				groups := queue.GroupBy[int](q, parity)
+
+				if len(groups) != 2 {
+					t.Fatalf("expected 2 buckets, got %v", groups)
+				}
+
+				for k, bucket := range groups {
+					if expected := filteredDrain(newQueue(elems), k); !reflect.DeepEqual(expected, bucket) {
+						t.Fatalf("expected bucket %d to be %v, got %v", k, expected, bucket)
+					}
+				}
+
+				if size := len(q.ToSlice()); size != len(elems) {
+					t.Fatalf("expected the queue to keep its %d elements, got %d", len(elems), size)
+				}
+			})
+
+			t.Run("GroupByDrain", func(t *testing.T) {
+				t.Parallel()
+
+				q := newQueue(elems)
+
+				groups := queue.GroupByDrain[int](q, parity)
+
+				for k, bucket := range groups {
+					if expected := filteredDrain(newQueue(elems), k); !reflect.DeepEqual(expected, bucket) {
+						t.Fatalf("expected bucket %d to be %v, got %v", k, expected, bucket)
+					}
+				}
+
+				if remaining := q.ToSlice(); len(remaining) != 0 {
+					t.Fatalf("expected the queue to be drained, got %v", remaining)
+				}
+			})
+
+			t.Run("GroupByStream", func(t *testing.T) {
+				t.Parallel()
+
+				q := newQueue(elems)
+
+				var (
+					keys   []int
+					groups = make(map[int][]int)
+				)
+
+				err := queue.GroupByStream[int](q, parity, func(k, elem int) error {
+					if len(keys) == 0 || keys[len(keys)-1] != k {
+						keys = append(keys, k)
+					}
+
+					groups[k] = append(groups[k], elem)
+
+					return nil
+				})
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+
+				// each bucket is passed as a whole.
+				if len(keys) != 2 {
+					t.Fatalf("expected 2 contiguous buckets, got %v", keys)
+				}
+
+				if expected := queue.GroupBy[int](q, parity); !reflect.DeepEqual(expected, groups) {
+					t.Fatalf("expected the streamed buckets to be %v, got %v", expected, groups)
+				}
+			})
+
+			t.Run("Empty", func(t *testing.T) {
+				t.Parallel()
+
+				q := newQueue([]int{})
+
+				if groups := queue.GroupBy[int](q, parity); groups == nil || len(groups) != 0 {
+					t.Fatalf("expected no bucket, got %v", groups)
+				}
+
+				if groups := queue.GroupByDrain[int](q, parity); groups == nil || len(groups) != 0 {
+					t.Fatalf("expected no bucket, got %v", groups)
+				}
+
+				err := queue.GroupByStream[int](q, parity, func(int, int) error {
+					t.Fatal("expected sink not to be called")
+
+					return nil
+				})
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+			})
+
+			t.Run("SingleBucket", func(t *testing.T) {
+				t.Parallel()
+
+				q := newQueue(elems)
+
+				groups := queue.GroupBy[int](q, func(int) string { return "all" })
+
+				if expected := newQueue(elems).Clear(); !reflect.DeepEqual(map[string][]int{"all": expected}, groups) {
+					t.Fatalf("expected a single bucket holding %v, got %v", expected, groups)
+				}
+			})
+		})
+	}
+
+	t.Run("StreamError", func(t *testing.T) {
+		t.Parallel()
+
+		errSink := errors.New("sink error")
+
+		linkedQueue := queue.NewLinked(elems)
+
+		var calls int
+
+		err := queue.GroupByStream[int](linkedQueue, parity, func(int, int) error {
+			calls++
+
+			if calls == 3 {
+				return errSink
+			}
+
+			return nil
+		})
+		if !errors.Is(err, errSink) {
+			t.Fatalf("expected error to be %v, got %v", errSink, err)
+		}
+
+		if calls != 3 {
+			t.Fatalf("expected the stream to abort after 3 elements, got %d", calls)
+		}
+
+		if remaining := linkedQueue.ToSlice(); !reflect.DeepEqual(elems, remaining) {
+			t.Fatalf("expected the queue to be unchanged, got %v", remaining)
+		}
+	})
+}
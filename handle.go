@@ -0,0 +1,224 @@
+package queue
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Ensure Handle implements the Queue interface.
+var _ Queue[any] = (*Handle[any])(nil)
+
+// Handle is a Queue implementation that delegates all operations to an inner
+// queue, which can be replaced at runtime using Swap.
+// It allows switching the implementation of a live queue without changing
+// the code that uses it.
+//
+// Operations are delegated concurrently to the inner queue, while Swap waits
+// for the in-flight operations to complete and blocks new ones until the
+// inner queue is replaced. Thus, every operation is applied either to the
+// old inner queue before it is replaced or to the new one.
+type Handle[T comparable] struct {
+	inner Queue[T]
+
+	// synchronization
+	lock sync.RWMutex
+}
+
+// NewHandle returns a new Handle delegating to the given queue.
+func NewHandle[T comparable](inner Queue[T]) *Handle[T] {
+	return &Handle[T]{
+		inner: inner,
+		lock:  sync.RWMutex{},
+	}
+}
+
+// Swap replaces the inner queue with newInner and returns the replaced queue.
+//
+// If migrate is true, the elements of the replaced queue are moved to
+// newInner, after its current elements, before any other operation is
+// delegated to it, as MoveAll moves them. The migration is all-or-nothing: if
+// newInner rejects one of the elements, the ones already moved are taken back
+// out of it, both queues are left with their previous contents, the inner
+// queue is not replaced and the error is returned. The elements flushed
+// meanwhile by a newInner created using the WithAutoFlush option were
+// delivered, thus they are not restored.
+// If newInner is Bounded, the elements are not migrated unless they fit in its
+// remaining capacity, even if it would accept them by dropping others, as a
+// full Circular queue does.
+// The migration supports the queues supported by MoveAll, it returns the
+// ErrUnsupportedOperation error for the other queues.
+//
+// newInner must not be used directly while it is being swapped in.
+func (h *Handle[T]) Swap(newInner Queue[T], migrate bool) (Queue[T], error) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	old := h.inner
+
+	if migrate {
+		if err := migrateElements(newInner, old); err != nil {
+			return nil, err
+		}
+	}
+
+	h.inner = newInner
+
+	return old, nil
+}
+
+// Inner returns the queue the operations are currently delegated to.
+func (h *Handle[T]) Inner() Queue[T] {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+
+	return h.inner
+}
+
+// Get retrieves and removes the head of the inner queue.
+func (h *Handle[T]) Get() (T, error) {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+
+	return h.inner.Get()
+}
+
+// Offer inserts the element into the inner queue.
+func (h *Handle[T]) Offer(elem T) error {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+
+	return h.inner.Offer(elem)
+}
+
+// Reset sets the inner queue to its initial state.
+func (h *Handle[T]) Reset() {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+
+	h.inner.Reset()
+}
+
+// Contains returns true if the inner queue contains the element.
+func (h *Handle[T]) Contains(elem T) bool {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+
+	return h.inner.Contains(elem)
+}
+
+// Peek retrieves but does not remove the head of the inner queue.
+func (h *Handle[T]) Peek() (T, error) {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+
+	return h.inner.Peek()
+}
+
+// Size returns the number of elements in the inner queue.
+func (h *Handle[T]) Size() int {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+
+	return h.inner.Size()
+}
+
+// IsEmpty returns true if the inner queue is empty.
+func (h *Handle[T]) IsEmpty() bool {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+
+	return h.inner.IsEmpty()
+}
+
+// Iterator returns an iterator over the elements in the inner queue.
+// It removes the elements from the inner queue.
+func (h *Handle[T]) Iterator() <-chan T {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+
+	return h.inner.Iterator()
+}
+
+// Clear removes and returns all elements from the inner queue.
+func (h *Handle[T]) Clear() []T {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+
+	return h.inner.Clear()
+}
+
+// migrateElements moves all the elements of src to the tail of dst, along
+// the locked path of MoveAll. If dst rejects one of them, the elements already
+// moved are taken back out of dst, leaving both queues with their previous
+// contents, and the error is returned. The elements are not migrated unless
+// they fit in the remaining capacity of a Bounded dst, since a full Circular
+// queue would accept them by overwriting its oldest elements.
+// The queues must not be used concurrently during the migration.
+func migrateElements[T comparable](dst, src Queue[T]) error {
+	if dst == src {
+		return nil
+	}
+
+	remaining := unboundedCapacity
+
+	if bounded, ok := dst.(Bounded); ok && bounded.Capacity() != unboundedCapacity {
+		remaining = bounded.Remaining()
+	}
+
+	from, to, unlock, err := lockMovers(src, dst)
+	if err != nil {
+		return err
+	}
+
+	defer unlock()
+
+	elems := from.moveElems()
+
+	if remaining != unboundedCapacity && remaining < len(elems) {
+		return fmt.Errorf("migrate %d elements into %d remaining slots: %w", len(elems), remaining, ErrQueueIsFull)
+	}
+
+	for i, elem := range elems {
+		if err := to.moveAdmit(elem); err != nil {
+			// the elements flushed by dst were delivered, thus they are
+			// not restored to src.
+			removeElements(from, retractElements(to, elems[:i]))
+
+			return fmt.Errorf("migrate element %d of %d: %w", i, len(elems), err)
+		}
+
+		to.moveIn(elem)
+	}
+
+	removeElements(from, len(elems))
+
+	return nil
+}
+
+// retractElements takes the elements inserted into q using moveIn back out of
+// it, the last one first. It returns the number of elements, at the start of
+// elems, which q no longer holds, such as once they were flushed.
+func retractElements[T comparable](q mover[T], elems []T) int {
+	for i := len(elems) - 1; i >= 0; i-- {
+		pos, ok := q.moveTail(elems[i])
+		if !ok {
+			return i + 1
+		}
+
+		q.moveOut(elems[i], pos)
+	}
+
+	return 0
+}
+
+// removeElements removes the first n elements returned by moveElems from q.
+func removeElements[T comparable](q mover[T], n int) {
+	for ; n > 0; n-- {
+		elem, pos, err := q.moveCandidate(nil)
+		if err != nil {
+			return
+		}
+
+		q.moveOut(elem, pos)
+	}
+}
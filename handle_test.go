@@ -0,0 +1,393 @@
+package queue_test
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/adrianbrad/queue"
+)
+
+// understatedQueue is a queue reporting one element less than it holds.
+type understatedQueue struct {
+	*queue.Linked[int]
+}
+
+func (q understatedQueue) Size() int {
+	return q.Linked.Size() - 1
+}
+
+func TestHandle(t *testing.T) {
+	t.Parallel()
+
+	lessInt := func(elem, otherElem int) bool {
+		return elem < otherElem
+	}
+
+	t.Run("Delegates", func(t *testing.T) {
+		t.Parallel()
+
+		handle := queue.NewHandle[int](queue.NewLinked([]int{1, 2}))
+
+		if err := handle.Offer(3); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if !handle.Contains(3) {
+			t.Fatalf("expected handle to contain 3")
+		}
+
+		if handle.Size() != 3 {
+			t.Fatalf("expected size to be %d, got %d", 3, handle.Size())
+		}
+
+		if elem, err := handle.Peek(); err != nil || elem != 1 {
+			t.Fatalf("expected elem to be %d and no error, got %d and %v", 1, elem, err)
+		}
+
+		if elem, err := handle.Get(); err != nil || elem != 1 {
+			t.Fatalf("expected elem to be %d and no error, got %d and %v", 1, elem, err)
+		}
+
+		handle.Reset()
+
+		if elems := handle.Clear(); !reflect.DeepEqual([]int{1, 2}, elems) {
+			t.Fatalf("expected elements to be %v, got %v", []int{1, 2}, elems)
+		}
+
+		if !handle.IsEmpty() {
+			t.Fatalf("expected handle to be empty")
+		}
+
+		handle.Reset()
+
+		iterElems := make([]int, 0, 2)
+
+		for elem := range handle.Iterator() {
+			iterElems = append(iterElems, elem)
+		}
+
+		if !reflect.DeepEqual([]int{1, 2}, iterElems) {
+			t.Fatalf("expected elements to be %v, got %v", []int{1, 2}, iterElems)
+		}
+	})
+
+	t.Run("Swap", func(t *testing.T) {
+		t.Parallel()
+
+		t.Run("WithoutMigration", func(t *testing.T) {
+			t.Parallel()
+
+			linked := queue.NewLinked([]int{1, 2})
+
+			handle := queue.NewHandle[int](linked)
+
+			old, err := handle.Swap(queue.NewBlocking([]int{3}), false)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if old != queue.Queue[int](linked) {
+				t.Fatalf("expected the replaced queue to be returned")
+			}
+
+			if elems := handle.Clear(); !reflect.DeepEqual([]int{3}, elems) {
+				t.Fatalf("expected elements to be %v, got %v", []int{3}, elems)
+			}
+
+			if linked.Size() != 2 {
+				t.Fatalf("expected size to be %d, got %d", 2, linked.Size())
+			}
+		})
+
+		t.Run("WithMigration", func(t *testing.T) {
+			t.Parallel()
+
+			handle := queue.NewHandle[int](queue.NewLinked([]int{1, 2}))
+
+			old, err := handle.Swap(queue.NewBlocking([]int{0}, queue.WithCapacity(3)), true)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if !old.IsEmpty() {
+				t.Fatalf("expected replaced queue to be empty")
+			}
+
+			if elems := handle.Clear(); !reflect.DeepEqual([]int{0, 1, 2}, elems) {
+				t.Fatalf("expected elements to be %v, got %v", []int{0, 1, 2}, elems)
+			}
+		})
+
+		t.Run("MigrationNotEnoughCapacity", func(t *testing.T) {
+			t.Parallel()
+
+			linked := queue.NewLinked([]int{1, 2, 3})
+
+			handle := queue.NewHandle[int](linked)
+
+			blocking := queue.NewBlocking([]int{0}, queue.WithCapacity(3))
+
+			if _, err := handle.Swap(blocking, true); !errors.Is(err, queue.ErrQueueIsFull) {
+				t.Fatalf("expected error to be %v, got %v", queue.ErrQueueIsFull, err)
+			}
+
+			if handle.Inner() != queue.Queue[int](linked) {
+				t.Fatalf("expected the inner queue to not be replaced")
+			}
+
+			if elems := blocking.Clear(); !reflect.DeepEqual([]int{0}, elems) {
+				t.Fatalf("expected elements to be %v, got %v", []int{0}, elems)
+			}
+
+			if elems := handle.Clear(); !reflect.DeepEqual([]int{1, 2, 3}, elems) {
+				t.Fatalf("expected elements to be %v, got %v", []int{1, 2, 3}, elems)
+			}
+		})
+
+		t.Run("MigrationIntoCircular", func(t *testing.T) {
+			t.Parallel()
+
+			handle := queue.NewHandle[int](queue.NewLinked([]int{1, 2, 3}))
+
+			circular := queue.NewCircular([]int{0}, 3)
+
+			// the circular queue would overwrite its elements.
+			if _, err := handle.Swap(circular, true); !errors.Is(err, queue.ErrQueueIsFull) {
+				t.Fatalf("expected error to be %v, got %v", queue.ErrQueueIsFull, err)
+			}
+
+			if elems := circular.Clear(); !reflect.DeepEqual([]int{0}, elems) {
+				t.Fatalf("expected elements to be %v, got %v", []int{0}, elems)
+			}
+
+			if _, err := handle.Swap(circular, true); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if elems := handle.Clear(); !reflect.DeepEqual([]int{1, 2, 3}, elems) {
+				t.Fatalf("expected elements to be %v, got %v", []int{1, 2, 3}, elems)
+			}
+		})
+
+		t.Run("MigrationIntoCircularOverflow", func(t *testing.T) {
+			t.Parallel()
+
+			// the source grows past the size it reported, as if an element
+			// was inserted after the remaining slots were checked.
+			src := understatedQueue{queue.NewLinked([]int{1, 2, 3})}
+
+			handle := queue.NewHandle[int](src)
+
+			circular := queue.NewCircular([]int{0}, 3)
+
+			if _, err := handle.Swap(circular, true); !errors.Is(err, queue.ErrQueueIsFull) {
+				t.Fatalf("expected error to be %v, got %v", queue.ErrQueueIsFull, err)
+			}
+
+			// no element is overwritten.
+			if elems := circular.Clear(); !reflect.DeepEqual([]int{0}, elems) {
+				t.Fatalf("expected elements to be %v, got %v", []int{0}, elems)
+			}
+
+			if elems := src.Clear(); !reflect.DeepEqual([]int{1, 2, 3}, elems) {
+				t.Fatalf("expected elements to be %v, got %v", []int{1, 2, 3}, elems)
+			}
+		})
+
+		t.Run("MigrationRejected", func(t *testing.T) {
+			t.Parallel()
+
+			newDst := func() *queue.Blocking[int] {
+				return queue.NewBlocking(
+					[]int{1, 2},
+					queue.WithIdempotencyWindow(time.Hour, strconv.Itoa),
+				)
+			}
+
+			t.Run("Duplicate", func(t *testing.T) {
+				t.Parallel()
+
+				src := queue.NewLinked([]int{10, 11, 10})
+
+				handle := queue.NewHandle[int](src)
+
+				dst := newDst()
+
+				_, err := handle.Swap(dst, true)
+				if !errors.Is(err, queue.ErrDuplicateWithinWindow) {
+					t.Fatalf("expected error to be %v, got %v", queue.ErrDuplicateWithinWindow, err)
+				}
+
+				if !strings.Contains(err.Error(), "migrate element 2 of 3") {
+					t.Fatalf("expected the error to report the rejected element, got %v", err)
+				}
+
+				if handle.Inner() != queue.Queue[int](src) {
+					t.Fatalf("expected the inner queue to not be replaced")
+				}
+
+				// only the migrated elements are taken back out of dst.
+				if elems := dst.ToSlice(); !reflect.DeepEqual([]int{1, 2}, elems) {
+					t.Fatalf("expected destination elements to be %v, got %v", []int{1, 2}, elems)
+				}
+
+				if elems := src.ToSlice(); !reflect.DeepEqual([]int{10, 11, 10}, elems) {
+					t.Fatalf("expected source elements to be %v, got %v", []int{10, 11, 10}, elems)
+				}
+			})
+
+			t.Run("KeepsDestination", func(t *testing.T) {
+				t.Parallel()
+
+				handle := queue.NewHandle[int](queue.NewLinked([]int{10, 11}))
+
+				// the elements of dst are not offered to it again.
+				if _, err := handle.Swap(newDst(), true); err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+
+				if elems := handle.Clear(); !reflect.DeepEqual([]int{1, 2, 10, 11}, elems) {
+					t.Fatalf("expected elements to be %v, got %v", []int{1, 2, 10, 11}, elems)
+				}
+			})
+
+			t.Run("Priority", func(t *testing.T) {
+				t.Parallel()
+
+				src := queue.NewLinked([]int{3, 0, 9})
+
+				handle := queue.NewHandle[int](src)
+
+				dst := queue.NewPriority(
+					[]int{5, 3},
+					lessInt,
+					queue.WithStableOrder(),
+					queue.WithValidator(func(elem int) error {
+						if elem > 8 {
+							return errors.New("too large")
+						}
+
+						return nil
+					}),
+				)
+
+				if _, err := handle.Swap(dst, true); err == nil {
+					t.Fatal("expected an error")
+				}
+
+				if elems := dst.ToSlice(); !reflect.DeepEqual([]int{3, 5}, elems) {
+					t.Fatalf("expected destination elements to be %v, got %v", []int{3, 5}, elems)
+				}
+
+				if elems := src.ToSlice(); !reflect.DeepEqual([]int{3, 0, 9}, elems) {
+					t.Fatalf("expected source elements to be %v, got %v", []int{3, 0, 9}, elems)
+				}
+			})
+		})
+
+		t.Run("UnsupportedMigration", func(t *testing.T) {
+			t.Parallel()
+
+			handle := queue.NewHandle[int](queue.NewLinked([]int{1}))
+
+			if _, err := handle.Swap(queue.NewFromChannel(make(chan int, 1)), true); !errors.Is(err, queue.ErrUnsupportedOperation) {
+				t.Fatalf("expected error to be %v, got %v", queue.ErrUnsupportedOperation, err)
+			}
+		})
+	})
+
+	t.Run("ConcurrentSwaps", func(t *testing.T) {
+		t.Parallel()
+
+		const (
+			producers        = 4
+			elemsPerProducer = 1000
+			swaps            = 50
+		)
+
+		handle := queue.NewHandle[int](queue.NewLinked[int](nil))
+
+		factories := []func() queue.Queue[int]{
+			func() queue.Queue[int] { return queue.NewBlocking[int](nil) },
+			func() queue.Queue[int] { return queue.NewPriority[int](nil, lessInt) },
+			func() queue.Queue[int] { return queue.NewLinked[int](nil) },
+		}
+
+		var (
+			producersWg sync.WaitGroup
+			consumersWg sync.WaitGroup
+			consumedMu  sync.Mutex
+			consumed    = make(map[int]int)
+		)
+
+		producersWg.Add(producers)
+
+		for p := 0; p < producers; p++ {
+			go func(p int) {
+				defer producersWg.Done()
+
+				for i := 0; i < elemsPerProducer; i++ {
+					if err := handle.Offer(p*elemsPerProducer + i); err != nil {
+						t.Errorf("expected no error, got %v", err)
+					}
+				}
+			}(p)
+		}
+
+		done := make(chan struct{})
+
+		consumersWg.Add(producers)
+
+		for c := 0; c < producers; c++ {
+			go func() {
+				defer consumersWg.Done()
+
+				for {
+					select {
+					case <-done:
+						return
+					default:
+					}
+
+					elem, err := handle.Get()
+					if err != nil {
+						continue
+					}
+
+					consumedMu.Lock()
+					consumed[elem]++
+					consumedMu.Unlock()
+				}
+			}()
+		}
+
+		for i := 0; i < swaps; i++ {
+			if _, err := handle.Swap(factories[i%len(factories)](), true); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+		}
+
+		producersWg.Wait()
+		close(done)
+		consumersWg.Wait()
+
+		for _, elem := range handle.Clear() {
+			consumed[elem]++
+		}
+
+		if len(consumed) != producers*elemsPerProducer {
+			t.Fatalf("expected %d elements, got %d", producers*elemsPerProducer, len(consumed))
+		}
+
+		for elem, count := range consumed {
+			if count != 1 {
+				t.Fatalf("expected elem %d to be retrieved once, got %d", elem, count)
+			}
+		}
+	})
+}
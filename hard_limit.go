@@ -0,0 +1,99 @@
+package queue
+
+import (
+	"fmt"
+)
+
+// A LimitPolicy is the action taken by a queue whose size would exceed the
+// hard limit provided using WithHardLimit.
+type LimitPolicy struct {
+	kind     limitPolicyKind
+	callback func(size int)
+}
+
+type limitPolicyKind int
+
+const (
+	limitReturnError limitPolicyKind = iota
+	limitPanic
+	limitCallback
+)
+
+var (
+	// ReturnError rejects the insertions which would exceed the hard limit
+	// with the ErrQueueIsFull error.
+	ReturnError = LimitPolicy{kind: limitReturnError}
+
+	// Panic panics on the insertions which would exceed the hard limit,
+	// with a message carrying the name and the size of the queue.
+	Panic = LimitPolicy{kind: limitPanic}
+)
+
+// Callback accepts the insertions exceeding the hard limit, calling fn with
+// the size of the queue once per crossing of the limit: fn is called again
+// only once the size has dropped back to the limit and exceeds it again.
+// fn runs while holding the queue lock, thus it must be fast and must not
+// call the queue methods.
+func Callback(fn func(size int)) LimitPolicy {
+	return LimitPolicy{kind: limitCallback, callback: fn}
+}
+
+// hardLimit applies the policy provided using WithHardLimit to the
+// insertions which would exceed the limit. A nil hardLimit limits nothing.
+type hardLimit struct {
+	limit  int
+	policy LimitPolicy
+
+	// name is the name provided using WithName, carried by the panics.
+	name string
+
+	// crossed is set once the callback is called, until the size drops
+	// back to the limit.
+	crossed bool
+}
+
+// newHardLimit returns the hard limit of a queue, or nil if the
+// WithHardLimit option was not provided.
+func newHardLimit(opts options) *hardLimit {
+	if opts.hardLimit == nil {
+		return nil
+	}
+
+	return &hardLimit{
+		limit:  *opts.hardLimit,
+		policy: opts.limitPolicy,
+		name:   opts.name,
+	}
+}
+
+// check applies the policy to the insertion of n elements into a queue
+// holding count elements. It returns the ErrQueueIsFull error if the
+// insertion is rejected.
+func (l *hardLimit) check(count, n int) error {
+	if l == nil {
+		return nil
+	}
+
+	if count <= l.limit {
+		l.crossed = false
+	}
+
+	if count+n <= l.limit {
+		return nil
+	}
+
+	switch l.policy.kind {
+	case limitPanic:
+		panic(fmt.Sprintf("queue %q reached its hard limit of %d elements, holding %d", l.name, l.limit, count))
+	case limitCallback:
+		if !l.crossed {
+			l.crossed = true
+
+			l.policy.callback(count + n)
+		}
+
+		return nil
+	default:
+		return ErrQueueIsFull
+	}
+}
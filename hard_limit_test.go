@@ -0,0 +1,141 @@
+package queue_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/adrianbrad/queue"
+)
+
+func TestWithHardLimit(t *testing.T) {
+	t.Parallel()
+
+	const limit = 3
+
+	testCases := map[string]func(opts ...queue.Option) queue.Queue[int]{
+		"Blocking": func(opts ...queue.Option) queue.Queue[int] {
+			return queue.NewBlocking([]int{}, opts...)
+		},
+		"Linked": func(opts ...queue.Option) queue.Queue[int] {
+			return queue.NewLinked([]int{}, opts...)
+		},
+		"Priority": func(opts ...queue.Option) queue.Queue[int] {
+			return queue.NewPriority([]int{}, func(elem, otherElem int) bool { return elem < otherElem }, opts...)
+		},
+		"Deque": func(opts ...queue.Option) queue.Queue[int] {
+			return queue.NewDeque([]int{}, opts...)
+		},
+	}
+
+	// fill offers the elements up to the limit, which have no effect.
+	fill := func(t *testing.T, q queue.Queue[int]) {
+		t.Helper()
+
+		for i := 1; i <= limit; i++ {
+			if err := q.Offer(i); err != nil {
+				t.Fatalf("expected no error below the limit, got %v", err)
+			}
+		}
+	}
+
+	for impl, newQueue := range testCases {
+		newQueue := newQueue
+
+		t.Run(impl, func(t *testing.T) {
+			t.Parallel()
+
+			t.Run("ReturnError", func(t *testing.T) {
+				t.Parallel()
+
+				q := newQueue(queue.WithHardLimit(limit, queue.ReturnError))
+
+				fill(t, q)
+
+				if err := q.Offer(4); !errors.Is(err, queue.ErrQueueIsFull) {
+					t.Fatalf("expected error to be %v, got %v", queue.ErrQueueIsFull, err)
+				}
+
+				if _, err := q.Get(); err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+
+				if err := q.Offer(4); err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+
+				if size := q.Size(); size != limit {
+					t.Fatalf("expected size to be %d, got %d", limit, size)
+				}
+			})
+
+			t.Run("Panic", func(t *testing.T) {
+				t.Parallel()
+
+				q := newQueue(queue.WithHardLimit(limit, queue.Panic), queue.WithName("orders"))
+
+				fill(t, q)
+
+				defer func() {
+					r := recover()
+
+					msg := fmt.Sprint(r)
+					if !strings.Contains(msg, `"orders"`) || !strings.Contains(msg, "holding 3") {
+						t.Fatalf("expected a panic carrying the name and the size, got %v", r)
+					}
+
+					// the lock is released.
+					if size := q.Size(); size != limit {
+						t.Fatalf("expected size to be %d, got %d", limit, size)
+					}
+				}()
+
+				_ = q.Offer(4)
+			})
+
+			t.Run("Callback", func(t *testing.T) {
+				t.Parallel()
+
+				var crossings []int
+
+				q := newQueue(queue.WithHardLimit(limit, queue.Callback(func(size int) {
+					crossings = append(crossings, size)
+				})))
+
+				fill(t, q)
+
+				if len(crossings) != 0 {
+					t.Fatalf("expected no crossing below the limit, got %v", crossings)
+				}
+
+				// the insertions above the limit are accepted, the callback
+				// is called once.
+				for _, elem := range []int{4, 5} {
+					if err := q.Offer(elem); err != nil {
+						t.Fatalf("expected no error, got %v", err)
+					}
+				}
+
+				if len(crossings) != 1 || crossings[0] != limit+1 {
+					t.Fatalf("expected one crossing at size %d, got %v", limit+1, crossings)
+				}
+
+				// back to the limit, then crossing it again.
+				for i := 0; i < 2; i++ {
+					if _, err := q.Get(); err != nil {
+						t.Fatalf("expected no error, got %v", err)
+					}
+				}
+
+				if err := q.Offer(6); err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+
+				if len(crossings) != 2 || crossings[1] != limit+1 {
+					t.Fatalf("expected a second crossing at size %d, got %v", limit+1, crossings)
+				}
+			})
+		})
+	}
+}
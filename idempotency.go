@@ -0,0 +1,164 @@
+package queue
+
+import (
+	"time"
+)
+
+// idempotencyBuckets is the number of time buckets spanning the window of
+// the WithIdempotencyWindow option.
+const idempotencyBuckets = 8
+
+// idempotencyWindow remembers the keys of the elements admitted into a queue
+// within the window of the WithIdempotencyWindow option. The keys are held in
+// buckets spanning a fraction of the window each, so that the expired keys
+// are dropped a bucket at a time. A nil idempotencyWindow admits every
+// element.
+type idempotencyWindow[T any] struct {
+	clock  Clock
+	window time.Duration
+	key    func(elem T) string
+
+	// width is the duration spanned by each bucket.
+	width time.Duration
+
+	// buckets holds the remembered keys, oldest bucket first.
+	buckets []keyBucket
+}
+
+// keyBucket holds the keys remembered from start, for the width of the
+// window buckets, along with the time they were remembered at.
+type keyBucket struct {
+	start time.Time
+	keys  map[string]time.Time
+}
+
+// newIdempotencyWindow returns the idempotency window of a queue, or nil if
+// the WithIdempotencyWindow option was not provided.
+func newIdempotencyWindow[T any](opts options) *idempotencyWindow[T] {
+	if opts.idempotencyWindow <= 0 {
+		return nil
+	}
+
+	key, ok := opts.idempotencyKey.(func(elem T) string)
+	if !ok {
+		panic("idempotency key type does not match the queue element type")
+	}
+
+	clock := opts.clock
+	if clock == nil {
+		clock = systemClock{}
+	}
+
+	return &idempotencyWindow[T]{
+		clock:  clock,
+		window: opts.idempotencyWindow,
+		key:    key,
+		width:  max(opts.idempotencyWindow/idempotencyBuckets, 1),
+	}
+}
+
+// check returns the ErrDuplicateWithinWindow error if the key of the element
+// was remembered within the window, nil otherwise.
+func (w *idempotencyWindow[T]) check(elem T) error {
+	if w == nil {
+		return nil
+	}
+
+	if w.seen(w.key(elem), w.clock.Now()) {
+		return ErrDuplicateWithinWindow
+	}
+
+	return nil
+}
+
+// checkAll returns the ErrDuplicateWithinWindow error if the key of one of
+// the elements was remembered within the window, or is the key of another
+// one of them, nil otherwise.
+func (w *idempotencyWindow[T]) checkAll(elems []T) error {
+	if w == nil {
+		return nil
+	}
+
+	now := w.clock.Now()
+
+	keys := make(map[string]struct{}, len(elems))
+
+	for _, elem := range elems {
+		k := w.key(elem)
+
+		if _, ok := keys[k]; ok || w.seen(k, now) {
+			return ErrDuplicateWithinWindow
+		}
+
+		keys[k] = struct{}{}
+	}
+
+	return nil
+}
+
+// remember remembers the key of the admitted element, pruning the expired
+// buckets.
+func (w *idempotencyWindow[T]) remember(elem T) {
+	if w == nil {
+		return
+	}
+
+	now := w.clock.Now()
+
+	w.prune(now)
+
+	if len(w.buckets) == 0 || !now.Before(w.buckets[len(w.buckets)-1].start.Add(w.width)) {
+		w.buckets = append(w.buckets, keyBucket{start: now, keys: make(map[string]time.Time)})
+	}
+
+	w.buckets[len(w.buckets)-1].keys[w.key(elem)] = now
+}
+
+// rememberAll remembers the keys of the admitted elements.
+func (w *idempotencyWindow[T]) rememberAll(elems []T) {
+	for _, elem := range elems {
+		w.remember(elem)
+	}
+}
+
+// forget forgets all the remembered keys.
+func (w *idempotencyWindow[T]) forget() {
+	if w == nil {
+		return
+	}
+
+	clear(w.buckets)
+
+	w.buckets = w.buckets[:0]
+}
+
+// seen returns true if the key was remembered within the window.
+func (w *idempotencyWindow[T]) seen(key string, now time.Time) bool {
+	for i := len(w.buckets) - 1; i >= 0; i-- {
+		if at, ok := w.buckets[i].keys[key]; ok && now.Sub(at) < w.window {
+			return true
+		}
+	}
+
+	return false
+}
+
+// prune drops the buckets whose keys all expired.
+func (w *idempotencyWindow[T]) prune(now time.Time) {
+	expired := 0
+
+	for expired < len(w.buckets) && now.Sub(w.buckets[expired].start.Add(w.width)) >= w.window {
+		expired++
+	}
+
+	if expired == 0 {
+		return
+	}
+
+	n := copy(w.buckets, w.buckets[expired:])
+
+	// zero the vacated buckets, so they do not retain the expired keys.
+	clear(w.buckets[n:])
+
+	w.buckets = w.buckets[:n]
+}
@@ -0,0 +1,291 @@
+package queue_test
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/adrianbrad/queue"
+)
+
+// absKey returns the key of the element, shared by its opposite.
+func absKey(elem int) string {
+	return strconv.Itoa(max(elem, -elem))
+}
+
+func TestWithIdempotencyWindow(t *testing.T) {
+	t.Parallel()
+
+	lessInt := func(elem, otherElem int) bool { return elem < otherElem }
+
+	type windowQueue interface {
+		queue.Queue[int]
+		OfferAll(elems ...int) error
+		OfferSome(elems ...int) (int, error)
+		Exchange(elem int) (int, error)
+		ToSlice() []int
+	}
+
+	testCases := map[string]func(elems []int, opts ...queue.Option) windowQueue{
+		"Blocking": func(elems []int, opts ...queue.Option) windowQueue {
+			return queue.NewBlocking(elems, opts...)
+		},
+		"Circular": func(elems []int, opts ...queue.Option) windowQueue {
+			return queue.NewCircular(elems, 8, opts...)
+		},
+		"Linked": func(elems []int, opts ...queue.Option) windowQueue {
+			return queue.NewLinked(elems, opts...)
+		},
+		"Priority": func(elems []int, opts ...queue.Option) windowQueue {
+			return queue.NewPriority(elems, lessInt, opts...)
+		},
+		"PriorityAny": func(elems []int, opts ...queue.Option) windowQueue {
+			return queue.NewPriorityAny(elems, lessInt, opts...)
+		},
+	}
+
+	assertDuplicate := func(t *testing.T, err error) {
+		t.Helper()
+
+		if !errors.Is(err, queue.ErrDuplicateWithinWindow) {
+			t.Fatalf("expected error to be %v, got %v", queue.ErrDuplicateWithinWindow, err)
+		}
+	}
+
+	for impl, newQueue := range testCases {
+		newQueue := newQueue
+
+		t.Run(impl, func(t *testing.T) {
+			t.Parallel()
+
+			t.Run("Window", func(t *testing.T) {
+				t.Parallel()
+
+				clock := newFakeClock()
+
+				q := newQueue([]int{}, queue.WithClock(clock), queue.WithIdempotencyWindow(time.Minute, absKey))
+
+				if err := q.Offer(1); err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+
+				if _, err := q.Get(); err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+
+				// the original was consumed, the retry is still suppressed.
+				assertDuplicate(t, q.Offer(-1))
+
+				clock.Advance(time.Minute - time.Nanosecond)
+
+				assertDuplicate(t, q.Offer(1))
+
+				clock.Advance(time.Nanosecond)
+
+				if err := q.Offer(1); err != nil {
+					t.Fatalf("expected the key to be admitted once expired, got %v", err)
+				}
+
+				if elems := q.ToSlice(); !reflect.DeepEqual([]int{1}, elems) {
+					t.Fatalf("expected elements to be %v, got %v", []int{1}, elems)
+				}
+			})
+
+			t.Run("Bulk", func(t *testing.T) {
+				t.Parallel()
+
+				q := newQueue([]int{}, queue.WithIdempotencyWindow(time.Hour, absKey))
+
+				// the duplicates within the elements reject them all.
+				assertDuplicate(t, q.OfferAll(1, 2, -1))
+
+				if err := q.OfferAll(1, 2); err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+
+				n, err := q.OfferSome(3, 1, 4)
+				assertDuplicate(t, err)
+
+				if n != 1 {
+					t.Fatalf("expected 1 element to be inserted, got %d", n)
+				}
+
+				if _, err := q.Exchange(2); !errors.Is(err, queue.ErrDuplicateWithinWindow) {
+					t.Fatalf("expected error to be %v, got %v", queue.ErrDuplicateWithinWindow, err)
+				}
+
+				if elems := q.ToSlice(); !reflect.DeepEqual([]int{1, 2, 3}, elems) {
+					t.Fatalf("expected elements to be %v, got %v", []int{1, 2, 3}, elems)
+				}
+			})
+
+			t.Run("ClearAndReset", func(t *testing.T) {
+				t.Parallel()
+
+				q := newQueue([]int{5}, queue.WithIdempotencyWindow(time.Hour, absKey))
+
+				if err := q.Offer(1); err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+
+				q.Clear()
+
+				assertDuplicate(t, q.Offer(1))
+
+				q.Reset()
+
+				if err := q.Offer(1); err != nil {
+					t.Fatalf("expected the keys to be forgotten on Reset, got %v", err)
+				}
+
+				// the initial elements are not remembered.
+				if err := q.Offer(5); err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+			})
+
+			t.Run("Move", func(t *testing.T) {
+				t.Parallel()
+
+				src := queue.NewLinked([]int{1, -1})
+				dst := newQueue([]int{}, queue.WithIdempotencyWindow(time.Hour, absKey))
+
+				if _, err := queue.Move[int](src, dst); err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+
+				if _, err := queue.Move[int](src, dst); !errors.Is(err, queue.ErrDuplicateWithinWindow) {
+					t.Fatalf("expected error to be %v, got %v", queue.ErrDuplicateWithinWindow, err)
+				}
+
+				if elems := src.ToSlice(); !reflect.DeepEqual([]int{-1}, elems) {
+					t.Fatalf("expected the duplicate to stay in the source queue, got %v", elems)
+				}
+			})
+		})
+	}
+
+	t.Run("Precedence", func(t *testing.T) {
+		t.Parallel()
+
+		t.Run("Validator", func(t *testing.T) {
+			t.Parallel()
+
+			blockingQueue := queue.NewBlocking(
+				[]int{},
+				queue.WithValidator(validateNonNegative),
+				queue.WithIdempotencyWindow(time.Hour, absKey),
+			)
+
+			if err := blockingQueue.Offer(1); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			// -1 is both invalid and a duplicate of 1.
+			if err := blockingQueue.Offer(-1); !errors.Is(err, queue.ErrInvalidElement) {
+				t.Fatalf("expected error to be %v, got %v", queue.ErrInvalidElement, err)
+			}
+
+			// the invalid element is not remembered.
+			if err := blockingQueue.Offer(-2); !errors.Is(err, queue.ErrInvalidElement) {
+				t.Fatalf("expected error to be %v, got %v", queue.ErrInvalidElement, err)
+			}
+
+			if err := blockingQueue.Offer(2); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+		})
+
+		t.Run("Capacity", func(t *testing.T) {
+			t.Parallel()
+
+			blockingQueue := queue.NewBlocking(
+				[]int{},
+				queue.WithCapacity(1),
+				queue.WithIdempotencyWindow(time.Hour, absKey),
+			)
+
+			if err := blockingQueue.Offer(1); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			// the duplicate is reported before the queue being full.
+			assertDuplicate(t, blockingQueue.Offer(1))
+
+			if err := blockingQueue.Offer(2); !errors.Is(err, queue.ErrQueueIsFull) {
+				t.Fatalf("expected error to be %v, got %v", queue.ErrQueueIsFull, err)
+			}
+
+			if _, err := blockingQueue.Get(); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			// the element rejected for the queue being full is not remembered.
+			if err := blockingQueue.Offer(2); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+		})
+
+		t.Run("WithoutOverwrite", func(t *testing.T) {
+			t.Parallel()
+
+			circularQueue := queue.NewCircular(
+				[]int{},
+				1,
+				queue.WithoutOverwrite(),
+				queue.WithIdempotencyWindow(time.Hour, absKey),
+			)
+
+			if err := circularQueue.Offer(1); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if err := circularQueue.Offer(2); !errors.Is(err, queue.ErrQueueIsFull) {
+				t.Fatalf("expected error to be %v, got %v", queue.ErrQueueIsFull, err)
+			}
+
+			_, _ = circularQueue.Get()
+
+			if err := circularQueue.Offer(2); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+		})
+	})
+
+	t.Run("BlockingOfferVariants", func(t *testing.T) {
+		t.Parallel()
+
+		blockingQueue := queue.NewBlocking([]int{}, queue.WithIdempotencyWindow(time.Hour, absKey))
+
+		if err := blockingQueue.OfferUrgent(1); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		assertDuplicate(t, blockingQueue.OfferWaitErr(1))
+
+		if _, err := blockingQueue.OfferHandle(-1); !errors.Is(err, queue.ErrDuplicateWithinWindow) {
+			t.Fatalf("expected error to be %v, got %v", queue.ErrDuplicateWithinWindow, err)
+		}
+
+		n, err := blockingQueue.OfferAllWait(2, -2)
+		assertDuplicate(t, err)
+
+		if n != 1 {
+			t.Fatalf("expected 1 element to be inserted, got %d", n)
+		}
+	})
+
+	t.Run("TypeMismatch", func(t *testing.T) {
+		t.Parallel()
+
+		defer func() {
+			if recover() == nil {
+				t.Fatal("expected a key func of another type to panic")
+			}
+		}()
+
+		queue.NewLinked([]int{}, queue.WithIdempotencyWindow(time.Hour, func(string) string { return "" }))
+	})
+}
@@ -0,0 +1,87 @@
+package queue
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+// manualClock is a Clock whose time only advances when its now field is set.
+type manualClock struct {
+	now time.Time
+}
+
+func (c *manualClock) Now() time.Time {
+	return c.now
+}
+
+func (c *manualClock) NewTimer(d time.Duration) Timer {
+	return systemClock{}.NewTimer(d)
+}
+
+func TestIdempotencyWindow(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Pruned", func(t *testing.T) {
+		t.Parallel()
+
+		clock := &manualClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+
+		w := newIdempotencyWindow[int](options{
+			clock:             clock,
+			idempotencyWindow: time.Minute,
+			idempotencyKey:    func(elem int) string { return strconv.Itoa(elem) },
+		})
+
+		// a key is remembered every second, for ten windows.
+		for i := 0; i < 600; i++ {
+			w.remember(i)
+
+			clock.now = clock.now.Add(time.Second)
+
+			if len(w.buckets) > idempotencyBuckets+1 {
+				t.Fatalf("expected at most %d buckets, got %d", idempotencyBuckets+1, len(w.buckets))
+			}
+		}
+
+		keys := 0
+
+		for _, b := range w.buckets {
+			keys += len(b.keys)
+		}
+
+		// the keys of the last window, along with the ones of its oldest
+		// bucket, are held.
+		if maxKeys := 60 + 60/idempotencyBuckets + 1; keys > maxKeys {
+			t.Fatalf("expected at most %d keys to be held, got %d", maxKeys, keys)
+		}
+
+		if w.check(599) == nil || w.check(540) != nil {
+			t.Fatal("expected only the keys of the last window to be duplicates")
+		}
+
+		clock.now = clock.now.Add(time.Minute)
+
+		w.remember(-1)
+
+		if len(w.buckets) != 1 || len(w.buckets[0].keys) != 1 {
+			t.Fatalf("expected the expired buckets to be pruned, got %d buckets", len(w.buckets))
+		}
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		t.Parallel()
+
+		var w *idempotencyWindow[int]
+
+		w.remember(1)
+
+		if err := w.check(1); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if newIdempotencyWindow[int](options{idempotencyWindow: 0}) != nil {
+			t.Fatal("expected a non positive window to disable the option")
+		}
+	})
+}
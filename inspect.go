@@ -0,0 +1,70 @@
+package queue
+
+// Cursor is the position of a paginated inspection of a queue, returned by
+// InspectPage. The zero value starts the inspection from the head of the
+// queue.
+type Cursor struct {
+	// index is the position of the next element, relative to the head.
+	index int
+
+	// version is the version of the queue the cursor was issued at,
+	// only relevant if issued is true.
+	version uint64
+	issued  bool
+
+	// resume is the position the queues which cannot index their elements
+	// resume the inspection from, valid while the version is current.
+	resume any
+
+	done bool
+}
+
+// Done returns true if the cursor was returned along with the last page,
+// there are no more elements to inspect.
+func (c Cursor) Done() bool {
+	return c.done
+}
+
+// inspectPage returns the page of at most limit elements starting at the
+// cursor, copied using copyPage, along with the cursor of the next page.
+// It returns the ErrCursorInvalidated error if the queue was mutated since
+// the cursor was issued. A limit lower than 1 is treated as 1.
+//
+// copyPage appends the elements in [from, to) to dst and returns the
+// position the next page is resumed from, if the queue needs one.
+func inspectPage[T any](
+	cursor Cursor,
+	limit int,
+	version uint64,
+	size int,
+	copyPage func(dst []T, from, to int, resume any) ([]T, any),
+) ([]T, Cursor, error) {
+	if cursor.done {
+		return nil, cursor, nil
+	}
+
+	if cursor.issued && cursor.version != version {
+		return nil, cursor, ErrCursorInvalidated
+	}
+
+	from := cursor.index
+	to := min(from+max(limit, 1), size)
+
+	elems := make([]T, 0, max(to-from, 0))
+
+	var resume any
+
+	if from < to {
+		elems, resume = copyPage(elems, from, to, cursor.resume)
+	}
+
+	next := Cursor{
+		index:   to,
+		version: version,
+		issued:  true,
+		resume:  resume,
+		done:    to >= size,
+	}
+
+	return elems, next, nil
+}
@@ -0,0 +1,176 @@
+package queue_test
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/adrianbrad/queue"
+)
+
+// inspectedQueue is implemented by all the queues.
+type inspectedQueue interface {
+	Offer(int) error
+	Get() (int, error)
+	Peek() (int, error)
+	MarshalJSON() ([]byte, error)
+	InspectPage(cursor queue.Cursor, limit int) ([]int, queue.Cursor, error)
+}
+
+func TestInspectPage(t *testing.T) {
+	t.Parallel()
+
+	const size = 23
+
+	lessInt := func(elem, otherElem int) bool { return elem < otherElem }
+
+	testCases := map[string]func(elems []int) inspectedQueue{
+		"Blocking": func(elems []int) inspectedQueue {
+			blockingQueue := queue.NewBlocking(elems[len(elems)/2:])
+
+			// the urgent lane is inspected first.
+			for _, elem := range elems[:len(elems)/2] {
+				_ = blockingQueue.OfferUrgent(elem)
+			}
+
+			return blockingQueue
+		},
+		"Circular": func(elems []int) inspectedQueue {
+			circularQueue := queue.NewCircular([]int{-1, -2, -3}, size)
+
+			// wrap the ring around.
+			for i := 0; i < 3; i++ {
+				_, _ = circularQueue.Get()
+			}
+
+			for _, elem := range elems {
+				_ = circularQueue.Offer(elem)
+			}
+
+			return circularQueue
+		},
+		"Linked": func(elems []int) inspectedQueue {
+			return queue.NewLinked(elems)
+		},
+		"Priority": func(elems []int) inspectedQueue {
+			return queue.NewPriority(elems, lessInt)
+		},
+		"PriorityAny": func(elems []int) inspectedQueue {
+			return queue.NewPriorityAny(elems, lessInt)
+		},
+	}
+
+	elems := make([]int, size)
+
+	for i := range elems {
+		elems[i] = (i * 7) % size
+	}
+
+	for name, newQueue := range testCases {
+		newQueue := newQueue
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			t.Run("StableQueue", func(t *testing.T) {
+				t.Parallel()
+
+				q := newQueue(elems)
+
+				marshaled, err := q.MarshalJSON()
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+
+				var expected []int
+
+				if err := json.Unmarshal(marshaled, &expected); err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+
+				const limit = 5
+
+				var (
+					inspected []int
+					cursor    queue.Cursor
+					pages     int
+				)
+
+				for !cursor.Done() {
+					var page []int
+
+					page, cursor, err = q.InspectPage(cursor, limit)
+					if err != nil {
+						t.Fatalf("expected no error, got %v", err)
+					}
+
+					if cap(page) > limit {
+						t.Fatalf("expected page capacity to be at most %d, got %d", limit, cap(page))
+					}
+
+					// the reads do not invalidate the cursor.
+					_, _ = q.Peek()
+
+					inspected = append(inspected, page...)
+					pages++
+				}
+
+				if !reflect.DeepEqual(expected, inspected) {
+					t.Fatalf("expected inspected elements to be %v, got %v", expected, inspected)
+				}
+
+				if expectedPages := (size + limit - 1) / limit; pages != expectedPages {
+					t.Fatalf("expected %d pages, got %d", expectedPages, pages)
+				}
+
+				page, next, err := q.InspectPage(cursor, limit)
+				if err != nil || len(page) != 0 || !next.Done() {
+					t.Fatalf("expected a done cursor to return no elements, got %v, %v", page, err)
+				}
+			})
+
+			t.Run("MutatedBetweenPages", func(t *testing.T) {
+				t.Parallel()
+
+				mutations := map[string]func(q inspectedQueue){
+					"Offer": func(q inspectedQueue) { _ = q.Offer(size) },
+					"Get":   func(q inspectedQueue) { _, _ = q.Get() },
+				}
+
+				for mutation, mutate := range mutations {
+					q := newQueue(elems)
+
+					_, cursor, err := q.InspectPage(queue.Cursor{}, 10)
+					if err != nil {
+						t.Fatalf("expected no error, got %v", err)
+					}
+
+					mutate(q)
+
+					if _, _, err := q.InspectPage(cursor, 10); !errors.Is(err, queue.ErrCursorInvalidated) {
+						t.Fatalf("expected %s to invalidate the cursor, got %v", mutation, err)
+					}
+
+					// restarting the inspection succeeds.
+					if _, _, err := q.InspectPage(queue.Cursor{}, 10); err != nil {
+						t.Fatalf("expected no error, got %v", err)
+					}
+				}
+			})
+
+			t.Run("Empty", func(t *testing.T) {
+				t.Parallel()
+
+				page, cursor, err := newQueue(nil).InspectPage(queue.Cursor{}, 10)
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+
+				if len(page) != 0 || !cursor.Done() {
+					t.Fatalf("expected a single empty page, got %v", page)
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package queue
+
+// drainSeq returns a sequence removing the elements using get as they are
+// iterated, until get returns an error or the consumer stops.
+func drainSeq[T any](get func() (T, error)) func(yield func(T) bool) {
+	return func(yield func(T) bool) {
+		for {
+			elem, err := get()
+			if err != nil || !yield(elem) {
+				return
+			}
+		}
+	}
+}
+
+// sliceSeq returns a sequence over the elements.
+func sliceSeq[T any](elems []T) func(yield func(T) bool) {
+	return func(yield func(T) bool) {
+		for _, elem := range elems {
+			if !yield(elem) {
+				return
+			}
+		}
+	}
+}
+
+// snapshotSeq returns a sequence over the elements returned by snapshot,
+// which is called once the iteration starts.
+func snapshotSeq[T any](snapshot func() []T) func(yield func(T) bool) {
+	return func(yield func(T) bool) {
+		sliceSeq(snapshot())(yield)
+	}
+}
+
+// bufferedChan returns a closed channel holding at most n elements of seq.
+func bufferedChan[T any](n int, seq func(yield func(T) bool)) <-chan T {
+	ch := make(chan T, n)
+
+	if n > 0 {
+		seq(func(elem T) bool {
+			ch <- elem
+
+			return len(ch) < n
+		})
+	}
+
+	close(ch)
+
+	return ch
+}
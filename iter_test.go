@@ -0,0 +1,211 @@
+package queue_test
+
+import (
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/adrianbrad/queue"
+)
+
+func TestSeq(t *testing.T) {
+	t.Parallel()
+
+	lessInt := func(elem, otherElem int) bool { return elem < otherElem }
+
+	type seqQueue interface {
+		queue.Queue[int]
+		All() func(yield func(int) bool)
+		Drain() func(yield func(int) bool)
+	}
+
+	// the retrieval order of every queue is 1, 2, 3.
+	testCases := map[string]func() seqQueue{
+		"Blocking": func() seqQueue {
+			blockingQueue := queue.NewBlocking([]int{2, 3})
+
+			_ = blockingQueue.OfferUrgent(1)
+
+			return blockingQueue
+		},
+		"Circular": func() seqQueue {
+			return queue.NewCircular([]int{1, 2, 3}, 4)
+		},
+		"Linked": func() seqQueue {
+			return queue.NewLinked([]int{1, 2, 3})
+		},
+		"Priority": func() seqQueue {
+			return queue.NewPriority([]int{3, 1, 2}, lessInt)
+		},
+	}
+
+	collect := func(seq func(yield func(int) bool), body func(elem int) bool) []int {
+		var elems []int
+
+		seq(func(elem int) bool {
+			elems = append(elems, elem)
+
+			return body(elem)
+		})
+
+		return elems
+	}
+
+	for name, newQueue := range testCases {
+		newQueue := newQueue
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			t.Run("All", func(t *testing.T) {
+				t.Parallel()
+
+				q := newQueue()
+
+				// the loop may modify the queue, the changes are not visited.
+				elems := collect(q.All(), func(elem int) bool {
+					if elem == 1 {
+						_ = q.Offer(4)
+					}
+
+					return true
+				})
+
+				if !reflect.DeepEqual([]int{1, 2, 3}, elems) {
+					t.Fatalf("expected elems to be %v, got %v", []int{1, 2, 3}, elems)
+				}
+
+				if size := q.Size(); size != 4 {
+					t.Fatalf("expected size to be 4, got %d", size)
+				}
+			})
+
+			t.Run("AllStop", func(t *testing.T) {
+				t.Parallel()
+
+				q := newQueue()
+
+				elems := collect(q.All(), func(elem int) bool { return elem < 2 })
+
+				if !reflect.DeepEqual([]int{1, 2}, elems) {
+					t.Fatalf("expected elems to be %v, got %v", []int{1, 2}, elems)
+				}
+			})
+
+			t.Run("Drain", func(t *testing.T) {
+				t.Parallel()
+
+				q := newQueue()
+
+				sizes := make([]int, 0, 3)
+
+				elems := collect(q.Drain(), func(int) bool {
+					sizes = append(sizes, q.Size())
+
+					return true
+				})
+
+				if !reflect.DeepEqual([]int{1, 2, 3}, elems) {
+					t.Fatalf("expected elems to be %v, got %v", []int{1, 2, 3}, elems)
+				}
+
+				// the elements are removed as they are iterated.
+				if !reflect.DeepEqual([]int{2, 1, 0}, sizes) {
+					t.Fatalf("expected sizes to be %v, got %v", []int{2, 1, 0}, sizes)
+				}
+			})
+
+			t.Run("DrainStop", func(t *testing.T) {
+				t.Parallel()
+
+				q := newQueue()
+
+				elems := collect(q.Drain(), func(elem int) bool { return elem < 2 })
+
+				if !reflect.DeepEqual([]int{1, 2}, elems) {
+					t.Fatalf("expected elems to be %v, got %v", []int{1, 2}, elems)
+				}
+
+				if elem, err := q.Get(); err != nil || elem != 3 {
+					t.Fatalf("expected to get 3, got %d, %v", elem, err)
+				}
+
+				// the sequence can be iterated again.
+				_ = q.Offer(4)
+
+				if elems := collect(q.Drain(), func(int) bool { return true }); !reflect.DeepEqual([]int{4}, elems) {
+					t.Fatalf("expected elems to be %v, got %v", []int{4}, elems)
+				}
+			})
+
+			t.Run("Iterator", func(t *testing.T) {
+				t.Parallel()
+
+				q := newQueue()
+
+				var elems []int
+
+				for elem := range q.Iterator() {
+					elems = append(elems, elem)
+				}
+
+				if !reflect.DeepEqual([]int{1, 2, 3}, elems) {
+					t.Fatalf("expected elems to be %v, got %v", []int{1, 2, 3}, elems)
+				}
+
+				// the channel of an empty queue is closed.
+				if _, ok := <-q.Iterator(); ok {
+					t.Fatal("expected the channel to be closed")
+				}
+			})
+		})
+	}
+
+	t.Run("ConcurrentDrain", func(t *testing.T) {
+		t.Parallel()
+
+		const (
+			elems     = 1000
+			consumers = 4
+		)
+
+		values := make([]int, elems)
+
+		for i := range values {
+			values[i] = i
+		}
+
+		blockingQueue := queue.NewBlocking(values)
+
+		var (
+			wg      sync.WaitGroup
+			mu      sync.Mutex
+			drained []int
+		)
+
+		wg.Add(consumers)
+
+		for i := 0; i < consumers; i++ {
+			go func() {
+				defer wg.Done()
+
+				blockingQueue.Drain()(func(elem int) bool {
+					mu.Lock()
+					drained = append(drained, elem)
+					mu.Unlock()
+
+					return true
+				})
+			}()
+		}
+
+		wg.Wait()
+
+		sort.Ints(drained)
+
+		if !reflect.DeepEqual(values, drained) {
+			t.Fatalf("expected each element to be drained once, got %d elements", len(drained))
+		}
+	})
+}
@@ -0,0 +1,554 @@
+package queue
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// journalBacklog is the size, in bytes, of the records waiting to be written
+// to the journal writer past which the mutating operations wait for them to
+// be written.
+const journalBacklog = 1 << 20
+
+// ErrJournalDiverged is wrapped by the *JournalDivergenceError errors.
+var ErrJournalDiverged = errors.New("replayed queue diverged from the journal")
+
+// A JournalOp is the kind of a journal record.
+type JournalOp uint8
+
+// The journal record kinds.
+const (
+	// JournalOffer records an element inserted as Offer inserts it.
+	JournalOffer JournalOp = iota + 1
+	// JournalOfferUrgent records an element inserted to the urgent lane.
+	JournalOfferUrgent
+	// JournalGet records the removal of the element Get would return.
+	JournalGet
+	// JournalRemove records the removal of another element, such as by
+	// ElementHandle.Cancel or MoveMatching.
+	JournalRemove
+	// JournalRequeue records an element inserted back to the head of a
+	// Blocking queue, such as by ConsumeBatches.
+	JournalRequeue
+	// JournalClear records the removal of all the elements.
+	JournalClear
+	// JournalReset records a reset of the queue to its initial elements.
+	JournalReset
+	// JournalExchange records an element inserted by the Exchange method of
+	// a Priority queue, in place of its head.
+	JournalExchange
+	// JournalUpdate records an element inserted by the Update or the
+	// OfferBounded method of a Priority queue, in place of another element.
+	JournalUpdate
+)
+
+// String returns the name of the record kind.
+func (op JournalOp) String() string {
+	switch op {
+	case JournalOffer:
+		return "Offer"
+	case JournalOfferUrgent:
+		return "OfferUrgent"
+	case JournalGet:
+		return "Get"
+	case JournalRemove:
+		return "Remove"
+	case JournalRequeue:
+		return "Requeue"
+	case JournalClear:
+		return "Clear"
+	case JournalReset:
+		return "Reset"
+	case JournalExchange:
+		return "Exchange"
+	case JournalUpdate:
+		return "Update"
+	default:
+		return fmt.Sprintf("JournalOp(%d)", uint8(op))
+	}
+}
+
+// positioned reports whether the records of the kind hold the position of
+// their element.
+func (op JournalOp) positioned() bool {
+	return op == JournalRemove || op == JournalUpdate
+}
+
+// JournalCodec holds the functions encoding and decoding the elements
+// of the journal records. A nil function falls back to encoding/json.
+type JournalCodec[T any] struct {
+	Encode func(elem T) ([]byte, error)
+	Decode func(data []byte) (T, error)
+}
+
+// encode encodes the element, falling back to encoding/json.
+func (c JournalCodec[T]) encode(elem T) ([]byte, error) {
+	if c.Encode == nil {
+		return json.Marshal(elem)
+	}
+
+	return c.Encode(elem)
+}
+
+// decode decodes the element, falling back to encoding/json.
+func (c JournalCodec[T]) decode(data []byte) (elem T, _ error) {
+	if c.Decode == nil {
+		return elem, json.Unmarshal(data, &elem)
+	}
+
+	return c.Decode(data)
+}
+
+// JournalDivergenceError is returned by Replay when the replayed queue does
+// not behave as the journaled queue did.
+type JournalDivergenceError struct {
+	// Record is the index of the diverging record, starting at 0.
+	Record int
+
+	// Op and Time are the kind and the time of the diverging record.
+	Op   JournalOp
+	Time time.Time
+
+	// Reason describes the divergence.
+	Reason string
+}
+
+// Error returns the error message.
+func (e *JournalDivergenceError) Error() string {
+	return fmt.Sprintf("%v: record %d (%v at %v): %s",
+		ErrJournalDiverged, e.Record, e.Op, e.Time.Format(time.RFC3339Nano), e.Reason)
+}
+
+// Unwrap returns the ErrJournalDiverged error.
+func (e *JournalDivergenceError) Unwrap() error {
+	return ErrJournalDiverged
+}
+
+// journalConfig holds the arguments of WithJournal.
+type journalConfig[T any] struct {
+	w     io.Writer
+	codec JournalCodec[T]
+}
+
+// journal appends the records of the mutating operations of a queue to its
+// writer, from a goroutine started once records are pending and exiting
+// once they are all written, so that the queue lock is not held while
+// writing. A nil journal records nothing. The records are appended while
+// holding the queue lock, in the order of the operations.
+type journal[T any] struct {
+	w     io.Writer
+	codec JournalCodec[T]
+	clock Clock
+
+	lock sync.Mutex
+	// cond is signalled once pending shrinks or the writer goroutine exits.
+	cond *sync.Cond
+
+	// pending holds the encoded records waiting to be written, spare is the
+	// buffer the writer goroutine last wrote, reused as the next pending.
+	pending []byte
+	spare   []byte
+
+	// writing is set while the writer goroutine runs.
+	writing bool
+
+	// err is the first error returned by the codec or by the writer, after
+	// which the journal records nothing.
+	err error
+}
+
+// newJournal returns the journal provided using WithJournal, or nil if none
+// was provided.
+func newJournal[T any](opts options) *journal[T] {
+	if opts.journal == nil {
+		return nil
+	}
+
+	cfg, ok := opts.journal.(journalConfig[T])
+	if !ok {
+		panic("journal codec type does not match the queue element type")
+	}
+
+	clock := opts.clock
+	if clock == nil {
+		clock = systemClock{}
+	}
+
+	j := &journal[T]{
+		w:     cfg.w,
+		codec: cfg.codec,
+		clock: clock,
+	}
+
+	j.cond = sync.NewCond(&j.lock)
+
+	return j
+}
+
+// record appends a record of an operation inserting or removing the element,
+// size being the size of the queue after the operation.
+func (j *journal[T]) record(op JournalOp, elem T, size int) {
+	if j == nil {
+		return
+	}
+
+	j.appendRecord(op, elem, true, 0, size)
+}
+
+// recordRemove appends a JournalRemove record of the element removed at the
+// given position, as examined by MoveMatching.
+func (j *journal[T]) recordRemove(elem T, pos, size int) {
+	if j == nil {
+		return
+	}
+
+	j.appendRecord(JournalRemove, elem, true, pos, size)
+}
+
+// recordUpdate appends a JournalUpdate record of the element inserted in
+// place of the element at the given position.
+func (j *journal[T]) recordUpdate(elem T, pos, size int) {
+	if j == nil {
+		return
+	}
+
+	j.appendRecord(JournalUpdate, elem, true, pos, size)
+}
+
+// recordOp appends a record of an operation involving no single element,
+// such as JournalClear and JournalReset.
+func (j *journal[T]) recordOp(op JournalOp, size int) {
+	if j == nil {
+		return
+	}
+
+	var zero T
+
+	j.appendRecord(op, zero, false, 0, size)
+}
+
+// appendRecord encodes the record into the pending records, waiting for the
+// writer goroutine if the backlog is full.
+func (j *journal[T]) appendRecord(op JournalOp, elem T, hasElem bool, pos, size int) {
+	now := j.clock.Now()
+
+	j.lock.Lock()
+	defer j.lock.Unlock()
+
+	for len(j.pending) >= journalBacklog && j.err == nil {
+		j.cond.Wait()
+	}
+
+	if j.err != nil {
+		return
+	}
+
+	var data []byte
+
+	if hasElem {
+		var err error
+
+		if data, err = j.codec.encode(elem); err != nil {
+			j.err = fmt.Errorf("encode journal element: %w", err)
+
+			return
+		}
+	}
+
+	var header [1 + 3*binary.MaxVarintLen64]byte
+
+	header[0] = byte(op)
+
+	n := 1
+	n += binary.PutVarint(header[n:], now.UnixNano())
+	n += binary.PutUvarint(header[n:], uint64(size))
+
+	if op.positioned() {
+		n += binary.PutUvarint(header[n:], uint64(pos))
+	}
+
+	j.pending = binary.AppendUvarint(j.pending, uint64(n+len(data)))
+	j.pending = append(j.pending, header[:n]...)
+	j.pending = append(j.pending, data...)
+
+	if !j.writing {
+		j.writing = true
+
+		go j.write()
+	}
+}
+
+// write writes the pending records until there are none left.
+func (j *journal[T]) write() {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+
+	for len(j.pending) > 0 && j.err == nil {
+		batch := j.pending
+		j.pending = j.spare[:0]
+
+		j.cond.Broadcast()
+
+		j.lock.Unlock()
+
+		_, err := j.w.Write(batch)
+
+		j.lock.Lock()
+
+		j.spare = batch[:0]
+
+		if err != nil {
+			j.err = fmt.Errorf("write journal: %w", err)
+		}
+	}
+
+	j.writing = false
+
+	j.cond.Broadcast()
+}
+
+// flush waits until the pending records are written.
+func (j *journal[T]) flush() {
+	if j == nil {
+		return
+	}
+
+	j.lock.Lock()
+	defer j.lock.Unlock()
+
+	for j.writing {
+		j.cond.Wait()
+	}
+}
+
+// journalRecord is a decoded journal record.
+type journalRecord[T any] struct {
+	op   JournalOp
+	time time.Time
+	size int
+	pos  int
+	elem T
+}
+
+// readJournalRecord reads the next record from r, returning io.EOF if there
+// are no more records.
+func readJournalRecord[T any](r *bufio.Reader, codec JournalCodec[T]) (rec journalRecord[T], _ error) {
+	length, err := binary.ReadUvarint(r)
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return rec, io.EOF
+		}
+
+		return rec, err
+	}
+
+	payload := make([]byte, length)
+
+	if _, err := io.ReadFull(r, payload); err != nil {
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+
+		return rec, err
+	}
+
+	if len(payload) == 0 {
+		return rec, errors.New("empty record")
+	}
+
+	rec.op = JournalOp(payload[0])
+
+	if rec.op < JournalOffer || rec.op > JournalUpdate {
+		return rec, fmt.Errorf("unknown operation %d", payload[0])
+	}
+
+	rest := payload[1:]
+
+	nanos, n := binary.Varint(rest)
+	if n <= 0 {
+		return rec, errors.New("invalid timestamp")
+	}
+
+	rest = rest[n:]
+
+	rec.time = time.Unix(0, nanos)
+
+	size, n := binary.Uvarint(rest)
+	if n <= 0 {
+		return rec, errors.New("invalid size")
+	}
+
+	rest = rest[n:]
+
+	rec.size = int(size)
+
+	if rec.op.positioned() {
+		pos, n := binary.Uvarint(rest)
+		if n <= 0 {
+			return rec, errors.New("invalid position")
+		}
+
+		rest = rest[n:]
+
+		rec.pos = int(pos)
+	}
+
+	if rec.op == JournalClear || rec.op == JournalReset {
+		return rec, nil
+	}
+
+	if rec.elem, err = codec.decode(rest); err != nil {
+		return rec, fmt.Errorf("decode element: %w", err)
+	}
+
+	return rec, nil
+}
+
+// Ensure the Blocking queue implements the requeuer interface.
+var _ requeuer[int] = (*Blocking[int])(nil)
+
+// requeuer is implemented by the queues journaling JournalRequeue records.
+type requeuer[T comparable] interface {
+	// replayRequeue inserts the element to the head of the queue.
+	replayRequeue(elem T)
+}
+
+// Ensure the Priority queue implements the updater interface.
+var _ updater[int] = (*Priority[int])(nil)
+
+// updater is implemented by the queues journaling JournalUpdate records.
+type updater[T comparable] interface {
+	// replayUpdate replaces the element at the given position, returning
+	// false if there is no such element.
+	replayUpdate(pos int, elem T) bool
+}
+
+// Replay creates a queue using factory and re-applies to it the operations
+// of the journal written by a queue created using the WithJournal option,
+// in order to reproduce the state of that queue. factory must create the
+// queue as the journaled queue was created, with the same initial elements
+// and options.
+//
+// After each record it checks that the replayed queue behaves as the
+// journaled queue did: that the operation succeeds, that the removed
+// elements are equal to the recorded ones and that the queue size matches the
+// recorded size. At the first divergence it returns the replayed queue along
+// with a *JournalDivergenceError, wrapping the ErrJournalDiverged error.
+// If a record cannot be read or decoded, it returns the replayed queue along
+// with an error reporting the index of the record.
+//
+// The JournalOfferUrgent and JournalExchange records are replayed using the
+// OfferUrgent and Exchange methods of the queue, and the JournalRemove records
+// using MoveMatching, a queue lacking them diverges at such records. The
+// JournalUpdate records are only replayed by the Priority queues.
+func Replay[T comparable](r io.Reader, codec JournalCodec[T], factory func() Queue[T]) (Queue[T], error) {
+	q := factory()
+
+	br := bufio.NewReader(r)
+
+	for i := 0; ; i++ {
+		rec, err := readJournalRecord(br, codec)
+		if errors.Is(err, io.EOF) {
+			return q, nil
+		}
+
+		if err != nil {
+			return q, fmt.Errorf("read journal record %d: %w", i, err)
+		}
+
+		if reason := replayRecord(q, rec); reason != "" {
+			return q, &JournalDivergenceError{Record: i, Op: rec.op, Time: rec.time, Reason: reason}
+		}
+
+		if size := exactSize(q); size != rec.size {
+			return q, &JournalDivergenceError{
+				Record: i,
+				Op:     rec.op,
+				Time:   rec.time,
+				Reason: fmt.Sprintf("replayed size %d, recorded %d", size, rec.size),
+			}
+		}
+	}
+}
+
+// replayRecord applies the record to q, returning the reason why q diverged,
+// or an empty string if it did not.
+func replayRecord[T comparable](q Queue[T], rec journalRecord[T]) string {
+	switch rec.op {
+	case JournalOffer:
+		if err := q.Offer(rec.elem); err != nil {
+			return fmt.Sprintf("offer: %v", err)
+		}
+	case JournalOfferUrgent:
+		urgent, ok := q.(interface{ OfferUrgent(elem T) error })
+		if !ok {
+			return fmt.Sprintf("%T does not support OfferUrgent", q)
+		}
+
+		if err := urgent.OfferUrgent(rec.elem); err != nil {
+			return fmt.Sprintf("offer urgent: %v", err)
+		}
+	case JournalGet:
+		elem, err := q.Get()
+		if err != nil {
+			return fmt.Sprintf("get: %v", err)
+		}
+
+		if elem != rec.elem {
+			return fmt.Sprintf("got %v, recorded %v", elem, rec.elem)
+		}
+	case JournalRemove:
+		// the candidates are examined in the order of the recorded positions.
+		examined := 0
+
+		elem, err := MoveMatching[T](q, NewLinked[T](nil), func(T) bool {
+			examined++
+
+			return examined-1 == rec.pos
+		})
+		if err != nil {
+			return fmt.Sprintf("remove at %d: %v", rec.pos, err)
+		}
+
+		if elem != rec.elem {
+			return fmt.Sprintf("removed %v at %d, recorded %v", elem, rec.pos, rec.elem)
+		}
+	case JournalRequeue:
+		requeue, ok := q.(requeuer[T])
+		if !ok {
+			return fmt.Sprintf("%T does not support requeueing", q)
+		}
+
+		requeue.replayRequeue(rec.elem)
+	case JournalClear:
+		q.Clear()
+	case JournalReset:
+		q.Reset()
+	case JournalExchange:
+		exchange, ok := q.(interface{ Exchange(elem T) (T, error) })
+		if !ok {
+			return fmt.Sprintf("%T does not support Exchange", q)
+		}
+
+		if _, err := exchange.Exchange(rec.elem); err != nil {
+			return fmt.Sprintf("exchange: %v", err)
+		}
+	case JournalUpdate:
+		update, ok := q.(updater[T])
+		if !ok {
+			return fmt.Sprintf("%T does not support Update", q)
+		}
+
+		if !update.replayUpdate(rec.pos, rec.elem) {
+			return fmt.Sprintf("update at %d: no such element", rec.pos)
+		}
+	}
+
+	return ""
+}
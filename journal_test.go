@@ -0,0 +1,471 @@
+package queue_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math/rand"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/adrianbrad/queue"
+)
+
+// journaledQueue is implemented by the queues supporting WithJournal.
+type journaledQueue interface {
+	queue.Queue[int]
+	ToSlice() []int
+	SyncJournal()
+}
+
+func TestJournal(t *testing.T) {
+	t.Parallel()
+
+	less := func(elem, otherElem int) bool {
+		return elem < otherElem
+	}
+
+	initial := []int{5, 1, 3}
+
+	// each test case creates the queue with the given options, so that the
+	// replayed queue is created as the journaled one.
+	testCases := map[string]func(opts ...queue.Option) journaledQueue{
+		"Blocking": func(opts ...queue.Option) journaledQueue {
+			return queue.NewBlocking(initial, opts...)
+		},
+		"BlockingBounded": func(opts ...queue.Option) journaledQueue {
+			return queue.NewBlocking(initial, append(opts, queue.WithCapacity(16))...)
+		},
+		"Circular": func(opts ...queue.Option) journaledQueue {
+			return queue.NewCircular(initial, 16, opts...)
+		},
+		"Linked": func(opts ...queue.Option) journaledQueue {
+			return queue.NewLinked(initial, opts...)
+		},
+		"Priority": func(opts ...queue.Option) journaledQueue {
+			return queue.NewPriority(initial, less, opts...)
+		},
+		"PriorityBounded": func(opts ...queue.Option) journaledQueue {
+			return queue.NewPriority(initial, less, append(opts, queue.WithCapacity(16))...)
+		},
+	}
+
+	t.Run("Replay", func(t *testing.T) {
+		t.Parallel()
+
+		for name, newQueue := range testCases {
+			newQueue := newQueue
+
+			t.Run(name, func(t *testing.T) {
+				t.Parallel()
+
+				for seed := int64(0); seed < 10; seed++ {
+					var journal bytes.Buffer
+
+					q := newQueue(queue.WithJournal(&journal, queue.JournalCodec[int]{}))
+
+					runJournaledSession(rand.New(rand.NewSource(seed)), q)
+
+					q.SyncJournal()
+
+					replayed, err := queue.Replay(&journal, queue.JournalCodec[int]{}, func() queue.Queue[int] {
+						return newQueue()
+					})
+					if err != nil {
+						t.Fatalf("seed %d: expected no error, got %v", seed, err)
+					}
+
+					got, want := replayed.(journaledQueue).ToSlice(), q.ToSlice()
+
+					if !slices.Equal(got, want) {
+						t.Fatalf("seed %d: expected the replayed elements %v, got %v", seed, want, got)
+					}
+				}
+			})
+		}
+	})
+
+	t.Run("Requeue", func(t *testing.T) {
+		t.Parallel()
+
+		var journal bytes.Buffer
+
+		blockingQueue := queue.NewBlocking([]int{}, queue.WithJournal(&journal, queue.JournalCodec[int]{}))
+
+		_ = blockingQueue.OfferUrgent(0)
+		_ = blockingQueue.OfferAll(1, 2, 3)
+
+		errConsume := errors.New("consume")
+
+		err := blockingQueue.ConsumeBatches(context.Background(), 1, 2, 0, func([]int) error {
+			return errConsume
+		}, queue.WithRequeueOnError())
+		if !errors.Is(err, errConsume) {
+			t.Fatalf("expected the consume error, got %v", err)
+		}
+
+		blockingQueue.Close()
+
+		replayed, err := queue.Replay(&journal, queue.JournalCodec[int]{}, func() queue.Queue[int] {
+			return queue.NewBlocking([]int{})
+		})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		got, want := replayed.(*queue.Blocking[int]).ToSlice(), blockingQueue.ToSlice()
+
+		if !slices.Equal(got, want) {
+			t.Fatalf("expected the replayed elements %v, got %v", want, got)
+		}
+	})
+
+	t.Run("Divergence", func(t *testing.T) {
+		t.Parallel()
+
+		var journal bytes.Buffer
+
+		linkedQueue := queue.NewLinked([]int{}, queue.WithJournal(&journal, queue.JournalCodec[int]{}))
+
+		_ = linkedQueue.Offer(1)
+		_ = linkedQueue.Offer(2)
+		_, _ = linkedQueue.Get()
+
+		linkedQueue.SyncJournal()
+
+		testCases := map[string]struct {
+			corrupt func(rec *journalRecord)
+			record  int
+			op      queue.JournalOp
+		}{
+			"Element": {
+				corrupt: func(rec *journalRecord) {
+					if rec.op == queue.JournalGet {
+						rec.elem = []byte("2")
+					}
+				},
+				record: 2,
+				op:     queue.JournalGet,
+			},
+			"Size": {
+				corrupt: func(rec *journalRecord) {
+					if rec.op == queue.JournalOffer && rec.size == 2 {
+						rec.size = 3
+					}
+				},
+				record: 1,
+				op:     queue.JournalOffer,
+			},
+		}
+
+		for name, tc := range testCases {
+			tc := tc
+
+			t.Run(name, func(t *testing.T) {
+				t.Parallel()
+
+				corrupted := rewriteJournal(t, journal.Bytes(), tc.corrupt)
+
+				replayed, err := queue.Replay(bytes.NewReader(corrupted), queue.JournalCodec[int]{}, func() queue.Queue[int] {
+					return queue.NewLinked([]int{})
+				})
+
+				var divergence *queue.JournalDivergenceError
+
+				if !errors.As(err, &divergence) || !errors.Is(err, queue.ErrJournalDiverged) {
+					t.Fatalf("expected a divergence error, got %v", err)
+				}
+
+				if divergence.Record != tc.record || divergence.Op != tc.op {
+					t.Fatalf("expected the divergence at record %d (%v), got %d (%v)",
+						tc.record, tc.op, divergence.Record, divergence.Op)
+				}
+
+				if replayed == nil {
+					t.Fatal("expected the replayed queue to be returned")
+				}
+			})
+		}
+	})
+
+	t.Run("Truncated", func(t *testing.T) {
+		t.Parallel()
+
+		var journal bytes.Buffer
+
+		linkedQueue := queue.NewLinked([]int{}, queue.WithJournal(&journal, queue.JournalCodec[int]{}))
+
+		_ = linkedQueue.Offer(1)
+		_ = linkedQueue.Offer(2)
+
+		linkedQueue.SyncJournal()
+
+		truncated := journal.Bytes()[:journal.Len()-1]
+
+		_, err := queue.Replay(bytes.NewReader(truncated), queue.JournalCodec[int]{}, func() queue.Queue[int] {
+			return queue.NewLinked([]int{})
+		})
+
+		if !errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, queue.ErrJournalDiverged) {
+			t.Fatalf("expected an unexpected EOF error, got %v", err)
+		}
+	})
+
+	t.Run("UnsupportedOperation", func(t *testing.T) {
+		t.Parallel()
+
+		var journal bytes.Buffer
+
+		blockingQueue := queue.NewBlocking([]int{}, queue.WithJournal(&journal, queue.JournalCodec[int]{}))
+
+		_ = blockingQueue.OfferUrgent(1)
+
+		blockingQueue.Close()
+
+		_, err := queue.Replay(&journal, queue.JournalCodec[int]{}, func() queue.Queue[int] {
+			return queue.NewCircular([]int{}, 2)
+		})
+
+		var divergence *queue.JournalDivergenceError
+
+		if !errors.As(err, &divergence) || divergence.Op != queue.JournalOfferUrgent {
+			t.Fatalf("expected a divergence at the OfferUrgent record, got %v", err)
+		}
+	})
+
+	t.Run("WriteDoesNotHoldLock", func(t *testing.T) {
+		t.Parallel()
+
+		w := &blockingWriter{release: make(chan struct{})}
+
+		linkedQueue := queue.NewLinked([]int{}, queue.WithJournal(w, queue.JournalCodec[int]{}))
+
+		_ = linkedQueue.Offer(1)
+
+		// the writer is blocked, while the queue remains usable.
+		done := make(chan struct{})
+
+		go func() {
+			defer close(done)
+
+			_ = linkedQueue.Offer(2)
+			_, _ = linkedQueue.Get()
+		}()
+
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("expected the queue not to wait for the journal writer")
+		}
+
+		close(w.release)
+
+		linkedQueue.SyncJournal()
+
+		replayed, err := queue.Replay(bytes.NewReader(w.bytes()), queue.JournalCodec[int]{}, func() queue.Queue[int] {
+			return queue.NewLinked([]int{})
+		})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if got := replayed.(*queue.Linked[int]).ToSlice(); !slices.Equal(got, []int{2}) {
+			t.Fatalf("expected the replayed elements [2], got %v", got)
+		}
+	})
+
+	t.Run("WriteError", func(t *testing.T) {
+		t.Parallel()
+
+		blockingQueue := queue.NewBlocking([]int{}, queue.WithJournal(failingWriter{}, queue.JournalCodec[int]{}))
+
+		for i := 0; i < 10; i++ {
+			if err := blockingQueue.Offer(i); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+		}
+
+		blockingQueue.Close()
+
+		if size := blockingQueue.Size(); size != 10 {
+			t.Fatalf("expected the queue to hold 10 elements, got %d", size)
+		}
+	})
+}
+
+// runJournaledSession runs random operations on the queue, including the
+// operations recorded as urgent insertions, removals, requeues and
+// evictions by the queues supporting them.
+func runJournaledSession(rng *rand.Rand, q journaledQueue) {
+	type (
+		urgentOfferer  interface{ OfferUrgent(elem int) error }
+		exchanger      interface{ Exchange(elem int) (int, error) }
+		bulkGetter     interface{ GetN(n int) []int }
+		bulkOfferer    interface{ OfferAll(elems ...int) error }
+		boundedOfferer interface {
+			OfferBounded(elem int) (queue.OfferOutcome[int], error)
+		}
+		handleOfferer interface {
+			OfferHandle(elem int) (queue.ElementHandle, error)
+		}
+	)
+
+	scratch := queue.NewLinked([]int{})
+
+	var handles []queue.ElementHandle
+
+	for op := 0; op < 300; op++ {
+		elem := rng.Intn(32)
+
+		switch rng.Intn(15) {
+		case 0, 1, 2:
+			_ = q.Offer(elem)
+		case 3, 4:
+			_, _ = q.Get()
+		case 5:
+			if u, ok := q.(urgentOfferer); ok {
+				_ = u.OfferUrgent(elem)
+			}
+		case 6:
+			if e, ok := q.(exchanger); ok {
+				_, _ = e.Exchange(elem)
+			}
+		case 7:
+			if b, ok := q.(bulkGetter); ok {
+				b.GetN(rng.Intn(4))
+			}
+		case 8:
+			if b, ok := q.(bulkOfferer); ok {
+				_ = b.OfferAll(elem, elem+1)
+			}
+		case 9:
+			if h, ok := q.(handleOfferer); ok {
+				if handle, err := h.OfferHandle(elem); err == nil {
+					handles = append(handles, handle)
+				}
+			}
+		case 10:
+			if len(handles) > 0 {
+				i := rng.Intn(len(handles))
+
+				handles[i].Cancel()
+
+				handles = append(handles[:i], handles[i+1:]...)
+			}
+		case 11:
+			_, _ = queue.MoveMatching[int](q, scratch, func(e int) bool { return e%3 == 0 })
+		case 12:
+			if rng.Intn(2) == 0 {
+				_, _ = queue.Move[int](q, scratch)
+			} else {
+				_, _ = queue.Move[int](scratch, q)
+			}
+		case 13:
+			if b, ok := q.(boundedOfferer); ok {
+				_, _ = b.OfferBounded(elem)
+			}
+		case 14:
+			switch rng.Intn(6) {
+			case 0:
+				q.Clear()
+			case 1:
+				q.Reset()
+			case 2:
+				for range q.Iterator() {
+				}
+			}
+		}
+	}
+}
+
+// journalRecord is a journal record, as documented by WithJournal.
+type journalRecord struct {
+	op    queue.JournalOp
+	nanos int64
+	size  uint64
+	pos   uint64
+	elem  []byte
+}
+
+// rewriteJournal decodes the records of the journal, calls rewrite for each
+// of them and encodes them back.
+func rewriteJournal(t *testing.T, journal []byte, rewrite func(rec *journalRecord)) []byte {
+	t.Helper()
+
+	var rewritten []byte
+
+	for len(journal) > 0 {
+		length, n := binary.Uvarint(journal)
+		payload := journal[n : n+int(length)]
+		journal = journal[n+int(length):]
+
+		rec := journalRecord{op: queue.JournalOp(payload[0])}
+
+		payload = payload[1:]
+
+		rec.nanos, n = binary.Varint(payload)
+		payload = payload[n:]
+
+		rec.size, n = binary.Uvarint(payload)
+		payload = payload[n:]
+
+		if rec.op == queue.JournalRemove {
+			rec.pos, n = binary.Uvarint(payload)
+			payload = payload[n:]
+		}
+
+		rec.elem = payload
+
+		rewrite(&rec)
+
+		encoded := []byte{byte(rec.op)}
+		encoded = binary.AppendVarint(encoded, rec.nanos)
+		encoded = binary.AppendUvarint(encoded, rec.size)
+
+		if rec.op == queue.JournalRemove {
+			encoded = binary.AppendUvarint(encoded, rec.pos)
+		}
+
+		encoded = append(encoded, rec.elem...)
+
+		rewritten = binary.AppendUvarint(rewritten, uint64(len(encoded)))
+		rewritten = append(rewritten, encoded...)
+	}
+
+	return rewritten
+}
+
+// blockingWriter blocks the writes until release is closed.
+type blockingWriter struct {
+	release chan struct{}
+
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (w *blockingWriter) Write(p []byte) (int, error) {
+	<-w.release
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.buf.Write(p)
+}
+
+func (w *blockingWriter) bytes() []byte {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.buf.Bytes()
+}
+
+// failingWriter fails every write.
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("write failed")
+}
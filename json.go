@@ -0,0 +1,244 @@
+package queue
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+)
+
+// jsonCodec encodes and decodes the queue elements in the JSON methods.
+type jsonCodec[T any] struct {
+	marshal   func(T) ([]byte, error)
+	unmarshal func([]byte) (T, error)
+
+	// streaming makes the decoded elements be inserted one at a time, as
+	// they are decoded, rather than all at once after decoding them all.
+	streaming bool
+}
+
+// jsonCodecOf returns the codec provided using WithJSONCodec, falling back to
+// encoding/json for the functions which were not provided. Without a codec
+// the WithStrictJSON option makes the marshalling reject the lossy elements.
+func jsonCodecOf[T any](opts options) jsonCodec[T] {
+	var codec jsonCodec[T]
+
+	if opts.jsonCodec != nil {
+		provided, ok := opts.jsonCodec.(jsonCodec[T])
+		if !ok {
+			panic("json codec type does not match the queue element type")
+		}
+
+		codec = provided
+	}
+
+	if codec.marshal == nil {
+		codec.marshal = marshalJSON[T]
+
+		if opts.strictJSON {
+			codec.marshal = strictMarshalJSON[T]()
+		}
+	}
+
+	if codec.unmarshal == nil {
+		codec.unmarshal = unmarshalJSON[T]
+
+		if r := recyclerOf[T](opts); r.acquire != nil {
+			codec.unmarshal = unmarshalRecycledJSON(r)
+		}
+	}
+
+	codec.streaming = opts.streamingJSON
+
+	return codec
+}
+
+// decodeInto reads a JSON array from r and replaces the elements of a queue
+// with its elements. Unless the codec is streaming, the elements are all
+// decoded before replacing the elements of the queue at once using
+// replaceAll, so that the queue is left unchanged if an element cannot be
+// decoded or if the elements cannot all be inserted. Otherwise the elements
+// of the queue are removed using replaceAll once the array is opened, and the
+// decoded elements are inserted one at a time using offer. The elements which
+// are decoded but not inserted are released by the recycler.
+func (c jsonCodec[T]) decodeInto(
+	r io.Reader,
+	rec recycler[T],
+	offer func(T) error,
+	replaceAll func(...T) error,
+) error {
+	if c.streaming {
+		clear := func() error { return replaceAll() }
+
+		return decodeJSONArray(r, c.unmarshal, clear, rec.offerOrDiscard(offer))
+	}
+
+	var staged []T
+
+	stage := func(elem T) error {
+		staged = append(staged, elem)
+
+		return nil
+	}
+
+	if err := decodeJSONArray(r, c.unmarshal, nil, stage); err != nil {
+		rec.discardAll(staged)
+
+		return err
+	}
+
+	if err := replaceAll(staged...); err != nil {
+		rec.discardAll(staged)
+
+		return fmt.Errorf("offer %d elements: %w", len(staged), err)
+	}
+
+	return nil
+}
+
+func marshalJSON[T any](elem T) ([]byte, error) {
+	return json.Marshal(elem)
+}
+
+func unmarshalJSON[T any](data []byte) (elem T, _ error) {
+	err := json.Unmarshal(data, &elem)
+
+	return elem, err
+}
+
+// unmarshalRecycledJSON returns an unmarshal function which decodes the
+// elements into the elements acquired from the recycler, releasing them if
+// the decoding fails.
+func unmarshalRecycledJSON[T any](r recycler[T]) func([]byte) (T, error) {
+	return func(data []byte) (T, error) {
+		elem := r.acquire()
+
+		if err := json.Unmarshal(data, &elem); err != nil {
+			r.discard(elem)
+
+			var zero T
+
+			return zero, err
+		}
+
+		return elem, nil
+	}
+}
+
+// strictMarshalJSON returns a marshal function which rejects, with the
+// ErrLossyJSON error, the non-zero elements encoded as the zero value of T,
+// such as the structs holding only unexported fields, which encode to {}.
+func strictMarshalJSON[T any]() func(T) ([]byte, error) {
+	var zero T
+
+	zeroJSON, zeroErr := json.Marshal(zero)
+
+	return func(elem T) ([]byte, error) {
+		b, err := json.Marshal(elem)
+		if err != nil || zeroErr != nil || !bytes.Equal(b, zeroJSON) {
+			return b, err
+		}
+
+		if !reflect.ValueOf(&elem).Elem().IsZero() {
+			return nil, fmt.Errorf(
+				"%w: non-zero %T encodes as its zero value %s",
+				ErrLossyJSON, elem, zeroJSON,
+			)
+		}
+
+		return b, nil
+	}
+}
+
+// encodeJSONArray writes the elements to w as a JSON array, encoding one
+// element at a time. The output is identical to the json.Marshal output for
+// the elements slice, except that an empty or nil slice is encoded as [].
+// The elements are encoded using the marshal function.
+func encodeJSONArray[T any](w io.Writer, elems []T, marshal func(T) ([]byte, error)) error {
+	bw := bufio.NewWriter(w)
+
+	if err := bw.WriteByte('['); err != nil {
+		return err
+	}
+
+	for i := range elems {
+		if i > 0 {
+			if err := bw.WriteByte(','); err != nil {
+				return err
+			}
+		}
+
+		b, err := marshal(elems[i])
+		if err != nil {
+			return fmt.Errorf("marshal element %d: %w", i, err)
+		}
+
+		if _, err := bw.Write(b); err != nil {
+			return err
+		}
+	}
+
+	if err := bw.WriteByte(']'); err != nil {
+		return err
+	}
+
+	return bw.Flush()
+}
+
+// decodeJSONArray reads a JSON array from r, decoding one element at a time
+// using the unmarshal function and passing it to the offer function. The
+// opened function, if not nil, is called once the start of the array is read.
+// It stops at the first error.
+func decodeJSONArray[T any](
+	r io.Reader,
+	unmarshal func([]byte) (T, error),
+	opened func() error,
+	offer func(T) error,
+) error {
+	dec := json.NewDecoder(r)
+
+	if err := expectJSONDelim(dec, '['); err != nil {
+		return err
+	}
+
+	if opened != nil {
+		if err := opened(); err != nil {
+			return err
+		}
+	}
+
+	for i := 0; dec.More(); i++ {
+		var raw json.RawMessage
+
+		if err := dec.Decode(&raw); err != nil {
+			return fmt.Errorf("unmarshal element %d: %w", i, err)
+		}
+
+		elem, err := unmarshal(raw)
+		if err != nil {
+			return fmt.Errorf("unmarshal element %d: %w", i, err)
+		}
+
+		if err := offer(elem); err != nil {
+			return fmt.Errorf("offer element %d: %w", i, err)
+		}
+	}
+
+	return expectJSONDelim(dec, ']')
+}
+
+// expectJSONDelim reads the next token and verifies it is the given delimiter.
+func expectJSONDelim(dec *json.Decoder, delim json.Delim) error {
+	token, err := dec.Token()
+	if err != nil {
+		return err
+	}
+
+	if token != delim {
+		return fmt.Errorf("%w: expected %v, got %v", errInvalidJSONArray, delim, token)
+	}
+
+	return nil
+}
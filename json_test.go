@@ -0,0 +1,379 @@
+package queue_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/adrianbrad/queue"
+)
+
+var errSecretFields = errors.New("expected 2 secret fields")
+
+// secret holds only unexported fields, encoding/json encodes it as {}.
+type secret struct {
+	id   int
+	name string
+}
+
+func marshalSecret(s secret) ([]byte, error) {
+	return json.Marshal([]any{s.id, s.name})
+}
+
+func unmarshalSecret(data []byte) (secret, error) {
+	var fields []json.RawMessage
+
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return secret{}, err
+	}
+
+	if len(fields) != 2 {
+		return secret{}, errSecretFields
+	}
+
+	var s secret
+
+	if err := json.Unmarshal(fields[0], &s.id); err != nil {
+		return secret{}, err
+	}
+
+	if err := json.Unmarshal(fields[1], &s.name); err != nil {
+		return secret{}, err
+	}
+
+	return s, nil
+}
+
+// jsonQueue is implemented by all the queues.
+type jsonQueue interface {
+	MarshalJSON() ([]byte, error)
+	MarshalJSONTo(w io.Writer) error
+	UnmarshalJSONFrom(r io.Reader) error
+	UnmarshalJSON(data []byte) error
+	Clear() []secret
+	ToSlice() []secret
+	InspectPage(cursor queue.Cursor, limit int) ([]secret, queue.Cursor, error)
+}
+
+func TestJSONCodec(t *testing.T) {
+	t.Parallel()
+
+	lessSecret := func(elem, otherElem secret) bool { return elem.id < otherElem.id }
+
+	testCases := map[string]func(elems []secret, opts ...queue.Option) jsonQueue{
+		"Blocking": func(elems []secret, opts ...queue.Option) jsonQueue {
+			return queue.NewBlocking(elems, opts...)
+		},
+		"Circular": func(elems []secret, opts ...queue.Option) jsonQueue {
+			return queue.NewCircular(elems, 10, opts...)
+		},
+		"Linked": func(elems []secret, opts ...queue.Option) jsonQueue {
+			return queue.NewLinked(elems, opts...)
+		},
+		"Priority": func(elems []secret, opts ...queue.Option) jsonQueue {
+			return queue.NewPriority(elems, lessSecret, opts...)
+		},
+	}
+
+	elems := []secret{{id: 1, name: "a"}, {id: 2, name: "b"}, {id: 3, name: "c"}}
+
+	for name, newQueue := range testCases {
+		newQueue := newQueue
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			t.Run("RoundTrip", func(t *testing.T) {
+				t.Parallel()
+
+				codec := queue.WithJSONCodec(marshalSecret, unmarshalSecret)
+
+				q := newQueue(elems, codec)
+
+				marshaled, err := q.MarshalJSON()
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+
+				var buf bytes.Buffer
+
+				if err := q.MarshalJSONTo(&buf); err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+
+				if !bytes.Equal(marshaled, buf.Bytes()) {
+					t.Fatalf("expected MarshalJSONTo output %s to match %s", buf.Bytes(), marshaled)
+				}
+
+				if expected := `[[1,"a"],[2,"b"],[3,"c"]]`; string(marshaled) != expected {
+					t.Fatalf("expected JSON to be %s, got %s", expected, marshaled)
+				}
+
+				restored := newQueue(nil, codec)
+
+				if err := restored.UnmarshalJSONFrom(&buf); err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+
+				if restoredElems := restored.Clear(); !reflect.DeepEqual(elems, restoredElems) {
+					t.Fatalf("expected restored elements to be %v, got %v", elems, restoredElems)
+				}
+			})
+
+			t.Run("UnmarshalError", func(t *testing.T) {
+				t.Parallel()
+
+				q := newQueue(nil, queue.WithJSONCodec(marshalSecret, unmarshalSecret), queue.WithStreamingJSON())
+
+				if err := q.UnmarshalJSONFrom(bytes.NewBufferString(`[[1,"a"],[2]]`)); err == nil {
+					t.Fatalf("expected an error")
+				}
+
+				// the elements decoded before the error remain in the queue.
+				if restored := q.Clear(); !reflect.DeepEqual(elems[:1], restored) {
+					t.Fatalf("expected elements to be %v, got %v", elems[:1], restored)
+				}
+			})
+
+			t.Run("UnmarshalErrorAtomic", func(t *testing.T) {
+				t.Parallel()
+
+				corrupt := map[string]struct {
+					data  string
+					index int
+				}{
+					"First":  {data: `[[4],[5,"e"],[6,"f"]]`, index: 0},
+					"Middle": {data: `[[4,"d"],[5],[6,"f"]]`, index: 1},
+					"Last":   {data: `[[4,"d"],[5,"e"],[6]]`, index: 2},
+				}
+
+				for position, tc := range corrupt {
+					data, index := tc.data, tc.index
+
+					t.Run(position, func(t *testing.T) {
+						t.Parallel()
+
+						q := newQueue(elems, queue.WithJSONCodec(marshalSecret, unmarshalSecret))
+
+						// the cursor is invalidated by any mutation of the queue.
+						_, cursor, err := q.InspectPage(queue.Cursor{}, 1)
+						if err != nil {
+							t.Fatalf("expected no error, got %v", err)
+						}
+
+						err = q.UnmarshalJSONFrom(bytes.NewBufferString(data))
+						if !errors.Is(err, errSecretFields) {
+							t.Fatalf("expected error to be %v, got %v", errSecretFields, err)
+						}
+
+						if expected := fmt.Sprintf("element %d:", index); !strings.Contains(err.Error(), expected) {
+							t.Fatalf("expected error to contain %q, got %v", expected, err)
+						}
+
+						if err := q.UnmarshalJSON([]byte(data)); !errors.Is(err, errSecretFields) {
+							t.Fatalf("expected error to be %v, got %v", errSecretFields, err)
+						}
+
+						if _, _, err := q.InspectPage(cursor, 1); err != nil {
+							t.Fatalf("expected the queue not to be mutated, got %v", err)
+						}
+
+						if snapshot := q.ToSlice(); !reflect.DeepEqual(elems, snapshot) {
+							t.Fatalf("expected elements to be %v, got %v", elems, snapshot)
+						}
+					})
+				}
+			})
+
+			t.Run("UnmarshalJSON", func(t *testing.T) {
+				t.Parallel()
+
+				q := newQueue(elems[:1], queue.WithJSONCodec(marshalSecret, unmarshalSecret))
+
+				if err := json.Unmarshal([]byte(`[[2,"b"],[3,"c"]]`), q); err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+
+				// the elements of the queue are replaced.
+				if restored := q.Clear(); !reflect.DeepEqual(elems[1:], restored) {
+					t.Fatalf("expected elements to be %v, got %v", elems[1:], restored)
+				}
+			})
+
+			t.Run("Strict", func(t *testing.T) {
+				t.Parallel()
+
+				if _, err := newQueue(elems).MarshalJSON(); err != nil {
+					t.Fatalf("expected the default marshalling to silently succeed, got %v", err)
+				}
+
+				_, err := newQueue(elems, queue.WithStrictJSON()).MarshalJSON()
+				if !errors.Is(err, queue.ErrLossyJSON) {
+					t.Fatalf("expected error to be %v, got %v", queue.ErrLossyJSON, err)
+				}
+
+				// the zero values are not lossy.
+				if _, err := newQueue([]secret{{}}, queue.WithStrictJSON()).MarshalJSON(); err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+
+				// the codec is not checked.
+				strictCodec := []queue.Option{
+					queue.WithStrictJSON(),
+					queue.WithJSONCodec(marshalSecret, unmarshalSecret),
+				}
+
+				if _, err := newQueue(elems, strictCodec...).MarshalJSON(); err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+			})
+		})
+	}
+
+	t.Run("StrictBasicType", func(t *testing.T) {
+		t.Parallel()
+
+		linkedQueue := queue.NewLinked([]int{0, 1, 2}, queue.WithStrictJSON())
+
+		marshaled, err := linkedQueue.MarshalJSON()
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if string(marshaled) != "[0,1,2]" {
+			t.Fatalf("expected JSON to be [0,1,2], got %s", marshaled)
+		}
+	})
+
+	t.Run("MismatchedType", func(t *testing.T) {
+		t.Parallel()
+
+		defer func() {
+			if r := recover(); r == nil {
+				t.Fatalf("expected panic")
+			}
+		}()
+
+		queue.NewLinked([]int{}, queue.WithJSONCodec(marshalSecret, unmarshalSecret))
+	})
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	lessInt := func(elem, otherElem int) bool { return elem < otherElem }
+
+	type roundTripQueue interface {
+		MarshalJSON() ([]byte, error)
+		UnmarshalJSON(data []byte) error
+		ToSlice() []int
+		Get() (int, error)
+		Offer(elem int) error
+	}
+
+	testCases := map[string]func(elems []int) roundTripQueue{
+		"Blocking": func(elems []int) roundTripQueue { return queue.NewBlocking(elems) },
+		"Circular": func(elems []int) roundTripQueue { return queue.NewCircular(elems, 3) },
+		"Linked":   func(elems []int) roundTripQueue { return queue.NewLinked(elems) },
+		"Priority": func(elems []int) roundTripQueue { return queue.NewPriority(elems, lessInt) },
+	}
+
+	roundTrip := func(t *testing.T, q, restored roundTripQueue) {
+		t.Helper()
+
+		data, err := q.MarshalJSON()
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if err := restored.UnmarshalJSON(data); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if elems, restoredElems := q.ToSlice(), restored.ToSlice(); !reflect.DeepEqual(elems, restoredElems) {
+			t.Fatalf("expected restored elements to be %v, got %v", elems, restoredElems)
+		}
+	}
+
+	for name, newQueue := range testCases {
+		newQueue := newQueue
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			t.Run("Empty", func(t *testing.T) {
+				t.Parallel()
+
+				q := newQueue([]int{})
+
+				data, err := q.MarshalJSON()
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+
+				if string(data) != "[]" {
+					t.Fatalf("expected JSON to be [], got %s", data)
+				}
+
+				roundTrip(t, q, newQueue([]int{}))
+			})
+
+			t.Run("Wrapped", func(t *testing.T) {
+				t.Parallel()
+
+				q := newQueue([]int{1, 2, 3})
+
+				// the head of the Circular queue moves to index 1 and its
+				// tail wraps around to index 0.
+				if _, err := q.Get(); err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+
+				if err := q.Offer(0); err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+
+				roundTrip(t, q, newQueue([]int{}))
+			})
+		})
+	}
+
+	t.Run("CircularHeadToTail", func(t *testing.T) {
+		t.Parallel()
+
+		circularQueue := queue.NewCircular([]int{1, 2, 3}, 3)
+
+		_, _ = circularQueue.Get()
+		_ = circularQueue.Offer(4)
+
+		data, err := circularQueue.MarshalJSON()
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if expected := "[2,3,4]"; string(data) != expected {
+			t.Fatalf("expected JSON to be %s, got %s", expected, data)
+		}
+	})
+
+	t.Run("PriorityHeapified", func(t *testing.T) {
+		t.Parallel()
+
+		priorityQueue := queue.NewPriority([]int{}, lessInt)
+
+		if err := priorityQueue.UnmarshalJSON([]byte("[3,1,2]")); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		for _, expected := range []int{1, 2, 3} {
+			if elem, err := priorityQueue.Get(); err != nil || elem != expected {
+				t.Fatalf("expected elem to be %d, got %d (error %v)", expected, elem, err)
+			}
+		}
+	})
+}
@@ -1,11 +1,19 @@
 package queue
 
 import (
-	"sync"
+	"bytes"
+	"context"
+	"io"
+	"time"
+	"unsafe"
 )
 
 var _ Queue[any] = (*Linked[any])(nil)
 
+// maxFreeNodes is the number of released nodes a Linked queue keeps for
+// reuse, bounding the memory retained after a burst of insertions.
+const maxFreeNodes = 1024
+
 // node is an individual element of the linked list.
 type node[T any] struct {
 	value T
@@ -15,30 +23,113 @@ type node[T any] struct {
 // Linked represents a data structure representing a queue that uses a
 // linked list for its internal storage.
 type Linked[T comparable] struct {
-	head *node[T] // first node of the queue.
-	tail *node[T] // last node of the queue.
-	size int      // number of elements in the queue.
+	head      *node[T]  // first node of the queue.
+	tail      *node[T]  // last node of the queue.
+	occupancy occupancy // counts the elements in the queue and enforces its capacity, if the WithCapacity option is provided.
+	// nolint: revive
+	urgentTail *node[T] // last node of the urgent lane, which is a prefix of the list.
+	urgentSize int      // number of elements in the urgent lane.
+	// nolint: revive
+	recent     []T // ring of the most recently offered elements, sized by WithRecentWindow.
+	recentNext int // index of the ring slot written by the next offer.
+	recentSize int // number of ring slots holding elements still mirroring the tail of the list.
+	// nolint: revive
+	flusher *autoFlusher[T] // drains the queue, if the WithAutoFlush option is provided.
+	// nolint: revive
+	initialElements []T          // initial elements with which the queue was created, allowing for a reset to its original state if needed.
+	resetCloner     func(T) T    // clones the initial elements on reset, if provided.
+	codec           jsonCodec[T] // encodes and decodes the elements in the JSON methods.
+	recycler        recycler[T]  // releases the discarded elements, if WithRecycler is provided.
+	validator       validator[T] // checks the inserted elements, if WithValidator is provided.
+	poller          poller[T]    // retries Get in Poll.
+	version         uint64       // incremented by every mutation, invalidating the InspectPage cursors.
+	// nolint: revive
+	reads *readCache[T] // caches the results of the reads, if WithCachedReads is provided.
+	// nolint: revive
+	window *idempotencyWindow[T] // rejects the elements admitted within the idempotency window, if WithIdempotencyWindow is provided.
+	// nolint: revive
+	tracker *callerTracker[T] // records the mutating operations, if the WithCallerTracking option is provided.
 	// nolint: revive
-	initialElements []T // initial elements with which the queue was created, allowing for a reset to its original state if needed.
+	bloom *countingBloom[T] // filters the elements looked up by Contains, if the WithBloomFilter option is provided.
+	// nolint: revive
+	journal  *journal[T] // records the mutating operations, if the WithJournal option is provided.
+	name     string      // the name provided using WithName, carried by the errors.
+	free     *node[T]    // released nodes, linked by next, reused by the insertions while pooling is set.
+	freeSize int         // number of nodes in free, at most maxFreeNodes.
+	pooling  bool        // unset by the WithoutNodePooling option and once a handle is issued.
 	// synchronization
-	lock sync.RWMutex
+	lock profiledRWMutex
 }
 
 // NewLinked creates a new Linked containing the given elements.
-func NewLinked[T comparable](elements []T) *Linked[T] {
+// If the WithCapacity option is provided, the queue is bounded, rejecting the
+// insertions beyond its capacity with the ErrQueueIsFull error, and the
+// initial elements beyond the capacity are discarded.
+func NewLinked[T comparable](elements []T, opts ...Option) *Linked[T] {
+	options := options{
+		capacity: nil,
+	}
+
+	for _, o := range opts {
+		o.apply(&options)
+	}
+
+	recycler := recyclerOf[T](options)
+
+	validator := validatorOf[T](options)
+
+	elements = validator.filter(elements, recycler)
+
+	if options.capacity != nil && len(elements) > *options.capacity {
+		recycler.discardAll(elements[*options.capacity:])
+
+		elements = elements[:*options.capacity]
+	}
+
+	resetCloner := resetClonerOf[T](options)
+
 	queue := &Linked[T]{
 		head:            nil,
 		tail:            nil,
-		size:            0,
-		initialElements: make([]T, len(elements)),
+		occupancy:       newOccupancy(options.capacity),
+		initialElements: initialElementsOf(options, elements, resetCloner),
+		resetCloner:     resetCloner,
+		codec:           jsonCodecOf[T](options),
+		recycler:        recycler,
+		validator:       validator,
+		window:          newIdempotencyWindow[T](options),
+		reads:           newReadCache[T](options),
+		poller:          newPoller[T](options),
+		recent:          make([]T, max(options.recentWindow, 0)),
+		bloom:           newCountingBloom[T](options),
+		name:            options.name,
+		pooling:         !options.noNodePooling,
+		lock: profiledRWMutex{
+			profiler: newContentionProfiler(options.contentionSampleRate),
+		},
 	}
 
-	copy(queue.initialElements, elements)
-
 	for _, element := range elements {
 		_ = queue.offer(element)
 	}
 
+	// the initial elements are not limited.
+	queue.occupancy.limit = newHardLimit(options)
+
+	queue.flusher = newAutoFlusher(options, &queue.lock, queue.clear)
+
+	if queue.flusher != nil {
+		queue.flusher.started(queue.occupancy.count)
+	}
+
+	// the initial elements are not recorded.
+	queue.tracker = newCallerTracker[T](options)
+	queue.journal = newJournal[T](options)
+
+	if checkInvariants {
+		queue.lock.verify = queue.verifyOccupancy
+	}
+
 	return queue
 }
 
@@ -47,32 +138,238 @@ func (lq *Linked[T]) Get() (elem T, _ error) {
 	lq.lock.Lock()
 	defer lq.lock.Unlock()
 
+	elem, err := lq.get()
+
+	return elem, lq.named(lq.tracker.recordResult(elem, err))
+}
+
+// TryGet attempts to remove and return the head of the queue without
+// waiting for the queue lock. If the lock is held by another goroutine it
+// returns false without attempting the removal, otherwise it returns true
+// along with the element and the error Get would have returned.
+// False negatives are expected under contention.
+func (lq *Linked[T]) TryGet() (elem T, acquired bool, _ error) {
+	if !lq.lock.TryLock() {
+		return elem, false, nil
+	}
+
+	defer lq.lock.Unlock()
+
+	elem, err := lq.get()
+
+	return elem, true, lq.named(lq.tracker.recordResult(elem, err))
+}
+
+// get retrieves and removes the head of the queue.
+func (lq *Linked[T]) get() (elem T, _ error) {
 	if lq.isEmpty() {
 		return elem, ErrNoElementsAvailable
 	}
 
-	value := lq.head.value
-	lq.head = lq.head.next
-	lq.size--
+	if lq.urgentSize > 0 {
+		lq.urgentSize--
+
+		if lq.urgentSize == 0 {
+			lq.urgentTail = nil
+		}
+	}
+
+	head := lq.head
+	value := head.value
+	lq.head = head.next
+	lq.bloom.remove(value)
+	lq.occupancy.releaseAdmission(1, 0)
+	lq.journal.record(JournalGet, value, lq.occupancy.count)
+	lq.version++
 
 	if lq.isEmpty() {
 		lq.tail = nil
 	}
 
+	lq.releaseNode(head)
+
 	return value, nil
 }
 
 // Offer inserts the element into the queue.
+// If the element is rejected by the validator provided using WithValidator
+// it returns an InvalidElementError.
 func (lq *Linked[T]) Offer(value T) error {
 	lq.lock.Lock()
 	defer lq.lock.Unlock()
 
-	return lq.offer(value)
+	return lq.named(lq.tracker.recordResult(value, lq.offerValid(value)))
+}
+
+// TryOffer attempts to insert the element to the tail of the queue without
+// waiting for the queue lock. If the lock is held by another goroutine it
+// returns false without attempting the insertion, otherwise it returns true
+// and the error Offer would have returned.
+// False negatives are expected under contention, TryOffer is meant for
+// callers that prefer skipping an operation over waiting for the lock.
+func (lq *Linked[T]) TryOffer(value T) (acquired bool, _ error) {
+	if !lq.lock.TryLock() {
+		return false, nil
+	}
+
+	defer lq.lock.Unlock()
+
+	return true, lq.named(lq.tracker.recordResult(value, lq.offerValid(value)))
+}
+
+// OfferAll inserts all the elements to the tail of the queue, in order.
+// If one of the elements is rejected by the validator provided using
+// WithValidator it returns an InvalidElementError without inserting any of
+// them, and if the elements do not all fit into a bounded queue it returns
+// the ErrQueueIsFull error without inserting any of them.
+func (lq *Linked[T]) OfferAll(values ...T) error {
+	lq.lock.Lock()
+	defer lq.lock.Unlock()
+
+	if err := lq.validator.checkAll(values); err != nil {
+		return lq.named(err)
+	}
+
+	if err := lq.window.checkAll(values); err != nil {
+		return lq.named(err)
+	}
+
+	if !lq.occupancy.fits(len(values)) {
+		return lq.named(ErrQueueIsFull)
+	}
+
+	for _, value := range values {
+		if lq.offerFlushing(value) == nil {
+			lq.window.remember(value)
+		}
+	}
+
+	lq.tracker.recordBulk()
+
+	return nil
+}
+
+// replaceAll replaces the elements of the queue with the given ones, using
+// the same semantics as OfferAll into the emptied queue. If the elements
+// cannot all be inserted the queue is left unchanged. The replaced elements
+// are released by the recycler.
+func (lq *Linked[T]) replaceAll(values ...T) error {
+	lq.lock.Lock()
+	defer lq.lock.Unlock()
+
+	if err := lq.validator.checkAll(values); err != nil {
+		return err
+	}
+
+	if err := lq.window.checkAll(values); err != nil {
+		return err
+	}
+
+	if !lq.occupancy.fits(len(values) - lq.occupancy.count) {
+		return ErrQueueIsFull
+	}
+
+	lq.recycler.discardAll(lq.clear())
+
+	for _, value := range values {
+		if lq.offerFlushing(value) == nil {
+			lq.window.remember(value)
+		}
+	}
+
+	lq.tracker.recordBulk()
+
+	return nil
+}
+
+// OfferSome inserts the elements to the tail of the queue, in order, and
+// returns the number of inserted elements. It stops at the first element
+// which cannot be inserted, returning the error Offer would have returned for
+// it, such as an InvalidElementError if it is rejected by the validator
+// provided using WithValidator or the ErrQueueIsFull error once a bounded
+// queue is full.
+func (lq *Linked[T]) OfferSome(values ...T) (n int, _ error) {
+	lq.lock.Lock()
+	defer lq.lock.Unlock()
+
+	var err error
+
+	for _, value := range values {
+		if err = lq.offerValid(value); err != nil {
+			break
+		}
+
+		n++
+	}
+
+	if n > 0 {
+		lq.tracker.recordBulk()
+	}
+
+	return n, lq.named(err)
+}
+
+// CanOffer returns true if n elements would currently fit into the queue,
+// which is always the case if the queue is unbounded. The result is advisory,
+// since the queue may change before the elements are offered, use OfferAll
+// in order to insert the elements all or nothing.
+func (lq *Linked[T]) CanOffer(n int) bool {
+	lq.lock.RLock()
+	defer lq.lock.RUnlock()
+
+	return lq.occupancy.fits(n)
+}
+
+// OfferHandle inserts the element into the queue and returns a handle
+// identifying the inserted instance, which can be cancelled even if the queue
+// holds equal elements. Cancelling an element takes linear time in the number
+// of queued elements.
+// The handles identify the elements by their node, thus the queue stops
+// reusing its nodes once a handle is issued.
+func (lq *Linked[T]) OfferHandle(value T) (ElementHandle, error) {
+	lq.lock.Lock()
+	defer lq.lock.Unlock()
+
+	lq.stopPooling()
+
+	if err := lq.validator.check(value); err != nil {
+		return ElementHandle{}, lq.named(err)
+	}
+
+	if err := lq.window.check(value); err != nil {
+		return ElementHandle{}, lq.named(err)
+	}
+
+	newNode, err := lq.offerNode(value)
+	if err != nil {
+		return ElementHandle{}, lq.named(err)
+	}
+
+	lq.window.remember(value)
+
+	if lq.flusher != nil {
+		lq.flusher.inserted(lq.occupancy.count)
+	}
+
+	lq.tracker.record(value)
+
+	return ElementHandle{target: linkedNode[T]{queue: lq, node: newNode}}, nil
 }
 
 // offer inserts the element into the queue.
 func (lq *Linked[T]) offer(value T) error {
-	newNode := &node[T]{value: value}
+	_, err := lq.offerNode(value)
+
+	return err
+}
+
+// offerNode inserts the element into the queue and returns its node.
+func (lq *Linked[T]) offerNode(value T) (*node[T], error) {
+	if err := lq.occupancy.admit(1, 0); err != nil {
+		return nil, err
+	}
+
+	newNode := lq.newNode(value)
 
 	if lq.isEmpty() {
 		lq.head = newNode
@@ -81,111 +378,1165 @@ func (lq *Linked[T]) offer(value T) error {
 	}
 
 	lq.tail = newNode
-	lq.size++
+	lq.bloom.add(value)
+	lq.journal.record(JournalOffer, value, lq.occupancy.count)
+	lq.version++
 
-	return nil
+	if len(lq.recent) > 0 {
+		lq.recent[lq.recentNext] = value
+		lq.recentNext = (lq.recentNext + 1) % len(lq.recent)
+		lq.recentSize = min(lq.recentSize+1, len(lq.recent))
+	}
+
+	return newNode, nil
 }
 
-// Reset sets the queue to its initial state.
-func (lq *Linked[T]) Reset() {
+// newNode returns a node holding the value, reusing a released node if
+// there is one.
+func (lq *Linked[T]) newNode(value T) *node[T] {
+	n := lq.free
+	if n == nil {
+		return &node[T]{value: value}
+	}
+
+	lq.free = n.next
+	lq.freeSize--
+
+	n.value = value
+	n.next = nil
+
+	return n
+}
+
+// releaseNode keeps the node, which is no longer linked to the queue, for
+// reuse. Its value is zeroed, so that the node does not retain it.
+func (lq *Linked[T]) releaseNode(n *node[T]) {
+	if !lq.pooling || lq.freeSize >= maxFreeNodes {
+		return
+	}
+
+	var zero T
+
+	n.value = zero
+	n.next = lq.free
+
+	lq.free = n
+	lq.freeSize++
+}
+
+// releaseNodes releases the nodes linked from the given one.
+func (lq *Linked[T]) releaseNodes(n *node[T]) {
+	for n != nil && lq.pooling && lq.freeSize < maxFreeNodes {
+		next := n.next
+
+		lq.releaseNode(n)
+
+		n = next
+	}
+}
+
+// stopPooling makes the queue stop reusing its nodes and drops the released
+// ones, since the handles identify the elements by their node.
+func (lq *Linked[T]) stopPooling() {
+	lq.pooling = false
+	lq.free = nil
+	lq.freeSize = 0
+}
+
+// cancelNode unlinks the node from the queue, if it is queued. The nodes
+// inserted using OfferHandle are never part of the urgent lane.
+func (lq *Linked[T]) cancelNode(target *node[T]) bool {
 	lq.lock.Lock()
 	defer lq.lock.Unlock()
 
-	lq.head = nil
-	lq.tail = nil
-	lq.size = 0
+	var prev *node[T]
 
-	for _, element := range lq.initialElements {
-		_ = lq.offer(element)
+	for n, pos := lq.head, 0; n != nil; prev, n, pos = n, n.next, pos+1 {
+		if n != target {
+			continue
+		}
+
+		if prev == nil {
+			lq.head = n.next
+		} else {
+			prev.next = n.next
+		}
+
+		if n == lq.tail {
+			lq.tail = prev
+		}
+
+		lq.forgetRecent(pos)
+		lq.bloom.remove(n.value)
+		lq.occupancy.releaseAdmission(1, 0)
+		lq.journal.recordRemove(n.value, pos, lq.occupancy.count)
+		lq.version++
+
+		lq.tracker.record(n.value)
+
+		lq.discard(n.value)
+
+		return true
 	}
+
+	return false
 }
 
-// Contains returns true if the queue contains the element.
-func (lq *Linked[T]) Contains(value T) bool {
+// nodeQueued returns true if the node is queued.
+func (lq *Linked[T]) nodeQueued(target *node[T]) bool {
 	lq.lock.RLock()
 	defer lq.lock.RUnlock()
 
-	current := lq.head
-	for current != nil {
-		if current.value == value {
+	for n := lq.head; n != nil; n = n.next {
+		if n == target {
 			return true
 		}
-
-		current = current.next
 	}
 
 	return false
 }
 
-// Peek retrieves but does not remove the head of the queue.
-func (lq *Linked[T]) Peek() (elem T, _ error) {
-	lq.lock.RLock()
-	defer lq.lock.RUnlock()
+// moveLock returns the lock of the queue.
+func (lq *Linked[T]) moveLock() *profiledRWMutex {
+	return &lq.lock
+}
 
+// moveCandidate returns the element to be moved by Move or MoveMatching,
+// along with its position in the list.
+func (lq *Linked[T]) moveCandidate(pred func(T) bool) (value T, pos int, _ error) {
 	if lq.isEmpty() {
-		return elem, ErrNoElementsAvailable
+		return value, 0, ErrNoElementsAvailable
 	}
 
-	return lq.head.value, nil
+	if pred == nil {
+		return lq.head.value, 0, nil
+	}
+
+	for n := lq.head; n != nil; n, pos = n.next, pos+1 {
+		if pred(n.value) {
+			return n.value, pos, nil
+		}
+	}
+
+	return value, 0, ErrNoElementsAvailable
 }
 
-// Size returns the number of elements in the queue.
-func (lq *Linked[T]) Size() int {
-	lq.lock.RLock()
-	defer lq.lock.RUnlock()
+// moveOut unlinks the node at the given position of the list.
+func (lq *Linked[T]) moveOut(value T, pos int) {
+	lq.tracker.record(value)
+
+	if pos == 0 {
+		_, _ = lq.get()
+
+		return
+	}
+
+	lq.forgetRecent(pos)
+
+	prev := lq.head
+
+	for i := 1; i < pos; i++ {
+		prev = prev.next
+	}
+
+	target := prev.next
+
+	prev.next = target.next
+
+	if target == lq.tail {
+		lq.tail = prev
+	}
+
+	// the urgent lane is a prefix of the list, thus the previous node is
+	// part of it as well.
+	if pos < lq.urgentSize {
+		lq.urgentSize--
+
+		if target == lq.urgentTail {
+			lq.urgentTail = prev
+		}
+	}
+
+	lq.bloom.remove(value)
+	lq.occupancy.releaseAdmission(1, 0)
+	lq.journal.recordRemove(value, pos, lq.occupancy.count)
+	lq.version++
 
-	return lq.size
+	lq.releaseNode(target)
 }
 
-// IsEmpty returns true if the queue is empty, false otherwise.
-func (lq *Linked[T]) IsEmpty() bool {
-	lq.lock.RLock()
-	defer lq.lock.RUnlock()
+// moveAdmit admits every valid element which is not a duplicate within the
+// idempotency window, as long as the queue is not full.
+func (lq *Linked[T]) moveAdmit(value T) error {
+	if err := lq.validator.check(value); err != nil {
+		return err
+	}
 
-	return lq.isEmpty()
+	if err := lq.window.check(value); err != nil {
+		return err
+	}
+
+	if !lq.occupancy.fits(1) {
+		return ErrQueueIsFull
+	}
+
+	return nil
 }
 
-// IsEmpty returns true if the queue is empty, false otherwise.
-func (lq *Linked[T]) isEmpty() bool {
-	return lq.size == 0
+// moveIn inserts the element moved into the queue to its tail.
+func (lq *Linked[T]) moveIn(value T) {
+	lq.tracker.record(value)
+
+	_ = lq.offerFlushing(value)
+
+	lq.window.remember(value)
 }
 
-// Iterator returns a channel that will be filled with the elements.
-// It removes the elements from the queue.
-func (lq *Linked[T]) Iterator() <-chan T {
-	ch := make(chan T)
+// moveElems returns a copy of the elements.
+func (lq *Linked[T]) moveElems() []T {
+	return lq.snapshot()
+}
 
-	elems := lq.Clear()
+// moveTail returns the position of the tail of the list, provided it holds
+// value.
+func (lq *Linked[T]) moveTail(value T) (pos int, ok bool) {
+	if lq.isEmpty() || lq.tail.value != value {
+		return 0, false
+	}
 
-	go func() {
-		for _, e := range elems {
-			ch <- e
-		}
+	return lq.occupancy.count - 1, true
+}
+
+// OfferUrgent inserts the element to the tail of the urgent lane of the
+// queue. The elements of the urgent lane are retrieved, in FIFO order, before
+// all the other elements.
+func (lq *Linked[T]) OfferUrgent(value T) error {
+	lq.lock.Lock()
+	defer lq.lock.Unlock()
 
-		close(ch)
-	}()
+	if err := lq.validator.check(value); err != nil {
+		return lq.named(err)
+	}
+
+	if err := lq.window.check(value); err != nil {
+		return lq.named(err)
+	}
+
+	if err := lq.occupancy.admit(1, 0); err != nil {
+		return lq.named(err)
+	}
+
+	lq.window.remember(value)
+
+	newNode := lq.newNode(value)
+
+	if lq.urgentTail != nil {
+		newNode.next = lq.urgentTail.next
+		lq.urgentTail.next = newNode
+	} else {
+		newNode.next = lq.head
+		lq.head = newNode
+	}
+
+	if newNode.next == nil {
+		lq.tail = newNode
+	}
+
+	lq.urgentTail = newNode
+	lq.urgentSize++
+	lq.bloom.add(value)
+	lq.journal.record(JournalOfferUrgent, value, lq.occupancy.count)
+	lq.version++
+
+	if lq.flusher != nil {
+		lq.flusher.inserted(lq.occupancy.count)
+	}
 
-	return ch
+	lq.tracker.record(value)
+
+	return nil
 }
 
-// Clear removes and returns all elements from the queue.
-func (lq *Linked[T]) Clear() []T {
+// Exchange atomically removes the head of the queue and inserts the element
+// to the tail of the queue, returning the removed head.
+// If no element is available it inserts the element and returns the
+// ErrNoElementsAvailable error. If the element is rejected by the validator
+// provided using WithValidator it returns an InvalidElementError, leaving the
+// queue unchanged.
+func (lq *Linked[T]) Exchange(value T) (elem T, _ error) {
 	lq.lock.Lock()
 	defer lq.lock.Unlock()
 
-	elements := make([]T, 0, lq.size)
+	if err := lq.validator.check(value); err != nil {
+		return elem, lq.named(err)
+	}
 
-	current := lq.head
-	for current != nil {
-		elements = append(elements, current.value)
-		next := current.next
-		current = next
+	if err := lq.window.check(value); err != nil {
+		return elem, lq.named(err)
 	}
 
-	// Clear the queue
-	lq.head = nil
-	lq.tail = nil
-	lq.size = 0
+	lq.window.remember(value)
 
-	return elements
+	elem, err := lq.get()
+	if err != nil {
+		_ = lq.offerFlushing(value)
+
+		lq.tracker.record(value)
+
+		return elem, lq.named(err)
+	}
+
+	// the size is unchanged, the auto flusher is not notified.
+	_ = lq.offer(value)
+
+	lq.tracker.record(value)
+
+	return elem, nil
+}
+
+// Rotate atomically moves the head of the queue to its tail, so that the
+// elements can be visited in a round-robin fashion without removing them.
+// The moved element counts as the most recently offered one.
+// If no element is available it returns an ErrNoElementsAvailable error.
+func (lq *Linked[T]) Rotate() error {
+	lq.lock.Lock()
+	defer lq.lock.Unlock()
+
+	elem, err := lq.get()
+	if err != nil {
+		return lq.named(err)
+	}
+
+	return lq.named(lq.tracker.recordResult(elem, lq.offer(elem)))
+}
+
+// offerValid inserts the element into the queue, as offerFlushing does, if
+// it is admitted by the validator and the idempotency window.
+func (lq *Linked[T]) offerValid(value T) error {
+	if err := lq.validator.check(value); err != nil {
+		return err
+	}
+
+	if err := lq.window.check(value); err != nil {
+		return err
+	}
+
+	if err := lq.offerFlushing(value); err != nil {
+		return err
+	}
+
+	lq.window.remember(value)
+
+	return nil
+}
+
+// offerFlushing inserts the element into the queue and notifies the auto
+// flusher, if any.
+func (lq *Linked[T]) offerFlushing(value T) error {
+	if err := lq.offer(value); err != nil {
+		return err
+	}
+
+	if lq.flusher != nil {
+		lq.flusher.inserted(lq.occupancy.count)
+	}
+
+	return nil
+}
+
+// Reset sets the queue to its initial state.
+func (lq *Linked[T]) Reset() {
+	lq.lock.Lock()
+	defer lq.lock.Unlock()
+
+	if lq.recycler.enabled() {
+		for n := lq.head; n != nil; n = n.next {
+			lq.recycler.discard(n.value)
+		}
+	}
+
+	lq.releaseNodes(lq.head)
+
+	lq.head = nil
+	lq.tail = nil
+	lq.urgentTail = nil
+	lq.urgentSize = 0
+	lq.bloom.reset()
+	lq.occupancy.reset(0)
+	lq.version++
+
+	lq.clearRecent()
+
+	lq.window.forget()
+
+	// the initial elements are journaled as part of the reset.
+	journal := lq.journal
+	lq.journal = nil
+
+	for _, element := range lq.initialElements {
+		if lq.resetCloner != nil {
+			element = lq.resetCloner(element)
+		}
+
+		_ = lq.offer(element)
+	}
+
+	lq.journal = journal
+	lq.journal.recordOp(JournalReset, lq.occupancy.count)
+
+	lq.tracker.recordBulk()
+}
+
+// Contains returns true if the queue contains the element.
+// If the WithBloomFilter option is provided, the queue is only scanned for
+// the elements which the filter does not rule out.
+func (lq *Linked[T]) Contains(value T) bool {
+	lq.lock.RLock()
+	defer lq.lock.RUnlock()
+
+	if !lq.bloom.mayContain(value) {
+		return false
+	}
+
+	current := lq.head
+	for current != nil {
+		if current.value == value {
+			return true
+		}
+
+		current = current.next
+	}
+
+	return false
+}
+
+// ContainsRecent returns true if the element is among the n elements nearest
+// to the tail of the queue, which are the most recently offered elements
+// unless the urgent lane is reached.
+// It costs O(n) if n does not exceed the window provided using
+// WithRecentWindow, otherwise it falls back to walking the list in O(size).
+func (lq *Linked[T]) ContainsRecent(n int, elem T) bool {
+	lq.lock.RLock()
+	defer lq.lock.RUnlock()
+
+	n = min(n, lq.occupancy.count)
+
+	// the ring only covers the elements offered to the tail of the queue.
+	if n > lq.recentSize || n > lq.occupancy.count-lq.urgentSize {
+		skip := lq.occupancy.count - n
+
+		for current := lq.head; current != nil; current = current.next {
+			if skip > 0 {
+				skip--
+
+				continue
+			}
+
+			if current.value == elem {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	for i := 1; i <= n; i++ {
+		idx := (lq.recentNext - i + len(lq.recent)) % len(lq.recent)
+
+		if lq.recent[idx] == elem {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Peek retrieves but does not remove the head of the queue.
+func (lq *Linked[T]) Peek() (elem T, _ error) {
+	if lq.reads != nil {
+		h := lq.readHead()
+
+		return h.elem, lq.named(h.err)
+	}
+
+	lq.lock.RLock()
+	defer lq.lock.RUnlock()
+
+	elem, err := lq.peek()
+
+	return elem, lq.named(err)
+}
+
+// TryPeek attempts to retrieve, without removing, the head of the queue
+// without waiting for the queue lock. If the lock is held by a writer it
+// returns false without attempting the retrieval, otherwise it returns true
+// along with the element and the error Peek would have returned.
+// False negatives are expected under contention.
+func (lq *Linked[T]) TryPeek() (elem T, acquired bool, _ error) {
+	if !lq.lock.TryRLock() {
+		return elem, false, nil
+	}
+
+	defer lq.lock.RUnlock()
+
+	elem, err := lq.peek()
+
+	return elem, true, lq.named(err)
+}
+
+// peek retrieves but does not remove the head of the queue.
+func (lq *Linked[T]) peek() (elem T, _ error) {
+	if lq.isEmpty() {
+		return elem, ErrNoElementsAvailable
+	}
+
+	return lq.head.value, nil
+}
+
+// Size returns the number of elements in the queue.
+func (lq *Linked[T]) Size() int {
+	if lq.reads != nil {
+		return lq.readHead().size
+	}
+
+	lq.lock.RLock()
+	defer lq.lock.RUnlock()
+
+	return lq.occupancy.count
+}
+
+// Capacity returns the fixed capacity of the queue, or -1 if the queue was
+// created without the WithCapacity option.
+func (lq *Linked[T]) Capacity() int {
+	return lq.occupancy.capacity
+}
+
+// Remaining returns the number of elements the queue can hold in addition to
+// its current elements, or -1 if the queue is unbounded.
+func (lq *Linked[T]) Remaining() int {
+	lq.lock.RLock()
+	defer lq.lock.RUnlock()
+
+	return lq.occupancy.remaining()
+}
+
+// IsEmpty returns true if the queue is empty, false otherwise.
+func (lq *Linked[T]) IsEmpty() bool {
+	if lq.reads != nil {
+		return lq.readHead().size == 0
+	}
+
+	lq.lock.RLock()
+	defer lq.lock.RUnlock()
+
+	return lq.isEmpty()
+}
+
+// ToSlice returns a copy of the queue elements in FIFO order, starting with
+// the urgent lane, without removing them.
+func (lq *Linked[T]) ToSlice() []T {
+	if lq.reads != nil {
+		e := lq.readElems()
+
+		return e.copyN(len(e.elems))
+	}
+
+	lq.lock.RLock()
+	defer lq.lock.RUnlock()
+
+	return lq.snapshot()
+}
+
+// Each calls fn for the queue elements in FIFO order, starting with the
+// urgent lane, without removing or copying them, until fn returns false.
+// It holds the queue lock for reading, thus fn must not modify the queue.
+func (lq *Linked[T]) Each(fn func(elem T) bool) {
+	lq.lock.RLock()
+	defer lq.lock.RUnlock()
+
+	for current := lq.head; current != nil; current = current.next {
+		if !fn(current.value) {
+			return
+		}
+	}
+}
+
+// PeekN returns a copy of at most n elements from the head of the queue,
+// in FIFO order starting with the urgent lane, without removing them.
+func (lq *Linked[T]) PeekN(n int) []T {
+	if lq.reads != nil {
+		return lq.readElems().copyN(n)
+	}
+
+	lq.lock.RLock()
+	defer lq.lock.RUnlock()
+
+	n = min(max(n, 0), lq.occupancy.count)
+
+	elems, _ := lq.copyRange(make([]T, 0, n), 0, n, nil)
+
+	return elems
+}
+
+// At returns, without removing it, the element at index i in FIFO order.
+// It returns the ErrIndexOutOfRange error if i is negative or not lower than
+// the size of the queue. It walks the list from its head, thus it takes O(i)
+// time.
+func (lq *Linked[T]) At(i int) (elem T, _ error) {
+	lq.lock.RLock()
+	defer lq.lock.RUnlock()
+
+	if i < 0 || i >= lq.occupancy.count {
+		return elem, lq.named(ErrIndexOutOfRange)
+	}
+
+	current := lq.head
+
+	for ; i > 0; i-- {
+		current = current.next
+	}
+
+	return current.value, nil
+}
+
+// InspectPage returns a copy of at most limit elements, starting at the
+// cursor, along with the cursor of the next page, in FIFO order. The zero
+// Cursor starts from the head of the queue, the cursor returned along with the
+// last page is Done. The queue lock is held only while copying the page, the
+// cursor remembers the node the next page starts from.
+//
+// If the queue was mutated since the cursor was issued it returns the
+// ErrCursorInvalidated error, the inspection has to be restarted.
+func (lq *Linked[T]) InspectPage(cursor Cursor, limit int) ([]T, Cursor, error) {
+	lq.lock.RLock()
+	defer lq.lock.RUnlock()
+
+	elems, next, err := inspectPage(cursor, limit, lq.version, lq.occupancy.count, lq.copyRange)
+
+	return elems, next, lq.named(err)
+}
+
+// ContentionProfile returns, for every queue method sampled by the
+// WithContentionProfiling option, the time spent waiting to acquire the
+// queue lock. It returns nil if the option was not provided.
+func (lq *Linked[T]) ContentionProfile() map[string]ContentionStats {
+	return lq.lock.contentionProfile()
+}
+
+// RecentOperations returns the last mutating operations of the queue, oldest
+// first, as recorded by the WithCallerTracking option. It returns nil if the
+// option was not provided.
+func (lq *Linked[T]) RecentOperations() []OpRecord {
+	return lq.tracker.operations()
+}
+
+// MemoryFootprint returns an estimate of the memory retained by the queue,
+// measuring every element using sizeOf, or its shallow size if sizeOf is nil.
+// Every element is held by its own node and the released nodes kept for reuse
+// are reported as wasted slots, the structural bytes accounting for the links
+// between the nodes.
+func (lq *Linked[T]) MemoryFootprint(sizeOf func(T) uintptr) MemoryReport {
+	lq.lock.RLock()
+	defer lq.lock.RUnlock()
+
+	f := newFootprint(sizeOf, unsafe.Sizeof(*lq))
+
+	for n := lq.head; n != nil; n = n.next {
+		f.elements(n.value)
+	}
+
+	nodes := lq.occupancy.count + lq.freeSize
+
+	f.slots(nodes)
+
+	f.overhead(uintptr(nodes) * (unsafe.Sizeof(node[T]{}) - f.slot))
+	f.copies(lq.recent)
+	f.copies(lq.initialElements)
+
+	return f.done()
+}
+
+// SyncJournal waits until the journal records of the operations completed so
+// far are written, if the WithJournal option is provided.
+func (lq *Linked[T]) SyncJournal() {
+	lq.journal.flush()
+}
+
+// Name returns the name provided using WithName, or an empty string if the
+// queue is unnamed.
+func (lq *Linked[T]) Name() string {
+	return lq.name
+}
+
+// named wraps err in a QueueError if the queue is named.
+func (lq *Linked[T]) named(err error) error {
+	return nameError(lq.name, "Linked", err)
+}
+
+// IsEmpty returns true if the queue is empty, false otherwise.
+func (lq *Linked[T]) isEmpty() bool {
+	return lq.head == nil
+}
+
+// All returns a sequence over the queue elements, in FIFO order, without
+// removing them. It is an iter.Seq, thus it can be ranged over:
+//
+//	for elem := range q.All() {
+//		...
+//	}
+//
+// The elements are copied once the iteration starts, so the loop may modify
+// the queue, the changes not being visited.
+func (lq *Linked[T]) All() func(yield func(T) bool) {
+	return snapshotSeq(lq.ToSlice)
+}
+
+// Drain returns a sequence removing the queue elements, in FIFO order, as they
+// are iterated, until the queue is empty or the loop stops. It is an
+// iter.Seq, thus it can be ranged over:
+//
+//	for elem := range q.Drain() {
+//		...
+//	}
+//
+// Each element is removed using Get, so the queue lock is not held while the
+// loop body runs, and stopping the loop early leaves the other elements in
+// the queue.
+func (lq *Linked[T]) Drain() func(yield func(T) bool) {
+	return drainSeq(lq.Get)
+}
+
+// Iterator returns a channel that will be filled with the elements.
+// It removes the elements from the queue.
+func (lq *Linked[T]) Iterator() <-chan T {
+	elems := lq.Clear()
+
+	// use a buffered channel, so that no goroutine is left blocked if the
+	// channel is not drained.
+	return bufferedChan(len(elems), sliceSeq(elems))
+}
+
+// IteratorsN removes all the elements from the queue at once and distributes
+// them, in FIFO order, among k closed and buffered channels, as specified by
+// the partition mode, so that k workers can consume disjoint shares of the
+// queue without contending on it. Some channels are empty if k is greater than
+// the number of elements.
+// It returns the ErrInvalidPartitions error if k is lower than 1, leaving the
+// queue unchanged.
+func (lq *Linked[T]) IteratorsN(k int, mode Partition) ([]<-chan T, error) {
+	if k < 1 {
+		return nil, lq.named(ErrInvalidPartitions)
+	}
+
+	lq.lock.Lock()
+	elems := lq.clear()
+	lq.lock.Unlock()
+
+	lq.tracker.recordBulk()
+
+	return partition(elems, k, mode), nil
+}
+
+// Poll removes and returns the head of the queue. If no element is available
+// it retries after the polling interval elapses on the queue clock, until an
+// element is available or ctx is done, in which case it returns the ctx error.
+// The queue lock is not held between the attempts, which are always at least
+// an interval apart, thus Poll never busy-spins.
+// It returns the ErrInvalidInterval error if the interval is not positive.
+func (lq *Linked[T]) Poll(ctx context.Context, interval time.Duration) (T, error) {
+	elem, err := lq.poller.poll(ctx, interval, lq.Get)
+
+	return elem, lq.named(err)
+}
+
+// GetN removes and returns up to n elements from the head of the queue, in
+// FIFO order starting with the urgent lane, acquiring the queue lock once.
+// GetN(Size()) is equivalent to Clear.
+func (lq *Linked[T]) GetN(n int) []T {
+	lq.lock.Lock()
+	defer lq.lock.Unlock()
+
+	elems := make([]T, 0, min(max(n, 0), lq.occupancy.count))
+
+	for len(elems) < cap(elems) {
+		elem, err := lq.get()
+		if err != nil {
+			break
+		}
+
+		elems = append(elems, elem)
+	}
+
+	if len(elems) > 0 {
+		lq.tracker.recordBulk()
+	}
+
+	return elems
+}
+
+// Clear removes and returns all elements from the queue.
+func (lq *Linked[T]) Clear() []T {
+	lq.lock.Lock()
+	defer lq.lock.Unlock()
+
+	lq.tracker.recordBulk()
+
+	return lq.clear()
+}
+
+// Flush drains the elements of a queue created with the WithAutoFlush option,
+// even if the threshold was not reached, and waits until they are flushed
+// along with the batches drained before them. It is typically called before
+// discarding the queue, so that the elements inserted after the last
+// drain are flushed. Without the option it does nothing.
+func (lq *Linked[T]) Flush() {
+	if lq.flusher == nil {
+		return
+	}
+
+	lq.lock.Lock()
+
+	lq.tracker.recordBulk()
+
+	lq.flusher.drain()
+
+	lq.lock.Unlock()
+
+	lq.flusher.waitDrained()
+}
+
+// ClearIf evaluates pred against the current state of the queue and, if it
+// returns true, removes and returns all elements from the queue along with
+// true. Otherwise, it returns nil and false, leaving the queue unchanged.
+// The check and the removal are atomic with respect to the other operations.
+//
+// pred runs while holding the queue lock, thus it must be fast and must not
+// call the queue methods.
+func (lq *Linked[T]) ClearIf(pred func(snapshot ClearSnapshot[T]) bool) ([]T, bool) {
+	lq.lock.Lock()
+	defer lq.lock.Unlock()
+
+	snapshot := ClearSnapshot[T]{
+		Size:     lq.occupancy.count,
+		Capacity: lq.occupancy.capacity,
+	}
+
+	if !lq.isEmpty() {
+		snapshot.Head = lq.head.value
+	}
+
+	if !pred(snapshot) {
+		return nil, false
+	}
+
+	lq.tracker.recordBulk()
+
+	return lq.clear(), true
+}
+
+// clear removes and returns all elements from the queue.
+func (lq *Linked[T]) clear() []T {
+	elements := make([]T, 0, lq.occupancy.count)
+
+	current := lq.head
+	for current != nil {
+		elements = append(elements, current.value)
+		next := current.next
+		current = next
+	}
+
+	lq.releaseNodes(lq.head)
+
+	// Clear the queue
+	lq.head = nil
+	lq.tail = nil
+	lq.urgentTail = nil
+	lq.urgentSize = 0
+	lq.bloom.reset()
+	lq.occupancy.reset(0)
+	lq.journal.recordOp(JournalClear, 0)
+	lq.version++
+
+	lq.clearRecent()
+
+	return elements
+}
+
+// MarshalJSON serializes the queue elements to a JSON array in FIFO order.
+func (lq *Linked[T]) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+
+	if err := lq.MarshalJSONTo(&buf); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// MarshalJSONTo streams the queue elements to w as a JSON array in FIFO order.
+// The elements are copied while holding the queue lock and encoded one at a
+// time after releasing it, or before releasing it if the WithRecycler option
+// is provided. The output is identical to the MarshalJSON output.
+func (lq *Linked[T]) MarshalJSONTo(w io.Writer) error {
+	lq.lock.RLock()
+	elems := lq.snapshot()
+
+	// the pooled elements are encoded before any of them can be released.
+	if lq.recycler.enabled() {
+		defer lq.lock.RUnlock()
+
+		return lq.named(encodeJSONArray(w, elems, lq.codec.marshal))
+	}
+
+	lq.lock.RUnlock()
+
+	return lq.named(encodeJSONArray(w, elems, lq.codec.marshal))
+}
+
+// UnmarshalJSONFrom reads a JSON array from r and replaces the elements of
+// the queue with its elements, inserted using the same semantics as OfferAll
+// into the emptied queue. If an element cannot be decoded, the error reports
+// its index, and if the elements cannot all be inserted, none of them is and
+// the queue is left unchanged. The decoded elements are held until they are
+// inserted, unless the WithStreamingJSON option is provided.
+func (lq *Linked[T]) UnmarshalJSONFrom(r io.Reader) error {
+	return lq.named(lq.codec.decodeInto(r, lq.recycler, lq.Offer, lq.replaceAll))
+}
+
+// UnmarshalJSON replaces the elements of the queue with the elements of the
+// JSON array data, as UnmarshalJSONFrom does.
+func (lq *Linked[T]) UnmarshalJSON(data []byte) error {
+	return lq.UnmarshalJSONFrom(bytes.NewReader(data))
+}
+
+// MarshalBinary encodes the queue elements using encoding/gob, so that they
+// can be restored exactly using UnmarshalBinary, including the urgent lane.
+// The elements are copied while holding the queue lock and encoded after
+// releasing it, or before releasing it if the WithRecycler option is provided.
+func (lq *Linked[T]) MarshalBinary() ([]byte, error) {
+	lq.lock.RLock()
+
+	snapshot := &binarySnapshot[T]{
+		Capacity:   lq.occupancy.capacity,
+		Elems:      lq.snapshot(),
+		UrgentSize: lq.urgentSize,
+	}
+
+	// the pooled elements are encoded before any of them can be released.
+	if lq.recycler.enabled() {
+		defer lq.lock.RUnlock()
+	} else {
+		lq.lock.RUnlock()
+	}
+
+	data, err := snapshot.encode()
+
+	return data, lq.named(err)
+}
+
+// UnmarshalBinary replaces the queue elements with the elements encoded by
+// MarshalBinary, which are restored as is, without being validated. The
+// replaced elements are released if the WithRecycler option is provided, and
+// the handles issued for them are invalidated.
+//
+// It returns the ErrCapacityMismatch error, leaving the queue unchanged, if
+// the elements were encoded from a queue with another capacity.
+func (lq *Linked[T]) UnmarshalBinary(data []byte) error {
+	snapshot, err := decodeBinarySnapshot[T](data)
+	if err != nil {
+		return lq.named(err)
+	}
+
+	lq.lock.Lock()
+	defer lq.lock.Unlock()
+
+	if err := snapshot.checkCapacity(lq.occupancy.capacity); err != nil {
+		return lq.named(err)
+	}
+
+	if snapshot.UrgentSize < 0 || snapshot.UrgentSize > len(snapshot.Elems) {
+		return lq.named(errInvalidBinarySnapshot)
+	}
+
+	if lq.recycler.enabled() {
+		for n := lq.head; n != nil; n = n.next {
+			lq.recycler.discard(n.value)
+		}
+	}
+
+	lq.releaseNodes(lq.head)
+
+	lq.head = nil
+	lq.tail = nil
+	lq.urgentTail = nil
+	lq.urgentSize = 0
+	lq.bloom.reset()
+	lq.occupancy.reset(0)
+	lq.version++
+
+	lq.clearRecent()
+
+	// the restored elements are journaled as offered after a clear.
+	journal := lq.journal
+	lq.journal = nil
+
+	for i, value := range snapshot.Elems {
+		newNode, err := lq.offerNode(value)
+		if err != nil {
+			break
+		}
+
+		if i < snapshot.UrgentSize {
+			lq.urgentTail = newNode
+			lq.urgentSize++
+		}
+	}
+
+	lq.journal = journal
+	lq.journal.recordOp(JournalClear, 0)
+
+	for i, value := range snapshot.Elems[:lq.occupancy.count] {
+		op := JournalOffer
+		if i < lq.urgentSize {
+			op = JournalOfferUrgent
+		}
+
+		lq.journal.record(op, value, i+1)
+	}
+
+	lq.tracker.recordBulk()
+
+	return nil
+}
+
+// Dump writes a snapshot of the queue elements to w, in the format of
+// MarshalJSONTo, so that it can be restored using UnmarshalJSONFrom.
+func (lq *Linked[T]) Dump(w io.Writer) error {
+	return lq.MarshalJSONTo(w)
+}
+
+// exactSize returns the number of elements in the queue, bypassing the
+// cache of the WithCachedReads option.
+func (lq *Linked[T]) exactSize() int {
+	lq.lock.RLock()
+	defer lq.lock.RUnlock()
+
+	return lq.occupancy.count
+}
+
+// readHead returns the size and the head of the queue cached by the
+// WithCachedReads option, refreshing them if they expired.
+func (lq *Linked[T]) readHead() *cachedHead[T] {
+	if h := lq.reads.freshHead(); h != nil {
+		return h
+	}
+
+	lq.lock.RLock()
+	defer lq.lock.RUnlock()
+
+	elem, err := lq.peek()
+
+	return lq.reads.storeHead(lq.occupancy.count, elem, err)
+}
+
+// readElems returns the elements of the queue cached by the WithCachedReads
+// option, refreshing them if they expired.
+func (lq *Linked[T]) readElems() *cachedElems[T] {
+	if e := lq.reads.freshElems(); e != nil {
+		return e
+	}
+
+	lq.lock.RLock()
+	defer lq.lock.RUnlock()
+
+	return lq.reads.storeElems(lq.snapshot())
+}
+
+// snapshot returns a copy of the queue elements in FIFO order.
+func (lq *Linked[T]) snapshot() []T {
+	elems := make([]T, 0, lq.occupancy.count)
+
+	for current := lq.head; current != nil; current = current.next {
+		elems = append(elems, current.value)
+	}
+
+	return elems
+}
+
+// copyRange appends the elements in [from, to), relative to the head of the
+// queue, to dst, starting from the resume node if not nil. It returns the
+// node following the last copied element.
+func (lq *Linked[T]) copyRange(dst []T, from, to int, resume any) ([]T, any) {
+	current, _ := resume.(*node[T])
+
+	if current == nil {
+		current = lq.head
+
+		for i := 0; i < from; i++ {
+			current = current.next
+		}
+	}
+
+	for i := from; i < to; i++ {
+		dst = append(dst, current.value)
+		current = current.next
+	}
+
+	return dst, current
+}
+
+// verifyOccupancy checks that the occupancy counts the elements held by
+// the queue.
+func (lq *Linked[T]) verifyOccupancy() {
+	structural := 0
+
+	for n := lq.head; n != nil; n = n.next {
+		structural++
+	}
+
+	lq.occupancy.verify(structural)
+}
+
+// clearRecent empties the ring of the most recently offered elements.
+func (lq *Linked[T]) clearRecent() {
+	clear(lq.recent)
+
+	lq.recentNext = 0
+	lq.recentSize = 0
+}
+
+// forgetRecent removes the node at the given position of the list, about to
+// be unlinked from it, from the ring of the most recently offered elements.
+// The more recent elements are shifted back, so that the ring keeps
+// mirroring the tail of the list.
+func (lq *Linked[T]) forgetRecent(pos int) {
+	// the urgent lane is not part of the ring.
+	if pos < lq.urgentSize {
+		return
+	}
+
+	// the offset of the node from the tail, which is the offset of its
+	// element from the most recent one in the ring.
+	offset := lq.occupancy.count - 1 - pos
+	if offset >= lq.recentSize {
+		return
+	}
+
+	size := len(lq.recent)
+
+	for i := offset; i > 0; i-- {
+		lq.recent[(lq.recentNext-1-i+size)%size] = lq.recent[(lq.recentNext-i+size)%size]
+	}
+
+	lq.recentNext = (lq.recentNext - 1 + size) % size
+	lq.recentSize--
+
+	var zero T
+
+	lq.recent[lq.recentNext] = zero
+}
+
+// discard releases an element dropped after being retrieved from the queue.
+func (lq *Linked[T]) discard(elem T) {
+	lq.recycler.discard(elem)
 }
@@ -1,9 +1,14 @@
 package queue_test
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
 	"reflect"
+	"runtime"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/adrianbrad/queue"
 )
@@ -116,6 +121,103 @@ func TestLinked(t *testing.T) {
 		})
 	})
 
+	t.Run("WithCapacity", func(t *testing.T) {
+		t.Parallel()
+
+		t.Run("TruncatesInitialElements", func(t *testing.T) {
+			t.Parallel()
+
+			linkedQueue := queue.NewLinked([]int{1, 2, 3, 4}, queue.WithCapacity(2))
+
+			if elems := linkedQueue.ToSlice(); !reflect.DeepEqual([]int{1, 2}, elems) {
+				t.Fatalf("expected elements to be %v, got %v", []int{1, 2}, elems)
+			}
+
+			if capacity := linkedQueue.Capacity(); capacity != 2 {
+				t.Fatalf("expected capacity to be 2, got %d", capacity)
+			}
+		})
+
+		t.Run("Full", func(t *testing.T) {
+			t.Parallel()
+
+			linkedQueue := queue.NewLinked([]int{1}, queue.WithCapacity(2))
+
+			if err := linkedQueue.Offer(2); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if err := linkedQueue.Offer(3); !errors.Is(err, queue.ErrQueueIsFull) {
+				t.Fatalf("expected error to be %v, got %v", queue.ErrQueueIsFull, err)
+			}
+
+			if err := linkedQueue.OfferUrgent(3); !errors.Is(err, queue.ErrQueueIsFull) {
+				t.Fatalf("expected error to be %v, got %v", queue.ErrQueueIsFull, err)
+			}
+
+			if linkedQueue.CanOffer(1) {
+				t.Fatal("expected the queue to be full")
+			}
+
+			if size := linkedQueue.Size(); size != 2 {
+				t.Fatalf("expected size to be 2, got %d", size)
+			}
+
+			if _, err := linkedQueue.Get(); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if err := linkedQueue.Offer(3); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+		})
+
+		t.Run("OfferAll", func(t *testing.T) {
+			t.Parallel()
+
+			linkedQueue := queue.NewLinked([]int{1}, queue.WithCapacity(3))
+
+			if err := linkedQueue.OfferAll(2, 3, 4); !errors.Is(err, queue.ErrQueueIsFull) {
+				t.Fatalf("expected error to be %v, got %v", queue.ErrQueueIsFull, err)
+			}
+
+			if size := linkedQueue.Size(); size != 1 {
+				t.Fatalf("expected no element to be inserted, got size %d", size)
+			}
+
+			n, err := linkedQueue.OfferSome(2, 3, 4)
+			if n != 2 || !errors.Is(err, queue.ErrQueueIsFull) {
+				t.Fatalf("expected 2 elements and error %v, got %d, %v", queue.ErrQueueIsFull, n, err)
+			}
+		})
+
+		t.Run("ClearAndReset", func(t *testing.T) {
+			t.Parallel()
+
+			linkedQueue := queue.NewLinked([]int{1, 2, 3}, queue.WithCapacity(2))
+
+			if elems := linkedQueue.Clear(); !reflect.DeepEqual([]int{1, 2}, elems) {
+				t.Fatalf("expected elements to be %v, got %v", []int{1, 2}, elems)
+			}
+
+			if remaining := linkedQueue.Remaining(); remaining != 2 {
+				t.Fatalf("expected remaining to be 2, got %d", remaining)
+			}
+
+			_ = linkedQueue.Offer(4)
+
+			linkedQueue.Reset()
+
+			if elems := linkedQueue.ToSlice(); !reflect.DeepEqual([]int{1, 2}, elems) {
+				t.Fatalf("expected elements to be %v, got %v", []int{1, 2}, elems)
+			}
+
+			if err := linkedQueue.Offer(5); !errors.Is(err, queue.ErrQueueIsFull) {
+				t.Fatalf("expected error to be %v, got %v", queue.ErrQueueIsFull, err)
+			}
+		})
+	})
+
 	t.Run("Contains", func(t *testing.T) {
 		t.Parallel()
 
@@ -231,6 +333,627 @@ func TestLinked(t *testing.T) {
 			t.Fatalf("expected elements to be %v, got %v", elems, iterElems)
 		}
 	})
+
+	t.Run("IteratorBreak", func(t *testing.T) {
+		t.Parallel()
+
+		elems := make([]int, 1000)
+
+		for i := range elems {
+			elems[i] = i
+		}
+
+		linkedQueue := queue.NewLinked(elems)
+
+		for e := range linkedQueue.Iterator() {
+			if e != 0 {
+				t.Fatalf("expected the first element to be 0, got %d", e)
+			}
+
+			break
+		}
+
+		// no goroutine is left sending the other elements.
+		buf := make([]byte, 1<<20)
+
+		for {
+			n := runtime.Stack(buf, true)
+			if n < len(buf) {
+				buf = buf[:n]
+
+				break
+			}
+
+			buf = make([]byte, 2*len(buf))
+		}
+
+		if bytes.Contains(buf, []byte("queue.(*Linked[...]).Iterator")) {
+			t.Fatalf("expected no goroutine to be left by Iterator, got:\n%s", buf)
+		}
+	})
+
+	t.Run("WithResetCloner", func(t *testing.T) {
+		t.Parallel()
+
+		type elem struct {
+			value int
+		}
+
+		clone := func(e *elem) *elem {
+			c := *e
+
+			return &c
+		}
+
+		t.Run("Cloned", func(t *testing.T) {
+			t.Parallel()
+
+			elems := []*elem{{value: 1}, {value: 2}}
+
+			linkedQueue := queue.NewLinked(elems, queue.WithResetCloner(clone))
+
+			elems[0].value = 10
+
+			for i := 0; i < 2; i++ {
+				linkedQueue.Reset()
+
+				head, err := linkedQueue.Get()
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+
+				if head.value != 1 {
+					t.Fatalf("expected head value to be %d, got %d", 1, head.value)
+				}
+
+				head.value = 100
+			}
+		})
+
+		t.Run("Shallow", func(t *testing.T) {
+			t.Parallel()
+
+			elems := []*elem{{value: 1}, {value: 2}}
+
+			linkedQueue := queue.NewLinked(elems)
+
+			elems[0].value = 10
+
+			linkedQueue.Reset()
+
+			head, err := linkedQueue.Get()
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if head.value != 10 {
+				t.Fatalf("expected head value to be %d, got %d", 10, head.value)
+			}
+		})
+
+		t.Run("MismatchedType", func(t *testing.T) {
+			t.Parallel()
+
+			defer func() {
+				if p := recover(); p == nil {
+					t.Fatalf("expected panic")
+				}
+			}()
+
+			_ = queue.NewLinked([]int{1}, queue.WithResetCloner(clone))
+		})
+	})
+
+	t.Run("Try", func(t *testing.T) {
+		t.Parallel()
+
+		t.Run("Acquired", func(t *testing.T) {
+			t.Parallel()
+
+			linkedQueue := queue.NewLinked([]int{1})
+
+			if acquired, err := linkedQueue.TryOffer(2); !acquired || err != nil {
+				t.Fatalf("expected lock to be acquired and no error, got %t and %v", acquired, err)
+			}
+
+			elem, acquired, err := linkedQueue.TryPeek()
+			if !acquired || err != nil || elem != 1 {
+				t.Fatalf("expected elem %d, lock acquired and no error, got %d, %t and %v", 1, elem, acquired, err)
+			}
+
+			elem, acquired, err = linkedQueue.TryGet()
+			if !acquired || err != nil || elem != 1 {
+				t.Fatalf("expected elem %d, lock acquired and no error, got %d, %t and %v", 1, elem, acquired, err)
+			}
+		})
+
+		t.Run("Contended", func(t *testing.T) {
+			t.Parallel()
+
+			holder := newLockHolder()
+
+			linkedQueue := queue.NewLinked([]int{1}, queue.WithResetCloner(holder.Clone))
+
+			holder.Lock(linkedQueue.Reset)
+			defer holder.Unlock()
+
+			if acquired, err := linkedQueue.TryOffer(2); acquired || err != nil {
+				t.Fatalf("expected lock to not be acquired and no error, got %t and %v", acquired, err)
+			}
+
+			if _, acquired, err := linkedQueue.TryPeek(); acquired || err != nil {
+				t.Fatalf("expected lock to not be acquired and no error, got %t and %v", acquired, err)
+			}
+
+			if _, acquired, err := linkedQueue.TryGet(); acquired || err != nil {
+				t.Fatalf("expected lock to not be acquired and no error, got %t and %v", acquired, err)
+			}
+		})
+	})
+
+	t.Run("MarshalJSON", func(t *testing.T) {
+		t.Parallel()
+
+		t.Run("Streamed", func(t *testing.T) {
+			t.Parallel()
+
+			linkedQueue := queue.NewLinked([]int{1, 2, 3})
+
+			_, _ = linkedQueue.Get()
+
+			marshaled, err := linkedQueue.MarshalJSON()
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			expected, err := json.Marshal([]int{2, 3})
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if !bytes.Equal(expected, marshaled) {
+				t.Fatalf("expected json to be %s, got %s", expected, marshaled)
+			}
+
+			var buf bytes.Buffer
+
+			if err := linkedQueue.MarshalJSONTo(&buf); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if !bytes.Equal(marshaled, buf.Bytes()) {
+				t.Fatalf("expected streamed json to be %s, got %s", marshaled, buf.Bytes())
+			}
+		})
+
+		t.Run("Empty", func(t *testing.T) {
+			t.Parallel()
+
+			linkedQueue := queue.NewLinked([]int{})
+
+			marshaled, err := json.Marshal(linkedQueue)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if string(marshaled) != "[]" {
+				t.Fatalf("expected json to be [], got %s", marshaled)
+			}
+		})
+	})
+
+	t.Run("UnmarshalJSONFrom", func(t *testing.T) {
+		t.Parallel()
+
+		t.Run("Success", func(t *testing.T) {
+			t.Parallel()
+
+			linkedQueue := queue.NewLinked([]int{1})
+
+			if err := linkedQueue.UnmarshalJSONFrom(strings.NewReader("[2, 3]")); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			// the elements of the queue are replaced.
+			if elems := linkedQueue.Clear(); !reflect.DeepEqual([]int{2, 3}, elems) {
+				t.Fatalf("expected elements to be %v, got %v", []int{2, 3}, elems)
+			}
+		})
+
+		t.Run("InvalidJSON", func(t *testing.T) {
+			t.Parallel()
+
+			linkedQueue := queue.NewLinked([]int{})
+
+			if err := linkedQueue.UnmarshalJSONFrom(strings.NewReader(`{"a": 1}`)); err == nil {
+				t.Fatalf("expected error")
+			}
+
+			if err := linkedQueue.UnmarshalJSONFrom(strings.NewReader(`[1, "a"]`)); err == nil {
+				t.Fatalf("expected error")
+			}
+		})
+	})
+
+	t.Run("OfferUrgent", func(t *testing.T) {
+		t.Parallel()
+
+		t.Run("UrgentFirst", func(t *testing.T) {
+			t.Parallel()
+
+			linkedQueue := queue.NewLinked([]int{1, 2})
+
+			for _, offer := range []func() error{
+				func() error { return linkedQueue.OfferUrgent(10) },
+				func() error { return linkedQueue.Offer(3) },
+				func() error { return linkedQueue.OfferUrgent(11) },
+			} {
+				if err := offer(); err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+			}
+
+			if !linkedQueue.Contains(11) {
+				t.Fatalf("expected queue to contain %d", 11)
+			}
+
+			for _, expected := range []int{10, 11} {
+				if e, err := linkedQueue.Get(); err != nil || e != expected {
+					t.Fatalf("expected elem to be %d and no error, got %d and %v", expected, e, err)
+				}
+			}
+
+			if err := linkedQueue.OfferUrgent(12); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			expected := []int{12, 1, 2, 3}
+
+			if elems := linkedQueue.Clear(); !reflect.DeepEqual(expected, elems) {
+				t.Fatalf("expected elements to be %v, got %v", expected, elems)
+			}
+		})
+
+		t.Run("EmptyQueue", func(t *testing.T) {
+			t.Parallel()
+
+			linkedQueue := queue.NewLinked([]int{})
+
+			if err := linkedQueue.OfferUrgent(1); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if err := linkedQueue.Offer(2); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if err := linkedQueue.OfferUrgent(3); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			expected := []int{1, 3, 2}
+
+			if elems := linkedQueue.Clear(); !reflect.DeepEqual(expected, elems) {
+				t.Fatalf("expected elements to be %v, got %v", expected, elems)
+			}
+
+			linkedQueue.Reset()
+
+			if size := linkedQueue.Size(); size != 0 {
+				t.Fatalf("expected size to be %d, got %d", 0, size)
+			}
+		})
+	})
+
+	t.Run("ContainsRecent", func(t *testing.T) {
+		t.Parallel()
+
+		t.Run("Window", func(t *testing.T) {
+			t.Parallel()
+
+			linkedQueue := queue.NewLinked([]int{1, 2, 3, 4, 5}, queue.WithRecentWindow(3))
+
+			testCases := []struct {
+				n        int
+				elem     int
+				expected bool
+			}{
+				{n: 3, elem: 3, expected: true},
+				{n: 2, elem: 3, expected: false},
+				{n: 0, elem: 5, expected: false},
+				// beyond the window the list is walked.
+				{n: 5, elem: 1, expected: true},
+				{n: 4, elem: 1, expected: false},
+			}
+
+			for _, tc := range testCases {
+				if got := linkedQueue.ContainsRecent(tc.n, tc.elem); got != tc.expected {
+					t.Fatalf("expected ContainsRecent(%d, %d) to be %t", tc.n, tc.elem, tc.expected)
+				}
+			}
+		})
+
+		t.Run("LeftWindow", func(t *testing.T) {
+			t.Parallel()
+
+			linkedQueue := queue.NewLinked([]int{1, 2, 3, 4, 5}, queue.WithRecentWindow(3))
+
+			for i := 0; i < 3; i++ {
+				if _, err := linkedQueue.Get(); err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+			}
+
+			if linkedQueue.ContainsRecent(3, 3) {
+				t.Fatalf("expected removed element not to be found")
+			}
+
+			if !linkedQueue.ContainsRecent(3, 4) {
+				t.Fatalf("expected element to be found")
+			}
+
+			linkedQueue.Clear()
+
+			if linkedQueue.ContainsRecent(3, 5) {
+				t.Fatalf("expected cleared element not to be found")
+			}
+
+			linkedQueue.Reset()
+
+			if !linkedQueue.ContainsRecent(1, 5) {
+				t.Fatalf("expected reset element to be found")
+			}
+		})
+
+		t.Run("CancelledHandle", func(t *testing.T) {
+			t.Parallel()
+
+			linkedQueue := queue.NewLinked([]int{1, 2}, queue.WithRecentWindow(3))
+
+			handle, err := linkedQueue.OfferHandle(3)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if err := linkedQueue.Offer(4); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if !handle.Cancel() {
+				t.Fatalf("expected the element to be cancelled")
+			}
+
+			if linkedQueue.ContainsRecent(3, 3) {
+				t.Fatalf("expected cancelled element not to be found")
+			}
+
+			// the elements offered around the cancelled one are still found.
+			if !linkedQueue.ContainsRecent(2, 2) || !linkedQueue.ContainsRecent(1, 4) {
+				t.Fatalf("expected elements to be found")
+			}
+
+			if !linkedQueue.ContainsRecent(3, 1) {
+				t.Fatalf("expected element to be found")
+			}
+		})
+
+		t.Run("MovedOut", func(t *testing.T) {
+			t.Parallel()
+
+			linkedQueue := queue.NewLinked([]int{1, 2, 3, 4}, queue.WithRecentWindow(3))
+			dst := queue.NewLinked([]int{})
+
+			moveMatching := func(elem int) {
+				t.Helper()
+
+				if _, err := queue.MoveMatching[int](linkedQueue, dst, func(e int) bool { return e == elem }); err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+			}
+
+			moveMatching(3)
+
+			if linkedQueue.ContainsRecent(3, 3) {
+				t.Fatalf("expected moved element not to be found")
+			}
+
+			if !linkedQueue.ContainsRecent(2, 2) || !linkedQueue.ContainsRecent(1, 4) {
+				t.Fatalf("expected elements to be found")
+			}
+
+			// moving the urgent elements leaves the window unchanged.
+			for _, elem := range []int{9, 8} {
+				if err := linkedQueue.OfferUrgent(elem); err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+			}
+
+			moveMatching(8)
+
+			if !linkedQueue.ContainsRecent(3, 1) || !linkedQueue.ContainsRecent(1, 4) {
+				t.Fatalf("expected elements to be found")
+			}
+
+			if _, err := queue.Move[int](linkedQueue, dst); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if linkedQueue.ContainsRecent(4, 9) || !linkedQueue.ContainsRecent(3, 1) {
+				t.Fatalf("expected only the remaining elements to be found")
+			}
+		})
+
+		t.Run("UrgentLane", func(t *testing.T) {
+			t.Parallel()
+
+			linkedQueue := queue.NewLinked([]int{1}, queue.WithRecentWindow(3))
+
+			if err := linkedQueue.OfferUrgent(9); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if linkedQueue.ContainsRecent(1, 9) {
+				t.Fatalf("expected urgent element to be outside the window")
+			}
+
+			if !linkedQueue.ContainsRecent(2, 9) {
+				t.Fatalf("expected urgent element to be inside the window")
+			}
+		})
+	})
+
+	t.Run("ClearIf", func(t *testing.T) {
+		t.Parallel()
+
+		t.Run("True", func(t *testing.T) {
+			t.Parallel()
+
+			linkedQueue := queue.NewLinked([]int{1, 2, 3})
+
+			var snapshot queue.ClearSnapshot[int]
+
+			elems, cleared := linkedQueue.ClearIf(func(s queue.ClearSnapshot[int]) bool {
+				snapshot = s
+
+				return s.Size >= 3
+			})
+			if !cleared {
+				t.Fatalf("expected queue to be cleared")
+			}
+
+			expectedSnapshot := queue.ClearSnapshot[int]{Size: 3, Capacity: -1, Head: 1}
+
+			if snapshot != expectedSnapshot {
+				t.Fatalf("expected snapshot to be %+v, got %+v", expectedSnapshot, snapshot)
+			}
+
+			if !reflect.DeepEqual([]int{1, 2, 3}, elems) {
+				t.Fatalf("expected elements to be %v, got %v", []int{1, 2, 3}, elems)
+			}
+
+			if !linkedQueue.IsEmpty() {
+				t.Fatalf("expected queue to be empty")
+			}
+		})
+
+		t.Run("False", func(t *testing.T) {
+			t.Parallel()
+
+			linkedQueue := queue.NewLinked([]int{1, 2, 3})
+
+			elems, cleared := linkedQueue.ClearIf(func(s queue.ClearSnapshot[int]) bool {
+				return s.Size > 3
+			})
+			if cleared || elems != nil {
+				t.Fatalf("expected queue not to be cleared, got %v and %t", elems, cleared)
+			}
+
+			if size := linkedQueue.Size(); size != 3 {
+				t.Fatalf("expected size to be %d, got %d", 3, size)
+			}
+		})
+	})
+
+	t.Run("NodePooling", func(t *testing.T) {
+		t.Parallel()
+
+		t.Run("ReleasesValues", func(t *testing.T) {
+			t.Parallel()
+
+			linkedQueue := queue.NewLinked([]*[64]byte{})
+
+			collected := make(chan struct{})
+
+			func() {
+				elem := new([64]byte)
+
+				runtime.SetFinalizer(elem, func(*[64]byte) {
+					close(collected)
+				})
+
+				_ = linkedQueue.Offer(elem)
+
+				_, _ = linkedQueue.Get()
+			}()
+
+			// the released node is kept for reuse.
+			defer runtime.KeepAlive(linkedQueue)
+
+			for i := 0; i < 10; i++ {
+				runtime.GC()
+
+				select {
+				case <-collected:
+					return
+				case <-time.After(10 * time.Millisecond):
+				}
+			}
+
+			t.Fatal("expected the value of the retrieved element to be released")
+		})
+
+		t.Run("Order", func(t *testing.T) {
+			t.Parallel()
+
+			linkedQueue := queue.NewLinked([]int{1, 2, 3})
+
+			_, _ = linkedQueue.Get()
+			_ = linkedQueue.Offer(4)
+			_ = linkedQueue.OfferUrgent(0)
+			_ = linkedQueue.Offer(5)
+
+			if elems := linkedQueue.ToSlice(); !reflect.DeepEqual([]int{0, 2, 3, 4, 5}, elems) {
+				t.Fatalf("expected elements to be %v, got %v", []int{0, 2, 3, 4, 5}, elems)
+			}
+
+			_ = linkedQueue.Clear()
+
+			for i := 0; i < 3; i++ {
+				_ = linkedQueue.Offer(i)
+			}
+
+			if elems := linkedQueue.ToSlice(); !reflect.DeepEqual([]int{0, 1, 2}, elems) {
+				t.Fatalf("expected elements to be %v, got %v", []int{0, 1, 2}, elems)
+			}
+		})
+
+		t.Run("Handles", func(t *testing.T) {
+			t.Parallel()
+
+			linkedQueue := queue.NewLinked([]int{})
+
+			handle, err := linkedQueue.OfferHandle(1)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			_, _ = linkedQueue.Get()
+
+			_ = linkedQueue.Offer(2)
+
+			// the node of the retrieved element is not reused, thus the
+			// handle does not identify the new element.
+			if handle.Cancel() {
+				t.Fatal("expected the handle not to cancel the new element")
+			}
+
+			if size := linkedQueue.Size(); size != 1 {
+				t.Fatalf("expected size to be 1, got %d", size)
+			}
+		})
+
+		t.Run("WithoutNodePooling", func(t *testing.T) {
+			t.Parallel()
+
+			linkedQueue := queue.NewLinked([]int{1}, queue.WithoutNodePooling())
+
+			_, _ = linkedQueue.Get()
+
+			if report := linkedQueue.MemoryFootprint(nil); report.Slots != 0 {
+				t.Fatalf("expected no node to be kept, got %+v", report)
+			}
+		})
+	})
 }
 
 func BenchmarkLinkedQueue(b *testing.B) {
@@ -258,6 +981,19 @@ func BenchmarkLinkedQueue(b *testing.B) {
 		}
 	})
 
+	b.Run("Get_Offer_WithoutNodePooling", func(b *testing.B) {
+		linkedQueue := queue.NewLinked([]int{1}, queue.WithoutNodePooling())
+
+		b.ReportAllocs()
+		b.ResetTimer()
+
+		for i := 0; i <= b.N; i++ {
+			_, _ = linkedQueue.Get()
+
+			_ = linkedQueue.Offer(1)
+		}
+	})
+
 	b.Run("Offer", func(b *testing.B) {
 		linkedQueue := queue.NewLinked[int](nil)
 
@@ -0,0 +1,76 @@
+package queue
+
+// ClearMap removes all elements from the queue, as Clear does, and returns
+// them converted by fn, in the order in which Clear returns them.
+// The converted elements are stored in a single slice, sized to the number
+// of removed elements.
+func ClearMap[S comparable, D any](q Queue[S], fn func(S) D) []D {
+	elems := q.Clear()
+
+	mapped := make([]D, len(elems))
+
+	for i, elem := range elems {
+		mapped[i] = fn(elem)
+	}
+
+	return mapped
+}
+
+// A View is a read-only view of a queue whose elements are converted to
+// another type when they are retrieved, as returned by MapView.
+type View[D any] interface {
+	// Peek retrieves but does not remove the converted head of the queue.
+	Peek() (D, error)
+
+	// Size returns the number of elements in the queue.
+	Size() int
+
+	// Get retrieves and removes the head of the queue, returning it
+	// converted.
+	Get() (D, error)
+}
+
+// MapView returns a view of the queue converting its elements using fn when
+// they are retrieved. The elements are retrieved in the order of the queue,
+// the priority order for a Priority queue.
+//
+// The view does not insert elements, since it cannot convert them back, the
+// elements are inserted using the queue itself. The errors returned by the
+// queue are returned unchanged, fn is not called when an error is returned.
+func MapView[S comparable, D any](q Queue[S], fn func(S) D) View[D] {
+	return &mapView[S, D]{
+		queue: q,
+		fn:    fn,
+	}
+}
+
+// mapView is the View returned by MapView.
+type mapView[S comparable, D any] struct {
+	queue Queue[S]
+	fn    func(S) D
+}
+
+// Peek retrieves but does not remove the converted head of the queue.
+func (v *mapView[S, D]) Peek() (D, error) {
+	return v.convert(v.queue.Peek())
+}
+
+// Size returns the number of elements in the queue.
+func (v *mapView[S, D]) Size() int {
+	return v.queue.Size()
+}
+
+// Get retrieves and removes the head of the queue, returning it converted.
+func (v *mapView[S, D]) Get() (D, error) {
+	return v.convert(v.queue.Get())
+}
+
+// convert converts the element retrieved from the queue, unless err is
+// not nil.
+func (v *mapView[S, D]) convert(elem S, err error) (d D, _ error) {
+	if err != nil {
+		return d, err
+	}
+
+	return v.fn(elem), nil
+}
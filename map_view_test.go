@@ -0,0 +1,195 @@
+package queue_test
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/adrianbrad/queue"
+)
+
+func TestClearMap(t *testing.T) {
+	t.Parallel()
+
+	lessInt := func(elem, otherElem int) bool { return elem < otherElem }
+
+	testCases := map[string]struct {
+		queue    queue.Queue[int]
+		expected []string
+	}{
+		"Blocking": {
+			queue:    queue.NewBlocking([]int{3, 1, 2}),
+			expected: []string{"3", "1", "2"},
+		},
+		"Circular": {
+			queue:    queue.NewCircular([]int{3, 1, 2}, 4),
+			expected: []string{"3", "1", "2"},
+		},
+		"Linked": {
+			queue:    queue.NewLinked([]int{3, 1, 2}),
+			expected: []string{"3", "1", "2"},
+		},
+		"Priority": {
+			queue:    queue.NewPriority([]int{3, 1, 2}, lessInt),
+			expected: []string{"1", "2", "3"},
+		},
+		"Handle": {
+			queue:    queue.NewHandle[int](queue.NewLinked([]int{3, 1, 2})),
+			expected: []string{"3", "1", "2"},
+		},
+	}
+
+	for name, tc := range testCases {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if mapped := queue.ClearMap(tc.queue, strconv.Itoa); !reflect.DeepEqual(tc.expected, mapped) {
+				t.Fatalf("expected elements to be %v, got %v", tc.expected, mapped)
+			}
+
+			if !tc.queue.IsEmpty() {
+				t.Fatalf("expected queue to be empty, got %d elements", tc.queue.Size())
+			}
+		})
+	}
+
+	t.Run("ChanQueue", func(t *testing.T) {
+		t.Parallel()
+
+		ch := make(chan int, 3)
+
+		ch <- 3
+		ch <- 1
+
+		if mapped := queue.ClearMap[int](queue.NewFromChannel(ch), strconv.Itoa); !reflect.DeepEqual([]string{"3", "1"}, mapped) {
+			t.Fatalf("expected elements to be %v, got %v", []string{"3", "1"}, mapped)
+		}
+	})
+
+	t.Run("Empty", func(t *testing.T) {
+		t.Parallel()
+
+		if mapped := queue.ClearMap[int](queue.NewLinked([]int{}), strconv.Itoa); len(mapped) != 0 {
+			t.Fatalf("expected no elements, got %v", mapped)
+		}
+	})
+}
+
+func TestMapView(t *testing.T) {
+	t.Parallel()
+
+	lessInt := func(elem, otherElem int) bool { return elem < otherElem }
+
+	testCases := map[string]struct {
+		queue    queue.Queue[int]
+		expected []string
+	}{
+		"Blocking": {
+			queue:    queue.NewBlocking([]int{3, 1, 2}),
+			expected: []string{"3", "1", "2"},
+		},
+		"Circular": {
+			queue:    queue.NewCircular([]int{3, 1, 2}, 4),
+			expected: []string{"3", "1", "2"},
+		},
+		"Linked": {
+			queue:    queue.NewLinked([]int{3, 1, 2}),
+			expected: []string{"3", "1", "2"},
+		},
+		"Priority": {
+			queue:    queue.NewPriority([]int{3, 1, 2}, lessInt),
+			expected: []string{"1", "2", "3"},
+		},
+		"Handle": {
+			queue:    queue.NewHandle[int](queue.NewLinked([]int{3, 1, 2})),
+			expected: []string{"3", "1", "2"},
+		},
+	}
+
+	for name, tc := range testCases {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			view := queue.MapView(tc.queue, strconv.Itoa)
+
+			if size := view.Size(); size != len(tc.expected) {
+				t.Fatalf("expected size to be %d, got %d", len(tc.expected), size)
+			}
+
+			if head, err := view.Peek(); err != nil || head != tc.expected[0] {
+				t.Fatalf("expected head to be %s, got %s, %v", tc.expected[0], head, err)
+			}
+
+			mapped := make([]string, 0, len(tc.expected))
+
+			for !tc.queue.IsEmpty() {
+				elem, err := view.Get()
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+
+				mapped = append(mapped, elem)
+			}
+
+			if !reflect.DeepEqual(tc.expected, mapped) {
+				t.Fatalf("expected elements to be %v, got %v", tc.expected, mapped)
+			}
+
+			// the errors of the queue are returned unchanged.
+			if _, err := view.Get(); !errors.Is(err, queue.ErrNoElementsAvailable) {
+				t.Fatalf("expected error to be %v, got %v", queue.ErrNoElementsAvailable, err)
+			}
+
+			if _, err := view.Peek(); !errors.Is(err, queue.ErrNoElementsAvailable) {
+				t.Fatalf("expected error to be %v, got %v", queue.ErrNoElementsAvailable, err)
+			}
+		})
+	}
+
+	t.Run("ChanQueue", func(t *testing.T) {
+		t.Parallel()
+
+		ch := make(chan int, 1)
+
+		ch <- 1
+
+		view := queue.MapView[int](queue.NewFromChannel(ch), strconv.Itoa)
+
+		if _, err := view.Peek(); !errors.Is(err, queue.ErrUnsupportedOperation) {
+			t.Fatalf("expected error to be %v, got %v", queue.ErrUnsupportedOperation, err)
+		}
+
+		if elem, err := view.Get(); err != nil || elem != "1" {
+			t.Fatalf("expected to get 1, got %s, %v", elem, err)
+		}
+	})
+
+	t.Run("Closed", func(t *testing.T) {
+		t.Parallel()
+
+		blockingQueue := queue.NewBlocking([]int{})
+
+		blockingQueue.Close()
+
+		called := false
+
+		view := queue.MapView[int](blockingQueue, func(elem int) string {
+			called = true
+
+			return strconv.Itoa(elem)
+		})
+
+		if _, err := view.Get(); !errors.Is(err, queue.ErrQueueClosed) {
+			t.Fatalf("expected error to be %v, got %v", queue.ErrQueueClosed, err)
+		}
+
+		if called {
+			t.Fatalf("expected the conversion not to be called on error")
+		}
+	})
+}
@@ -0,0 +1,98 @@
+package queue
+
+import (
+	"unsafe"
+)
+
+// MemoryReport estimates the memory retained by a queue, as returned by the
+// MemoryFootprint method of the queues.
+type MemoryReport struct {
+	// Elements is the number of elements held by the queue.
+	Elements int
+
+	// PayloadBytes is the size of the elements held by the queue, as given
+	// by the sizer passed to MemoryFootprint.
+	PayloadBytes uintptr
+
+	// Slots is the number of element slots allocated by the queue storage:
+	// the capacity of the backing arrays of the slice based queues and of
+	// the channel buffer of a ChanQueue, the number of nodes of a Linked
+	// queue. WastedSlots is the number of slots holding no element.
+	Slots       int
+	WastedSlots int
+
+	// StructuralBytes is the memory retained by the queue storage in
+	// addition to the payload: the wasted slots, the links of the nodes, the
+	// metadata held alongside the elements by the enabled options and the
+	// copy of the initial elements restored by Reset.
+	StructuralBytes uintptr
+
+	// BaselineBytes is the size of the queue itself.
+	BaselineBytes uintptr
+}
+
+// TotalBytes returns the sum of the payload, structural and baseline bytes.
+func (r MemoryReport) TotalBytes() uintptr {
+	return r.PayloadBytes + r.StructuralBytes + r.BaselineBytes
+}
+
+// footprint accumulates the MemoryReport of a queue.
+type footprint[T any] struct {
+	report MemoryReport
+	sizeOf func(T) uintptr
+
+	// slot is the shallow size of an element.
+	slot uintptr
+}
+
+// newFootprint returns a footprint measuring the elements using sizeOf, or
+// their shallow size if sizeOf is nil, for a queue of the given size.
+func newFootprint[T any](sizeOf func(T) uintptr, baseline uintptr) *footprint[T] {
+	var zero T
+
+	return &footprint[T]{
+		report: MemoryReport{BaselineBytes: baseline},
+		sizeOf: sizeOf,
+		slot:   unsafe.Sizeof(zero),
+	}
+}
+
+// elements accounts for the elements held by the queue.
+func (f *footprint[T]) elements(elems ...T) {
+	f.report.Elements += len(elems)
+
+	if f.sizeOf == nil {
+		f.report.PayloadBytes += uintptr(len(elems)) * f.slot
+
+		return
+	}
+
+	for _, elem := range elems {
+		f.report.PayloadBytes += f.sizeOf(elem)
+	}
+}
+
+// slots accounts for n allocated element slots.
+func (f *footprint[T]) slots(n int) {
+	f.report.Slots += n
+}
+
+// overhead accounts for the bytes retained by the storage of the queue.
+func (f *footprint[T]) overhead(bytes uintptr) {
+	f.report.StructuralBytes += bytes
+}
+
+// copies accounts for a slice of elements retained besides the elements held
+// by the queue, such as the initial elements.
+func (f *footprint[T]) copies(elems []T) {
+	f.overhead(uintptr(cap(elems)) * f.slot)
+}
+
+// done returns the report, accounting for the slots holding no element.
+func (f *footprint[T]) done() MemoryReport {
+	f.report.WastedSlots = max(f.report.Slots-f.report.Elements, 0)
+
+	f.overhead(uintptr(f.report.WastedSlots) * f.slot)
+
+	return f.report
+}
@@ -0,0 +1,238 @@
+package queue_test
+
+import (
+	"testing"
+	"unsafe"
+
+	"github.com/adrianbrad/queue"
+)
+
+func TestMemoryFootprint(t *testing.T) {
+	t.Parallel()
+
+	const (
+		intSize     = unsafe.Sizeof(int(0))
+		pointerSize = unsafe.Sizeof(uintptr(0))
+	)
+
+	assertReport := func(t *testing.T, got, expected queue.MemoryReport) {
+		t.Helper()
+
+		expected.BaselineBytes = got.BaselineBytes
+
+		if got != expected {
+			t.Fatalf("expected report %+v, got %+v", expected, got)
+		}
+
+		if got.BaselineBytes == 0 {
+			t.Fatalf("expected the baseline bytes to be reported")
+		}
+
+		if total := got.PayloadBytes + got.StructuralBytes + got.BaselineBytes; got.TotalBytes() != total {
+			t.Fatalf("expected total bytes to be %d, got %d", total, got.TotalBytes())
+		}
+	}
+
+	t.Run("Circular", func(t *testing.T) {
+		t.Parallel()
+
+		t.Run("WastedCapacity", func(t *testing.T) {
+			t.Parallel()
+
+			circularQueue := queue.NewCircular([]int{}, 1000)
+
+			for i := 0; i < 10; i++ {
+				_ = circularQueue.Offer(i)
+			}
+
+			assertReport(t, circularQueue.MemoryFootprint(nil), queue.MemoryReport{
+				Elements:        10,
+				PayloadBytes:    10 * intSize,
+				Slots:           1000,
+				WastedSlots:     990,
+				StructuralBytes: 990 * intSize,
+			})
+		})
+
+		t.Run("Wrapped", func(t *testing.T) {
+			t.Parallel()
+
+			circularQueue := queue.NewCircular([]int{1, 2, 3}, 4)
+
+			_, _ = circularQueue.Get()
+			_, _ = circularQueue.Get()
+			_ = circularQueue.Offer(4)
+			_ = circularQueue.Offer(5)
+
+			var measured []int
+
+			report := circularQueue.MemoryFootprint(func(elem int) uintptr {
+				measured = append(measured, elem)
+
+				return 100
+			})
+
+			if len(measured) != 3 || measured[0] != 3 || measured[1] != 4 || measured[2] != 5 {
+				t.Fatalf("expected the elements [3 4 5] to be measured, got %v", measured)
+			}
+
+			// the initial elements are kept for Reset.
+			assertReport(t, report, queue.MemoryReport{
+				Elements:        3,
+				PayloadBytes:    300,
+				Slots:           4,
+				WastedSlots:     1,
+				StructuralBytes: intSize + 3*intSize,
+			})
+		})
+	})
+
+	t.Run("Blocking", func(t *testing.T) {
+		t.Parallel()
+
+		t.Run("ConsumedPrefix", func(t *testing.T) {
+			t.Parallel()
+
+			blockingQueue := queue.NewBlocking([]int{}, queue.WithCapacity(100))
+
+			for i := 0; i < 10; i++ {
+				_ = blockingQueue.Offer(i)
+			}
+
+			assertReport(t, blockingQueue.MemoryFootprint(nil), queue.MemoryReport{
+				Elements:        10,
+				PayloadBytes:    10 * intSize,
+				Slots:           100,
+				WastedSlots:     90,
+				StructuralBytes: 90 * intSize,
+			})
+
+			for i := 0; i < 5; i++ {
+				_, _ = blockingQueue.Get()
+			}
+
+			assertReport(t, blockingQueue.MemoryFootprint(nil), queue.MemoryReport{
+				Elements:        5,
+				PayloadBytes:    5 * intSize,
+				Slots:           100,
+				WastedSlots:     95,
+				StructuralBytes: 95 * intSize,
+			})
+		})
+
+		t.Run("UrgentAndMeta", func(t *testing.T) {
+			t.Parallel()
+
+			blockingQueue := queue.NewBlocking([]int{1})
+
+			_ = blockingQueue.OfferUrgent(0)
+
+			if _, err := blockingQueue.OfferHandle(2); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			report := blockingQueue.MemoryFootprint(nil)
+
+			if report.Elements != 3 || report.PayloadBytes != 3*intSize {
+				t.Fatalf("expected 3 elements of %d bytes, got %+v", intSize, report)
+			}
+
+			if report.Slots < 3 || report.WastedSlots != report.Slots-3 {
+				t.Fatalf("expected the slots beyond the 3 elements to be wasted, got %+v", report)
+			}
+
+			// the metadata of the handles is retained alongside the elements.
+			if minimum := uintptr(report.WastedSlots+1) * intSize; report.StructuralBytes <= minimum {
+				t.Fatalf("expected the structural bytes to exceed %d, got %d", minimum, report.StructuralBytes)
+			}
+		})
+	})
+
+	t.Run("Linked", func(t *testing.T) {
+		t.Parallel()
+
+		linkedQueue := queue.NewLinked([]int{}, queue.WithRecentWindow(4))
+
+		for i := 0; i < 10; i++ {
+			_ = linkedQueue.Offer(i)
+		}
+
+		_, _ = linkedQueue.Get()
+
+		// every node links to the next one, the node released by Get is kept
+		// for reuse.
+		assertReport(t, linkedQueue.MemoryFootprint(nil), queue.MemoryReport{
+			Elements:        9,
+			PayloadBytes:    9 * intSize,
+			Slots:           10,
+			WastedSlots:     1,
+			StructuralBytes: 10*pointerSize + 5*intSize,
+		})
+	})
+
+	t.Run("Priority", func(t *testing.T) {
+		t.Parallel()
+
+		lessFunc := func(elem, otherElem int) bool { return elem < otherElem }
+
+		t.Run("Default", func(t *testing.T) {
+			t.Parallel()
+
+			priorityQueue := queue.NewPriority([]int{3, 1, 2}, lessFunc)
+
+			_, _ = priorityQueue.Get()
+
+			assertReport(t, priorityQueue.MemoryFootprint(nil), queue.MemoryReport{
+				Elements:        2,
+				PayloadBytes:    2 * intSize,
+				Slots:           3,
+				WastedSlots:     1,
+				StructuralBytes: intSize + 3*intSize,
+			})
+		})
+
+		t.Run("StableOrder", func(t *testing.T) {
+			t.Parallel()
+
+			priorityQueue := queue.NewPriority([]int{3, 1, 2}, lessFunc, queue.WithStableOrder())
+
+			seqSize := unsafe.Sizeof(uint64(0))
+
+			assertReport(t, priorityQueue.MemoryFootprint(nil), queue.MemoryReport{
+				Elements:        3,
+				PayloadBytes:    3 * intSize,
+				Slots:           3,
+				StructuralBytes: 3*intSize + 6*seqSize,
+			})
+		})
+	})
+
+	t.Run("ChanQueue", func(t *testing.T) {
+		t.Parallel()
+
+		chanQueue := queue.NewFromChannel(make(chan int, 10))
+
+		for i := 0; i < 3; i++ {
+			_ = chanQueue.Offer(i)
+		}
+
+		// the elements cannot be measured without being received.
+		report := chanQueue.MemoryFootprint(func(int) uintptr {
+			t.Fatalf("expected the sizer not to be called")
+
+			return 0
+		})
+
+		assertReport(t, report, queue.MemoryReport{
+			Elements:        3,
+			PayloadBytes:    3 * intSize,
+			Slots:           10,
+			WastedSlots:     7,
+			StructuralBytes: 7 * intSize,
+		})
+
+		if size := chanQueue.Size(); size != 3 {
+			t.Fatalf("expected size to be 3, got %d", size)
+		}
+	})
+}
@@ -0,0 +1,205 @@
+package queue
+
+import (
+	"unsafe"
+)
+
+// Ensure the queues implement the mover interface.
+var (
+	_ mover[int] = (*Blocking[int])(nil)
+	_ mover[int] = (*Circular[int])(nil)
+	_ mover[int] = (*Linked[int])(nil)
+	_ mover[int] = (*Priority[int])(nil)
+)
+
+// mover is implemented by the queues taking part in Move and MoveMatching.
+// Its methods, except moveLock, are called while holding the lock it returns
+// for writing.
+type mover[T comparable] interface {
+	// moveLock returns the lock of the queue.
+	moveLock() *profiledRWMutex
+
+	// moveCandidate returns the element Get would return if pred is nil, or
+	// the first element satisfying pred otherwise, along with its position
+	// in the queue. If there is no such element it returns the error Get
+	// would return, or the ErrNoElementsAvailable error if no element
+	// satisfies pred.
+	moveCandidate(pred func(T) bool) (elem T, pos int, _ error)
+
+	// moveOut removes the element returned by moveCandidate, at the given
+	// position.
+	moveOut(elem T, pos int)
+
+	// moveAdmit admits the element, which is inserted right after using
+	// moveIn, or returns the error Offer would return.
+	moveAdmit(elem T) error
+
+	// moveIn inserts the admitted element to the tail of the queue.
+	moveIn(elem T)
+
+	// moveElems returns a copy of the elements moveCandidate and moveOut
+	// would move out of the queue one after the other, until moveCandidate
+	// fails, in the order in which Clear would return them.
+	moveElems() []T
+
+	// moveTail returns the position of the element last inserted using
+	// moveIn, as moveOut expects it, or false if the queue no longer holds
+	// it, such as once it was flushed by the WithAutoFlush option.
+	moveTail(elem T) (pos int, ok bool)
+}
+
+// Move removes the element src.Get would return and inserts it to the tail
+// of dst, as one operation: no other goroutine observes the element in both
+// queues or in neither. If dst rejects the element, such as with the
+// ErrQueueIsFull error, the element stays at the head of src and is returned
+// along with the error dst.Offer would have returned. If src holds no element
+// it returns the error src.Get would return.
+//
+// The element is never spilled to the overflow of a Blocking dst created
+// using the WithDiskOverflow option: if dst is full Move returns the
+// ErrQueueIsFull error, while dst.Offer would spill the element and succeed.
+//
+// Both queues are locked for the duration of the move, in the order of
+// their addresses, so that concurrent moves between the same queues in
+// opposite directions do not deadlock.
+//
+// Move supports the Blocking, Circular, Linked and Priority queues, it
+// returns the ErrUnsupportedOperation error for the other queues.
+// It panics if src and dst are the same queue.
+func Move[T comparable](src, dst Queue[T]) (T, error) {
+	return move(src, dst, nil)
+}
+
+// MoveMatching moves the first element of src satisfying pred to the tail of
+// dst, as Move does. The elements of src are examined in FIFO order, starting
+// with the urgent lane of the Blocking and Linked queues, and in priority
+// order for the Priority queues. If dst rejects the element it stays at its
+// position in src. If no element satisfies pred it returns the
+// ErrNoElementsAvailable error.
+//
+// pred runs while holding the locks of both queues, thus it must be fast and
+// must not call the queue methods.
+func MoveMatching[T comparable](src, dst Queue[T], pred func(elem T) bool) (T, error) {
+	return move(src, dst, pred)
+}
+
+// move moves the element of src selected by pred, or its head if pred is nil,
+// to the tail of dst.
+func move[T comparable](src, dst Queue[T], pred func(elem T) bool) (elem T, _ error) {
+	from, to, unlock, err := lockMovers(src, dst)
+	if err != nil {
+		return elem, err
+	}
+
+	defer unlock()
+
+	return moveOne(from, to, pred)
+}
+
+// MoveAll moves all the elements of src to the tail of dst, in the order in
+// which src.Get would return them, as one operation: both queues are locked
+// until the last element is moved, as they are by Move. It returns the number
+// of moved elements.
+//
+// If dst rejects an element, such as with the ErrQueueIsFull error, the
+// element and the following ones stay in src, and MoveAll returns the number
+// of elements moved before along with the error dst.Offer would have returned,
+// except for a full Blocking dst created using the WithDiskOverflow option,
+// which rejects the elements with the ErrQueueIsFull error, as it does for
+// Move. An empty src, including a closed Blocking queue, is not an error, nor
+// are the elements of a Blocking src reserved for its waiting consumers,
+// which stay in src.
+//
+// MoveAll supports the queues supported by Move, it returns the
+// ErrUnsupportedOperation error for the other queues.
+// It panics if src and dst are the same queue.
+func MoveAll[T comparable](src, dst Queue[T]) (n int, _ error) {
+	from, to, unlock, err := lockMovers(src, dst)
+	if err != nil {
+		return 0, err
+	}
+
+	defer unlock()
+
+	for ; ; n++ {
+		elem, pos, err := from.moveCandidate(nil)
+		if err != nil {
+			// src is empty, or its elements are reserved for its waiters.
+			return n, nil
+		}
+
+		if err := to.moveAdmit(elem); err != nil {
+			return n, err
+		}
+
+		from.moveOut(elem, pos)
+
+		to.moveIn(elem)
+	}
+}
+
+// lockMovers locks the queues taking part in a move, in the order of the
+// addresses of their locks, and returns them along with the function
+// unlocking them.
+func lockMovers[T comparable](src, dst Queue[T]) (from, to mover[T], unlock func(), _ error) {
+	from, fromOK := src.(mover[T])
+	to, toOK := dst.(mover[T])
+
+	if !fromOK || !toOK {
+		return nil, nil, nil, ErrUnsupportedOperation
+	}
+
+	first, second := from.moveLock(), to.moveLock()
+
+	if first == second {
+		panic("queue moves an element to itself")
+	}
+
+	if uintptr(unsafe.Pointer(first)) > uintptr(unsafe.Pointer(second)) {
+		first, second = second, first
+	}
+
+	first.Lock()
+	second.Lock()
+
+	return from, to, func() {
+		second.Unlock()
+		first.Unlock()
+	}, nil
+}
+
+// moveOne moves the element of from selected by pred, or its head if pred is
+// nil, to the tail of to. Both queues must be locked.
+func moveOne[T comparable](from, to mover[T], pred func(elem T) bool) (elem T, _ error) {
+	elem, pos, err := from.moveCandidate(pred)
+	if err != nil {
+		return elem, err
+	}
+
+	// the element is admitted before being removed, so that it never has
+	// to be put back.
+	if err := to.moveAdmit(elem); err != nil {
+		return elem, err
+	}
+
+	from.moveOut(elem, pos)
+
+	to.moveIn(elem)
+
+	return elem, nil
+}
+
+// deleteIndex removes the element at index i of s, shifting the following
+// elements, and zeroes the vacated slot so that it does not retain the last
+// element.
+func deleteIndex[E any](s []E, i int) []E {
+	last := len(s) - 1
+
+	copy(s[i:], s[i+1:])
+
+	var zero E
+
+	s[last] = zero
+
+	return s[:last]
+}
@@ -0,0 +1,538 @@
+package queue_test
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/adrianbrad/queue"
+)
+
+// moveQueue is implemented by all the queues taking part in the moves.
+type moveQueue interface {
+	queue.Queue[int]
+	ToSlice() []int
+}
+
+func TestMove(t *testing.T) {
+	t.Parallel()
+
+	lessInt := func(elem, otherElem int) bool { return elem < otherElem }
+
+	testCases := map[string]struct {
+		newQueue func(elems []int) moveQueue
+
+		// order is the order in which the queue returns 3, 1, 4, 2.
+		order []int
+	}{
+		"Blocking": {
+			newQueue: func(elems []int) moveQueue { return queue.NewBlocking(elems) },
+			order:    []int{3, 1, 4, 2},
+		},
+		"Circular": {
+			newQueue: func(elems []int) moveQueue { return queue.NewCircular(elems, 10) },
+			order:    []int{3, 1, 4, 2},
+		},
+		"Linked": {
+			newQueue: func(elems []int) moveQueue { return queue.NewLinked(elems) },
+			order:    []int{3, 1, 4, 2},
+		},
+		"Priority": {
+			newQueue: func(elems []int) moveQueue { return queue.NewPriority(elems, lessInt) },
+			order:    []int{1, 2, 3, 4},
+		},
+	}
+
+	for name, tc := range testCases {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			t.Run("Head", func(t *testing.T) {
+				t.Parallel()
+
+				src, dst := tc.newQueue([]int{3, 1, 4, 2}), queue.NewLinked([]int{0})
+
+				elem, err := queue.Move[int](src, dst)
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+
+				if elem != tc.order[0] {
+					t.Fatalf("expected elem to be %d, got %d", tc.order[0], elem)
+				}
+
+				if elems := dst.ToSlice(); !reflect.DeepEqual([]int{0, tc.order[0]}, elems) {
+					t.Fatalf("expected destination elements to be %v, got %v", []int{0, tc.order[0]}, elems)
+				}
+
+				if size := src.Size(); size != 3 {
+					t.Fatalf("expected source size to be 3, got %d", size)
+				}
+			})
+
+			t.Run("Into", func(t *testing.T) {
+				t.Parallel()
+
+				src, dst := queue.NewLinked([]int{2}), tc.newQueue([]int{3, 1, 4})
+
+				if _, err := queue.Move[int](src, dst); err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+
+				if elems := queue.DrainQueue[int](dst, 4); !reflect.DeepEqual(tc.order, elems) {
+					t.Fatalf("expected destination elements to be %v, got %v", tc.order, elems)
+				}
+			})
+
+			t.Run("Matching", func(t *testing.T) {
+				t.Parallel()
+
+				src, dst := tc.newQueue([]int{3, 1, 4, 2}), queue.NewLinked([]int{})
+
+				even := func(elem int) bool { return elem%2 == 0 }
+
+				expected := tc.order[0]
+
+				for _, elem := range tc.order {
+					if even(elem) {
+						expected = elem
+
+						break
+					}
+				}
+
+				elem, err := queue.MoveMatching[int](src, dst, even)
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+
+				if elem != expected {
+					t.Fatalf("expected elem to be %d, got %d", expected, elem)
+				}
+
+				// the remaining elements keep their order.
+				remaining := make([]int, 0, 3)
+
+				for _, elem := range tc.order {
+					if elem != expected {
+						remaining = append(remaining, elem)
+					}
+				}
+
+				if elems := queue.DrainQueue[int](src, 4); !reflect.DeepEqual(remaining, elems) {
+					t.Fatalf("expected source elements to be %v, got %v", remaining, elems)
+				}
+			})
+
+			t.Run("NoMatch", func(t *testing.T) {
+				t.Parallel()
+
+				src, dst := tc.newQueue([]int{3, 1, 4, 2}), queue.NewLinked([]int{})
+
+				_, err := queue.MoveMatching[int](src, dst, func(elem int) bool { return elem > 4 })
+				if !errors.Is(err, queue.ErrNoElementsAvailable) {
+					t.Fatalf("expected error to be %v, got %v", queue.ErrNoElementsAvailable, err)
+				}
+
+				if size := src.Size(); size != 4 {
+					t.Fatalf("expected source size to be 4, got %d", size)
+				}
+			})
+
+			t.Run("Empty", func(t *testing.T) {
+				t.Parallel()
+
+				src, dst := tc.newQueue([]int{}), queue.NewLinked([]int{})
+
+				if _, err := queue.Move[int](src, dst); !errors.Is(err, queue.ErrNoElementsAvailable) {
+					t.Fatalf("expected error to be %v, got %v", queue.ErrNoElementsAvailable, err)
+				}
+			})
+
+			t.Run("RollbackOnFullDestination", func(t *testing.T) {
+				t.Parallel()
+
+				src := tc.newQueue([]int{3, 1, 4, 2})
+				dst := queue.NewBlocking([]int{0}, queue.WithCapacity(1))
+
+				elem, err := queue.Move[int](src, dst)
+				if !errors.Is(err, queue.ErrQueueIsFull) {
+					t.Fatalf("expected error to be %v, got %v", queue.ErrQueueIsFull, err)
+				}
+
+				if elem != tc.order[0] {
+					t.Fatalf("expected the rejected elem to be %d, got %d", tc.order[0], elem)
+				}
+
+				_, err = queue.MoveMatching[int](src, dst, func(elem int) bool { return elem == 4 })
+				if !errors.Is(err, queue.ErrQueueIsFull) {
+					t.Fatalf("expected error to be %v, got %v", queue.ErrQueueIsFull, err)
+				}
+
+				// the elements stay at their positions.
+				if elems := queue.DrainQueue[int](src, 4); !reflect.DeepEqual(tc.order, elems) {
+					t.Fatalf("expected source elements to be %v, got %v", tc.order, elems)
+				}
+
+				if elems := dst.ToSlice(); !reflect.DeepEqual([]int{0}, elems) {
+					t.Fatalf("expected destination elements to be %v, got %v", []int{0}, elems)
+				}
+			})
+		})
+	}
+
+	t.Run("BlockingUrgentLane", func(t *testing.T) {
+		t.Parallel()
+
+		src, dst := queue.NewBlocking([]int{3, 4}), queue.NewLinked([]int{})
+
+		_ = src.OfferUrgent(1)
+		_ = src.OfferUrgent(2)
+
+		if elem, err := queue.MoveMatching[int](src, dst, func(elem int) bool { return elem == 2 }); err != nil || elem != 2 {
+			t.Fatalf("expected elem to be 2 and no error, got %d and %v", elem, err)
+		}
+
+		if elem, err := queue.Move[int](src, dst); err != nil || elem != 1 {
+			t.Fatalf("expected elem to be 1 and no error, got %d and %v", elem, err)
+		}
+
+		if elems := src.ToSlice(); !reflect.DeepEqual([]int{3, 4}, elems) {
+			t.Fatalf("expected source elements to be %v, got %v", []int{3, 4}, elems)
+		}
+	})
+
+	t.Run("LinkedUrgentLane", func(t *testing.T) {
+		t.Parallel()
+
+		src, dst := queue.NewLinked([]int{4, 5}), queue.NewLinked([]int{})
+
+		_ = src.OfferUrgent(1)
+		_ = src.OfferUrgent(2)
+
+		if _, err := queue.MoveMatching[int](src, dst, func(elem int) bool { return elem == 2 }); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		// the urgent lane ends at the remaining urgent element.
+		_ = src.OfferUrgent(3)
+
+		if _, err := queue.MoveMatching[int](src, dst, func(elem int) bool { return elem == 5 }); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		_ = src.Offer(6)
+
+		if elems := src.ToSlice(); !reflect.DeepEqual([]int{1, 3, 4, 6}, elems) {
+			t.Fatalf("expected source elements to be %v, got %v", []int{1, 3, 4, 6}, elems)
+		}
+	})
+
+	t.Run("CircularWrapped", func(t *testing.T) {
+		t.Parallel()
+
+		src, dst := queue.NewCircular([]int{1, 2, 3, 4}, 4), queue.NewLinked([]int{})
+
+		_, _ = src.Get()
+		_, _ = src.Get()
+		_ = src.Offer(5)
+
+		if _, err := queue.MoveMatching[int](src, dst, func(elem int) bool { return elem == 4 }); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		_ = src.Offer(6)
+
+		if elems := src.ToSlice(); !reflect.DeepEqual([]int{3, 5, 6}, elems) {
+			t.Fatalf("expected source elements to be %v, got %v", []int{3, 5, 6}, elems)
+		}
+	})
+
+	t.Run("WakesConsumer", func(t *testing.T) {
+		t.Parallel()
+
+		src, dst := queue.NewLinked([]int{1}), queue.NewBlocking([]int{})
+
+		received := make(chan int)
+
+		go func() {
+			received <- dst.GetWait()
+		}()
+
+		time.Sleep(10 * time.Millisecond)
+
+		if _, err := queue.Move[int](src, dst); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if elem := <-received; elem != 1 {
+			t.Fatalf("expected elem to be 1, got %d", elem)
+		}
+	})
+
+	t.Run("ClosedDestination", func(t *testing.T) {
+		t.Parallel()
+
+		src, dst := queue.NewLinked([]int{1}), queue.NewBlocking([]int{})
+
+		dst.Close()
+
+		if _, err := queue.Move[int](src, dst); !errors.Is(err, queue.ErrQueueClosed) {
+			t.Fatalf("expected error to be %v, got %v", queue.ErrQueueClosed, err)
+		}
+
+		if size := src.Size(); size != 1 {
+			t.Fatalf("expected source size to be 1, got %d", size)
+		}
+	})
+
+	t.Run("Unsupported", func(t *testing.T) {
+		t.Parallel()
+
+		src, dst := queue.NewFromChannel(make(chan int, 1)), queue.NewLinked([]int{})
+
+		_ = src.Offer(1)
+
+		if _, err := queue.Move[int](src, dst); !errors.Is(err, queue.ErrUnsupportedOperation) {
+			t.Fatalf("expected error to be %v, got %v", queue.ErrUnsupportedOperation, err)
+		}
+
+		if _, err := queue.Move[int](dst, src); !errors.Is(err, queue.ErrUnsupportedOperation) {
+			t.Fatalf("expected error to be %v, got %v", queue.ErrUnsupportedOperation, err)
+		}
+	})
+
+	t.Run("SameQueue", func(t *testing.T) {
+		t.Parallel()
+
+		linkedQueue := queue.NewLinked([]int{1})
+
+		defer func() {
+			if p := recover(); p == nil {
+				t.Fatalf("expected Move to panic")
+			}
+		}()
+
+		_, _ = queue.Move[int](linkedQueue, linkedQueue)
+	})
+
+	t.Run("CallerTracking", func(t *testing.T) {
+		t.Parallel()
+
+		src := queue.NewLinked([]int{1}, queue.WithCallerTracking(1))
+		dst := queue.NewBlocking([]int{}, queue.WithCallerTracking(1))
+
+		_, _ = queue.Move[int](src, dst)
+
+		for name, ops := range map[string][]queue.OpRecord{
+			"source":      src.RecentOperations(),
+			"destination": dst.RecentOperations(),
+		} {
+			if len(ops) != 1 || ops[0].Op != "Move" {
+				t.Fatalf("expected the %s to record a Move, got %+v", name, ops)
+			}
+		}
+	})
+}
+
+func TestMoveAll(t *testing.T) {
+	t.Parallel()
+
+	t.Run("All", func(t *testing.T) {
+		t.Parallel()
+
+		src, dst := queue.NewBlocking([]int{1, 2, 3}), queue.NewLinked([]int{0})
+
+		src.Close()
+
+		n, err := queue.MoveAll[int](src, dst)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if n != 3 {
+			t.Fatalf("expected 3 elements to be moved, got %d", n)
+		}
+
+		if elems := dst.ToSlice(); !reflect.DeepEqual([]int{0, 1, 2, 3}, elems) {
+			t.Fatalf("expected destination elements to be %v, got %v", []int{0, 1, 2, 3}, elems)
+		}
+
+		if !src.IsEmpty() {
+			t.Fatalf("expected the source to be empty, got %v", src.ToSlice())
+		}
+	})
+
+	t.Run("DestinationFull", func(t *testing.T) {
+		t.Parallel()
+
+		src, dst := queue.NewLinked([]int{1, 2, 3}), queue.NewBlocking([]int{}, queue.WithCapacity(2))
+
+		n, err := queue.MoveAll[int](src, dst)
+		if !errors.Is(err, queue.ErrQueueIsFull) {
+			t.Fatalf("expected error to be %v, got %v", queue.ErrQueueIsFull, err)
+		}
+
+		if n != 2 {
+			t.Fatalf("expected 2 elements to be moved, got %d", n)
+		}
+
+		if elems := src.ToSlice(); !reflect.DeepEqual([]int{3}, elems) {
+			t.Fatalf("expected source elements to be %v, got %v", []int{3}, elems)
+		}
+	})
+
+	t.Run("DestinationClosed", func(t *testing.T) {
+		t.Parallel()
+
+		src, dst := queue.NewLinked([]int{1}), queue.NewBlocking([]int{})
+
+		dst.Close()
+
+		if n, err := queue.MoveAll[int](src, dst); n != 0 || !errors.Is(err, queue.ErrQueueClosed) {
+			t.Fatalf("expected no element to be moved and error %v, got %d and %v", queue.ErrQueueClosed, n, err)
+		}
+	})
+
+	t.Run("Unsupported", func(t *testing.T) {
+		t.Parallel()
+
+		src := queue.NewFromChannel(make(chan int, 1))
+
+		if _, err := queue.MoveAll[int](src, queue.NewLinked([]int{})); !errors.Is(err, queue.ErrUnsupportedOperation) {
+			t.Fatalf("expected error to be %v, got %v", queue.ErrUnsupportedOperation, err)
+		}
+	})
+}
+
+func TestMoveConcurrent(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Observers", func(t *testing.T) {
+		t.Parallel()
+
+		const elems = 500
+
+		initial := make([]int, elems)
+
+		for i := range initial {
+			initial[i] = i
+		}
+
+		src, dst := queue.NewBlocking(initial), queue.NewLinked([]int{})
+
+		done := make(chan struct{})
+
+		var wg sync.WaitGroup
+
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+
+				for {
+					if _, err := queue.Move[int](src, dst); err != nil {
+						return
+					}
+				}
+			}()
+		}
+
+		go func() {
+			wg.Wait()
+			close(done)
+		}()
+
+		// the elements only move from src to dst, thus an element absent
+		// from src is in dst afterwards, and an element in dst is absent
+		// from src afterwards.
+		for observing := true; observing; {
+			select {
+			case <-done:
+				observing = false
+			default:
+			}
+
+			if size := src.Size() + dst.Size(); size < elems {
+				t.Fatalf("expected no element to be observed in neither queue, observed %d elements", size)
+			}
+
+			if size := dst.Size() + src.Size(); size > elems {
+				t.Fatalf("expected no element to be observed in both queues, observed %d elements", size)
+			}
+
+			seen := make(map[int]int, elems)
+
+			for _, elem := range src.ToSlice() {
+				seen[elem]++
+			}
+
+			for _, elem := range dst.ToSlice() {
+				seen[elem]++
+			}
+
+			if len(seen) != elems {
+				t.Fatalf("expected all %d elements to be observed, observed %d", elems, len(seen))
+			}
+
+			inDst := dst.ToSlice()
+
+			for _, elem := range src.ToSlice() {
+				for _, moved := range inDst {
+					if elem == moved {
+						t.Fatalf("expected elem %d not to be observed in both queues", elem)
+					}
+				}
+			}
+		}
+
+		if size := dst.Size(); size != elems {
+			t.Fatalf("expected destination size to be %d, got %d", elems, size)
+		}
+	})
+
+	t.Run("OppositeDirections", func(t *testing.T) {
+		t.Parallel()
+
+		first := queue.NewBlocking([]int{1, 2, 3}, queue.WithCapacity(4))
+		second := queue.NewCircular([]int{4, 5, 6}, 6)
+
+		var wg sync.WaitGroup
+
+		for _, pair := range [][2]queue.Queue[int]{{first, second}, {second, first}} {
+			pair := pair
+
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+
+				for i := 0; i < 10_000; i++ {
+					_, _ = queue.Move[int](pair[0], pair[1])
+				}
+			}()
+		}
+
+		moved := make(chan struct{})
+
+		go func() {
+			wg.Wait()
+			close(moved)
+		}()
+
+		select {
+		case <-moved:
+		case <-time.After(10 * time.Second):
+			t.Fatalf("expected the moves in opposite directions not to deadlock")
+		}
+
+		if total := first.Size() + second.Size(); total != 6 {
+			t.Fatalf("expected 6 elements in total, got %d", total)
+		}
+	})
+}
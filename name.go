@@ -0,0 +1,52 @@
+package queue
+
+import (
+	"errors"
+	"fmt"
+)
+
+// QueueError is the error returned by the queues created with the WithName
+// option. It carries the name and the implementation of the queue along with
+// the error of the operation, which it wraps, so that errors.Is and errors.As
+// see through it.
+type QueueError struct {
+	// Name is the name provided using WithName.
+	Name string
+
+	// Queue is the implementation of the queue, such as "Blocking".
+	Queue string
+
+	// Err is the error of the operation.
+	Err error
+}
+
+// Error returns the name and the implementation of the queue along with the
+// error of the operation.
+func (e *QueueError) Error() string {
+	return fmt.Sprintf("%s queue %q: %v", e.Queue, e.Name, e.Err)
+}
+
+// Unwrap returns the error of the operation.
+func (e *QueueError) Unwrap() error {
+	return e.Err
+}
+
+// nameError wraps err in a QueueError if the queue is named. The errors of
+// the unnamed queues, as well as the errors already carrying a name, are
+// returned as is.
+func nameError(name, impl string, err error) error {
+	if err == nil || name == "" {
+		return err
+	}
+
+	return wrapQueueError(name, impl, err)
+}
+
+func wrapQueueError(name, impl string, err error) error {
+	var queueErr *QueueError
+	if errors.As(err, &queueErr) && queueErr.Name == name {
+		return err
+	}
+
+	return &QueueError{Name: name, Queue: impl, Err: err}
+}
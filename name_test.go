@@ -0,0 +1,150 @@
+package queue_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/adrianbrad/queue"
+)
+
+func TestWithName(t *testing.T) {
+	t.Parallel()
+
+	lessInt := func(elem, otherElem int) bool { return elem < otherElem }
+
+	type namedQueue interface {
+		queue.Queue[int]
+		Name() string
+	}
+
+	testCases := map[string]func(opts ...queue.Option) namedQueue{
+		"Blocking": func(opts ...queue.Option) namedQueue {
+			return queue.NewBlocking([]int{}, opts...)
+		},
+		"Circular": func(opts ...queue.Option) namedQueue {
+			return queue.NewCircular([]int{}, 2, opts...)
+		},
+		"Linked": func(opts ...queue.Option) namedQueue {
+			return queue.NewLinked([]int{}, opts...)
+		},
+		"Priority": func(opts ...queue.Option) namedQueue {
+			return queue.NewPriority([]int{}, lessInt, opts...)
+		},
+		"PriorityAny": func(opts ...queue.Option) namedQueue {
+			return queue.NewPriorityAny([]int{}, lessInt, opts...)
+		},
+	}
+
+	for impl, newQueue := range testCases {
+		impl, newQueue := impl, newQueue
+
+		t.Run(impl, func(t *testing.T) {
+			t.Parallel()
+
+			t.Run("Named", func(t *testing.T) {
+				t.Parallel()
+
+				q := newQueue(queue.WithName("jobs"))
+
+				if name := q.Name(); name != "jobs" {
+					t.Fatalf("expected name to be jobs, got %q", name)
+				}
+
+				_, err := q.Get()
+				if !errors.Is(err, queue.ErrNoElementsAvailable) {
+					t.Fatalf("expected error to be %v, got %v", queue.ErrNoElementsAvailable, err)
+				}
+
+				var queueErr *queue.QueueError
+				if !errors.As(err, &queueErr) {
+					t.Fatalf("expected a QueueError, got %T", err)
+				}
+
+				if queueErr.Name != "jobs" || queueErr.Queue != impl {
+					t.Fatalf("expected the error of the jobs %s queue, got %q %q", impl, queueErr.Name, queueErr.Queue)
+				}
+
+				expected := impl + ` queue "jobs": ` + queue.ErrNoElementsAvailable.Error()
+				if err.Error() != expected {
+					t.Fatalf("expected error message %q, got %q", expected, err.Error())
+				}
+			})
+
+			t.Run("Unnamed", func(t *testing.T) {
+				t.Parallel()
+
+				q := newQueue()
+
+				if name := q.Name(); name != "" {
+					t.Fatalf("expected no name, got %q", name)
+				}
+
+				// nolint: errorlint // the error must not be wrapped.
+				if _, err := q.Get(); err != queue.ErrNoElementsAvailable {
+					t.Fatalf("expected error to be %v, got %v", queue.ErrNoElementsAvailable, err)
+				}
+			})
+		})
+	}
+
+	t.Run("Distinguishable", func(t *testing.T) {
+		t.Parallel()
+
+		jobs := queue.NewBlocking([]int{}, queue.WithName("jobs"))
+		events := queue.NewBlocking([]int{}, queue.WithName("events"))
+
+		names := make([]string, 0, 2)
+
+		for _, q := range []*queue.Blocking[int]{jobs, events} {
+			_, err := q.Get()
+
+			var queueErr *queue.QueueError
+			if !errors.As(err, &queueErr) {
+				t.Fatalf("expected a QueueError, got %T", err)
+			}
+
+			names = append(names, queueErr.Name)
+		}
+
+		if names[0] != "jobs" || names[1] != "events" {
+			t.Fatalf("expected the errors of the jobs and events queues, got %v", names)
+		}
+	})
+
+	t.Run("WrappedOnce", func(t *testing.T) {
+		t.Parallel()
+
+		// UnmarshalJSON offers the elements using Offer, which names its
+		// errors as well.
+		q := queue.NewBlocking([]int{}, queue.WithName("jobs"), queue.WithCapacity(1))
+
+		err := q.UnmarshalJSON([]byte("[1,2]"))
+		if !errors.Is(err, queue.ErrQueueIsFull) {
+			t.Fatalf("expected error to be %v, got %v", queue.ErrQueueIsFull, err)
+		}
+
+		var queueErr *queue.QueueError
+		if !errors.As(err, &queueErr) {
+			t.Fatalf("expected a QueueError, got %T", err)
+		}
+
+		if errors.As(queueErr.Err, &queueErr) {
+			t.Fatalf("expected the error to be named once, got %v", err)
+		}
+	})
+}
+
+// TestUnnamedErrorAllocs is not parallel, since testing.AllocsPerRun counts
+// the allocations of all the goroutines.
+func TestUnnamedErrorAllocs(t *testing.T) {
+	blockingQueue := queue.NewBlocking([]int{})
+
+	allocs := testing.AllocsPerRun(100, func() {
+		_, _ = blockingQueue.Get()
+		_, _ = blockingQueue.Peek()
+	})
+
+	if allocs != 0 {
+		t.Fatalf("expected no allocations per op, got %.0f", allocs)
+	}
+}
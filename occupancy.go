@@ -0,0 +1,242 @@
+package queue
+
+import (
+	"fmt"
+)
+
+// checkInvariants enables the verification of the queue invariants whenever
+// a queue lock is released for writing. It is only set by the tests.
+var checkInvariants = false
+
+// occupancy accounts for the slots of a queue, enforcing its capacity.
+// Every element inserted into a queue is admitted and every element leaving
+// it is released, so that the capacity is checked in a single place,
+// including by the features adjusting the effective size of a queue.
+type occupancy struct {
+	// capacity is the number of slots of the queue, or unboundedCapacity.
+	capacity int
+
+	// count is the number of elements held by the queue and bytes their
+	// accounted size.
+	count int
+	bytes int
+
+	// reserved is the number of slots promised to elements not yet inserted
+	// and inFlight the number of slots held by elements retrieved but not
+	// yet acknowledged. Both take up capacity without being counted.
+	reserved int
+	inFlight int
+
+	// shared accounts for the elements in the CapacityGroup of the queue,
+	// if any.
+	shared *capacityMember
+
+	// limit applies the policy provided using WithHardLimit, if any.
+	limit *hardLimit
+}
+
+// newOccupancy returns the occupancy of an empty queue with the given
+// capacity, which is unbounded if nil.
+func newOccupancy(capacity *int) occupancy {
+	return occupancy{capacity: capacityOf(capacity)}
+}
+
+// share makes the elements of the queue take up slots of the group.
+// It must be called before any element is admitted.
+func (o *occupancy) share(group *CapacityGroup, wake func()) {
+	o.shared = group.join(wake)
+}
+
+// bounded returns true if the queue has a fixed capacity.
+func (o *occupancy) bounded() bool {
+	return o.capacity != unboundedCapacity
+}
+
+// used returns the number of slots taken up.
+func (o *occupancy) used() int {
+	return o.count + o.reserved + o.inFlight
+}
+
+// fits returns true if n more elements fit into the queue and its group.
+func (o *occupancy) fits(n int) bool {
+	if o.shared != nil && !o.shared.fits(n) {
+		return false
+	}
+
+	return !o.bounded() || o.used()+n <= o.capacity
+}
+
+// full returns true if no more elements fit into the queue.
+func (o *occupancy) full() bool {
+	return !o.fits(1)
+}
+
+// remaining returns the number of free slots, or unboundedCapacity if the
+// queue is unbounded and does not belong to a group.
+func (o *occupancy) remaining() int {
+	switch {
+	case o.shared == nil && !o.bounded():
+		return unboundedCapacity
+	case o.shared == nil:
+		return max(o.capacity-o.used(), 0)
+	case !o.bounded():
+		return o.shared.group.Remaining()
+	default:
+		return min(max(o.capacity-o.used(), 0), o.shared.group.Remaining())
+	}
+}
+
+// admit accounts for n elements of the given accumulated size entering the
+// queue. It returns the ErrQueueIsFull error, admitting none of them, if they
+// do not all fit.
+func (o *occupancy) admit(n, bytes int) error {
+	if o.bounded() && o.used()+n > o.capacity {
+		return ErrQueueIsFull
+	}
+
+	if err := o.limit.check(o.count, n); err != nil {
+		return err
+	}
+
+	if o.shared != nil && !o.shared.tryAcquire(n) {
+		return ErrQueueIsFull
+	}
+
+	o.count += n
+	o.bytes += bytes
+
+	return nil
+}
+
+// reserve reserves n slots for elements inserted one at a time using
+// fillReserved, so that the elements are counted as they are inserted. It
+// returns the ErrQueueIsFull error, reserving none of the slots, if the
+// elements do not all fit.
+func (o *occupancy) reserve(n int) error {
+	if err := o.admit(n, 0); err != nil {
+		return err
+	}
+
+	o.count -= n
+	o.reserved += n
+
+	return nil
+}
+
+// fillReserved accounts for n elements inserted into reserved slots.
+func (o *occupancy) fillReserved(n int) {
+	o.reserved -= n
+	o.count += n
+}
+
+// forceAdmit accounts for n elements entering the queue regardless of its
+// capacity, such as the elements re-offered to its head.
+func (o *occupancy) forceAdmit(n, bytes int) {
+	if o.shared != nil {
+		o.shared.acquire(n)
+	}
+
+	o.count += n
+	o.bytes += bytes
+}
+
+// releaseAdmission accounts for n elements of the given accumulated size
+// leaving the queue.
+func (o *occupancy) releaseAdmission(n, bytes int) {
+	if o.shared != nil {
+		o.shared.release(n)
+	}
+
+	o.count -= n
+	o.bytes -= bytes
+}
+
+// handOver accounts for an element leaving the queue while its slot is
+// handed over to an element entering it using takeOver, such as when they
+// are exchanged. Unlike releaseAdmission, it keeps the slot taken up in the
+// CapacityGroup of the queue, so that another queue of the group cannot take
+// it in between.
+func (o *occupancy) handOver() {
+	o.count--
+}
+
+// takeOver accounts for an element entering the slot handed over by
+// handOver.
+func (o *occupancy) takeOver() {
+	o.count++
+}
+
+// admitFitting accounts for n elements entering the queue if they fit into
+// the queue and its group, as fits reports, taking up the slots of the group
+// at once so that another queue of the group cannot take them in between.
+// Unlike admit, it does not apply the WithHardLimit policy.
+func (o *occupancy) admitFitting(n int) bool {
+	if o.bounded() && o.used()+n > o.capacity {
+		return false
+	}
+
+	if o.shared != nil && !o.shared.tryAcquire(n) {
+		return false
+	}
+
+	o.count += n
+
+	return true
+}
+
+// replace accounts for the elements of the queue being replaced by n
+// elements, reserving their slots as reserve does, so that they are counted
+// by fillReserved once inserted. The difference with the slots taken up by
+// the replaced elements is taken from, or returned to, the CapacityGroup of
+// the queue at once, so that another queue of the group cannot take the
+// slots in between. It returns the ErrQueueIsFull error, changing nothing, if
+// the elements do not all fit into the emptied queue.
+func (o *occupancy) replace(n int) error {
+	if o.bounded() && n+o.reserved+o.inFlight > o.capacity {
+		return ErrQueueIsFull
+	}
+
+	if err := o.limit.check(0, n); err != nil {
+		return err
+	}
+
+	if o.shared != nil {
+		switch delta := n - o.count; {
+		case delta > 0:
+			if !o.shared.tryAcquire(delta) {
+				return ErrQueueIsFull
+			}
+		case delta < 0:
+			o.shared.release(-delta)
+		}
+	}
+
+	o.count = 0
+	o.bytes = 0
+	o.reserved += n
+
+	return nil
+}
+
+// reset accounts for the queue holding exactly n elements, such as after
+// a Clear or a Reset. The reserved and in flight slots are kept.
+func (o *occupancy) reset(n int) {
+	if o.shared != nil {
+		if n > o.count {
+			o.shared.acquire(n - o.count)
+		} else {
+			o.shared.release(o.count - n)
+		}
+	}
+
+	o.count = n
+	o.bytes = 0
+}
+
+// verify panics if the counted elements do not match the number of elements
+// derived from the structure of the queue.
+func (o *occupancy) verify(structural int) {
+	if o.count != structural {
+		panic(fmt.Sprintf("queue occupancy counts %d elements, the queue holds %d", o.count, structural))
+	}
+}
@@ -0,0 +1,201 @@
+package queue
+
+import (
+	"errors"
+	"testing"
+)
+
+// the invariants are verified by the whole test suite, including the tests
+// of the queue_test package which are linked into the same binary.
+func init() {
+	checkInvariants = true
+}
+
+func TestOccupancy(t *testing.T) {
+	t.Parallel()
+
+	t.Run("AdmitBoundary", func(t *testing.T) {
+		t.Parallel()
+
+		capacity := 3
+
+		o := newOccupancy(&capacity)
+
+		if err := o.admit(2, 20); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if err := o.admit(2, 20); !errors.Is(err, ErrQueueIsFull) {
+			t.Fatalf("expected error to be %v, got %v", ErrQueueIsFull, err)
+		}
+
+		if o.count != 2 || o.bytes != 20 {
+			t.Fatalf("expected the rejected admission not to be counted, got %d elements and %d bytes", o.count, o.bytes)
+		}
+
+		if err := o.admit(1, 10); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if !o.full() {
+			t.Fatalf("expected occupancy to be full")
+		}
+
+		if remaining := o.remaining(); remaining != 0 {
+			t.Fatalf("expected remaining to be 0, got %d", remaining)
+		}
+	})
+
+	t.Run("ForceAdmit", func(t *testing.T) {
+		t.Parallel()
+
+		capacity := 1
+
+		o := newOccupancy(&capacity)
+
+		o.forceAdmit(3, 0)
+
+		if o.count != 3 {
+			t.Fatalf("expected count to be 3, got %d", o.count)
+		}
+
+		if remaining := o.remaining(); remaining != 0 {
+			t.Fatalf("expected remaining to be 0, got %d", remaining)
+		}
+
+		o.releaseAdmission(3, 0)
+
+		if !o.fits(1) {
+			t.Fatalf("expected an element to fit")
+		}
+	})
+
+	t.Run("ReservedAndInFlight", func(t *testing.T) {
+		t.Parallel()
+
+		capacity := 4
+
+		o := newOccupancy(&capacity)
+
+		o.reserved = 1
+		o.inFlight = 2
+
+		if err := o.admit(2, 0); !errors.Is(err, ErrQueueIsFull) {
+			t.Fatalf("expected error to be %v, got %v", ErrQueueIsFull, err)
+		}
+
+		if remaining := o.remaining(); remaining != 1 {
+			t.Fatalf("expected remaining to be 1, got %d", remaining)
+		}
+
+		o.reset(1)
+
+		if o.count != 1 || o.reserved != 1 || o.inFlight != 2 {
+			t.Fatalf("expected reset to keep the reserved and in flight slots, got %+v", o)
+		}
+
+		if !o.full() {
+			t.Fatalf("expected occupancy to be full")
+		}
+	})
+
+	t.Run("Unbounded", func(t *testing.T) {
+		t.Parallel()
+
+		o := newOccupancy(nil)
+
+		if o.bounded() {
+			t.Fatalf("expected occupancy to be unbounded")
+		}
+
+		if err := o.admit(1<<20, 0); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if remaining := o.remaining(); remaining != unboundedCapacity {
+			t.Fatalf("expected remaining to be %d, got %d", unboundedCapacity, remaining)
+		}
+	})
+
+	t.Run("Verify", func(t *testing.T) {
+		t.Parallel()
+
+		o := newOccupancy(nil)
+
+		o.forceAdmit(2, 0)
+
+		o.verify(2)
+
+		defer func() {
+			if r := recover(); r == nil {
+				t.Fatalf("expected panic")
+			}
+		}()
+
+		o.verify(1)
+	})
+}
+
+// FuzzOccupancy checks the occupancy against a model deriving its counts
+// from the admitted batches.
+func FuzzOccupancy(f *testing.F) {
+	f.Add(3, []byte{0, 0, 1, 0, 2, 0, 0, 1})
+	f.Add(-1, []byte{0, 0, 0, 1, 2, 1})
+	f.Add(0, []byte{0, 3, 1, 0})
+
+	f.Fuzz(func(t *testing.T, capacity int, ops []byte) {
+		if capacity < unboundedCapacity {
+			capacity = unboundedCapacity
+		}
+
+		o := newOccupancy(&capacity)
+
+		var batches []int
+
+		for i, op := range ops {
+			n := i%3 + 1
+
+			switch op % 4 {
+			case 0:
+				fits := capacity == unboundedCapacity || o.count+n <= capacity
+
+				err := o.admit(n, n*10)
+				if fits != (err == nil) {
+					t.Fatalf("expected admission of %d to succeed %t, got %v", n, fits, err)
+				}
+
+				if err == nil {
+					batches = append(batches, n)
+				}
+			case 1:
+				if len(batches) == 0 {
+					continue
+				}
+
+				o.releaseAdmission(batches[0], batches[0]*10)
+
+				batches = batches[1:]
+			case 2:
+				o.reset(0)
+
+				batches = batches[:0]
+			case 3:
+				o.forceAdmit(n, n*10)
+
+				batches = append(batches, n)
+			}
+
+			count := 0
+
+			for _, batch := range batches {
+				count += batch
+			}
+
+			o.verify(count)
+
+			if o.bytes != count*10 {
+				t.Fatalf("expected %d bytes, got %d", count*10, o.bytes)
+			}
+		}
+	})
+}
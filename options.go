@@ -1,7 +1,112 @@
 package queue
 
+import (
+	"context"
+	"io"
+	"time"
+)
+
 type options struct {
 	capacity *int
+	// nolint: containedctx // the context is only used at construction.
+	ctx   context.Context
+	clock Clock
+	// resetCloner holds a func(T) T, it is typed by the queue constructors.
+	resetCloner any
+	// resetBehavior is the state restored by Reset.
+	resetBehavior ResetBehavior
+	// overflow holds an overflowConfig[T], it is typed by the queue
+	// constructors. overflowSync and overflowWarn configure it.
+	overflow       any
+	overflowSync   OverflowSync
+	overflowWarn   func(err error)
+	waiterPriority bool
+	laneRatio      int
+	maxSpins       int
+	recentWindow   int
+	// equalFunc holds a func(T, T) bool, it is typed by the queue constructors.
+	equalFunc any
+	// autoFlush holds a func([]T), it is typed by the queue constructors.
+	autoFlush          any
+	autoFlushThreshold int
+	flushLinger        time.Duration
+	// sentinel holds a T, it is typed by the queue constructors.
+	sentinel any
+	// jsonCodec holds a jsonCodec[T], it is typed by the queue constructors.
+	jsonCodec     any
+	strictJSON    bool
+	streamingJSON bool
+	pollJitter    float64
+	stableOrder   bool
+	tieBreak      TieBreak
+	autoRebuild   int
+	// lessName is the name of the registered comparator of a Priority queue.
+	lessName           string
+	comparatorOverride bool
+	// capacityGroup is the group sharing its capacity with the queue.
+	capacityGroup *CapacityGroup
+	// recycler holds a recycler[T], it is typed by the queue constructors.
+	recycler any
+	// contentionSampleRate is the fraction of the lock acquisitions whose
+	// wait time is profiled.
+	contentionSampleRate float64
+	// propagator carries the values of the OfferCtx contexts.
+	propagator propagator
+	// conservationChecks enables the ledger of a Blocking or Priority queue.
+	conservationChecks bool
+	// enqueueTimes makes a Blocking queue record the enqueue time of its
+	// elements.
+	enqueueTimes bool
+	// callerDepth is the number of caller frames recorded per operation.
+	callerDepth int
+	// operationSummary holds a func(T) string, it is typed by the queue
+	// constructors.
+	operationSummary any
+	// comparatorSampleRate is the fraction of the Priority queue operations
+	// verifying the consistency of the less function.
+	comparatorSampleRate float64
+	// comparatorViolationHandler is called with the inconsistencies found.
+	comparatorViolationHandler func(err error)
+	// maxProjectedWait is the longest time an element offered to a Blocking
+	// queue may be projected to wait, the drain rate being measured over
+	// windows of projectedWaitWindow.
+	maxProjectedWait    time.Duration
+	projectedWaitWindow time.Duration
+	// bloomExpected and bloomFalsePositiveRate size the bloom filter of the
+	// Blocking and Linked queues.
+	bloomExpected          int
+	bloomFalsePositiveRate float64
+	// journal holds a journalConfig[T], it is typed by the queue
+	// constructors.
+	journal any
+	// name is the name of the queue, carried by its errors.
+	name string
+	// hardLimit is the size past which limitPolicy applies, nil if there
+	// is no hard limit.
+	hardLimit   *int
+	limitPolicy LimitPolicy
+	// validator holds a func(T) error, it is typed by the queue
+	// constructors.
+	validator any
+	// withoutOverwrite makes a full Circular queue reject the insertions.
+	withoutOverwrite bool
+	// maxStaleness is the age after which the snapshots serving the cached
+	// reads are refreshed.
+	maxStaleness time.Duration
+	// readSnapshotting makes the queues publish their size and head for the
+	// lock-free reads.
+	readSnapshotting bool
+	// idempotencyWindow is the duration for which the keys of the admitted
+	// elements are remembered, idempotencyKey holds a func(T) string, it is
+	// typed by the queue constructors.
+	idempotencyWindow time.Duration
+	idempotencyKey    any
+	// noNodePooling makes a Linked queue allocate a new node per insertion.
+	noNodePooling bool
+	// exclusiveConsumer restricts the removals from a Blocking queue to the
+	// consumer lease, reclaimed once unused for consumerLeaseTimeout.
+	exclusiveConsumer    bool
+	consumerLeaseTimeout time.Duration
 }
 
 // An Option configures a Queue using the functional options paradigm.
@@ -21,3 +126,1191 @@ func (c capacityOption) apply(opts *options) {
 func WithCapacity(capacity int) Option {
 	return capacityOption(capacity)
 }
+
+type withoutOverwriteOption struct{}
+
+func (withoutOverwriteOption) apply(opts *options) {
+	opts.withoutOverwrite = true
+}
+
+// WithoutOverwrite makes a full Circular queue reject the insertions with the
+// ErrQueueIsFull error, as the other bounded queues do, instead of
+// overwriting its oldest elements. OfferAll then inserts all the elements or
+// none of them, and OfferSome stops at the first element which does not fit.
+// It has no effect on the other queues.
+func WithoutOverwrite() Option {
+	return withoutOverwriteOption{}
+}
+
+type contextOption struct {
+	// nolint: containedctx // the option only carries the context to the
+	// queue constructor.
+	ctx context.Context
+}
+
+func (c contextOption) apply(opts *options) {
+	opts.ctx = c.ctx
+}
+
+// WithContext binds the lifecycle of a Blocking queue to the given context.
+// Once the context is done the queue is closed, as if Close was called, and
+// the errors returned afterwards wrap both ErrQueueClosed and the context
+// cause.
+//
+// No goroutine is started until the context is done. The context references
+// the queue only while goroutines wait on it, thus a queue which is no longer
+// used can be garbage collected before its context is done. If the
+// WithAutoFlush or WithReadSnapshotting option is provided, the context
+// references the queue until it is done, unless the queue is closed or
+// destroyed first, which unbinds it, thus close such queues bound to
+// long-lived contexts once they are no longer used.
+func WithContext(ctx context.Context) Option {
+	return contextOption{ctx: ctx}
+}
+
+type clockOption struct {
+	clock Clock
+}
+
+func (c clockOption) apply(opts *options) {
+	opts.clock = c.clock
+}
+
+// WithClock specifies the clock used by the time dependent functionality
+// of a queue. By default, the system clock is used.
+func WithClock(clock Clock) Option {
+	return clockOption{clock: clock}
+}
+
+type waiterPriorityOption struct{}
+
+func (waiterPriorityOption) apply(opts *options) {
+	opts.waiterPriority = true
+}
+
+// WithWaiterPriority reserves the elements of a Blocking queue for the
+// consumers waiting in GetWait: while there are waiting consumers, Get
+// returns the ErrNoElementsAvailable error and each element is handed to
+// the consumer that has been waiting the longest.
+// It trades throughput for fairness, since every insertion wakes up all
+// the waiting consumers.
+func WithWaiterPriority() Option {
+	return waiterPriorityOption{}
+}
+
+type laneRatioOption int
+
+func (l laneRatioOption) apply(opts *options) {
+	opts.laneRatio = int(l)
+}
+
+// WithLaneRatio bounds the starvation of the elements of a Blocking queue
+// offered using Offer by the elements offered using OfferUrgent: after
+// urgentPerNormal consecutive urgent elements are retrieved while the other
+// elements are waiting, the head of the other elements is retrieved before
+// the urgent lane resumes. Get, GetWait, Peek and the batch retrievals follow
+// the ratio, so that Peek returns the element the next Get retrieves.
+//
+// Clear, Iterator and the snapshots of the queue still return the urgent lane
+// first. Reset and Clear restart the count of consecutive urgent elements.
+// A ratio lower than or equal to 0, which is the default, always retrieves the
+// urgent lane first. It has no effect on the other queues.
+func WithLaneRatio(urgentPerNormal int) Option {
+	return laneRatioOption(urgentPerNormal)
+}
+
+type spinWaitOption int
+
+func (s spinWaitOption) apply(opts *options) {
+	opts.maxSpins = int(s)
+}
+
+// WithSpinWait makes the waiting methods of a Blocking queue, such as GetWait,
+// PeekWait and OfferWait, yield the processor and re-check the queue up to
+// maxSpins times before parking the calling goroutine, which lowers the
+// latency of the waits ended within a few microseconds at the cost of the
+// CPU time spent spinning. The queue lock is released while yielding, and
+// only locked again once another goroutine may have changed the queue.
+// A maxSpins lower than or equal to 0, which is the default, parks the
+// goroutines right away. It has no effect on the other queues.
+func WithSpinWait(maxSpins int) Option {
+	return spinWaitOption(maxSpins)
+}
+
+type conservationChecksOption struct{}
+
+func (conservationChecksOption) apply(opts *options) {
+	opts.conservationChecks = true
+}
+
+// WithConservationChecks makes a Blocking or Priority queue account for every
+// element it admits, in order to detect the elements lost or delivered twice:
+// each admitted element is assigned a hidden sequence ID, recorded in a
+// ledger until the element leaves the queue, and LedgerCheck reports the IDs
+// unaccounted for or accounted twice. It is meant for tests, as it allocates
+// and slows down every operation. It has no effect on the other queues.
+func WithConservationChecks() Option {
+	return conservationChecksOption{}
+}
+
+type enqueueTimesOption struct{}
+
+func (enqueueTimesOption) apply(opts *options) {
+	opts.enqueueTimes = true
+}
+
+// WithEnqueueTimes makes a Blocking queue record the time at which each
+// element is inserted, taken from the clock provided using WithClock, which
+// GetWaitAffinity uses to bound the time the elements it skips may wait.
+// The elements of the urgent lane carry no enqueue time, and the elements
+// restored using Reset or UnmarshalBinary are recorded as inserted then.
+// It has no effect on the other queues.
+func WithEnqueueTimes() Option {
+	return enqueueTimesOption{}
+}
+
+type lessNameOption string
+
+func (l lessNameOption) apply(opts *options) {
+	opts.lessName = string(l)
+}
+
+// WithLessName makes a Priority queue order its elements using the
+// comparator registered under name using RegisterLess, the lessFunc passed to
+// the constructor being ignored, and record the name in the JSON
+// checkpoints of the queue, so that UnmarshalJSONFrom rejects the
+// checkpoints recorded with another comparator, as described by
+// RegisterLess. The constructors panic if no comparator of the queue element
+// type is registered under name. It has no effect on the other queues.
+func WithLessName(name string) Option {
+	return lessNameOption(name)
+}
+
+type comparatorOverrideOption struct{}
+
+func (comparatorOverrideOption) apply(opts *options) {
+	opts.comparatorOverride = true
+}
+
+// WithComparatorOverride makes a Priority queue restore, using
+// UnmarshalJSONFrom or UnmarshalBinary, the checkpoints recorded with another
+// comparator than its own, instead of returning the ErrComparatorMismatch
+// error.
+// The restored elements are ordered using the comparator of the queue.
+// It has no effect on the other queues.
+func WithComparatorOverride() Option {
+	return comparatorOverrideOption{}
+}
+
+type recentWindowOption int
+
+func (r recentWindowOption) apply(opts *options) {
+	opts.recentWindow = int(r)
+}
+
+// WithRecentWindow makes a Linked queue keep track of its n most recently
+// offered elements, so that ContainsRecent calls with a length of at most n
+// cost O(n) instead of O(size).
+func WithRecentWindow(n int) Option {
+	return recentWindowOption(n)
+}
+
+type equalFuncOption struct {
+	equal any
+}
+
+func (e equalFuncOption) apply(opts *options) {
+	opts.equalFunc = e.equal
+}
+
+// WithEqualFunc specifies the function used by the Contains method of the
+// Priority and PriorityAny queues to compare their elements.
+// Priority compares the elements using the == operator by default.
+// The constructors panic if T does not match the queue element type.
+func WithEqualFunc[T any](equal func(elem, otherElem T) bool) Option {
+	return equalFuncOption{equal: equal}
+}
+
+type autoFlushOption struct {
+	threshold int
+	flush     any
+}
+
+func (a autoFlushOption) apply(opts *options) {
+	opts.autoFlushThreshold = a.threshold
+	opts.autoFlush = a.flush
+}
+
+// WithAutoFlush makes a Blocking or Linked queue drain all its elements,
+// as Clear does, whenever an insertion makes it reach the threshold size, and
+// pass them to flush. A threshold lower than 1 disables the size trigger,
+// which is useful along with WithFlushLinger.
+//
+// flush is never invoked while holding the queue lock, nor on the goroutine
+// inserting the element: the batches are flushed one at a time, in the order
+// in which they were drained, on a goroutine owned by the queue. Every
+// element is flushed exactly once, even if elements are inserted while a
+// batch is flushed.
+// The elements left below the threshold are flushed by Blocking.Close and
+// Linked.Flush.
+// The constructors panic if T does not match the queue element type.
+func WithAutoFlush[T any](threshold int, flush func([]T)) Option {
+	return autoFlushOption{threshold: threshold, flush: flush}
+}
+
+type flushLingerOption time.Duration
+
+func (f flushLingerOption) apply(opts *options) {
+	opts.flushLinger = time.Duration(f)
+}
+
+// WithFlushLinger makes a queue created with the WithAutoFlush option also
+// drain its elements once d elapsed, on the queue clock, since an element was
+// inserted into the empty queue, or since the queue was created with initial
+// elements, even if the threshold was not reached.
+func WithFlushLinger(d time.Duration) Option {
+	return flushLingerOption(d)
+}
+
+type sentinelOption struct {
+	sentinel any
+}
+
+func (s sentinelOption) apply(opts *options) {
+	opts.sentinel = s.sentinel
+}
+
+// WithSentinel reserves the sentinel value of a Blocking queue as the end of
+// stream marker, also known as poison pill. Offering the sentinel value
+// returns the ErrReservedSentinel error, the sentinels are only inserted
+// using OfferSentinel, after which the queue accepts no more elements.
+// The constructors panic if T does not match the queue element type.
+func WithSentinel[T comparable](sentinel T) Option {
+	return sentinelOption{sentinel: sentinel}
+}
+
+type jsonCodecOption struct {
+	codec any
+}
+
+func (j jsonCodecOption) apply(opts *options) {
+	opts.jsonCodec = j.codec
+}
+
+// WithJSONCodec specifies the functions used to encode and decode each
+// element by MarshalJSON, MarshalJSONTo, UnmarshalJSONFrom and Dump, instead
+// of encoding/json, which ignores the unexported fields of the structs.
+// The elements are still written as a JSON array, thus marshal must return
+// valid JSON. A nil function falls back to encoding/json.
+// The constructors panic if T does not match the queue element type.
+func WithJSONCodec[T any](
+	marshal func(T) ([]byte, error),
+	unmarshal func([]byte) (T, error),
+) Option {
+	return jsonCodecOption{codec: jsonCodec[T]{marshal: marshal, unmarshal: unmarshal}}
+}
+
+type strictJSONOption struct{}
+
+func (strictJSONOption) apply(opts *options) {
+	opts.strictJSON = true
+}
+
+// WithStrictJSON makes the JSON marshalling methods of a queue return the
+// ErrLossyJSON error instead of silently losing data, whenever encoding/json
+// encodes a non-zero element as the zero value of its type, as it does for a
+// struct holding only unexported fields. It has no effect on the elements
+// encoded using the marshal function provided with WithJSONCodec.
+func WithStrictJSON() Option {
+	return strictJSONOption{}
+}
+
+type streamingJSONOption struct{}
+
+func (streamingJSONOption) apply(opts *options) {
+	opts.streamingJSON = true
+}
+
+// WithStreamingJSON makes UnmarshalJSON and UnmarshalJSONFrom remove the
+// elements of the queue once the start of the array is read, then insert the
+// decoded elements as they are decoded, using the same semantics as Offer,
+// and stop at the first element that cannot be decoded or inserted, leaving
+// the elements inserted before it in the queue.
+//
+// By default the elements are all decoded before replacing the elements of
+// the queue at once, so that the queue is left unchanged on error, which
+// transiently holds a copy of the decoded elements. Streaming trades this
+// atomicity for constant memory.
+func WithStreamingJSON() Option {
+	return streamingJSONOption{}
+}
+
+type pollJitterOption float64
+
+func (p pollJitterOption) apply(opts *options) {
+	opts.pollJitter = float64(p)
+}
+
+// WithPollJitter makes Poll wait, between its attempts, for the polling
+// interval plus a random duration of up to the given fraction of the
+// interval, so that the pollers started together spread their attempts.
+// The fraction is capped to 1. Poll never waits less than the interval.
+func WithPollJitter(jitter float64) Option {
+	return pollJitterOption(jitter)
+}
+
+type stableOrderOption struct{}
+
+func (stableOrderOption) apply(opts *options) {
+	opts.stableOrder = true
+}
+
+// WithStableOrder makes a Priority queue retrieve the equal elements in the
+// order in which they were offered, the initial elements being offered first.
+// Every ordering exposed by the queue, including MarshalJSON, Iterator and
+// Clear, then depends only on the sequence of insertions, regardless of the
+// internal heap layout. It has no effect on the other queues.
+func WithStableOrder() Option {
+	return stableOrderOption{}
+}
+
+// TieBreak specifies the order in which a Priority queue retrieves the
+// elements its less function considers equal.
+type TieBreak int
+
+const (
+	// TieBreakFIFO retrieves the equal elements in the order in which they
+	// were offered.
+	TieBreakFIFO TieBreak = iota
+
+	// TieBreakLIFO retrieves the most recently offered of the equal
+	// elements first.
+	TieBreakLIFO
+)
+
+type tieBreakOption TieBreak
+
+func (t tieBreakOption) apply(opts *options) {
+	opts.stableOrder = true
+	opts.tieBreak = TieBreak(t)
+}
+
+// WithTieBreak makes a Priority queue order the equal elements by their
+// insertion, as WithStableOrder does, in the given direction: TieBreakFIFO
+// is equivalent to WithStableOrder, TieBreakLIFO retrieves the most recently
+// offered of the equal elements first, the initial elements being offered
+// first. The elements exchanged or updated are ordered as the last offered.
+// The unequal elements are ordered by the less function alone, and every
+// ordering exposed by the queue, including MarshalJSON, Iterator and Clear,
+// follows the tie break. It has no effect on the other queues.
+func WithTieBreak(tieBreak TieBreak) Option {
+	return tieBreakOption(tieBreak)
+}
+
+type autoRebuildOption int
+
+func (a autoRebuildOption) apply(opts *options) {
+	opts.autoRebuild = int(a)
+}
+
+// WithAutoRebuild makes a Priority queue rebuild its heap, as Rebuild does,
+// after every everyNOps insertions and removals of elements. Each rebuild
+// sorts the elements, thus everyNOps should be large compared to the size of
+// the queue. The option is ignored if everyNOps is not positive.
+// It has no effect on the other queues.
+func WithAutoRebuild(everyNOps int) Option {
+	return autoRebuildOption(everyNOps)
+}
+
+type comparatorChecksOption float64
+
+func (c comparatorChecksOption) apply(opts *options) {
+	opts.comparatorSampleRate = float64(c)
+}
+
+// WithComparatorChecks makes a Priority queue verify the consistency of its
+// less function on the given fraction of its operations: an inserted element
+// must not be less than itself, nor both less and greater than a few random
+// resident elements, and a removed element must not be greater than the new
+// head of the queue. The inconsistencies are passed to the handler provided
+// using WithComparatorViolationHandler, by default the queue panics.
+//
+// A sample rate of 1 verifies every operation, a sample rate lower than or
+// equal to 0 disables the checks, which is the default.
+// It has no effect on the other queues.
+func WithComparatorChecks(sampleRate float64) Option {
+	return comparatorChecksOption(sampleRate)
+}
+
+type comparatorViolationHandlerOption func(err error)
+
+func (c comparatorViolationHandlerOption) apply(opts *options) {
+	opts.comparatorViolationHandler = c
+}
+
+// WithComparatorViolationHandler specifies the function called with the
+// inconsistencies of the less function found by the checks enabled using
+// WithComparatorChecks, instead of panicking. The error wraps the
+// ErrComparatorInconsistent error and renders the offending elements.
+//
+// The handler runs while holding the queue lock, thus it must not call the
+// queue methods.
+func WithComparatorViolationHandler(handler func(err error)) Option {
+	return comparatorViolationHandlerOption(handler)
+}
+
+type sharedCapacityOption struct {
+	group *CapacityGroup
+}
+
+func (s sharedCapacityOption) apply(opts *options) {
+	opts.capacityGroup = s.group
+}
+
+// WithSharedCapacity makes a Blocking queue take up the slots of the given
+// group, in addition to its own capacity, if any. An element is only inserted
+// if it fits into both, the producers waiting in OfferWait being woken up
+// when another queue of the group releases some slots, so that the
+// backpressure of a full group propagates to all of its queues. The initial
+// elements and the elements re-offered to the head of the queue take up
+// slots regardless of the group capacity.
+// It has no effect on the other queues.
+func WithSharedCapacity(group *CapacityGroup) Option {
+	return sharedCapacityOption{group: group}
+}
+
+type maxProjectedWaitOption struct {
+	maxWait, window time.Duration
+}
+
+func (m maxProjectedWaitOption) apply(opts *options) {
+	opts.maxProjectedWait = m.maxWait
+	opts.projectedWaitWindow = m.window
+}
+
+// WithMaxProjectedWait makes a Blocking queue reject the elements which
+// would wait longer than maxWait to be retrieved, given the current size of
+// the queue and its drain rate: the number of elements retrieved per window,
+// measured on the queue clock and averaged over the recent windows with
+// exponentially decreasing weights. The windows during which the queue stays
+// empty are not measured.
+//
+// Offer, TryOffer, OfferAll and OfferHandle then return a *ProjectedWaitError,
+// wrapping the ErrWouldExceedWait error, instead of inserting the elements.
+// The elements are admitted until an element is retrieved for the first time.
+// OfferWait, OfferCtx and OfferUrgent are not affected.
+// It has no effect on the other queues.
+func WithMaxProjectedWait(maxWait, window time.Duration) Option {
+	return maxProjectedWaitOption{maxWait: maxWait, window: window}
+}
+
+type callerTrackingOption int
+
+func (c callerTrackingOption) apply(opts *options) {
+	opts.callerDepth = int(c)
+}
+
+// WithCallerTracking makes a queue record its last mutating operations,
+// such as the insertions, the retrievals and the removals of all the
+// elements, in order to find out which code drained or filled the queue.
+// Each record holds the queue method called, the time of the operation, the
+// summary of the element provided using WithOperationSummary and up to depth
+// frames of the caller stack, retrieved using the RecentOperations method of
+// the queue. The records are kept in a fixed ring of 64 operations, the
+// oldest ones being overwritten.
+//
+// Recording an operation captures the caller stack, which is costly, thus
+// the option is meant for debugging. A depth lower than or equal to 0, which
+// is the default, disables the recording. It has no effect on ChanQueue.
+func WithCallerTracking(depth int) Option {
+	return callerTrackingOption(depth)
+}
+
+type operationSummaryOption struct {
+	summary any
+}
+
+func (o operationSummaryOption) apply(opts *options) {
+	opts.operationSummary = o.summary
+}
+
+// WithOperationSummary specifies the function summarizing the elements in
+// the operations recorded by the WithCallerTracking option. Without it the
+// elements are not summarized.
+// The constructors panic if T does not match the queue element type.
+func WithOperationSummary[T any](summary func(T) string) Option {
+	return operationSummaryOption{summary: summary}
+}
+
+type contentionProfilingOption float64
+
+func (c contentionProfilingOption) apply(opts *options) {
+	opts.contentionSampleRate = float64(c)
+}
+
+// WithContentionProfiling makes a queue measure, for the given fraction of
+// its operations, the time spent waiting to acquire the queue lock. The wait
+// times are accumulated per queue method and retrieved using the
+// ContentionProfile method of the queue.
+//
+// A sample rate of 1 profiles every operation, a sample rate lower than or
+// equal to 0 disables the profiling, which is the default.
+func WithContentionProfiling(sampleRate float64) Option {
+	return contentionProfilingOption(sampleRate)
+}
+
+type contextPropagationOption propagator
+
+func (c contextPropagationOption) apply(opts *options) {
+	opts.propagator = propagator(c)
+}
+
+// WithContextPropagation makes a Blocking queue carry request scoped values,
+// such as trace identifiers, from the producers to the consumers: OfferCtx
+// stores, along with the element, the value returned by extract for the
+// producer context, and GetWaitCtx returns the context obtained by calling
+// inject with the consumer context and the stored value. A nil value is not
+// stored. The values are dropped along with their elements, the retrievals
+// other than GetWaitCtx dropping them as well.
+// It has no effect on the other queues.
+func WithContextPropagation(
+	extract func(context.Context) any,
+	inject func(context.Context, any) context.Context,
+) Option {
+	return contextPropagationOption{extract: extract, inject: inject}
+}
+
+type recyclerOption struct {
+	recycler any
+}
+
+func (r recyclerOption) apply(opts *options) {
+	opts.recycler = r.recycler
+}
+
+// WithRecycler makes a queue of pooled elements return the elements it
+// discards internally to their pool, by calling release exactly once for
+// each of them. The elements handed to the callers, by Get, Clear, Iterator,
+// Exchange, Destroy, Circular.OfferWithEvicted or the flush function of
+// WithAutoFlush, are owned by the callers and are not released.
+//
+// The elements are released when they are:
+//   - overwritten by the insertions into a full Circular queue;
+//   - replaced by the initial elements on Reset and ResetStrict;
+//   - replaced by the elements restored by UnmarshalBinary;
+//   - exceeding the capacity at creation;
+//   - rejected by UnmarshalJSONFrom, after being decoded;
+//   - re-offered by ConsumeBatches to a destroyed Blocking queue;
+//   - dropped by ProcessEach, being neither retried nor dead-lettered;
+//   - cancelled using an ElementHandle;
+//   - removed or replaced by the Remove and Update methods of a Priority
+//     queue.
+//
+// The elements decoded by UnmarshalJSONFrom are acquired using acquire and
+// decoded into, unless WithJSONCodec provides an unmarshal function.
+// The JSON marshalling methods encode the elements while holding the queue
+// lock, so that none of them is released while being encoded.
+//
+// Since Reset reinserts the initial elements, provide WithResetCloner along
+// with WithRecycler in order for Reset to insert fresh copies of them.
+// The constructors panic if T does not match the queue element type.
+func WithRecycler[T any](acquire func() T, release func(T)) Option {
+	return recyclerOption{recycler: recycler[T]{acquire: acquire, release: release}}
+}
+
+type resetClonerOption struct {
+	clone any
+}
+
+func (r resetClonerOption) apply(opts *options) {
+	opts.resetCloner = r.clone
+}
+
+// WithResetCloner specifies a function used to deep copy the initial
+// elements of a queue. The queue stores clones of the initial elements at
+// construction, and Reset restores fresh clones of them every time, so that
+// mutations of the elements retrieved from the queue do not alter the state
+// restored by Reset. This is useful for pointer element types.
+//
+// Without this option the initial elements are copied shallowly.
+// The constructors panic if T does not match the queue element type.
+func WithResetCloner[T any](clone func(T) T) Option {
+	return resetClonerOption{clone: clone}
+}
+
+// ResetBehavior specifies the state Reset restores a queue to.
+type ResetBehavior int
+
+const (
+	// ResetInitial makes Reset replace the elements of the queue with the
+	// elements provided at creation, which the queue keeps a copy of.
+	ResetInitial ResetBehavior = iota
+
+	// ResetEmpty makes Reset discard all the elements of the queue. The
+	// elements provided at creation are not retained.
+	ResetEmpty
+)
+
+type resetBehaviorOption ResetBehavior
+
+func (r resetBehaviorOption) apply(opts *options) {
+	opts.resetBehavior = ResetBehavior(r)
+}
+
+// WithResetBehavior specifies the state Reset restores a queue to. By
+// default, ResetInitial, the queues keep a copy of the elements provided at
+// creation for Reset to restore. ResetEmpty makes Reset empty the queue
+// instead, sparing the copy, so that large initial datasets can be reclaimed
+// by the garbage collector once retrieved. The consumers waiting on a
+// Blocking queue emptied by Reset keep waiting.
+// It has no effect on the ChanQueue.
+func WithResetBehavior(behavior ResetBehavior) Option {
+	return resetBehaviorOption(behavior)
+}
+
+type bloomFilterOption struct {
+	expectedElems     int
+	falsePositiveRate float64
+}
+
+func (b bloomFilterOption) apply(opts *options) {
+	opts.bloomExpected = b.expectedElems
+	opts.bloomFalsePositiveRate = b.falsePositiveRate
+}
+
+// WithBloomFilter makes the Blocking and Linked queues maintain a counting
+// bloom filter of their elements, sized for expectedElems elements and the
+// given false positive rate. Contains then consults the filter first and
+// returns false without scanning the queue if the filter rules the element
+// out, which is the case for most of the elements not queued. The other
+// elements are checked by scanning the queue, so Contains never reports a
+// false positive nor a false negative.
+//
+// The filter takes one byte per cell, about 10 bytes per expected element
+// for a 1% false positive rate. Holding more elements than expected raises
+// the false positive rate, not the size of the filter.
+// The option is ignored if expectedElems is not positive or if
+// falsePositiveRate is not between 0 and 1, exclusive.
+// It has no effect on the other queues.
+func WithBloomFilter(expectedElems int, falsePositiveRate float64) Option {
+	return bloomFilterOption{
+		expectedElems:     expectedElems,
+		falsePositiveRate: falsePositiveRate,
+	}
+}
+
+type journalOption struct {
+	journal any
+}
+
+func (j journalOption) apply(opts *options) {
+	opts.journal = j.journal
+}
+
+// WithJournal makes a queue append a record of each of its mutating
+// operations to w, so that its state can be reproduced using Replay. Each
+// record holds the kind of the operation, the element involved, encoded using
+// codec, the time of the operation, taken from the clock provided using
+// WithClock, and the size of the queue after the operation. The initial
+// elements of the queue are not recorded.
+//
+// The operations are recorded by their effect: an Exchange is recorded as a
+// JournalGet followed by a JournalOffer, except by the Priority queues, an
+// Iterator call as a JournalClear, and a Move as a JournalGet or a
+// JournalRemove in the source queue and a JournalOffer in the destination
+// queue.
+//
+// Each record is written as its length, as a uvarint, followed by the kind
+// of the operation as a byte, the time as varint Unix nanoseconds, the size
+// as a uvarint, the position of the element as a uvarint for the
+// JournalRemove and JournalUpdate records, and the encoded element, except
+// for the JournalClear and JournalReset records.
+//
+// The records are buffered and written by a separate goroutine, so that
+// the queue lock is not held while writing. Once 1 MiB of records is waiting
+// to be written, the mutating operations wait for the writer, holding the
+// queue lock. SyncJournal, as well as the Close and Destroy methods of a
+// Blocking queue, wait until the buffered records are written.
+// If codec or w returns an error, the journal stops recording.
+// It has no effect on the ChanQueue.
+// The constructors panic if T does not match the queue element type.
+func WithJournal[T any](w io.Writer, codec JournalCodec[T]) Option {
+	return journalOption{journal: journalConfig[T]{w: w, codec: codec}}
+}
+
+type diskOverflowOption struct {
+	overflow any
+}
+
+func (d diskOverflowOption) apply(opts *options) {
+	opts.overflow = d.overflow
+}
+
+// WithDiskOverflow makes a Blocking queue append the elements offered once
+// it is full to segment files in dir, encoded using codec, instead of
+// rejecting them or waiting for capacity, so that they survive a restart of
+// the process. A new segment is started once a segment holds segmentBytes.
+// As the consumers drain the queue, it is refilled from the oldest segment,
+// and once the overflow holds elements the offered elements are appended to
+// it as well, so that the elements are retrieved in FIFO order across the
+// memory and disk boundary. The segments read entirely are removed.
+//
+// The constructor resumes the segments left in dir, refilling the queue
+// after its initial elements. The records cut short by a crash, or failing
+// their checksum, are truncated at the end of their segment and reported to
+// the function provided using WithOverflowWarnings. An I/O error is reported
+// the same way, after which the queue stops overflowing.
+//
+// The elements inserted at the tail one at a time overflow, by Offer,
+// OfferSome, OfferWait, OfferCtx and the like, the elements being stored
+// without the value propagated by OfferCtx. OfferAll, OfferUrgent and the
+// other insertions are rejected when the queue is full, as without the
+// option. Size and the other reads
+// only account for the elements in memory, see OverflowSize. The segments are
+// accessed while holding the queue lock.
+// It has no effect on the other queues.
+// The constructors panic if T does not match the queue element type.
+func WithDiskOverflow[T any](dir string, segmentBytes int64, codec JournalCodec[T]) Option {
+	return diskOverflowOption{overflow: overflowConfig[T]{dir: dir, segmentBytes: segmentBytes, codec: codec}}
+}
+
+type overflowSyncOption OverflowSync
+
+func (o overflowSyncOption) apply(opts *options) {
+	opts.overflowSync = OverflowSync(o)
+}
+
+// WithOverflowSync specifies when the segments written by a queue created
+// with the WithDiskOverflow option are synced to disk. By default,
+// OverflowSyncNone, the syncing is left to the operating system.
+func WithOverflowSync(sync OverflowSync) Option {
+	return overflowSyncOption(sync)
+}
+
+type overflowWarningsOption func(err error)
+
+func (o overflowWarningsOption) apply(opts *options) {
+	opts.overflowWarn = o
+}
+
+// WithOverflowWarnings specifies a function called with the corrupted records
+// truncated, the elements failing to be decoded and the I/O errors of a queue
+// created with the WithDiskOverflow option. It is called while holding the
+// queue lock, thus it must be fast and must not call the queue methods.
+func WithOverflowWarnings(warn func(err error)) Option {
+	return overflowWarningsOption(warn)
+}
+
+type nameOption struct {
+	name string
+}
+
+func (n nameOption) apply(opts *options) {
+	opts.name = n.name
+}
+
+// WithName names the queue, so that its errors can be told apart from the
+// errors of other queues. The errors returned by a named queue are
+// QueueError values carrying the name, which still match the sentinel errors
+// using errors.Is. The errors of an unnamed queue are returned as is.
+// WriteDumps names the dump file of the queue after it, unless another name
+// is provided.
+// It has no effect on the ChanQueue.
+func WithName(name string) Option {
+	return nameOption{name: name}
+}
+
+type hardLimitOption struct {
+	limit  int
+	policy LimitPolicy
+}
+
+func (h hardLimitOption) apply(opts *options) {
+	limit := max(h.limit, 0)
+
+	opts.hardLimit = &limit
+	opts.limitPolicy = h.policy
+}
+
+// WithHardLimit guards a queue against growing without bound, such as when
+// its consumers fail: the insertions which would take its size past limit
+// are handled by policy, which either rejects them using ReturnError, panics
+// using Panic, or reports the crossing using Callback. Unlike WithCapacity,
+// which bounds a queue by design, the limit is a safety net, checked using
+// a single comparison in the insertion paths. The insertions ignoring the
+// capacity, such as OfferSentinel and the re-insertions of Exchange and
+// Rotate, are not checked.
+// It has no effect on the Circular queue and the ChanQueue.
+func WithHardLimit(limit int, policy LimitPolicy) Option {
+	return hardLimitOption{limit: limit, policy: policy}
+}
+
+type validatorOption struct {
+	validate any
+}
+
+func (v validatorOption) apply(opts *options) {
+	opts.validator = v.validate
+}
+
+// WithValidator makes a queue check the elements inserted into it using
+// validate. The insertions of an element for which validate returns an error
+// fail with an InvalidElementError wrapping it, which matches the
+// ErrInvalidElement error using errors.Is, leaving the queue unchanged: the
+// element takes no capacity and no consumer is woken up.
+//
+// The element is validated before any other check, thus an invalid element
+// is reported as such even if the queue is full or closed, or if a Priority
+// queue already holds an equal element in OfferBounded. OfferWait and OfferCtx
+// validate the element before waiting for capacity, and the bulk insertions
+// which insert all the elements or none of them, such as OfferAll, validate
+// every element first. Move validates the element against the destination
+// queue, leaving it in the source queue if it is rejected.
+//
+// The initial elements which are rejected are dropped by the constructors,
+// before those exceeding the capacity, and released to the pool provided using
+// WithRecycler. Neither Reset nor the elements moved back to the queue by its
+// own operations, such as Rotate, are validated again.
+// validate is called while holding the queue lock, thus it must be fast and
+// must not call the queue methods.
+// It has no effect on the ChanQueue.
+// The constructors panic if T does not match the queue element type.
+func WithValidator[T any](validate func(elem T) error) Option {
+	return validatorOption{validate: validate}
+}
+
+type cachedReadsOption time.Duration
+
+func (c cachedReadsOption) apply(opts *options) {
+	opts.maxStaleness = time.Duration(c)
+}
+
+// WithCachedReads makes Size, IsEmpty, Peek, ToSlice and PeekN serve their
+// results from snapshots of the queue, for read paths polling a queue more
+// often than they need its exact state, such as metrics. A snapshot is taken
+// by the first read after the previous one expired, holding the queue lock
+// for reading, and expires once maxStaleness elapses on the clock provided
+// using WithClock. The reads in between only load the snapshot atomically.
+//
+// The mutations do not refresh the snapshots, which age out, thus the cached
+// reads may not reflect the mutations of the last maxStaleness, including the
+// ones of the calling goroutine. The size and the head are refreshed apart
+// from the elements returned by ToSlice and PeekN, so that reading the size
+// does not copy the elements, thus the two may have been taken at different
+// times. The other reads, such as Contains, Each, At and InspectPage, are
+// exact.
+// The option is ignored if maxStaleness is not positive.
+// It has no effect on the ChanQueue.
+func WithCachedReads(maxStaleness time.Duration) Option {
+	return cachedReadsOption(maxStaleness)
+}
+
+type readSnapshottingOption struct{}
+
+func (readSnapshottingOption) apply(opts *options) {
+	opts.readSnapshotting = true
+}
+
+// WithReadSnapshotting makes Size, IsEmpty and Peek serve their results
+// without acquiring the queue lock, for read paths needing a bounded latency
+// while writers contend for the lock, such as dashboards. Every time the queue
+// lock is released for writing, the size and the head of the queue are
+// published atomically, along with a version, which only copies the head
+// element. The reads load the last published state, which is a state the
+// queue was in, although it may not reflect the mutations completed by the
+// other goroutines meanwhile.
+//
+// Contains answers without acquiring the lock if the last published state is
+// empty or its head is the element, and scans the queue otherwise. The other
+// reads, such as ToSlice and PeekN, are exact. The option takes precedence
+// over WithCachedReads for Size, IsEmpty and Peek.
+// It has no effect on the Linked, Priority and ChanQueue queues.
+func WithReadSnapshotting() Option {
+	return readSnapshottingOption{}
+}
+
+type idempotencyOption struct {
+	window time.Duration
+	key    any
+}
+
+func (i idempotencyOption) apply(opts *options) {
+	opts.idempotencyWindow = i.window
+	opts.idempotencyKey = i.key
+}
+
+// WithIdempotencyWindow makes the insertions reject, with the
+// ErrDuplicateWithinWindow error, the elements whose key, as returned by key,
+// is the key of an element admitted within the trailing window on the clock
+// provided using WithClock, even if that element was removed since. This lets
+// the producers retrying an ambiguous failure re-offer an element without
+// duplicating it downstream.
+//
+// The keys of the admitted elements are remembered in time buckets, the
+// expired buckets being pruned as new keys are remembered, so that the memory
+// held by the keys is bounded by the number of insertions within a window.
+// The keys are checked after the validator provided using WithValidator and
+// before the capacity, thus an element rejected for being invalid, or because
+// the queue is full or closed, is not remembered and may be offered again.
+// The elements of OfferAll are rejected all together if one of them is a
+// duplicate, including of another one of them.
+//
+// The remembered keys survive Clear, but are forgotten by Reset, ResetStrict
+// and Destroy. The initial elements, the elements moved back to the queue by
+// its own operations, such as Rotate or the retries of ProcessEach using
+// WithRetryAtHead, are not checked; the retries re-offered by ProcessEach
+// otherwise are rejected as duplicates.
+// key is called while holding the queue lock, thus it must be fast and must
+// not call the queue methods.
+// The option is ignored if window is not positive.
+// It has no effect on the ChanQueue.
+// The constructors panic if T does not match the queue element type.
+func WithIdempotencyWindow[T any](window time.Duration, key func(elem T) string) Option {
+	return idempotencyOption{window: window, key: key}
+}
+
+type withoutNodePoolingOption struct{}
+
+func (withoutNodePoolingOption) apply(opts *options) {
+	opts.noNodePooling = true
+}
+
+// WithoutNodePooling makes a Linked queue allocate a new node for every
+// insertion. By default, the nodes released by the removals are kept, up to
+// a bounded number, and reused by the next insertions, so that a queue
+// alternating insertions and removals stops allocating.
+// It has no effect on the other queues.
+func WithoutNodePooling() Option {
+	return withoutNodePoolingOption{}
+}
+
+type exclusiveConsumerOption struct{}
+
+func (exclusiveConsumerOption) apply(opts *options) {
+	opts.exclusiveConsumer = true
+}
+
+// WithExclusiveConsumer makes a Blocking queue be consumed by a single
+// consumer at a time, holding the lease returned by AcquireConsumer. The
+// removals, such as Get, GetWait, Clear and Iterator, go through the lease,
+// while the ones of the queue itself, including the ones made by ProcessEach
+// and Move, return the ErrConsumerRequired error. The queue methods which
+// cannot return an error, such as GetWait, Clear, ClearIf, Live and Drain,
+// remove no element and return the zero value of T, no elements, a closed
+// channel or an empty sequence instead. The insertions and the examinations
+// are not restricted.
+// It has no effect on the other queues.
+func WithExclusiveConsumer() Option {
+	return exclusiveConsumerOption{}
+}
+
+type consumerLeaseTimeoutOption time.Duration
+
+func (c consumerLeaseTimeoutOption) apply(opts *options) {
+	opts.consumerLeaseTimeout = time.Duration(c)
+}
+
+// WithConsumerLeaseTimeout makes the consumer lease of a Blocking queue
+// created with the WithExclusiveConsumer option expire once it has not been
+// used for the given timeout, measured on the clock provided using WithClock,
+// so that the lease of a consumer which stopped without releasing it can be
+// acquired again. A lease never expires while one of its removals is waiting.
+// By default, or if timeout is not positive, the leases do not expire.
+func WithConsumerLeaseTimeout(timeout time.Duration) Option {
+	return consumerLeaseTimeoutOption(timeout)
+}
+
+// resetClonerOf returns the clone function provided using WithResetCloner,
+// or nil if none was provided.
+func resetClonerOf[T any](opts options) func(T) T {
+	if opts.resetCloner == nil {
+		return nil
+	}
+
+	clone, ok := opts.resetCloner.(func(T) T)
+	if !ok {
+		panic("reset cloner type does not match the queue element type")
+	}
+
+	return clone
+}
+
+// equalFuncOf returns the equality function provided using WithEqualFunc,
+// or nil if none was provided.
+func equalFuncOf[T any](opts options) func(elem, otherElem T) bool {
+	if opts.equalFunc == nil {
+		return nil
+	}
+
+	equal, ok := opts.equalFunc.(func(elem, otherElem T) bool)
+	if !ok {
+		panic("equal func type does not match the queue element type")
+	}
+
+	return equal
+}
+
+// sentinelOf returns the sentinel provided using WithSentinel,
+// or nil if none was provided.
+func sentinelOf[T comparable](opts options) *T {
+	if opts.sentinel == nil {
+		return nil
+	}
+
+	sentinel, ok := opts.sentinel.(T)
+	if !ok {
+		panic("sentinel type does not match the queue element type")
+	}
+
+	return &sentinel
+}
+
+// cloneElements returns a copy of the given elements, using the clone
+// function for each element if it is not nil.
+func cloneElements[T any](elems []T, clone func(T) T) []T {
+	cloned := make([]T, len(elems))
+
+	copyElements(cloned, elems, clone)
+
+	return cloned
+}
+
+// initialElementsOf returns the copy of the elements restored by Reset, made
+// using the clone function if it is not nil, or nil if the ResetEmpty
+// behavior is provided using WithResetBehavior.
+func initialElementsOf[T any](opts options, elems []T, clone func(T) T) []T {
+	if opts.resetBehavior == ResetEmpty {
+		return nil
+	}
+
+	return cloneElements(elems, clone)
+}
+
+// copyElements copies the src elements into dst, using the clone function
+// for each element if it is not nil.
+func copyElements[T any](dst, src []T, clone func(T) T) {
+	if clone == nil {
+		copy(dst, src)
+
+		return
+	}
+
+	for i := 0; i < len(dst) && i < len(src); i++ {
+		dst[i] = clone(src[i])
+	}
+}
+
+type batchOptions struct {
+	requeueOnError bool
+}
+
+// A BatchOption configures the batch consumption of a Blocking queue.
+type BatchOption interface {
+	applyBatch(o *batchOptions)
+}
+
+type requeueOnErrorOption struct{}
+
+func (requeueOnErrorOption) applyBatch(opts *batchOptions) {
+	opts.requeueOnError = true
+}
+
+// WithRequeueOnError re-offers the elements of a batch to the head of the
+// queue when the batch consumer function returns an error.
+func WithRequeueOnError() BatchOption {
+	return requeueOnErrorOption{}
+}
+
+type processOptions struct {
+	maxRetries  int
+	backoff     time.Duration
+	retryAtHead bool
+	// deadLetter holds a Queue[T], it is typed by ProcessEach.
+	deadLetter  any
+	concurrency int
+	clock       Clock
+}
+
+// A ProcessOption configures the processing of the queue elements
+// using ProcessEach.
+type ProcessOption interface {
+	applyProcess(o *processOptions)
+}
+
+type maxRetriesOption int
+
+func (m maxRetriesOption) applyProcess(opts *processOptions) {
+	opts.maxRetries = int(m)
+}
+
+// WithMaxRetries specifies how many times an element is retried after its
+// first failed attempt. By default, failed elements are not retried.
+func WithMaxRetries(n int) ProcessOption {
+	return maxRetriesOption(n)
+}
+
+type retryBackoffOption time.Duration
+
+func (r retryBackoffOption) applyProcess(opts *processOptions) {
+	opts.backoff = time.Duration(r)
+}
+
+// WithRetryBackoff specifies how long a failed element is held before being
+// re-offered to the queue.
+func WithRetryBackoff(backoff time.Duration) ProcessOption {
+	return retryBackoffOption(backoff)
+}
+
+type retryAtHeadOption struct{}
+
+func (retryAtHeadOption) applyProcess(opts *processOptions) {
+	opts.retryAtHead = true
+}
+
+// WithRetryAtHead re-offers the failed elements of a Blocking queue to its
+// head, regardless of the queue capacity and of the queue being closed.
+// The other queues always re-offer the failed elements to their tail.
+func WithRetryAtHead() ProcessOption {
+	return retryAtHeadOption{}
+}
+
+type deadLetterOption struct {
+	queue any
+}
+
+func (d deadLetterOption) applyProcess(opts *processOptions) {
+	opts.deadLetter = d.queue
+}
+
+// WithDeadLetter specifies the queue to which the elements are offered once
+// they have exhausted their retries. By default, such elements are dropped.
+// ProcessEach panics if T does not match the processed queue element type.
+func WithDeadLetter[T comparable](deadLetter Queue[T]) ProcessOption {
+	return deadLetterOption{queue: deadLetter}
+}
+
+type concurrencyOption int
+
+func (c concurrencyOption) applyProcess(opts *processOptions) {
+	opts.concurrency = int(c)
+}
+
+// WithConcurrency specifies the number of elements processed in parallel.
+// By default, the elements are processed one at a time.
+func WithConcurrency(n int) ProcessOption {
+	return concurrencyOption(n)
+}
+
+type processClockOption struct {
+	clock Clock
+}
+
+func (p processClockOption) applyProcess(opts *processOptions) {
+	opts.clock = p.clock
+}
+
+// WithProcessClock specifies the clock used for the retry backoff.
+// By default, the system clock is used.
+func WithProcessClock(clock Clock) ProcessOption {
+	return processClockOption{clock: clock}
+}
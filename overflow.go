@@ -0,0 +1,542 @@
+package queue
+
+import (
+	"cmp"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// The overflow files of a directory: the segments, named after their
+// sequence number, and the cursor, holding the sequence number of the oldest
+// segment and the offset of its first record not yet read.
+const (
+	overflowSegmentExt = ".seg"
+	overflowCursorFile = "cursor"
+
+	// overflowHeaderSize is the size of a record header: the length of the
+	// encoded element and its CRC-32 checksum, both as little endian uint32.
+	overflowHeaderSize = 8
+)
+
+// errCorruptedOverflowRecord is reported for the records whose checksum
+// does not match or which are cut short.
+var errCorruptedOverflowRecord = errors.New("corrupted overflow record")
+
+// OverflowSync specifies when the overflow segments written by a Blocking
+// queue created with the WithDiskOverflow option are synced to disk.
+type OverflowSync int
+
+const (
+	// OverflowSyncNone leaves the syncing to the operating system.
+	OverflowSyncNone OverflowSync = iota
+
+	// OverflowSyncSegment syncs every segment once it is full, along with
+	// the cursor.
+	OverflowSyncSegment
+
+	// OverflowSyncAlways syncs the segment after every record written and
+	// the cursor after every record read.
+	OverflowSyncAlways
+)
+
+// overflowConfig holds the arguments of WithDiskOverflow.
+type overflowConfig[T any] struct {
+	dir          string
+	segmentBytes int64
+	codec        JournalCodec[T]
+}
+
+// overflowSegment is a segment file, size being the length of its records.
+type overflowSegment struct {
+	seq  uint64
+	size int64
+}
+
+// diskOverflow holds the elements offered to a full Blocking queue in
+// segment files, appending them to the newest segment and reading them back
+// from the oldest one, so that they leave the overflow in FIFO order.
+// A nil diskOverflow holds no elements. Its methods are called while holding
+// the queue lock.
+type diskOverflow[T any] struct {
+	dir          string
+	segmentBytes int64
+	codec        JournalCodec[T]
+	sync         OverflowSync
+	warn         func(err error)
+
+	// segments are the segment files, oldest first. The records of the
+	// oldest one are read from offset using r, the newest one is appended
+	// to using w.
+	segments []overflowSegment
+	offset   int64
+	r, w     *os.File
+
+	// count is the number of records not yet read.
+	count int
+
+	// nextSeq is the sequence number of the next segment.
+	nextSeq uint64
+
+	// err is the first I/O error, after which the overflow accepts no more
+	// elements.
+	err error
+}
+
+// newDiskOverflow returns the disk overflow provided using WithDiskOverflow,
+// resuming the segments left in its directory, or nil if none was provided.
+func newDiskOverflow[T any](opts options) *diskOverflow[T] {
+	if opts.overflow == nil {
+		return nil
+	}
+
+	cfg, ok := opts.overflow.(overflowConfig[T])
+	if !ok {
+		panic("overflow codec type does not match the queue element type")
+	}
+
+	warn := opts.overflowWarn
+	if warn == nil {
+		warn = func(error) {}
+	}
+
+	d := &diskOverflow[T]{
+		dir:          cfg.dir,
+		segmentBytes: cfg.segmentBytes,
+		codec:        cfg.codec,
+		sync:         opts.overflowSync,
+		warn:         warn,
+	}
+
+	if err := d.resume(); err != nil {
+		d.fail(err)
+	}
+
+	return d
+}
+
+// len returns the number of elements held by the overflow.
+func (d *diskOverflow[T]) len() int {
+	if d == nil {
+		return 0
+	}
+
+	return d.count
+}
+
+// active returns true if the overflow accepts elements.
+func (d *diskOverflow[T]) active() bool {
+	return d != nil && d.err == nil
+}
+
+// fail records the I/O error and reports it.
+func (d *diskOverflow[T]) fail(err error) {
+	if d.err == nil {
+		d.err = fmt.Errorf("queue overflow: %w", err)
+	}
+
+	d.warn(d.err)
+}
+
+// resume scans the segments left in the directory, truncating the corrupted
+// records at their end, and positions the cursor at the first record not yet
+// read.
+func (d *diskOverflow[T]) resume() error {
+	if err := os.MkdirAll(d.dir, 0o755); err != nil {
+		return err
+	}
+
+	entries, err := os.ReadDir(d.dir)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), overflowSegmentExt)
+		if !ok {
+			continue
+		}
+
+		seq, err := strconv.ParseUint(name, 10, 64)
+		if err != nil {
+			continue
+		}
+
+		d.segments = append(d.segments, overflowSegment{seq: seq})
+	}
+
+	slices.SortFunc(d.segments, func(a, b overflowSegment) int {
+		return cmp.Compare(a.seq, b.seq)
+	})
+
+	cursorSeq, cursorOffset := d.readCursor()
+
+	d.nextSeq = cursorSeq
+
+	// the segments before the cursor were read entirely.
+	for len(d.segments) > 0 && d.segments[0].seq < cursorSeq {
+		if err := os.Remove(d.segmentPath(d.segments[0].seq)); err != nil {
+			return err
+		}
+
+		d.segments = d.segments[1:]
+	}
+
+	for i := range d.segments {
+		count, size, err := d.scan(d.segments[i].seq)
+		if err != nil {
+			return err
+		}
+
+		d.segments[i].size = size
+		d.count += count
+	}
+
+	if len(d.segments) == 0 {
+		return nil
+	}
+
+	d.nextSeq = max(d.nextSeq, d.segments[len(d.segments)-1].seq+1)
+
+	if d.segments[0].seq == cursorSeq {
+		d.offset = min(cursorOffset, d.segments[0].size)
+
+		read, _, err := d.scanTo(d.segments[0].seq, d.offset)
+		if err != nil {
+			return err
+		}
+
+		d.count -= read
+	}
+
+	if d.r, err = os.Open(d.segmentPath(d.segments[0].seq)); err != nil {
+		return err
+	}
+
+	last := d.segments[len(d.segments)-1]
+
+	d.w, err = os.OpenFile(d.segmentPath(last.seq), os.O_WRONLY|os.O_APPEND, 0o644)
+
+	return err
+}
+
+// scan counts the records of the segment, truncating the segment after its
+// last valid record.
+func (d *diskOverflow[T]) scan(seq uint64) (count int, size int64, _ error) {
+	count, size, err := d.scanTo(seq, -1)
+	if !errors.Is(err, errCorruptedOverflowRecord) {
+		return count, size, err
+	}
+
+	d.warn(fmt.Errorf("queue overflow: truncating segment %s at offset %d: %w", d.segmentPath(seq), size, err))
+
+	return count, size, os.Truncate(d.segmentPath(seq), size)
+}
+
+// scanTo counts the valid records of the segment up to the given offset, or
+// up to its end if the offset is negative, and returns the offset following
+// the last of them. It returns the errCorruptedOverflowRecord error if a
+// record is cut short or does not match its checksum.
+func (d *diskOverflow[T]) scanTo(seq uint64, offset int64) (count int, size int64, _ error) {
+	data, err := os.ReadFile(d.segmentPath(seq))
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if offset < 0 || offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+
+	for size < offset {
+		_, n, err := decodeOverflowRecord(data[size:])
+		if err != nil {
+			return count, size, err
+		}
+
+		count++
+		size += n
+	}
+
+	return count, size, nil
+}
+
+// push appends the element to the newest segment, starting a new segment
+// once the newest one holds segmentBytes.
+func (d *diskOverflow[T]) push(elem T) error {
+	if d.err != nil {
+		return d.err
+	}
+
+	data, err := d.codec.encode(elem)
+	if err != nil {
+		return err
+	}
+
+	record := make([]byte, overflowHeaderSize, overflowHeaderSize+len(data))
+
+	binary.LittleEndian.PutUint32(record, uint32(len(data)))
+	binary.LittleEndian.PutUint32(record[4:], crc32.ChecksumIEEE(data))
+
+	record = append(record, data...)
+
+	if d.w == nil || d.segments[len(d.segments)-1].size+int64(len(record)) > d.segmentBytes {
+		if err := d.rotate(); err != nil {
+			d.fail(err)
+
+			return d.err
+		}
+	}
+
+	if _, err := d.w.Write(record); err != nil {
+		d.fail(err)
+
+		return d.err
+	}
+
+	if d.sync == OverflowSyncAlways {
+		if err := d.w.Sync(); err != nil {
+			d.fail(err)
+
+			return d.err
+		}
+	}
+
+	d.segments[len(d.segments)-1].size += int64(len(record))
+	d.count++
+
+	return nil
+}
+
+// rotate starts a new segment, unless the newest segment is empty.
+func (d *diskOverflow[T]) rotate() error {
+	if d.w != nil {
+		if d.segments[len(d.segments)-1].size == 0 {
+			return nil
+		}
+
+		if d.sync != OverflowSyncNone {
+			if err := d.w.Sync(); err != nil {
+				return err
+			}
+		}
+
+		if err := d.w.Close(); err != nil {
+			return err
+		}
+	}
+
+	seq := d.nextSeq
+
+	d.nextSeq++
+
+	w, err := os.OpenFile(d.segmentPath(seq), os.O_CREATE|os.O_EXCL|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+
+	d.w = w
+	d.segments = append(d.segments, overflowSegment{seq: seq})
+
+	if d.r == nil {
+		d.r, err = os.Open(d.segmentPath(seq))
+	}
+
+	return err
+}
+
+// pop reads the oldest element, removing the segments read entirely.
+// It returns false if no element could be read, the overflow holding no
+// element or having failed. The elements failing to be decoded are skipped
+// and reported.
+func (d *diskOverflow[T]) pop() (elem T, _ bool) {
+	for d.count > 0 && d.err == nil {
+		if d.offset == d.segments[0].size {
+			if err := d.dropOldest(); err != nil {
+				d.fail(err)
+
+				return elem, false
+			}
+
+			continue
+		}
+
+		data, err := d.readRecord()
+		if err != nil {
+			d.fail(err)
+
+			return elem, false
+		}
+
+		d.count--
+
+		if d.count == 0 {
+			err = d.drain()
+		} else {
+			err = d.writeCursor()
+		}
+
+		if err != nil {
+			d.fail(err)
+
+			return elem, false
+		}
+
+		elem, err := d.codec.decode(data)
+		if err != nil {
+			d.warn(fmt.Errorf("queue overflow: skipping element: %w", err))
+
+			continue
+		}
+
+		return elem, true
+	}
+
+	return elem, false
+}
+
+// readRecord reads the encoded element of the record at the offset in the
+// oldest segment, advancing the offset.
+func (d *diskOverflow[T]) readRecord() ([]byte, error) {
+	header := make([]byte, overflowHeaderSize)
+
+	if _, err := d.r.ReadAt(header, d.offset); err != nil {
+		return nil, err
+	}
+
+	data := make([]byte, binary.LittleEndian.Uint32(header))
+
+	if _, err := d.r.ReadAt(data, d.offset+overflowHeaderSize); err != nil {
+		return nil, err
+	}
+
+	if crc32.ChecksumIEEE(data) != binary.LittleEndian.Uint32(header[4:]) {
+		return nil, errCorruptedOverflowRecord
+	}
+
+	d.offset += overflowHeaderSize + int64(len(data))
+
+	return data, nil
+}
+
+// dropOldest removes the oldest segment, read entirely, and starts reading
+// the next one.
+func (d *diskOverflow[T]) dropOldest() error {
+	if err := d.r.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Remove(d.segmentPath(d.segments[0].seq)); err != nil {
+		return err
+	}
+
+	d.segments = d.segments[1:]
+	d.offset = 0
+
+	var err error
+
+	d.r, err = os.Open(d.segmentPath(d.segments[0].seq))
+
+	return err
+}
+
+// drain removes the segments and the cursor once every element is read, so
+// that an empty overflow leaves no files behind.
+func (d *diskOverflow[T]) drain() error {
+	for _, f := range []*os.File{d.r, d.w} {
+		if err := f.Close(); err != nil {
+			return err
+		}
+	}
+
+	for _, segment := range d.segments {
+		if err := os.Remove(d.segmentPath(segment.seq)); err != nil {
+			return err
+		}
+	}
+
+	d.r, d.w = nil, nil
+	d.segments = nil
+	d.offset = 0
+
+	// the sequence numbers keep increasing, so that the cursor never points
+	// past a new segment.
+	return d.writeCursorAt(d.nextSeq, 0)
+}
+
+// segmentPath returns the path of the segment with the given sequence number.
+func (d *diskOverflow[T]) segmentPath(seq uint64) string {
+	return filepath.Join(d.dir, fmt.Sprintf("%020d%s", seq, overflowSegmentExt))
+}
+
+// readCursor returns the position recorded by the cursor, or the start of
+// the first segment if there is no valid cursor.
+func (d *diskOverflow[T]) readCursor() (seq uint64, offset int64) {
+	data, err := os.ReadFile(filepath.Join(d.dir, overflowCursorFile))
+	if err != nil || len(data) != 16 {
+		return 0, 0
+	}
+
+	return binary.LittleEndian.Uint64(data), int64(binary.LittleEndian.Uint64(data[8:]))
+}
+
+// writeCursor records the position of the next record to be read.
+func (d *diskOverflow[T]) writeCursor() error {
+	return d.writeCursorAt(d.segments[0].seq, d.offset)
+}
+
+// writeCursorAt records the given position as the next record to be read.
+func (d *diskOverflow[T]) writeCursorAt(seq uint64, offset int64) error {
+	data := make([]byte, 16)
+
+	binary.LittleEndian.PutUint64(data, seq)
+	binary.LittleEndian.PutUint64(data[8:], uint64(offset))
+
+	f, err := os.OpenFile(filepath.Join(d.dir, overflowCursorFile), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+
+		return err
+	}
+
+	if d.sync == OverflowSyncAlways {
+		if err := f.Sync(); err != nil {
+			_ = f.Close()
+
+			return err
+		}
+	}
+
+	return f.Close()
+}
+
+// decodeOverflowRecord returns the encoded element of the record at the
+// start of data, along with the length of the record.
+func decodeOverflowRecord(data []byte) ([]byte, int64, error) {
+	if len(data) < overflowHeaderSize {
+		return nil, 0, fmt.Errorf("%w: header cut short", errCorruptedOverflowRecord)
+	}
+
+	length := int64(binary.LittleEndian.Uint32(data))
+
+	if int64(len(data)) < overflowHeaderSize+length {
+		return nil, 0, fmt.Errorf("%w: element cut short", errCorruptedOverflowRecord)
+	}
+
+	elem := data[overflowHeaderSize : overflowHeaderSize+length]
+
+	if crc32.ChecksumIEEE(elem) != binary.LittleEndian.Uint32(data[4:]) {
+		return nil, 0, fmt.Errorf("%w: checksum mismatch", errCorruptedOverflowRecord)
+	}
+
+	return elem, overflowHeaderSize + length, nil
+}
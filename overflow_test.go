@@ -0,0 +1,406 @@
+package queue_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/adrianbrad/queue"
+)
+
+func TestWithDiskOverflow(t *testing.T) {
+	t.Parallel()
+
+	// segmentBytes holds a few records per segment, so that the elements
+	// span several segments.
+	const segmentBytes = 32
+
+	codec := queue.JournalCodec[int]{}
+
+	segments := func(t *testing.T, dir string) []string {
+		t.Helper()
+
+		matches, err := filepath.Glob(filepath.Join(dir, "*.seg"))
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		sort.Strings(matches)
+
+		return matches
+	}
+
+	t.Run("Ordering", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+
+		blockingQueue := queue.NewBlocking(
+			[]int{},
+			queue.WithCapacity(2),
+			queue.WithDiskOverflow(dir, segmentBytes, codec),
+		)
+
+		for i := 1; i <= 20; i++ {
+			if err := blockingQueue.Offer(i); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+		}
+
+		if size := blockingQueue.Size(); size != 2 {
+			t.Fatalf("expected size to be 2, got %d", size)
+		}
+
+		if size := blockingQueue.OverflowSize(); size != 18 {
+			t.Fatalf("expected overflow size to be 18, got %d", size)
+		}
+
+		if n := len(segments(t, dir)); n < 2 {
+			t.Fatalf("expected the overflow to span several segments, got %d", n)
+		}
+
+		// the elements offered while the overflow holds elements go after
+		// them, even if the queue has room.
+		if _, err := blockingQueue.Get(); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		for i := 2; i <= 20; i++ {
+			if i == 10 {
+				if err := blockingQueue.Offer(21); err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+			}
+
+			if elem := blockingQueue.GetWait(); elem != i {
+				t.Fatalf("expected elem to be %d, got %d", i, elem)
+			}
+		}
+
+		if elem := blockingQueue.GetWait(); elem != 21 {
+			t.Fatalf("expected elem to be 21, got %d", elem)
+		}
+
+		// the segments read entirely are removed.
+		if files := segments(t, dir); len(files) != 0 {
+			t.Fatalf("expected no segment to be left, got %v", files)
+		}
+	})
+
+	t.Run("ExchangeOrdering", func(t *testing.T) {
+		t.Parallel()
+
+		blockingQueue := queue.NewBlocking(
+			[]int{},
+			queue.WithCapacity(2),
+			queue.WithDiskOverflow(t.TempDir(), segmentBytes, codec),
+		)
+
+		for i := 1; i <= 4; i++ {
+			if err := blockingQueue.Offer(i); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+		}
+
+		if elem, err := blockingQueue.Exchange(99); err != nil || elem != 1 {
+			t.Fatalf("expected elem to be 1, got %d, %v", elem, err)
+		}
+
+		// the exchanged element goes after the elements of the overflow.
+		for _, want := range []int{2, 3, 4, 99} {
+			if elem := blockingQueue.GetWait(); elem != want {
+				t.Fatalf("expected elem to be %d, got %d", want, elem)
+			}
+		}
+
+		// with an empty overflow, the element takes the slot of the head.
+		for i := 1; i <= 2; i++ {
+			if err := blockingQueue.Offer(i); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+		}
+
+		if elem, err := blockingQueue.Exchange(3); err != nil || elem != 1 {
+			t.Fatalf("expected elem to be 1, got %d, %v", elem, err)
+		}
+
+		if size := blockingQueue.OverflowSize(); size != 0 {
+			t.Fatalf("expected overflow size to be 0, got %d", size)
+		}
+
+		if elems := blockingQueue.Clear(); !reflect.DeepEqual([]int{2, 3}, elems) {
+			t.Fatalf("expected elements to be %v, got %v", []int{2, 3}, elems)
+		}
+	})
+
+	t.Run("RotateOrdering", func(t *testing.T) {
+		t.Parallel()
+
+		blockingQueue := queue.NewBlocking(
+			[]int{},
+			queue.WithCapacity(2),
+			queue.WithDiskOverflow(t.TempDir(), segmentBytes, codec),
+		)
+
+		for i := 1; i <= 4; i++ {
+			if err := blockingQueue.Offer(i); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+		}
+
+		if err := blockingQueue.Rotate(); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		// the rotated head goes after the elements of the overflow.
+		for _, want := range []int{2, 3, 4, 1} {
+			if elem := blockingQueue.GetWait(); elem != want {
+				t.Fatalf("expected elem to be %d, got %d", want, elem)
+			}
+		}
+
+		// with an empty overflow, the head moves to the tail of the queue.
+		for i := 1; i <= 2; i++ {
+			if err := blockingQueue.Offer(i); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+		}
+
+		if err := blockingQueue.Rotate(); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if size := blockingQueue.OverflowSize(); size != 0 {
+			t.Fatalf("expected overflow size to be 0, got %d", size)
+		}
+
+		if elems := blockingQueue.Clear(); !reflect.DeepEqual([]int{2, 1}, elems) {
+			t.Fatalf("expected elements to be %v, got %v", []int{2, 1}, elems)
+		}
+	})
+
+	t.Run("MoveIntoFull", func(t *testing.T) {
+		t.Parallel()
+
+		blockingQueue := queue.NewBlocking(
+			[]int{1, 2},
+			queue.WithCapacity(2),
+			queue.WithDiskOverflow(t.TempDir(), segmentBytes, codec),
+		)
+
+		src := queue.NewLinked([]int{3, 4})
+
+		// the moved elements are not spilled, unlike the offered ones.
+		if _, err := queue.Move[int](src, blockingQueue); !errors.Is(err, queue.ErrQueueIsFull) {
+			t.Fatalf("expected error to be %v, got %v", queue.ErrQueueIsFull, err)
+		}
+
+		if n, err := queue.MoveAll[int](src, blockingQueue); n != 0 || !errors.Is(err, queue.ErrQueueIsFull) {
+			t.Fatalf("expected no element moved and error %v, got %d, %v", queue.ErrQueueIsFull, n, err)
+		}
+
+		if elems := src.ToSlice(); !reflect.DeepEqual([]int{3, 4}, elems) {
+			t.Fatalf("expected source elements to be %v, got %v", []int{3, 4}, elems)
+		}
+
+		if err := blockingQueue.Offer(3); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if size := blockingQueue.OverflowSize(); size != 1 {
+			t.Fatalf("expected overflow size to be 1, got %d", size)
+		}
+	})
+
+	t.Run("Resume", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+
+		blockingQueue := queue.NewBlocking(
+			[]int{},
+			queue.WithCapacity(2),
+			queue.WithDiskOverflow(dir, segmentBytes, codec),
+		)
+
+		for i := 1; i <= 10; i++ {
+			if err := blockingQueue.Offer(i); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+		}
+
+		for i := 1; i <= 5; i++ {
+			if elem := blockingQueue.GetWait(); elem != i {
+				t.Fatalf("expected elem to be %d, got %d", i, elem)
+			}
+		}
+
+		// the next process resumes the overflow, after the elements held
+		// in memory. Clear would refill the queue from the overflow.
+		resumed := queue.NewBlocking(
+			blockingQueue.ToSlice(),
+			queue.WithCapacity(2),
+			queue.WithDiskOverflow(dir, segmentBytes, codec),
+		)
+
+		if size := resumed.OverflowSize(); size != 3 {
+			t.Fatalf("expected overflow size to be 3, got %d", size)
+		}
+
+		for i := 6; i <= 10; i++ {
+			if elem := resumed.GetWait(); elem != i {
+				t.Fatalf("expected elem to be %d, got %d", i, elem)
+			}
+		}
+
+		if !resumed.IsEmpty() || resumed.OverflowSize() != 0 {
+			t.Fatalf("expected the queue to be drained, got %v", resumed.Clear())
+		}
+	})
+
+	t.Run("CorruptedTail", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+
+		blockingQueue := queue.NewBlocking(
+			[]int{},
+			queue.WithCapacity(1),
+			queue.WithDiskOverflow(dir, segmentBytes, codec),
+		)
+
+		for i := 1; i <= 10; i++ {
+			if err := blockingQueue.Offer(i); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+		}
+
+		// a crash cuts the last record short.
+		files := segments(t, dir)
+		last := files[len(files)-1]
+
+		info, err := os.Stat(last)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if err := os.Truncate(last, info.Size()-1); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		var warnings []error
+
+		resumed := queue.NewBlocking(
+			[]int{},
+			queue.WithCapacity(1),
+			queue.WithDiskOverflow(dir, segmentBytes, codec),
+			queue.WithOverflowWarnings(func(err error) {
+				warnings = append(warnings, err)
+			}),
+		)
+
+		if len(warnings) != 1 {
+			t.Fatalf("expected one warning, got %v", warnings)
+		}
+
+		// the elements before the corrupted record are intact.
+		for i := 2; i <= 9; i++ {
+			if elem := resumed.GetWait(); elem != i {
+				t.Fatalf("expected elem to be %d, got %d", i, elem)
+			}
+		}
+
+		if !resumed.IsEmpty() || resumed.OverflowSize() != 0 {
+			t.Fatalf("expected the queue to be drained, got %v", resumed.Clear())
+		}
+	})
+
+	t.Run("IOError", func(t *testing.T) {
+		t.Parallel()
+
+		dir := filepath.Join(t.TempDir(), "file")
+
+		if err := os.WriteFile(dir, nil, 0o600); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		var warnings []error
+
+		blockingQueue := queue.NewBlocking(
+			[]int{1},
+			queue.WithCapacity(1),
+			queue.WithDiskOverflow(dir, segmentBytes, codec),
+			queue.WithOverflowWarnings(func(err error) {
+				warnings = append(warnings, err)
+			}),
+		)
+
+		if len(warnings) != 1 {
+			t.Fatalf("expected one warning, got %v", warnings)
+		}
+
+		// the queue stops overflowing.
+		if err := blockingQueue.Offer(2); !errors.Is(err, queue.ErrQueueIsFull) {
+			t.Fatalf("expected error to be %v, got %v", queue.ErrQueueIsFull, err)
+		}
+	})
+
+	t.Run("Conservation", func(t *testing.T) {
+		t.Parallel()
+
+		blockingQueue := queue.NewBlocking(
+			[]int{},
+			queue.WithCapacity(4),
+			queue.WithDiskOverflow(t.TempDir(), 256, codec),
+			queue.WithConservationChecks(),
+		)
+
+		const (
+			producers        = 4
+			elemsPerProducer = 250
+		)
+
+		var producersGroup sync.WaitGroup
+
+		for p := 0; p < producers; p++ {
+			producersGroup.Add(1)
+
+			go func(p int) {
+				defer producersGroup.Done()
+
+				for i := 0; i < elemsPerProducer; i++ {
+					blockingQueue.OfferWait(p*elemsPerProducer + i)
+				}
+			}(p)
+		}
+
+		received := make([]int, 0, producers*elemsPerProducer)
+
+		for len(received) < producers*elemsPerProducer {
+			received = append(received, blockingQueue.GetWait())
+		}
+
+		producersGroup.Wait()
+
+		// the elements of every producer are received in order.
+		last := make(map[int]int)
+
+		for _, elem := range received {
+			p := elem / elemsPerProducer
+
+			if prev, ok := last[p]; ok && prev >= elem {
+				t.Fatalf("expected the elements of producer %d in order, got %d after %d", p, elem, prev)
+			}
+
+			last[p] = elem
+		}
+
+		if err := blockingQueue.LedgerCheck(); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	})
+}
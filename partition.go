@@ -0,0 +1,61 @@
+package queue
+
+// Partition specifies how IteratorsN distributes the drained elements
+// among the iterators.
+type Partition int
+
+const (
+	// RoundRobin sends the drained element i to the iterator i % k, thus
+	// iterator j holds the elements j, j+k, j+2k... of the drain order.
+	RoundRobin Partition = iota
+
+	// Contiguous sends consecutive blocks of drained elements to each
+	// iterator, the sizes of the blocks differing by at most one element.
+	// Iterator 0 holds the first block.
+	Contiguous
+)
+
+// partition distributes the elements into k buffered channels, which are
+// closed. k must be positive.
+func partition[T any](elems []T, k int, mode Partition) []<-chan T {
+	// the first size%k blocks hold one extra element.
+	blockSize, extra := len(elems)/k, len(elems)%k
+
+	chans := make([]chan T, k)
+	iterators := make([]<-chan T, k)
+
+	for i := range chans {
+		capacity := blockSize
+
+		if i < extra {
+			capacity++
+		}
+
+		chans[i] = make(chan T, capacity)
+		iterators[i] = chans[i]
+	}
+
+	switch mode {
+	case Contiguous:
+		start := 0
+
+		for _, ch := range chans {
+			for _, elem := range elems[start : start+cap(ch)] {
+				ch <- elem
+			}
+
+			start += cap(ch)
+		}
+
+	default:
+		for i, elem := range elems {
+			chans[i%k] <- elem
+		}
+	}
+
+	for _, ch := range chans {
+		close(ch)
+	}
+
+	return iterators
+}
@@ -0,0 +1,161 @@
+package queue_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/adrianbrad/queue"
+)
+
+// partitionedQueue is implemented by all the queues.
+type partitionedQueue interface {
+	IteratorsN(k int, mode queue.Partition) ([]<-chan int, error)
+	IsEmpty() bool
+	Size() int
+}
+
+func TestIteratorsN(t *testing.T) {
+	t.Parallel()
+
+	const size = 10
+
+	lessInt := func(elem, otherElem int) bool { return elem < otherElem }
+
+	// the drain order of every queue is 0, 1, 2...
+	testCases := map[string]func() partitionedQueue{
+		"Blocking": func() partitionedQueue {
+			blockingQueue := queue.NewBlocking([]int{2, 3, 4, 5, 6, 7, 8, 9})
+
+			_ = blockingQueue.OfferUrgent(0)
+			_ = blockingQueue.OfferUrgent(1)
+
+			return blockingQueue
+		},
+		"Circular": func() partitionedQueue {
+			return queue.NewCircular([]int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, size)
+		},
+		"Linked": func() partitionedQueue {
+			return queue.NewLinked([]int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9})
+		},
+		"Priority": func() partitionedQueue {
+			return queue.NewPriority([]int{9, 3, 0, 7, 1, 8, 2, 6, 4, 5}, lessInt)
+		},
+	}
+
+	drain := func(iterators []<-chan int) [][]int {
+		partitions := make([][]int, len(iterators))
+
+		for i, it := range iterators {
+			partitions[i] = []int{}
+
+			for elem := range it {
+				partitions[i] = append(partitions[i], elem)
+			}
+		}
+
+		return partitions
+	}
+
+	for name, newQueue := range testCases {
+		newQueue := newQueue
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			t.Run("RoundRobin", func(t *testing.T) {
+				t.Parallel()
+
+				q := newQueue()
+
+				iterators, err := q.IteratorsN(3, queue.RoundRobin)
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+
+				if !q.IsEmpty() {
+					t.Fatalf("expected queue to be drained")
+				}
+
+				partitions := drain(iterators)
+
+				expected := [][]int{{0, 3, 6, 9}, {1, 4, 7}, {2, 5, 8}}
+
+				if !reflect.DeepEqual(expected, partitions) {
+					t.Fatalf("expected partitions to be %v, got %v", expected, partitions)
+				}
+
+				// the interleave of the partitions reconstructs the drain order.
+				reconstructed := make([]int, 0, size)
+
+				for i := 0; len(reconstructed) < size; i++ {
+					partition := partitions[i%len(partitions)]
+
+					reconstructed = append(reconstructed, partition[i/len(partitions)])
+				}
+
+				for i, elem := range reconstructed {
+					if elem != i {
+						t.Fatalf("expected reconstructed order to be sorted, got %v", reconstructed)
+					}
+				}
+			})
+
+			t.Run("Contiguous", func(t *testing.T) {
+				t.Parallel()
+
+				iterators, err := newQueue().IteratorsN(4, queue.Contiguous)
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+
+				expected := [][]int{{0, 1, 2}, {3, 4, 5}, {6, 7}, {8, 9}}
+
+				if partitions := drain(iterators); !reflect.DeepEqual(expected, partitions) {
+					t.Fatalf("expected partitions to be %v, got %v", expected, partitions)
+				}
+			})
+
+			t.Run("MorePartitionsThanElements", func(t *testing.T) {
+				t.Parallel()
+
+				for _, mode := range []queue.Partition{queue.RoundRobin, queue.Contiguous} {
+					iterators, err := newQueue().IteratorsN(size+2, mode)
+					if err != nil {
+						t.Fatalf("expected no error, got %v", err)
+					}
+
+					partitions := drain(iterators)
+
+					for i, partition := range partitions {
+						expected := []int{}
+
+						if i < size {
+							expected = []int{i}
+						}
+
+						if !reflect.DeepEqual(expected, partition) {
+							t.Fatalf("expected partition %d to be %v, got %v", i, expected, partition)
+						}
+					}
+				}
+			})
+
+			t.Run("InvalidPartitions", func(t *testing.T) {
+				t.Parallel()
+
+				q := newQueue()
+
+				for _, k := range []int{0, -1} {
+					if _, err := q.IteratorsN(k, queue.RoundRobin); !errors.Is(err, queue.ErrInvalidPartitions) {
+						t.Fatalf("expected error to be %v, got %v", queue.ErrInvalidPartitions, err)
+					}
+				}
+
+				if q.Size() != size {
+					t.Fatalf("expected queue to be unchanged")
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"time"
+)
+
+// Ensure the queues implement the Poller interface.
+var (
+	_ Poller[any] = (*Blocking[any])(nil)
+	_ Poller[any] = (*Circular[any])(nil)
+	_ Poller[any] = (*Linked[any])(nil)
+	_ Poller[any] = (*PriorityAny[any])(nil)
+)
+
+// A Poller is a queue whose head can be retrieved as soon as it is available,
+// for the callers that cannot wait in a dedicated goroutine.
+type Poller[T any] interface {
+	// Poll removes and returns the head of the queue, waiting for it to
+	// become available until ctx is done.
+	Poll(ctx context.Context, interval time.Duration) (T, error)
+}
+
+// poller retries get, using the clock to wait between the attempts.
+type poller[T any] struct {
+	clock Clock
+
+	// jitter is the maximum fraction of the interval added to each wait.
+	jitter float64
+}
+
+func newPoller[T any](opts options) poller[T] {
+	clock := opts.clock
+	if clock == nil {
+		clock = systemClock{}
+	}
+
+	return poller[T]{
+		clock:  clock,
+		jitter: min(max(opts.pollJitter, 0), 1),
+	}
+}
+
+// poll calls get until it returns an element or an error other than
+// ErrNoElementsAvailable, waiting for at least the interval between the
+// attempts. It returns the ctx error once ctx is done.
+func (p poller[T]) poll(
+	ctx context.Context,
+	interval time.Duration,
+	get func() (T, error),
+) (elem T, _ error) {
+	if interval <= 0 {
+		return elem, ErrInvalidInterval
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return elem, err
+		}
+
+		elem, err := get()
+		if !errors.Is(err, ErrNoElementsAvailable) {
+			return elem, err
+		}
+
+		wait := interval
+
+		if p.jitter > 0 {
+			// nolint: gosec // the jitter does not need a secure source.
+			wait += time.Duration(rand.Float64() * p.jitter * float64(interval))
+		}
+
+		sleep(ctx, p.clock, wait)
+	}
+}
@@ -0,0 +1,221 @@
+package queue_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/adrianbrad/queue"
+)
+
+// polledQueue is implemented by all the queues.
+type polledQueue interface {
+	queue.Poller[int]
+	Offer(int) error
+}
+
+func TestPoll(t *testing.T) {
+	t.Parallel()
+
+	const interval = time.Second
+
+	lessInt := func(elem, otherElem int) bool { return elem < otherElem }
+
+	testCases := map[string]func(opts ...queue.Option) polledQueue{
+		"Circular": func(opts ...queue.Option) polledQueue {
+			return queue.NewCircular([]int{}, 3, opts...)
+		},
+		"Linked": func(opts ...queue.Option) polledQueue {
+			return queue.NewLinked([]int{}, opts...)
+		},
+		"Priority": func(opts ...queue.Option) polledQueue {
+			return queue.NewPriority([]int{}, lessInt, opts...)
+		},
+	}
+
+	type result struct {
+		elem int
+		err  error
+	}
+
+	for name, newQueue := range testCases {
+		newQueue := newQueue
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			t.Run("ElementAppears", func(t *testing.T) {
+				t.Parallel()
+
+				clock := newFakeClock()
+
+				q := newQueue(queue.WithClock(clock))
+
+				done := make(chan result, 1)
+
+				go func() {
+					elem, err := q.Poll(context.Background(), interval)
+
+					done <- result{elem: elem, err: err}
+				}()
+
+				// the first 3 attempts find the queue empty.
+				for i := 0; i < 3; i++ {
+					clock.WaitForTimers(t, 1)
+
+					select {
+					case r := <-done:
+						t.Fatalf("expected Poll to wait, got %+v", r)
+					default:
+					}
+
+					if i == 2 {
+						_ = q.Offer(1)
+					}
+
+					clock.Advance(interval)
+				}
+
+				// the 4th attempt retrieves the element.
+				select {
+				case r := <-done:
+					if r.err != nil || r.elem != 1 {
+						t.Fatalf("expected Poll to return 1, got %+v", r)
+					}
+				case <-time.After(time.Second):
+					t.Fatalf("expected Poll to return")
+				}
+			})
+
+			t.Run("Available", func(t *testing.T) {
+				t.Parallel()
+
+				q := newQueue()
+
+				_ = q.Offer(1)
+
+				if elem, err := q.Poll(context.Background(), interval); err != nil || elem != 1 {
+					t.Fatalf("expected Poll to return 1, got %d, %v", elem, err)
+				}
+			})
+
+			t.Run("CancelledBetweenAttempts", func(t *testing.T) {
+				t.Parallel()
+
+				clock := newFakeClock()
+
+				q := newQueue(queue.WithClock(clock), queue.WithPollJitter(0.5))
+
+				ctx, cancel := context.WithCancel(context.Background())
+
+				done := make(chan result, 1)
+
+				go func() {
+					elem, err := q.Poll(ctx, interval)
+
+					done <- result{elem: elem, err: err}
+				}()
+
+				clock.WaitForTimers(t, 1)
+
+				cancel()
+
+				select {
+				case r := <-done:
+					if !errors.Is(r.err, context.Canceled) {
+						t.Fatalf("expected error to be %v, got %v", context.Canceled, r.err)
+					}
+				case <-time.After(time.Second):
+					t.Fatalf("expected Poll to return after cancellation")
+				}
+			})
+
+			t.Run("Jitter", func(t *testing.T) {
+				t.Parallel()
+
+				clock := newFakeClock()
+
+				q := newQueue(queue.WithClock(clock), queue.WithPollJitter(1))
+
+				done := make(chan result, 1)
+
+				go func() {
+					elem, err := q.Poll(context.Background(), interval)
+
+					done <- result{elem: elem, err: err}
+				}()
+
+				clock.WaitForTimers(t, 1)
+
+				_ = q.Offer(1)
+
+				// the jitter never shortens the interval.
+				clock.Advance(interval - time.Nanosecond)
+
+				select {
+				case r := <-done:
+					t.Fatalf("expected Poll to wait for at least the interval, got %+v", r)
+				case <-time.After(10 * time.Millisecond):
+				}
+
+				clock.Advance(interval + time.Nanosecond)
+
+				if r := <-done; r.err != nil || r.elem != 1 {
+					t.Fatalf("expected Poll to return 1, got %+v", r)
+				}
+			})
+
+			t.Run("InvalidInterval", func(t *testing.T) {
+				t.Parallel()
+
+				q := newQueue()
+
+				for _, interval := range []time.Duration{0, -time.Second} {
+					if _, err := q.Poll(context.Background(), interval); !errors.Is(err, queue.ErrInvalidInterval) {
+						t.Fatalf("expected error to be %v, got %v", queue.ErrInvalidInterval, err)
+					}
+				}
+			})
+		})
+	}
+
+	t.Run("Blocking", func(t *testing.T) {
+		t.Parallel()
+
+		blockingQueue := queue.NewBlocking([]int{})
+
+		done := make(chan result, 1)
+
+		go func() {
+			elem, err := blockingQueue.Poll(context.Background(), time.Hour)
+
+			done <- result{elem: elem, err: err}
+		}()
+
+		time.Sleep(time.Millisecond)
+
+		// the element is retrieved as soon as it is inserted.
+		_ = blockingQueue.Offer(1)
+
+		select {
+		case r := <-done:
+			if r.err != nil || r.elem != 1 {
+				t.Fatalf("expected Poll to return 1, got %+v", r)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("expected Poll to return once the element is inserted")
+		}
+
+		if _, err := blockingQueue.Poll(context.Background(), 0); !errors.Is(err, queue.ErrInvalidInterval) {
+			t.Fatalf("expected error to be %v, got %v", queue.ErrInvalidInterval, err)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		if _, err := blockingQueue.Poll(ctx, time.Second); !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected error to be %v, got %v", context.Canceled, err)
+		}
+	})
+}
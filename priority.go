@@ -1,9 +1,18 @@
 package queue
 
 import (
+	"bytes"
 	"container/heap"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/bits"
+	"slices"
 	"sort"
 	"sync"
+	"time"
+	"unsafe"
 )
 
 // Ensure Priority implements the heap.Interface.
@@ -11,9 +20,31 @@ var _ heap.Interface = (*priorityHeap[any])(nil)
 
 // priorityHeap implements the heap.Interface, thus enabling this struct
 // to be accepted as a parameter for the methods available in the heap package.
-type priorityHeap[T comparable] struct {
+type priorityHeap[T any] struct {
 	elems    []T
 	lessFunc func(elem, otherElem T) bool
+
+	// stable breaks the ties between equal elements using their insertion
+	// sequence numbers, held in seqs alongside elems, the lowest first, or
+	// the highest first if lifo is set. nextSeq is the sequence number of
+	// the next inserted element.
+	stable  bool
+	lifo    bool
+	seqs    []uint64
+	nextSeq uint64
+
+	// rebuildEvery is the number of insertions and removals after which the
+	// heap is rebuilt, if the WithAutoRebuild option is provided. ops counts
+	// them since the last rebuild.
+	rebuildEvery int
+	ops          int
+
+	// ledger accounts for the elements, if the WithConservationChecks option
+	// is provided, in which case ids holds the sequence IDs of the elements
+	// alongside elems. Every pushed element is admitted into the ledger and
+	// every popped or replaced one leaves it.
+	ledger *ledger
+	ids    []uint64
 }
 
 // Len is the number of elements in the collection.
@@ -24,12 +55,32 @@ func (h *priorityHeap[T]) Len() int {
 // Less reports whether the element with index i
 // must sort before the element with index j.
 func (h *priorityHeap[T]) Less(i, j int) bool {
-	return h.lessFunc(h.elems[i], h.elems[j])
+	if h.lessFunc(h.elems[i], h.elems[j]) {
+		return true
+	}
+
+	if !h.stable || h.lessFunc(h.elems[j], h.elems[i]) {
+		return false
+	}
+
+	if h.lifo {
+		return h.seqs[i] > h.seqs[j]
+	}
+
+	return h.seqs[i] < h.seqs[j]
 }
 
 // Swap swaps the elements with indexes i and j.
 func (h *priorityHeap[T]) Swap(i, j int) {
 	h.elems[i], h.elems[j] = h.elems[j], h.elems[i]
+
+	if h.stable {
+		h.seqs[i], h.seqs[j] = h.seqs[j], h.seqs[i]
+	}
+
+	if h.ledger != nil {
+		h.ids[i], h.ids[j] = h.ids[j], h.ids[i]
+	}
 }
 
 // Push inserts elem into the heap.
@@ -39,6 +90,19 @@ func (h *priorityHeap[T]) Push(elem any) {
 	// by the heap package functions. Thus, it is safe to expect that the
 	// input parameter `elem` type is always T.
 	h.elems = append(h.elems, elem.(T))
+
+	if h.stable {
+		h.seqs = append(h.seqs, h.nextSeq)
+		h.nextSeq++
+	}
+
+	if h.ledger != nil {
+		h.ids = append(h.ids, h.ledger.admit())
+	}
+
+	// the heap order of the previous elements holds, the element is sifted
+	// up after being appended.
+	h.rebuildIfDue(len(h.elems) - 1)
 }
 
 // Pop removes and returns the highest priority element.
@@ -49,9 +113,168 @@ func (h *priorityHeap[T]) Pop() any {
 
 	h.elems = (h.elems)[0 : n-1]
 
+	if h.stable {
+		h.seqs = h.seqs[:n-1]
+	}
+
+	if h.ledger != nil {
+		h.ledger.exit(h.ids[n-1])
+
+		h.ids = h.ids[:n-1]
+	}
+
+	h.rebuildIfDue(len(h.elems))
+
 	return elem
 }
 
+// rebuildIfDue counts an insertion or a removal and rebuilds the first n
+// elements, which must be in heap order, once rebuildEvery of them are
+// counted.
+func (h *priorityHeap[T]) rebuildIfDue(n int) {
+	if h.rebuildEvery <= 0 {
+		return
+	}
+
+	h.ops++
+
+	if h.ops >= h.rebuildEvery {
+		h.rebuild(n)
+	}
+}
+
+// rebuild lays the first n elements out in priority order, which is a valid
+// heap layout, keeping their sequence numbers. The order in which they are
+// retrieved is unchanged, since the equal elements of a stable heap are
+// ordered by their sequence numbers.
+func (h *priorityHeap[T]) rebuild(n int) {
+	h.ops = 0
+
+	order := make([]int, n)
+
+	for i := range order {
+		order[i] = i
+	}
+
+	sort.Slice(order, func(i, j int) bool {
+		return h.Less(order[i], order[j])
+	})
+
+	elems := make([]T, n)
+
+	for i, j := range order {
+		elems[i] = h.elems[j]
+	}
+
+	copy(h.elems, elems)
+
+	if h.ledger != nil {
+		ids := make([]uint64, n)
+
+		for i, j := range order {
+			ids[i] = h.ids[j]
+		}
+
+		copy(h.ids, ids)
+	}
+
+	if !h.stable {
+		return
+	}
+
+	seqs := make([]uint64, n)
+
+	for i, j := range order {
+		seqs[i] = h.seqs[j]
+	}
+
+	copy(h.seqs, seqs)
+}
+
+// readmit accounts for the elements of the heap being replaced as a whole:
+// the elements previously held leave the ledger and the current ones are
+// admitted into it, in their layout order.
+func (h *priorityHeap[T]) readmit() {
+	if h.ledger == nil {
+		return
+	}
+
+	for _, id := range h.ids {
+		h.ledger.exit(id)
+	}
+
+	h.ids = h.ids[:0]
+
+	for range h.elems {
+		h.ids = append(h.ids, h.ledger.admit())
+	}
+}
+
+// replaceHead replaces the highest priority element with elem, which is
+// ordered as the last inserted element, and restores the heap order.
+func (h *priorityHeap[T]) replaceHead(elem T) {
+	h.replace(0, elem)
+}
+
+// replace replaces the element with index i with elem, which is ordered as
+// the last inserted element, and restores the heap order.
+func (h *priorityHeap[T]) replace(i int, elem T) {
+	h.elems[i] = elem
+
+	if h.stable {
+		h.seqs[i] = h.nextSeq
+		h.nextSeq++
+	}
+
+	if h.ledger != nil {
+		h.ledger.exit(h.ids[i])
+
+		h.ids[i] = h.ledger.admit()
+	}
+
+	heap.Fix(h, i)
+}
+
+// worst returns the index of the lowest priority element, which is one of
+// the leaves of the heap. The heap must not be empty.
+func (h *priorityHeap[T]) worst() int {
+	worst := len(h.elems) / 2
+
+	for i := worst + 1; i < len(h.elems); i++ {
+		if h.Less(worst, i) {
+			worst = i
+		}
+	}
+
+	return worst
+}
+
+// clone returns a copy of the heap, with the same layout, which is never
+// rebuilt automatically.
+func (h *priorityHeap[T]) clone() *priorityHeap[T] {
+	return &priorityHeap[T]{
+		elems:    slices.Clone(h.elems),
+		lessFunc: h.lessFunc,
+		stable:   h.stable,
+		lifo:     h.lifo,
+		seqs:     slices.Clone(h.seqs),
+		nextSeq:  h.nextSeq,
+	}
+}
+
+// siftCounter counts the swaps of the elements of a heap.
+type siftCounter[T any] struct {
+	*priorityHeap[T]
+	swaps int
+}
+
+// Swap swaps the elements with indexes i and j.
+func (c *siftCounter[T]) Swap(i, j int) {
+	c.swaps++
+
+	c.priorityHeap.Swap(i, j)
+}
+
 // Ensure Priority implements the Queue interface.
 var _ Queue[any] = (*Priority[any])(nil)
 
@@ -68,13 +291,9 @@ var _ Queue[any] = (*Priority[any])(nil)
 // > - for ascending order
 // < - for descending order.
 type Priority[T comparable] struct {
-	initialElements []T
-	elements        *priorityHeap[T]
-
-	capacity *int
-
-	// synchronization
-	lock sync.RWMutex
+	// core implements the queue, Priority only adds the methods requiring
+	// the elements to be comparable.
+	core PriorityAny[T]
 }
 
 // NewPriority creates a new Priority Queue containing the given elements.
@@ -84,50 +303,239 @@ func NewPriority[T comparable](
 	lessFunc func(elem, otherElem T) bool,
 	opts ...Option,
 ) *Priority[T] {
-	if lessFunc == nil {
-		panic("nil less func")
+	pq := &Priority[T]{}
+
+	pq.core.init(elems, lessFunc, opts)
+
+	pq.core.kind = "Priority"
+
+	if pq.core.equalFunc == nil {
+		pq.core.equalFunc = func(elem, otherElem T) bool { return elem == otherElem }
 	}
 
-	// default options
-	options := options{
-		capacity: nil,
+	return pq
+}
+
+// OfferStatus is the outcome of an OfferBounded call.
+type OfferStatus uint8
+
+// The outcomes of an OfferBounded call.
+const (
+	// OfferAccepted is reported when the element is inserted into a queue
+	// which is not full.
+	OfferAccepted OfferStatus = iota
+
+	// OfferAcceptedWithEviction is reported when the element is inserted into
+	// a full queue, evicting its lowest priority element.
+	OfferAcceptedWithEviction
+
+	// OfferRejectedDuplicate is reported when the queue already holds an
+	// element equal to the offered one.
+	OfferRejectedDuplicate
+
+	// OfferRejectedWorse is reported when the queue is full and the offered
+	// element does not have a higher priority than its lowest priority
+	// element.
+	OfferRejectedWorse
+)
+
+// offerStatusNames holds the names of the offer statuses, in value order.
+var offerStatusNames = []string{"Accepted", "AcceptedWithEviction", "RejectedDuplicate", "RejectedWorse"}
+
+// Accepted returns true if the offered element was inserted into the queue.
+func (s OfferStatus) Accepted() bool {
+	return s == OfferAccepted || s == OfferAcceptedWithEviction
+}
+
+// String returns the name of the status.
+func (s OfferStatus) String() string {
+	if int(s) < len(offerStatusNames) {
+		return offerStatusNames[s]
 	}
 
-	for _, o := range opts {
-		o.apply(&options)
+	return "Unknown"
+}
+
+// OfferOutcome reports the outcome of an OfferBounded call.
+type OfferOutcome[T any] struct {
+	Status OfferStatus
+
+	// Evicted is the element removed from the queue to make room for the
+	// offered element, set if Status is OfferAcceptedWithEviction.
+	Evicted T
+}
+
+// OfferBounded inserts the element into the queue unless the queue already
+// holds an equal element, as reported by Contains. If the queue is full, the
+// element replaces the lowest priority element of the queue, provided it has
+// a higher priority, so that a queue with a capacity of K holds the K highest
+// priority distinct elements offered to it. The lowest priority element is
+// the one which would be retrieved last, the ties being resolved in favour of
+// the elements already in the queue.
+//
+// The evicted element is returned in the outcome and is owned by the caller,
+// just as the rejected element. If the capacity of the queue is 0 it returns
+// the ErrQueueIsFull error.
+func (pq *Priority[T]) OfferBounded(elem T) (outcome OfferOutcome[T], _ error) {
+	core := &pq.core
+
+	core.lock.Lock()
+	defer core.lock.Unlock()
+
+	if err := core.validator.check(elem); err != nil {
+		return outcome, core.named(err)
 	}
 
-	heapElems := make([]T, len(elems))
+	if err := core.window.check(elem); err != nil {
+		return outcome, core.named(err)
+	}
 
-	copy(heapElems, elems)
+	h := core.elements
 
-	elementsHeap := &priorityHeap[T]{
-		elems:    heapElems,
-		lessFunc: lessFunc,
+	core.checks.offered(elem, h.elems)
+
+	for i := range h.elems {
+		if core.equalFunc(h.elems[i], elem) {
+			outcome.Status = OfferRejectedDuplicate
+
+			return outcome, nil
+		}
 	}
 
-	// if capacity is provided and is less than the number of elements
-	// provided, the elements are sorted and trimmed to fit the capacity.
-	if options.capacity != nil && *options.capacity < elementsHeap.Len() {
-		sort.Slice(elementsHeap.elems, func(i, j int) bool {
-			return lessFunc((elementsHeap.elems)[i], (elementsHeap.elems)[j])
-		})
+	if err := core.occupancy.admit(1, 0); err == nil {
+		heap.Push(h, elem)
 
-		elementsHeap.elems = (elementsHeap.elems)[:*options.capacity]
+		core.window.remember(elem)
+
+		core.journal.record(JournalOffer, elem, h.Len())
+
+		core.version++
+
+		core.tracker.record(elem)
+
+		outcome.Status = OfferAccepted
+
+		return outcome, nil
 	}
 
-	heap.Init(elementsHeap)
+	if h.Len() == 0 {
+		return outcome, core.named(ErrQueueIsFull)
+	}
 
-	initialElems := make([]T, elementsHeap.Len())
+	worst := h.worst()
 
-	copy(initialElems, elementsHeap.elems)
+	if !h.lessFunc(elem, h.elems[worst]) {
+		outcome.Status = OfferRejectedWorse
 
-	pq := &Priority[T]{
-		initialElements: initialElems,
-		elements:        elementsHeap,
-		capacity:        options.capacity,
+		return outcome, nil
 	}
 
+	outcome.Status = OfferAcceptedWithEviction
+	outcome.Evicted = h.elems[worst]
+
+	// the eviction and the insertion are recorded together, as the
+	// replacement of the evicted element.
+	core.update(worst, elem)
+
+	core.window.remember(elem)
+
+	core.tracker.record(elem)
+
+	return outcome, nil
+}
+
+// PriorityAny is a priority queue over elements which are not required to be
+// comparable, such as structs containing slices. It behaves like Priority,
+// except for Contains, which requires an equality function to be provided
+// using WithEqualFunc.
+type PriorityAny[T any] struct {
+	initialElements []T
+	resetCloner     func(T) T
+	elements        *priorityHeap[T]
+
+	// initialSeqs holds the sequence numbers of the initial elements, in the
+	// heap layout of initialElements, if the WithStableOrder option is provided.
+	initialSeqs []uint64
+
+	// occupancy counts the elements and enforces the capacity.
+	occupancy occupancy
+
+	// codec encodes and decodes the elements in the JSON methods.
+	codec jsonCodec[T]
+
+	// recycler releases the discarded elements, if WithRecycler is provided.
+	recycler recycler[T]
+
+	// validator checks the inserted elements, if WithValidator is provided.
+	validator validator[T]
+
+	// window rejects the elements admitted within the idempotency window, if
+	// WithIdempotencyWindow is provided.
+	window *idempotencyWindow[T]
+
+	// reads caches the results of the reads, if WithCachedReads is provided.
+	reads *readCache[T]
+
+	// poller retries Get in Poll.
+	poller poller[T]
+
+	// tracker records the mutating operations, if the WithCallerTracking
+	// option is provided.
+	tracker *callerTracker[T]
+
+	// journal records the mutating operations, if the WithJournal option is
+	// provided.
+	journal *journal[T]
+
+	// name is the name provided using WithName, carried by the errors, and
+	// kind is the implementation of the queue, either Priority or
+	// PriorityAny.
+	name string
+	kind string
+
+	// checks verifies the consistency of the less function, if the
+	// WithComparatorChecks option is provided.
+	checks *comparatorChecker[T]
+
+	// equalFunc reports whether two elements are equal, used by Contains.
+	equalFunc func(elem, otherElem T) bool
+
+	// lessName is the name of the comparator recorded in the JSON
+	// checkpoints, if WithLessName is provided. comparatorOverride makes
+	// UnmarshalJSONFrom restore the checkpoints of other comparators.
+	lessName           string
+	comparatorOverride bool
+
+	// version is incremented by every mutation, invalidating the cursors
+	// issued by InspectPage. inspected caches the elements in priority order
+	// as of inspectedVersion, so that InspectPage sorts them once per version.
+	version          uint64
+	inspected        []T
+	inspectedVersion uint64
+
+	// synchronization
+	lock profiledRWMutex
+
+	// notEmptyCond and notFullCond are broadcast whenever the size of the
+	// heap grows or shrinks, as of wokenSize, when the queue lock is
+	// released for writing, waking the goroutines waiting in GetWait,
+	// PeekWait and OfferWait.
+	notEmptyCond *sync.Cond
+	notFullCond  *sync.Cond
+	wokenSize    int
+}
+
+// NewPriorityAny creates a new PriorityAny Queue containing the given
+// elements. It panics if lessFunc is nil.
+func NewPriorityAny[T any](
+	elems []T,
+	lessFunc func(elem, otherElem T) bool,
+	opts ...Option,
+) *PriorityAny[T] {
+	pq := &PriorityAny[T]{}
+
+	pq.init(elems, lessFunc, opts)
+
 	return pq
 }
 
@@ -135,137 +543,1467 @@ func NewPriority[T comparable](
 
 // Offer inserts the element into the queue.
 // If the queue is full it returns the ErrQueueIsFull error.
-func (pq *Priority[T]) Offer(elem T) error {
+func (pq *PriorityAny[T]) Offer(elem T) error {
 	pq.lock.Lock()
 	defer pq.lock.Unlock()
 
-	if pq.capacity != nil && pq.elements.Len() >= *pq.capacity {
-		return ErrQueueIsFull
+	return pq.named(pq.tracker.recordResult(elem, pq.offer(elem)))
+}
+
+// TryOffer attempts to insert the element to the tail of the queue without
+// waiting for the queue lock. If the lock is held by another goroutine it
+// returns false without attempting the insertion, otherwise it returns true
+// and the error Offer would have returned.
+// False negatives are expected under contention, TryOffer is meant for
+// callers that prefer skipping an operation over waiting for the lock.
+func (pq *PriorityAny[T]) TryOffer(elem T) (acquired bool, _ error) {
+	if !pq.lock.TryLock() {
+		return false, nil
 	}
 
-	heap.Push(pq.elements, elem)
+	defer pq.lock.Unlock()
 
-	return nil
+	return true, pq.named(pq.tracker.recordResult(elem, pq.offer(elem)))
 }
 
-// Reset sets the queue to its initial stat, by replacing the current
-// elements with the elements provided at creation.
-func (pq *Priority[T]) Reset() {
+// OfferWait inserts the element into the queue, waiting for the necessary
+// space to become available. It only waits if the queue is bounded. If the
+// element cannot be inserted for another reason, such as being rejected by
+// the validator, it is discarded, use OfferWaitErr in order to observe the
+// error.
+func (pq *PriorityAny[T]) OfferWait(elem T) {
 	pq.lock.Lock()
 	defer pq.lock.Unlock()
 
-	if pq.elements.Len() > len(pq.initialElements) {
-		pq.elements.elems = (pq.elements.elems)[:len(pq.initialElements)]
-	}
+	_ = pq.tracker.recordResult(elem, pq.offerWait(elem))
+}
 
-	if pq.elements.Len() < len(pq.initialElements) {
-		pq.elements.elems = make([]T, len(pq.initialElements))
-	}
+// OfferWaitErr inserts the element into the queue, as OfferWait does, and
+// returns the errors other than ErrQueueIsFull which Offer would have
+// returned, such as the InvalidElementError, without waiting.
+func (pq *PriorityAny[T]) OfferWaitErr(elem T) error {
+	pq.lock.Lock()
+	defer pq.lock.Unlock()
 
-	copy(pq.elements.elems, pq.initialElements)
+	return pq.named(pq.tracker.recordResult(elem, pq.offerWait(elem)))
 }
 
-// ===================================Removal==================================
+// offerWait inserts the element into the queue, waiting for the necessary
+// space to become available.
+func (pq *PriorityAny[T]) offerWait(elem T) error {
+	for {
+		err := pq.offer(elem)
+		if !errors.Is(err, ErrQueueIsFull) {
+			return err
+		}
 
-// Get removes and returns the head of the queue.
-// If no element is available it returns an ErrNoElementsAvailable error.
-func (pq *Priority[T]) Get() (elem T, _ error) {
+		pq.notFullCond.Wait()
+	}
+}
+
+// OfferAll inserts all the elements into the queue, or none of them.
+// If the elements do not all fit it returns the ErrQueueIsFull error
+// without inserting any of them. The elements are all validated first, if
+// the WithValidator option is provided.
+func (pq *PriorityAny[T]) OfferAll(elems ...T) error {
 	pq.lock.Lock()
 	defer pq.lock.Unlock()
 
-	if pq.elements.Len() == 0 {
-		return elem, ErrNoElementsAvailable
+	if err := pq.validator.checkAll(elems); err != nil {
+		return pq.named(err)
 	}
 
-	// nolint: forcetypeassert, revive // since the heap package does not yet support
-	// generic types it has to use the `any` type. In this case, by design,
-	// type of the items available in the pq.elements collection is always T.
-	return heap.Pop(pq.elements).(T), nil
+	if err := pq.window.checkAll(elems); err != nil {
+		return pq.named(err)
+	}
+
+	for _, elem := range elems {
+		pq.checks.offered(elem, pq.elements.elems)
+	}
+
+	if err := pq.occupancy.admit(len(elems), 0); err != nil {
+		return pq.named(err)
+	}
+
+	pq.window.rememberAll(elems)
+
+	for _, elem := range elems {
+		heap.Push(pq.elements, elem)
+
+		pq.journal.record(JournalOffer, elem, pq.elements.Len())
+
+		pq.version++
+	}
+
+	pq.tracker.recordBulk()
+
+	return nil
 }
 
-// Clear removes all elements from the queue.
-func (pq *Priority[T]) Clear() []T {
+// replaceAll replaces the elements of the queue with the given ones, using
+// the same semantics as OfferAll into the emptied queue. If the elements
+// cannot all be inserted the queue is left unchanged. The replaced elements
+// are released by the recycler.
+func (pq *PriorityAny[T]) replaceAll(elems ...T) error {
 	pq.lock.Lock()
 	defer pq.lock.Unlock()
 
-	elemsLen := pq.elements.Len()
+	if err := pq.validator.checkAll(elems); err != nil {
+		return err
+	}
 
-	elems := make([]T, elemsLen)
+	if err := pq.window.checkAll(elems); err != nil {
+		return err
+	}
 
-	for i := 0; i < elemsLen; i++ {
-		// nolint: forcetypeassert, revive // since priorityHeap is unexported, this
-		// method cannot be directly called by a library client, it is only called
-		// by the heap package functions. Thus, it is safe to expect that the
-		// input parameter `elem` type is always T.
-		elems[i] = heap.Pop(pq.elements).(T)
+	for _, elem := range elems {
+		pq.checks.offered(elem, elems)
 	}
 
-	return elems
-}
+	if err := pq.occupancy.replace(len(elems)); err != nil {
+		return err
+	}
 
-// Iterator returns an iterator over the elements in the queue.
-// It removes the elements from the queue.
-func (pq *Priority[T]) Iterator() <-chan T {
-	pq.lock.RLock()
-	defer pq.lock.RUnlock()
+	pq.recycler.discardAll(pq.clear())
 
-	// use a buffered channel to avoid blocking the iterator.
-	iteratorCh := make(chan T, pq.elements.Len())
+	pq.window.rememberAll(elems)
 
-	// iterate over the elements and send them to the channel.
-	for pq.elements.Len() > 0 {
-		// nolint: forcetypeassert, revive // since priorityHeap is unexported, this
-		// method cannot be directly called by a library client, it is only called
-		// by the heap package functions. Thus, it is safe to expect that the
-		// input parameter `elem` type is always T.
-		iteratorCh <- heap.Pop(pq.elements).(T)
-	}
+	for _, elem := range elems {
+		pq.occupancy.fillReserved(1)
 
-	close(iteratorCh)
+		heap.Push(pq.elements, elem)
 
-	return iteratorCh
-}
+		pq.journal.record(JournalOffer, elem, pq.elements.Len())
 
-// =================================Examination================================
+		pq.version++
+	}
 
-// IsEmpty returns true if the queue is empty, false otherwise.
-func (pq *Priority[T]) IsEmpty() bool {
-	pq.lock.RLock()
-	defer pq.lock.RUnlock()
+	pq.tracker.recordBulk()
 
-	return pq.elements.Len() == 0
+	return nil
 }
 
-// Contains returns true if the queue contains the element, false otherwise.
-func (pq *Priority[T]) Contains(a T) bool {
-	pq.lock.RLock()
-	defer pq.lock.RUnlock()
+// OfferSome inserts as many of the elements as possible into the queue, in
+// order, and returns the number of inserted elements. If the elements do not
+// all fit it inserts the ones which fit and returns the ErrQueueIsFull error.
+func (pq *PriorityAny[T]) OfferSome(elems ...T) (n int, _ error) {
+	pq.lock.Lock()
+	defer pq.lock.Unlock()
 
-	for i := range pq.elements.elems {
-		if pq.elements.elems[i] == a {
-			return true
+	var err error
+
+	for _, elem := range elems {
+		if err = pq.offer(elem); err != nil {
+			break
 		}
+
+		n++
 	}
 
-	return false
+	if n > 0 {
+		pq.tracker.recordBulk()
+	}
+
+	return n, pq.named(err)
 }
 
-// Peek retrieves but does not return the head of the queue.
-func (pq *Priority[T]) Peek() (elem T, _ error) {
+// CanOffer returns true if n elements would currently fit into the queue.
+// The result is advisory, since the queue may change before the elements are
+// offered, use OfferAll in order to insert the elements all or nothing.
+func (pq *PriorityAny[T]) CanOffer(n int) bool {
 	pq.lock.RLock()
 	defer pq.lock.RUnlock()
 
-	if pq.elements.Len() == 0 {
-		return elem, ErrNoElementsAvailable
-	}
+	return pq.occupancy.fits(n)
+}
+
+// Reset sets the queue to its initial stat, by replacing the current
+// elements with the elements provided at creation, or empties it if the
+// ResetEmpty behavior is provided using WithResetBehavior.
+func (pq *PriorityAny[T]) Reset() {
+	pq.lock.Lock()
+	defer pq.lock.Unlock()
+
+	pq.recycler.discardAll(pq.elements.elems)
+
+	if pq.elements.Len() > len(pq.initialElements) {
+		// release the references to the elements past the initial ones.
+		clear(pq.elements.elems[len(pq.initialElements):])
+
+		pq.elements.elems = (pq.elements.elems)[:len(pq.initialElements)]
+	}
+
+	if pq.elements.Len() < len(pq.initialElements) {
+		pq.elements.elems = make([]T, len(pq.initialElements))
+	}
+
+	// the initial elements are kept in the heap layout, thus the copy
+	// replacing every element is a valid heap.
+	copyElements(pq.elements.elems, pq.initialElements, pq.resetCloner)
+
+	pq.occupancy.reset(pq.elements.Len())
+
+	pq.window.forget()
+
+	if pq.elements.stable {
+		pq.elements.seqs = slices.Clone(pq.initialSeqs)
+		pq.elements.nextSeq = uint64(len(pq.initialSeqs))
+	}
+
+	pq.elements.readmit()
+
+	pq.journal.recordOp(JournalReset, pq.elements.Len())
+
+	pq.version++
+
+	pq.tracker.recordBulk()
+}
+
+// Exchange atomically removes the head of the queue and inserts the element,
+// returning the removed head. Since one element leaves as one enters, it
+// succeeds even if the queue is full.
+// If no element is available it inserts the element, as Offer does, and
+// returns the ErrNoElementsAvailable error, unless the insertion fails.
+// If the element is rejected by the validator provided using WithValidator
+// it returns an InvalidElementError, leaving the queue unchanged.
+func (pq *PriorityAny[T]) Exchange(elem T) (v T, _ error) {
+	pq.lock.Lock()
+	defer pq.lock.Unlock()
+
+	if err := pq.validator.check(elem); err != nil {
+		return v, pq.named(err)
+	}
+
+	if err := pq.window.check(elem); err != nil {
+		return v, pq.named(err)
+	}
+
+	if pq.elements.Len() == 0 {
+		if err := pq.offer(elem); err != nil {
+			return v, pq.named(err)
+		}
+
+		pq.tracker.record(elem)
+
+		return v, pq.named(ErrNoElementsAvailable)
+	}
+
+	pq.checks.offered(elem, pq.elements.elems)
+
+	// replace the head and restore the heap order.
+	v = pq.elements.elems[0]
+
+	pq.elements.replaceHead(elem)
+
+	pq.window.remember(elem)
+
+	pq.journal.record(JournalExchange, elem, pq.elements.Len())
+
+	pq.version++
+
+	pq.tracker.record(elem)
+
+	return v, nil
+}
+
+// Update replaces the first element of the queue found equal to oldElem, as
+// reported by the equality function, with newElem, and restores the heap
+// order. The equal elements are examined in the heap order, thus the replaced
+// element is not necessarily the highest priority one among them. newElem is
+// ordered as the last inserted element, and the replaced element is released
+// to the pool provided using WithRecycler.
+// It returns false, leaving the queue unchanged, if no element is equal to
+// oldElem. For a PriorityAny queue created without the WithEqualFunc option
+// it always returns false.
+func (pq *PriorityAny[T]) Update(oldElem, newElem T) bool {
+	pq.lock.Lock()
+	defer pq.lock.Unlock()
+
+	i := pq.index(oldElem)
+	if i < 0 {
+		return false
+	}
+
+	pq.checks.offered(newElem, pq.elements.elems)
+
+	replaced := pq.elements.elems[i]
+
+	pq.update(i, newElem)
+
+	pq.recycler.discard(replaced)
+
+	pq.tracker.record(newElem)
+
+	return true
+}
+
+// ===================================Removal==================================
+
+// Remove removes the first element of the queue found equal to elem, as
+// reported by the equality function, and restores the heap order. The equal
+// elements are examined in the heap order, thus the removed element is not
+// necessarily the highest priority one among them. The removed element is
+// released to the pool provided using WithRecycler.
+// It returns false if no element is equal to elem. For a PriorityAny queue
+// created without the WithEqualFunc option it always returns false.
+func (pq *PriorityAny[T]) Remove(elem T) bool {
+	pq.lock.Lock()
+	defer pq.lock.Unlock()
+
+	i := pq.index(elem)
+	if i < 0 {
+		return false
+	}
+
+	removed := pq.elements.elems[i]
+
+	pq.moveOut(removed, i)
+
+	pq.recycler.discard(removed)
+
+	return true
+}
+
+// Get removes and returns the head of the queue.
+// If no element is available it returns an ErrNoElementsAvailable error.
+func (pq *PriorityAny[T]) Get() (elem T, _ error) {
+	pq.lock.Lock()
+	defer pq.lock.Unlock()
+
+	elem, err := pq.get()
+
+	return elem, pq.named(pq.tracker.recordResult(elem, err))
+}
+
+// GetWait removes and returns the head of the queue, waiting for an element
+// to become available, such as an element inserted by Offer or restored by
+// Reset.
+func (pq *PriorityAny[T]) GetWait() T {
+	pq.lock.Lock()
+	defer pq.lock.Unlock()
+
+	for pq.elements.Len() == 0 {
+		pq.notEmptyCond.Wait()
+	}
+
+	// the heap is not empty.
+	elem, _ := pq.get()
+
+	pq.tracker.record(elem)
+
+	return elem
+}
+
+// TryGet attempts to remove and return the head of the queue without
+// waiting for the queue lock. If the lock is held by another goroutine it
+// returns false without attempting the removal, otherwise it returns true
+// along with the element and the error Get would have returned.
+// False negatives are expected under contention.
+func (pq *PriorityAny[T]) TryGet() (elem T, acquired bool, _ error) {
+	if !pq.lock.TryLock() {
+		return elem, false, nil
+	}
+
+	defer pq.lock.Unlock()
+
+	elem, err := pq.get()
+
+	return elem, true, pq.named(pq.tracker.recordResult(elem, err))
+}
+
+// Poll removes and returns the head of the queue. If no element is available
+// it retries after the polling interval elapses on the queue clock, until an
+// element is available or ctx is done, in which case it returns the ctx error.
+// The queue lock is not held between the attempts, which are always at least
+// an interval apart, thus Poll never busy-spins.
+// It returns the ErrInvalidInterval error if the interval is not positive.
+func (pq *PriorityAny[T]) Poll(ctx context.Context, interval time.Duration) (T, error) {
+	elem, err := pq.poller.poll(ctx, interval, pq.Get)
+
+	return elem, pq.named(err)
+}
+
+// GetN removes and returns up to n of the highest priority elements, in
+// priority order, acquiring the queue lock once. GetN(Size()) is equivalent
+// to Clear.
+func (pq *PriorityAny[T]) GetN(n int) []T {
+	pq.lock.Lock()
+	defer pq.lock.Unlock()
+
+	elems := make([]T, 0, min(max(n, 0), pq.elements.Len()))
+
+	for len(elems) < cap(elems) {
+		elem, err := pq.get()
+		if err != nil {
+			break
+		}
+
+		elems = append(elems, elem)
+	}
+
+	if len(elems) > 0 {
+		pq.tracker.recordBulk()
+	}
+
+	return elems
+}
+
+// Clear removes all elements from the queue.
+func (pq *PriorityAny[T]) Clear() []T {
+	pq.lock.Lock()
+	defer pq.lock.Unlock()
+
+	pq.tracker.recordBulk()
+
+	return pq.clear()
+}
+
+// ClearUnsafe removes and returns all elements from the queue, in priority
+// order, as Clear does, sorting the backing array of the heap in place and
+// handing it over to the caller instead of copying the elements out of it.
+// The caller owns the returned slice, which the queue no longer references,
+// and the queue starts over with a fresh backing array, preallocated to its
+// capacity, so that a flush cycle swaps one array instead of copying every
+// element.
+func (pq *PriorityAny[T]) ClearUnsafe() []T {
+	pq.lock.Lock()
+	defer pq.lock.Unlock()
+
+	pq.tracker.recordBulk()
+
+	return pq.detach()
+}
+
+// ClearIf evaluates pred against the current state of the queue and, if it
+// returns true, removes and returns all elements from the queue along with
+// true. Otherwise, it returns nil and false, leaving the queue unchanged.
+// The check and the removal are atomic with respect to the other operations.
+//
+// pred runs while holding the queue lock, thus it must be fast and must not
+// call the queue methods.
+func (pq *PriorityAny[T]) ClearIf(pred func(snapshot ClearSnapshot[T]) bool) ([]T, bool) {
+	pq.lock.Lock()
+	defer pq.lock.Unlock()
+
+	snapshot := ClearSnapshot[T]{
+		Size:     pq.elements.Len(),
+		Capacity: pq.occupancy.capacity,
+	}
+
+	if pq.elements.Len() > 0 {
+		snapshot.Head = pq.elements.elems[0]
+	}
+
+	if !pred(snapshot) {
+		return nil, false
+	}
+
+	pq.tracker.recordBulk()
+
+	return pq.clear(), true
+}
+
+// IteratorsN removes all the elements from the queue at once and distributes
+// them, in priority order, among k closed and buffered channels, as specified
+// by the partition mode, so that k workers can consume disjoint shares of the
+// queue without contending on it. Some channels are empty if k is greater than
+// the number of elements.
+// It returns the ErrInvalidPartitions error if k is lower than 1, leaving the
+// queue unchanged.
+func (pq *PriorityAny[T]) IteratorsN(k int, mode Partition) ([]<-chan T, error) {
+	if k < 1 {
+		return nil, pq.named(ErrInvalidPartitions)
+	}
+
+	pq.lock.Lock()
+	elems := pq.clear()
+	pq.lock.Unlock()
+
+	pq.tracker.recordBulk()
+
+	return partition(elems, k, mode), nil
+}
+
+// All returns a sequence over the queue elements, in priority order, without
+// removing them. It is an iter.Seq, thus it can be ranged over:
+//
+//	for elem := range q.All() {
+//		...
+//	}
+//
+// The elements are copied once the iteration starts, so the loop may modify
+// the queue, the changes not being visited.
+func (pq *PriorityAny[T]) All() func(yield func(T) bool) {
+	return snapshotSeq(pq.ToSlice)
+}
+
+// Drain returns a sequence removing the queue elements, in priority order, as they
+// are iterated, until the queue is empty or the loop stops. It is an
+// iter.Seq, thus it can be ranged over:
+//
+//	for elem := range q.Drain() {
+//		...
+//	}
+//
+// Each element is removed using Get, so the queue lock is not held while the
+// loop body runs, and stopping the loop early leaves the other elements in
+// the queue.
+func (pq *PriorityAny[T]) Drain() func(yield func(T) bool) {
+	return drainSeq(pq.Get)
+}
+
+// Iterator returns an iterator over the elements in the queue.
+// It removes the elements from the queue, in the same order as Clear.
+func (pq *PriorityAny[T]) Iterator() <-chan T {
+	pq.lock.Lock()
+	defer pq.lock.Unlock()
+
+	elems := pq.sortedDrain(true)
+
+	pq.tracker.recordBulk()
+
+	// use a buffered channel to avoid blocking the iterator.
+	return bufferedChan(len(elems), sliceSeq(elems))
+}
+
+// =================================Examination================================
+
+// IsEmpty returns true if the queue is empty, false otherwise.
+func (pq *PriorityAny[T]) IsEmpty() bool {
+	if pq.reads != nil {
+		return pq.readHead().size == 0
+	}
+
+	pq.lock.RLock()
+	defer pq.lock.RUnlock()
+
+	return pq.elements.Len() == 0
+}
+
+// Contains returns true if the queue contains the element, false otherwise.
+// For a PriorityAny queue created without the WithEqualFunc option it
+// always returns false.
+func (pq *PriorityAny[T]) Contains(a T) bool {
+	pq.lock.RLock()
+	defer pq.lock.RUnlock()
+
+	if pq.equalFunc == nil {
+		return false
+	}
+
+	for i := range pq.elements.elems {
+		if pq.equalFunc(pq.elements.elems[i], a) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Peek retrieves but does not return the head of the queue.
+func (pq *PriorityAny[T]) Peek() (elem T, _ error) {
+	if pq.reads != nil {
+		h := pq.readHead()
+
+		return h.elem, pq.named(h.err)
+	}
+
+	pq.lock.RLock()
+	defer pq.lock.RUnlock()
+
+	elem, err := pq.peek()
+
+	return elem, pq.named(err)
+}
+
+// PeekWait retrieves but does not remove the head of the queue, waiting for
+// an element to become available.
+func (pq *PriorityAny[T]) PeekWait() T {
+	pq.lock.Lock()
+	defer pq.lock.Unlock()
+
+	for pq.elements.Len() == 0 {
+		pq.notEmptyCond.Wait()
+	}
+
+	// the heap is not empty.
+	elem, _ := pq.peek()
+
+	return elem
+}
+
+// TryPeek attempts to retrieve, without removing, the head of the queue
+// without waiting for the queue lock. If the lock is held by a writer it
+// returns false without attempting the retrieval, otherwise it returns true
+// along with the element and the error Peek would have returned.
+// False negatives are expected under contention.
+func (pq *PriorityAny[T]) TryPeek() (elem T, acquired bool, _ error) {
+	if !pq.lock.TryRLock() {
+		return elem, false, nil
+	}
+
+	defer pq.lock.RUnlock()
+
+	elem, err := pq.peek()
+
+	return elem, true, pq.named(err)
+}
+
+// Size returns the number of elements in the queue.
+func (pq *PriorityAny[T]) Size() int {
+	if pq.reads != nil {
+		return pq.readHead().size
+	}
+
+	pq.lock.RLock()
+	defer pq.lock.RUnlock()
+
+	return pq.elements.Len()
+}
+
+// Capacity returns the fixed capacity of the queue, or -1 if the queue was
+// created without the WithCapacity option.
+func (pq *PriorityAny[T]) Capacity() int {
+	return pq.occupancy.capacity
+}
+
+// Remaining returns the number of elements the queue can hold in addition to
+// its current elements, or -1 if the queue is unbounded.
+func (pq *PriorityAny[T]) Remaining() int {
+	pq.lock.RLock()
+	defer pq.lock.RUnlock()
+
+	return pq.occupancy.remaining()
+}
+
+// ToSlice returns a copy of the queue elements in priority order, the order
+// in which Clear would remove them, without removing them.
+func (pq *PriorityAny[T]) ToSlice() []T {
+	if pq.reads != nil {
+		e := pq.readElems()
+
+		return e.copyN(len(e.elems))
+	}
+
+	pq.lock.RLock()
+	defer pq.lock.RUnlock()
+
+	return pq.snapshot()
+}
+
+// Each calls fn for the queue elements in priority order, until fn returns
+// false, without removing them. The elements are popped from a copy of the
+// heap as fn is called, so that stopping early saves sorting the rest of
+// them. It holds the queue lock for reading, thus fn must not modify the
+// queue.
+func (pq *PriorityAny[T]) Each(fn func(elem T) bool) {
+	pq.lock.RLock()
+	defer pq.lock.RUnlock()
+
+	h := pq.elements.clone()
+
+	for h.Len() > 0 {
+		// nolint: forcetypeassert, revive // the heap only holds elements of
+		// type T.
+		if !fn(heap.Pop(h).(T)) {
+			return
+		}
+	}
+}
+
+// PeekN returns a copy of the at most n highest priority elements, in
+// priority order, without removing them.
+func (pq *PriorityAny[T]) PeekN(n int) []T {
+	if pq.reads != nil {
+		return pq.readElems().copyN(n)
+	}
+
+	pq.lock.RLock()
+	defer pq.lock.RUnlock()
+
+	h := pq.elements.clone()
+
+	elems := make([]T, min(max(n, 0), h.Len()))
+
+	for i := range elems {
+		// nolint: forcetypeassert, revive // the heap holds elements of type T.
+		elems[i] = heap.Pop(h).(T)
+	}
+
+	return elems
+}
+
+// InspectPage returns a copy of at most limit elements, starting at the
+// cursor, along with the cursor of the next page, in priority order. The zero
+// Cursor starts from the head of the queue, the cursor returned along with the
+// last page is Done.
+//
+// The elements are sorted once per version of the queue, the following pages
+// are copied from the sorted elements while holding the queue lock.
+// If the queue was mutated since the cursor was issued it returns the
+// ErrCursorInvalidated error, the inspection has to be restarted.
+func (pq *PriorityAny[T]) InspectPage(cursor Cursor, limit int) ([]T, Cursor, error) {
+	pq.lock.Lock()
+	defer pq.lock.Unlock()
+
+	if pq.inspected == nil || pq.inspectedVersion != pq.version {
+		pq.inspected = pq.snapshot()
+		pq.inspectedVersion = pq.version
+	}
+
+	elems, next, err := inspectPage(
+		cursor,
+		limit,
+		pq.version,
+		len(pq.inspected),
+		func(dst []T, from, to int, _ any) ([]T, any) {
+			return append(dst, pq.inspected[from:to]...), nil
+		},
+	)
+
+	// release the sorted elements once they were all inspected.
+	if next.Done() {
+		pq.inspected = nil
+	}
+
+	return elems, next, pq.named(err)
+}
+
+// ContentionProfile returns, for every queue method sampled by the
+// WithContentionProfiling option, the time spent waiting to acquire the
+// queue lock. It returns nil if the option was not provided.
+func (pq *PriorityAny[T]) ContentionProfile() map[string]ContentionStats {
+	return pq.lock.contentionProfile()
+}
+
+// RecentOperations returns the last mutating operations of the queue, oldest
+// first, as recorded by the WithCallerTracking option. It returns nil if the
+// option was not provided.
+func (pq *PriorityAny[T]) RecentOperations() []OpRecord {
+	return pq.tracker.operations()
+}
+
+// MemoryFootprint returns an estimate of the memory retained by the queue,
+// measuring every element using sizeOf, or its shallow size if sizeOf is nil.
+// The structural bytes include the sequence numbers kept by the
+// WithStableOrder and WithConservationChecks options and the elements cached
+// by InspectPage.
+func (pq *PriorityAny[T]) MemoryFootprint(sizeOf func(T) uintptr) MemoryReport {
+	pq.lock.RLock()
+	defer pq.lock.RUnlock()
+
+	f := newFootprint(sizeOf, unsafe.Sizeof(*pq)+unsafe.Sizeof(*pq.elements))
+
+	f.elements(pq.elements.elems...)
+	f.slots(cap(pq.elements.elems))
+
+	f.overhead(uintptr(cap(pq.elements.seqs)+cap(pq.elements.ids)+cap(pq.initialSeqs)) * unsafe.Sizeof(uint64(0)))
+	f.copies(pq.inspected)
+	f.copies(pq.initialElements)
+
+	return f.done()
+}
+
+// HeapStats returns the depth of the heap holding the queue elements, the
+// average number of levels an element sifts down while the elements are
+// retrieved one by one, and the number of elements. The sift lengths are
+// measured by retrieving the elements of a copy of the heap, thus HeapStats
+// takes O(n log n) time and O(n) memory.
+func (pq *PriorityAny[T]) HeapStats() (depth int, avgSiftLen float64, size int) {
+	pq.lock.RLock()
+	h := pq.elements.clone()
+	pq.lock.RUnlock()
+
+	size = h.Len()
+	depth = bits.Len(uint(size))
+
+	if size == 0 {
+		return depth, 0, 0
+	}
+
+	counter := &siftCounter[T]{priorityHeap: h}
+
+	for counter.Len() > 0 {
+		heap.Pop(counter)
+	}
+
+	// each retrieval swaps the head with the last element before sifting.
+	return depth, float64(counter.swaps-size) / float64(size), size
+}
+
+// Rebuild lays the heap holding the queue elements out in priority order,
+// which shortens the paths along which the elements sift after a long
+// sequence of insertions in adversarial orders. The order in which the
+// elements are retrieved is unchanged, including the order of the equal
+// elements if the WithStableOrder option is provided.
+// It takes O(n log n) time, holding the queue lock.
+func (pq *PriorityAny[T]) Rebuild() {
+	pq.lock.Lock()
+	defer pq.lock.Unlock()
+
+	pq.elements.rebuild(pq.elements.Len())
+}
+
+// LedgerCheck returns an error joining the conservation violations observed,
+// wrapping ErrElementsNotConserved for every element which left the queue
+// more than once, and for every admitted element which neither left the queue
+// nor is queued, or nil if every element is accounted for exactly once.
+// An element leaves the queue once it is retrieved, cleared, evicted by
+// OfferBounded, replaced by Reset, Exchange or Update, or moved to another
+// queue. The element replacing another one is admitted as a new element.
+// It panics if the queue was created without the WithConservationChecks option.
+func (pq *PriorityAny[T]) LedgerCheck() error {
+	pq.lock.RLock()
+	defer pq.lock.RUnlock()
+
+	if pq.elements.ledger == nil {
+		panic("queue created without the WithConservationChecks option")
+	}
+
+	return pq.named(pq.elements.ledger.check(pq.elements.ids))
+}
+
+// SyncJournal waits until the journal records of the operations completed so
+// far are written, if the WithJournal option is provided.
+func (pq *PriorityAny[T]) SyncJournal() {
+	pq.journal.flush()
+}
+
+// Name returns the name provided using WithName, or an empty string if the
+// queue is unnamed.
+func (pq *PriorityAny[T]) Name() string {
+	return pq.name
+}
+
+// named wraps err in a QueueError if the queue is named.
+func (pq *PriorityAny[T]) named(err error) error {
+	return nameError(pq.name, pq.kind, err)
+}
+
+// =================================Marshalling================================
+
+// MarshalJSON serializes the queue elements to a JSON array in priority order.
+func (pq *PriorityAny[T]) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+
+	if err := pq.MarshalJSONTo(&buf); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// MarshalJSONTo streams the queue elements to w as a JSON array in priority order.
+// The elements are copied while holding the queue lock and encoded one at a
+// time after releasing it, or before releasing it if the WithRecycler option
+// is provided. The output is identical to the MarshalJSON output.
+// If the WithLessName option is provided, the array is wrapped in an object
+// recording the comparator name, as described by RegisterLess.
+func (pq *PriorityAny[T]) MarshalJSONTo(w io.Writer) error {
+	pq.lock.RLock()
+	elems := pq.snapshot()
+
+	writeElements := func(w io.Writer) error {
+		return encodeJSONArray(w, elems, pq.codec.marshal)
+	}
+
+	// the pooled elements are encoded before any of them can be released.
+	if pq.recycler.enabled() {
+		defer pq.lock.RUnlock()
+
+		return pq.named(writeCheckpoint(w, pq.lessName, writeElements))
+	}
+
+	pq.lock.RUnlock()
+
+	return pq.named(writeCheckpoint(w, pq.lessName, writeElements))
+}
+
+// UnmarshalJSONFrom reads a JSON array from r and replaces the elements of
+// the queue with its elements, inserted using the same semantics as OfferAll
+// into the emptied queue. If an element cannot be decoded, the error reports
+// its index, and if the elements cannot all be inserted, none of them is and
+// the queue is left unchanged. The decoded elements are held until they are
+// inserted, unless the WithStreamingJSON option is provided.
+//
+// It also reads the objects written by MarshalJSONTo for the queues created
+// with the WithLessName option, returning the ErrComparatorMismatch error if
+// the recorded comparator is not the one of the queue, as described by
+// RegisterLess.
+func (pq *PriorityAny[T]) UnmarshalJSONFrom(r io.Reader) error {
+	elems, err := readCheckpoint(r, pq.lessName, pq.comparatorOverride)
+	if err != nil {
+		return pq.named(err)
+	}
+
+	return pq.named(pq.codec.decodeInto(elems, pq.recycler, pq.Offer, pq.replaceAll))
+}
+
+// UnmarshalJSON replaces the elements of the queue with the elements of the
+// JSON array data, as UnmarshalJSONFrom does.
+func (pq *PriorityAny[T]) UnmarshalJSON(data []byte) error {
+	return pq.UnmarshalJSONFrom(bytes.NewReader(data))
+}
+
+// MarshalBinary encodes the queue elements using encoding/gob, in their heap
+// layout, so that they can be restored exactly using UnmarshalBinary,
+// including the order of the equal elements if the WithStableOrder option is
+// provided. The elements are copied while holding the queue lock and encoded
+// after releasing it, or before releasing it if the WithRecycler option is
+// provided. The comparator name provided using WithLessName is recorded.
+func (pq *PriorityAny[T]) MarshalBinary() ([]byte, error) {
+	pq.lock.RLock()
+
+	snapshot := &binarySnapshot[T]{
+		Capacity: pq.occupancy.capacity,
+		Elems:    slices.Clone(pq.elements.elems),
+		Less:     pq.lessName,
+	}
+
+	if pq.elements.stable {
+		snapshot.Seqs = slices.Clone(pq.elements.seqs)
+		snapshot.NextSeq = pq.elements.nextSeq
+	}
+
+	// the pooled elements are encoded before any of them can be released.
+	if pq.recycler.enabled() {
+		defer pq.lock.RUnlock()
+	} else {
+		pq.lock.RUnlock()
+	}
+
+	data, err := snapshot.encode()
+
+	return data, pq.named(err)
+}
+
+// UnmarshalBinary replaces the queue elements with the elements encoded by
+// MarshalBinary, which are restored as is, without being validated. The
+// replaced elements are released if the WithRecycler option is provided.
+//
+// It returns the ErrCapacityMismatch error, leaving the queue unchanged, if
+// the elements were encoded from a queue with another capacity, and the
+// ErrComparatorMismatch error if they were encoded from a queue with another
+// comparator name, unless the WithComparatorOverride option is provided.
+func (pq *PriorityAny[T]) UnmarshalBinary(data []byte) error {
+	snapshot, err := decodeBinarySnapshot[T](data)
+	if err != nil {
+		return pq.named(err)
+	}
+
+	pq.lock.Lock()
+	defer pq.lock.Unlock()
+
+	if err := snapshot.checkCapacity(pq.occupancy.capacity); err != nil {
+		return pq.named(err)
+	}
+
+	if snapshot.Less != pq.lessName && !pq.comparatorOverride {
+		return pq.named(fmt.Errorf(
+			"%w: recorded with %q, restored with %q",
+			ErrComparatorMismatch, snapshot.Less, pq.lessName,
+		))
+	}
+
+	pq.recycler.discardAll(pq.elements.elems)
+
+	pq.elements.elems = snapshot.Elems
+
+	if pq.elements.stable {
+		if len(snapshot.Seqs) == len(snapshot.Elems) {
+			pq.elements.seqs = snapshot.Seqs
+			pq.elements.nextSeq = snapshot.NextSeq
+		} else {
+			// the elements were encoded from an unstable queue, so they are
+			// sequenced in their layout order.
+			pq.elements.seqs = make([]uint64, len(snapshot.Elems))
+
+			for i := range pq.elements.seqs {
+				pq.elements.seqs[i] = uint64(i)
+			}
+
+			pq.elements.nextSeq = uint64(len(snapshot.Elems))
+		}
+	}
+
+	pq.elements.readmit()
+
+	// a valid heap layout is left unchanged, so that the equal elements are
+	// retrieved in the same order, whatever the comparator.
+	heap.Init(pq.elements)
+
+	pq.occupancy.reset(pq.elements.Len())
+
+	pq.journal.recordOp(JournalClear, 0)
+
+	for i, elem := range pq.elements.elems {
+		pq.journal.record(JournalOffer, elem, i+1)
+	}
+
+	pq.version++
+
+	pq.tracker.recordBulk()
+
+	return nil
+}
+
+// Dump writes a snapshot of the queue elements to w, in the format of
+// MarshalJSONTo, so that it can be restored using UnmarshalJSONFrom.
+func (pq *PriorityAny[T]) Dump(w io.Writer) error {
+	return pq.MarshalJSONTo(w)
+}
+
+// ===================================Helpers==================================
+
+// discard releases an element dropped after being retrieved from the queue.
+func (pq *PriorityAny[T]) discard(elem T) {
+	pq.recycler.discard(elem)
+}
+
+// offer inserts the element into the heap, if it is valid, it is not a
+// duplicate within the idempotency window and there is enough capacity.
+func (pq *PriorityAny[T]) offer(elem T) error {
+	if err := pq.validator.check(elem); err != nil {
+		return err
+	}
+
+	if err := pq.window.check(elem); err != nil {
+		return err
+	}
+
+	pq.checks.offered(elem, pq.elements.elems)
+
+	if err := pq.occupancy.admit(1, 0); err != nil {
+		return err
+	}
+
+	heap.Push(pq.elements, elem)
+
+	pq.window.remember(elem)
+
+	pq.journal.record(JournalOffer, elem, pq.elements.Len())
+
+	pq.version++
+
+	return nil
+}
+
+// moveLock returns the lock of the queue.
+func (pq *PriorityAny[T]) moveLock() *profiledRWMutex {
+	return &pq.lock
+}
+
+// moveCandidate returns the element to be moved by Move or MoveMatching,
+// along with its index in the heap. Among the elements satisfying pred, it
+// returns the one Get would return first.
+func (pq *PriorityAny[T]) moveCandidate(pred func(T) bool) (elem T, pos int, _ error) {
+	if pq.elements.Len() == 0 {
+		return elem, 0, ErrNoElementsAvailable
+	}
+
+	if pred == nil {
+		return pq.elements.elems[0], 0, nil
+	}
+
+	pos = -1
+
+	for i, e := range pq.elements.elems {
+		if pred(e) && (pos < 0 || pq.elements.Less(i, pos)) {
+			pos = i
+		}
+	}
+
+	if pos < 0 {
+		return elem, 0, ErrNoElementsAvailable
+	}
+
+	return pq.elements.elems[pos], pos, nil
+}
+
+// moveOut removes the element at the given index of the heap.
+func (pq *PriorityAny[T]) moveOut(elem T, pos int) {
+	pq.tracker.record(elem)
+
+	if pos == 0 {
+		_, _ = pq.get()
+
+		return
+	}
+
+	pq.version++
+
+	pq.occupancy.releaseAdmission(1, 0)
+
+	heap.Remove(pq.elements, pos)
+
+	pq.journal.recordRemove(elem, pos, pq.elements.Len())
+}
+
+// moveAdmit admits the element moved into the queue, as Offer does.
+func (pq *PriorityAny[T]) moveAdmit(elem T) error {
+	if err := pq.validator.check(elem); err != nil {
+		return err
+	}
+
+	if err := pq.window.check(elem); err != nil {
+		return err
+	}
+
+	pq.checks.offered(elem, pq.elements.elems)
+
+	return pq.occupancy.admit(1, 0)
+}
+
+// moveIn inserts the element moved into the queue.
+func (pq *PriorityAny[T]) moveIn(elem T) {
+	pq.tracker.record(elem)
+
+	heap.Push(pq.elements, elem)
+
+	pq.window.remember(elem)
+
+	pq.journal.record(JournalOffer, elem, pq.elements.Len())
+
+	pq.version++
+}
+
+// moveElems returns a copy of the elements, in priority order.
+func (pq *PriorityAny[T]) moveElems() []T {
+	return pq.snapshot()
+}
+
+// moveTail returns the index in the heap of an element equal to elem, the
+// last inserted one among them if the WithStableOrder option is provided.
+func (pq *PriorityAny[T]) moveTail(elem T) (pos int, ok bool) {
+	h := pq.elements
+
+	pos = -1
+
+	for i := range h.elems {
+		if !pq.equalFunc(h.elems[i], elem) {
+			continue
+		}
+
+		if !h.stable {
+			return i, true
+		}
+
+		if pos < 0 || h.seqs[i] > h.seqs[pos] {
+			pos = i
+		}
+	}
+
+	return pos, pos >= 0
+}
+
+// index returns the index of the first element of the heap equal to elem,
+// or -1 if there is none.
+func (pq *PriorityAny[T]) index(elem T) int {
+	if pq.equalFunc == nil {
+		return -1
+	}
+
+	for i := range pq.elements.elems {
+		if pq.equalFunc(pq.elements.elems[i], elem) {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// update replaces the element at index i with elem and restores the heap
+// order.
+func (pq *PriorityAny[T]) update(i int, elem T) {
+	pq.elements.replace(i, elem)
+
+	pq.journal.recordUpdate(elem, i, pq.elements.Len())
+
+	pq.version++
+}
+
+// replayUpdate replaces the element at the given position, replaying a
+// JournalUpdate record.
+func (pq *PriorityAny[T]) replayUpdate(pos int, elem T) bool {
+	pq.lock.Lock()
+	defer pq.lock.Unlock()
+
+	if pos < 0 || pos >= pq.elements.Len() {
+		return false
+	}
+
+	pq.update(pos, elem)
+
+	return true
+}
+
+// verifyOccupancy checks that the occupancy counts the elements held by
+// the queue.
+func (pq *PriorityAny[T]) verifyOccupancy() {
+	pq.occupancy.verify(pq.elements.Len())
+}
+
+// wakeWaiters wakes the goroutines waiting for an element if the heap grew
+// since they were last woken, and the ones waiting for space if it shrank.
+func (pq *PriorityAny[T]) wakeWaiters() {
+	switch n := pq.elements.Len(); {
+	case n > pq.wokenSize:
+		pq.notEmptyCond.Broadcast()
+	case n < pq.wokenSize:
+		pq.notFullCond.Broadcast()
+	}
+
+	pq.wokenSize = pq.elements.Len()
+}
+
+// get removes and returns the head of the heap.
+func (pq *PriorityAny[T]) get() (elem T, _ error) {
+	if pq.elements.Len() == 0 {
+		return elem, ErrNoElementsAvailable
+	}
+
+	pq.version++
+
+	pq.occupancy.releaseAdmission(1, 0)
+
+	// nolint: forcetypeassert, revive // since the heap package does not yet support
+	// generic types it has to use the `any` type. In this case, by design,
+	// type of the items available in the pq.elements collection is always T.
+	elem = heap.Pop(pq.elements).(T)
+
+	pq.checks.removed(elem, pq.elements.elems)
+
+	pq.journal.record(JournalGet, elem, pq.elements.Len())
+
+	return elem, nil
+}
+
+// peek returns the head of the heap without removing it.
+func (pq *PriorityAny[T]) peek() (elem T, _ error) {
+	if pq.elements.Len() == 0 {
+		return elem, ErrNoElementsAvailable
+	}
 
 	return pq.elements.elems[0], nil
 }
 
-// Size returns the number of elements in the queue.
-func (pq *Priority[T]) Size() int {
+// exactSize returns the number of elements in the queue, bypassing the
+// cache of the WithCachedReads option.
+func (pq *PriorityAny[T]) exactSize() int {
 	pq.lock.RLock()
 	defer pq.lock.RUnlock()
 
 	return pq.elements.Len()
 }
+
+// readHead returns the size and the head of the queue cached by the
+// WithCachedReads option, refreshing them if they expired.
+func (pq *PriorityAny[T]) readHead() *cachedHead[T] {
+	if h := pq.reads.freshHead(); h != nil {
+		return h
+	}
+
+	pq.lock.RLock()
+	defer pq.lock.RUnlock()
+
+	elem, err := pq.peek()
+
+	return pq.reads.storeHead(pq.elements.Len(), elem, err)
+}
+
+// readElems returns the elements of the queue cached by the WithCachedReads
+// option, refreshing them if they expired.
+func (pq *PriorityAny[T]) readElems() *cachedElems[T] {
+	if e := pq.reads.freshElems(); e != nil {
+		return e
+	}
+
+	pq.lock.RLock()
+	defer pq.lock.RUnlock()
+
+	return pq.reads.storeElems(pq.snapshot())
+}
+
+// snapshot returns a copy of the queue elements in priority order,
+// the same order in which Clear would remove them.
+func (pq *PriorityAny[T]) snapshot() []T {
+	return pq.sortedDrain(false)
+}
+
+// clear removes and returns all elements from the queue, in priority order.
+func (pq *PriorityAny[T]) clear() []T {
+	return pq.sortedDrain(true)
+}
+
+// sortedDrain pops all elements from the heap, in priority order.
+// If live is false the elements are popped from a copy of the heap, leaving
+// the queue unchanged. Since the copy has the same layout as the heap, the
+// elements are returned in the same order, including the equal elements.
+func (pq *PriorityAny[T]) sortedDrain(live bool) []T {
+	h := pq.elements
+
+	if live {
+		pq.version++
+
+		pq.occupancy.reset(0)
+	} else {
+		h = pq.elements.clone()
+	}
+
+	elems := make([]T, h.Len())
+
+	for i := range elems {
+		// nolint: forcetypeassert, revive // since priorityHeap is unexported, this
+		// method cannot be directly called by a library client, it is only called
+		// by the heap package functions. Thus, it is safe to expect that the
+		// input parameter `elem` type is always T.
+		elems[i] = heap.Pop(h).(T)
+	}
+
+	if live {
+		pq.journal.recordOp(JournalClear, 0)
+	}
+
+	return elems
+}
+
+// detach removes and returns all elements from the queue, in priority order,
+// as clear does, replacing the backing array of the heap instead of copying
+// the elements out of it.
+func (pq *PriorityAny[T]) detach() []T {
+	pq.version++
+
+	pq.occupancy.reset(0)
+
+	h := pq.elements
+	elems := h.elems
+
+	// every pop swaps the head to the end of the heap before truncating it,
+	// thus the backing array ends up holding the elements in reverse
+	// priority order, popped in the same order as by clear.
+	for h.Len() > 0 {
+		heap.Pop(h)
+	}
+
+	slices.Reverse(elems)
+
+	size := max(pq.occupancy.capacity, 0)
+
+	h.elems = make([]T, 0, size)
+
+	if h.stable {
+		h.seqs = make([]uint64, 0, size)
+	}
+
+	pq.journal.recordOp(JournalClear, 0)
+
+	return elems
+}
+
+// init initializes the queue with the given elements and options.
+func (pq *PriorityAny[T]) init(
+	elems []T,
+	lessFunc func(elem, otherElem T) bool,
+	opts []Option,
+) {
+	// default options
+	options := options{
+		capacity: nil,
+	}
+
+	for _, o := range opts {
+		o.apply(&options)
+	}
+
+	if options.lessName != "" {
+		lessFunc = registeredLess[T](options.lessName)
+	}
+
+	if lessFunc == nil {
+		panic("nil less func")
+	}
+
+	pq.recycler = recyclerOf[T](options)
+	pq.validator = validatorOf[T](options)
+	pq.window = newIdempotencyWindow[T](options)
+
+	elems = pq.validator.filter(elems, pq.recycler)
+
+	heapElems := make([]T, len(elems))
+
+	copy(heapElems, elems)
+
+	elementsHeap := &priorityHeap[T]{
+		elems:        heapElems,
+		lessFunc:     lessFunc,
+		stable:       options.stableOrder,
+		lifo:         options.tieBreak == TieBreakLIFO,
+		rebuildEvery: options.autoRebuild,
+	}
+
+	// if capacity is provided and is less than the number of elements
+	// provided, the elements are sorted and trimmed to fit the capacity.
+	if options.capacity != nil && *options.capacity < elementsHeap.Len() {
+		sort.SliceStable(elementsHeap.elems, func(i, j int) bool {
+			return lessFunc((elementsHeap.elems)[i], (elementsHeap.elems)[j])
+		})
+
+		pq.recycler.discardAll(elementsHeap.elems[*options.capacity:])
+
+		elementsHeap.elems = (elementsHeap.elems)[:*options.capacity]
+	}
+
+	// the initial elements are offered in the given order.
+	if elementsHeap.stable {
+		elementsHeap.seqs = make([]uint64, elementsHeap.Len())
+
+		for i := range elementsHeap.seqs {
+			elementsHeap.seqs[i] = uint64(i)
+		}
+
+		elementsHeap.nextSeq = uint64(elementsHeap.Len())
+	}
+
+	if options.conservationChecks {
+		elementsHeap.ledger = newLedger()
+
+		elementsHeap.readmit()
+	}
+
+	heap.Init(elementsHeap)
+
+	resetCloner := resetClonerOf[T](options)
+
+	pq.initialElements = initialElementsOf(options, elementsHeap.elems, resetCloner)
+	pq.resetCloner = resetCloner
+	pq.initialSeqs = slices.Clone(elementsHeap.seqs)
+
+	if pq.initialElements == nil {
+		pq.initialSeqs = nil
+	}
+	pq.elements = elementsHeap
+	pq.occupancy = newOccupancy(options.capacity)
+	pq.occupancy.limit = newHardLimit(options)
+	pq.occupancy.forceAdmit(elementsHeap.Len(), 0)
+	pq.lessName = options.lessName
+	pq.comparatorOverride = options.comparatorOverride
+	pq.equalFunc = equalFuncOf[T](options)
+	pq.codec = jsonCodecOf[T](options)
+	pq.poller = newPoller[T](options)
+	pq.reads = newReadCache[T](options)
+	pq.tracker = newCallerTracker[T](options)
+	pq.journal = newJournal[T](options)
+	pq.name = options.name
+	pq.kind = "PriorityAny"
+	pq.checks = newComparatorChecker(options, lessFunc)
+	pq.lock.profiler = newContentionProfiler(options.contentionSampleRate)
+
+	if checkInvariants {
+		pq.lock.verify = pq.verifyOccupancy
+	}
+
+	pq.notEmptyCond = sync.NewCond(&pq.lock)
+	pq.notFullCond = sync.NewCond(&pq.lock)
+	pq.wokenSize = pq.elements.Len()
+	pq.lock.wake = pq.wakeWaiters
+}
@@ -0,0 +1,186 @@
+package queue
+
+import (
+	"cmp"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+	"unsafe"
+)
+
+// timeType is the type of the time.Time fields, ordered using Before.
+var timeType = reflect.TypeOf(time.Time{})
+
+// NewPriorityByField creates a new Priority queue containing the given
+// elements, ordered by the field of T at fieldPath, a dot-separated path of
+// exported field names, such as "Deadline" or "Meta.Priority", which may
+// cross struct fields but not pointers. The elements with the lowest field
+// value are retrieved first if ascending is true, the ones with the highest
+// value otherwise.
+//
+// The field must be an integer, a float, a string or a time.Time, including
+// the named types based on them. The floats are ordered as by cmp.Less, NaN
+// first. The path is resolved once, so that a comparison loads the two field
+// values and compares them.
+//
+// It returns an error wrapping ErrInvalidField, describing the path, if T is
+// not a struct, if a field of the path is missing or unexported, if the path
+// crosses a field which is not a struct, or if the type of the field is not
+// supported.
+func NewPriorityByField[T comparable](
+	elems []T,
+	fieldPath string,
+	ascending bool,
+	opts ...Option,
+) (*Priority[T], error) {
+	less, err := fieldLess[T](fieldPath, ascending)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewPriority(elems, less, opts...), nil
+}
+
+// fieldLess returns the less function ordering the elements of type T by
+// the field at fieldPath.
+func fieldLess[T any](fieldPath string, ascending bool) (func(elem, otherElem T) bool, error) {
+	typ := reflect.TypeOf((*T)(nil)).Elem()
+
+	field, offset, err := resolveField(typ, fieldPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if field == timeType {
+		return timeFieldLess[T](offset, ascending), nil
+	}
+
+	switch field.Kind() {
+	case reflect.Int:
+		return orderedFieldLess[T, int](offset, ascending), nil
+	case reflect.Int8:
+		return orderedFieldLess[T, int8](offset, ascending), nil
+	case reflect.Int16:
+		return orderedFieldLess[T, int16](offset, ascending), nil
+	case reflect.Int32:
+		return orderedFieldLess[T, int32](offset, ascending), nil
+	case reflect.Int64:
+		return orderedFieldLess[T, int64](offset, ascending), nil
+	case reflect.Uint:
+		return orderedFieldLess[T, uint](offset, ascending), nil
+	case reflect.Uint8:
+		return orderedFieldLess[T, uint8](offset, ascending), nil
+	case reflect.Uint16:
+		return orderedFieldLess[T, uint16](offset, ascending), nil
+	case reflect.Uint32:
+		return orderedFieldLess[T, uint32](offset, ascending), nil
+	case reflect.Uint64:
+		return orderedFieldLess[T, uint64](offset, ascending), nil
+	case reflect.Uintptr:
+		return orderedFieldLess[T, uintptr](offset, ascending), nil
+	case reflect.Float32:
+		return floatFieldLess[T, float32](offset, ascending), nil
+	case reflect.Float64:
+		return floatFieldLess[T, float64](offset, ascending), nil
+	case reflect.String:
+		return orderedFieldLess[T, string](offset, ascending), nil
+	default:
+		return nil, fmt.Errorf(
+			"%w %q of %s: %s is not an integer, a float, a string or a time.Time",
+			ErrInvalidField, fieldPath, typ, field,
+		)
+	}
+}
+
+// resolveField returns the type of the field of typ at fieldPath and its
+// offset from the start of typ.
+func resolveField(typ reflect.Type, fieldPath string) (reflect.Type, uintptr, error) {
+	invalid := func(format string, args ...any) error {
+		return fmt.Errorf("%w %q of %s: %s", ErrInvalidField, fieldPath, typ, fmt.Sprintf(format, args...))
+	}
+
+	var (
+		offset uintptr
+		field  = typ
+	)
+
+	for _, name := range strings.Split(fieldPath, ".") {
+		if field.Kind() != reflect.Struct {
+			return nil, 0, invalid("%s is not a struct", field)
+		}
+
+		structField, ok := field.FieldByName(name)
+		if !ok {
+			return nil, 0, invalid("%s has no field %q", field, name)
+		}
+
+		if !structField.IsExported() {
+			return nil, 0, invalid("field %q of %s is unexported", name, field)
+		}
+
+		// a promoted field is reached through its embedded structs, which
+		// must not be pointers.
+		for i := range structField.Index {
+			embedded := field.FieldByIndex(structField.Index[:i+1])
+
+			if i < len(structField.Index)-1 && embedded.Type.Kind() != reflect.Struct {
+				return nil, 0, invalid("field %q of %s is promoted through the pointer %s", name, field, embedded.Name)
+			}
+
+			offset += embedded.Offset
+		}
+
+		field = structField.Type
+	}
+
+	return field, offset, nil
+}
+
+// fieldOf returns a pointer to the field of type F at the given offset of
+// elem.
+func fieldOf[F, T any](elem *T, offset uintptr) *F {
+	return (*F)(unsafe.Add(unsafe.Pointer(elem), offset))
+}
+
+// orderedFieldLess orders the elements by their field of type F at the
+// given offset.
+func orderedFieldLess[T any, F cmp.Ordered](offset uintptr, ascending bool) func(elem, otherElem T) bool {
+	if ascending {
+		return func(elem, otherElem T) bool {
+			return *fieldOf[F](&elem, offset) < *fieldOf[F](&otherElem, offset)
+		}
+	}
+
+	return func(elem, otherElem T) bool {
+		return *fieldOf[F](&elem, offset) > *fieldOf[F](&otherElem, offset)
+	}
+}
+
+// floatFieldLess orders the elements by their float field at the given
+// offset, using cmp.Less, so that NaN is ordered consistently.
+func floatFieldLess[T any, F float32 | float64](offset uintptr, ascending bool) func(elem, otherElem T) bool {
+	if ascending {
+		return func(elem, otherElem T) bool {
+			return cmp.Less(*fieldOf[F](&elem, offset), *fieldOf[F](&otherElem, offset))
+		}
+	}
+
+	return func(elem, otherElem T) bool {
+		return cmp.Less(*fieldOf[F](&otherElem, offset), *fieldOf[F](&elem, offset))
+	}
+}
+
+// timeFieldLess orders the elements by their time.Time field at the given
+// offset.
+func timeFieldLess[T any](offset uintptr, ascending bool) func(elem, otherElem T) bool {
+	if ascending {
+		return func(elem, otherElem T) bool {
+			return fieldOf[time.Time](&elem, offset).Before(*fieldOf[time.Time](&otherElem, offset))
+		}
+	}
+
+	return func(elem, otherElem T) bool {
+		return fieldOf[time.Time](&elem, offset).After(*fieldOf[time.Time](&otherElem, offset))
+	}
+}
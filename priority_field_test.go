@@ -0,0 +1,320 @@
+package queue_test
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/adrianbrad/queue"
+)
+
+type fieldLevel int8
+
+type fieldMeta struct {
+	Deadline time.Time
+	Level    fieldLevel
+}
+
+type fieldAudit struct {
+	Owner string
+}
+
+type fieldTask struct {
+	fieldAudit
+
+	ID    int
+	Score float64
+	Meta  fieldMeta
+	Ref   *fieldMeta
+	Tags  [2]string
+
+	hidden int
+}
+
+type fieldTaskRef struct {
+	*fieldAudit
+
+	ID int
+}
+
+func TestNewPriorityByField(t *testing.T) {
+	t.Parallel()
+
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// drain returns the IDs of the tasks, in the order in which they are
+	// retrieved.
+	drain := func(t *testing.T, priorityQueue *queue.Priority[fieldTask]) []int {
+		t.Helper()
+
+		var ids []int
+
+		for !priorityQueue.IsEmpty() {
+			task, err := priorityQueue.Get()
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			ids = append(ids, task.ID)
+		}
+
+		return ids
+	}
+
+	newQueue := func(t *testing.T, elems []fieldTask, fieldPath string, ascending bool) *queue.Priority[fieldTask] {
+		t.Helper()
+
+		priorityQueue, err := queue.NewPriorityByField(elems, fieldPath, ascending)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		return priorityQueue
+	}
+
+	t.Run("Int", func(t *testing.T) {
+		t.Parallel()
+
+		elems := []fieldTask{{ID: 3}, {ID: 1}, {ID: 2}}
+
+		if ids := drain(t, newQueue(t, elems, "ID", true)); !reflect.DeepEqual([]int{1, 2, 3}, ids) {
+			t.Fatalf("expected ids to be %v, got %v", []int{1, 2, 3}, ids)
+		}
+
+		if ids := drain(t, newQueue(t, elems, "ID", false)); !reflect.DeepEqual([]int{3, 2, 1}, ids) {
+			t.Fatalf("expected ids to be %v, got %v", []int{3, 2, 1}, ids)
+		}
+	})
+
+	t.Run("NestedTime", func(t *testing.T) {
+		t.Parallel()
+
+		elems := []fieldTask{
+			{ID: 1, Meta: fieldMeta{Deadline: base.Add(time.Hour)}},
+			{ID: 2, Meta: fieldMeta{Deadline: base}},
+			// the same instant in another location.
+			{ID: 3, Meta: fieldMeta{Deadline: base.Add(30 * time.Minute).In(time.FixedZone("UTC+1", 3600))}},
+		}
+
+		if ids := drain(t, newQueue(t, elems, "Meta.Deadline", true)); !reflect.DeepEqual([]int{2, 3, 1}, ids) {
+			t.Fatalf("expected ids to be %v, got %v", []int{2, 3, 1}, ids)
+		}
+
+		if ids := drain(t, newQueue(t, elems, "Meta.Deadline", false)); !reflect.DeepEqual([]int{1, 3, 2}, ids) {
+			t.Fatalf("expected ids to be %v, got %v", []int{1, 3, 2}, ids)
+		}
+	})
+
+	t.Run("NamedType", func(t *testing.T) {
+		t.Parallel()
+
+		elems := []fieldTask{
+			{ID: 1, Meta: fieldMeta{Level: -1}},
+			{ID: 2, Meta: fieldMeta{Level: 5}},
+			{ID: 3, Meta: fieldMeta{Level: -7}},
+		}
+
+		if ids := drain(t, newQueue(t, elems, "Meta.Level", false)); !reflect.DeepEqual([]int{2, 1, 3}, ids) {
+			t.Fatalf("expected ids to be %v, got %v", []int{2, 1, 3}, ids)
+		}
+	})
+
+	t.Run("PromotedString", func(t *testing.T) {
+		t.Parallel()
+
+		elems := []fieldTask{
+			{ID: 1, fieldAudit: fieldAudit{Owner: "carol"}},
+			{ID: 2, fieldAudit: fieldAudit{Owner: "alice"}},
+			{ID: 3, fieldAudit: fieldAudit{Owner: "bob"}},
+		}
+
+		if ids := drain(t, newQueue(t, elems, "Owner", true)); !reflect.DeepEqual([]int{2, 3, 1}, ids) {
+			t.Fatalf("expected ids to be %v, got %v", []int{2, 3, 1}, ids)
+		}
+	})
+
+	t.Run("Float", func(t *testing.T) {
+		t.Parallel()
+
+		elems := []fieldTask{
+			{ID: 1, Score: 2.5},
+			{ID: 2, Score: math.NaN()},
+			{ID: 3, Score: -1},
+		}
+
+		if ids := drain(t, newQueue(t, elems, "Score", true)); !reflect.DeepEqual([]int{2, 3, 1}, ids) {
+			t.Fatalf("expected ids to be %v, got %v", []int{2, 3, 1}, ids)
+		}
+
+		if ids := drain(t, newQueue(t, elems, "Score", false)); !reflect.DeepEqual([]int{1, 3, 2}, ids) {
+			t.Fatalf("expected ids to be %v, got %v", []int{1, 3, 2}, ids)
+		}
+	})
+
+	t.Run("MatchesHandWritten", func(t *testing.T) {
+		t.Parallel()
+
+		rng := rand.New(rand.NewSource(1))
+
+		byField := newQueue(t, nil, "Meta.Deadline", true)
+		handWritten := queue.NewPriority(nil, func(elem, otherElem fieldTask) bool {
+			return elem.Meta.Deadline.Before(otherElem.Meta.Deadline)
+		})
+
+		for i := 0; i < 200; i++ {
+			task := fieldTask{ID: i, Meta: fieldMeta{Deadline: base.Add(time.Duration(rng.Intn(1000)) * time.Second)}}
+
+			_ = byField.Offer(task)
+			_ = handWritten.Offer(task)
+		}
+
+		if expected, elems := handWritten.Clear(), byField.Clear(); !reflect.DeepEqual(expected, elems) {
+			t.Fatalf("expected elements to be %v, got %v", expected, elems)
+		}
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		t.Parallel()
+
+		testCases := map[string]struct {
+			fieldPath string
+			contains  string
+		}{
+			"Missing":        {fieldPath: "Priority", contains: `has no field "Priority"`},
+			"MissingNested":  {fieldPath: "Meta.Priority", contains: `fieldMeta has no field "Priority"`},
+			"Unexported":     {fieldPath: "hidden", contains: `field "hidden" of queue_test.fieldTask is unexported`},
+			"Unsupported":    {fieldPath: "Tags", contains: "[2]string is not an integer, a float, a string or a time.Time"},
+			"Pointer":        {fieldPath: "Ref.Level", contains: "*queue_test.fieldMeta is not a struct"},
+			"NotStructField": {fieldPath: "ID.Value", contains: "int is not a struct"},
+			"Empty":          {fieldPath: "", contains: `has no field ""`},
+		}
+
+		for name, tc := range testCases {
+			tc := tc
+
+			t.Run(name, func(t *testing.T) {
+				t.Parallel()
+
+				priorityQueue, err := queue.NewPriorityByField([]fieldTask{}, tc.fieldPath, true)
+				if !errors.Is(err, queue.ErrInvalidField) {
+					t.Fatalf("expected error to be %v, got %v", queue.ErrInvalidField, err)
+				}
+
+				if !strings.Contains(err.Error(), tc.contains) {
+					t.Fatalf("expected error to contain %q, got %q", tc.contains, err)
+				}
+
+				if priorityQueue != nil {
+					t.Fatal("expected no queue")
+				}
+			})
+		}
+
+		t.Run("NotStruct", func(t *testing.T) {
+			t.Parallel()
+
+			_, err := queue.NewPriorityByField([]int{}, "Value", true)
+			if !errors.Is(err, queue.ErrInvalidField) || !strings.Contains(err.Error(), "int is not a struct") {
+				t.Fatalf("expected a not a struct error, got %v", err)
+			}
+		})
+
+		t.Run("PromotedThroughPointer", func(t *testing.T) {
+			t.Parallel()
+
+			_, err := queue.NewPriorityByField([]fieldTaskRef{}, "Owner", true)
+			if !errors.Is(err, queue.ErrInvalidField) || !strings.Contains(err.Error(), "promoted through the pointer") {
+				t.Fatalf("expected a promoted through pointer error, got %v", err)
+			}
+		})
+	})
+
+	t.Run("WithinTwiceHandWritten", func(t *testing.T) {
+		if testing.Short() {
+			t.Skip("skipping the comparator benchmark in short mode")
+		}
+
+		byField := benchmarkFieldLess(t, true)
+		handWritten := benchmarkFieldLess(t, false)
+
+		if byField > 2*handWritten {
+			t.Fatalf("expected the field comparator to take at most twice %v per drain, got %v", handWritten, byField)
+		}
+	})
+}
+
+// fieldTasks returns tasks with random deadlines, the same ones on every
+// call.
+func fieldTasks() []fieldTask {
+	rng := rand.New(rand.NewSource(1))
+
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tasks := make([]fieldTask, 1024)
+
+	for i := range tasks {
+		tasks[i] = fieldTask{ID: rng.Int(), Meta: fieldMeta{Deadline: base.Add(time.Duration(rng.Intn(1e6)) * time.Second)}}
+	}
+
+	return tasks
+}
+
+// benchmarkFieldLess returns the best time taken to drain a queue ordered by
+// deadline, using NewPriorityByField or a hand-written less function.
+func benchmarkFieldLess(t *testing.T, byField bool) time.Duration {
+	t.Helper()
+
+	tasks := fieldTasks()
+
+	best := time.Duration(math.MaxInt64)
+
+	for run := 0; run < 20; run++ {
+		var priorityQueue *queue.Priority[fieldTask]
+
+		if byField {
+			priorityQueue, _ = queue.NewPriorityByField(tasks, "Meta.Deadline", true)
+		} else {
+			priorityQueue = queue.NewPriority(tasks, func(elem, otherElem fieldTask) bool {
+				return elem.Meta.Deadline.Before(otherElem.Meta.Deadline)
+			})
+		}
+
+		start := time.Now()
+
+		_ = priorityQueue.Clear()
+
+		best = min(best, time.Since(start))
+	}
+
+	return best
+}
+
+func BenchmarkPriorityByField(b *testing.B) {
+	tasks := fieldTasks()
+
+	handWritten := func(elem, otherElem fieldTask) bool {
+		return elem.Meta.Deadline.Before(otherElem.Meta.Deadline)
+	}
+
+	b.Run("HandWritten", func(b *testing.B) {
+		b.ReportAllocs()
+
+		for i := 0; i < b.N; i++ {
+			_ = queue.NewPriority(tasks, handWritten).Clear()
+		}
+	})
+
+	b.Run("ByField", func(b *testing.B) {
+		b.ReportAllocs()
+
+		for i := 0; i < b.N; i++ {
+			priorityQueue, _ := queue.NewPriorityByField(tasks, "Meta.Deadline", true)
+
+			_ = priorityQueue.Clear()
+		}
+	})
+}
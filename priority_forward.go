@@ -0,0 +1,359 @@
+package queue
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// The methods of Priority forward to its PriorityAny core, which holds the
+// implementation shared by both queues. They are declared explicitly, rather
+// than promoted from an embedded PriorityAny, so that the core is not part of
+// the Priority API.
+
+// Offer inserts the element into the queue.
+func (pq *Priority[T]) Offer(elem T) error {
+	return pq.core.Offer(elem)
+}
+
+// TryOffer attempts to insert the element to the tail of the queue without
+// waiting for the queue lock.
+func (pq *Priority[T]) TryOffer(elem T) (acquired bool, _ error) {
+	return pq.core.TryOffer(elem)
+}
+
+// OfferWait inserts the element into the queue, waiting for the necessary
+// space to become available.
+func (pq *Priority[T]) OfferWait(elem T) {
+	pq.core.OfferWait(elem)
+}
+
+// OfferWaitErr inserts the element into the queue, as OfferWait does, and
+// returns the errors other than ErrQueueIsFull which Offer would have
+// returned, such as the InvalidElementError, without waiting.
+func (pq *Priority[T]) OfferWaitErr(elem T) error {
+	return pq.core.OfferWaitErr(elem)
+}
+
+// OfferAll inserts all the elements into the queue, or none of them.
+func (pq *Priority[T]) OfferAll(elems ...T) error {
+	return pq.core.OfferAll(elems...)
+}
+
+// OfferSome inserts as many of the elements as possible into the queue, in
+// order, and returns the number of inserted elements.
+func (pq *Priority[T]) OfferSome(elems ...T) (n int, _ error) {
+	return pq.core.OfferSome(elems...)
+}
+
+// CanOffer returns true if n elements would currently fit into the queue.
+func (pq *Priority[T]) CanOffer(n int) bool {
+	return pq.core.CanOffer(n)
+}
+
+// Reset sets the queue to its initial state, by replacing the current elements
+// with the elements provided at creation, or empties it if the ResetEmpty
+// behavior is provided using WithResetBehavior.
+func (pq *Priority[T]) Reset() {
+	pq.core.Reset()
+}
+
+// Exchange atomically removes the head of the queue and inserts the element,
+// returning the removed head.
+func (pq *Priority[T]) Exchange(elem T) (v T, _ error) {
+	return pq.core.Exchange(elem)
+}
+
+// Update replaces the first element of the queue found equal to oldElem, as
+// reported by the equality function, with newElem, and restores the heap
+// order.
+func (pq *Priority[T]) Update(oldElem, newElem T) bool {
+	return pq.core.Update(oldElem, newElem)
+}
+
+// Remove removes the first element of the queue found equal to elem, as
+// reported by the equality function, and restores the heap order.
+func (pq *Priority[T]) Remove(elem T) bool {
+	return pq.core.Remove(elem)
+}
+
+// Get removes and returns the head of the queue.
+func (pq *Priority[T]) Get() (elem T, _ error) {
+	return pq.core.Get()
+}
+
+// GetWait removes and returns the head of the queue, waiting for an element
+// to become available, such as an element inserted by Offer or restored by
+// Reset.
+func (pq *Priority[T]) GetWait() T {
+	return pq.core.GetWait()
+}
+
+// TryGet attempts to remove and return the head of the queue without waiting
+// for the queue lock.
+func (pq *Priority[T]) TryGet() (elem T, acquired bool, _ error) {
+	return pq.core.TryGet()
+}
+
+// Poll removes and returns the head of the queue.
+func (pq *Priority[T]) Poll(ctx context.Context, interval time.Duration) (T, error) {
+	return pq.core.Poll(ctx, interval)
+}
+
+// GetN removes and returns up to n of the highest priority elements, in
+// priority order, acquiring the queue lock once.
+func (pq *Priority[T]) GetN(n int) []T {
+	return pq.core.GetN(n)
+}
+
+// Clear removes all elements from the queue.
+func (pq *Priority[T]) Clear() []T {
+	return pq.core.Clear()
+}
+
+// ClearUnsafe removes and returns all elements from the queue, in priority
+// order, as Clear does, sorting the backing array of the heap in place and
+// handing it over to the caller instead of copying the elements out of it.
+func (pq *Priority[T]) ClearUnsafe() []T {
+	return pq.core.ClearUnsafe()
+}
+
+// ClearIf evaluates pred against the current state of the queue and, if it
+// returns true, removes and returns all elements from the queue along with
+// true.
+func (pq *Priority[T]) ClearIf(pred func(snapshot ClearSnapshot[T]) bool) ([]T, bool) {
+	return pq.core.ClearIf(pred)
+}
+
+// IteratorsN removes all the elements from the queue at once and distributes
+// them, in priority order, among k closed and buffered channels, as specified
+// by the partition mode, so that k workers can consume disjoint shares of the
+// queue without contending on it.
+func (pq *Priority[T]) IteratorsN(k int, mode Partition) ([]<-chan T, error) {
+	return pq.core.IteratorsN(k, mode)
+}
+
+// All returns a sequence over the queue elements, in priority order, without
+// removing them.
+func (pq *Priority[T]) All() func(yield func(T) bool) {
+	return pq.core.All()
+}
+
+// Drain returns a sequence removing the queue elements, in priority order, as
+// they are iterated, until the queue is empty or the loop stops.
+func (pq *Priority[T]) Drain() func(yield func(T) bool) {
+	return pq.core.Drain()
+}
+
+// Iterator returns an iterator over the elements in the queue.
+func (pq *Priority[T]) Iterator() <-chan T {
+	return pq.core.Iterator()
+}
+
+// IsEmpty returns true if the queue is empty, false otherwise.
+func (pq *Priority[T]) IsEmpty() bool {
+	return pq.core.IsEmpty()
+}
+
+// Contains returns true if the queue contains the element, false otherwise.
+func (pq *Priority[T]) Contains(a T) bool {
+	return pq.core.Contains(a)
+}
+
+// Peek retrieves but does not return the head of the queue.
+func (pq *Priority[T]) Peek() (elem T, _ error) {
+	return pq.core.Peek()
+}
+
+// PeekWait retrieves but does not remove the head of the queue, waiting for
+// an element to become available.
+func (pq *Priority[T]) PeekWait() T {
+	return pq.core.PeekWait()
+}
+
+// TryPeek attempts to retrieve, without removing, the head of the queue
+// without waiting for the queue lock.
+func (pq *Priority[T]) TryPeek() (elem T, acquired bool, _ error) {
+	return pq.core.TryPeek()
+}
+
+// Size returns the number of elements in the queue.
+func (pq *Priority[T]) Size() int {
+	return pq.core.Size()
+}
+
+// Capacity returns the fixed capacity of the queue, or -1 if the queue was
+// created without the WithCapacity option.
+func (pq *Priority[T]) Capacity() int {
+	return pq.core.Capacity()
+}
+
+// Remaining returns the number of elements the queue can hold in addition to
+// its current elements, or -1 if the queue is unbounded.
+func (pq *Priority[T]) Remaining() int {
+	return pq.core.Remaining()
+}
+
+// ToSlice returns a copy of the queue elements in priority order, the order
+// in which Clear would remove them, without removing them.
+func (pq *Priority[T]) ToSlice() []T {
+	return pq.core.ToSlice()
+}
+
+// Each calls fn for the queue elements in priority order, until fn returns
+// false, without removing them.
+func (pq *Priority[T]) Each(fn func(elem T) bool) {
+	pq.core.Each(fn)
+}
+
+// PeekN returns a copy of the at most n highest priority elements, in
+// priority order, without removing them.
+func (pq *Priority[T]) PeekN(n int) []T {
+	return pq.core.PeekN(n)
+}
+
+// InspectPage returns a copy of at most limit elements, starting at the
+// cursor, along with the cursor of the next page, in priority order.
+func (pq *Priority[T]) InspectPage(cursor Cursor, limit int) ([]T, Cursor, error) {
+	return pq.core.InspectPage(cursor, limit)
+}
+
+// ContentionProfile returns, for every queue method sampled by the
+// WithContentionProfiling option, the time spent waiting to acquire the queue
+// lock.
+func (pq *Priority[T]) ContentionProfile() map[string]ContentionStats {
+	return pq.core.ContentionProfile()
+}
+
+// RecentOperations returns the last mutating operations of the queue, oldest
+// first, as recorded by the WithCallerTracking option.
+func (pq *Priority[T]) RecentOperations() []OpRecord {
+	return pq.core.RecentOperations()
+}
+
+// MemoryFootprint returns an estimate of the memory retained by the queue,
+// measuring every element using sizeOf, or its shallow size if sizeOf is nil.
+func (pq *Priority[T]) MemoryFootprint(sizeOf func(T) uintptr) MemoryReport {
+	return pq.core.MemoryFootprint(sizeOf)
+}
+
+// HeapStats returns the depth of the heap holding the queue elements, the
+// average number of levels an element sifts down while the elements are
+// retrieved one by one, and the number of elements.
+func (pq *Priority[T]) HeapStats() (depth int, avgSiftLen float64, size int) {
+	return pq.core.HeapStats()
+}
+
+// Rebuild lays the heap holding the queue elements out in priority order,
+// which shortens the paths along which the elements sift after a long
+// sequence of insertions in adversarial orders.
+func (pq *Priority[T]) Rebuild() {
+	pq.core.Rebuild()
+}
+
+// LedgerCheck returns an error joining the conservation violations observed,
+// or nil if every element is accounted for exactly once.
+// It panics if the queue was created without the WithConservationChecks option.
+func (pq *Priority[T]) LedgerCheck() error {
+	return pq.core.LedgerCheck()
+}
+
+// SyncJournal waits until the journal records of the operations completed so
+// far are written, if the WithJournal option is provided.
+func (pq *Priority[T]) SyncJournal() {
+	pq.core.SyncJournal()
+}
+
+// Name returns the name provided using WithName, or an empty string if the
+// queue is unnamed.
+func (pq *Priority[T]) Name() string {
+	return pq.core.Name()
+}
+
+// MarshalJSON serializes the queue elements to a JSON array in priority
+// order.
+func (pq *Priority[T]) MarshalJSON() ([]byte, error) {
+	return pq.core.MarshalJSON()
+}
+
+// MarshalJSONTo streams the queue elements to w as a JSON array in priority
+// order.
+func (pq *Priority[T]) MarshalJSONTo(w io.Writer) error {
+	return pq.core.MarshalJSONTo(w)
+}
+
+// UnmarshalJSONFrom reads a JSON array from r and replaces the elements of
+// the queue with its elements, inserted using the same semantics as OfferAll
+// into the emptied queue.
+func (pq *Priority[T]) UnmarshalJSONFrom(r io.Reader) error {
+	return pq.core.UnmarshalJSONFrom(r)
+}
+
+// UnmarshalJSON replaces the elements of the queue with the elements of the
+// JSON array data, as UnmarshalJSONFrom does.
+func (pq *Priority[T]) UnmarshalJSON(data []byte) error {
+	return pq.core.UnmarshalJSON(data)
+}
+
+// MarshalBinary encodes the queue elements using encoding/gob, in their heap
+// layout, so that they can be restored exactly using UnmarshalBinary,
+// including the order of the equal elements if the WithStableOrder option is
+// provided.
+func (pq *Priority[T]) MarshalBinary() ([]byte, error) {
+	return pq.core.MarshalBinary()
+}
+
+// UnmarshalBinary replaces the queue elements with the elements encoded by
+// MarshalBinary, which are restored as is, without being validated.
+func (pq *Priority[T]) UnmarshalBinary(data []byte) error {
+	return pq.core.UnmarshalBinary(data)
+}
+
+// Dump writes a snapshot of the queue elements to w, in the format of
+// MarshalJSONTo, so that it can be restored using UnmarshalJSONFrom.
+func (pq *Priority[T]) Dump(w io.Writer) error {
+	return pq.core.Dump(w)
+}
+
+// The methods below forward the unexported methods the package relies on,
+// such as the ones implementing the mover interface used by Move.
+
+func (pq *Priority[T]) discard(elem T) {
+	pq.core.discard(elem)
+}
+
+func (pq *Priority[T]) moveLock() *profiledRWMutex {
+	return pq.core.moveLock()
+}
+
+func (pq *Priority[T]) moveCandidate(pred func(T) bool) (elem T, pos int, _ error) {
+	return pq.core.moveCandidate(pred)
+}
+
+func (pq *Priority[T]) moveOut(elem T, pos int) {
+	pq.core.moveOut(elem, pos)
+}
+
+func (pq *Priority[T]) moveAdmit(elem T) error {
+	return pq.core.moveAdmit(elem)
+}
+
+func (pq *Priority[T]) moveIn(elem T) {
+	pq.core.moveIn(elem)
+}
+
+func (pq *Priority[T]) moveElems() []T {
+	return pq.core.moveElems()
+}
+
+func (pq *Priority[T]) moveTail(elem T) (pos int, ok bool) {
+	return pq.core.moveTail(elem)
+}
+
+func (pq *Priority[T]) replayUpdate(pos int, elem T) bool {
+	return pq.core.replayUpdate(pos, elem)
+}
+
+func (pq *Priority[T]) exactSize() int {
+	return pq.core.exactSize()
+}
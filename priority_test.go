@@ -1,9 +1,17 @@
 package queue_test
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"math/rand"
 	"reflect"
+	"runtime"
+	"slices"
 	"sort"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/adrianbrad/queue"
@@ -230,6 +238,12 @@ func TestPriority(t *testing.T) {
 		}
 	})
 
+	t.Run("IteratorConcurrentWithOfferAndGet", func(t *testing.T) {
+		t.Parallel()
+
+		assertIteratorConcurrentConservation(t, queue.NewPriority([]int{}, lessAscending))
+	})
+
 	t.Run("IsEmpty", func(t *testing.T) {
 		t.Parallel()
 
@@ -361,94 +375,2053 @@ func TestPriority(t *testing.T) {
 				t.Fatalf("expected size to be 2, got %d", priorityQueue.Size())
 			}
 		})
+
+		t.Run("Repro", func(t *testing.T) {
+			t.Parallel()
+
+			priorityQueue := queue.NewPriority([]int{5, 1, 3}, lessAscending)
+
+			_, _ = priorityQueue.Get()
+			_, _ = priorityQueue.Get()
+
+			for _, elem := range []int{0, 2, 7} {
+				_ = priorityQueue.Offer(elem)
+			}
+
+			priorityQueue.Reset()
+
+			if elems := priorityQueue.Clear(); !reflect.DeepEqual([]int{1, 3, 5}, elems) {
+				t.Fatalf("expected elements to be %v, got %v", []int{1, 3, 5}, elems)
+			}
+		})
+
+		t.Run("InterleavedWithOfferAndGet", func(t *testing.T) {
+			t.Parallel()
+
+			rng := rand.New(rand.NewSource(1))
+
+			initial := rng.Perm(20)
+
+			priorityQueue := queue.NewPriority(initial, lessAscending)
+
+			// drain retrieves all the elements, which must come out sorted,
+			// and offers them back.
+			drain := func(expectedSize int) {
+				t.Helper()
+
+				elems := make([]int, 0, expectedSize)
+
+				for !priorityQueue.IsEmpty() {
+					elem, err := priorityQueue.Get()
+					if err != nil {
+						t.Fatalf("expected no error, got %v", err)
+					}
+
+					elems = append(elems, elem)
+				}
+
+				if len(elems) != expectedSize || !sort.IntsAreSorted(elems) {
+					t.Fatalf("expected %d sorted elements, got %v", expectedSize, elems)
+				}
+
+				for _, elem := range elems {
+					_ = priorityQueue.Offer(elem)
+				}
+			}
+
+			size := len(initial)
+
+			for round := 0; round < 500; round++ {
+				switch op := rng.Intn(10); {
+				case op < 4:
+					_ = priorityQueue.Offer(rng.Intn(100) - 50)
+
+					size++
+				case op < 8:
+					if _, err := priorityQueue.Get(); err == nil {
+						size--
+					}
+				default:
+					priorityQueue.Reset()
+
+					size = len(initial)
+
+					if head, err := priorityQueue.Peek(); err != nil || head != 0 {
+						t.Fatalf("expected head to be 0 after reset, got %d, %v", head, err)
+					}
+				}
+
+				drain(size)
+			}
+		})
 	})
-}
 
-func FuzzPriority(f *testing.F) {
-	testcases := [][]byte{{2, 10, 8, 4}, {11, 9, 7}, {8, 24, 255}}
-	for _, tc := range testcases {
-		f.Add(tc)
-	}
+	t.Run("WithResetCloner", func(t *testing.T) {
+		t.Parallel()
 
-	lessFunc := func(elem, elemAfter byte) bool {
-		return elem < elemAfter
-	}
+		type elem struct {
+			value int
+		}
 
-	f.Fuzz(func(t *testing.T, orig []byte) {
-		sort.Slice(orig, func(i, j int) bool {
-			return orig[i] < orig[j]
+		less := func(e, other *elem) bool {
+			return e.value < other.value
+		}
+
+		clone := func(e *elem) *elem {
+			c := *e
+
+			return &c
+		}
+
+		t.Run("Cloned", func(t *testing.T) {
+			t.Parallel()
+
+			elems := []*elem{{value: 1}, {value: 2}}
+
+			priorityQueue := queue.NewPriority(elems, less, queue.WithResetCloner(clone))
+
+			elems[0].value = 10
+
+			for i := 0; i < 2; i++ {
+				priorityQueue.Reset()
+
+				head, err := priorityQueue.Get()
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+
+				if head.value != 1 {
+					t.Fatalf("expected head value to be %d, got %d", 1, head.value)
+				}
+
+				head.value = 100
+			}
 		})
 
-		priorityQueue := queue.NewPriority(nil, lessFunc)
+		t.Run("Shallow", func(t *testing.T) {
+			t.Parallel()
 
-		for _, v := range orig {
-			err := priorityQueue.Offer(v)
+			elems := []*elem{{value: 1}, {value: 2}}
+
+			priorityQueue := queue.NewPriority(elems, less)
+
+			elems[0].value = 0
+
+			priorityQueue.Reset()
+
+			head, err := priorityQueue.Get()
 			if err != nil {
 				t.Fatalf("expected no error, got %v", err)
 			}
-		}
 
-		for _, v := range orig {
-			peekedVal, err := priorityQueue.Peek()
-			if err != nil {
+			if head.value != 0 {
+				t.Fatalf("expected head value to be %d, got %d", 0, head.value)
+			}
+		})
+
+		t.Run("MismatchedType", func(t *testing.T) {
+			t.Parallel()
+
+			defer func() {
+				if p := recover(); p == nil {
+					t.Fatalf("expected panic")
+				}
+			}()
+
+			_ = queue.NewPriority([]int{1}, lessInt, queue.WithResetCloner(clone))
+		})
+	})
+
+	t.Run("Wait", func(t *testing.T) {
+		t.Parallel()
+
+		t.Run("SequentialIteration", func(t *testing.T) {
+			t.Parallel()
+
+			priorityQueue := queue.NewPriority([]int{3, 1, 2}, lessAscending)
+
+			for _, want := range []int{1, 2, 3} {
+				if elem := priorityQueue.PeekWait(); elem != want {
+					t.Fatalf("expected elem to be %d, got %d", want, elem)
+				}
+
+				if elem := priorityQueue.GetWait(); elem != want {
+					t.Fatalf("expected elem to be %d, got %d", want, elem)
+				}
+			}
+		})
+
+		t.Run("100ConcurrentGoroutinesReading", func(t *testing.T) {
+			t.Parallel()
+
+			const lenElements = 100
+
+			priorityQueue := queue.NewPriority([]int{}, lessAscending)
+
+			var (
+				wg          sync.WaitGroup
+				resultMutex sync.Mutex
+			)
+
+			wg.Add(lenElements)
+
+			result := make([]int, 0, lenElements)
+
+			for i := 0; i < lenElements; i++ {
+				go func() {
+					defer wg.Done()
+
+					elem := priorityQueue.GetWait()
+
+					resultMutex.Lock()
+					result = append(result, elem)
+					resultMutex.Unlock()
+				}()
+			}
+
+			ids := make([]int, lenElements)
+
+			for i := range ids {
+				ids[i] = i + 1
+
+				if err := priorityQueue.Offer(i + 1); err != nil {
+					t.Errorf("expected no error, got %v", err)
+				}
+			}
+
+			wg.Wait()
+
+			sort.Ints(result)
+
+			if !reflect.DeepEqual(ids, result) {
+				t.Fatalf("expected result to be %v, got %v", ids, result)
+			}
+		})
+
+		t.Run("ResetWhileMoreRoutinesThanElementsAreWaiting", func(t *testing.T) {
+			t.Parallel()
+
+			elems := []int{3, 1, 2}
+
+			const noRoutines = 30
+
+			priorityQueue := queue.NewPriority(elems, lessAscending)
+
+			retrieved := make(chan int, noRoutines)
+
+			for i := 0; i < noRoutines; i++ {
+				go func() {
+					retrieved <- priorityQueue.GetWait()
+				}()
+			}
+
+			counts := make(map[int]int)
+
+			for i := 1; i <= noRoutines; i++ {
+				counts[<-retrieved]++
+
+				// the queue is refilled once emptied, waking the waiters.
+				if i%len(elems) == 0 {
+					priorityQueue.Reset()
+				}
+			}
+
+			for _, elem := range elems {
+				if counts[elem] != noRoutines/len(elems) {
+					t.Fatalf("expected %d to be retrieved %d times, got %d", elem, noRoutines/len(elems), counts[elem])
+				}
+			}
+		})
+
+		t.Run("OfferWaitFull", func(t *testing.T) {
+			t.Parallel()
+
+			priorityQueue := queue.NewPriority([]int{1, 2}, lessAscending, queue.WithCapacity(2))
+
+			offered := make(chan error, 2)
+
+			for _, elem := range []int{3, 4} {
+				go func(elem int) {
+					offered <- priorityQueue.OfferWaitErr(elem)
+				}(elem)
+			}
+
+			if elem := priorityQueue.GetWait(); elem != 1 {
+				t.Fatalf("expected elem to be 1, got %d", elem)
+			}
+
+			if err := <-offered; err != nil {
 				t.Fatalf("expected no error, got %v", err)
 			}
 
-			if v != peekedVal {
-				t.Fatalf("expected peeked value to be %d, got %d", v, peekedVal)
+			// the waiting producer is woken by the space freed by Clear.
+			if elems := priorityQueue.Clear(); len(elems) != 2 {
+				t.Fatalf("expected 2 elements to be cleared, got %v", elems)
 			}
 
-			getVal, err := priorityQueue.Get()
-			if err != nil {
+			if err := <-offered; err != nil {
 				t.Fatalf("expected no error, got %v", err)
 			}
 
-			if peekedVal != getVal {
-				t.Fatalf("expected peeked value to be %d, got %d", peekedVal, getVal)
+			if priorityQueue.Size() != 1 {
+				t.Fatalf("expected size to be 1, got %d", priorityQueue.Size())
 			}
-		}
-	})
-}
+		})
 
-func BenchmarkPriorityQueue(b *testing.B) {
-	b.Run("Peek", func(b *testing.B) {
-		priorityQueue := queue.NewPriority([]int{1}, func(elem, otherElem int) bool {
-			return elem < otherElem
+		t.Run("OfferWaitInvalid", func(t *testing.T) {
+			t.Parallel()
+
+			priorityQueue := queue.NewPriority(
+				[]int{1},
+				lessAscending,
+				queue.WithCapacity(1),
+				queue.WithValidator(validateNonNegative),
+			)
+
+			// the invalid element is rejected without waiting for space.
+			if err := priorityQueue.OfferWaitErr(-1); !errors.Is(err, queue.ErrInvalidElement) {
+				t.Fatalf("expected error to be %v, got %v", queue.ErrInvalidElement, err)
+			}
 		})
 
-		b.ReportAllocs()
-		b.ResetTimer()
+		t.Run("PeekWaitThenGetWait", func(t *testing.T) {
+			t.Parallel()
 
-		for i := 0; i <= b.N; i++ {
-			_, _ = priorityQueue.Peek()
-		}
+			priorityQueue := queue.NewPriority([]int{}, lessAscending)
+
+			peeked := make(chan int)
+
+			go func() {
+				peeked <- priorityQueue.PeekWait()
+			}()
+
+			go func() {
+				_ = priorityQueue.OfferAll(2, 1)
+			}()
+
+			// the elements are inserted at once.
+			if elem := <-peeked; elem != 1 {
+				t.Fatalf("expected elem to be 1, got %d", elem)
+			}
+
+			if elem := priorityQueue.GetWait(); elem != 1 {
+				t.Fatalf("expected elem to be 1, got %d", elem)
+			}
+		})
 	})
 
-	b.Run("Get_Offer", func(b *testing.B) {
-		priorityQueue := queue.NewPriority([]int{1}, func(elem, otherElem int) bool {
-			return elem < otherElem
+	t.Run("Try", func(t *testing.T) {
+		t.Parallel()
+
+		t.Run("Acquired", func(t *testing.T) {
+			t.Parallel()
+
+			priorityQueue := queue.NewPriority([]int{1}, lessInt)
+
+			if acquired, err := priorityQueue.TryOffer(2); !acquired || err != nil {
+				t.Fatalf("expected lock to be acquired and no error, got %t and %v", acquired, err)
+			}
+
+			elem, acquired, err := priorityQueue.TryPeek()
+			if !acquired || err != nil || elem != 1 {
+				t.Fatalf("expected elem %d, lock acquired and no error, got %d, %t and %v", 1, elem, acquired, err)
+			}
+
+			elem, acquired, err = priorityQueue.TryGet()
+			if !acquired || err != nil || elem != 1 {
+				t.Fatalf("expected elem %d, lock acquired and no error, got %d, %t and %v", 1, elem, acquired, err)
+			}
 		})
 
-		b.ReportAllocs()
-		b.ResetTimer()
+		t.Run("Contended", func(t *testing.T) {
+			t.Parallel()
 
-		for i := 0; i <= b.N; i++ {
-			_, _ = priorityQueue.Get()
+			holder := newLockHolder()
 
-			_ = priorityQueue.Offer(1)
-		}
+			priorityQueue := queue.NewPriority([]int{1}, lessInt, queue.WithResetCloner(holder.Clone))
+
+			holder.Lock(priorityQueue.Reset)
+			defer holder.Unlock()
+
+			if acquired, err := priorityQueue.TryOffer(2); acquired || err != nil {
+				t.Fatalf("expected lock to not be acquired and no error, got %t and %v", acquired, err)
+			}
+
+			if _, acquired, err := priorityQueue.TryPeek(); acquired || err != nil {
+				t.Fatalf("expected lock to not be acquired and no error, got %t and %v", acquired, err)
+			}
+
+			if _, acquired, err := priorityQueue.TryGet(); acquired || err != nil {
+				t.Fatalf("expected lock to not be acquired and no error, got %t and %v", acquired, err)
+			}
+		})
 	})
 
-	b.Run("Offer", func(b *testing.B) {
-		priorityQueue := queue.NewPriority[int](nil, func(elem, otherElem int) bool {
-			return elem < otherElem
+	t.Run("MarshalJSON", func(t *testing.T) {
+		t.Parallel()
+
+		t.Run("Streamed", func(t *testing.T) {
+			t.Parallel()
+
+			priorityQueue := queue.NewPriority([]int{3, 1, 4, 2}, lessInt)
+
+			marshaled, err := priorityQueue.MarshalJSON()
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			expected, err := json.Marshal([]int{1, 2, 3, 4})
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if !bytes.Equal(expected, marshaled) {
+				t.Fatalf("expected json to be %s, got %s", expected, marshaled)
+			}
+
+			var buf bytes.Buffer
+
+			if err := priorityQueue.MarshalJSONTo(&buf); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if !bytes.Equal(marshaled, buf.Bytes()) {
+				t.Fatalf("expected streamed json to be %s, got %s", marshaled, buf.Bytes())
+			}
 		})
 
-		b.ReportAllocs()
-		b.ResetTimer()
+		t.Run("Empty", func(t *testing.T) {
+			t.Parallel()
 
-		for i := 0; i <= b.N; i++ {
-			_ = priorityQueue.Offer(i)
-		}
-	})
+			priorityQueue := queue.NewPriority([]int{}, lessInt)
+
+			marshaled, err := json.Marshal(priorityQueue)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if string(marshaled) != "[]" {
+				t.Fatalf("expected json to be [], got %s", marshaled)
+			}
+		})
+	})
+
+	t.Run("UnmarshalJSONFrom", func(t *testing.T) {
+		t.Parallel()
+
+		t.Run("Success", func(t *testing.T) {
+			t.Parallel()
+
+			priorityQueue := queue.NewPriority([]int{1}, lessInt)
+
+			if err := priorityQueue.UnmarshalJSONFrom(strings.NewReader("[2, 3]")); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			// the elements of the queue are replaced.
+			if elems := priorityQueue.Clear(); !reflect.DeepEqual([]int{2, 3}, elems) {
+				t.Fatalf("expected elements to be %v, got %v", []int{2, 3}, elems)
+			}
+		})
+
+		t.Run("InvalidJSON", func(t *testing.T) {
+			t.Parallel()
+
+			priorityQueue := queue.NewPriority([]int{}, lessInt)
+
+			if err := priorityQueue.UnmarshalJSONFrom(strings.NewReader(`{"a": 1}`)); err == nil {
+				t.Fatalf("expected error")
+			}
+
+			if err := priorityQueue.UnmarshalJSONFrom(strings.NewReader(`[1, "a"]`)); err == nil {
+				t.Fatalf("expected error")
+			}
+		})
+
+		t.Run("ErrQueueIsFull", func(t *testing.T) {
+			t.Parallel()
+
+			priorityQueue := queue.NewPriority([]int{1}, lessInt, queue.WithCapacity(2))
+
+			err := priorityQueue.UnmarshalJSONFrom(strings.NewReader("[2, 3, 4]"))
+			if !errors.Is(err, queue.ErrQueueIsFull) {
+				t.Fatalf("expected error to be %v, got %v", queue.ErrQueueIsFull, err)
+			}
+
+			// none of the elements is inserted.
+			if elems := priorityQueue.Clear(); !reflect.DeepEqual([]int{1}, elems) {
+				t.Fatalf("expected elements to be %v, got %v", []int{1}, elems)
+			}
+		})
+
+		t.Run("ErrQueueIsFullStreaming", func(t *testing.T) {
+			t.Parallel()
+
+			priorityQueue := queue.NewPriority([]int{1}, lessInt, queue.WithCapacity(2), queue.WithStreamingJSON())
+
+			err := priorityQueue.UnmarshalJSONFrom(strings.NewReader("[2, 3, 4]"))
+			if !errors.Is(err, queue.ErrQueueIsFull) {
+				t.Fatalf("expected error to be %v, got %v", queue.ErrQueueIsFull, err)
+			}
+
+			// the elements of the queue are removed once the array is opened.
+			if elems := priorityQueue.Clear(); !reflect.DeepEqual([]int{2, 3}, elems) {
+				t.Fatalf("expected elements to be %v, got %v", []int{2, 3}, elems)
+			}
+		})
+	})
+
+	t.Run("ClearIf", func(t *testing.T) {
+		t.Parallel()
+
+		t.Run("True", func(t *testing.T) {
+			t.Parallel()
+
+			priorityQueue := queue.NewPriority([]int{3, 1, 2}, lessInt, queue.WithCapacity(5))
+
+			var snapshot queue.ClearSnapshot[int]
+
+			elems, cleared := priorityQueue.ClearIf(func(s queue.ClearSnapshot[int]) bool {
+				snapshot = s
+
+				return s.Size >= 3
+			})
+			if !cleared {
+				t.Fatalf("expected queue to be cleared")
+			}
+
+			expectedSnapshot := queue.ClearSnapshot[int]{Size: 3, Capacity: 5, Head: 1}
+
+			if snapshot != expectedSnapshot {
+				t.Fatalf("expected snapshot to be %+v, got %+v", expectedSnapshot, snapshot)
+			}
+
+			if !reflect.DeepEqual([]int{1, 2, 3}, elems) {
+				t.Fatalf("expected elements to be %v, got %v", []int{1, 2, 3}, elems)
+			}
+
+			if !priorityQueue.IsEmpty() {
+				t.Fatalf("expected queue to be empty")
+			}
+		})
+
+		t.Run("False", func(t *testing.T) {
+			t.Parallel()
+
+			priorityQueue := queue.NewPriority([]int{3, 1, 2}, lessInt, queue.WithCapacity(5))
+
+			elems, cleared := priorityQueue.ClearIf(func(s queue.ClearSnapshot[int]) bool {
+				return s.Size > 3
+			})
+			if cleared || elems != nil {
+				t.Fatalf("expected queue not to be cleared, got %v and %t", elems, cleared)
+			}
+
+			if size := priorityQueue.Size(); size != 3 {
+				t.Fatalf("expected size to be %d, got %d", 3, size)
+			}
+		})
+	})
+
+	t.Run("DrainOrder", func(t *testing.T) {
+		t.Parallel()
+
+		type item struct {
+			Key int `json:"key"`
+			ID  int `json:"id"`
+		}
+
+		lessItem := func(elem, otherElem item) bool {
+			return elem.Key < otherElem.Key
+		}
+
+		newQueue := func() *queue.Priority[item] {
+			elems := make([]item, 0, 60)
+
+			for i := 0; i < 50; i++ {
+				elems = append(elems, item{Key: i % 3, ID: i})
+			}
+
+			priorityQueue := queue.NewPriority(elems, lessItem)
+
+			for i := 50; i < 60; i++ {
+				_ = priorityQueue.Offer(item{Key: i % 2, ID: i})
+			}
+
+			return priorityQueue
+		}
+
+		t.Run("EqualElements", func(t *testing.T) {
+			t.Parallel()
+
+			marshaled := newQueue()
+
+			first, err := json.Marshal(marshaled)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			second, err := json.Marshal(marshaled)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if !bytes.Equal(first, second) {
+				t.Fatalf("expected identical json, got %s and %s", first, second)
+			}
+
+			var snapshot []item
+
+			if err := json.Unmarshal(first, &snapshot); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			cleared := newQueue().Clear()
+
+			if !reflect.DeepEqual(snapshot, cleared) {
+				t.Fatalf("expected json order %v to match clear order %v", snapshot, cleared)
+			}
+
+			iterated := make([]item, 0, len(cleared))
+
+			for elem := range newQueue().Iterator() {
+				iterated = append(iterated, elem)
+			}
+
+			if !reflect.DeepEqual(cleared, iterated) {
+				t.Fatalf("expected iterator order %v to match clear order %v", iterated, cleared)
+			}
+		})
+
+		t.Run("ConcurrentIterator", func(t *testing.T) {
+			t.Parallel()
+
+			priorityQueue := newQueue()
+
+			var wg sync.WaitGroup
+
+			wg.Add(2)
+
+			go func() {
+				defer wg.Done()
+
+				for range priorityQueue.Iterator() {
+				}
+			}()
+
+			go func() {
+				defer wg.Done()
+
+				for i := 0; i < 100; i++ {
+					_, _ = priorityQueue.Peek()
+					_ = priorityQueue.Size()
+				}
+			}()
+
+			wg.Wait()
+
+			if !priorityQueue.IsEmpty() {
+				t.Fatalf("expected queue to be empty")
+			}
+		})
+	})
+
+	t.Run("WithStableOrder", func(t *testing.T) {
+		t.Parallel()
+
+		type item struct {
+			Key int `json:"key"`
+			ID  int `json:"id"`
+		}
+
+		lessItem := func(elem, otherElem item) bool {
+			return elem.Key < otherElem.Key
+		}
+
+		// offer inserts the elements 0..n with keys 0, 1 and 2 after the
+		// initial elements, which have key 1.
+		offer := func(priorityQueue *queue.Priority[item], from, to int) {
+			for i := from; i < to; i++ {
+				_ = priorityQueue.Offer(item{Key: i % 3, ID: i})
+			}
+		}
+
+		t.Run("InsertionOrder", func(t *testing.T) {
+			t.Parallel()
+
+			initial := []item{{Key: 1, ID: -1}, {Key: 1, ID: -2}}
+
+			priorityQueue := queue.NewPriority(initial, lessItem, queue.WithStableOrder())
+
+			offer(priorityQueue, 0, 6)
+
+			// the exchanged element is ordered after the equal elements.
+			if head, _ := priorityQueue.Exchange(item{Key: 1, ID: 6}); head != (item{Key: 0, ID: 0}) {
+				t.Fatalf("expected exchanged element to be %v, got %v", item{Key: 0, ID: 0}, head)
+			}
+
+			expected := []item{
+				{Key: 0, ID: 3},
+				{Key: 1, ID: -1}, {Key: 1, ID: -2}, {Key: 1, ID: 1}, {Key: 1, ID: 4}, {Key: 1, ID: 6},
+				{Key: 2, ID: 2}, {Key: 2, ID: 5},
+			}
+
+			if elems := priorityQueue.Clear(); !reflect.DeepEqual(expected, elems) {
+				t.Fatalf("expected elements to be %v, got %v", expected, elems)
+			}
+
+			offer(priorityQueue, 7, 9)
+
+			priorityQueue.Reset()
+
+			if elems := priorityQueue.Clear(); !reflect.DeepEqual(initial, elems) {
+				t.Fatalf("expected elements to be %v, got %v", initial, elems)
+			}
+		})
+
+		t.Run("EqualElements", func(t *testing.T) {
+			t.Parallel()
+
+			const elems = 1000
+
+			priorityQueue := queue.NewPriority([]item{}, lessItem, queue.WithStableOrder())
+
+			for i := 0; i < elems; i++ {
+				_ = priorityQueue.Offer(item{Key: 1, ID: i})
+			}
+
+			for i := 0; i < elems; i++ {
+				if elem, err := priorityQueue.Get(); err != nil || elem.ID != i {
+					t.Fatalf("expected element %d, got %v, %v", i, elem, err)
+				}
+			}
+		})
+
+		t.Run("LayoutIndependent", func(t *testing.T) {
+			t.Parallel()
+
+			priorityQueue := queue.NewPriority([]item{}, lessItem, queue.WithStableOrder())
+
+			offer(priorityQueue, 0, 30)
+
+			// the same elements, offered along with elements retrieved
+			// right away, which reshuffle the heap.
+			reshuffled := queue.NewPriority([]item{}, lessItem, queue.WithStableOrder())
+
+			for i := 0; i < 30; i++ {
+				offer(reshuffled, i, i+1)
+
+				_ = reshuffled.Offer(item{Key: -1, ID: i})
+				_, _ = reshuffled.Get()
+			}
+
+			expected, err := priorityQueue.MarshalJSON()
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			marshaled, err := reshuffled.MarshalJSON()
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if !bytes.Equal(expected, marshaled) {
+				t.Fatalf("expected json to be %s, got %s", expected, marshaled)
+			}
+		})
+
+		t.Run("Determinism", func(t *testing.T) {
+			t.Parallel()
+
+			const (
+				runs  = 100
+				elems = 60
+			)
+
+			scenario := func(run int) []byte {
+				priorityQueue := queue.NewPriority(
+					[]item{{Key: 1, ID: -1}, {Key: 0, ID: -2}},
+					lessItem,
+					queue.WithStableOrder(),
+				)
+
+				var (
+					wg   sync.WaitGroup
+					done = make(chan struct{})
+				)
+
+				wg.Add(3)
+
+				// unrelated operations, including mutations which leave the
+				// logical contents unchanged.
+				go func() {
+					defer wg.Done()
+
+					for {
+						select {
+						case <-done:
+							return
+						default:
+						}
+
+						_, _ = priorityQueue.Peek()
+						_ = priorityQueue.Contains(item{Key: 1, ID: 1})
+						_, _ = priorityQueue.MarshalJSON()
+
+						runtime.Gosched()
+					}
+				}()
+
+				go func() {
+					defer wg.Done()
+
+					for i := 0; ; i++ {
+						select {
+						case <-done:
+							return
+						default:
+						}
+
+						_, _, _ = priorityQueue.InspectPage(queue.Cursor{}, 5)
+
+						if i%(run%5+1) == 0 {
+							runtime.Gosched()
+						}
+					}
+				}()
+
+				go func() {
+					defer wg.Done()
+
+					for i := 0; i < elems; i++ {
+						if i%(run%7+1) == 0 {
+							runtime.Gosched()
+						}
+
+						offer(priorityQueue, i, i+1)
+					}
+
+					close(done)
+				}()
+
+				wg.Wait()
+
+				marshaled, err := priorityQueue.MarshalJSON()
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+
+				iterated, err := json.Marshal(priorityQueue.Clear())
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+
+				return append(marshaled, iterated...)
+			}
+
+			// GOMAXPROCS is restored once all the runs are done.
+			defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(0))
+
+			expected := scenario(0)
+
+			for run := 1; run < runs; run++ {
+				runtime.GOMAXPROCS(run%4 + 1)
+
+				if serialized := scenario(run); !bytes.Equal(expected, serialized) {
+					t.Fatalf("run %d: expected %s, got %s", run, expected, serialized)
+				}
+			}
+		})
+	})
+
+	t.Run("ClearUnsafe", func(t *testing.T) {
+		t.Parallel()
+
+		lessInt := func(elem, otherElem int) bool { return elem < otherElem }
+
+		t.Run("PriorityOrder", func(t *testing.T) {
+			t.Parallel()
+
+			type item struct {
+				Key int
+				ID  int
+			}
+
+			lessItem := func(elem, otherElem item) bool { return elem.Key < otherElem.Key }
+
+			rng := rand.New(rand.NewSource(1))
+
+			cleared := queue.NewPriority([]item{}, lessItem)
+			detached := queue.NewPriority([]item{}, lessItem)
+
+			// the same operations, so that both heaps share their layout and
+			// the equal elements are retrieved in the same order.
+			for i := 0; i < 500; i++ {
+				elem := item{Key: rng.Intn(10), ID: i}
+
+				_ = cleared.Offer(elem)
+				_ = detached.Offer(elem)
+
+				if i%5 == 0 {
+					_, _ = cleared.Get()
+					_, _ = detached.Get()
+				}
+			}
+
+			if expected, elems := cleared.Clear(), detached.ClearUnsafe(); !reflect.DeepEqual(expected, elems) {
+				t.Fatalf("expected elements to be %v, got %v", expected, elems)
+			}
+		})
+
+		t.Run("NoAliasing", func(t *testing.T) {
+			t.Parallel()
+
+			priorityQueue := queue.NewPriority([]int{3, 1, 2}, lessInt, queue.WithCapacity(3))
+
+			elems := priorityQueue.ClearUnsafe()
+
+			if !reflect.DeepEqual([]int{1, 2, 3}, elems) {
+				t.Fatalf("expected elements to be %v, got %v", []int{1, 2, 3}, elems)
+			}
+
+			for _, elem := range []int{6, 4, 5} {
+				if err := priorityQueue.Offer(elem); err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+			}
+
+			if !reflect.DeepEqual([]int{1, 2, 3}, elems) {
+				t.Fatalf("expected elements to be unchanged, got %v", elems)
+			}
+
+			for i := range elems {
+				elems[i] = 0
+			}
+
+			if queued := priorityQueue.ToSlice(); !reflect.DeepEqual([]int{4, 5, 6}, queued) {
+				t.Fatalf("expected elements to be %v, got %v", []int{4, 5, 6}, queued)
+			}
+
+			// the initial elements are not shared either.
+			priorityQueue.Reset()
+
+			if queued := priorityQueue.ToSlice(); !reflect.DeepEqual([]int{1, 2, 3}, queued) {
+				t.Fatalf("expected elements to be %v, got %v", []int{1, 2, 3}, queued)
+			}
+		})
+
+		t.Run("StableOrder", func(t *testing.T) {
+			t.Parallel()
+
+			priorityQueue := queue.NewPriority([]int{2, 1}, lessInt, queue.WithStableOrder())
+
+			_ = priorityQueue.ClearUnsafe()
+
+			for _, elem := range []int{3, 1} {
+				_ = priorityQueue.Offer(elem)
+			}
+
+			if elems := priorityQueue.ClearUnsafe(); !reflect.DeepEqual([]int{1, 3}, elems) {
+				t.Fatalf("expected elements to be %v, got %v", []int{1, 3}, elems)
+			}
+		})
+	})
+
+	t.Run("WithTieBreak", func(t *testing.T) {
+		t.Parallel()
+
+		type item struct {
+			Key int `json:"key"`
+			ID  int `json:"id"`
+		}
+
+		lessItem := func(elem, otherElem item) bool {
+			return elem.Key < otherElem.Key
+		}
+
+		// stream drives the same elements through the queue, retrieving
+		// some of them along the way, and returns the retrieved elements
+		// followed by the remaining ones.
+		stream := func(priorityQueue *queue.Priority[item]) []item {
+			var retrieved []item
+
+			for i := 0; i < 40; i++ {
+				_ = priorityQueue.Offer(item{Key: i % 4, ID: i})
+
+				if i%7 == 6 {
+					elem, _ := priorityQueue.Get()
+					retrieved = append(retrieved, elem)
+				}
+			}
+
+			return append(retrieved, priorityQueue.Clear()...)
+		}
+
+		// groups returns the IDs of the elements of every key, in the
+		// order in which they were retrieved.
+		groups := func(elems []item) map[int][]int {
+			ids := make(map[int][]int)
+
+			for _, elem := range elems {
+				ids[elem.Key] = append(ids[elem.Key], elem.ID)
+			}
+
+			return ids
+		}
+
+		keys := func(elems []item) []int {
+			ks := make([]int, len(elems))
+
+			for i, elem := range elems {
+				ks[i] = elem.Key
+			}
+
+			return ks
+		}
+
+		t.Run("MirrorImage", func(t *testing.T) {
+			t.Parallel()
+
+			fifoQueue := queue.NewPriority([]item{}, lessItem, queue.WithTieBreak(queue.TieBreakFIFO))
+			lifoQueue := queue.NewPriority([]item{}, lessItem, queue.WithTieBreak(queue.TieBreakLIFO))
+
+			// without retrievals, the equal elements are retrieved in
+			// mirror-image orders.
+			for i := 0; i < 40; i++ {
+				_ = fifoQueue.Offer(item{Key: i % 4, ID: i})
+				_ = lifoQueue.Offer(item{Key: i % 4, ID: i})
+			}
+
+			fifo, lifo := fifoQueue.Clear(), lifoQueue.Clear()
+
+			if !reflect.DeepEqual(keys(fifo), keys(lifo)) {
+				t.Fatalf("expected the unequal elements in the same order, got %v and %v", keys(fifo), keys(lifo))
+			}
+
+			fifoGroups, lifoGroups := groups(fifo), groups(lifo)
+
+			for key, ids := range fifoGroups {
+				if !sort.IntsAreSorted(ids) {
+					t.Fatalf("expected the elements of key %d in insertion order, got %v", key, ids)
+				}
+
+				reversed := slices.Clone(lifoGroups[key])
+				slices.Reverse(reversed)
+
+				if !reflect.DeepEqual(ids, reversed) {
+					t.Fatalf("expected the elements of key %d in reverse order, got %v and %v", key, ids, lifoGroups[key])
+				}
+			}
+		})
+
+		t.Run("Stream", func(t *testing.T) {
+			t.Parallel()
+
+			lifo := stream(queue.NewPriority([]item{}, lessItem, queue.WithTieBreak(queue.TieBreakLIFO)))
+
+			// the first retrieval sees the elements 0 to 6, the key 0
+			// elements 0 and 4 being equal.
+			if lifo[0] != (item{Key: 0, ID: 4}) {
+				t.Fatalf("expected the most recent equal element first, got %v", lifo[0])
+			}
+
+			fifo := stream(queue.NewPriority([]item{}, lessItem, queue.WithStableOrder()))
+
+			if fifo[0] != (item{Key: 0, ID: 0}) {
+				t.Fatalf("expected the oldest equal element first, got %v", fifo[0])
+			}
+		})
+
+		t.Run("PeekAndMarshalJSON", func(t *testing.T) {
+			t.Parallel()
+
+			priorityQueue := queue.NewPriority(
+				[]item{{Key: 1, ID: -1}, {Key: 1, ID: -2}},
+				lessItem,
+				queue.WithTieBreak(queue.TieBreakLIFO),
+			)
+
+			_ = priorityQueue.Offer(item{Key: 2, ID: 0})
+			_ = priorityQueue.Offer(item{Key: 1, ID: 1})
+
+			if head, _ := priorityQueue.Peek(); head != (item{Key: 1, ID: 1}) {
+				t.Fatalf("expected head to be %v, got %v", item{Key: 1, ID: 1}, head)
+			}
+
+			marshaled, err := priorityQueue.MarshalJSON()
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			expected := `[{"key":1,"id":1},{"key":1,"id":-2},{"key":1,"id":-1},{"key":2,"id":0}]`
+
+			if string(marshaled) != expected {
+				t.Fatalf("expected json to be %s, got %s", expected, marshaled)
+			}
+		})
+
+		t.Run("Reset", func(t *testing.T) {
+			t.Parallel()
+
+			initial := []item{{Key: 1, ID: -1}, {Key: 1, ID: -2}}
+
+			priorityQueue := queue.NewPriority(initial, lessItem, queue.WithTieBreak(queue.TieBreakLIFO))
+
+			first := stream(priorityQueue)
+
+			priorityQueue.Reset()
+
+			// the initial elements are restored, the later initial element
+			// first, and the queue keeps breaking the ties in LIFO order.
+			if elems := priorityQueue.ToSlice(); !reflect.DeepEqual(elems, []item{{Key: 1, ID: -2}, {Key: 1, ID: -1}}) {
+				t.Fatalf("expected elements to be %v, got %v", []item{{Key: 1, ID: -2}, {Key: 1, ID: -1}}, elems)
+			}
+
+			_ = priorityQueue.Clear()
+
+			// the initial elements are not offered again.
+			first = slices.DeleteFunc(first, func(elem item) bool { return elem.ID < 0 })
+
+			if second := stream(priorityQueue); !reflect.DeepEqual(first, second) {
+				t.Fatalf("expected the same order after Reset, got %v and %v", first, second)
+			}
+		})
+	})
+
+	t.Run("OfferBounded", func(t *testing.T) {
+		t.Parallel()
+
+		t.Run("Outcomes", func(t *testing.T) {
+			t.Parallel()
+
+			// the lower elements have the higher priority.
+			priorityQueue := queue.NewPriority([]int{5, 3}, lessInt, queue.WithCapacity(3))
+
+			testCases := []struct {
+				elem     int
+				expected queue.OfferOutcome[int]
+			}{
+				{elem: 4, expected: queue.OfferOutcome[int]{Status: queue.OfferAccepted}},
+				{elem: 4, expected: queue.OfferOutcome[int]{Status: queue.OfferRejectedDuplicate}},
+				{elem: 6, expected: queue.OfferOutcome[int]{Status: queue.OfferRejectedWorse}},
+				{elem: 5, expected: queue.OfferOutcome[int]{Status: queue.OfferRejectedDuplicate}},
+				{elem: 1, expected: queue.OfferOutcome[int]{Status: queue.OfferAcceptedWithEviction, Evicted: 5}},
+				{elem: 5, expected: queue.OfferOutcome[int]{Status: queue.OfferRejectedWorse}},
+				{elem: 2, expected: queue.OfferOutcome[int]{Status: queue.OfferAcceptedWithEviction, Evicted: 4}},
+			}
+
+			for _, tc := range testCases {
+				outcome, err := priorityQueue.OfferBounded(tc.elem)
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+
+				if outcome != tc.expected {
+					t.Fatalf("offering %d: expected outcome %+v, got %+v", tc.elem, tc.expected, outcome)
+				}
+			}
+
+			if elems := priorityQueue.Clear(); !reflect.DeepEqual([]int{1, 2, 3}, elems) {
+				t.Fatalf("expected elements to be %v, got %v", []int{1, 2, 3}, elems)
+			}
+		})
+
+		t.Run("Unbounded", func(t *testing.T) {
+			t.Parallel()
+
+			priorityQueue := queue.NewPriority([]int{}, lessInt)
+
+			for _, elem := range []int{3, 1, 3, 2} {
+				if _, err := priorityQueue.OfferBounded(elem); err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+			}
+
+			if elems := priorityQueue.Clear(); !reflect.DeepEqual([]int{1, 2, 3}, elems) {
+				t.Fatalf("expected elements to be %v, got %v", []int{1, 2, 3}, elems)
+			}
+		})
+
+		t.Run("ZeroCapacity", func(t *testing.T) {
+			t.Parallel()
+
+			priorityQueue := queue.NewPriority([]int{}, lessInt, queue.WithCapacity(0))
+
+			if _, err := priorityQueue.OfferBounded(1); !errors.Is(err, queue.ErrQueueIsFull) {
+				t.Fatalf("expected error to be %v, got %v", queue.ErrQueueIsFull, err)
+			}
+		})
+
+		t.Run("StableOrderTies", func(t *testing.T) {
+			t.Parallel()
+
+			type candidate struct {
+				id    string
+				score int
+			}
+
+			priorityQueue := queue.NewPriority(
+				[]candidate{{id: "a", score: 1}, {id: "b", score: 2}},
+				func(elem, otherElem candidate) bool { return elem.score > otherElem.score },
+				queue.WithCapacity(2),
+				queue.WithStableOrder(),
+			)
+
+			// the candidates already in the queue win the ties.
+			outcome, err := priorityQueue.OfferBounded(candidate{id: "c", score: 1})
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if outcome.Status != queue.OfferRejectedWorse {
+				t.Fatalf("expected status to be %v, got %v", queue.OfferRejectedWorse, outcome.Status)
+			}
+
+			if outcome.Status.Accepted() {
+				t.Fatalf("expected the rejected status not to be accepted")
+			}
+		})
+	})
+}
+
+func TestPriorityAny(t *testing.T) {
+	t.Parallel()
+
+	type job struct {
+		Priority int      `json:"priority"`
+		Tags     []string `json:"tags"`
+	}
+
+	lessJob := func(elem, otherElem job) bool {
+		return elem.Priority < otherElem.Priority
+	}
+
+	equalJob := func(elem, otherElem job) bool {
+		return reflect.DeepEqual(elem, otherElem)
+	}
+
+	jobs := func() []job {
+		return []job{
+			{Priority: 3, Tags: []string{"c"}},
+			{Priority: 1, Tags: []string{"a"}},
+			{Priority: 2, Tags: []string{"b"}},
+		}
+	}
+
+	t.Run("NotExposedByPriority", func(t *testing.T) {
+		t.Parallel()
+
+		typ := reflect.TypeOf(queue.Priority[int]{})
+
+		for i := 0; i < typ.NumField(); i++ {
+			if field := typ.Field(i); field.IsExported() {
+				t.Fatalf("expected Priority to have no exported fields, got %s", field.Name)
+			}
+		}
+
+		// every method of PriorityAny is forwarded by Priority.
+		anyTyp := reflect.TypeOf((*queue.PriorityAny[int])(nil))
+		priorityTyp := reflect.TypeOf((*queue.Priority[int])(nil))
+
+		for i := 0; i < anyTyp.NumMethod(); i++ {
+			if _, ok := priorityTyp.MethodByName(anyTyp.Method(i).Name); !ok {
+				t.Fatalf("expected Priority to have the %s method", anyTyp.Method(i).Name)
+			}
+		}
+	})
+
+	t.Run("API", func(t *testing.T) {
+		t.Parallel()
+
+		priorityQueue := queue.NewPriorityAny(jobs(), lessJob, queue.WithCapacity(4))
+
+		if size := priorityQueue.Size(); size != 3 {
+			t.Fatalf("expected size to be %d, got %d", 3, size)
+		}
+
+		if err := priorityQueue.Offer(job{Priority: 0, Tags: []string{"z"}}); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if err := priorityQueue.Offer(job{Priority: 5}); !errors.Is(err, queue.ErrQueueIsFull) {
+			t.Fatalf("expected error to be %v, got %v", queue.ErrQueueIsFull, err)
+		}
+
+		if e, err := priorityQueue.Peek(); err != nil || e.Priority != 0 {
+			t.Fatalf("expected peeked priority to be %d and no error, got %d and %v", 0, e.Priority, err)
+		}
+
+		if e, err := priorityQueue.Get(); err != nil || e.Priority != 0 {
+			t.Fatalf("expected priority to be %d and no error, got %d and %v", 0, e.Priority, err)
+		}
+
+		marshaled, err := json.Marshal(priorityQueue)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		expectedJSON := `[{"priority":1,"tags":["a"]},{"priority":2,"tags":["b"]},{"priority":3,"tags":["c"]}]`
+
+		if string(marshaled) != expectedJSON {
+			t.Fatalf("expected json to be %s, got %s", expectedJSON, marshaled)
+		}
+
+		expected := []job{
+			{Priority: 1, Tags: []string{"a"}},
+			{Priority: 2, Tags: []string{"b"}},
+			{Priority: 3, Tags: []string{"c"}},
+		}
+
+		if elems := priorityQueue.Clear(); !reflect.DeepEqual(expected, elems) {
+			t.Fatalf("expected elements to be %v, got %v", expected, elems)
+		}
+
+		if !priorityQueue.IsEmpty() {
+			t.Fatalf("expected queue to be empty")
+		}
+
+		priorityQueue.Reset()
+
+		var priorities []int
+
+		for e := range priorityQueue.Iterator() {
+			priorities = append(priorities, e.Priority)
+		}
+
+		if !reflect.DeepEqual([]int{1, 2, 3}, priorities) {
+			t.Fatalf("expected priorities to be %v, got %v", []int{1, 2, 3}, priorities)
+		}
+	})
+
+	t.Run("Contains", func(t *testing.T) {
+		t.Parallel()
+
+		t.Run("WithoutEqualFunc", func(t *testing.T) {
+			t.Parallel()
+
+			priorityQueue := queue.NewPriorityAny(jobs(), lessJob)
+
+			if priorityQueue.Contains(jobs()[0]) {
+				t.Fatalf("expected Contains to be false without an equal func")
+			}
+		})
+
+		t.Run("WithEqualFunc", func(t *testing.T) {
+			t.Parallel()
+
+			priorityQueue := queue.NewPriorityAny(jobs(), lessJob, queue.WithEqualFunc(equalJob))
+
+			if !priorityQueue.Contains(job{Priority: 2, Tags: []string{"b"}}) {
+				t.Fatalf("expected queue to contain the job")
+			}
+
+			if priorityQueue.Contains(job{Priority: 2, Tags: []string{"x"}}) {
+				t.Fatalf("expected queue not to contain the job")
+			}
+		})
+
+		t.Run("MismatchedType", func(t *testing.T) {
+			t.Parallel()
+
+			defer func() {
+				if r := recover(); r == nil {
+					t.Fatalf("expected panic")
+				}
+			}()
+
+			queue.NewPriorityAny(jobs(), lessJob, queue.WithEqualFunc(func(a, b int) bool { return a == b }))
+		})
+
+		t.Run("Comparable", func(t *testing.T) {
+			t.Parallel()
+
+			priorityQueue := queue.NewPriority([]int{1, 2}, func(elem, otherElem int) bool {
+				return elem < otherElem
+			})
+
+			if !priorityQueue.Contains(2) || priorityQueue.Contains(3) {
+				t.Fatalf("expected comparable queue to compare elements using ==")
+			}
+		})
+	})
+}
+
+func FuzzPriority(f *testing.F) {
+	testcases := [][]byte{{2, 10, 8, 4}, {11, 9, 7}, {8, 24, 255}}
+	for _, tc := range testcases {
+		f.Add(tc)
+	}
+
+	lessFunc := func(elem, elemAfter byte) bool {
+		return elem < elemAfter
+	}
+
+	f.Fuzz(func(t *testing.T, orig []byte) {
+		sort.Slice(orig, func(i, j int) bool {
+			return orig[i] < orig[j]
+		})
+
+		priorityQueue := queue.NewPriority(nil, lessFunc)
+
+		for _, v := range orig {
+			err := priorityQueue.Offer(v)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+		}
+
+		for _, v := range orig {
+			peekedVal, err := priorityQueue.Peek()
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if v != peekedVal {
+				t.Fatalf("expected peeked value to be %d, got %d", v, peekedVal)
+			}
+
+			getVal, err := priorityQueue.Get()
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if peekedVal != getVal {
+				t.Fatalf("expected peeked value to be %d, got %d", peekedVal, getVal)
+			}
+		}
+	})
+}
+
+// FuzzOfferBounded checks the outcomes of OfferBounded against a model
+// holding the k lowest distinct elements offered.
+func FuzzOfferBounded(f *testing.F) {
+	f.Add(uint8(3), []byte{5, 1, 5, 9, 0, 2, 2, 7, 1})
+	f.Add(uint8(1), []byte{4, 4, 3, 5, 3})
+	f.Add(uint8(5), []byte{1, 2, 3})
+
+	lessFunc := func(elem, elemAfter byte) bool {
+		return elem < elemAfter
+	}
+
+	f.Fuzz(func(t *testing.T, k uint8, stream []byte) {
+		capacity := int(k%8) + 1
+
+		priorityQueue := queue.NewPriority(nil, lessFunc, queue.WithCapacity(capacity))
+
+		var model []byte
+
+		for _, elem := range stream {
+			expected := queue.OfferOutcome[byte]{Status: queue.OfferAccepted}
+
+			switch {
+			case bytes.IndexByte(model, elem) >= 0:
+				expected.Status = queue.OfferRejectedDuplicate
+			case len(model) < capacity:
+				model = append(model, elem)
+			case elem < model[len(model)-1]:
+				expected.Status = queue.OfferAcceptedWithEviction
+				expected.Evicted = model[len(model)-1]
+
+				model[len(model)-1] = elem
+			default:
+				expected.Status = queue.OfferRejectedWorse
+			}
+
+			sort.Slice(model, func(i, j int) bool { return model[i] < model[j] })
+
+			outcome, err := priorityQueue.OfferBounded(elem)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if outcome != expected {
+				t.Fatalf("offering %d: expected outcome %+v, got %+v", elem, expected, outcome)
+			}
+		}
+
+		if elems := priorityQueue.Clear(); !bytes.Equal(model, elems) {
+			t.Fatalf("expected elements to be %v, got %v", model, elems)
+		}
+	})
+}
+
+func TestPriorityRebuild(t *testing.T) {
+	t.Parallel()
+
+	type item struct {
+		Key int
+		ID  int
+	}
+
+	lessItem := func(elem, otherElem item) bool {
+		return elem.Key < otherElem.Key
+	}
+
+	// run applies the same random insertions and removals to the queues,
+	// failing if they retrieve different elements, then drains them.
+	run := func(t *testing.T, seed int64, queues [2]*queue.Priority[item], between func()) {
+		t.Helper()
+
+		rng := rand.New(rand.NewSource(seed))
+
+		for i := 0; i < 2000; i++ {
+			if rng.Intn(3) == 0 {
+				got, err := queues[0].Get()
+				otherGot, otherErr := queues[1].Get()
+
+				if got != otherGot || !errors.Is(otherErr, err) {
+					t.Fatalf("op %d: expected to get %v, %v, got %v, %v", i, got, err, otherGot, otherErr)
+				}
+
+				continue
+			}
+
+			// few keys, so that many elements are equal.
+			elem := item{Key: rng.Intn(8), ID: i}
+
+			_ = queues[0].Offer(elem)
+			_ = queues[1].Offer(elem)
+
+			if i%100 == 0 {
+				between()
+			}
+		}
+
+		if drained, otherDrained := queues[0].Clear(), queues[1].Clear(); !reflect.DeepEqual(drained, otherDrained) {
+			t.Fatalf("expected the drain orders to be equal, got %v and %v", drained, otherDrained)
+		}
+	}
+
+	t.Run("Rebuild", func(t *testing.T) {
+		t.Parallel()
+
+		for seed := int64(0); seed < 10; seed++ {
+			queues := [2]*queue.Priority[item]{
+				queue.NewPriority([]item{}, lessItem, queue.WithStableOrder()),
+				queue.NewPriority([]item{}, lessItem, queue.WithStableOrder()),
+			}
+
+			run(t, seed, queues, queues[1].Rebuild)
+		}
+	})
+
+	t.Run("AutoRebuild", func(t *testing.T) {
+		t.Parallel()
+
+		for seed := int64(0); seed < 10; seed++ {
+			queues := [2]*queue.Priority[item]{
+				queue.NewPriority([]item{}, lessItem, queue.WithStableOrder()),
+				queue.NewPriority([]item{}, lessItem, queue.WithStableOrder(), queue.WithAutoRebuild(7)),
+			}
+
+			run(t, seed, queues, func() {})
+		}
+	})
+
+	t.Run("Distinct", func(t *testing.T) {
+		t.Parallel()
+
+		elems := rand.New(rand.NewSource(1)).Perm(1000)
+
+		priorityQueue := queue.NewPriority[int](nil, func(elem, otherElem int) bool {
+			return elem > otherElem
+		})
+
+		for _, elem := range elems {
+			_ = priorityQueue.Offer(elem)
+		}
+
+		priorityQueue.Rebuild()
+
+		for expected := len(elems) - 1; expected >= 0; expected-- {
+			if elem, err := priorityQueue.Get(); err != nil || elem != expected {
+				t.Fatalf("expected to get %d, got %d, %v", expected, elem, err)
+			}
+		}
+	})
+
+	t.Run("HeapStats", func(t *testing.T) {
+		t.Parallel()
+
+		priorityQueue := queue.NewPriority([]int{}, func(elem, otherElem int) bool {
+			return elem < otherElem
+		})
+
+		if depth, avgSiftLen, size := priorityQueue.HeapStats(); depth != 0 || avgSiftLen != 0 || size != 0 {
+			t.Fatalf("expected no stats for an empty queue, got %d %v %d", depth, avgSiftLen, size)
+		}
+
+		for i := 100; i > 0; i-- {
+			_ = priorityQueue.Offer(i)
+		}
+
+		depth, avgSiftLen, size := priorityQueue.HeapStats()
+
+		if depth != 7 || size != 100 {
+			t.Fatalf("expected a depth of 7 and a size of 100, got %d and %d", depth, size)
+		}
+
+		if avgSiftLen <= 0 || avgSiftLen >= float64(depth) {
+			t.Fatalf("expected the average sift length to be between 0 and %d, got %v", depth, avgSiftLen)
+		}
+
+		// the elements are not removed.
+		if elem, err := priorityQueue.Peek(); err != nil || elem != 1 || priorityQueue.Size() != 100 {
+			t.Fatalf("expected the queue to be unchanged, got head %d, %v and size %d", elem, err, priorityQueue.Size())
+		}
+	})
+
+	t.Run("Concurrent", func(t *testing.T) {
+		t.Parallel()
+
+		priorityQueue := queue.NewPriority([]int{}, func(elem, otherElem int) bool {
+			return elem < otherElem
+		}, queue.WithAutoRebuild(50))
+
+		assertIteratorConcurrentConservation(t, priorityQueue)
+
+		var wg sync.WaitGroup
+
+		wg.Add(2)
+
+		go func() {
+			defer wg.Done()
+
+			for i := 0; i < 1000; i++ {
+				_ = priorityQueue.Offer(i)
+			}
+		}()
+
+		go func() {
+			defer wg.Done()
+
+			for i := 0; i < 100; i++ {
+				priorityQueue.Rebuild()
+				_, _, _ = priorityQueue.HeapStats()
+			}
+		}()
+
+		wg.Wait()
+
+		for expected := 0; expected < 1000; expected++ {
+			if elem, err := priorityQueue.Get(); err != nil || elem != expected {
+				t.Fatalf("expected to get %d, got %d, %v", expected, elem, err)
+			}
+		}
+	})
+}
+
+func TestPriorityUpdateRemove(t *testing.T) {
+	t.Parallel()
+
+	lessInt := func(elem, otherElem int) bool { return elem < otherElem }
+
+	t.Run("UpdateHigherThanHead", func(t *testing.T) {
+		t.Parallel()
+
+		priorityQueue := queue.NewPriority([]int{2, 4, 6, 8}, lessInt)
+
+		if !priorityQueue.Update(6, 1) {
+			t.Fatal("expected the element to be updated")
+		}
+
+		expected := []int{1, 2, 4, 8}
+
+		if elems := priorityQueue.Clear(); !reflect.DeepEqual(expected, elems) {
+			t.Fatalf("expected elements to be %v, got %v", expected, elems)
+		}
+	})
+
+	t.Run("UpdateHeadLower", func(t *testing.T) {
+		t.Parallel()
+
+		priorityQueue := queue.NewPriority([]int{2, 4, 6, 8}, lessInt)
+
+		if !priorityQueue.Update(2, 7) {
+			t.Fatal("expected the element to be updated")
+		}
+
+		if head, err := priorityQueue.Peek(); err != nil || head != 4 {
+			t.Fatalf("expected head to be 4, got %d, %v", head, err)
+		}
+
+		expected := []int{4, 6, 7, 8}
+
+		if elems := priorityQueue.Clear(); !reflect.DeepEqual(expected, elems) {
+			t.Fatalf("expected elements to be %v, got %v", expected, elems)
+		}
+	})
+
+	t.Run("Absent", func(t *testing.T) {
+		t.Parallel()
+
+		priorityQueue := queue.NewPriority([]int{2, 4}, lessInt)
+
+		if priorityQueue.Update(3, 1) {
+			t.Fatal("expected no element to be updated")
+		}
+
+		if priorityQueue.Remove(3) {
+			t.Fatal("expected no element to be removed")
+		}
+
+		if elems := priorityQueue.Clear(); !reflect.DeepEqual([]int{2, 4}, elems) {
+			t.Fatalf("expected elements to be %v, got %v", []int{2, 4}, elems)
+		}
+	})
+
+	t.Run("Remove", func(t *testing.T) {
+		t.Parallel()
+
+		priorityQueue := queue.NewPriority([]int{5, 1, 4, 2, 3}, lessInt)
+
+		// the head, then an inner element.
+		for _, elem := range []int{1, 4} {
+			if !priorityQueue.Remove(elem) {
+				t.Fatalf("expected %d to be removed", elem)
+			}
+		}
+
+		if size := priorityQueue.Size(); size != 3 {
+			t.Fatalf("expected size to be 3, got %d", size)
+		}
+
+		if elems := priorityQueue.Clear(); !reflect.DeepEqual([]int{2, 3, 5}, elems) {
+			t.Fatalf("expected elements to be %v, got %v", []int{2, 3, 5}, elems)
+		}
+	})
+
+	t.Run("HeapOrder", func(t *testing.T) {
+		t.Parallel()
+
+		rng := rand.New(rand.NewSource(1))
+
+		priorityQueue := queue.NewPriority([]int{}, lessInt)
+
+		var oracle []int
+
+		for i := 0; i < 5000; i++ {
+			elem := rng.Intn(100)
+
+			switch rng.Intn(4) {
+			case 0:
+				_ = priorityQueue.Offer(elem)
+
+				oracle = append(oracle, elem)
+			case 1:
+				newElem := rng.Intn(100)
+
+				if !priorityQueue.Update(elem, newElem) {
+					continue
+				}
+
+				oracle[slices.Index(oracle, elem)] = newElem
+			case 2:
+				if priorityQueue.Remove(elem) {
+					oracle = slices.Delete(oracle, slices.Index(oracle, elem), slices.Index(oracle, elem)+1)
+				}
+			case 3:
+				sort.Ints(oracle)
+
+				if len(oracle) == 0 {
+					continue
+				}
+
+				if head, err := priorityQueue.Get(); err != nil || head != oracle[0] {
+					t.Fatalf("op %d: expected to get %d, got %d, %v", i, oracle[0], head, err)
+				}
+
+				oracle = oracle[1:]
+			}
+		}
+
+		sort.Ints(oracle)
+
+		if elems := priorityQueue.Clear(); !slices.Equal(oracle, elems) {
+			t.Fatalf("expected elements to be %v, got %v", oracle, elems)
+		}
+	})
+
+	t.Run("NoEqualFunc", func(t *testing.T) {
+		t.Parallel()
+
+		priorityQueue := queue.NewPriorityAny([]int{1, 2}, lessInt)
+
+		if priorityQueue.Update(1, 3) || priorityQueue.Remove(1) {
+			t.Fatal("expected no element to be found without an equality function")
+		}
+	})
+
+	t.Run("WithRecycler", func(t *testing.T) {
+		t.Parallel()
+
+		var released []int
+
+		priorityQueue := queue.NewPriority([]int{1, 2, 3}, lessInt, queue.WithRecycler(
+			func() int { return 0 },
+			func(elem int) { released = append(released, elem) },
+		))
+
+		_ = priorityQueue.Update(2, 4)
+		_ = priorityQueue.Remove(3)
+
+		if !reflect.DeepEqual([]int{2, 3}, released) {
+			t.Fatalf("expected the released elements to be %v, got %v", []int{2, 3}, released)
+		}
+	})
+
+	t.Run("Journal", func(t *testing.T) {
+		t.Parallel()
+
+		var journal bytes.Buffer
+
+		newQueue := func(opts ...queue.Option) *queue.Priority[int] {
+			return queue.NewPriority([]int{5, 3, 8}, lessInt, opts...)
+		}
+
+		priorityQueue := newQueue(queue.WithJournal(&journal, queue.JournalCodec[int]{}))
+
+		rng := rand.New(rand.NewSource(1))
+
+		for i := 0; i < 500; i++ {
+			switch rng.Intn(4) {
+			case 0:
+				_ = priorityQueue.Offer(rng.Intn(20))
+			case 1:
+				_ = priorityQueue.Update(rng.Intn(20), rng.Intn(20))
+			case 2:
+				_ = priorityQueue.Remove(rng.Intn(20))
+			case 3:
+				_, _ = priorityQueue.Get()
+			}
+		}
+
+		priorityQueue.SyncJournal()
+
+		replayed, err := queue.Replay(&journal, queue.JournalCodec[int]{}, func() queue.Queue[int] {
+			return newQueue()
+		})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if elems, replayedElems := priorityQueue.Clear(), replayed.Clear(); !slices.Equal(elems, replayedElems) {
+			t.Fatalf("expected the replayed elements to be %v, got %v", elems, replayedElems)
+		}
+	})
+}
+
+func BenchmarkPriorityQueue(b *testing.B) {
+	b.Run("Peek", func(b *testing.B) {
+		priorityQueue := queue.NewPriority([]int{1}, func(elem, otherElem int) bool {
+			return elem < otherElem
+		})
+
+		b.ReportAllocs()
+		b.ResetTimer()
+
+		for i := 0; i <= b.N; i++ {
+			_, _ = priorityQueue.Peek()
+		}
+	})
+
+	b.Run("Get_Offer", func(b *testing.B) {
+		priorityQueue := queue.NewPriority([]int{1}, func(elem, otherElem int) bool {
+			return elem < otherElem
+		})
+
+		b.ReportAllocs()
+		b.ResetTimer()
+
+		for i := 0; i <= b.N; i++ {
+			_, _ = priorityQueue.Get()
+
+			_ = priorityQueue.Offer(1)
+		}
+	})
+
+	b.Run("Offer", func(b *testing.B) {
+		priorityQueue := queue.NewPriority[int](nil, func(elem, otherElem int) bool {
+			return elem < otherElem
+		})
+
+		b.ReportAllocs()
+		b.ResetTimer()
+
+		for i := 0; i <= b.N; i++ {
+			_ = priorityQueue.Offer(i)
+		}
+	})
+}
+
+// BenchmarkPriorityRebuild retrieves the elements of a queue offered in a
+// nearly descending order, each of them being sifted up to the head, with
+// and without rebuilding the heap first.
+func BenchmarkPriorityClear(b *testing.B) {
+	for _, size := range []int{1 << 10, 1 << 14, 1 << 18} {
+		size := size
+
+		for _, unsafe := range []bool{false, true} {
+			unsafe := unsafe
+
+			name := fmt.Sprintf("Clear/%d", size)
+			if unsafe {
+				name = fmt.Sprintf("ClearUnsafe/%d", size)
+			}
+
+			b.Run(name, func(b *testing.B) {
+				priorityQueue := queue.NewPriority([]int{}, func(elem, otherElem int) bool {
+					return elem < otherElem
+				}, queue.WithCapacity(size))
+
+				b.ReportAllocs()
+				b.ResetTimer()
+
+				for i := 0; i < b.N; i++ {
+					for j := 0; j < size; j++ {
+						_ = priorityQueue.Offer(size - j)
+					}
+
+					var elems []int
+
+					if unsafe {
+						elems = priorityQueue.ClearUnsafe()
+					} else {
+						elems = priorityQueue.Clear()
+					}
+
+					if len(elems) != size {
+						b.Fatalf("expected %d elements, got %d", size, len(elems))
+					}
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkPriorityRebuild(b *testing.B) {
+	const size = 1 << 16
+
+	for _, rebuild := range []bool{false, true} {
+		rebuild := rebuild
+
+		name := "Offered"
+		if rebuild {
+			name = "Rebuilt"
+		}
+
+		b.Run(name, func(b *testing.B) {
+			rng := rand.New(rand.NewSource(1))
+
+			for i := 0; i < b.N; {
+				b.StopTimer()
+
+				priorityQueue := queue.NewPriority[int](nil, func(elem, otherElem int) bool {
+					return elem < otherElem
+				})
+
+				for j := 0; j < size; j++ {
+					_ = priorityQueue.Offer(size - j + rng.Intn(64))
+				}
+
+				if rebuild {
+					priorityQueue.Rebuild()
+				}
+
+				_, avgSiftLen, _ := priorityQueue.HeapStats()
+
+				b.ReportMetric(avgSiftLen, "sifts/get")
+				b.StartTimer()
+
+				for j := 0; j < size && i < b.N; j, i = j+1, i+1 {
+					_, _ = priorityQueue.Get()
+				}
+			}
+		})
+	}
+}
+
+// assertIteratorConcurrentConservation runs Iterator concurrently with Offer,
+// Get and another Iterator, and checks that every offered element is
+// retrieved exactly once. The queue must be empty and able to hold 1000
+// elements.
+func assertIteratorConcurrentConservation(t *testing.T, q queue.Queue[int]) {
+	t.Helper()
+
+	const (
+		elems   = 1000
+		workers = 4
+	)
+
+	var (
+		wg   sync.WaitGroup
+		lock sync.Mutex
+		seen = make(map[int]int, elems)
+	)
+
+	received := func(elem int) {
+		lock.Lock()
+		defer lock.Unlock()
+
+		seen[elem]++
+	}
+
+	offered := make(chan struct{})
+
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+		defer close(offered)
+
+		for i := 0; i < elems; i++ {
+			if err := q.Offer(i); err != nil {
+				t.Errorf("expected no error, got %v", err)
+
+				return
+			}
+		}
+	}()
+
+	for i := 0; i < workers; i++ {
+		i := i
+
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for done := false; !done; {
+				select {
+				case <-offered:
+					done = true
+				default:
+				}
+
+				if i%2 == 0 {
+					for elem := range q.Iterator() {
+						received(elem)
+					}
+
+					continue
+				}
+
+				if elem, err := q.Get(); err == nil {
+					received(elem)
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	for elem := range q.Iterator() {
+		received(elem)
+	}
+
+	for i := 0; i < elems; i++ {
+		if seen[i] != 1 {
+			t.Fatalf("expected elem %d to be retrieved once, retrieved %d times", i, seen[i])
+		}
+	}
 }
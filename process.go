@@ -0,0 +1,319 @@
+package queue
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// ProcessReport summarizes the processing of the queue elements performed
+// by ProcessEach.
+type ProcessReport struct {
+	// Succeeded is the number of elements processed successfully.
+	Succeeded int
+
+	// Retried is the number of times a failed element was re-offered
+	// to the queue.
+	Retried int
+
+	// DeadLettered is the number of elements offered to the dead-letter
+	// queue after exhausting their retries.
+	DeadLettered int
+
+	// Dropped is the number of failed elements which could be neither
+	// retried nor offered to the dead-letter queue.
+	Dropped int
+}
+
+// ProcessEach removes the elements from the queue and passes them to fn,
+// re-offering the elements for which fn returns an error according to the
+// given options.
+//
+// For a Blocking queue it waits for new elements until ctx is done or the
+// queue is closed and drained. For the other queues it returns once the
+// queue is empty and no element is being processed.
+//
+// Once ctx is done no more elements are removed from the queue, the elements
+// being processed are settled and ProcessEach returns. The elements failing
+// after ctx is done are re-offered without consuming their retries.
+// The dropped elements are released if the queue was created using the
+// WithRecycler option.
+//
+// The retries of an element are tracked by value, thus equal elements share
+// their retry state: an element removed from the queue is assigned the
+// retries of the oldest re-offered element equal to it, if any.
+func ProcessEach[T comparable](
+	ctx context.Context,
+	q Queue[T],
+	fn func(context.Context, T) error,
+	opts ...ProcessOption,
+) ProcessReport {
+	options := processOptions{
+		concurrency: 1,
+		clock:       systemClock{},
+	}
+
+	for _, o := range opts {
+		o.applyProcess(&options)
+	}
+
+	p := &processor[T]{
+		queue:      q,
+		fn:         fn,
+		options:    options,
+		deadLetter: deadLetterOf[T](options),
+		attempts:   make(map[T][]int),
+	}
+
+	p.settled = sync.NewCond(&p.lock)
+
+	var wg sync.WaitGroup
+
+	wg.Add(max(options.concurrency, 1))
+
+	for i := 0; i < max(options.concurrency, 1); i++ {
+		go func() {
+			defer wg.Done()
+
+			p.run(ctx)
+		}()
+	}
+
+	wg.Wait()
+
+	return p.report
+}
+
+// contextWaiter is implemented by the queues which can wait for an element
+// until a context is done, ProcessEach waits for their new elements.
+type contextWaiter[T any] interface {
+	getCtx(ctx context.Context) (T, error)
+}
+
+// frontRequeuer is implemented by the queues which can re-offer an element
+// to their head, as requested by the WithRetryAtHead option.
+type frontRequeuer[T any] interface {
+	requeueFront(elem T)
+}
+
+// processor holds the state shared by the ProcessEach workers.
+type processor[T comparable] struct {
+	queue      Queue[T]
+	fn         func(context.Context, T) error
+	options    processOptions
+	deadLetter Queue[T]
+
+	// inFlight is the number of elements removed from the queue
+	// and not settled yet.
+	inFlight int
+
+	// attempts holds, for each re-offered element, the number of failed
+	// attempts of its re-offered occurrences, in the order they were
+	// re-offered.
+	attempts map[T][]int
+
+	report ProcessReport
+
+	// synchronization
+	lock    sync.Mutex
+	settled *sync.Cond
+}
+
+// run processes elements until there are no more elements to process.
+func (p *processor[T]) run(ctx context.Context) {
+	for {
+		elem, attempt, ok := p.next(ctx)
+		if !ok {
+			return
+		}
+
+		p.settle(ctx, elem, attempt, p.fn(ctx, elem))
+	}
+}
+
+// next removes the next element from the queue and returns it along with
+// its number of failed attempts.
+func (p *processor[T]) next(ctx context.Context) (elem T, attempt int, ok bool) {
+	if ctx.Err() != nil {
+		return elem, 0, false
+	}
+
+	if waiter, canWait := p.queue.(contextWaiter[T]); canWait {
+		elem, err := waiter.getCtx(ctx)
+		if err != nil {
+			return elem, 0, false
+		}
+
+		p.lock.Lock()
+		defer p.lock.Unlock()
+
+		p.inFlight++
+
+		return elem, p.takeAttempts(elem), true
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	for {
+		elem, err := p.queue.Get()
+		if err == nil {
+			p.inFlight++
+
+			return elem, p.takeAttempts(elem), true
+		}
+
+		// the elements being processed may be re-offered.
+		if p.inFlight == 0 {
+			break
+		}
+
+		p.settled.Wait()
+
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	return elem, 0, false
+}
+
+// settle records the outcome of processing the element, re-offering it or
+// offering it to the dead-letter queue if it failed.
+func (p *processor[T]) settle(ctx context.Context, elem T, attempt int, err error) {
+	defer func() {
+		p.lock.Lock()
+		defer p.lock.Unlock()
+
+		p.inFlight--
+
+		p.settled.Broadcast()
+	}()
+
+	switch {
+	case err == nil:
+		p.record(func(r *ProcessReport) { r.Succeeded++ })
+
+		return
+
+	case ctx.Err() != nil:
+		// the element is not retried, but put back for the next run.
+		if p.retry(elem, attempt) {
+			return
+		}
+
+	case attempt < p.options.maxRetries:
+		sleep(ctx, p.options.clock, p.options.backoff)
+
+		if p.retry(elem, attempt+1) {
+			p.record(func(r *ProcessReport) { r.Retried++ })
+
+			return
+		}
+	}
+
+	if p.deadLetter != nil && p.deadLetter.Offer(elem) == nil {
+		p.record(func(r *ProcessReport) { r.DeadLettered++ })
+
+		return
+	}
+
+	discardFrom(p.queue, elem)
+
+	p.record(func(r *ProcessReport) { r.Dropped++ })
+}
+
+// retry re-offers the element to the queue, recording its number of failed
+// attempts. It returns false if the element could not be re-offered.
+func (p *processor[T]) retry(elem T, attempt int) bool {
+	// record the attempts before the element becomes available to the
+	// other workers.
+	p.lock.Lock()
+	p.attempts[elem] = append(p.attempts[elem], attempt)
+	p.lock.Unlock()
+
+	if requeuer, canRequeue := p.queue.(frontRequeuer[T]); canRequeue && p.options.retryAtHead {
+		requeuer.requeueFront(elem)
+
+		return true
+	}
+
+	if err := p.queue.Offer(elem); err != nil {
+		p.lock.Lock()
+		defer p.lock.Unlock()
+
+		p.dropAttempts(elem)
+
+		return false
+	}
+
+	return true
+}
+
+// takeAttempts returns the number of failed attempts of the oldest
+// re-offered occurrence of the element, or 0 if it was not re-offered.
+func (p *processor[T]) takeAttempts(elem T) int {
+	attempts, ok := p.attempts[elem]
+	if !ok {
+		return 0
+	}
+
+	if len(attempts) == 1 {
+		delete(p.attempts, elem)
+	} else {
+		p.attempts[elem] = attempts[1:]
+	}
+
+	return attempts[0]
+}
+
+// dropAttempts removes the attempts recorded last for the element.
+func (p *processor[T]) dropAttempts(elem T) {
+	attempts := p.attempts[elem]
+
+	if len(attempts) <= 1 {
+		delete(p.attempts, elem)
+
+		return
+	}
+
+	p.attempts[elem] = attempts[:len(attempts)-1]
+}
+
+// record updates the report.
+func (p *processor[T]) record(update func(r *ProcessReport)) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	update(&p.report)
+}
+
+// deadLetterOf returns the dead-letter queue provided using WithDeadLetter,
+// or nil if none was provided.
+func deadLetterOf[T comparable](opts processOptions) Queue[T] {
+	if opts.deadLetter == nil {
+		return nil
+	}
+
+	deadLetter, ok := opts.deadLetter.(Queue[T])
+	if !ok {
+		panic("dead-letter queue type does not match the queue element type")
+	}
+
+	return deadLetter
+}
+
+// sleep waits for d to elapse on the clock or for ctx to be done.
+func sleep(ctx context.Context, clock Clock, d time.Duration) {
+	if d <= 0 {
+		return
+	}
+
+	timer := clock.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C():
+	case <-ctx.Done():
+	}
+}
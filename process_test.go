@@ -0,0 +1,258 @@
+package queue_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/adrianbrad/queue"
+)
+
+var errProcess = errors.New("process failed")
+
+func TestProcessEach(t *testing.T) {
+	t.Parallel()
+
+	t.Run("FlakySucceedsOnRetry", func(t *testing.T) {
+		t.Parallel()
+
+		linkedQueue := queue.NewLinked([]int{1, 2, 3})
+
+		var (
+			lock     sync.Mutex
+			attempts = make(map[int]int)
+		)
+
+		report := queue.ProcessEach(
+			context.Background(),
+			queue.Queue[int](linkedQueue),
+			func(_ context.Context, elem int) error {
+				lock.Lock()
+				defer lock.Unlock()
+
+				attempts[elem]++
+
+				if elem == 2 && attempts[elem] == 1 {
+					return errProcess
+				}
+
+				return nil
+			},
+			queue.WithMaxRetries(1),
+		)
+
+		expected := queue.ProcessReport{Succeeded: 3, Retried: 1}
+
+		if report != expected {
+			t.Fatalf("expected report to be %+v, got %+v", expected, report)
+		}
+
+		if !linkedQueue.IsEmpty() {
+			t.Fatalf("expected queue to be empty")
+		}
+	})
+
+	t.Run("ExhaustedRetriesDeadLettered", func(t *testing.T) {
+		t.Parallel()
+
+		linkedQueue := queue.NewLinked([]int{1, 2, 3})
+		deadLetter := queue.NewLinked([]int{})
+
+		report := queue.ProcessEach(
+			context.Background(),
+			queue.Queue[int](linkedQueue),
+			func(_ context.Context, elem int) error {
+				if elem == 2 {
+					return errProcess
+				}
+
+				return nil
+			},
+			queue.WithMaxRetries(2),
+			queue.WithDeadLetter[int](deadLetter),
+		)
+
+		expected := queue.ProcessReport{Succeeded: 2, Retried: 2, DeadLettered: 1}
+
+		if report != expected {
+			t.Fatalf("expected report to be %+v, got %+v", expected, report)
+		}
+
+		if elems := deadLetter.Clear(); !reflect.DeepEqual([]int{2}, elems) {
+			t.Fatalf("expected dead-lettered elements to be %v, got %v", []int{2}, elems)
+		}
+	})
+
+	t.Run("DroppedWithoutDeadLetter", func(t *testing.T) {
+		t.Parallel()
+
+		report := queue.ProcessEach(
+			context.Background(),
+			queue.Queue[int](queue.NewLinked([]int{1})),
+			func(context.Context, int) error { return errProcess },
+		)
+
+		if expected := (queue.ProcessReport{Dropped: 1}); report != expected {
+			t.Fatalf("expected report to be %+v, got %+v", expected, report)
+		}
+	})
+
+	t.Run("Backoff", func(t *testing.T) {
+		t.Parallel()
+
+		clock := newFakeClock()
+
+		var calls atomic.Int32
+
+		done := make(chan queue.ProcessReport)
+
+		go func() {
+			done <- queue.ProcessEach(
+				context.Background(),
+				queue.Queue[int](queue.NewLinked([]int{1})),
+				func(context.Context, int) error {
+					if calls.Add(1) == 1 {
+						return errProcess
+					}
+
+					return nil
+				},
+				queue.WithMaxRetries(1),
+				queue.WithRetryBackoff(time.Minute),
+				queue.WithProcessClock(clock),
+			)
+		}()
+
+		clock.WaitForTimers(t, 1)
+
+		if c := calls.Load(); c != 1 {
+			t.Fatalf("expected the element not to be retried before the backoff, got %d calls", c)
+		}
+
+		clock.Advance(time.Minute)
+
+		if report := <-done; report != (queue.ProcessReport{Succeeded: 1, Retried: 1}) {
+			t.Fatalf("expected element to succeed after the backoff, got %+v", report)
+		}
+	})
+
+	t.Run("CancellationDrainsGracefully", func(t *testing.T) {
+		t.Parallel()
+
+		blockingQueue := queue.NewBlocking([]int{1, 2, 3})
+
+		ctx, cancel := context.WithCancel(context.Background())
+
+		started := make(chan struct{})
+
+		done := make(chan queue.ProcessReport)
+
+		go func() {
+			done <- queue.ProcessEach(
+				ctx,
+				queue.Queue[int](blockingQueue),
+				func(ctx context.Context, elem int) error {
+					if elem == 1 {
+						return nil
+					}
+
+					close(started)
+
+					<-ctx.Done()
+
+					return ctx.Err()
+				},
+				queue.WithRetryAtHead(),
+			)
+		}()
+
+		<-started
+
+		cancel()
+
+		select {
+		case report := <-done:
+			if expected := (queue.ProcessReport{Succeeded: 1}); report != expected {
+				t.Fatalf("expected report to be %+v, got %+v", expected, report)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("expected ProcessEach to return after cancellation")
+		}
+
+		// the interrupted element is put back at the head.
+		if elems := blockingQueue.Clear(); !reflect.DeepEqual([]int{2, 3}, elems) {
+			t.Fatalf("expected remaining elements to be %v, got %v", []int{2, 3}, elems)
+		}
+	})
+
+	t.Run("ConcurrencyLimit", func(t *testing.T) {
+		t.Parallel()
+
+		const (
+			limit = 3
+			elems = 60
+		)
+
+		initial := make([]int, elems)
+
+		for i := range initial {
+			initial[i] = i
+		}
+
+		blockingQueue := queue.NewBlocking(initial)
+
+		// processing stops once the closed queue is drained.
+		blockingQueue.Close()
+
+		var (
+			current, peak atomic.Int32
+			lock          sync.Mutex
+			processed     []int
+		)
+
+		report := queue.ProcessEach(
+			context.Background(),
+			queue.Queue[int](blockingQueue),
+			func(_ context.Context, elem int) error {
+				n := current.Add(1)
+				defer current.Add(-1)
+
+				for {
+					p := peak.Load()
+					if n <= p || peak.CompareAndSwap(p, n) {
+						break
+					}
+				}
+
+				time.Sleep(100 * time.Microsecond)
+
+				lock.Lock()
+				defer lock.Unlock()
+
+				processed = append(processed, elem)
+
+				return nil
+			},
+			queue.WithConcurrency(limit),
+		)
+
+		if report.Succeeded != elems {
+			t.Fatalf("expected %d elements to succeed, got %d", elems, report.Succeeded)
+		}
+
+		if p := peak.Load(); p > limit {
+			t.Fatalf("expected at most %d concurrent calls, got %d", limit, p)
+		}
+
+		sort.Ints(processed)
+
+		if !reflect.DeepEqual(initial, processed) {
+			t.Fatalf("expected every element to be processed once")
+		}
+	})
+}
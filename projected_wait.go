@@ -0,0 +1,180 @@
+package queue
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// drainRateSmoothing is the weight of the last window in the drain rate,
+// the previous windows weighing the rest.
+const drainRateSmoothing = 0.5
+
+// ProjectedWaitError is the error returned by the insertions of a Blocking
+// queue created with the WithMaxProjectedWait option whenever an element
+// would wait longer than the maximum wait to be retrieved, given the current
+// drain rate of the queue. It wraps the ErrWouldExceedWait error.
+type ProjectedWaitError struct {
+	// Projected is the time the element would wait before being retrieved.
+	Projected time.Duration
+
+	// MaxWait is the maximum wait provided using WithMaxProjectedWait.
+	MaxWait time.Duration
+}
+
+// Error returns the projected and the maximum wait.
+func (e *ProjectedWaitError) Error() string {
+	return fmt.Sprintf("%s: projected %s, max %s", ErrWouldExceedWait, e.Projected, e.MaxWait)
+}
+
+// Unwrap returns the ErrWouldExceedWait error.
+func (e *ProjectedWaitError) Unwrap() error {
+	return ErrWouldExceedWait
+}
+
+// drainRate estimates the rate at which the elements of a queue are
+// retrieved, as an exponentially weighted average of the rates measured over
+// consecutive windows, and rejects the elements which would wait longer than
+// the maximum wait. A nil drainRate admits every element.
+type drainRate struct {
+	clock   Clock
+	maxWait time.Duration
+	window  time.Duration
+
+	// start is the start of the current window, drained the number of
+	// elements retrieved during it and backlogged is set if the queue held
+	// elements during it. size is the last observed size of the queue.
+	start      time.Time
+	drained    int
+	backlogged bool
+	size       int
+
+	// rate is the drain rate, in elements per second, measured once an
+	// element was retrieved.
+	rate     float64
+	measured bool
+}
+
+// newDrainRate returns the drain rate configured by the WithMaxProjectedWait
+// option, or nil if the option was not provided.
+func newDrainRate(opts options) *drainRate {
+	if opts.maxProjectedWait <= 0 || opts.projectedWaitWindow <= 0 {
+		return nil
+	}
+
+	return &drainRate{
+		clock:   opts.clock,
+		maxWait: opts.maxProjectedWait,
+		window:  opts.projectedWaitWindow,
+	}
+}
+
+// observe records the size of the queue after an insertion.
+func (r *drainRate) observe(size int) {
+	if r == nil {
+		return
+	}
+
+	r.roll()
+	r.setSize(size)
+}
+
+// removed records the retrieval of n elements, size being the size of the
+// queue afterwards.
+func (r *drainRate) removed(n, size int) {
+	if r == nil || n == 0 {
+		return
+	}
+
+	r.roll()
+
+	r.drained += n
+	r.backlogged = true
+
+	r.setSize(size)
+}
+
+// admit returns a ProjectedWaitError if an element inserted into a queue of
+// the given size would wait longer than the maximum wait. The elements are
+// admitted until the drain rate is measured.
+func (r *drainRate) admit(size int) error {
+	if r == nil {
+		return nil
+	}
+
+	r.roll()
+	r.setSize(size)
+
+	if !r.measured || size == 0 {
+		return nil
+	}
+
+	projected := time.Duration(math.MaxInt64)
+
+	if seconds := float64(size) / r.rate; seconds < projected.Seconds() {
+		projected = time.Duration(seconds * float64(time.Second))
+	}
+
+	if projected <= r.maxWait {
+		return nil
+	}
+
+	return &ProjectedWaitError{Projected: projected, MaxWait: r.maxWait}
+}
+
+// setSize records the size of the queue.
+func (r *drainRate) setSize(size int) {
+	r.size = size
+
+	if size > 0 {
+		r.backlogged = true
+	}
+}
+
+// roll folds the elapsed windows into the drain rate. The windows during
+// which the queue stayed empty are skipped, while the ones during which it
+// held elements without any being retrieved lower the rate.
+func (r *drainRate) roll() {
+	now := r.clock.Now()
+
+	if r.start.IsZero() {
+		r.start = now
+
+		return
+	}
+
+	windows := now.Sub(r.start) / r.window
+	if windows <= 0 {
+		return
+	}
+
+	r.fold()
+
+	// no event happened during the following windows, thus the queue held
+	// its last observed size.
+	if idle := windows - 1; idle > 0 && r.measured && r.size > 0 {
+		r.rate *= math.Pow(1-drainRateSmoothing, float64(idle))
+	}
+
+	r.start = r.start.Add(windows * r.window)
+	r.drained = 0
+	r.backlogged = r.size > 0
+}
+
+// fold folds the current window into the drain rate.
+func (r *drainRate) fold() {
+	if !r.backlogged || (!r.measured && r.drained == 0) {
+		return
+	}
+
+	sample := float64(r.drained) / r.window.Seconds()
+
+	if !r.measured {
+		r.rate = sample
+		r.measured = true
+
+		return
+	}
+
+	r.rate = drainRateSmoothing*sample + (1-drainRateSmoothing)*r.rate
+}
@@ -0,0 +1,205 @@
+package queue_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/adrianbrad/queue"
+)
+
+func TestWithMaxProjectedWait(t *testing.T) {
+	t.Parallel()
+
+	const (
+		maxWait = 2 * time.Second
+		window  = time.Second
+	)
+
+	newProjected := func(clock *fakeClock) *queue.Blocking[int] {
+		return queue.NewBlocking([]int{}, queue.WithMaxProjectedWait(maxWait, window), queue.WithClock(clock))
+	}
+
+	// drainWindow retrieves n elements and moves the clock to the next window.
+	drainWindow := func(t *testing.T, clock *fakeClock, blockingQueue *queue.Blocking[int], n int) {
+		t.Helper()
+
+		for i := 0; i < n; i++ {
+			if _, err := blockingQueue.Get(); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+		}
+
+		clock.Advance(window)
+	}
+
+	// steadyWindow retrieves and re-inserts n elements, keeping the size of
+	// the queue, and moves the clock to the next window.
+	steadyWindow := func(t *testing.T, clock *fakeClock, blockingQueue *queue.Blocking[int], n int) {
+		t.Helper()
+
+		for i := 0; i < n; i++ {
+			elem, err := blockingQueue.Get()
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if err := blockingQueue.OfferWaitErr(elem); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+		}
+
+		clock.Advance(window)
+	}
+
+	t.Run("ColdStart", func(t *testing.T) {
+		t.Parallel()
+
+		clock := newFakeClock()
+
+		blockingQueue := newProjected(clock)
+
+		// no element was retrieved yet, thus every element is admitted.
+		for i := 0; i < 100; i++ {
+			if err := blockingQueue.Offer(i); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			clock.Advance(window)
+		}
+	})
+
+	t.Run("ScriptedRate", func(t *testing.T) {
+		t.Parallel()
+
+		clock := newFakeClock()
+
+		blockingQueue := newProjected(clock)
+
+		for i := 0; i < 100; i++ {
+			_ = blockingQueue.Offer(i)
+		}
+
+		// 10 elements per second.
+		for i := 0; i < 3; i++ {
+			drainWindow(t, clock, blockingQueue, 10)
+		}
+
+		err := blockingQueue.Offer(100)
+		if !errors.Is(err, queue.ErrWouldExceedWait) {
+			t.Fatalf("expected error to be %v, got %v", queue.ErrWouldExceedWait, err)
+		}
+
+		var projectedErr *queue.ProjectedWaitError
+
+		if !errors.As(err, &projectedErr) {
+			t.Fatalf("expected a ProjectedWaitError, got %T", err)
+		}
+
+		// 70 elements retrieved at 10 elements per second.
+		if projectedErr.Projected != 7*time.Second || projectedErr.MaxWait != maxWait {
+			t.Fatalf("expected a projection of 7s over 2s, got %s over %s", projectedErr.Projected, projectedErr.MaxWait)
+		}
+
+		if _, err := blockingQueue.TryOffer(100); !errors.Is(err, queue.ErrWouldExceedWait) {
+			t.Fatalf("expected TryOffer error to be %v, got %v", queue.ErrWouldExceedWait, err)
+		}
+
+		if err := blockingQueue.OfferAll(100, 101); !errors.Is(err, queue.ErrWouldExceedWait) {
+			t.Fatalf("expected OfferAll error to be %v, got %v", queue.ErrWouldExceedWait, err)
+		}
+
+		if _, err := blockingQueue.OfferHandle(100); !errors.Is(err, queue.ErrWouldExceedWait) {
+			t.Fatalf("expected OfferHandle error to be %v, got %v", queue.ErrWouldExceedWait, err)
+		}
+
+		// the waiting and urgent insertions are not affected.
+		if err := blockingQueue.OfferWaitErr(100); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if err := blockingQueue.OfferUrgent(101); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if size := blockingQueue.Size(); size != 72 {
+			t.Fatalf("expected size to be 72, got %d", size)
+		}
+	})
+
+	t.Run("StalledAndRecovered", func(t *testing.T) {
+		t.Parallel()
+
+		clock := newFakeClock()
+
+		blockingQueue := newProjected(clock)
+
+		for i := 0; i < 5; i++ {
+			_ = blockingQueue.Offer(i)
+		}
+
+		for i := 0; i < 3; i++ {
+			steadyWindow(t, clock, blockingQueue, 10)
+		}
+
+		if err := blockingQueue.Offer(5); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		// the consumer stalls, the drain rate drops with every window.
+		var projectedErr *queue.ProjectedWaitError
+
+		for windows := 0; !errors.As(blockingQueue.Offer(6), &projectedErr); windows++ {
+			if windows == 5 {
+				t.Fatalf("expected the stalled consumer to cause rejections")
+			}
+
+			clock.Advance(window)
+		}
+
+		if projectedErr.Projected <= maxWait {
+			t.Fatalf("expected the projection to exceed %s, got %s", maxWait, projectedErr.Projected)
+		}
+
+		// the consumer recovers.
+		for windows := 0; blockingQueue.Offer(6) != nil; windows++ {
+			if windows == 3 {
+				t.Fatalf("expected the recovered consumer to re-admit the elements")
+			}
+
+			steadyWindow(t, clock, blockingQueue, 40)
+		}
+	})
+
+	t.Run("IdleWindows", func(t *testing.T) {
+		t.Parallel()
+
+		clock := newFakeClock()
+
+		blockingQueue := newProjected(clock)
+
+		for i := 0; i < 30; i++ {
+			_ = blockingQueue.Offer(i)
+		}
+
+		for i := 0; i < 3; i++ {
+			drainWindow(t, clock, blockingQueue, 10)
+		}
+
+		// the windows during which the queue stays empty keep the rate.
+		clock.Advance(10 * window)
+
+		admitted := 0
+
+		for i := 0; i < 25; i++ {
+			if err := blockingQueue.Offer(i); err == nil {
+				admitted++
+			}
+		}
+
+		// up to 20 elements are retrieved within 2s at 10 elements per second.
+		if admitted != 21 {
+			t.Fatalf("expected 21 elements to be admitted, got %d", admitted)
+		}
+	})
+}
@@ -0,0 +1,62 @@
+package queue
+
+import (
+	"context"
+	"time"
+)
+
+// elementMeta is the metadata of an element of a Blocking queue.
+type elementMeta struct {
+	// stamp identifies the elements inserted using OfferHandle,
+	// it is 0 for the other elements.
+	stamp uint64
+
+	// value is the value extracted from the context passed to OfferCtx,
+	// nil for the other elements.
+	value any
+
+	// seq is the sequence ID of the element in the ledger, 0 unless the
+	// WithConservationChecks option is provided.
+	seq uint64
+
+	// enqueued is the time at which the element was inserted, the zero time
+	// unless the WithEnqueueTimes option is provided.
+	enqueued time.Time
+}
+
+// isZero returns true if the element carries no metadata.
+func (m elementMeta) isZero() bool {
+	return m.stamp == 0 && m.value == nil && m.seq == 0 && m.enqueued.IsZero()
+}
+
+// propagator carries values from the producer contexts to the consumer
+// contexts, as provided using the WithContextPropagation option.
+type propagator struct {
+	extract func(context.Context) any
+	inject  func(context.Context, any) context.Context
+}
+
+// enabled returns true if the WithContextPropagation option was provided.
+func (p propagator) enabled() bool {
+	return p.extract != nil && p.inject != nil
+}
+
+// extractFrom returns the value to be stored along with an element offered
+// using ctx, nil if there is none.
+func (p propagator) extractFrom(ctx context.Context) any {
+	if !p.enabled() {
+		return nil
+	}
+
+	return p.extract(ctx)
+}
+
+// injectInto returns base carrying the value stored along with an element,
+// base itself if there is none.
+func (p propagator) injectInto(base context.Context, value any) context.Context {
+	if !p.enabled() || value == nil {
+		return base
+	}
+
+	return p.inject(base, value)
+}
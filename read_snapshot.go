@@ -0,0 +1,68 @@
+package queue
+
+import (
+	"sync/atomic"
+)
+
+// readSnapshots publishes the size and the head of a queue created with the
+// WithReadSnapshotting option every time the queue lock is released for
+// writing, so that Size, IsEmpty, Peek and the fast path of Contains load
+// them without acquiring the lock. A nil readSnapshots publishes nothing.
+type readSnapshots[T comparable] struct {
+	current atomic.Pointer[readSnapshot[T]]
+
+	// version is the version of the last published snapshot, it is only
+	// accessed while holding the queue lock for writing.
+	version uint64
+}
+
+// readSnapshot is the size and the head of a queue, along with the error
+// Peek returned, as of version. The snapshots are immutable.
+type readSnapshot[T comparable] struct {
+	version uint64
+	size    int
+	head    T
+	err     error
+}
+
+// newReadSnapshots returns the read snapshots of a queue, or nil if the
+// WithReadSnapshotting option was not provided.
+func newReadSnapshots[T comparable](opts options) *readSnapshots[T] {
+	if !opts.readSnapshotting {
+		return nil
+	}
+
+	return &readSnapshots[T]{}
+}
+
+// publish publishes the size and the head of the queue, along with the error
+// Peek returned. It must be called while holding the queue lock for writing.
+func (s *readSnapshots[T]) publish(size int, head T, err error) {
+	s.version++
+
+	s.current.Store(&readSnapshot[T]{
+		version: s.version,
+		size:    size,
+		head:    head,
+		err:     err,
+	})
+}
+
+// load returns the last published snapshot.
+func (s *readSnapshots[T]) load() *readSnapshot[T] {
+	return s.current.Load()
+}
+
+// contains returns true, and true for the answer being known, if the head
+// of the snapshot is the element, false and true if the snapshot is empty,
+// and false for the answer being unknown otherwise.
+func (s *readSnapshot[T]) contains(elem T) (found, known bool) {
+	switch {
+	case s.size == 0:
+		return false, true
+	case s.head == elem:
+		return true, true
+	default:
+		return false, false
+	}
+}
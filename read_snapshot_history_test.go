@@ -0,0 +1,124 @@
+package queue
+
+import (
+	"errors"
+	"sync"
+	"testing"
+)
+
+func TestReadSnapshotsHistory(t *testing.T) {
+	t.Parallel()
+
+	const offers = 3000
+
+	// state is the size and the head of a queue.
+	type state struct {
+		size int
+		head int
+	}
+
+	// the writer offers every element, retrieving the head after two of
+	// every three of them, thus the history of the queue states is known.
+	history := map[state]bool{{}: true}
+
+	var model []int
+
+	for i := 0; i < offers; i++ {
+		model = append(model, i)
+		history[state{len(model), model[0]}] = true
+
+		if i%3 != 0 {
+			model = model[1:]
+
+			if len(model) > 0 {
+				history[state{len(model), model[0]}] = true
+			} else {
+				history[state{}] = true
+			}
+		}
+	}
+
+	testCases := map[string]func() (Queue[int], *readSnapshots[int]){
+		"Blocking": func() (Queue[int], *readSnapshots[int]) {
+			q := NewBlocking([]int{}, WithReadSnapshotting())
+
+			return q, q.snapshots
+		},
+		"Circular": func() (Queue[int], *readSnapshots[int]) {
+			q := NewCircular([]int{}, offers, WithReadSnapshotting())
+
+			return q, q.snapshots
+		},
+	}
+
+	for impl, newQueue := range testCases {
+		newQueue := newQueue
+
+		t.Run(impl, func(t *testing.T) {
+			t.Parallel()
+
+			q, snapshots := newQueue()
+
+			done := make(chan struct{})
+
+			var wg sync.WaitGroup
+
+			for r := 0; r < 4; r++ {
+				wg.Add(1)
+
+				go func() {
+					defer wg.Done()
+
+					var version uint64
+
+					for {
+						select {
+						case <-done:
+							return
+						default:
+						}
+
+						s := snapshots.load()
+
+						if s.version < version {
+							t.Errorf("expected the versions not to decrease, got %d after %d", s.version, version)
+
+							return
+						}
+
+						version = s.version
+
+						observed := state{s.size, s.head}
+						if s.size == 0 {
+							observed.head = 0
+						}
+
+						if !history[observed] || (s.size == 0) != errors.Is(s.err, ErrNoElementsAvailable) {
+							t.Errorf("expected the snapshot to be a past state of the queue, got %+v", s)
+
+							return
+						}
+					}
+				}()
+			}
+
+			for i := 0; i < offers; i++ {
+				if err := q.Offer(i); err != nil {
+					t.Errorf("expected no error, got %v", err)
+				}
+
+				if i%3 != 0 {
+					if _, err := q.Get(); err != nil {
+						t.Errorf("expected no error, got %v", err)
+					}
+				}
+			}
+
+			close(done)
+			wg.Wait()
+			if s := snapshots.load(); s.size != len(model) || s.head != model[0] {
+				t.Fatalf("expected the last state to be published, got size %d and head %d", s.size, s.head)
+			}
+		})
+	}
+}
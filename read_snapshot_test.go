@@ -0,0 +1,169 @@
+package queue_test
+
+import (
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/adrianbrad/queue"
+)
+
+func TestWithReadSnapshotting(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]func(elems []int, opts ...queue.Option) queue.Queue[int]{
+		"Blocking": func(elems []int, opts ...queue.Option) queue.Queue[int] {
+			return queue.NewBlocking(elems, opts...)
+		},
+		"Circular": func(elems []int, opts ...queue.Option) queue.Queue[int] {
+			return queue.NewCircular(elems, 4, opts...)
+		},
+	}
+
+	for impl, newQueue := range testCases {
+		newQueue := newQueue
+
+		t.Run(impl, func(t *testing.T) {
+			t.Parallel()
+
+			t.Run("Reads", func(t *testing.T) {
+				t.Parallel()
+
+				q := newQueue([]int{1, 2}, queue.WithReadSnapshotting())
+
+				if elem, err := q.Peek(); err != nil || elem != 1 {
+					t.Fatalf("expected head to be 1, got %d, %v", elem, err)
+				}
+
+				// the mutations of the calling goroutine are published.
+				if _, err := q.Get(); err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+
+				if err := q.Offer(3); err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+
+				if elem, err := q.Peek(); err != nil || elem != 2 {
+					t.Fatalf("expected head to be 2, got %d, %v", elem, err)
+				}
+
+				if q.Size() != 2 || q.IsEmpty() {
+					t.Fatalf("expected size to be 2, got %d", q.Size())
+				}
+
+				// the element is not the head, the queue is scanned.
+				if !q.Contains(2) || !q.Contains(3) || q.Contains(1) {
+					t.Fatalf("expected the queue to contain only [2 3]")
+				}
+
+				q.Clear()
+
+				if _, err := q.Peek(); !errors.Is(err, queue.ErrNoElementsAvailable) {
+					t.Fatalf("expected error to be %v, got %v", queue.ErrNoElementsAvailable, err)
+				}
+
+				if q.Size() != 0 || !q.IsEmpty() || q.Contains(2) {
+					t.Fatalf("expected queue to be empty, got size %d", q.Size())
+				}
+
+				q.Reset()
+
+				if elem, err := q.Peek(); err != nil || elem != 1 {
+					t.Fatalf("expected head to be 1, got %d, %v", elem, err)
+				}
+			})
+
+			t.Run("PrecedesCachedReads", func(t *testing.T) {
+				t.Parallel()
+
+				q := newQueue([]int{1}, queue.WithCachedReads(time.Hour), queue.WithReadSnapshotting())
+
+				_ = q.Size()
+
+				if err := q.Offer(2); err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+
+				if q.Size() != 2 {
+					t.Fatalf("expected size to be 2, got %d", q.Size())
+				}
+			})
+		})
+	}
+
+	t.Run("BlockingClosed", func(t *testing.T) {
+		t.Parallel()
+
+		blockingQueue := queue.NewBlocking([]int{}, queue.WithReadSnapshotting())
+
+		blockingQueue.Close()
+
+		if _, err := blockingQueue.Peek(); !errors.Is(err, queue.ErrQueueClosed) {
+			t.Fatalf("expected error to be %v, got %v", queue.ErrQueueClosed, err)
+		}
+	})
+}
+
+func BenchmarkReadSnapshotting(b *testing.B) {
+	testCases := map[string][]queue.Option{
+		"Locked":      nil,
+		"Snapshotted": {queue.WithReadSnapshotting()},
+	}
+
+	for name, opts := range testCases {
+		opts := opts
+
+		// the p99 latency of Peek is reported while writers contend for the
+		// queue lock.
+		b.Run(name+"/Peek_WriterStorm", func(b *testing.B) {
+			blockingQueue := queue.NewBlocking([]int{1}, opts...)
+
+			stop := make(chan struct{})
+
+			var wg sync.WaitGroup
+
+			for i := 0; i < 4; i++ {
+				wg.Add(1)
+
+				go func() {
+					defer wg.Done()
+
+					for {
+						select {
+						case <-stop:
+							return
+						default:
+							_ = blockingQueue.Offer(1)
+							_, _ = blockingQueue.Get()
+						}
+					}
+				}()
+			}
+
+			latencies := make([]time.Duration, b.N)
+
+			b.ReportAllocs()
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				start := time.Now()
+
+				_, _ = blockingQueue.Peek()
+
+				latencies[i] = time.Since(start)
+			}
+
+			b.StopTimer()
+
+			close(stop)
+			wg.Wait()
+
+			slices.Sort(latencies)
+
+			b.ReportMetric(float64(latencies[len(latencies)*99/100].Nanoseconds()), "p99-ns/op")
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package queue
+
+// recycler returns the elements discarded by a queue to the pool they were
+// acquired from, as specified using the WithRecycler option.
+// The zero recycler does nothing.
+type recycler[T any] struct {
+	acquire func() T
+	release func(T)
+}
+
+// recyclerOf returns the recycler provided using WithRecycler, or the zero
+// recycler if none was provided.
+func recyclerOf[T any](opts options) recycler[T] {
+	if opts.recycler == nil {
+		return recycler[T]{}
+	}
+
+	r, ok := opts.recycler.(recycler[T])
+	if !ok {
+		panic("recycler type does not match the queue element type")
+	}
+
+	return r
+}
+
+// enabled returns true if the WithRecycler option was provided.
+func (r recycler[T]) enabled() bool {
+	return r.release != nil
+}
+
+// discard releases an element dropped by the queue.
+func (r recycler[T]) discard(elem T) {
+	if r.release != nil {
+		r.release(elem)
+	}
+}
+
+// discardAll releases the elements dropped by the queue.
+func (r recycler[T]) discardAll(elems []T) {
+	if r.release == nil {
+		return
+	}
+
+	for _, elem := range elems {
+		r.release(elem)
+	}
+}
+
+// offerOrDiscard returns an offer function which releases the elements
+// rejected by the given offer function.
+func (r recycler[T]) offerOrDiscard(offer func(T) error) func(T) error {
+	if r.release == nil {
+		return offer
+	}
+
+	return func(elem T) error {
+		err := offer(elem)
+		if err != nil {
+			r.release(elem)
+		}
+
+		return err
+	}
+}
+
+// discarder is implemented by the queues, allowing the package functions
+// retrieving elements from a queue to release the elements they drop.
+type discarder[T any] interface {
+	discard(elem T)
+}
+
+// discardFrom releases the element dropped by a package function, if the
+// queue it was retrieved from was created using the WithRecycler option.
+func discardFrom[T any](q any, elem T) {
+	if d, ok := q.(discarder[T]); ok {
+		d.discard(elem)
+	}
+}
@@ -0,0 +1,306 @@
+package queue_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/adrianbrad/queue"
+)
+
+// pooled is an element recycled using a countingPool.
+type pooled struct {
+	ID int `json:"id"`
+}
+
+// countingPool tracks the elements it creates, along with the number of times
+// each of them is released and whether it was handed to the caller.
+type countingPool struct {
+	lock     sync.Mutex
+	created  []*pooled
+	released map[*pooled]int
+	handed   map[*pooled]bool
+	// templates are the clones of the initial elements kept by the queue.
+	templates map[*pooled]bool
+}
+
+func newCountingPool() *countingPool {
+	return &countingPool{
+		released:  make(map[*pooled]int),
+		handed:    make(map[*pooled]bool),
+		templates: make(map[*pooled]bool),
+	}
+}
+
+func (p *countingPool) acquire() *pooled {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	elem := &pooled{ID: len(p.created)}
+
+	p.created = append(p.created, elem)
+
+	return elem
+}
+
+func (p *countingPool) release(elem *pooled) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.released[elem]++
+}
+
+func (p *countingPool) clone(elem *pooled) *pooled {
+	cloned := p.acquire()
+
+	cloned.ID = elem.ID
+
+	return cloned
+}
+
+// hand records the elements handed to the caller.
+func (p *countingPool) hand(elems ...*pooled) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	for _, elem := range elems {
+		p.handed[elem] = true
+	}
+}
+
+// markTemplates records the elements created so far which are not owned by
+// the caller as the templates of the queue initial elements.
+func (p *countingPool) markTemplates(initial []*pooled) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	owned := make(map[*pooled]bool, len(initial))
+
+	for _, elem := range initial {
+		owned[elem] = true
+	}
+
+	for _, elem := range p.created {
+		if !owned[elem] {
+			p.templates[elem] = true
+		}
+	}
+}
+
+// assertExactlyOnce checks that every element which is not a template was
+// either handed to the caller or released, exactly once.
+func (p *countingPool) assertExactlyOnce(t *testing.T) {
+	t.Helper()
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	for _, elem := range p.created {
+		released := p.released[elem]
+
+		switch {
+		case p.templates[elem]:
+			if released != 0 || p.handed[elem] {
+				t.Fatalf("expected template %d to be kept, released %d times", elem.ID, released)
+			}
+		case p.handed[elem]:
+			if released != 0 {
+				t.Fatalf("expected handed element %d not to be released, released %d times", elem.ID, released)
+			}
+		case released != 1:
+			t.Fatalf("expected element %d to be released once, released %d times", elem.ID, released)
+		}
+	}
+}
+
+// recycledQueue is implemented by all the queues.
+type recycledQueue interface {
+	Offer(*pooled) error
+	Get() (*pooled, error)
+	Reset()
+	Clear() []*pooled
+}
+
+// newRecycledQueue returns the queue of the given kind, along with a function
+// unmarshalling a JSON array into it.
+func newRecycledQueue(
+	kind byte,
+	pool *countingPool,
+	initial []*pooled,
+) (q recycledQueue, unmarshal func(string) error) {
+	const capacity = 3
+
+	opts := []queue.Option{
+		queue.WithRecycler(pool.acquire, pool.release),
+		queue.WithResetCloner(pool.clone),
+	}
+
+	lessPooled := func(elem, otherElem *pooled) bool { return elem.ID < otherElem.ID }
+
+	switch kind % 4 {
+	case 0:
+		blockingQueue := queue.NewBlocking(initial, append(opts, queue.WithCapacity(capacity))...)
+
+		return blockingQueue, func(s string) error { return blockingQueue.UnmarshalJSONFrom(strings.NewReader(s)) }
+	case 1:
+		circularQueue := queue.NewCircular(initial, capacity, opts...)
+
+		return circularQueue, func(s string) error { return circularQueue.UnmarshalJSONFrom(strings.NewReader(s)) }
+	case 2:
+		linkedQueue := queue.NewLinked(initial, opts...)
+
+		return linkedQueue, func(s string) error { return linkedQueue.UnmarshalJSONFrom(strings.NewReader(s)) }
+	default:
+		priorityQueue := queue.NewPriority(initial, lessPooled, append(opts, queue.WithCapacity(capacity))...)
+
+		return priorityQueue, func(s string) error { return priorityQueue.UnmarshalJSONFrom(strings.NewReader(s)) }
+	}
+}
+
+func FuzzRecycler(f *testing.F) {
+	testcases := []struct {
+		kind byte
+		ops  []byte
+	}{
+		{kind: 0, ops: []byte{0, 0, 1, 2, 0, 4, 3, 0, 2, 1}},
+		{kind: 1, ops: []byte{0, 0, 0, 0, 1, 2, 4, 4, 0, 3, 2}},
+		{kind: 2, ops: []byte{0, 1, 2, 4, 0, 0, 3, 2, 1}},
+		{kind: 3, ops: []byte{0, 0, 4, 1, 2, 0, 3, 4, 2}},
+	}
+
+	for _, tc := range testcases {
+		f.Add(tc.kind, tc.ops)
+	}
+
+	f.Fuzz(func(t *testing.T, kind byte, ops []byte) {
+		pool := newCountingPool()
+
+		// one more element than the capacity of the bounded queues.
+		initial := []*pooled{pool.acquire(), pool.acquire(), pool.acquire(), pool.acquire()}
+
+		q, unmarshal := newRecycledQueue(kind, pool, initial)
+
+		pool.markTemplates(initial)
+
+		for _, op := range ops {
+			switch op % 5 {
+			case 0:
+				// the rejected elements are still owned by the caller.
+				if elem := pool.acquire(); q.Offer(elem) != nil {
+					pool.hand(elem)
+				}
+			case 1:
+				if elem, err := q.Get(); err == nil {
+					pool.hand(elem)
+				}
+			case 2:
+				q.Reset()
+			case 3:
+				pool.hand(q.Clear()...)
+			case 4:
+				// the decoded elements are acquired from the pool.
+				_ = unmarshal(`[{"id":-1},{"id":-2},{"id":-3},{"id":-4}]`)
+			}
+		}
+
+		pool.hand(q.Clear()...)
+
+		pool.assertExactlyOnce(t)
+	})
+}
+
+func TestRecycler(t *testing.T) {
+	t.Parallel()
+
+	t.Run("HandedElements", func(t *testing.T) {
+		t.Parallel()
+
+		pool := newCountingPool()
+
+		blockingQueue := queue.NewBlocking(
+			[]*pooled{pool.acquire(), pool.acquire(), pool.acquire()},
+			queue.WithRecycler(pool.acquire, pool.release),
+		)
+
+		exchanged, err := blockingQueue.Exchange(pool.acquire())
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		pool.hand(exchanged)
+
+		if _, err := blockingQueue.MarshalJSON(); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		// the elements salvaged by Destroy are handed to the caller.
+		pool.hand(blockingQueue.Destroy()...)
+
+		if err := blockingQueue.Offer(pool.acquire()); !errors.Is(err, queue.ErrQueueDestroyed) {
+			t.Fatalf("expected error to be %v, got %v", queue.ErrQueueDestroyed, err)
+		}
+
+		// the rejected element is still owned by the caller.
+		pool.hand(pool.created[len(pool.created)-1])
+
+		pool.assertExactlyOnce(t)
+	})
+
+	t.Run("InvalidJSON", func(t *testing.T) {
+		t.Parallel()
+
+		pool := newCountingPool()
+
+		linkedQueue := queue.NewLinked([]*pooled{}, queue.WithRecycler(pool.acquire, pool.release))
+
+		if err := linkedQueue.UnmarshalJSONFrom(strings.NewReader(`[{"id":1},{"id":"2"}]`)); err == nil {
+			t.Fatalf("expected an error")
+		}
+
+		pool.hand(linkedQueue.Clear()...)
+
+		// the element failing to decode is released.
+		pool.assertExactlyOnce(t)
+
+		if len(pool.created) != 2 {
+			t.Fatalf("expected 2 acquired elements, got %d", len(pool.created))
+		}
+	})
+
+	t.Run("ProcessEachDropped", func(t *testing.T) {
+		t.Parallel()
+
+		pool := newCountingPool()
+
+		linkedQueue := queue.NewLinked(
+			[]*pooled{pool.acquire(), pool.acquire()},
+			queue.WithRecycler(pool.acquire, pool.release),
+		)
+
+		report := queue.ProcessEach[*pooled](
+			context.Background(),
+			linkedQueue,
+			func(context.Context, *pooled) error { return errors.New("failed") },
+		)
+
+		if report.Dropped != 2 {
+			t.Fatalf("expected 2 dropped elements, got %d", report.Dropped)
+		}
+
+		pool.assertExactlyOnce(t)
+	})
+
+	t.Run("MismatchedType", func(t *testing.T) {
+		t.Parallel()
+
+		defer func() {
+			if r := recover(); r == nil {
+				t.Fatalf("expected panic")
+			}
+		}()
+
+		queue.NewLinked([]int{}, queue.WithRecycler(func() string { return "" }, func(string) {}))
+	})
+}
@@ -0,0 +1,117 @@
+package queue_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/adrianbrad/queue"
+)
+
+func TestWithResetBehavior(t *testing.T) {
+	t.Parallel()
+
+	lessAscending := func(elem, otherElem int) bool { return elem < otherElem }
+
+	testCases := map[string]func(elems []int, opts ...queue.Option) queue.Queue[int]{
+		"Blocking": func(elems []int, opts ...queue.Option) queue.Queue[int] {
+			return queue.NewBlocking(elems, opts...)
+		},
+		"Circular": func(elems []int, opts ...queue.Option) queue.Queue[int] {
+			return queue.NewCircular(elems, 5, opts...)
+		},
+		"Linked": func(elems []int, opts ...queue.Option) queue.Queue[int] {
+			return queue.NewLinked(elems, opts...)
+		},
+		"Priority": func(elems []int, opts ...queue.Option) queue.Queue[int] {
+			return queue.NewPriority(elems, lessAscending, opts...)
+		},
+		"StablePriority": func(elems []int, opts ...queue.Option) queue.Queue[int] {
+			return queue.NewPriority(elems, lessAscending, append(opts, queue.WithStableOrder())...)
+		},
+		"Deque": func(elems []int, opts ...queue.Option) queue.Queue[int] {
+			return queue.NewDeque(elems, opts...)
+		},
+	}
+
+	for impl, newQueue := range testCases {
+		newQueue := newQueue
+
+		t.Run(impl, func(t *testing.T) {
+			t.Parallel()
+
+			t.Run("ResetEmpty", func(t *testing.T) {
+				t.Parallel()
+
+				q := newQueue([]int{1, 2, 3}, queue.WithResetBehavior(queue.ResetEmpty))
+
+				// the initial elements are held until Reset.
+				if size := q.Size(); size != 3 {
+					t.Fatalf("expected size to be 3, got %d", size)
+				}
+
+				if err := q.Offer(4); err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+
+				q.Reset()
+
+				if !q.IsEmpty() {
+					t.Fatalf("expected queue to be empty, got %v", q.Clear())
+				}
+
+				if err := q.Offer(5); err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+
+				if elem, err := q.Get(); err != nil || elem != 5 {
+					t.Fatalf("expected elem to be 5, got %d, %v", elem, err)
+				}
+			})
+
+			t.Run("ResetInitial", func(t *testing.T) {
+				t.Parallel()
+
+				q := newQueue([]int{1, 2, 3}, queue.WithResetBehavior(queue.ResetInitial))
+
+				_, _ = q.Get()
+
+				q.Reset()
+
+				if size := q.Size(); size != 3 {
+					t.Fatalf("expected size to be 3, got %d", size)
+				}
+			})
+		})
+	}
+
+	t.Run("BlockingWaiters", func(t *testing.T) {
+		t.Parallel()
+
+		blockingQueue := queue.NewBlocking([]int{}, queue.WithResetBehavior(queue.ResetEmpty))
+
+		result := make(chan int)
+
+		go func() {
+			result <- blockingQueue.GetWait()
+		}()
+
+		time.Sleep(time.Millisecond)
+
+		blockingQueue.Reset()
+
+		// the consumer keeps waiting.
+		select {
+		case elem := <-result:
+			t.Fatalf("expected the consumer to wait, got %d", elem)
+		case <-time.After(10 * time.Millisecond):
+		}
+
+		if err := blockingQueue.Offer(1); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if elem := <-result; elem != 1 {
+			t.Fatalf("expected elem to be 1, got %d", elem)
+		}
+	})
+}
@@ -0,0 +1,143 @@
+package queue
+
+import (
+	"context"
+)
+
+// Semaphore is a counting semaphore backed by a Blocking queue holding its
+// available tokens: acquiring tokens removes them from the queue and
+// releasing them offers them back, the capacity of the queue being the size
+// of the semaphore. The acquisitions waiting for tokens are woken up once
+// tokens are released, no order being guaranteed among them.
+type Semaphore struct {
+	tokens *Blocking[struct{}]
+}
+
+// NewSemaphore returns a new Semaphore of size n, holding n available tokens.
+func NewSemaphore(n int) *Semaphore {
+	n = max(n, 0)
+
+	return &Semaphore{
+		tokens: NewBlocking(make([]struct{}, n), WithCapacity(n)),
+	}
+}
+
+// Acquire acquires a token, waiting for one to be released until ctx is
+// done, in which case it returns the ctx error without acquiring a token.
+func (s *Semaphore) Acquire(ctx context.Context) error {
+	return s.AcquireN(ctx, 1)
+}
+
+// AcquireN acquires n tokens at once, waiting for n tokens to be available
+// until ctx is done, in which case it returns the ctx error without
+// acquiring any token. If n is greater than the size of the semaphore it
+// returns the ErrSemaphoreWeight error.
+func (s *Semaphore) AcquireN(ctx context.Context, n int) error {
+	bq := s.tokens
+
+	if n > bq.Capacity() {
+		return ErrSemaphoreWeight
+	}
+
+	bq.lock.Lock()
+	defer bq.lock.Unlock()
+
+	if ctx.Done() != nil {
+		stop := context.AfterFunc(ctx, func() {
+			bq.lock.Lock()
+			defer bq.lock.Unlock()
+
+			bq.notEmptyCond.Broadcast()
+		})
+		defer stop()
+	}
+
+	for bq.size() < n {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		bq.notEmptyCond.Wait()
+	}
+
+	s.take(n)
+
+	return nil
+}
+
+// TryAcquire acquires a token without waiting and returns true, or returns
+// false if no token is available.
+func (s *Semaphore) TryAcquire() bool {
+	bq := s.tokens
+
+	bq.lock.Lock()
+	defer bq.lock.Unlock()
+
+	if bq.isEmpty() {
+		return false
+	}
+
+	s.take(1)
+
+	return true
+}
+
+// Release releases a token.
+// It panics if more tokens are released than acquired.
+func (s *Semaphore) Release() {
+	s.ReleaseN(1)
+}
+
+// ReleaseN releases n tokens at once.
+// It panics if more tokens are released than acquired, in which case none of
+// the n tokens is released.
+func (s *Semaphore) ReleaseN(n int) {
+	if n <= 0 {
+		return
+	}
+
+	bq := s.tokens
+
+	bq.lock.Lock()
+	defer bq.lock.Unlock()
+
+	if bq.occupancy.admit(n, 0) != nil {
+		panic("queue: semaphore released more tokens than acquired")
+	}
+
+	for i := 0; i < n; i++ {
+		bq.pushBack(struct{}{})
+	}
+
+	bq.version++
+
+	// the acquisitions waiting for several tokens are all checked.
+	bq.notEmptyCond.Broadcast()
+}
+
+// Available returns the number of tokens which can be acquired without
+// waiting.
+func (s *Semaphore) Available() int {
+	return s.tokens.Size()
+}
+
+// Size returns the number of tokens of the semaphore.
+func (s *Semaphore) Size() int {
+	return s.tokens.Capacity()
+}
+
+// take removes n available tokens from the queue.
+// The caller holds the queue lock.
+func (s *Semaphore) take(n int) {
+	if n <= 0 {
+		return
+	}
+
+	bq := s.tokens
+
+	bq.dropFront(n)
+
+	bq.occupancy.releaseAdmission(n, 0)
+
+	bq.version++
+}
@@ -0,0 +1,186 @@
+package queue_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/adrianbrad/queue"
+)
+
+func TestSemaphore(t *testing.T) {
+	t.Parallel()
+
+	t.Run("MaxHolders", func(t *testing.T) {
+		t.Parallel()
+
+		const (
+			size       = 3
+			goroutines = 16
+			iterations = 200
+		)
+
+		semaphore := queue.NewSemaphore(size)
+
+		var (
+			wg      sync.WaitGroup
+			holders atomic.Int64
+			maxSeen atomic.Int64
+		)
+
+		wg.Add(goroutines)
+
+		for g := 0; g < goroutines; g++ {
+			go func(weight int) {
+				defer wg.Done()
+
+				for i := 0; i < iterations; i++ {
+					if err := semaphore.AcquireN(context.Background(), weight); err != nil {
+						t.Errorf("expected no error, got %v", err)
+						return
+					}
+
+					held := holders.Add(int64(weight))
+
+					for {
+						seen := maxSeen.Load()
+						if held <= seen || maxSeen.CompareAndSwap(seen, held) {
+							break
+						}
+					}
+
+					holders.Add(-int64(weight))
+
+					semaphore.ReleaseN(weight)
+				}
+			}(g%2 + 1)
+		}
+
+		wg.Wait()
+
+		if seen := maxSeen.Load(); seen > size {
+			t.Fatalf("expected at most %d tokens held, got %d", size, seen)
+		}
+
+		if available := semaphore.Available(); available != size {
+			t.Fatalf("expected %d available tokens, got %d", size, available)
+		}
+	})
+
+	t.Run("TryAcquire", func(t *testing.T) {
+		t.Parallel()
+
+		semaphore := queue.NewSemaphore(1)
+
+		if !semaphore.TryAcquire() {
+			t.Fatal("expected the token to be acquired")
+		}
+
+		if semaphore.TryAcquire() {
+			t.Fatal("expected no token to be available")
+		}
+
+		semaphore.Release()
+
+		if !semaphore.TryAcquire() {
+			t.Fatal("expected the released token to be acquired")
+		}
+	})
+
+	t.Run("OverRelease", func(t *testing.T) {
+		t.Parallel()
+
+		semaphore := queue.NewSemaphore(2)
+
+		_ = semaphore.Acquire(context.Background())
+
+		defer func() {
+			if recover() == nil {
+				t.Fatal("expected releasing more tokens than acquired to panic")
+			}
+
+			// none of the tokens was released.
+			if available := semaphore.Available(); available != 1 {
+				t.Fatalf("expected 1 available token, got %d", available)
+			}
+		}()
+
+		semaphore.ReleaseN(2)
+	})
+
+	t.Run("WeightExceedingSize", func(t *testing.T) {
+		t.Parallel()
+
+		semaphore := queue.NewSemaphore(2)
+
+		if err := semaphore.AcquireN(context.Background(), 3); !errors.Is(err, queue.ErrSemaphoreWeight) {
+			t.Fatalf("expected error to be %v, got %v", queue.ErrSemaphoreWeight, err)
+		}
+	})
+
+	t.Run("ContextCancelledWhileWaiting", func(t *testing.T) {
+		t.Parallel()
+
+		semaphore := queue.NewSemaphore(2)
+
+		_ = semaphore.Acquire(context.Background())
+
+		ctx, cancel := context.WithCancel(context.Background())
+
+		done := make(chan error)
+
+		go func() {
+			done <- semaphore.AcquireN(ctx, 2)
+		}()
+
+		time.Sleep(10 * time.Millisecond)
+
+		cancel()
+
+		if err := <-done; !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected error to be %v, got %v", context.Canceled, err)
+		}
+
+		// the waiting acquisition took no token.
+		if available := semaphore.Available(); available != 1 {
+			t.Fatalf("expected 1 available token, got %d", available)
+		}
+	})
+
+	t.Run("WeightedWakeUp", func(t *testing.T) {
+		t.Parallel()
+
+		semaphore := queue.NewSemaphore(2)
+
+		_ = semaphore.AcquireN(context.Background(), 2)
+
+		done := make(chan error, 2)
+
+		go func() {
+			done <- semaphore.AcquireN(context.Background(), 2)
+		}()
+
+		go func() {
+			done <- semaphore.Acquire(context.Background())
+		}()
+
+		time.Sleep(10 * time.Millisecond)
+
+		// a single token wakes up the single token acquisition, even if
+		// the weighted acquisition checks it first.
+		semaphore.Release()
+
+		if err := <-done; err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		semaphore.ReleaseN(2)
+
+		if err := <-done; err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	})
+}
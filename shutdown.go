@@ -0,0 +1,181 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// drainPollInterval is the interval at which CloseAll and DrainAll check
+// whether a closed queue is drained.
+const drainPollInterval = time.Millisecond
+
+// ShutdownError is the error returned by CloseAll and DrainAll whenever a
+// stage of the shutdown fails or times out. It wraps the error of the stage,
+// such as the context error.
+type ShutdownError struct {
+	// Stage is the index of the failed stage, in the order given to CloseAll
+	// or DrainAll.
+	Stage int
+
+	// Remaining is the number of elements left in the queue of the stage
+	// when it failed.
+	Remaining int
+
+	// Err is the error of the stage.
+	Err error
+}
+
+// Error returns the stage along with its error.
+func (e *ShutdownError) Error() string {
+	return fmt.Sprintf("shutdown stage %d, %d elements remaining: %v", e.Stage, e.Remaining, e.Err)
+}
+
+// Unwrap returns the error of the stage.
+func (e *ShutdownError) Unwrap() error {
+	return e.Err
+}
+
+// ProcessedBy returns a Closer closing q, which CloseAll considers drained
+// once done is closed, instead of once q is empty. Closing done once the
+// workers consuming q return, such as the goroutines running ProcessEach,
+// which return once a Blocking queue is closed and drained, ensures that the
+// elements they are still processing reach the next stage before it is
+// closed.
+func ProcessedBy(q Closer, done <-chan struct{}) Closer {
+	return processedCloser{Closer: q, done: done}
+}
+
+// processedCloser is a Closer drained once done is closed.
+type processedCloser struct {
+	Closer
+	done <-chan struct{}
+}
+
+// CloseAll shuts down a pipeline of queues, closing them in the given order:
+// each queue is closed, then CloseAll waits for it to be drained before
+// closing the next one, so that the elements of the upstream queues reach the
+// downstream queues while they are still open. A queue is drained once it
+// holds no element, or once the done channel is closed for the queues wrapped
+// using ProcessedBy.
+//
+// If ctx is done before a queue is drained, CloseAll returns a *ShutdownError
+// identifying the queue and wrapping the ctx error, leaving the following
+// queues open.
+func CloseAll(ctx context.Context, order []Closer) error {
+	for i, q := range order {
+		q.Close()
+
+		if err := waitDrained(ctx, q); err != nil {
+			return &ShutdownError{Stage: i, Remaining: sizeOf(q), Err: err}
+		}
+	}
+
+	return nil
+}
+
+// DrainPair is a stage of DrainAll: the elements left in Source once the
+// shutdown deadline is reached are moved to Sink.
+type DrainPair[T comparable] struct {
+	// Source is the queue shut down by the stage.
+	Source Queue[T]
+
+	// Sink receives the elements left in Source.
+	Sink Queue[T]
+
+	// Done, if not nil, is closed once the workers consuming Source return.
+	// The stage is then drained once Done is closed, as for the queues
+	// wrapped using ProcessedBy.
+	Done <-chan struct{}
+}
+
+// DrainAll shuts down a pipeline of queues as CloseAll does, closing the
+// sources which implement Closer in the given order and waiting for each of
+// them to be drained. Once ctx is done, the elements left in the source of
+// the current stage and of the following ones are moved to their sinks using
+// MoveAll, instead of waiting for them to be drained.
+//
+// If the elements of a source cannot all be moved to its sink, DrainAll
+// returns a *ShutdownError identifying the stage and wrapping the error of
+// MoveAll. Otherwise, if ctx was done before the pipeline drained, it returns
+// a *ShutdownError identifying the first stage which did not drain, wrapping
+// the ctx error, all the elements having been moved to the sinks. The
+// elements the workers of a source fail to process after it is moved are not
+// accounted for, they are retried, dead-lettered or dropped as ProcessEach
+// does.
+func DrainAll[T comparable](ctx context.Context, pairs []DrainPair[T]) error {
+	var timedOut error
+
+	for i, pair := range pairs {
+		if q, ok := pair.Source.(Closer); ok {
+			q.Close()
+		}
+
+		var err error
+
+		if pair.Done != nil {
+			err = waitDrained(ctx, processedCloser{done: pair.Done})
+		} else {
+			err = waitDrained(ctx, pair.Source)
+		}
+
+		if err == nil {
+			continue
+		}
+
+		if _, moveErr := MoveAll(pair.Source, pair.Sink); moveErr != nil {
+			return &ShutdownError{Stage: i, Remaining: sizeOf(pair.Source), Err: moveErr}
+		}
+
+		if timedOut == nil {
+			timedOut = &ShutdownError{Stage: i, Err: err}
+		}
+	}
+
+	return timedOut
+}
+
+// waitDrained waits until the queue is drained, or until ctx is done, in
+// which case it returns the ctx error.
+func waitDrained(ctx context.Context, q any) error {
+	if p, ok := q.(processedCloser); ok {
+		select {
+		case <-p.done:
+			return nil
+		default:
+		}
+
+		select {
+		case <-p.done:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	ticker := time.NewTicker(drainPollInterval)
+	defer ticker.Stop()
+
+	for sizeOf(q) > 0 {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return nil
+}
+
+// sizeOf returns the number of elements in the queue, bypassing the cache of
+// the WithCachedReads option, or 0 if the size of the queue is unknown.
+func sizeOf(q any) int {
+	switch q := q.(type) {
+	case processedCloser:
+		return sizeOf(q.Closer)
+	case interface{ Size() int }:
+		return exactSize(q)
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,195 @@
+package queue_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/adrianbrad/queue"
+)
+
+func TestCloseAll(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Pipeline", func(t *testing.T) {
+		t.Parallel()
+
+		const elems = 100
+
+		initial := make([]int, elems)
+		for i := range initial {
+			initial[i] = i
+		}
+
+		stages := []*queue.Blocking[int]{
+			queue.NewBlocking(initial),
+			queue.NewBlocking([]int{}),
+			queue.NewBlocking([]int{}),
+		}
+
+		var (
+			lock      sync.Mutex
+			collected []int
+		)
+
+		order := make([]queue.Closer, len(stages))
+
+		for i, stage := range stages {
+			stage, done := stage, make(chan struct{})
+
+			order[i] = queue.ProcessedBy(stage, done)
+
+			fn := func(_ context.Context, elem int) error {
+				lock.Lock()
+				defer lock.Unlock()
+
+				collected = append(collected, elem)
+
+				return nil
+			}
+
+			if i < len(stages)-1 {
+				next := stages[i+1]
+
+				fn = func(_ context.Context, elem int) error {
+					// the elements reach the next stage before it is closed.
+					return next.Offer(elem)
+				}
+			}
+
+			go func() {
+				defer close(done)
+
+				queue.ProcessEach[int](context.Background(), stage, fn, queue.WithConcurrency(4))
+			}()
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		if err := queue.CloseAll(ctx, order); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		sort.Ints(collected)
+
+		if !reflect.DeepEqual(initial, collected) {
+			t.Fatalf("expected all the elements to be processed, got %v", collected)
+		}
+	})
+
+	t.Run("ContextExpired", func(t *testing.T) {
+		t.Parallel()
+
+		// the second queue has no consumer, it never drains.
+		first, second, third := queue.NewBlocking([]int{}), queue.NewBlocking([]int{1, 2}), queue.NewBlocking([]int{})
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		err := queue.CloseAll(ctx, []queue.Closer{first, second, third})
+
+		var shutdownErr *queue.ShutdownError
+		if !errors.As(err, &shutdownErr) {
+			t.Fatalf("expected a ShutdownError, got %v", err)
+		}
+
+		if shutdownErr.Stage != 1 || shutdownErr.Remaining != 2 {
+			t.Fatalf("expected stage 1 to time out with 2 elements remaining, got %+v", shutdownErr)
+		}
+
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("expected error to be %v, got %v", context.DeadlineExceeded, err)
+		}
+
+		if err := second.Offer(3); !errors.Is(err, queue.ErrQueueClosed) {
+			t.Fatalf("expected the second queue to be closed, got %v", err)
+		}
+
+		if err := third.Offer(3); err != nil {
+			t.Fatalf("expected the third queue to stay open, got %v", err)
+		}
+	})
+}
+
+func TestDrainAll(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Drained", func(t *testing.T) {
+		t.Parallel()
+
+		src, sink := queue.NewBlocking([]int{}), queue.NewLinked([]int{})
+
+		err := queue.DrainAll(context.Background(), []queue.DrainPair[int]{{Source: src, Sink: sink}})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if err := src.Offer(1); !errors.Is(err, queue.ErrQueueClosed) {
+			t.Fatalf("expected the source to be closed, got %v", err)
+		}
+	})
+
+	t.Run("StuckStage", func(t *testing.T) {
+		t.Parallel()
+
+		first := queue.NewBlocking([]int{})
+		// the workers of the second stage never return.
+		second, stuck := queue.NewBlocking([]int{1, 2, 3}), make(chan struct{})
+		third := queue.NewLinked([]int{4})
+
+		sinks := []*queue.Linked[int]{queue.NewLinked([]int{}), queue.NewLinked([]int{0}), queue.NewLinked([]int{})}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		err := queue.DrainAll(ctx, []queue.DrainPair[int]{
+			{Source: first, Sink: sinks[0]},
+			{Source: second, Sink: sinks[1], Done: stuck},
+			{Source: third, Sink: sinks[2]},
+		})
+
+		var shutdownErr *queue.ShutdownError
+		if !errors.As(err, &shutdownErr) || shutdownErr.Stage != 1 {
+			t.Fatalf("expected stage 1 to time out, got %v", err)
+		}
+
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("expected error to be %v, got %v", context.DeadlineExceeded, err)
+		}
+
+		for i, expected := range [][]int{{}, {0, 1, 2, 3}, {4}} {
+			if elems := sinks[i].ToSlice(); !reflect.DeepEqual(expected, elems) {
+				t.Fatalf("expected the elements of sink %d to be %v, got %v", i, expected, elems)
+			}
+		}
+
+		if !second.IsEmpty() || !third.IsEmpty() {
+			t.Fatalf("expected the sources to be empty, got %v and %v", second.ToSlice(), third.ToSlice())
+		}
+	})
+
+	t.Run("SinkFull", func(t *testing.T) {
+		t.Parallel()
+
+		src, sink := queue.NewBlocking([]int{1, 2}), queue.NewBlocking([]int{}, queue.WithCapacity(1))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		err := queue.DrainAll(ctx, []queue.DrainPair[int]{{Source: src, Sink: sink}})
+
+		var shutdownErr *queue.ShutdownError
+		if !errors.As(err, &shutdownErr) || shutdownErr.Stage != 0 || shutdownErr.Remaining != 1 {
+			t.Fatalf("expected stage 0 to fail with 1 element remaining, got %v", err)
+		}
+
+		if !errors.Is(err, queue.ErrQueueIsFull) {
+			t.Fatalf("expected error to be %v, got %v", queue.ErrQueueIsFull, err)
+		}
+	})
+}
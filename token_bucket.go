@@ -0,0 +1,136 @@
+package queue
+
+import (
+	"context"
+	"time"
+)
+
+// TokenBucket is a rate limiter built on a Semaphore whose tokens are never
+// released but refilled over time: it holds at most burst tokens and one
+// token is added every interval, measured on the clock provided using the
+// WithClock option. Taking a token waits for the next refill if none is
+// available.
+type TokenBucket struct {
+	tokens   *Semaphore
+	clock    Clock
+	interval time.Duration
+
+	// refilled is the time up to which the tokens were refilled, guarded by
+	// the lock of the tokens queue.
+	refilled time.Time
+}
+
+// NewTokenBucket returns a new TokenBucket holding burst tokens, refilled
+// with one token every interval. It panics if burst or interval is not
+// positive, since Take would wait forever for a token.
+func NewTokenBucket(burst int, interval time.Duration, opts ...Option) *TokenBucket {
+	if burst <= 0 {
+		panic("queue: token bucket burst must be positive")
+	}
+
+	if interval <= 0 {
+		panic("queue: token bucket interval must be positive")
+	}
+
+	options := options{
+		clock: systemClock{},
+	}
+
+	for _, o := range opts {
+		o.apply(&options)
+	}
+
+	return &TokenBucket{
+		tokens:   NewSemaphore(burst),
+		clock:    options.clock,
+		interval: interval,
+		refilled: options.clock.Now(),
+	}
+}
+
+// Take takes a token, waiting for the bucket to be refilled until ctx is
+// done, in which case it returns the ctx error without taking a token.
+func (tb *TokenBucket) Take(ctx context.Context) error {
+	for {
+		wait, taken := tb.tryTake()
+		if taken {
+			return nil
+		}
+
+		timer := tb.clock.NewTimer(wait)
+
+		select {
+		case <-timer.C():
+		case <-ctx.Done():
+			timer.Stop()
+
+			return ctx.Err()
+		}
+	}
+}
+
+// TryTake takes a token without waiting and returns true, or returns false
+// if the bucket is empty.
+func (tb *TokenBucket) TryTake() bool {
+	_, taken := tb.tryTake()
+
+	return taken
+}
+
+// Available returns the number of tokens which can be taken without waiting.
+func (tb *TokenBucket) Available() int {
+	bq := tb.tokens.tokens
+
+	bq.lock.Lock()
+	defer bq.lock.Unlock()
+
+	tb.refill()
+
+	return bq.size()
+}
+
+// tryTake refills the bucket and takes a token if one is available.
+// Otherwise, it returns the time left until the next refill.
+func (tb *TokenBucket) tryTake() (time.Duration, bool) {
+	bq := tb.tokens.tokens
+
+	bq.lock.Lock()
+	defer bq.lock.Unlock()
+
+	now := tb.refill()
+
+	if bq.isEmpty() {
+		return tb.refilled.Add(tb.interval).Sub(now), false
+	}
+
+	tb.tokens.take(1)
+
+	return 0, true
+}
+
+// refill adds the tokens due since the last refill and returns the current
+// time. The caller holds the lock of the tokens queue.
+func (tb *TokenBucket) refill() time.Time {
+	bq := tb.tokens.tokens
+
+	now := tb.clock.Now()
+
+	due := int(now.Sub(tb.refilled) / tb.interval)
+
+	n := min(due, bq.occupancy.remaining())
+
+	for i := 0; i < n; i++ {
+		bq.pushBack(struct{}{})
+	}
+
+	bq.occupancy.forceAdmit(n, 0)
+
+	tb.refilled = tb.refilled.Add(time.Duration(due) * tb.interval)
+
+	// a full bucket does not accumulate the tokens due while it is full.
+	if bq.occupancy.remaining() == 0 {
+		tb.refilled = now
+	}
+
+	return now
+}
@@ -0,0 +1,147 @@
+package queue_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/adrianbrad/queue"
+)
+
+func TestTokenBucket(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Burst", func(t *testing.T) {
+		t.Parallel()
+
+		bucket := queue.NewTokenBucket(3, time.Second, queue.WithClock(newFakeClock()))
+
+		for i := 0; i < 3; i++ {
+			if !bucket.TryTake() {
+				t.Fatalf("expected token %d to be taken", i)
+			}
+		}
+
+		if bucket.TryTake() {
+			t.Fatal("expected the bucket to be empty")
+		}
+	})
+
+	t.Run("RefillPacing", func(t *testing.T) {
+		t.Parallel()
+
+		clock := newFakeClock()
+
+		bucket := queue.NewTokenBucket(2, time.Second, queue.WithClock(clock))
+
+		_ = bucket.TryTake()
+		_ = bucket.TryTake()
+
+		clock.Advance(500 * time.Millisecond)
+
+		if bucket.TryTake() {
+			t.Fatal("expected no token before the interval elapses")
+		}
+
+		clock.Advance(500 * time.Millisecond)
+
+		if available := bucket.Available(); available != 1 {
+			t.Fatalf("expected 1 available token, got %d", available)
+		}
+
+		// the tokens due while the bucket is full are not accumulated.
+		clock.Advance(10 * time.Second)
+
+		if available := bucket.Available(); available != 2 {
+			t.Fatalf("expected 2 available tokens, got %d", available)
+		}
+	})
+
+	t.Run("TakeWaitsForRefill", func(t *testing.T) {
+		t.Parallel()
+
+		clock := newFakeClock()
+
+		bucket := queue.NewTokenBucket(1, time.Second, queue.WithClock(clock))
+
+		_ = bucket.TryTake()
+
+		clock.Advance(300 * time.Millisecond)
+
+		done := make(chan error)
+
+		go func() {
+			done <- bucket.Take(context.Background())
+		}()
+
+		clock.WaitForTimers(t, 1)
+
+		select {
+		case err := <-done:
+			t.Fatalf("expected Take to wait, got %v", err)
+		default:
+		}
+
+		// the token is due 700ms later.
+		clock.Advance(700 * time.Millisecond)
+
+		if err := <-done; err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	})
+
+	t.Run("ContextCancelledWhileWaiting", func(t *testing.T) {
+		t.Parallel()
+
+		clock := newFakeClock()
+
+		bucket := queue.NewTokenBucket(1, time.Second, queue.WithClock(clock))
+
+		_ = bucket.TryTake()
+
+		ctx, cancel := context.WithCancel(context.Background())
+
+		done := make(chan error)
+
+		go func() {
+			done <- bucket.Take(ctx)
+		}()
+
+		clock.WaitForTimers(t, 1)
+
+		cancel()
+
+		if err := <-done; !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected error to be %v, got %v", context.Canceled, err)
+		}
+	})
+
+	t.Run("InvalidInterval", func(t *testing.T) {
+		t.Parallel()
+
+		defer func() {
+			if recover() == nil {
+				t.Fatal("expected a non positive interval to panic")
+			}
+		}()
+
+		queue.NewTokenBucket(1, 0)
+	})
+
+	t.Run("InvalidBurst", func(t *testing.T) {
+		t.Parallel()
+
+		for _, burst := range []int{0, -1} {
+			func() {
+				defer func() {
+					if recover() == nil {
+						t.Fatalf("expected a burst of %d to panic", burst)
+					}
+				}()
+
+				queue.NewTokenBucket(burst, time.Second)
+			}()
+		}
+	})
+}
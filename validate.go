@@ -0,0 +1,109 @@
+package queue
+
+import (
+	"fmt"
+)
+
+// InvalidElementError is the error returned by the insertions of a queue
+// created with the WithValidator option whenever the validator rejects the
+// element. It wraps both the ErrInvalidElement error and the error returned by
+// the validator, so that errors.Is and errors.As match either of them.
+type InvalidElementError struct {
+	// Err is the error returned by the validator.
+	Err error
+}
+
+// Error returns the error returned by the validator.
+func (e *InvalidElementError) Error() string {
+	return fmt.Sprintf("%s: %v", ErrInvalidElement, e.Err)
+}
+
+// Unwrap returns the ErrInvalidElement error and the error returned by the
+// validator.
+func (e *InvalidElementError) Unwrap() []error {
+	return []error{ErrInvalidElement, e.Err}
+}
+
+// validator checks the elements inserted into a queue, as specified using
+// the WithValidator option. The zero validator admits every element.
+type validator[T any] struct {
+	validate func(elem T) error
+}
+
+// validatorOf returns the validator provided using WithValidator, or the
+// zero validator if none was provided.
+func validatorOf[T any](opts options) validator[T] {
+	if opts.validator == nil {
+		return validator[T]{}
+	}
+
+	validate, ok := opts.validator.(func(elem T) error)
+	if !ok {
+		panic("validator type does not match the queue element type")
+	}
+
+	return validator[T]{validate: validate}
+}
+
+// check returns an InvalidElementError if the element is rejected by the
+// validator, nil otherwise.
+func (v validator[T]) check(elem T) error {
+	if v.validate == nil {
+		return nil
+	}
+
+	if err := v.validate(elem); err != nil {
+		return &InvalidElementError{Err: err}
+	}
+
+	return nil
+}
+
+// checkAll returns the error of the first element rejected by the
+// validator, nil if all the elements are valid.
+func (v validator[T]) checkAll(elems []T) error {
+	if v.validate == nil {
+		return nil
+	}
+
+	for _, elem := range elems {
+		if err := v.check(elem); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// filter returns the elements admitted by the validator, in order. The
+// rejected elements are released using the recycler. The given slice is
+// returned as is if all its elements are valid, it is never modified.
+func (v validator[T]) filter(elems []T, rec recycler[T]) []T {
+	if v.validate == nil {
+		return elems
+	}
+
+	var valid []T
+
+	for i, elem := range elems {
+		if v.validate(elem) == nil {
+			if valid != nil {
+				valid = append(valid, elem)
+			}
+
+			continue
+		}
+
+		if valid == nil {
+			valid = append(make([]T, 0, len(elems)-1), elems[:i]...)
+		}
+
+		rec.discard(elem)
+	}
+
+	if valid == nil {
+		return elems
+	}
+
+	return valid
+}
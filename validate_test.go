@@ -0,0 +1,334 @@
+package queue_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/adrianbrad/queue"
+)
+
+var errNegative = errors.New("negative element")
+
+// validateNonNegative rejects the negative elements.
+func validateNonNegative(elem int) error {
+	if elem < 0 {
+		return errNegative
+	}
+
+	return nil
+}
+
+func TestWithValidator(t *testing.T) {
+	t.Parallel()
+
+	lessInt := func(elem, otherElem int) bool { return elem < otherElem }
+
+	type validatedQueue interface {
+		queue.Queue[int]
+		OfferAll(elems ...int) error
+		OfferSome(elems ...int) (int, error)
+		Exchange(elem int) (int, error)
+		ToSlice() []int
+		InspectPage(cursor queue.Cursor, limit int) ([]int, queue.Cursor, error)
+	}
+
+	testCases := map[string]func(elems []int, opts ...queue.Option) validatedQueue{
+		"Blocking": func(elems []int, opts ...queue.Option) validatedQueue {
+			return queue.NewBlocking(elems, opts...)
+		},
+		"Circular": func(elems []int, opts ...queue.Option) validatedQueue {
+			return queue.NewCircular(elems, 4, opts...)
+		},
+		"Linked": func(elems []int, opts ...queue.Option) validatedQueue {
+			return queue.NewLinked(elems, opts...)
+		},
+		"Priority": func(elems []int, opts ...queue.Option) validatedQueue {
+			return queue.NewPriority(elems, lessInt, opts...)
+		},
+		"PriorityAny": func(elems []int, opts ...queue.Option) validatedQueue {
+			return queue.NewPriorityAny(elems, lessInt, opts...)
+		},
+	}
+
+	assertInvalid := func(t *testing.T, err error) {
+		t.Helper()
+
+		if !errors.Is(err, queue.ErrInvalidElement) {
+			t.Fatalf("expected error to be %v, got %v", queue.ErrInvalidElement, err)
+		}
+
+		if !errors.Is(err, errNegative) {
+			t.Fatalf("expected error to wrap %v, got %v", errNegative, err)
+		}
+
+		var invalidErr *queue.InvalidElementError
+		if !errors.As(err, &invalidErr) || invalidErr.Err != errNegative {
+			t.Fatalf("expected an InvalidElementError wrapping %v, got %v", errNegative, err)
+		}
+	}
+
+	for impl, newQueue := range testCases {
+		impl, newQueue := impl, newQueue
+
+		t.Run(impl, func(t *testing.T) {
+			t.Parallel()
+
+			t.Run("OfferRejected", func(t *testing.T) {
+				t.Parallel()
+
+				q := newQueue([]int{1}, queue.WithValidator(validateNonNegative))
+
+				_, cursor, err := q.InspectPage(queue.Cursor{}, 0)
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+
+				assertInvalid(t, q.Offer(-1))
+
+				if size := q.Size(); size != 1 {
+					t.Fatalf("expected size to be 1, got %d", size)
+				}
+
+				// the cursor is still valid, the queue was not mutated.
+				if _, _, err := q.InspectPage(cursor, 1); err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+
+				if err := q.Offer(2); err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+			})
+
+			t.Run("OfferAll", func(t *testing.T) {
+				t.Parallel()
+
+				q := newQueue([]int{}, queue.WithValidator(validateNonNegative))
+
+				assertInvalid(t, q.OfferAll(1, -2, 3))
+
+				if !q.IsEmpty() {
+					t.Fatalf("expected no element to be inserted, got %v", q.ToSlice())
+				}
+			})
+
+			t.Run("OfferSome", func(t *testing.T) {
+				t.Parallel()
+
+				q := newQueue([]int{}, queue.WithValidator(validateNonNegative))
+
+				n, err := q.OfferSome(1, -2, 3)
+				assertInvalid(t, err)
+
+				if n != 1 {
+					t.Fatalf("expected 1 element to be inserted, got %d", n)
+				}
+
+				if elems := q.ToSlice(); !reflect.DeepEqual([]int{1}, elems) {
+					t.Fatalf("expected elements to be %v, got %v", []int{1}, elems)
+				}
+			})
+
+			t.Run("Exchange", func(t *testing.T) {
+				t.Parallel()
+
+				q := newQueue([]int{1}, queue.WithValidator(validateNonNegative))
+
+				_, err := q.Exchange(-1)
+				assertInvalid(t, err)
+
+				if elems := q.ToSlice(); !reflect.DeepEqual([]int{1}, elems) {
+					t.Fatalf("expected elements to be %v, got %v", []int{1}, elems)
+				}
+			})
+
+			t.Run("InitialElements", func(t *testing.T) {
+				t.Parallel()
+
+				var released []int
+
+				q := newQueue(
+					[]int{-1, 2, -3, 4},
+					queue.WithValidator(validateNonNegative),
+					queue.WithRecycler(func() int { return 0 }, func(elem int) { released = append(released, elem) }),
+				)
+
+				if elems := q.ToSlice(); !reflect.DeepEqual([]int{2, 4}, elems) {
+					t.Fatalf("expected elements to be %v, got %v", []int{2, 4}, elems)
+				}
+
+				if !reflect.DeepEqual([]int{-1, -3}, released) {
+					t.Fatalf("expected the invalid elements to be released, got %v", released)
+				}
+
+				q.Clear()
+				q.Reset()
+
+				if elems := q.ToSlice(); !reflect.DeepEqual([]int{2, 4}, elems) {
+					t.Fatalf("expected reset elements to be %v, got %v", []int{2, 4}, elems)
+				}
+			})
+
+			t.Run("Move", func(t *testing.T) {
+				t.Parallel()
+
+				src := queue.NewLinked([]int{-1})
+				dst := newQueue([]int{}, queue.WithValidator(validateNonNegative))
+
+				_, err := queue.Move[int](src, dst)
+				assertInvalid(t, err)
+
+				if src.Size() != 1 || !dst.IsEmpty() {
+					t.Fatalf("expected the element to stay in the source queue, got %v and %v", src.ToSlice(), dst.ToSlice())
+				}
+			})
+		})
+	}
+
+	t.Run("InitialElementsBeforeCapacity", func(t *testing.T) {
+		t.Parallel()
+
+		blockingQueue := queue.NewBlocking(
+			[]int{-1, 1, -2, 2, 3},
+			queue.WithCapacity(2),
+			queue.WithValidator(validateNonNegative),
+		)
+
+		if elems := blockingQueue.ToSlice(); !reflect.DeepEqual([]int{1, 2}, elems) {
+			t.Fatalf("expected elements to be %v, got %v", []int{1, 2}, elems)
+		}
+
+		circularQueue := queue.NewCircular(
+			[]int{-1, 1, -2, 2, 3},
+			2,
+			queue.WithValidator(validateNonNegative),
+		)
+
+		if elems := circularQueue.ToSlice(); !reflect.DeepEqual([]int{1, 2}, elems) {
+			t.Fatalf("expected elements to be %v, got %v", []int{1, 2}, elems)
+		}
+	})
+
+	t.Run("Precedence", func(t *testing.T) {
+		t.Parallel()
+
+		t.Run("Full", func(t *testing.T) {
+			t.Parallel()
+
+			blockingQueue := queue.NewBlocking(
+				[]int{1},
+				queue.WithCapacity(1),
+				queue.WithValidator(validateNonNegative),
+			)
+
+			assertInvalid(t, blockingQueue.Offer(-1))
+
+			if err := blockingQueue.Offer(2); !errors.Is(err, queue.ErrQueueIsFull) {
+				t.Fatalf("expected error to be %v, got %v", queue.ErrQueueIsFull, err)
+			}
+		})
+
+		t.Run("Closed", func(t *testing.T) {
+			t.Parallel()
+
+			blockingQueue := queue.NewBlocking([]int{}, queue.WithValidator(validateNonNegative))
+
+			blockingQueue.Close()
+
+			assertInvalid(t, blockingQueue.Offer(-1))
+		})
+
+		t.Run("Duplicate", func(t *testing.T) {
+			t.Parallel()
+
+			// -1 is a duplicate of 1 for the equality function.
+			priorityQueue := queue.NewPriority(
+				[]int{1},
+				lessInt,
+				queue.WithCapacity(2),
+				queue.WithEqualFunc(func(elem, otherElem int) bool {
+					return elem == otherElem || elem == -otherElem
+				}),
+				queue.WithValidator(validateNonNegative),
+			)
+
+			_, err := priorityQueue.OfferBounded(-1)
+			assertInvalid(t, err)
+
+			outcome, err := priorityQueue.OfferBounded(1)
+			if err != nil || outcome.Status != queue.OfferRejectedDuplicate {
+				t.Fatalf("expected a duplicate rejection, got %v, err %v", outcome.Status, err)
+			}
+		})
+	})
+
+	t.Run("OfferWaitFailsFast", func(t *testing.T) {
+		t.Parallel()
+
+		blockingQueue := queue.NewBlocking(
+			[]int{1},
+			queue.WithCapacity(1),
+			queue.WithValidator(validateNonNegative),
+		)
+
+		// the full queue would block a valid element forever.
+		assertInvalid(t, blockingQueue.OfferWaitErr(-1))
+
+		assertInvalid(t, blockingQueue.OfferCtx(context.Background(), -1))
+
+		n, err := blockingQueue.OfferAllWait(-1, 2)
+		assertInvalid(t, err)
+
+		if n != 0 {
+			t.Fatalf("expected no element to be inserted, got %d", n)
+		}
+
+		if state := blockingQueue.BlockedState(); state.BlockedOffers != 0 {
+			t.Fatalf("expected no blocked producer, got %d", state.BlockedOffers)
+		}
+	})
+
+	t.Run("ConsumersNotWoken", func(t *testing.T) {
+		t.Parallel()
+
+		blockingQueue := queue.NewBlocking([]int{}, queue.WithValidator(validateNonNegative))
+
+		elems := make(chan int)
+
+		go func() { elems <- blockingQueue.GetWait() }()
+
+		waitForBlocked(t, blockingQueue, 0, 1)
+
+		assertInvalid(t, blockingQueue.Offer(-1))
+		assertInvalid(t, blockingQueue.OfferUrgent(-1))
+
+		if _, err := blockingQueue.OfferHandle(-1); !errors.Is(err, queue.ErrInvalidElement) {
+			t.Fatalf("expected error to be %v, got %v", queue.ErrInvalidElement, err)
+		}
+
+		if state := blockingQueue.BlockedState(); state.BlockedGets != 1 || state.Size != 0 {
+			t.Fatalf("expected the consumer to keep waiting on an empty queue, got %+v", state)
+		}
+
+		if err := blockingQueue.Offer(1); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if elem := <-elems; elem != 1 {
+			t.Fatalf("expected elem to be 1, got %d", elem)
+		}
+	})
+
+	t.Run("TypeMismatch", func(t *testing.T) {
+		t.Parallel()
+
+		defer func() {
+			if recover() == nil {
+				t.Fatal("expected a validator of another type to panic")
+			}
+		}()
+
+		queue.NewLinked([]int{}, queue.WithValidator(func(string) error { return nil }))
+	})
+}
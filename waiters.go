@@ -0,0 +1,84 @@
+package queue
+
+import "time"
+
+// BlockingState describes the goroutines blocked on a Blocking queue, as
+// returned by its BlockedState method.
+type BlockingState struct {
+	// BlockedOffers is the number of producers waiting for capacity in
+	// OfferWait or OfferCtx.
+	BlockedOffers int
+
+	// BlockedGets is the number of consumers waiting for an element in
+	// GetWait, GetCtx or the other methods waiting for an element.
+	BlockedGets int
+
+	// LongestWait is the time the longest blocked producer or consumer has
+	// been waiting for, 0 if none is blocked.
+	LongestWait time.Duration
+
+	// Size and Capacity are the size and the capacity of the queue, the
+	// capacity being -1 if the queue is unbounded.
+	Size     int
+	Capacity int
+
+	// Closed is true once the queue is closed.
+	Closed bool
+}
+
+// waitQueue keeps track of the goroutines waiting on a queue, in the order
+// in which they started waiting.
+type waitQueue struct {
+	waiters []waiter
+	nextID  uint64
+}
+
+// waiter is a goroutine waiting on a queue since the given time.
+type waiter struct {
+	id    uint64
+	since time.Time
+}
+
+// enqueue registers a new waiter, waiting since now, and returns its id.
+func (w *waitQueue) enqueue(now time.Time) uint64 {
+	id := w.nextID
+
+	w.nextID++
+
+	w.waiters = append(w.waiters, waiter{id: id, since: now})
+
+	return id
+}
+
+// remove unregisters the waiter with the given id.
+func (w *waitQueue) remove(id uint64) {
+	for i := range w.waiters {
+		if w.waiters[i].id == id {
+			copy(w.waiters[i:], w.waiters[i+1:])
+			w.waiters = w.waiters[:len(w.waiters)-1]
+
+			return
+		}
+	}
+}
+
+// isFront returns true if the waiter with the given id is the one that
+// has been waiting the longest.
+func (w *waitQueue) isFront(id uint64) bool {
+	return len(w.waiters) > 0 && w.waiters[0].id == id
+}
+
+// len returns the number of waiters.
+func (w *waitQueue) len() int {
+	return len(w.waiters)
+}
+
+// longestWait returns the time the front waiter has been waiting for at
+// now, 0 if there are no waiters.
+func (w *waitQueue) longestWait(now time.Time) time.Duration {
+	if len(w.waiters) == 0 {
+		return 0
+	}
+
+	return now.Sub(w.waiters[0].since)
+}
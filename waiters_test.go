@@ -0,0 +1,181 @@
+package queue_test
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/adrianbrad/queue"
+)
+
+// waitForBlocked spins until the queue reports the given number of blocked
+// producers and consumers.
+func waitForBlocked[T comparable](t *testing.T, q *queue.Blocking[T], offers, gets int) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+
+	for {
+		state := q.BlockedState()
+		if state.BlockedOffers == offers && state.BlockedGets == gets {
+			return
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatalf(
+				"expected %d blocked offers and %d blocked gets, got %d and %d",
+				offers, gets, state.BlockedOffers, state.BlockedGets,
+			)
+		}
+
+		runtime.Gosched()
+	}
+}
+
+func TestBlockingBlockedState(t *testing.T) {
+	t.Parallel()
+
+	t.Run("NothingBlocked", func(t *testing.T) {
+		t.Parallel()
+
+		blockingQueue := queue.NewBlocking([]int{1, 2}, queue.WithCapacity(3))
+
+		expected := queue.BlockingState{Size: 2, Capacity: 3}
+
+		if state := blockingQueue.BlockedState(); state != expected {
+			t.Fatalf("expected state to be %+v, got %+v", expected, state)
+		}
+	})
+
+	t.Run("Unbounded", func(t *testing.T) {
+		t.Parallel()
+
+		blockingQueue := queue.NewBlocking([]int{1})
+
+		expected := queue.BlockingState{Size: 1, Capacity: -1}
+
+		if state := blockingQueue.BlockedState(); state != expected {
+			t.Fatalf("expected state to be %+v, got %+v", expected, state)
+		}
+	})
+
+	t.Run("BlockedProducers", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithCancel(context.Background())
+
+		clock := newFakeClock()
+
+		blockingQueue := queue.NewBlocking(
+			[]int{1},
+			queue.WithCapacity(1),
+			queue.WithClock(clock),
+		)
+
+		errs := make(chan error, 2)
+
+		go func() { errs <- blockingQueue.OfferCtx(ctx, 2) }()
+
+		waitForBlocked(t, blockingQueue, 1, 0)
+
+		clock.Advance(3 * time.Second)
+
+		go func() { errs <- blockingQueue.OfferCtx(ctx, 3) }()
+
+		waitForBlocked(t, blockingQueue, 2, 0)
+
+		clock.Advance(2 * time.Second)
+
+		expected := queue.BlockingState{
+			BlockedOffers: 2,
+			LongestWait:   5 * time.Second,
+			Size:          1,
+			Capacity:      1,
+		}
+
+		if state := blockingQueue.BlockedState(); state != expected {
+			t.Fatalf("expected state to be %+v, got %+v", expected, state)
+		}
+
+		cancel()
+
+		for i := 0; i < 2; i++ {
+			if err := <-errs; !errors.Is(err, context.Canceled) {
+				t.Fatalf("expected error to be %v, got %v", context.Canceled, err)
+			}
+		}
+
+		waitForBlocked(t, blockingQueue, 0, 0)
+
+		if state := blockingQueue.BlockedState(); state.LongestWait != 0 {
+			t.Fatalf("expected longest wait to be 0, got %s", state.LongestWait)
+		}
+	})
+
+	t.Run("BlockedConsumers", func(t *testing.T) {
+		t.Parallel()
+
+		clock := newFakeClock()
+
+		blockingQueue := queue.NewBlocking(
+			[]int{},
+			queue.WithCapacity(2),
+			queue.WithClock(clock),
+		)
+
+		elems := make(chan int, 2)
+
+		for i := 0; i < 2; i++ {
+			go func() { elems <- blockingQueue.GetWait() }()
+
+			waitForBlocked(t, blockingQueue, 0, i+1)
+
+			clock.Advance(time.Second)
+		}
+
+		expected := queue.BlockingState{
+			BlockedGets: 2,
+			LongestWait: 2 * time.Second,
+			Capacity:    2,
+		}
+
+		if state := blockingQueue.BlockedState(); state != expected {
+			t.Fatalf("expected state to be %+v, got %+v", expected, state)
+		}
+
+		if err := blockingQueue.Offer(1); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		<-elems
+
+		waitForBlocked(t, blockingQueue, 0, 1)
+
+		// the remaining consumer started waiting a second after the first.
+		if state := blockingQueue.BlockedState(); state.LongestWait != time.Second {
+			t.Fatalf("expected longest wait to be %s, got %s", time.Second, state.LongestWait)
+		}
+
+		if err := blockingQueue.Offer(2); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		<-elems
+
+		waitForBlocked(t, blockingQueue, 0, 0)
+	})
+
+	t.Run("Closed", func(t *testing.T) {
+		t.Parallel()
+
+		blockingQueue := queue.NewBlocking([]int{1}, queue.WithCapacity(1))
+
+		blockingQueue.Close()
+
+		if state := blockingQueue.BlockedState(); !state.Closed {
+			t.Fatalf("expected the queue to be reported closed, got %+v", state)
+		}
+	})
+}